@@ -0,0 +1,218 @@
+// cmd/gopyte-replay/main.go
+//
+// # gopyte-replay
+//
+// Replays a recorded terminal session to the current terminal. Supported
+// capture formats are asciinema v2 (.cast), ttyrec, raw byte captures and
+// gopyte transcripts.
+//
+// By default the original bytes are written straight to the terminal while
+// gopyte tracks the screen alongside. With -render the terminal instead shows
+// gopyte's own rendering of the screen, which makes the tool an end-to-end
+// check of the parser. With -dump the recording is replayed without delays
+// and the final screen is printed as plain text.
+//
+// Usage:
+//
+//	gopyte-replay [flags] <file>
+//	gopyte-replay -speed 2 -idle 1 session.cast
+//	gopyte-replay -render -cols 132 -rows 43 capture.ttyrec
+//	gopyte-replay -dump -format raw vim.input
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/scottpeterman/gopyte/gopyte"
+)
+
+type options struct {
+	format  string
+	speed   float64
+	idle    float64
+	cols    int
+	rows    int
+	render  bool
+	dump    bool
+	history int
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.format, "format", "auto", "capture format: auto, cast, ttyrec, raw or transcript")
+	flag.Float64Var(&opts.speed, "speed", 1, "playback speed multiplier")
+	flag.Float64Var(&opts.idle, "idle", 0, "cap pauses between events to this many seconds (0 = no cap)")
+	flag.IntVar(&opts.cols, "cols", 0, "screen width (default: from recording or terminal)")
+	flag.IntVar(&opts.rows, "rows", 0, "screen height (default: from recording or terminal)")
+	flag.BoolVar(&opts.render, "render", false, "draw gopyte's rendering of the screen instead of the raw bytes")
+	flag.BoolVar(&opts.dump, "dump", false, "replay without delays and print the final screen")
+	flag.IntVar(&opts.history, "history", 1000, "scrollback lines kept by the emulated screen")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <file>\n\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), opts); err != nil {
+		fmt.Fprintf(os.Stderr, "gopyte-replay: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(path string, opts options) error {
+	transcript, cols, rows, err := load(path, opts.format)
+	if err != nil {
+		return err
+	}
+
+	// Explicit flags win over the recording, which wins over the terminal
+	if cols == 0 || rows == 0 {
+		cols, rows = 80, 24
+		if c, r, err := term.GetSize(int(os.Stdout.Fd())); err == nil && c > 0 && r > 0 {
+			cols, rows = c, r
+		}
+	}
+	if opts.cols > 0 {
+		cols = opts.cols
+	}
+	if opts.rows > 0 {
+		rows = opts.rows
+	}
+
+	screen := gopyte.NewWideCharScreen(cols, rows, opts.history)
+	player := gopyte.NewPlayer(transcript, screen)
+	player.SetSpeed(opts.speed)
+	if opts.idle > 0 {
+		player.SetMaxIdle(time.Duration(opts.idle * float64(time.Second)))
+	}
+
+	if opts.dump {
+		player.SeekEvent(player.Len())
+		printScreen(os.Stdout, player.Screen().(*gopyte.WideCharScreen))
+		return nil
+	}
+
+	if opts.render {
+		player.SetEventHandler(func(ev gopyte.TranscriptEvent, s gopyte.Screen) {
+			if ev.Direction != gopyte.DirInput {
+				redraw(os.Stdout, s.(*gopyte.WideCharScreen))
+			}
+		})
+	} else {
+		player.SetEventHandler(func(ev gopyte.TranscriptEvent, s gopyte.Screen) {
+			if ev.Direction == gopyte.DirOutput {
+				_, _ = io.WriteString(os.Stdout, ev.Data)
+			}
+		})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = player.Play(ctx)
+	if opts.render {
+		fmt.Fprint(os.Stdout, "\r\n")
+	}
+	if err == context.Canceled {
+		return nil
+	}
+	return err
+}
+
+// load reads a capture file. cols and rows are zero when the format does
+// not record the terminal size.
+func load(path, format string) (t *gopyte.Transcript, cols, rows int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer f.Close()
+
+	if format == "auto" {
+		format, err = detectFormat(f, path)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	switch format {
+	case "cast":
+		t, header, err := gopyte.ReadAsciicast(f)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return t, header.Width, header.Height, nil
+	case "ttyrec":
+		t, err = gopyte.ReadTTYRec(f)
+	case "raw":
+		t, err = gopyte.ReadRawCapture(f)
+	case "transcript":
+		t, err = gopyte.ReadTranscript(f)
+	default:
+		return nil, 0, 0, fmt.Errorf("unknown format %q", format)
+	}
+	return t, 0, 0, err
+}
+
+// detectFormat guesses the capture format from the extension, falling back
+// to sniffing the first bytes. The file offset is left at the start.
+func detectFormat(f *os.File, path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cast":
+		return "cast", nil
+	case ".ttyrec", ".tty":
+		return "ttyrec", nil
+	}
+
+	head := make([]byte, 16)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	head = head[:n]
+
+	switch {
+	case strings.HasPrefix(string(head), `{"version"`):
+		return "cast", nil
+	case strings.HasPrefix(string(head), "["):
+		return "transcript", nil
+	}
+	return "raw", nil
+}
+
+func redraw(w io.Writer, screen *gopyte.WideCharScreen) {
+	_, _ = io.WriteString(w, "\x1b[2J\x1b[H") // clear + home
+	lines := screen.GetDisplay()
+	for i, line := range lines {
+		_, _ = io.WriteString(w, strings.TrimRight(line, " "))
+		if i < len(lines)-1 {
+			_, _ = io.WriteString(w, "\r\n")
+		}
+	}
+	x, y := screen.GetCursor()
+	fmt.Fprintf(w, "\x1b[%d;%dH", y+1, x+1)
+}
+
+func printScreen(w io.Writer, screen *gopyte.WideCharScreen) {
+	for _, line := range screen.GetDisplay() {
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
@@ -0,0 +1,356 @@
+// cmd/gopyte-term/main.go
+//
+// # gopyte-term
+//
+// A small interactive terminal: runs a live shell under a PTY with the
+// gopyte/pty package (ConPTY on Windows), feeds its output through gopyte
+// and draws the emulated screen, colors and attributes included, with
+// tcell. It exercises the library end to end: resizing the window resizes
+// both the screen and the PTY, full-screen programs switch to the
+// alternate screen, mouse clicks and drags are reported to programs that
+// ask for them, and otherwise the mouse wheel scrolls the scrollback (or
+// sends cursor keys while the alternate screen is active).
+//
+// Usage:
+//
+//	gopyte-term [-history 5000]
+//
+// Press Ctrl+] to quit.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/scottpeterman/gopyte/gopyte"
+	"github.com/scottpeterman/gopyte/gopyte/pty"
+)
+
+// wheelLines is how many lines one mouse wheel notch scrolls.
+const wheelLines = 3
+
+type terminal struct {
+	ui      tcell.Screen
+	session *pty.Session
+	screen  *gopyte.WideCharScreen // Only inside session.Screen().View or Update
+
+	// Mouse state of the last event, to tell presses, releases and
+	// motion apart
+	buttons tcell.ButtonMask
+	mouseX  int
+	mouseY  int
+}
+
+// outputEvent asks the UI loop to redraw after new PTY output.
+type outputEvent struct {
+	tcell.EventTime
+}
+
+// exitEvent tells the UI loop the shell has gone away.
+type exitEvent struct {
+	tcell.EventTime
+	err error
+}
+
+func main() {
+	history := flag.Int("history", 5000, "scrollback lines")
+	flag.Parse()
+
+	if err := run(*history); err != nil {
+		fmt.Fprintf(os.Stderr, "gopyte-term: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(history int) error {
+	ui, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	if err := ui.Init(); err != nil {
+		return err
+	}
+	defer ui.Fini()
+	ui.EnableMouse()
+
+	cols, rows := ui.Size()
+	t := &terminal{ui: ui, screen: gopyte.NewWideCharScreen(cols, rows, history)}
+	t.session, err = pty.Start(shellCommand(), t.screen, pty.Options{
+		Columns: cols,
+		Lines:   rows,
+		OnOutput: func() {
+			ev := &outputEvent{}
+			ev.SetEventNow()
+			_ = ui.PostEvent(ev)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("starting shell: %w", err)
+	}
+	defer t.session.Close()
+
+	go func() {
+		ev := &exitEvent{err: t.session.Wait()}
+		ev.SetEventNow()
+		_ = ui.PostEvent(ev)
+	}()
+	return t.eventLoop()
+}
+
+// shellCommand returns the user's shell: $SHELL, or %COMSPEC% on Windows.
+func shellCommand() *exec.Cmd {
+	var shell string
+	if runtime.GOOS == "windows" {
+		if shell = os.Getenv("COMSPEC"); shell == "" {
+			shell = `C:\Windows\System32\cmd.exe`
+		}
+	} else if shell = os.Getenv("SHELL"); shell == "" {
+		shell = "/bin/sh"
+	}
+	cmd := exec.Command(shell)
+	cmd.Env = append(os.Environ(), "TERM="+pty.DefaultTerm, "COLORTERM=truecolor")
+	return cmd
+}
+
+func (t *terminal) eventLoop() error {
+	for {
+		switch ev := t.ui.PollEvent().(type) {
+		case nil:
+			return nil
+		case *exitEvent:
+			return ev.err
+		case *outputEvent:
+			t.draw()
+		case *tcell.EventResize:
+			_ = t.session.Resize(ev.Size())
+			t.ui.Sync()
+			t.draw()
+		case *tcell.EventKey:
+			if ev.Key() == tcell.KeyCtrlRightSq {
+				return nil
+			}
+			if key, ok := keyEvent(ev); ok {
+				var data []byte
+				t.session.Screen().Update(func(gopyte.Screen) {
+					if data = t.screen.EncodeKey(key); len(data) > 0 {
+						t.screen.ScrollToBottom()
+					}
+				})
+				if len(data) > 0 {
+					t.send(string(data))
+				}
+			}
+		case *tcell.EventMouse:
+			t.mouse(ev)
+		}
+	}
+}
+
+func (t *terminal) send(data string) {
+	_, _ = t.session.WriteInput([]byte(data))
+}
+
+// mouse reports mouse events to a program that turned mouse tracking on,
+// in the encoding it asked for. Otherwise it handles the wheel: on the
+// main screen it scrolls the scrollback, on the alternate screen it sends
+// cursor keys, as most terminals do.
+func (t *terminal) mouse(ev *tcell.EventMouse) {
+	var proto gopyte.MouseProtocol
+	var alt, viewingHistory bool
+	t.session.Screen().View(func(gopyte.Screen) {
+		proto = t.screen.MouseProtocol()
+		alt = t.screen.IsUsingAlternate()
+		viewingHistory = t.screen.IsViewingHistory()
+	})
+
+	buttons := ev.Buttons()
+	x, y := ev.Position()
+	prev, moved := t.buttons, x != t.mouseX || y != t.mouseY
+	t.buttons, t.mouseX, t.mouseY = buttons&^(tcell.WheelUp|tcell.WheelDown|tcell.WheelLeft|tcell.WheelRight), x, y
+
+	if proto.Enabled() && !viewingHistory {
+		for _, report := range mouseReports(proto, prev, buttons, moved, ev.Modifiers()) {
+			t.send(encodeMouse(proto.Encoding, report, x, y))
+		}
+		return
+	}
+
+	up := buttons&tcell.WheelUp != 0
+	down := buttons&tcell.WheelDown != 0
+	if !up && !down {
+		return
+	}
+	if alt {
+		key := gopyte.KeyEvent{Key: gopyte.KeyUp}
+		if down {
+			key.Key = gopyte.KeyDown
+		}
+		var data []byte
+		t.session.Screen().View(func(gopyte.Screen) {
+			data = t.screen.EncodeKey(key)
+		})
+		for i := 0; i < wheelLines; i++ {
+			t.send(string(data))
+		}
+		return
+	}
+	t.session.Screen().Update(func(gopyte.Screen) {
+		if up {
+			t.screen.ScrollUp(wheelLines)
+		} else {
+			t.screen.ScrollDown(wheelLines)
+		}
+	})
+	t.draw()
+}
+
+// draw copies the emulated screen to the UI.
+func (t *terminal) draw() {
+	var lines [][]gopyte.Cell
+	var cursor gopyte.Cursor
+	var viewingHistory bool
+	t.session.Screen().View(func(gopyte.Screen) {
+		for y := 0; ; y++ {
+			cells := t.screen.GetLineCells(y)
+			if cells == nil {
+				break
+			}
+			lines = append(lines, cells)
+		}
+		cursor = *t.screen.GetCursorObject()
+		viewingHistory = t.screen.IsViewingHistory()
+	})
+
+	t.ui.Clear()
+	for y, cells := range lines {
+		for x, c := range cells {
+			if c.Width == 0 || c.Char == 0 {
+				continue // Right half of a wide character
+			}
+			var comb []rune
+			if c.Cluster != "" {
+				comb = []rune(c.Cluster)
+			}
+			t.ui.SetContent(x, y, c.Char, comb, cellStyle(c.Attrs))
+		}
+	}
+
+	if viewingHistory || cursor.Hidden {
+		t.ui.HideCursor()
+	} else {
+		x := cursor.X
+		if cols, _ := t.ui.Size(); x >= cols && cols > 0 {
+			x = cols - 1 // Pending wrap
+		}
+		t.ui.ShowCursor(x, cursor.Y)
+	}
+	t.ui.Show()
+}
+
+// cellStyle maps a cell's attributes to a tcell style. Named and indexed
+// colors go through the host terminal's palette, so its color scheme
+// applies.
+func cellStyle(a gopyte.Attributes) tcell.Style {
+	st := tcell.StyleDefault.
+		Foreground(tcellColor(a.Fg)).
+		Background(tcellColor(a.Bg)).
+		Bold(a.Bold).
+		Italic(a.Italics).
+		StrikeThrough(a.Strikethrough).
+		Reverse(a.Reverse).
+		Blink(a.Blink)
+	if a.UnderlineStyle != gopyte.UnderlineNone {
+		// The two enumerations list the same styles in the same order
+		st = st.Underline(tcell.UnderlineStyle(a.UnderlineStyle), tcellColor(a.UnderlineColor))
+	} else if a.Underscore {
+		st = st.Underline(true)
+	}
+	return st
+}
+
+func tcellColor(c gopyte.Color) tcell.Color {
+	switch c.Kind() {
+	case gopyte.ColorNamed, gopyte.ColorIndexed:
+		return tcell.PaletteColor(c.Index())
+	case gopyte.ColorDirect:
+		rgb, _ := c.RGB()
+		return tcell.NewRGBColor(int32(rgb.R), int32(rgb.G), int32(rgb.B))
+	}
+	return tcell.ColorDefault
+}
+
+// tcellKeys maps tcell's special keys to gopyte's.
+var tcellKeys = map[tcell.Key]gopyte.Key{
+	tcell.KeyEnter:      gopyte.KeyEnter,
+	tcell.KeyTab:        gopyte.KeyTab,
+	tcell.KeyBacktab:    gopyte.KeyTab,
+	tcell.KeyBackspace:  gopyte.KeyBackspace,
+	tcell.KeyBackspace2: gopyte.KeyBackspace,
+	tcell.KeyEscape:     gopyte.KeyEscape,
+	tcell.KeyUp:         gopyte.KeyUp,
+	tcell.KeyDown:       gopyte.KeyDown,
+	tcell.KeyRight:      gopyte.KeyRight,
+	tcell.KeyLeft:       gopyte.KeyLeft,
+	tcell.KeyHome:       gopyte.KeyHome,
+	tcell.KeyEnd:        gopyte.KeyEnd,
+	tcell.KeyInsert:     gopyte.KeyInsert,
+	tcell.KeyDelete:     gopyte.KeyDelete,
+	tcell.KeyPgUp:       gopyte.KeyPageUp,
+	tcell.KeyPgDn:       gopyte.KeyPageDown,
+	tcell.KeyF1:         gopyte.KeyF1,
+	tcell.KeyF2:         gopyte.KeyF2,
+	tcell.KeyF3:         gopyte.KeyF3,
+	tcell.KeyF4:         gopyte.KeyF4,
+	tcell.KeyF5:         gopyte.KeyF5,
+	tcell.KeyF6:         gopyte.KeyF6,
+	tcell.KeyF7:         gopyte.KeyF7,
+	tcell.KeyF8:         gopyte.KeyF8,
+	tcell.KeyF9:         gopyte.KeyF9,
+	tcell.KeyF10:        gopyte.KeyF10,
+	tcell.KeyF11:        gopyte.KeyF11,
+	tcell.KeyF12:        gopyte.KeyF12,
+}
+
+// keyEvent translates a tcell key event for Screen.EncodeKey, which
+// follows the cursor key, keypad and keyboard protocol modes the program
+// set. ok is false for keys gopyte has no encoding for.
+func keyEvent(ev *tcell.EventKey) (key gopyte.KeyEvent, ok bool) {
+	mod := ev.Modifiers()
+	if mod&tcell.ModShift != 0 {
+		key.Mods |= gopyte.ModShift
+	}
+	if mod&tcell.ModAlt != 0 {
+		key.Mods |= gopyte.ModAlt
+	}
+	if mod&tcell.ModCtrl != 0 {
+		key.Mods |= gopyte.ModCtrl
+	}
+
+	k := ev.Key()
+	if special, found := tcellKeys[k]; found {
+		key.Key = special
+		if k == tcell.KeyBacktab {
+			key.Mods |= gopyte.ModShift
+		}
+		return key, true
+	}
+	switch {
+	case k == tcell.KeyRune:
+		key.Key, key.Rune = gopyte.KeyRune, ev.Rune()
+	case k == tcell.KeyCtrlSpace:
+		key.Key, key.Rune, key.Mods = gopyte.KeyRune, ' ', key.Mods|gopyte.ModCtrl
+	case k >= tcell.KeyCtrlA && k <= tcell.KeyCtrlZ:
+		key.Key, key.Rune, key.Mods = gopyte.KeyRune, rune('a'+k-tcell.KeyCtrlA), key.Mods|gopyte.ModCtrl
+	case k > tcell.KeyCtrlZ && k <= tcell.KeyCtrlUnderscore:
+		// Ctrl+[ \ ] ^ _
+		key.Key, key.Rune, key.Mods = gopyte.KeyRune, rune('@'+k), key.Mods|gopyte.ModCtrl
+	default:
+		return key, false
+	}
+	return key, true
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/scottpeterman/gopyte/gopyte"
+)
+
+// mouseReport is one event to report to the program: the xterm button
+// code, with the motion and modifier bits, and whether it is a release.
+type mouseReport struct {
+	code    int
+	release bool
+}
+
+// Xterm button codes and the bits added to them.
+const (
+	mouseLeft   = 0
+	mouseMiddle = 1
+	mouseRight  = 2
+	mouseNone   = 3 // No button: motion with none held, or a legacy release
+	mouseWheel  = 64
+	mouseShift  = 4
+	mouseAlt    = 8
+	mouseCtrl   = 16
+	mouseMotion = 32
+)
+
+var mouseButtons = []struct {
+	mask tcell.ButtonMask
+	code int
+}{
+	{tcell.ButtonPrimary, mouseLeft},
+	{tcell.ButtonMiddle, mouseMiddle},
+	{tcell.ButtonSecondary, mouseRight},
+}
+
+var mouseWheels = []struct {
+	mask tcell.ButtonMask
+	code int
+}{
+	{tcell.WheelUp, mouseWheel},
+	{tcell.WheelDown, mouseWheel + 1},
+	{tcell.WheelLeft, mouseWheel + 2},
+	{tcell.WheelRight, mouseWheel + 3},
+}
+
+// mouseReports returns the reports for a tcell mouse event, given the
+// buttons held before it, as the program's tracking mode asks for them:
+// presses only in X10 mode, releases too in normal mode, and motion while
+// a button is held or always in the button and any-event modes.
+func mouseReports(p gopyte.MouseProtocol, prev, cur tcell.ButtonMask, moved bool, mod tcell.ModMask) []mouseReport {
+	mods := 0
+	if p.Tracking != gopyte.MouseTrackingX10 {
+		if mod&tcell.ModShift != 0 {
+			mods |= mouseShift
+		}
+		if mod&tcell.ModAlt != 0 {
+			mods |= mouseAlt
+		}
+		if mod&tcell.ModCtrl != 0 {
+			mods |= mouseCtrl
+		}
+	}
+
+	var reports []mouseReport
+	for _, w := range mouseWheels {
+		if cur&w.mask != 0 {
+			reports = append(reports, mouseReport{code: w.code | mods})
+		}
+	}
+	held := -1
+	for _, b := range mouseButtons {
+		switch {
+		case cur&b.mask != 0 && prev&b.mask == 0:
+			reports = append(reports, mouseReport{code: b.code | mods})
+		case cur&b.mask == 0 && prev&b.mask != 0 && p.Tracking != gopyte.MouseTrackingX10:
+			reports = append(reports, mouseReport{code: b.code | mods, release: true})
+		}
+		if cur&b.mask != 0 && held < 0 {
+			held = b.code
+		}
+	}
+	if len(reports) > 0 || !moved {
+		return reports
+	}
+
+	switch {
+	case p.Tracking == gopyte.MouseTrackingAny && held < 0:
+		reports = append(reports, mouseReport{code: mouseNone | mouseMotion | mods})
+	case (p.Tracking == gopyte.MouseTrackingAny || p.Tracking == gopyte.MouseTrackingButton) && held >= 0:
+		reports = append(reports, mouseReport{code: held | mouseMotion | mods})
+	}
+	return reports
+}
+
+// encodeMouse encodes a report at cell x, y (from 0) in the program's
+// encoding. The legacy encodings cannot say which button was released,
+// and X10 and UTF-8 cannot reach far columns; such reports come back
+// empty. tcell gives cells, not pixels, so SGR pixel mode gets cell
+// coordinates like SGR.
+func encodeMouse(enc gopyte.MouseEncoding, r mouseReport, x, y int) string {
+	x, y = x+1, y+1
+	switch enc {
+	case gopyte.MouseEncodingSGR, gopyte.MouseEncodingSGRPixels:
+		final := 'M'
+		if r.release {
+			final = 'm'
+		}
+		return fmt.Sprintf("\x1b[<%d;%d;%d%c", r.code, x, y, final)
+	}
+
+	code := r.code
+	if r.release {
+		code |= mouseNone
+	}
+	switch enc {
+	case gopyte.MouseEncodingURXVT:
+		return fmt.Sprintf("\x1b[%d;%d;%dM", code+32, x, y)
+	case gopyte.MouseEncodingUTF8:
+		if x+32 > 2047 || y+32 > 2047 {
+			return ""
+		}
+		return "\x1b[M" + string(rune(code+32)) + string(rune(x+32)) + string(rune(y+32))
+	}
+	if x+32 > 255 || y+32 > 255 {
+		return ""
+	}
+	return string([]byte{0x1b, '[', 'M', byte(code + 32), byte(x + 32), byte(y + 32)})
+}
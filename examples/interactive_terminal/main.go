@@ -4,7 +4,7 @@
 //
 // This example demonstrates GoPyte's terminal emulation capabilities by providing
 // an interactive shell where you can:
-//   - Execute Windows commands and see parsed output
+//   - Execute commands on a pseudo-terminal and see parsed output
 //   - Test ANSI escape sequence handling
 //   - Explore scrollback history
 //   - Switch between main and alternate screen buffers
@@ -16,8 +16,8 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"runtime"
@@ -25,6 +25,7 @@ import (
 	"strings"
 
 	"github.com/scottpeterman/gopyte/gopyte"
+	"github.com/scottpeterman/gopyte/gopyte/pty"
 )
 
 const (
@@ -50,15 +51,16 @@ func main() {
 	printBanner()
 	printHelp()
 
-	scanner := bufio.NewScanner(os.Stdin)
+	lines := readLines(os.Stdin)
 
 	for {
 		fmt.Print("\n> ")
-		if !scanner.Scan() {
+		line, ok := <-lines
+		if !ok {
 			break
 		}
 
-		input := strings.TrimSpace(scanner.Text())
+		input := strings.TrimSpace(line)
 		if input == "" {
 			continue
 		}
@@ -73,7 +75,7 @@ func main() {
 		switch cmd {
 		case "run", "r":
 			if args != "" {
-				term.runCommand(args, false)
+				term.runCommand(args, false, lines)
 			} else {
 				fmt.Println("Usage: run <command>")
 				fmt.Println("Example: run dir /w")
@@ -81,7 +83,7 @@ func main() {
 
 		case "ps", "powershell":
 			if args != "" {
-				term.runCommand(args, true)
+				term.runCommand(args, true, lines)
 			} else {
 				fmt.Println("Usage: ps <command>")
 				fmt.Println("Example: ps Get-Date")
@@ -144,44 +146,72 @@ func main() {
 	}
 }
 
-func (t *Terminal) runCommand(command string, isPowerShell bool) {
-	shellName := "CMD"
+// readLines reads r a line at a time on a goroutine of its own, so that
+// the lines can go to the prompt or to a running command. The channel is
+// closed at the end of the input.
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+func (t *Terminal) runCommand(command string, isPowerShell bool, input <-chan string) {
+	shellName := "Shell"
 	if isPowerShell {
 		shellName = "PowerShell"
+	} else if runtime.GOOS == "windows" {
+		shellName = "CMD"
 	}
 
 	fmt.Printf("\n=== Running %s Command ===\n", shellName)
 	fmt.Printf("Command: %s\n", command)
 
 	var cmd *exec.Cmd
-	if isPowerShell {
+	switch {
+	case isPowerShell:
 		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", command)
-	} else {
+	case runtime.GOOS == "windows":
 		cmd = exec.Command("cmd", "/c", command)
+	default:
+		cmd = exec.Command("sh", "-c", command)
 	}
+	// The screen is shown once the command exits, so a pager would only
+	// wait for keys no one sees
+	cmd.Env = append(os.Environ(), "TERM="+pty.DefaultTerm, "PAGER=cat", "GIT_PAGER=cat")
 
-	// Set up environment for better compatibility
-	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-
-	output := stdout.String()
-	if stderr.Len() > 0 {
-		output += "\n" + stderr.String()
+	// Run it on a pseudo-terminal (ConPTY on Windows), so it sees a real
+	// terminal and its output streams into the screen as it is written
+	session, err := pty.Start(cmd, t.screen, pty.Options{Columns: screenWidth, Lines: screenHeight})
+	if err != nil {
+		fmt.Printf("Failed to start command: %v\n", err)
+		return
 	}
 
-	if output != "" {
-		t.stream.Feed(output)
-		fmt.Printf("Output captured: %d bytes\n", len(output))
+	// Lines typed while it runs are its input, for commands that prompt
+	for done := false; !done; {
+		select {
+		case line, ok := <-input:
+			if !ok {
+				input = nil // End of our input; let the command finish
+				continue
+			}
+			_, _ = session.WriteInput([]byte(line + "\r"))
+		case <-session.Done():
+			done = true
+		}
 	}
-
-	if err != nil {
+	if err := session.Wait(); err != nil {
 		fmt.Printf("Command exit code: %v\n", err)
 	}
+	// The session answered the command's queries; feed and demo output
+	// has no one to answer
+	t.screen.SetResponseWriter(nil)
 
 	t.showDisplay(false)
 }
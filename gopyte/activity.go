@@ -0,0 +1,186 @@
+package gopyte
+
+import (
+	"sync"
+	"time"
+)
+
+// ActivityEvent reports a session going from idle to active or back.
+type ActivityEvent struct {
+	Active     bool      // True when output resumed, false when it went quiet
+	At         time.Time // When the transition was noticed
+	LastOutput time.Time // Time of the latest output
+}
+
+// ActivityMonitor watches a session's output and reports when it starts
+// and stops, so a supervisor of many sessions can spot hung devices or
+// finished jobs without reading their screens:
+//
+//	m := gopyte.NewActivityMonitor(30*time.Second, nil, func(ev gopyte.ActivityEvent) {
+//		if !ev.Active {
+//			log.Printf("core1 quiet since %v", ev.LastOutput)
+//		}
+//	})
+//	stream.SetActivityMonitor(m)
+//	defer m.Stop()
+//
+// A session is idle until its first output and after every quiet period
+// without output. The active event is delivered from the goroutine that
+// feeds the output, the idle event from the monitor's own goroutine, one
+// at a time and in order: a transition overtaken by a later one before it
+// could be delivered is dropped, so the last event always matches the
+// state. onChange must not feed output to the monitor. It is safe for
+// concurrent use.
+type ActivityMonitor struct {
+	mu       sync.Mutex
+	quiet    time.Duration
+	clock    Clock
+	onChange func(ActivityEvent)
+	last     time.Time
+	bytes    int64
+	active   bool
+	seq      uint64 // Counts transitions, to tell stale events
+	stopped  bool
+	stop     chan struct{}
+
+	deliverMu sync.Mutex // Held while an event is delivered
+	reported  bool       // State of the last event delivered
+}
+
+// NewActivityMonitor creates a monitor that declares the session idle
+// after quiet without output. clock may be nil for SystemClock, onChange
+// nil when the state is polled instead.
+func NewActivityMonitor(quiet time.Duration, clock Clock, onChange func(ActivityEvent)) *ActivityMonitor {
+	if clock == nil {
+		clock = SystemClock
+	}
+	return &ActivityMonitor{quiet: quiet, clock: clock, onChange: onChange, stop: make(chan struct{})}
+}
+
+// Write records output of len(p) bytes, so the monitor can also be used as
+// an io.Writer in a tee. It never fails.
+func (m *ActivityMonitor) Write(p []byte) (int, error) {
+	m.Output(len(p))
+	return len(p), nil
+}
+
+// Output records n bytes of output now.
+func (m *ActivityMonitor) Output(n int) {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return
+	}
+	now := m.clock.Now()
+	m.last = now
+	m.bytes += int64(n)
+	wasActive := m.active
+	m.active = true
+	if !wasActive {
+		m.seq++
+	}
+	seq := m.seq
+	m.mu.Unlock()
+
+	if wasActive {
+		return
+	}
+	go m.watch()
+	m.deliver(seq, ActivityEvent{Active: true, At: now, LastOutput: now})
+}
+
+// watch waits for the quiet period to pass without output, then reports
+// the session idle.
+func (m *ActivityMonitor) watch() {
+	m.mu.Lock()
+	wait := m.quiet
+	m.mu.Unlock()
+	for {
+		select {
+		case <-m.clock.After(wait):
+		case <-m.stop:
+			return
+		}
+
+		m.mu.Lock()
+		now := m.clock.Now()
+		if quiet := now.Sub(m.last); quiet < m.quiet {
+			wait = m.quiet - quiet // Output arrived meanwhile
+			m.mu.Unlock()
+			continue
+		}
+		m.active = false
+		m.seq++
+		seq := m.seq
+		ev := ActivityEvent{At: now, LastOutput: m.last}
+		m.mu.Unlock()
+
+		m.deliver(seq, ev)
+		return
+	}
+}
+
+// deliver passes ev, the event of transition seq, to the callback unless
+// a later transition or Stop overtook it, or the callback already knows
+// the state.
+func (m *ActivityMonitor) deliver(seq uint64, ev ActivityEvent) {
+	m.deliverMu.Lock()
+	defer m.deliverMu.Unlock()
+	m.mu.Lock()
+	stale := seq != m.seq || m.stopped
+	fn := m.onChange
+	m.mu.Unlock()
+
+	if stale || fn == nil || ev.Active == m.reported {
+		return
+	}
+	m.reported = ev.Active
+	fn(ev)
+}
+
+// Active reports whether output arrived within the last quiet period.
+func (m *ActivityMonitor) Active() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active
+}
+
+// LastOutputAt returns the time of the latest output, or the zero time if
+// there has been none.
+func (m *ActivityMonitor) LastOutputAt() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+// Bytes returns the total output recorded.
+func (m *ActivityMonitor) Bytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bytes
+}
+
+// Stop ends the monitor's goroutine. No events are delivered afterwards.
+func (m *ActivityMonitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.stopped {
+		m.stopped = true
+		close(m.stop)
+	}
+}
+
+// SetActivityMonitor reports every chunk passed to Feed to m. Pass nil to
+// stop.
+func (s *Stream) SetActivityMonitor(m *ActivityMonitor) {
+	s.activity = m
+}
+
+// LastOutputAt returns the time of the latest output seen by the stream's
+// activity monitor, or the zero time without one.
+func (s *Stream) LastOutputAt() time.Time {
+	if s.activity == nil {
+		return time.Time{}
+	}
+	return s.activity.LastOutputAt()
+}
@@ -9,25 +9,35 @@ import (
 type AlternateScreen struct {
 	*HistoryScreen
 
-	// Alternative screen state
-	mainBuffer   [][]rune
-	mainAttrs    [][]Attributes
-	mainCursor   Cursor
-	mainTabStops map[int]bool
-	mainHistory  *list.List
+	// Alternative screen state. Everything the main screen needs to come
+	// back exactly as it was is parked here while the alternate one is up.
+	mainBuffer    [][]rune
+	mainAttrs     [][]Attributes
+	mainWrapped   []bool
+	mainRepainted []bool
+	mainMeta      []*LineMeta
+	mainCRPending bool
+	mainCRRow     int
+	mainCursor    Cursor  // Includes a pending wrap
+	mainSaved     *Cursor // DECSC slot of the main screen
+	mainTabStops  map[int]bool
+	mainHistory   *list.List
+	mainSpill     *spill
+	mainKeyboard  []int // Kitty keyboard flag stack of the main screen
 
 	altBuffer   [][]rune
 	altAttrs    [][]Attributes
 	altCursor   Cursor
+	altSaved    *Cursor // DECSC slot of the alternate screen
 	altTabStops map[int]bool
 
 	usingAlternate bool
 }
 
 // NewAlternateScreen creates a screen with both main and alternate buffers
-func NewAlternateScreen(columns, lines, maxHistory int) *AlternateScreen {
+func NewAlternateScreen(columns, lines, maxHistory int, opts ...ScreenOption) *AlternateScreen {
 	a := &AlternateScreen{
-		HistoryScreen:  NewHistoryScreen(columns, lines, maxHistory),
+		HistoryScreen:  NewHistoryScreen(columns, lines, maxHistory, opts...),
 		usingAlternate: false,
 	}
 
@@ -53,19 +63,22 @@ func NewAlternateScreen(columns, lines, maxHistory int) *AlternateScreen {
 
 // Override SetMode to handle alternate screen switching
 func (a *AlternateScreen) SetMode(modes []int, private bool) {
+	defer a.holdEvents()()
+	defer a.watchAlternate()()
 	if private {
 		for _, mode := range modes {
 			switch mode {
-			case 1049, 1047, 47: // Alternate screen modes
+			case 1049: // Save cursor as DECSC, then switch
 				if !a.usingAlternate {
+					a.SaveCursor()
 					a.switchToAlternate()
 				}
-			case 1048: // Save cursor
-				if a.usingAlternate {
-					a.altCursor = a.cursor
-				} else {
-					a.mainCursor = a.cursor
+			case 1047, 47: // Alternate screen modes
+				if !a.usingAlternate {
+					a.switchToAlternate()
 				}
+			case 1048: // Save cursor, same slot as DECSC
+				a.SaveCursor()
 			}
 		}
 	}
@@ -76,19 +89,22 @@ func (a *AlternateScreen) SetMode(modes []int, private bool) {
 
 // Override ResetMode to handle alternate screen switching
 func (a *AlternateScreen) ResetMode(modes []int, private bool) {
+	defer a.holdEvents()()
+	defer a.watchAlternate()()
 	if private {
 		for _, mode := range modes {
 			switch mode {
-			case 1049, 1047, 47: // Exit alternate screen
+			case 1049: // Switch back, then restore cursor as DECRC
 				if a.usingAlternate {
 					a.switchToMain()
+					a.RestoreCursor()
 				}
-			case 1048: // Restore cursor
+			case 1047, 47: // Exit alternate screen
 				if a.usingAlternate {
-					a.cursor = a.altCursor
-				} else {
-					a.cursor = a.mainCursor
+					a.switchToMain()
 				}
+			case 1048: // Restore cursor, same slot as DECRC
+				a.RestoreCursor()
 			}
 		}
 	}
@@ -102,9 +118,26 @@ func (a *AlternateScreen) switchToAlternate() {
 	// Save main screen state
 	a.mainBuffer = a.buffer
 	a.mainAttrs = a.attrs
+	a.mainWrapped = a.wrapped
+	a.mainRepainted = a.repainted
+	a.mainMeta = a.meta
+	a.mainCRPending, a.mainCRRow = a.crPending, a.crRow
 	a.mainCursor = a.cursor
+	a.mainSaved = a.saved
 	a.mainTabStops = a.tabStops
 	a.mainHistory = a.history
+	a.mainKeyboard = a.keyboardFlags
+
+	// The alternate buffer is not resized with the main one; catch up
+	if len(a.altBuffer) != a.lines || len(a.altAttrs) != a.lines ||
+		(a.lines > 0 && (len(a.altBuffer[0]) != a.columns || len(a.altAttrs[0]) != a.columns)) {
+		a.altBuffer = make([][]rune, a.lines)
+		a.altAttrs = make([][]Attributes, a.lines)
+		for i := range a.altBuffer {
+			a.altBuffer[i] = make([]rune, a.columns)
+			a.altAttrs[i] = make([]Attributes, a.columns)
+		}
+	}
 
 	// Clear alternate buffer before switching
 	for i := 0; i < a.lines; i++ {
@@ -117,11 +150,18 @@ func (a *AlternateScreen) switchToAlternate() {
 	// Switch to alternate
 	a.buffer = a.altBuffer
 	a.attrs = a.altAttrs
-	a.cursor = Cursor{X: 0, Y: 0, Attrs: DefaultAttributes()}
+	a.wrapped = make([]bool, a.lines)
+	a.repainted = make([]bool, a.lines)
+	a.meta = make([]*LineMeta, a.lines)
+	a.crPending = false
+	a.cursor = Cursor{X: 0, Y: 0, Attrs: DefaultAttributes(), Hidden: a.cursor.Hidden}
+	a.saved = a.altSaved
 	a.tabStops = a.altTabStops
+	a.keyboardFlags = nil
 
 	// Alternate screen doesn't use history, use empty list
 	a.history = list.New()
+	a.mainSpill, a.spill = a.spill, nil
 	a.usingAlternate = true
 
 	// If we were viewing history, exit that mode
@@ -141,14 +181,23 @@ func (a *AlternateScreen) switchToMain() {
 	a.altBuffer = a.buffer
 	a.altAttrs = a.attrs
 	a.altCursor = a.cursor
+	a.altSaved = a.saved
 	a.altTabStops = a.tabStops
 
 	// Restore main screen
 	a.buffer = a.mainBuffer
 	a.attrs = a.mainAttrs
+	a.wrapped = a.mainWrapped
+	a.repainted = a.mainRepainted
+	a.meta = a.mainMeta
+	a.crPending, a.crRow = a.mainCRPending, a.mainCRRow
 	a.cursor = a.mainCursor
+	a.cursor.Hidden = a.altCursor.Hidden // DECTCEM is shared by both buffers
+	a.saved = a.mainSaved
 	a.tabStops = a.mainTabStops
 	a.history = a.mainHistory
+	a.spill, a.mainSpill = a.mainSpill, nil
+	a.keyboardFlags = a.mainKeyboard
 
 	a.usingAlternate = false
 }
@@ -156,6 +205,7 @@ func (a *AlternateScreen) switchToMain() {
 // Override methods that shouldn't save to history in alternate mode
 
 func (a *AlternateScreen) Linefeed() {
+	a.cursor.wrapPending = false
 	if a.usingAlternate {
 		// Check if at bottom BEFORE incrementing
 		if a.cursor.Y == a.lines-1 {
@@ -168,7 +218,7 @@ func (a *AlternateScreen) Linefeed() {
 		}
 
 		if a.newlineMode {
-			a.cursor.X = 0
+			a.cursor.X = a.lineStart()
 		}
 	} else {
 		// Use parent implementation with history
@@ -177,6 +227,7 @@ func (a *AlternateScreen) Linefeed() {
 }
 
 func (a *AlternateScreen) Index() {
+	a.cursor.wrapPending = false
 	if a.usingAlternate {
 		// Check if at bottom BEFORE incrementing
 		if a.cursor.Y == a.lines-1 {
@@ -194,9 +245,13 @@ func (a *AlternateScreen) Index() {
 
 // scrollUpNoHistory scrolls without saving to history (for alternate screen)
 func (a *AlternateScreen) scrollUpNoHistory() {
+	if a.scrollMargins(true) {
+		return
+	}
 	// Move all lines up by one
 	copy(a.buffer[0:], a.buffer[1:])
 	copy(a.attrs[0:], a.attrs[1:])
+	a.shiftWrappedUp(0, a.lines-1)
 
 	// Clear the last line
 	lastLine := a.lines - 1
@@ -220,28 +275,8 @@ func (a *AlternateScreen) Draw(text string) {
 
 // drawTextDirect draws text without history handling
 func (a *AlternateScreen) drawTextDirect(text string) {
-	for _, ch := range text {
-		// Check if we need to wrap
-		if a.cursor.X >= a.columns {
-			if a.autoWrap {
-				a.cursor.X = 0
-				a.cursor.Y++
-				if a.cursor.Y >= a.lines {
-					a.scrollUpNoHistory()
-					a.cursor.Y = a.lines - 1
-				}
-			} else {
-				a.cursor.X = a.columns - 1
-			}
-		}
-
-		// Place character
-		if a.cursor.Y < a.lines && a.cursor.X < a.columns {
-			a.buffer[a.cursor.Y][a.cursor.X] = ch
-			a.attrs[a.cursor.Y][a.cursor.X] = a.cursor.Attrs
-			a.cursor.X++
-		}
-	}
+	a.noteRepaint()
+	a.drawText(text, a.scrollUpNoHistory)
 }
 
 // ensureRowSize makes sure row slices match the current column count.
@@ -284,6 +319,9 @@ func (a *AlternateScreen) ensureRowSize() {
 
 // Reset clears the current (active) buffer safely without writing out of bounds.
 func (a *AlternateScreen) Reset() {
+	defer a.holdEvents()()
+	defer a.watchModes()()
+
 	// Normalize row widths first
 	a.ensureRowSize()
 
@@ -298,10 +336,24 @@ func (a *AlternateScreen) Reset() {
 		y++
 	}
 
+	a.clearWrapped(0, a.lines-1)
+	a.selection = nil
+
 	a.cursor.X = 0
 	a.cursor.Y = 0
+	a.cursor.wrapPending = false
+	a.cursor.Hidden = false
 	a.savedCursor.X = 0
 	a.savedCursor.Y = 0
+	a.cursorStyle = CursorStyleDefault
+	a.originMode = false
+	a.setLeftRightMarginMode(false)
+	a.mouse = MouseProtocol{}
+	a.bracketedPaste = false
+	a.appCursorKeys = false
+	a.appKeypad = false
+	a.keyboardFlags = nil
+	a.focusReporting = false
 
 	// Rebuild tab stops at every 8th column
 	a.tabStops = make(map[int]bool)
@@ -347,6 +399,7 @@ func (a *AlternateScreen) Resize(newCols, newLines int) {
 	if newCols <= 0 || newLines <= 0 {
 		return
 	}
+	defer a.holdEvents()()
 	if a.usingAlternate {
 		// Resize the alt buffer “in place” by temporarily making it active,
 		// delegating to base, then restoring invariants already held.
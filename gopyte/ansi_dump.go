@@ -0,0 +1,161 @@
+package gopyte
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ANSIOptions configures DumpANSI.
+type ANSIOptions struct {
+	Profile    ColorProfile // Color depth to write; the zero value keeps all colors
+	Scrollback bool         // Write the scrollback before the screen (history screens only)
+	Cursor     bool         // Finish by moving the cursor to its position and hiding it if hidden
+	Bidi       bool         // Write lines in display order, for terminals without bidi (see GetDisplayBidi)
+}
+
+// DumpANSI writes the screen back out as a minimal escape stream that
+// redraws it, like tmux capture-pane -e: the text of every line with an
+// SGR sequence wherever the attributes change, lines ending in a reset and
+// CR LF. Trailing blanks with default attributes are left out.
+//
+// With opts.Cursor the output assumes it starts at the top of a terminal
+// of the same size, or scrolls the scrollback off it, so that the last
+// line lands on the bottom row.
+func (s *NativeScreen) DumpANSI(opts ANSIOptions) string {
+	return dumpANSI(nil, s.screenCells(), s.cursor, opts)
+}
+
+// DumpANSI writes the scrollback, if opts.Scrollback, and the screen as an
+// escape stream. See NativeScreen.DumpANSI.
+func (h *HistoryScreen) DumpANSI(opts ANSIOptions) string {
+	var history [][]Cell
+	if opts.Scrollback {
+		history = h.historyCells()
+	}
+	return dumpANSI(history, h.screenCells(), h.cursor, opts)
+}
+
+// DumpANSI writes the scrollback, if opts.Scrollback, and the screen as an
+// escape stream. Wide characters take one rune and two columns.
+func (w *WideCharScreen) DumpANSI(opts ANSIOptions) string {
+	var history [][]Cell
+	if opts.Scrollback {
+		history = w.historyCells()
+	}
+	lines := make([][]Cell, w.lines)
+	for y := range lines {
+		lines[y] = w.lineCells(y)
+	}
+	return dumpANSI(history, lines, w.cursor, opts)
+}
+
+// screenCells returns every line of the screen as cells.
+func (s *NativeScreen) screenCells() [][]Cell {
+	lines := make([][]Cell, s.lines)
+	for y := range lines {
+		lines[y] = s.lineCells(y)
+	}
+	return lines
+}
+
+// historyCells returns the scrollback as cells, oldest first. A null rune
+// is the right half of a wide character and gets Width 0.
+func (h *HistoryScreen) historyCells() [][]Cell {
+	lines := make([][]Cell, 0, h.GetHistorySize())
+	h.eachHistory(h.history, h.spill, 0, func(line HistoryLine, attrs []Attributes) bool {
+		cells := make([]Cell, len(line.Chars))
+		for x, ch := range line.Chars {
+			cells[x] = Cell{Char: ch, Attrs: attrs[x], Width: 1}
+			if ch == 0 {
+				cells[x].Width = 0
+			} else {
+				cells[x].Cluster, _ = clusterKey.GetCell(line.Meta, x)
+			}
+		}
+		lines = append(lines, cells)
+		return true
+	})
+	return lines
+}
+
+func dumpANSI(history, screen [][]Cell, cursor Cursor, opts ANSIOptions) string {
+	var b strings.Builder
+	for i, cells := range append(history, screen...) {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		if opts.Bidi {
+			cells = bidiCells(cells)
+		}
+		writeANSILine(&b, cells, opts.Profile)
+	}
+	if opts.Cursor {
+		fmt.Fprintf(&b, "\x1b[%d;%dH", cursor.Y+1, cursor.X+1)
+		if cursor.Hidden {
+			b.WriteString("\x1b[?25l")
+		}
+	}
+	return b.String()
+}
+
+// writeANSILine writes the runs of a line, each preceded by the SGR that
+// selects its attributes from scratch, and resets them at the end.
+func writeANSILine(b *strings.Builder, cells []Cell, p ColorProfile) {
+	def := DefaultAttributes()
+	current := def
+	for _, run := range styledRuns(cells) {
+		if attrs := p.Attrs(run.Attrs); attrs != current {
+			b.WriteString("\x1b[" + sgrParams(attrs, p) + "m")
+			current = attrs
+		}
+		b.WriteString(run.Text)
+	}
+	if current != def {
+		b.WriteString("\x1b[0m")
+	}
+}
+
+// sgrParams returns the SGR parameters that set a, starting with 0 so that
+// nothing set before carries over.
+func sgrParams(a Attributes, p ColorProfile) string {
+	params := []string{"0"}
+	if a.Bold {
+		params = append(params, "1")
+	}
+	if a.Italics {
+		params = append(params, "3")
+	}
+	switch a.UnderlineStyle {
+	case UnderlineNone:
+		if a.Underscore {
+			params = append(params, "4")
+		}
+	case UnderlineSingle:
+		params = append(params, "4")
+	default:
+		params = append(params, "4:"+strconv.Itoa(int(a.UnderlineStyle)))
+	}
+	if a.Blink {
+		params = append(params, "5")
+	}
+	if a.Reverse {
+		params = append(params, "7")
+	}
+	if a.Strikethrough {
+		params = append(params, "9")
+	}
+	for _, c := range []string{p.SGR(a.Fg, false), p.SGR(a.Bg, true)} {
+		if c != "" {
+			params = append(params, c)
+		}
+	}
+	if c := a.UnderlineColor; !c.IsDefault() {
+		if c.kind == ColorDirect {
+			params = append(params, fmt.Sprintf("58;2;%d;%d;%d", c.rgb.R, c.rgb.G, c.rgb.B))
+		} else {
+			params = append(params, "58;5;"+strconv.Itoa(c.Index()))
+		}
+	}
+	return strings.Join(params, ";")
+}
@@ -0,0 +1,233 @@
+package gopyte
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/bidi"
+)
+
+// Bidirectional text. The screen keeps characters in the order they were
+// written, the logical order, which is what applications addressing cells
+// by column expect (ECMA TR/53's implicit mode). For reading, the display
+// order of a line puts right-to-left runs, Arabic and Hebrew, the right
+// way round. It is a simplified form of the Unicode Bidirectional
+// Algorithm: every line is a left-to-right paragraph, explicit embeddings
+// and isolates are ignored, and numbers, neutrals and mirrored brackets
+// are resolved as in the algorithm's implicit rules.
+
+// GetDisplayBidi returns the screen like GetDisplay, each line in display
+// order rather than in the order the characters are stored in.
+func (s *NativeScreen) GetDisplayBidi() []string {
+	lines := make([]string, s.lines)
+	for y := range lines {
+		lines[y] = strings.TrimRight(bidiText(s.lineCells(y)), " ")
+	}
+	return lines
+}
+
+// GetDisplayBidi returns the screen like GetDisplay, in display order. A
+// wide character and its cluster are moved as one.
+func (w *WideCharScreen) GetDisplayBidi() []string {
+	lines := make([]string, w.lines)
+	for y := range lines {
+		lines[y] = bidiText(w.lineCells(y))
+	}
+	return lines
+}
+
+// bidiText returns the text of cells in display order.
+func bidiText(cells []Cell) string {
+	var b strings.Builder
+	for _, c := range bidiCells(cells) {
+		if c.Char != 0 {
+			b.WriteRune(c.Char)
+			b.WriteString(c.Cluster)
+		}
+	}
+	return b.String()
+}
+
+// Resolved directions of characters. Once neutrals are resolved, they
+// are also the embedding levels: 0 left to right, 1 right to left and 2
+// for numbers within it.
+const (
+	bidiL       = iota // Left to right
+	bidiR              // Right to left
+	bidiNumber         // Digits in right-to-left text, which keep their order
+	bidiNeutral        // Not yet resolved
+)
+
+// bidiCells returns cells in display order, with mirrored characters such
+// as brackets swapped in right-to-left runs. The right half of a wide
+// character stays after its left half. A line without right-to-left text
+// is returned as it is.
+func bidiCells(cells []Cell) []Cell {
+	// Each character, wide or narrow, is a unit starting at cell start
+	var start, dirs []int
+	rtl := false
+	last := bidiL // Last strong direction, for numbers
+	for x, c := range cells {
+		if c.Char == 0 && x > 0 {
+			continue // Right half
+		}
+		start = append(start, x)
+		d := bidiNeutral
+		switch props, _ := bidi.LookupRune(c.Char); props.Class() {
+		case bidi.L:
+			d, last = bidiL, bidiL
+		case bidi.R, bidi.AL:
+			d, last, rtl = bidiR, bidiR, true
+		case bidi.AN:
+			d, rtl = bidiNumber, true
+		case bidi.EN:
+			// European digits take the direction of the text before them
+			d = bidiL
+			if last == bidiR {
+				d = bidiNumber
+			}
+		}
+		dirs = append(dirs, d)
+	}
+	if !rtl {
+		return cells
+	}
+	resolveNumberSeparators(cells, start, dirs)
+	resolveNeutrals(dirs)
+
+	// Reverse runs at or above each level, highest first
+	order := make([]int, len(dirs))
+	for i := range order {
+		order[i] = i
+	}
+	for level := 2; level >= 1; level-- {
+		for i := 0; i < len(order); {
+			if dirs[order[i]] < level {
+				i++
+				continue
+			}
+			j := i
+			for j < len(order) && dirs[order[j]] >= level {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				order[a], order[b] = order[b], order[a]
+			}
+			i = j
+		}
+	}
+
+	out := make([]Cell, 0, len(cells))
+	for _, u := range order {
+		end := len(cells)
+		if u+1 < len(start) {
+			end = start[u+1]
+		}
+		first := len(out)
+		out = append(out, cells[start[u]:end]...)
+		if dirs[u] == bidiR {
+			out[first].Char = mirrorRune(out[first].Char)
+		}
+	}
+	return out
+}
+
+// resolveNumberSeparators makes a single separator between digits, as in
+// "1,000" or "3.5", and currency signs and percent signs next to them part
+// of the number.
+func resolveNumberSeparators(cells []Cell, start, dirs []int) {
+	class := func(u int) bidi.Class {
+		props, _ := bidi.LookupRune(cells[start[u]].Char)
+		return props.Class()
+	}
+	for u := range dirs {
+		if dirs[u] != bidiNeutral {
+			continue
+		}
+		switch class(u) {
+		case bidi.CS, bidi.ES:
+			if u > 0 && u+1 < len(dirs) && dirs[u-1] == bidiNumber && dirs[u+1] == bidiNumber {
+				dirs[u] = bidiNumber
+			}
+		case bidi.ET:
+			// A run of terminators joins digits on either side of it
+			v := u
+			for v < len(dirs) && dirs[v] == bidiNeutral && class(v) == bidi.ET {
+				v++
+			}
+			if (u > 0 && dirs[u-1] == bidiNumber) || (v < len(dirs) && dirs[v] == bidiNumber) {
+				for ; u < v; u++ {
+					dirs[u] = bidiNumber
+				}
+			}
+		}
+	}
+}
+
+// resolveNeutrals gives a run of neutral characters, such as spaces and
+// punctuation, the direction of the text on both sides of it if that is
+// the same, or left to right, the direction of the line, if it is not.
+// Numbers count as right to left.
+func resolveNeutrals(dirs []int) {
+	side := func(d int) int {
+		if d == bidiNumber {
+			return bidiR
+		}
+		return d
+	}
+	for i := 0; i < len(dirs); {
+		if dirs[i] != bidiNeutral {
+			i++
+			continue
+		}
+		j := i
+		for j < len(dirs) && dirs[j] == bidiNeutral {
+			j++
+		}
+		before, after := bidiL, bidiL // The line starts and ends left to right
+		if i > 0 {
+			before = side(dirs[i-1])
+		}
+		if j < len(dirs) {
+			after = side(dirs[j])
+		}
+		d := bidiL
+		if before == after {
+			d = before
+		}
+		for ; i < j; i++ {
+			dirs[i] = d
+		}
+	}
+}
+
+// mirrorRune returns the mirror image of a paired character, drawn in
+// right-to-left text, or r itself.
+func mirrorRune(r rune) rune {
+	switch r {
+	case '(':
+		return ')'
+	case ')':
+		return '('
+	case '[':
+		return ']'
+	case ']':
+		return '['
+	case '{':
+		return '}'
+	case '}':
+		return '{'
+	case '<':
+		return '>'
+	case '>':
+		return '<'
+	case '«':
+		return '»'
+	case '»':
+		return '«'
+	case '‹':
+		return '›'
+	case '›':
+		return '‹'
+	}
+	return r
+}
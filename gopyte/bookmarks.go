@@ -0,0 +1,141 @@
+package gopyte
+
+import (
+	"sort"
+	"time"
+)
+
+// Bookmarks mark a point in a long capture - "failure started here" - so an
+// operator can jump back to it later. Each one records both where it falls
+// in the output stream and the line it resolved to, numbered from the
+// first line the screen ever showed so the number stays valid as the
+// screen scrolls:
+//
+//	b, _ := stream.Bookmark("failure", "BGP flap starts")
+//	...
+//	screen.ScrollToBookmark("failure")
+//
+// Bookmarks are copied by Clone, so snapshots keep them.
+
+// Bookmark is a named position in a session.
+type Bookmark struct {
+	Name   string
+	Note   string
+	Offset int64     // Bytes fed to the stream before the bookmark
+	Line   int       // Absolute line number of the cursor's line
+	Time   time.Time // When the bookmark was added, by the screen's clock
+}
+
+// Bookmarker is implemented by screens that keep bookmarks. It is
+// optional: Stream.Bookmark reports false for screens without it.
+type Bookmarker interface {
+	AddBookmark(name, note string, offset int64) Bookmark
+}
+
+// Offset returns the number of bytes fed to the stream so far.
+func (s *Stream) Offset() int64 {
+	return s.offset
+}
+
+// Bookmark adds a bookmark at the current position in the stream. ok is
+// false when the screen does not keep bookmarks.
+func (s *Stream) Bookmark(name, note string) (b Bookmark, ok bool) {
+	bm, ok := s.listener.(Bookmarker)
+	if !ok {
+		return Bookmark{}, false
+	}
+	return bm.AddBookmark(name, note, s.offset), true
+}
+
+// AddBookmark bookmarks the cursor's line, replacing any bookmark with the
+// same name. offset is the stream position to record with it; use
+// Stream.Bookmark to fill it in. On the alternate screen the line is
+// resolved against the main screen's numbering.
+func (h *HistoryScreen) AddBookmark(name, note string, offset int64) Bookmark {
+	b := Bookmark{
+		Name:   name,
+		Note:   note,
+		Offset: offset,
+		Line:   h.lineBase + h.cursor.Y,
+		Time:   h.Clock().Now(),
+	}
+	h.RemoveBookmark(name)
+	h.bookmarks = append(h.bookmarks, b)
+	return b
+}
+
+// RemoveBookmark deletes the named bookmark and reports whether it
+// existed.
+func (h *HistoryScreen) RemoveBookmark(name string) bool {
+	for i, b := range h.bookmarks {
+		if b.Name == name {
+			h.bookmarks = append(h.bookmarks[:i:i], h.bookmarks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// GetBookmark returns the named bookmark.
+func (h *HistoryScreen) GetBookmark(name string) (Bookmark, bool) {
+	for _, b := range h.bookmarks {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return Bookmark{}, false
+}
+
+// Bookmarks returns all bookmarks in stream order.
+func (h *HistoryScreen) Bookmarks() []Bookmark {
+	out := append([]Bookmark(nil), h.bookmarks...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Offset < out[j].Offset })
+	return out
+}
+
+// BookmarkRow returns where the named bookmark's line is now, relative to
+// the top of the screen: 0 and up are screen rows, -1 is the newest
+// scrollback line, -2 the one before and so on. ok is false when there is
+// no such bookmark or its line has been dropped from the scrollback.
+func (h *HistoryScreen) BookmarkRow(name string) (row int, ok bool) {
+	b, ok := h.GetBookmark(name)
+	if !ok {
+		return 0, false
+	}
+	row = b.Line - h.lineBase
+	if row < -h.history.Len() || row >= h.lines {
+		return 0, false
+	}
+	return row, true
+}
+
+// ScrollToBookmark scrolls the view so the named bookmark's line is at the
+// top, or returns to the live view when the line is on the screen. It
+// reports whether the line could be found.
+func (h *HistoryScreen) ScrollToBookmark(name string) bool {
+	row, ok := h.BookmarkRow(name)
+	if !ok {
+		return false
+	}
+	h.ScrollToBottom()
+	if row < 0 {
+		h.ScrollUp(-row)
+	}
+	return true
+}
+
+// lineScrolled renumbers after screen row 0 moved into the scrollback.
+// When collapsed, the row replaced the newest scrollback line instead of
+// following it, so the lines below it move up one number; bookmarks on
+// them move along.
+func (h *HistoryScreen) lineScrolled(collapsed bool) {
+	if !collapsed {
+		h.lineBase++
+		return
+	}
+	for i := range h.bookmarks {
+		if h.bookmarks[i].Line >= h.lineBase {
+			h.bookmarks[i].Line--
+		}
+	}
+}
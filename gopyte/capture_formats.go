@@ -0,0 +1,120 @@
+package gopyte
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CastHeader is the header line of an asciinema v2 recording.
+type CastHeader struct {
+	Version       int               `json:"version"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	Timestamp     int64             `json:"timestamp,omitempty"`
+	Duration      float64           `json:"duration,omitempty"`
+	IdleTimeLimit float64           `json:"idle_time_limit,omitempty"`
+	Command       string            `json:"command,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+}
+
+// ReadAsciicast parses an asciinema v2 .cast file into a Transcript.
+// Output, input and resize ("o", "i" and "r") events are kept; other event
+// types, such as markers, are skipped.
+func ReadAsciicast(r io.Reader) (*Transcript, CastHeader, error) {
+	var header CastHeader
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, header, err
+		}
+		return nil, header, errors.New("asciicast: empty input")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, header, fmt.Errorf("asciicast header: %w", err)
+	}
+	if header.Version != 2 {
+		return nil, header, fmt.Errorf("asciicast: unsupported version %d", header.Version)
+	}
+
+	t := NewTranscript()
+	lineNo := 1
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		ev, err := parseEventLine(line)
+		if err != nil {
+			var raw []interface{}
+			if json.Unmarshal(line, &raw) == nil && len(raw) == 3 {
+				continue // Well-formed event of a type we do not replay
+			}
+			return nil, header, fmt.Errorf("asciicast line %d: %w", lineNo, err)
+		}
+		t.events = append(t.events, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, header, err
+	}
+	return t, header, nil
+}
+
+// ReadTTYRec parses a ttyrec recording. Each record is a 12 byte header
+// (seconds, microseconds, length; little-endian uint32) followed by the
+// output bytes. Timestamps are made relative to the first record.
+func ReadTTYRec(r io.Reader) (*Transcript, error) {
+	t := NewTranscript()
+	br := bufio.NewReader(r)
+
+	var first time.Time
+	var hdr [12]byte
+	for n := 0; ; n++ {
+		if _, err := io.ReadFull(br, hdr[:]); err != nil {
+			if err == io.EOF {
+				return t, nil
+			}
+			return nil, fmt.Errorf("ttyrec record %d: %w", n, err)
+		}
+		sec := binary.LittleEndian.Uint32(hdr[0:4])
+		usec := binary.LittleEndian.Uint32(hdr[4:8])
+		size := binary.LittleEndian.Uint32(hdr[8:12])
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, fmt.Errorf("ttyrec record %d: %w", n, err)
+		}
+
+		ts := time.Unix(int64(sec), int64(usec)*int64(time.Microsecond))
+		if n == 0 {
+			first = ts
+		}
+		t.events = append(t.events, TranscriptEvent{
+			Time:      ts.Sub(first),
+			Direction: DirOutput,
+			Data:      string(data),
+		})
+	}
+}
+
+// ReadRawCapture wraps an untimed byte capture (e.g. from script(1) or a
+// PTY log) in a Transcript with a single output event.
+func ReadRawCapture(r io.Reader) (*Transcript, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	t := NewTranscript()
+	if len(data) > 0 {
+		t.events = append(t.events, TranscriptEvent{Direction: DirOutput, Data: string(data)})
+	}
+	return t, nil
+}
@@ -0,0 +1,135 @@
+package gopyte
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// CastRecorder feeds output to a Stream and writes it, timestamped, as an
+// asciinema v2 recording that standard players replay:
+//
+//	f, _ := os.Create("session.cast")
+//	rec, err := gopyte.NewCastRecorder(f, stream, gopyte.CastHeader{Width: 80, Height: 24})
+//	io.Copy(rec, pty)
+//
+// Each chunk becomes one "o" event, written as it arrives, so a recording
+// cut short is still playable up to that point. A UTF-8 sequence split
+// between chunks is held back until it is complete, since event data must
+// be valid JSON strings. Unlike Recorder, which keeps the exact bytes for
+// forensic use, the file is a faithful replay, not a byte-exact capture.
+// It is safe for concurrent use.
+type CastRecorder struct {
+	mu      sync.Mutex
+	w       io.Writer
+	stream  *Stream
+	clock   Clock
+	start   time.Time
+	pending []byte // Incomplete UTF-8 sequence at the end of the last chunk
+	err     error
+}
+
+// NewCastRecorder writes header to w and returns a recorder feeding
+// stream, timed by the system clock. See NewCastRecorderWithClock.
+func NewCastRecorder(w io.Writer, stream *Stream, header CastHeader) (*CastRecorder, error) {
+	return NewCastRecorderWithClock(w, stream, header, SystemClock)
+}
+
+// NewCastRecorderWithClock writes header to w and returns a recorder
+// feeding stream, timed by c starting at c.Now(). The header's Version is
+// set to 2 and a zero Timestamp to the start time; Width and Height are
+// required.
+func NewCastRecorderWithClock(w io.Writer, stream *Stream, header CastHeader, c Clock) (*CastRecorder, error) {
+	if header.Width <= 0 || header.Height <= 0 {
+		return nil, errors.New("asciicast: header needs a width and height")
+	}
+	r := &CastRecorder{w: w, stream: stream, clock: c, start: c.Now()}
+	header.Version = 2
+	if header.Timestamp == 0 {
+		header.Timestamp = r.start.Unix()
+	}
+	line, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Feed feeds data to the stream and records it as output.
+func (r *CastRecorder) Feed(data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stream.Feed(data)
+	r.output([]byte(data))
+}
+
+// Write feeds p to the stream and records it as output. It returns the
+// first error writing the recording met; the stream is fed regardless.
+func (r *CastRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stream.Feed(string(p))
+	r.output(p)
+	return len(p), r.err
+}
+
+// RecordInput records data sent to the program as an "i" event. Players
+// ignore input; it is kept for auditing.
+func (r *CastRecorder) RecordInput(data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("i", data)
+}
+
+// Resize records a terminal resize as an "r" event. It does not resize
+// the screen.
+func (r *CastRecorder) Resize(columns, lines int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("r", fmt.Sprintf("%dx%d", columns, lines))
+}
+
+// Marker records a marker, which players show as a chapter point.
+func (r *CastRecorder) Marker(label string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("m", label)
+}
+
+// Err returns the first error writing the recording met. Once there is
+// one, nothing more is written.
+func (r *CastRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// output records p after any held-back bytes, holding back an incomplete
+// UTF-8 sequence at its end.
+func (r *CastRecorder) output(p []byte) {
+	data := append(r.pending, p...)
+	cut := len(data) - incompleteTail(string(data))
+	r.pending = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		r.event("o", string(data[:cut]))
+	}
+}
+
+// event writes one [seconds, code, data] line.
+func (r *CastRecorder) event(code, data string) {
+	if r.err != nil {
+		return
+	}
+	elapsed := r.clock.Now().Sub(r.start)
+	line, err := json.Marshal([]interface{}{elapsed.Seconds(), code, data})
+	if err == nil {
+		_, err = r.w.Write(append(line, '\n'))
+	}
+	r.err = err
+}
@@ -0,0 +1,82 @@
+package gopyte
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Clipboard access through OSC 52 (ESC ] 52 ; Pc ; Pd ST). Programs on the
+// far side of an SSH connection use it to copy into, and sometimes read
+// from, the user's clipboard. The screen only stores and fetches through a
+// ClipboardProvider supplied by the embedding application, so there is no
+// clipboard access at all unless one is set.
+
+// ErrClipboardDenied is returned by providers that refuse an operation.
+var ErrClipboardDenied = errors.New("gopyte: clipboard access denied")
+
+// ClipboardProvider connects the screen to a clipboard. selection holds
+// the selection names the program sent: "c" clipboard, "p" primary, "q"
+// secondary, "s" select and "0"-"7" cut buffers, "s0" when it sent none.
+// Returning an error, such as ErrClipboardDenied, refuses the operation;
+// a refused query is not answered.
+type ClipboardProvider interface {
+	SetClipboard(selection string, data []byte) error
+	GetClipboard(selection string) ([]byte, error)
+}
+
+// ClipboardRequest is one OSC 52 operation.
+type ClipboardRequest struct {
+	Selection string // Selection names, "s0" when none were sent
+	Data      []byte // Decoded contents to store; empty clears the selection
+	Query     bool   // The program asked for the contents
+}
+
+// ClipboardListener is implemented by screens that handle OSC 52. It is
+// optional: Stream ignores OSC 52 for screens without it.
+type ClipboardListener interface {
+	Clipboard(r ClipboardRequest)
+}
+
+// SetClipboardProvider gives the screen clipboard access through p. Pass
+// nil (the default) to ignore OSC 52.
+func (s *NativeScreen) SetClipboardProvider(p ClipboardProvider) {
+	s.clipboard = p
+}
+
+// Clipboard stores data through the provider, or answers a query with the
+// provider's contents through Respond, so a ResponseGuard that does not
+// allow ResponseClipboard also blocks reads.
+func (s *NativeScreen) Clipboard(r ClipboardRequest) {
+	if s.clipboard == nil {
+		return
+	}
+	if !r.Query {
+		_ = s.clipboard.SetClipboard(r.Selection, r.Data)
+		return
+	}
+	data, err := s.clipboard.GetClipboard(r.Selection)
+	if err != nil {
+		return
+	}
+	s.Respond(ResponseClipboard, OSC+"52;"+r.Selection+";"+base64.StdEncoding.EncodeToString(data)+ST)
+}
+
+// parseClipboard decodes the payload of OSC 52. Data that is neither "?"
+// nor valid base64 clears the selection, as in xterm.
+func parseClipboard(param string) (ClipboardRequest, bool) {
+	sel, data, ok := strings.Cut(param, ";")
+	if !ok {
+		return ClipboardRequest{}, false
+	}
+	if sel == "" {
+		sel = "s0"
+	}
+	r := ClipboardRequest{Selection: sel}
+	if data == "?" {
+		r.Query = true
+	} else if b, err := base64.StdEncoding.DecodeString(data); err == nil {
+		r.Data = b
+	}
+	return r, true
+}
@@ -0,0 +1,110 @@
+package gopyte
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for everything in gopyte that timestamps or
+// waits: transcripts, players, log rotation and the blink phase of the
+// screen. Tests substitute a FakeClock to advance time deterministically.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel that receives the current time once d has
+	// elapsed, like time.After.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the wall clock.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// FakeClock is a Clock that only moves when told to. It is safe for
+// concurrent use.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock creates a clock stopped at start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that fires once Advance has moved the clock d
+// past the current time. A non-positive d fires immediately.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d and fires the waiters that are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// Waiters returns the number of After channels that have not fired yet,
+// so tests can wait until a goroutine is blocked on the clock.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlinkInterval is the time blinking text spends in each phase.
+const BlinkInterval = 500 * time.Millisecond
+
+// SetClock sets the screen's clock. Pass nil for SystemClock.
+func (s *NativeScreen) SetClock(c Clock) {
+	s.clock = c
+}
+
+// Clock returns the screen's clock.
+func (s *NativeScreen) Clock() Clock {
+	if s.clock == nil {
+		return SystemClock
+	}
+	return s.clock
+}
+
+// BlinkOn reports whether text with the Blink attribute should be drawn
+// right now. The phase flips every BlinkInterval of the screen's clock, so
+// renderers sharing a screen blink in step.
+func (s *NativeScreen) BlinkOn() bool {
+	return s.Clock().Now().UnixNano()/int64(BlinkInterval)%2 == 0
+}
@@ -0,0 +1,234 @@
+package gopyte
+
+import (
+	"container/list"
+	"maps"
+)
+
+// Deep copies of screen and parser state, used by tests and by the Player to
+// take checkpoints. Screens alias their active buffers with the saved
+// main/alternate ones, so the helpers below preserve that aliasing in the
+// copy instead of producing independent grids.
+
+func cloneRunes(grid [][]rune) [][]rune {
+	if grid == nil {
+		return nil
+	}
+	c := make([][]rune, len(grid))
+	for i, row := range grid {
+		c[i] = append([]rune(nil), row...)
+	}
+	return c
+}
+
+func cloneAttrs(grid [][]Attributes) [][]Attributes {
+	if grid == nil {
+		return nil
+	}
+	c := make([][]Attributes, len(grid))
+	for i, row := range grid {
+		c[i] = append([]Attributes(nil), row...)
+	}
+	return c
+}
+
+func cloneInts(grid [][]int) [][]int {
+	if grid == nil {
+		return nil
+	}
+	c := make([][]int, len(grid))
+	for i, row := range grid {
+		c[i] = append([]int(nil), row...)
+	}
+	return c
+}
+
+func cloneTabStops(m map[int]bool) map[int]bool {
+	if m == nil {
+		return nil
+	}
+	c := make(map[int]bool, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneHistory(l *list.List) *list.List {
+	if l == nil {
+		return nil
+	}
+	c := list.New()
+	for e := l.Front(); e != nil; e = e.Next() {
+		// History lines are never modified once pushed, so rows can be shared
+		c.PushBack(e.Value)
+	}
+	return c
+}
+
+// sameBacking reports whether two slices share their backing array.
+func sameBacking[T any](a, b []T) bool {
+	return len(a) > 0 && len(b) > 0 && &a[0] == &b[0]
+}
+
+// Clone returns a deep copy of the screen. Handlers set with the
+// Set*Handler methods are shared with the copy; a transcript, response
+// writer and region watches are not.
+func (s *NativeScreen) Clone() *NativeScreen {
+	c := *s
+	c.buffer = cloneRunes(s.buffer)
+	c.attrs = cloneAttrs(s.attrs)
+	if s.saved != nil {
+		saved := *s.saved
+		c.saved = &saved
+	}
+	c.stateStack = append([]ScreenState(nil), s.stateStack...)
+	c.tabStops = cloneTabStops(s.tabStops)
+	c.wrapped = append([]bool(nil), s.wrapped...)
+	c.repainted = append([]bool(nil), s.repainted...)
+	c.meta = cloneMeta(s.meta)
+	c.colors = maps.Clone(s.colors)
+	c.baseColors = maps.Clone(s.baseColors)
+	c.termcap = maps.Clone(s.termcap)
+	c.notifications = append([]Notification(nil), s.notifications...)
+	c.titleStack = append([]string(nil), s.titleStack...)
+	c.dirtyText = cloneRunes(s.dirtyText)
+	c.dirtyAttrs = cloneAttrs(s.dirtyAttrs)
+	c.lineBuf = nil
+	if s.selection != nil {
+		sel := *s.selection
+		c.selection = &sel
+	}
+	c.iconStack = append([]string(nil), s.iconStack...)
+	c.keyboardFlags = append([]int(nil), s.keyboardFlags...)
+	c.transcript = nil
+	c.responseWriter = nil
+	c.regionWatches = nil
+	return &c
+}
+
+// Clone returns a deep copy of the screen, including its scrollback.
+func (h *HistoryScreen) Clone() *HistoryScreen {
+	c := *h
+	c.NativeScreen = *h.NativeScreen.Clone()
+	c.history = cloneHistory(h.history)
+	c.styles = h.styles.clone()
+	c.spill = h.spill.clone()
+	c.savedBuffer = cloneRunes(h.savedBuffer)
+	c.savedAttrs = cloneAttrs(h.savedAttrs)
+	c.savedWrapped = append([]bool(nil), h.savedWrapped...)
+	c.savedMeta = cloneMeta(h.savedMeta)
+	c.bookmarks = append([]Bookmark(nil), h.bookmarks...)
+	return &c
+}
+
+// Clone returns a deep copy of the screen, including the inactive buffer.
+func (a *AlternateScreen) Clone() *AlternateScreen {
+	c := *a
+	c.HistoryScreen = a.HistoryScreen.Clone()
+
+	// The inactive buffer set is either aliased with the active one or
+	// independent; mirror whichever it is.
+	if sameBacking(a.mainBuffer, a.buffer) {
+		c.mainBuffer, c.mainAttrs = c.buffer, c.attrs
+	} else {
+		c.mainBuffer, c.mainAttrs = cloneRunes(a.mainBuffer), cloneAttrs(a.mainAttrs)
+	}
+	if sameBacking(a.altBuffer, a.buffer) {
+		c.altBuffer, c.altAttrs = c.buffer, c.attrs
+	} else {
+		c.altBuffer, c.altAttrs = cloneRunes(a.altBuffer), cloneAttrs(a.altAttrs)
+	}
+	if sameBacking(a.mainWrapped, a.wrapped) {
+		c.mainWrapped = c.wrapped
+	} else {
+		c.mainWrapped = append([]bool(nil), a.mainWrapped...)
+	}
+	if sameBacking(a.mainRepainted, a.repainted) {
+		c.mainRepainted = c.repainted
+	} else {
+		c.mainRepainted = append([]bool(nil), a.mainRepainted...)
+	}
+	if sameBacking(a.mainMeta, a.meta) {
+		c.mainMeta = c.meta
+	} else {
+		c.mainMeta = cloneMeta(a.mainMeta)
+	}
+	c.mainTabStops = cloneTabStops(a.mainTabStops)
+	c.altTabStops = cloneTabStops(a.altTabStops)
+	if a.mainHistory == a.history {
+		c.mainHistory = c.history
+	} else {
+		c.mainHistory = cloneHistory(a.mainHistory)
+	}
+	c.mainSpill = a.mainSpill.clone()
+	return &c
+}
+
+// Clone returns a deep copy of the screen, including cell widths.
+func (w *WideCharScreen) Clone() *WideCharScreen {
+	c := *w
+	c.AlternateScreen = w.AlternateScreen.Clone()
+	c.cellWidths = cloneInts(w.cellWidths)
+	if sameBacking(w.mainCellWidths, w.cellWidths) {
+		c.mainCellWidths = c.cellWidths
+	} else {
+		c.mainCellWidths = cloneInts(w.mainCellWidths)
+	}
+	if sameBacking(w.altCellWidths, w.cellWidths) {
+		c.altCellWidths = c.cellWidths
+	} else {
+		c.altCellWidths = cloneInts(w.altCellWidths)
+	}
+	return &c
+}
+
+// cloneScreen copies one of the built-in screens. ok is false for other
+// Screen implementations.
+func cloneScreen(screen Screen) (c Screen, ok bool) {
+	switch s := screen.(type) {
+	case *WideCharScreen:
+		return s.Clone(), true
+	case *AlternateScreen:
+		return s.Clone(), true
+	case *HistoryScreen:
+		return s.Clone(), true
+	case *NativeScreen:
+		return s.Clone(), true
+	}
+	return nil, false
+}
+
+// clone copies the parser state and attaches it to listener. Taps,
+// transcripts, output logs and activity monitors are not carried over.
+func (s *Stream) clone(listener Screen) *Stream {
+	c := *s
+	c.listener = listener
+	c.params = append([]int(nil), s.params...)
+	c.subParams = append([]bool(nil), s.subParams...)
+	c.oscParam = append([]byte(nil), s.oscParam...)
+	c.dcsData = append([]byte(nil), s.dcsData...)
+	c.parseErrors = nil
+	if s.customCSI != nil {
+		c.customCSI = make(map[string]CSIHandler, len(s.customCSI))
+		for k, v := range s.customCSI {
+			c.customCSI[k] = v
+		}
+	}
+	if s.customOSC != nil {
+		c.customOSC = make(map[int]OSCHandler, len(s.customOSC))
+		for k, v := range s.customOSC {
+			c.customOSC[k] = v
+		}
+	}
+	if s.watchdog != nil {
+		w := *s.watchdog
+		c.watchdog = &w
+	}
+	c.rawTap = nil
+	c.tapPending, c.tapDropped = nil, 0
+	c.transcript = nil
+	c.outputLog = nil
+	c.activity = nil
+	return &c
+}
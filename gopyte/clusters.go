@@ -0,0 +1,205 @@
+package gopyte
+
+// Grapheme clusters. A cell holds one rune in the buffer; the rest of a
+// cluster that starts with it - combining marks, the members of an emoji
+// ZWJ sequence, the second half of a flag, a skin tone, a variation
+// selector - is kept as cell metadata, so it moves, scrolls and is erased
+// along with the cell. Only WideCharScreen joins clusters. Spilled
+// scrollback keeps the first rune of each cell only.
+
+// clusterKey holds the runes after the first of a cell's cluster.
+var clusterKey = NewMetaKey[string]("cluster")
+
+const (
+	zeroWidthJoiner   = '\u200d'
+	textPresentation  = '\ufe0e' // VS15
+	emojiPresentation = '\ufe0f' // VS16
+)
+
+// Text returns the cell's text: Char followed by the rest of its
+// grapheme cluster. The right half of a wide character has none.
+func (c Cell) Text() string {
+	if c.Char == 0 {
+		return ""
+	}
+	return string(c.Char) + c.Cluster
+}
+
+// cluster returns the rest of the cluster in cell x of line y.
+func (s *NativeScreen) cluster(y, x int) string {
+	m := s.lineMeta(y)
+	if m == nil || len(m.cells) == 0 {
+		return ""
+	}
+	tail, _ := clusterKey.GetCell(m, x)
+	return tail
+}
+
+// clearClusters drops the clusters of columns from..to of line y, which
+// are being overwritten.
+func (s *NativeScreen) clearClusters(y, from, to int) {
+	m := s.lineMeta(y)
+	if m == nil || len(m.cells) == 0 {
+		return
+	}
+	for x := from; x <= to; x++ {
+		clusterKey.DeleteCell(m, x)
+	}
+}
+
+// rowClusters returns the clusters of line y by column, nil if it has
+// none.
+func (s *NativeScreen) rowClusters(y int) map[int]string {
+	return lineClusters(s.lineMeta(y))
+}
+
+// lineClusters returns the clusters held in m by column, nil if there are
+// none.
+func lineClusters(m *LineMeta) map[int]string {
+	if m == nil || len(m.cells) == 0 {
+		return nil
+	}
+	var row map[int]string
+	for x := range m.cells {
+		if tail, ok := clusterKey.GetCell(m, x); ok {
+			if row == nil {
+				row = make(map[int]string)
+			}
+			row[x] = tail
+		}
+	}
+	return row
+}
+
+// screenClusters returns the clusters of every line, for the baselines of
+// damage tracking. The maps are fresh, never written in place, so clones
+// can share them.
+func (s *NativeScreen) screenClusters() []map[int]string {
+	rows := make([]map[int]string, s.lines)
+	for y := range rows {
+		rows[y] = s.rowClusters(y)
+	}
+	return rows
+}
+
+// joinCluster adds ch to the cluster of the character drawn last, if it
+// continues it. It reports whether it did.
+func (w *WideCharScreen) joinCluster(ch rune) bool {
+	y, x, ok := w.prevCell()
+	if !ok {
+		return false
+	}
+	tail := w.cluster(y, x)
+	if !continuesCluster(w.buffer[y][x], tail, ch) {
+		return false
+	}
+	clusterKey.SetCell(w.LineMeta(y), x, tail+string(ch))
+	// A presentation selector right after a character sets its width
+	if tail == "" && ch == emojiPresentation {
+		w.widenCell(y, x)
+	} else if tail == "" && ch == textPresentation {
+		w.narrowCell(y, x)
+	}
+	return true
+}
+
+// widenCell makes the narrow character in cell x of line y, the one just
+// drawn, two cells wide, taking the cell under the cursor. A character in
+// the last column stays narrow, as it cannot be moved to the next line
+// once drawn.
+func (w *WideCharScreen) widenCell(y, x int) {
+	c := w.cursor
+	if w.cellWidths[y][x] != 1 || c.Y != y || c.X != x+1 || c.wrapPending || x+1 > w.lineEnd() {
+		return
+	}
+	w.clearCellAt(y, x+1)
+	w.buffer[y][x+1] = 0
+	w.attrs[y][x+1] = w.attrs[y][x]
+	w.cellWidths[y][x] = 2
+	w.cellWidths[y][x+1] = 0
+	w.markLink(y, x+1)
+	w.advanceCursor(1)
+}
+
+// narrowCell makes the wide character in cell x of line y, the one just
+// drawn, one cell wide, and moves the cursor back to the freed cell.
+func (w *WideCharScreen) narrowCell(y, x int) {
+	c := w.cursor
+	after := c.X == x+2 && !c.wrapPending || c.X == x+1 && c.wrapPending
+	if w.cellWidths[y][x] != 2 || x+1 >= w.columns || c.Y != y || !after {
+		return
+	}
+	w.cellWidths[y][x] = 1
+	w.buffer[y][x+1] = ' '
+	w.attrs[y][x+1] = DefaultAttributes()
+	w.cellWidths[y][x+1] = 1
+	w.cursor.X = x + 1
+	w.cursor.wrapPending = false
+}
+
+// prevCell returns the cell of the character just before the cursor,
+// following a soft wrap back to the line above. When a wrap is pending
+// the cursor is still on that character.
+func (w *WideCharScreen) prevCell() (y, x int, ok bool) {
+	y, x = w.cursor.Y, w.cursor.X
+	if w.cursor.wrapPending {
+		x++
+	}
+	if x == 0 {
+		if y == 0 || !w.IsWrapped(y-1) {
+			return 0, 0, false
+		}
+		y, x = y-1, w.columns
+	}
+	x = min(x, w.columns) - 1
+	if x > 0 && w.cellWidths[y][x] == 0 {
+		x-- // Right half of a wide character
+	}
+	if ch := w.buffer[y][x]; ch == ' ' || ch == 0 {
+		return 0, 0, false
+	}
+	return y, x, true
+}
+
+// continuesCluster reports whether ch belongs to the cluster of a cell
+// holding base and tail rather than starting a new one.
+func continuesCluster(base rune, tail string, ch rune) bool {
+	last := base
+	for _, r := range tail {
+		last = r
+	}
+	switch {
+	case last == zeroWidthJoiner: // Anything after a joiner
+		return true
+	case ch == zeroWidthJoiner, isVariationSelector(ch), isEmojiModifier(ch), isTag(ch):
+		return true
+	case isRegionalIndicator(ch):
+		// Regional indicators pair up into flags
+		n := 0
+		for _, r := range string(base) + tail {
+			if isRegionalIndicator(r) {
+				n++
+			}
+		}
+		return n%2 == 1
+	}
+	return false
+}
+
+func isVariationSelector(r rune) bool {
+	return (r >= 0xfe00 && r <= 0xfe0f) || (r >= 0xe0100 && r <= 0xe01ef)
+}
+
+// isEmojiModifier reports the Fitzpatrick skin tones.
+func isEmojiModifier(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// isTag reports the tag characters of subdivision flags.
+func isTag(r rune) bool {
+	return r >= 0xe0020 && r <= 0xe007f
+}
@@ -0,0 +1,140 @@
+package gopyte
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ColorKind says how a Color is specified.
+type ColorKind uint8
+
+const (
+	ColorDefault ColorKind = iota // The terminal's default foreground or background
+	ColorNamed                    // One of the 16 ANSI colors (SGR 30-37, 40-47)
+	ColorIndexed                  // An entry of the 256-color palette (SGR 38;5)
+	ColorDirect                   // A 24-bit color (SGR 38;2)
+)
+
+// Color is the foreground or background color of a cell. The zero value is
+// the default color. Colors are comparable.
+type Color struct {
+	kind  ColorKind
+	index uint8
+	rgb   RGB
+}
+
+// DefaultColor returns the default color.
+func DefaultColor() Color {
+	return Color{}
+}
+
+// NamedColor returns ANSI color n, 0-15: black, red, green, brown, blue,
+// magenta, cyan, white and their bright variants.
+func NamedColor(n int) Color {
+	return Color{kind: ColorNamed, index: uint8(n & 15)}
+}
+
+// IndexedColor returns entry n of the 256-color palette.
+func IndexedColor(n int) Color {
+	return Color{kind: ColorIndexed, index: uint8(n)}
+}
+
+// DirectColor returns the 24-bit color c.
+func DirectColor(c RGB) Color {
+	return Color{kind: ColorDirect, rgb: c}
+}
+
+// Kind returns how the color is specified.
+func (c Color) Kind() ColorKind {
+	return c.kind
+}
+
+// IsDefault reports whether c is the default color.
+func (c Color) IsDefault() bool {
+	return c.kind == ColorDefault
+}
+
+// Index returns the palette index of a named or indexed color, or -1.
+func (c Color) Index() int {
+	if c.kind == ColorNamed || c.kind == ColorIndexed {
+		return int(c.index)
+	}
+	return -1
+}
+
+// RGB returns the color's value, resolving named and indexed colors through
+// xterm's palette. ok is false for the default color, whose value only the
+// renderer knows; use NativeScreen.Color for the colors a program set.
+func (c Color) RGB() (rgb RGB, ok bool) {
+	switch c.kind {
+	case ColorNamed, ColorIndexed:
+		p := paletteRGB(int(c.index))
+		return RGB{R: p[0], G: p[1], B: p[2]}, true
+	case ColorDirect:
+		return c.rgb, true
+	}
+	return RGB{}, false
+}
+
+// String returns the color in the string form Attributes used before
+// colors were typed: "default", a name such as "red" or "brightblue",
+// "colorN" or "#rrggbb". ParseColor reads it back.
+func (c Color) String() string {
+	switch c.kind {
+	case ColorNamed:
+		return ansiColorNames[c.index]
+	case ColorIndexed:
+		return fmt.Sprintf("color%d", c.index)
+	case ColorDirect:
+		return c.rgb.Hex()
+	}
+	return "default"
+}
+
+// MarshalText encodes the color in its string form, so that attributes
+// can be written as JSON (see snapshot.go).
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText parses the string form of a color.
+func (c *Color) UnmarshalText(text []byte) error {
+	v, ok := ParseColor(string(text))
+	if !ok {
+		return fmt.Errorf("gopyte: invalid color %q", text)
+	}
+	*c = v
+	return nil
+}
+
+// ParseColor parses the string form of a color. Besides what String
+// returns it accepts "" for the default color, "yellow" for brown and hex
+// colors without the '#'.
+func ParseColor(s string) (Color, bool) {
+	if s == "" || s == "default" {
+		return Color{}, true
+	}
+	if n, ok := strings.CutPrefix(s, "color"); ok {
+		i, err := strconv.Atoi(n)
+		if err != nil || i < 0 || i > 255 {
+			return Color{}, false
+		}
+		return IndexedColor(i), true
+	}
+	name := strings.Replace(s, "yellow", "brown", 1)
+	for i, n := range ansiColorNames {
+		if n == name {
+			return NamedColor(i), true
+		}
+	}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return Color{}, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, false
+	}
+	return DirectColor(RGB{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}), true
+}
@@ -0,0 +1,179 @@
+package gopyte
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ColorProfile is the color depth an exporter writes for. Captures taken
+// from a truecolor session can be downconverted so they replay on a
+// limited terminal or fit systems that only accept basic ANSI.
+type ColorProfile int
+
+const (
+	ProfileTrueColor ColorProfile = iota // 24-bit RGB, colors kept as they are
+	Profile256                           // xterm 256-color palette
+	Profile16                            // 8 ANSI colors and their bright variants
+	ProfileMono                          // No color at all
+)
+
+var colorProfileNames = map[ColorProfile]string{
+	ProfileTrueColor: "truecolor",
+	Profile256:       "256",
+	Profile16:        "16",
+	ProfileMono:      "mono",
+}
+
+func (p ColorProfile) String() string {
+	if name, ok := colorProfileNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ansiColorNames are the 16 base colors in palette order, as Color.String
+// spells them.
+var ansiColorNames = [16]string{
+	"black", "red", "green", "brown", "blue", "magenta", "cyan", "white",
+	"brightblack", "brightred", "brightgreen", "brightbrown",
+	"brightblue", "brightmagenta", "brightcyan", "brightwhite",
+}
+
+// ansiColorRGB is xterm's rendering of the 16 base colors.
+var ansiColorRGB = [16][3]uint8{
+	{0x00, 0x00, 0x00}, {0xcd, 0x00, 0x00}, {0x00, 0xcd, 0x00}, {0xcd, 0xcd, 0x00},
+	{0x00, 0x00, 0xee}, {0xcd, 0x00, 0xcd}, {0x00, 0xcd, 0xcd}, {0xe5, 0xe5, 0xe5},
+	{0x7f, 0x7f, 0x7f}, {0xff, 0x00, 0x00}, {0x00, 0xff, 0x00}, {0xff, 0xff, 0x00},
+	{0x5c, 0x5c, 0xff}, {0xff, 0x00, 0xff}, {0x00, 0xff, 0xff}, {0xff, 0xff, 0xff},
+}
+
+// cubeLevels are the channel values of the 6x6x6 cube (colors 16-231).
+var cubeLevels = [6]uint8{0x00, 0x5f, 0x87, 0xaf, 0xd7, 0xff}
+
+// Convert returns c reduced to the profile: a named color for Profile16,
+// a named or indexed one for Profile256 and the default color for
+// ProfileMono. Colors the profile can already show are returned unchanged.
+func (p ColorProfile) Convert(c Color) Color {
+	if c.IsDefault() {
+		return c
+	}
+	switch p {
+	case ProfileMono:
+		return DefaultColor()
+	case Profile256:
+		if c.kind == ColorDirect {
+			return IndexedColor(nearest256(c.rgb.array()))
+		}
+	case Profile16:
+		switch {
+		case c.kind == ColorIndexed && c.index < 16:
+			return NamedColor(int(c.index))
+		case c.kind != ColorNamed:
+			rgb, _ := c.RGB()
+			return NamedColor(nearest16(rgb.array()))
+		}
+	}
+	return c
+}
+
+// Attrs returns a with its colors converted to the profile.
+func (p ColorProfile) Attrs(a Attributes) Attributes {
+	a.Fg = p.Convert(a.Fg)
+	a.Bg = p.Convert(a.Bg)
+	a.UnderlineColor = p.Convert(a.UnderlineColor)
+	return a
+}
+
+// SGR returns the SGR parameters that select c in the profile, for
+// example "31", "91", "38;5;208" or "38;2;255;135;0" ("48..." and
+// "4x"/"10x" when background is true). It returns "" for the default
+// color and for ProfileMono.
+func (p ColorProfile) SGR(c Color, background bool) string {
+	c = p.Convert(c)
+	base, ext := 30, 38
+	if background {
+		base, ext = 40, 48
+	}
+	switch n := c.Index(); {
+	case c.IsDefault():
+		return ""
+	case c.kind == ColorDirect:
+		return fmt.Sprintf("%d;2;%d;%d;%d", ext, c.rgb.R, c.rgb.G, c.rgb.B)
+	case n < 8:
+		return strconv.Itoa(base + n)
+	case n < 16:
+		return strconv.Itoa(base + 60 + n - 8)
+	default:
+		return fmt.Sprintf("%d;5;%d", ext, n)
+	}
+}
+
+// paletteRGB returns the xterm RGB value of 256-color palette entry n.
+func paletteRGB(n int) [3]uint8 {
+	switch {
+	case n < 16:
+		return ansiColorRGB[n]
+	case n < 232:
+		n -= 16
+		return [3]uint8{cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]}
+	default:
+		v := uint8(8 + (n-232)*10)
+		return [3]uint8{v, v, v}
+	}
+}
+
+// nearest256 picks the closest entry among the color cube and the gray
+// ramp. The 16 base colors are skipped because terminals theme them, so
+// their actual appearance is unknown.
+func nearest256(rgb [3]uint8) int {
+	var idx [3]int
+	for i, c := range rgb {
+		idx[i] = nearestLevel(c)
+	}
+	cube := 16 + 36*idx[0] + 6*idx[1] + idx[2]
+
+	avg := (int(rgb[0]) + int(rgb[1]) + int(rgb[2])) / 3
+	gray := 232 + min(max((avg-3)/10, 0), 23)
+
+	if colorDistance(rgb, paletteRGB(gray)) < colorDistance(rgb, paletteRGB(cube)) {
+		return gray
+	}
+	return cube
+}
+
+func nearestLevel(c uint8) int {
+	best := 0
+	for i, l := range cubeLevels {
+		if absInt(int(c)-int(l)) < absInt(int(c)-int(cubeLevels[best])) {
+			best = i
+		}
+	}
+	return best
+}
+
+func nearest16(rgb [3]uint8) int {
+	best, bestDist := 0, -1
+	for i, c := range ansiColorRGB {
+		if d := colorDistance(rgb, c); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// colorDistance is the "redmean" approximation of perceived difference,
+// which weights the channels by how sensitive the eye is to them.
+func colorDistance(a, b [3]uint8) int {
+	rmean := (int(a[0]) + int(b[0])) / 2
+	dr := int(a[0]) - int(b[0])
+	dg := int(a[1]) - int(b[1])
+	db := int(a[2]) - int(b[2])
+	return ((512+rmean)*dr*dr)>>8 + 4*dg*dg + ((767-rmean)*db*db)>>8
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
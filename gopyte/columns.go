@@ -0,0 +1,129 @@
+package gopyte
+
+// ColumnEditor is implemented by screens that support the DEC column
+// editing functions DECIC (CSI Pn ' }) and DECDC (CSI Pn ' ~). Like
+// ITermListener it is optional.
+type ColumnEditor interface {
+	InsertColumns(count int)
+	DeleteColumns(count int)
+}
+
+// InsertColumns inserts count blank columns at the cursor column, shifting
+// the columns to its right towards the right margin. Columns pushed past
+// the margin are lost. The cursor does not move.
+func (s *NativeScreen) InsertColumns(count int) {
+	top, bottom, _, right, ok := s.columnEditBounds()
+	if !ok {
+		return
+	}
+	for y := top; y <= bottom; y++ {
+		x, end := s.cursor.X, min(right+1, len(s.buffer[y]), len(s.attrs[y]))
+		if x >= end {
+			continue
+		}
+		shiftCellsRight(s.buffer[y][x:end], count, ' ')
+		shiftCellsRight(s.attrs[y][x:end], count, DefaultAttributes())
+		s.lineMeta(y).shiftCells(x, count, end)
+	}
+}
+
+// DeleteColumns deletes count columns at the cursor column, shifting the
+// columns to its right in and filling blanks at the right margin. The
+// cursor does not move.
+func (s *NativeScreen) DeleteColumns(count int) {
+	top, bottom, _, right, ok := s.columnEditBounds()
+	if !ok {
+		return
+	}
+	for y := top; y <= bottom; y++ {
+		x, end := s.cursor.X, min(right+1, len(s.buffer[y]), len(s.attrs[y]))
+		if x >= end {
+			continue
+		}
+		shiftCellsLeft(s.buffer[y][x:end], count, ' ')
+		shiftCellsLeft(s.attrs[y][x:end], count, DefaultAttributes())
+		s.lineMeta(y).shiftCells(x, -count, end)
+	}
+}
+
+// columnEditBounds returns the inclusive area DECIC and DECDC work in:
+// the whole height of the screen between the left and right margins. ok
+// is false when the cursor is outside it.
+func (s *NativeScreen) columnEditBounds() (top, bottom, left, right int, ok bool) {
+	top, bottom = 0, s.lines-1
+	left, right, _ = s.LeftRightMargins()
+	ok = s.cursor.X >= left && s.cursor.X <= right && s.cursor.Y >= top && s.cursor.Y <= bottom
+	return
+}
+
+// InsertColumns inserts blank columns like NativeScreen.InsertColumns,
+// blanking wide characters split by the insertion point or pushed half
+// past the right margin.
+func (w *WideCharScreen) InsertColumns(count int) {
+	w.AlternateScreen.InsertColumns(count)
+	w.editWidths(func(widths []int) { shiftCellsRight(widths, count, 1) })
+}
+
+// DeleteColumns deletes columns like NativeScreen.DeleteColumns, blanking
+// wide characters left with only one half.
+func (w *WideCharScreen) DeleteColumns(count int) {
+	w.AlternateScreen.DeleteColumns(count)
+	w.editWidths(func(widths []int) { shiftCellsLeft(widths, count, 1) })
+}
+
+// editWidths applies a column edit to the cell widths of each row in the
+// edit area and then repairs any wide characters it broke.
+func (w *WideCharScreen) editWidths(edit func(widths []int)) {
+	top, bottom, left, right, ok := w.columnEditBounds()
+	if !ok {
+		return
+	}
+	for y := top; y <= bottom && y < len(w.cellWidths); y++ {
+		x, end := w.cursor.X, min(right+1, len(w.cellWidths[y]))
+		if x >= end {
+			continue
+		}
+		edit(w.cellWidths[y][x:end])
+		w.repairWide(y, max(left, x-1), end-1)
+	}
+}
+
+// repairWide blanks the halves of wide characters in row y between x0 and
+// x1 whose other half is missing.
+func (s *NativeScreen) repairWide(y, x0, x1 int) {
+	widths := s.cellWidths[y]
+	for x := x0; x <= x1 && x < len(widths); x++ {
+		broken := false
+		switch widths[x] {
+		case 2:
+			broken = x+1 >= len(widths) || x+1 > x1 || widths[x+1] != 0
+		case 0:
+			broken = x == 0 || widths[x-1] != 2
+		}
+		if broken {
+			s.buffer[y][x] = ' '
+			s.attrs[y][x] = DefaultAttributes()
+			widths[x] = 1
+		}
+	}
+}
+
+// shiftCellsRight moves cells right by n, filling the vacated cells with
+// blank.
+func shiftCellsRight[T any](cells []T, n int, blank T) {
+	n = min(n, len(cells))
+	copy(cells[n:], cells[:len(cells)-n])
+	for i := 0; i < n; i++ {
+		cells[i] = blank
+	}
+}
+
+// shiftCellsLeft moves cells left by n, filling the vacated cells with
+// blank.
+func shiftCellsLeft[T any](cells []T, n int, blank T) {
+	n = min(n, len(cells))
+	copy(cells, cells[n:])
+	for i := len(cells) - n; i < len(cells); i++ {
+		cells[i] = blank
+	}
+}
@@ -0,0 +1,158 @@
+package gopyte
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CommandOutput is one command of an interactive session: the prompt line
+// that introduced it and the lines printed before the next prompt.
+type CommandOutput struct {
+	Prompt  string   // Text matched by the prompt pattern
+	Command string   // Rest of the prompt line
+	Output  []string // Lines up to the next prompt
+}
+
+// SplitCommands segments session lines (scrollback followed by the
+// display, say) into commands. A line starts a new command when prompt
+// matches at its start, e.g. `^[\w.-]+[>#]` for network devices. Lines
+// before the first prompt are dropped; trailing blank lines of each output
+// are trimmed.
+func SplitCommands(lines []string, prompt *regexp.Regexp) []CommandOutput {
+	var cmds []CommandOutput
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \x00")
+		if loc := prompt.FindStringIndex(line); loc != nil && loc[0] == 0 {
+			cmds = append(cmds, CommandOutput{
+				Prompt:  line[:loc[1]],
+				Command: strings.TrimSpace(line[loc[1]:]),
+			})
+			continue
+		}
+		if len(cmds) > 0 {
+			c := &cmds[len(cmds)-1]
+			c.Output = append(c.Output, line)
+		}
+	}
+	for i := range cmds {
+		cmds[i].Output = trimBlankTail(cmds[i].Output)
+	}
+	return cmds
+}
+
+// Scraper turns a whole session into structured data: it splits the
+// session into commands and parses each command's output with the first
+// template registered for it, like an ntc-templates index.
+type Scraper struct {
+	prompt  *regexp.Regexp
+	entries []scraperEntry
+}
+
+type scraperEntry struct {
+	command  *regexp.Regexp
+	template *Template
+}
+
+// ScrapeResult holds the records parsed from one command's output.
+type ScrapeResult struct {
+	Command string
+	Records []Record
+}
+
+// NewScraper creates a Scraper for sessions whose prompt matches prompt.
+func NewScraper(prompt *regexp.Regexp) *Scraper {
+	return &Scraper{prompt: prompt}
+}
+
+// Add registers t for commands matching command. Abbreviations are the
+// caller's business: `^sh(ow)?\s+ip\s+int(erface)?\s+br(ief)?` is typical.
+func (s *Scraper) Add(command *regexp.Regexp, t *Template) {
+	s.entries = append(s.entries, scraperEntry{command: command, template: t})
+}
+
+// Scrape segments lines and parses every command that has a template.
+// Commands without one are skipped.
+func (s *Scraper) Scrape(lines []string) ([]ScrapeResult, error) {
+	var results []ScrapeResult
+	for _, cmd := range SplitCommands(lines, s.prompt) {
+		for _, e := range s.entries {
+			if !e.command.MatchString(cmd.Command) {
+				continue
+			}
+			records, err := e.template.Parse(cmd.Output)
+			if err != nil {
+				return results, err
+			}
+			results = append(results, ScrapeResult{Command: cmd.Command, Records: records})
+			break
+		}
+	}
+	return results, nil
+}
+
+// CleanOutput returns the output of command from a captured region: the
+// echoed command line at the top and the prompt at the bottom are
+// stripped, along with surrounding blank lines. prompt is the same pattern
+// given to SplitCommands; it may be nil when the region holds no prompts.
+func CleanOutput(lines []string, command string, prompt *regexp.Regexp) []string {
+	return StripPrompt(StripEcho(lines, command, prompt), prompt)
+}
+
+// StripEcho removes the echo of command from the start of lines, with or
+// without the prompt in front of it. An echo that the terminal wrapped
+// over several lines is removed whole. Lines are returned unchanged when
+// they do not start with the echo.
+func StripEcho(lines []string, command string, prompt *regexp.Regexp) []string {
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	want := squeezeSpace(command)
+	if want == "" {
+		return lines[i:]
+	}
+
+	// Wrapped lines lose the space at the wrap point to trailing-blank
+	// trimming, so compare without whitespace
+	echoed := ""
+	for j := i; j < len(lines); j++ {
+		line := strings.TrimRight(lines[j], " \x00")
+		if j == i && prompt != nil {
+			if loc := prompt.FindStringIndex(line); loc != nil && loc[0] == 0 {
+				line = line[loc[1]:]
+			}
+		}
+		echoed += squeezeSpace(line)
+		if echoed == want {
+			return lines[j+1:]
+		}
+		if line == "" || !strings.HasPrefix(want, echoed) {
+			break
+		}
+	}
+	return lines[i:]
+}
+
+// StripPrompt removes trailing blank lines and the prompt line after them,
+// if prompt matches at its start.
+func StripPrompt(lines []string, prompt *regexp.Regexp) []string {
+	lines = trimBlankTail(lines)
+	if prompt != nil && len(lines) > 0 {
+		last := strings.TrimRight(lines[len(lines)-1], " \x00")
+		if loc := prompt.FindStringIndex(last); loc != nil && loc[0] == 0 {
+			lines = trimBlankTail(lines[:len(lines)-1])
+		}
+	}
+	return lines
+}
+
+func trimBlankTail(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func squeezeSpace(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
@@ -0,0 +1,284 @@
+package gopyte
+
+import (
+	"container/list"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// Structural comparison of screens. Equal reports whether two screens are in
+// the same state - text, attributes, grapheme clusters, cursor, modes and,
+// for the richer screen types, scrollback, alternate buffer and cell widths. DiffString
+// describes the differences, one per line, receiver first:
+//
+//	line 2: "foo" != "fob"
+//	attrs at (3, 0): {Fg:red ...} != {Fg:default ...}
+//	cursor: (4, 2) != (0, 0)
+//
+// Only the first differing cell of each line is reported.
+
+// Equal reports whether s and other hold the same state.
+func (s *NativeScreen) Equal(other *NativeScreen) bool {
+	return len(s.diff(other)) == 0
+}
+
+// DiffString describes how s differs from other. It is empty when the
+// screens are equal.
+func (s *NativeScreen) DiffString(other *NativeScreen) string {
+	return strings.Join(s.diff(other), "\n")
+}
+
+// Equal reports whether h and other hold the same state, including
+// scrollback.
+func (h *HistoryScreen) Equal(other *HistoryScreen) bool {
+	return len(h.diff(other)) == 0
+}
+
+// DiffString describes how h differs from other.
+func (h *HistoryScreen) DiffString(other *HistoryScreen) string {
+	return strings.Join(h.diff(other), "\n")
+}
+
+// Equal reports whether a and other hold the same state, including the
+// inactive buffer.
+func (a *AlternateScreen) Equal(other *AlternateScreen) bool {
+	return len(a.diff(other)) == 0
+}
+
+// DiffString describes how a differs from other.
+func (a *AlternateScreen) DiffString(other *AlternateScreen) string {
+	return strings.Join(a.diff(other), "\n")
+}
+
+// Equal reports whether w and other hold the same state, including cell
+// widths.
+func (w *WideCharScreen) Equal(other *WideCharScreen) bool {
+	return len(w.diff(other)) == 0
+}
+
+// DiffString describes how w differs from other.
+func (w *WideCharScreen) DiffString(other *WideCharScreen) string {
+	return strings.Join(w.diff(other), "\n")
+}
+
+func (s *NativeScreen) diff(o *NativeScreen) []string {
+	var d []string
+	if s.columns != o.columns || s.lines != o.lines {
+		// Nothing else lines up
+		return append(d, fmt.Sprintf("size: %dx%d != %dx%d", s.columns, s.lines, o.columns, o.lines))
+	}
+
+	d = append(d, diffGrid("", s.buffer, o.buffer, s.attrs, o.attrs)...)
+	d = append(d, diffClusters("", s.meta, o.meta, s.lines)...)
+	for y := 0; y < s.lines; y++ {
+		if s.IsWrapped(y) != o.IsWrapped(y) {
+			d = append(d, fmt.Sprintf("wrapped %d: %v != %v", y, s.IsWrapped(y), o.IsWrapped(y)))
+		}
+	}
+
+	d = append(d, diffCursor("cursor", s.cursor, o.cursor)...)
+	switch {
+	case s.saved == nil && o.saved != nil:
+		d = append(d, "saved cursor: none != set")
+	case s.saved != nil && o.saved == nil:
+		d = append(d, "saved cursor: set != none")
+	case s.saved != nil:
+		d = append(d, diffCursor("saved cursor", *s.saved, *o.saved)...)
+	}
+
+	d = appendIfDiff(d, "cursor style", s.cursorStyle, o.cursorStyle)
+	d = appendIfDiff(d, "title", s.title, o.title)
+	d = appendIfDiff(d, "icon name", s.iconName, o.iconName)
+	d = appendIfDiff(d, "window", s.window, o.window)
+	d = appendIfDiff(d, "colors", fmt.Sprint(s.colors), fmt.Sprint(o.colors))
+	d = appendIfDiff(d, "autowrap", s.autoWrap, o.autoWrap)
+	d = appendIfDiff(d, "origin mode", s.originMode, o.originMode)
+	d = appendIfDiff(d, "left/right margin mode", s.lrMarginMode, o.lrMarginMode)
+	d = appendIfDiff(d, "left/right margins",
+		[2]int{s.leftMargin, s.rightMargin}, [2]int{o.leftMargin, o.rightMargin})
+	d = appendIfDiff(d, "newline mode", s.newlineMode, o.newlineMode)
+	d = appendIfDiff(d, "conpty mode", s.conptyMode, o.conptyMode)
+	d = appendIfDiff(d, "win32 input mode", s.win32InputMode, o.win32InputMode)
+	d = appendIfDiff(d, "mouse protocol", s.mouse, o.mouse)
+	d = appendIfDiff(d, "bracketed paste", s.bracketedPaste, o.bracketedPaste)
+	d = appendIfDiff(d, "application cursor keys", s.appCursorKeys, o.appCursorKeys)
+	d = appendIfDiff(d, "application keypad", s.appKeypad, o.appKeypad)
+	d = appendIfDiff(d, "keyboard flags", fmt.Sprint(s.keyboardFlags), fmt.Sprint(o.keyboardFlags))
+	d = appendIfDiff(d, "focus reporting", s.focusReporting, o.focusReporting)
+	d = appendIfDiff(d, "selection", fmt.Sprint(s.GetSelection()), fmt.Sprint(o.GetSelection()))
+	if a, b := tabStopList(s.tabStops), tabStopList(o.tabStops); a != b {
+		d = append(d, fmt.Sprintf("tab stops: [%s] != [%s]", a, b))
+	}
+	return d
+}
+
+func (h *HistoryScreen) diff(o *HistoryScreen) []string {
+	d := h.NativeScreen.diff(&o.NativeScreen)
+	d = append(d, diffHistory(h.history, o.history, &h.styles, &o.styles)...)
+	d = appendIfDiff(d, "spilled history lines", h.spill.len(), o.spill.len())
+	d = appendIfDiff(d, "history position", h.historyPos, o.historyPos)
+	d = appendIfDiff(d, "line base", h.lineBase, o.lineBase)
+	d = appendIfDiff(d, "bookmarks", fmt.Sprint(h.Bookmarks()), fmt.Sprint(o.Bookmarks()))
+	return d
+}
+
+func (a *AlternateScreen) diff(o *AlternateScreen) []string {
+	d := a.HistoryScreen.diff(o.HistoryScreen)
+	d = appendIfDiff(d, "alternate screen", a.usingAlternate, o.usingAlternate)
+	if a.usingAlternate && o.usingAlternate {
+		// The main screen is parked; compare it too
+		d = append(d, diffGrid("main ", a.mainBuffer, o.mainBuffer, a.mainAttrs, o.mainAttrs)...)
+		d = append(d, diffClusters("main ", a.mainMeta, o.mainMeta, a.lines)...)
+		d = append(d, diffCursor("main cursor", a.mainCursor, o.mainCursor)...)
+		d = append(d, diffHistory(a.mainHistory, o.mainHistory, &a.styles, &o.styles)...)
+		d = appendIfDiff(d, "main spilled history lines", a.mainSpill.len(), o.mainSpill.len())
+	}
+	return d
+}
+
+func (w *WideCharScreen) diff(o *WideCharScreen) []string {
+	d := w.AlternateScreen.diff(o.AlternateScreen)
+	if w.columns != o.columns || w.lines != o.lines {
+		return d
+	}
+	for y := 0; y < w.lines && y < len(w.cellWidths) && y < len(o.cellWidths); y++ {
+		for x := 0; x < w.columns && x < len(w.cellWidths[y]) && x < len(o.cellWidths[y]); x++ {
+			if w.cellWidths[y][x] != o.cellWidths[y][x] {
+				d = append(d, fmt.Sprintf("width at (%d, %d): %d != %d", x, y, w.cellWidths[y][x], o.cellWidths[y][x]))
+				break
+			}
+		}
+	}
+	return d
+}
+
+// diffGrid compares text and attributes row by row. prefix names the
+// buffer ("" for the visible one).
+func diffGrid(prefix string, aText, bText [][]rune, aAttrs, bAttrs [][]Attributes) []string {
+	var d []string
+	if len(aText) != len(bText) {
+		return append(d, fmt.Sprintf("%slines: %d != %d", prefix, len(aText), len(bText)))
+	}
+	for y := range aText {
+		if a, b := string(aText[y]), string(bText[y]); a != b {
+			ta, tb := strings.TrimRight(a, " \x00"), strings.TrimRight(b, " \x00")
+			if ta == tb {
+				ta, tb = a, b // Only the padding differs; show it
+			}
+			d = append(d, fmt.Sprintf("%sline %d: %q != %q", prefix, y, ta, tb))
+		}
+		if y >= len(aAttrs) || y >= len(bAttrs) {
+			continue
+		}
+		for x := 0; x < len(aAttrs[y]) && x < len(bAttrs[y]); x++ {
+			if aAttrs[y][x] != bAttrs[y][x] {
+				d = append(d, fmt.Sprintf("%sattrs at (%d, %d): %+v != %+v", prefix, x, y, aAttrs[y][x], bAttrs[y][x]))
+				break
+			}
+		}
+	}
+	return d
+}
+
+// diffClusters compares the grapheme clusters of the first n lines,
+// reporting the first differing cell of each line.
+func diffClusters(prefix string, a, b []*LineMeta, n int) []string {
+	var d []string
+	for y := 0; y < n; y++ {
+		var ma, mb *LineMeta
+		if y < len(a) {
+			ma = a[y]
+		}
+		if y < len(b) {
+			mb = b[y]
+		}
+		if ca, cb := lineClusters(ma), lineClusters(mb); !maps.Equal(ca, cb) {
+			x := firstClusterDiff(ca, cb)
+			d = append(d, fmt.Sprintf("%sclusters at (%d, %d): %q != %q", prefix, x, y, ca[x], cb[x]))
+		}
+	}
+	return d
+}
+
+// firstClusterDiff returns the first column whose cluster differs.
+func firstClusterDiff(a, b map[int]string) int {
+	first := -1
+	for _, m := range []map[int]string{a, b} {
+		for x := range m {
+			if a[x] != b[x] && (first < 0 || x < first) {
+				first = x
+			}
+		}
+	}
+	return first
+}
+
+func diffCursor(name string, a, b Cursor) []string {
+	var d []string
+	if a.X != b.X || a.Y != b.Y {
+		d = append(d, fmt.Sprintf("%s: (%d, %d) != (%d, %d)", name, a.X, a.Y, b.X, b.Y))
+	}
+	d = appendIfDiff(d, name+" attrs", a.Attrs, b.Attrs)
+	d = appendIfDiff(d, name+" hidden", a.Hidden, b.Hidden)
+	return d
+}
+
+func diffHistory(a, b *list.List, sa, sb *styleTable) []string {
+	na, nb := historyLen(a), historyLen(b)
+	if na != nb {
+		return []string{fmt.Sprintf("history lines: %d != %d", na, nb)}
+	}
+	if na == 0 {
+		return nil
+	}
+	var d []string
+	i := 0
+	for ea, eb := a.Front(), b.Front(); ea != nil && eb != nil; ea, eb = ea.Next(), eb.Next() {
+		la, lb := ea.Value.(HistoryLine), eb.Value.(HistoryLine)
+		if string(la.Chars) != string(lb.Chars) {
+			d = append(d, fmt.Sprintf("history line %d: %q != %q", i,
+				strings.TrimRight(string(la.Chars), " "), strings.TrimRight(string(lb.Chars), " ")))
+		} else if la.Wrapped != lb.Wrapped {
+			d = append(d, fmt.Sprintf("history line %d wrapped: %v != %v", i, la.Wrapped, lb.Wrapped))
+		} else if ca, cb := lineClusters(la.Meta), lineClusters(lb.Meta); !maps.Equal(ca, cb) {
+			x := firstClusterDiff(ca, cb)
+			d = append(d, fmt.Sprintf("history line %d clusters at %d: %q != %q", i, x, ca[x], cb[x]))
+		} else {
+			for x := range la.Chars {
+				if aa, ab := sa.at(la.styles, x), sb.at(lb.styles, x); aa != ab {
+					d = append(d, fmt.Sprintf("history line %d attrs at %d: %+v != %+v", i, x, aa, ab))
+					break
+				}
+			}
+		}
+		i++
+	}
+	return d
+}
+
+func historyLen(l *list.List) int {
+	if l == nil {
+		return 0
+	}
+	return l.Len()
+}
+
+func tabStopList(m map[int]bool) string {
+	var cols []int
+	for col, set := range m {
+		if set {
+			cols = append(cols, col)
+		}
+	}
+	sort.Ints(cols)
+	return strings.Trim(fmt.Sprint(cols), "[]")
+}
+
+func appendIfDiff[T comparable](d []string, name string, a, b T) []string {
+	if a != b {
+		d = append(d, fmt.Sprintf("%s: %+v != %+v", name, a, b))
+	}
+	return d
+}
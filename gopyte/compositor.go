@@ -0,0 +1,335 @@
+package gopyte
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+// Compositor tiles several screens into one larger virtual screen, each in
+// a titled box, and routes input to the focused one - the core of a
+// tmux-like multiplexer:
+//
+//	c := gopyte.NewCompositor(160, 48)
+//	c.Add("core1", screen1, pty1)
+//	c.Add("core2", screen2, pty2)
+//	c.Layout() // Grid tiles; screens that can resize are fitted to them
+//	...
+//	draw(c.Cells())        // After feeding any pane's stream
+//	c.Write(keystrokes)    // Goes to the focused pane
+//	c.FocusAt(mouseX, mouseY)
+//
+// The compositor only reads the screens; feeding them stays with the
+// caller, as does locking if they are fed from other goroutines.
+type Compositor struct {
+	columns, lines int
+	panes          []*Pane
+	focus          int
+}
+
+// Pane is a screen placed in a Compositor.
+type Pane struct {
+	Title  string
+	Screen Screen
+	Input  io.Writer // Receives the input routed to the pane; may be nil
+	Tile   Region    // Placement including the border, set by Layout
+}
+
+// ErrNoPane is returned by Compositor.Write when there is no pane to
+// route input to.
+var ErrNoPane = errors.New("gopyte: no focused pane")
+
+// Border attributes for unfocused and focused panes.
+var (
+	paneBorder        = DefaultAttributes()
+	paneBorderFocused = Attributes{Fg: NamedColor(6), Bold: true}
+)
+
+// NewCompositor creates an empty compositor of the given size.
+func NewCompositor(columns, lines int) *Compositor {
+	return &Compositor{columns: columns, lines: lines}
+}
+
+// Add appends a pane and returns it. Call Layout to place it.
+func (c *Compositor) Add(title string, screen Screen, input io.Writer) *Pane {
+	p := &Pane{Title: title, Screen: screen, Input: input}
+	c.panes = append(c.panes, p)
+	return p
+}
+
+// Remove drops pane p. Call Layout to reclaim its space. If p had the
+// focus, it passes to the pane after it, or to the last pane.
+func (c *Compositor) Remove(p *Pane) {
+	for i, q := range c.panes {
+		if q == p {
+			c.panes = append(c.panes[:i], c.panes[i+1:]...)
+			if i < c.focus {
+				c.focus-- // Keep the focused pane focused
+			} else if c.focus >= len(c.panes) {
+				c.focus = max(len(c.panes)-1, 0)
+			}
+			return
+		}
+	}
+}
+
+// Panes returns the panes in order.
+func (c *Compositor) Panes() []*Pane {
+	return c.panes
+}
+
+// Resize changes the size of the virtual screen and lays the panes out
+// again.
+func (c *Compositor) Resize(columns, lines int) {
+	c.columns, c.lines = columns, lines
+	c.Layout()
+}
+
+// Layout arranges the panes in a grid of near-equal tiles, filled row by
+// row, and resizes each screen that has a Resize method to the inside of
+// its tile.
+func (c *Compositor) Layout() {
+	n := len(c.panes)
+	if n == 0 {
+		return
+	}
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	rows := (n + cols - 1) / cols
+	for i, p := range c.panes {
+		row, col := i/cols, i%cols
+		inRow := cols
+		if row == rows-1 {
+			inRow = n - row*cols // The last row may be short; stretch it
+		}
+		p.Tile = Region{
+			Top:    row * c.lines / rows,
+			Bottom: (row+1)*c.lines/rows - 1,
+			Left:   col * c.columns / inRow,
+			Right:  (col+1)*c.columns/inRow - 1,
+		}
+		if r, ok := p.Screen.(interface{ Resize(columns, lines int) }); ok {
+			if w, h := p.InnerSize(); w > 0 && h > 0 {
+				r.Resize(w, h)
+			}
+		}
+	}
+}
+
+// InnerSize returns the columns and lines inside the pane's border.
+func (p *Pane) InnerSize() (columns, lines int) {
+	return p.Tile.Right - p.Tile.Left - 1, p.Tile.Bottom - p.Tile.Top - 1
+}
+
+// Focus makes pane i the input target.
+func (c *Compositor) Focus(i int) {
+	if i >= 0 && i < len(c.panes) {
+		c.focus = i
+	}
+}
+
+// FocusNext moves the focus to the next pane, wrapping around.
+func (c *Compositor) FocusNext() {
+	if len(c.panes) > 0 {
+		c.focus = (c.focus + 1) % len(c.panes)
+	}
+}
+
+// Focused returns the focused pane, or nil when there are none.
+func (c *Compositor) Focused() *Pane {
+	if c.focus < len(c.panes) {
+		return c.panes[c.focus]
+	}
+	return nil
+}
+
+// PaneAt returns the pane whose tile contains (x, y) and the position
+// relative to the pane's screen, which is outside it on the border.
+func (c *Compositor) PaneAt(x, y int) (p *Pane, px, py int) {
+	for _, p := range c.panes {
+		t := p.Tile
+		if x >= t.Left && x <= t.Right && y >= t.Top && y <= t.Bottom {
+			return p, x - t.Left - 1, y - t.Top - 1
+		}
+	}
+	return nil, 0, 0
+}
+
+// FocusAt focuses the pane under (x, y), for click-to-focus. It reports
+// whether there was one.
+func (c *Compositor) FocusAt(x, y int) bool {
+	p, _, _ := c.PaneAt(x, y)
+	for i, q := range c.panes {
+		if q == p && p != nil {
+			c.focus = i
+			return true
+		}
+	}
+	return false
+}
+
+// Write sends p to the focused pane's input.
+func (c *Compositor) Write(p []byte) (int, error) {
+	f := c.Focused()
+	if f == nil || f.Input == nil {
+		return 0, ErrNoPane
+	}
+	return f.Input.Write(p)
+}
+
+// Cursor returns the focused pane's cursor in virtual screen coordinates.
+// ok is false when no pane is focused, its screen has no cursor to report
+// or the cursor is hidden (DECTCEM).
+func (c *Compositor) Cursor() (x, y int, ok bool) {
+	f := c.Focused()
+	if f == nil {
+		return 0, 0, false
+	}
+	if co, ok := f.Screen.(interface{ GetCursorObject() *Cursor }); ok && co.GetCursorObject().Hidden {
+		return 0, 0, false
+	}
+	gc, ok := f.Screen.(interface{ GetCursor() (int, int) })
+	if !ok {
+		return 0, 0, false
+	}
+	cx, cy := gc.GetCursor()
+	w, h := f.InnerSize()
+	cx, cy = min(max(cx, 0), w-1), min(max(cy, 0), h-1)
+	return f.Tile.Left + 1 + cx, f.Tile.Top + 1 + cy, true
+}
+
+// Cells renders the virtual screen: every pane's cells inside a box, the
+// title in the top border and the focused pane's border highlighted.
+func (c *Compositor) Cells() [][]Cell {
+	grid := make([][]Cell, c.lines)
+	for y := range grid {
+		grid[y] = make([]Cell, c.columns)
+		for x := range grid[y] {
+			grid[y][x] = Cell{Char: ' ', Attrs: DefaultAttributes(), Width: 1}
+		}
+	}
+	for i, p := range c.panes {
+		attrs := paneBorder
+		if i == c.focus {
+			attrs = paneBorderFocused
+		}
+		c.drawBox(grid, p, attrs)
+		c.drawPane(grid, p)
+	}
+	return grid
+}
+
+// Display renders the virtual screen as text, like GetDisplay.
+func (c *Compositor) Display() []string {
+	cells := c.Cells()
+	lines := make([]string, len(cells))
+	for y, row := range cells {
+		runes := make([]rune, 0, len(row))
+		for _, cell := range row {
+			if cell.Width != 0 {
+				runes = append(runes, cell.Char)
+				runes = append(runes, []rune(cell.Cluster)...)
+			}
+		}
+		lines[y] = string(runes)
+	}
+	return lines
+}
+
+func (c *Compositor) set(grid [][]Cell, x, y int, cell Cell) {
+	if y >= 0 && y < len(grid) && x >= 0 && x < len(grid[y]) {
+		grid[y][x] = cell
+	}
+}
+
+func (c *Compositor) drawBox(grid [][]Cell, p *Pane, attrs Attributes) {
+	t := p.Tile
+	if t.Right-t.Left < 1 || t.Bottom-t.Top < 1 {
+		return
+	}
+	put := func(x, y int, ch rune) { c.set(grid, x, y, Cell{Char: ch, Attrs: attrs, Width: 1}) }
+	for x := t.Left + 1; x < t.Right; x++ {
+		put(x, t.Top, '─')
+		put(x, t.Bottom, '─')
+	}
+	for y := t.Top + 1; y < t.Bottom; y++ {
+		put(t.Left, y, '│')
+		put(t.Right, y, '│')
+	}
+	put(t.Left, t.Top, '┌')
+	put(t.Right, t.Top, '┐')
+	put(t.Left, t.Bottom, '└')
+	put(t.Right, t.Bottom, '┘')
+
+	// " title " in the top border, cut to fit
+	if p.Title == "" {
+		return
+	}
+	x := t.Left + 2
+	for _, ch := range " " + p.Title + " " {
+		if x >= t.Right-1 {
+			break
+		}
+		put(x, t.Top, ch)
+		x++
+	}
+}
+
+func (c *Compositor) drawPane(grid [][]Cell, p *Pane) {
+	src, ok := p.Screen.(lineCellSource)
+	if !ok {
+		return
+	}
+	w, h := p.InnerSize()
+	for y := 0; y < h; y++ {
+		row := src.lineCells(y)
+		for x := 0; x < w && x < len(row); x++ {
+			cell := row[x]
+			if cell.Width == 2 && x == w-1 {
+				// Cut by the border; do not leave half a character
+				cell = Cell{Char: ' ', Attrs: cell.Attrs, Width: 1}
+			}
+			c.set(grid, p.Tile.Left+1+x, p.Tile.Top+1+y, cell)
+		}
+	}
+}
+
+// lineCellSource is implemented by the built-in screens.
+type lineCellSource interface {
+	lineCells(y int) []Cell
+}
+
+// lineCells returns row y as cells. Rows outside the screen are nil.
+func (s *NativeScreen) lineCells(y int) []Cell {
+	if y < 0 || y >= s.lines {
+		return nil
+	}
+	cells := make([]Cell, s.columns)
+	for x := range cells {
+		cells[x] = Cell{Char: ' ', Attrs: DefaultAttributes(), Width: 1}
+		if x < len(s.buffer[y]) {
+			cells[x].Char = s.buffer[y][x]
+		}
+		if x < len(s.attrs[y]) {
+			cells[x].Attrs = s.attrs[y][x]
+		}
+	}
+	if m := s.lineMeta(y); m != nil && len(m.cells) > 0 {
+		for x := range cells {
+			if cells[x].Char != 0 {
+				cells[x].Cluster, _ = clusterKey.GetCell(m, x)
+			}
+		}
+	}
+	return cells
+}
+
+// lineCells returns row y as cells with their widths.
+func (w *WideCharScreen) lineCells(y int) []Cell {
+	cells := w.AlternateScreen.lineCells(y)
+	for x := range cells {
+		if y < len(w.cellWidths) && x < len(w.cellWidths[y]) {
+			cells[x].Width = w.cellWidths[y][x]
+		}
+	}
+	return cells
+}
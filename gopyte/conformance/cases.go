@@ -0,0 +1,256 @@
+package conformance
+
+import (
+	"fmt"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+// Cases is the curated set Run uses. Each case names the vttest screen or
+// esctest test it is derived from where there is one. Expectations are
+// what xterm does, with its default resources, on a screen of the case's
+// size.
+var Cases = []Case{
+	// Cursor movement (vttest 1, esctest CUP/CUU/CUD/CUF/CUB/CHA/VPA/HPA/CNL/CPL/HVP)
+	{Capability: "cursor movement", Name: "CUP", Input: "\x1b[3;4HX",
+		Screen: []string{"", "", "   X"}, Cursor: &Pos{4, 2}},
+	{Capability: "cursor movement", Name: "CUP defaults to home", Input: "\x1b[3;4H\x1b[H",
+		Cursor: &Pos{0, 0}},
+	{Capability: "cursor movement", Name: "CUP clamps to the screen", Input: "\x1b[99;99H",
+		Cursor: &Pos{9, 4}},
+	{Capability: "cursor movement", Name: "HVP", Input: "\x1b[2;5f",
+		Cursor: &Pos{4, 1}},
+	{Capability: "cursor movement", Name: "CUU stops at the top", Input: "\x1b[3;3H\x1b[9A",
+		Cursor: &Pos{2, 0}},
+	{Capability: "cursor movement", Name: "CUD stops at the bottom", Input: "\x1b[3;3H\x1b[9B",
+		Cursor: &Pos{2, 4}},
+	{Capability: "cursor movement", Name: "CUF stops at the right margin", Input: "\x1b[2;3H\x1b[99C",
+		Cursor: &Pos{9, 1}},
+	{Capability: "cursor movement", Name: "CUB stops at the left margin", Input: "\x1b[2;3H\x1b[99D",
+		Cursor: &Pos{0, 1}},
+	{Capability: "cursor movement", Name: "CUU parameter 0 means 1", Input: "\x1b[3;3H\x1b[0A",
+		Cursor: &Pos{2, 1}},
+	{Capability: "cursor movement", Name: "CHA", Input: "\x1b[2;2H\x1b[7G",
+		Cursor: &Pos{6, 1}},
+	{Capability: "cursor movement", Name: "HPA", Input: "\x1b[2;2H\x1b[7`",
+		Cursor: &Pos{6, 1}},
+	{Capability: "cursor movement", Name: "VPA", Input: "\x1b[2;6H\x1b[4d",
+		Cursor: &Pos{5, 3}},
+	{Capability: "cursor movement", Name: "CNL", Input: "\x1b[2;6H\x1b[2E",
+		Cursor: &Pos{0, 3}},
+	{Capability: "cursor movement", Name: "CPL", Input: "\x1b[4;6H\x1b[2F",
+		Cursor: &Pos{0, 1}},
+	{Capability: "cursor movement", Name: "BS stops at the left margin", Input: "\b\bA",
+		Screen: []string{"A"}, Cursor: &Pos{1, 0}},
+	{Capability: "cursor movement", Name: "CR", Input: "abc\rX",
+		Screen: []string{"Xbc"}, Cursor: &Pos{1, 0}},
+
+	// Erasing (vttest 1, esctest ED/EL/ECH)
+	{Capability: "erase", Name: "ED 0", Input: "aaaa\r\nbbbb\r\ncccc\x1b[2;3H\x1b[J",
+		Screen: []string{"aaaa", "bb"}},
+	{Capability: "erase", Name: "ED 1", Input: "aaaa\r\nbbbb\r\ncccc\x1b[2;3H\x1b[1J",
+		Screen: []string{"", "   b", "cccc"}},
+	{Capability: "erase", Name: "ED 2 keeps the cursor", Input: "aaaa\r\nbbbb\x1b[2;3H\x1b[2J",
+		Screen: []string{}, Cursor: &Pos{2, 1}},
+	{Capability: "erase", Name: "EL 0", Input: "abcdef\x1b[3G\x1b[K",
+		Screen: []string{"ab"}},
+	{Capability: "erase", Name: "EL 1", Input: "abcdef\x1b[3G\x1b[1K",
+		Screen: []string{"   def"}},
+	{Capability: "erase", Name: "EL 2", Input: "abcdef\x1b[3G\x1b[2K",
+		Screen: []string{}, Cursor: &Pos{2, 0}},
+	{Capability: "erase", Name: "ECH", Input: "abcdef\x1b[2G\x1b[3X",
+		Screen: []string{"a   ef"}, Cursor: &Pos{1, 0}},
+	{Capability: "erase", Name: "ECH stops at the right margin", Input: "abcdefghij\x1b[8G\x1b[99X",
+		Screen: []string{"abcdefg"}},
+	{Capability: "erase", Name: "erase uses the background color", Input: "\x1b[41m\x1b[2J",
+		Check: func(t Target) error {
+			return wantBg(t, 2, 5, gopyte.NamedColor(1))
+		}},
+
+	// Character and line editing (vttest 8, esctest ICH/DCH/IL/DL)
+	{Capability: "editing", Name: "ICH", Input: "abcdef\x1b[2G\x1b[2@",
+		Screen: []string{"a  bcdef"}, Cursor: &Pos{1, 0}},
+	{Capability: "editing", Name: "ICH pushes text off the edge", Input: "abcdefghij\x1b[2G\x1b[3@",
+		Screen: []string{"a   bcdefg"}},
+	{Capability: "editing", Name: "DCH", Input: "abcdef\x1b[2G\x1b[2P",
+		Screen: []string{"adef"}, Cursor: &Pos{1, 0}},
+	{Capability: "editing", Name: "IL", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2H\x1b[2L",
+		Screen: []string{"1", "", "", "2", "3"}, Cursor: &Pos{0, 1}},
+	{Capability: "editing", Name: "DL", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2H\x1b[2M",
+		Screen: []string{"1", "4", "5"}, Cursor: &Pos{0, 1}},
+	{Capability: "editing", Name: "IL inside the scroll region", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2;4r\x1b[3H\x1b[L",
+		Screen: []string{"1", "2", "", "3", "5"}},
+	{Capability: "editing", Name: "DL inside the scroll region", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2;4r\x1b[2H\x1b[M",
+		Screen: []string{"1", "3", "4", "", "5"}},
+	{Capability: "editing", Name: "IL outside the scroll region does nothing", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2;4r\x1b[5H\x1b[L",
+		Screen: []string{"1", "2", "3", "4", "5"}},
+	{Capability: "editing", Name: "IRM", Input: "abc\x1b[4h\x1b[GXY",
+		Screen: []string{"XYabc"}},
+	{Capability: "editing", Name: "REP", Input: "ab\x1b[3b",
+		Screen: []string{"abbbb"}},
+
+	// Scrolling (vttest 2, esctest DECSTBM/IND/RI/NEL/SU/SD)
+	{Capability: "scrolling", Name: "LF at the bottom scrolls", Lines: 3, Input: "1\r\n2\r\n3\r\n4",
+		Screen: []string{"2", "3", "4"}, Cursor: &Pos{1, 2}},
+	{Capability: "scrolling", Name: "IND at the bottom scrolls", Lines: 3, Input: "1\r\n2\r\n3\x1bDX",
+		Screen: []string{"2", "3", " X"}},
+	{Capability: "scrolling", Name: "RI at the top scrolls down", Lines: 3, Input: "1\r\n2\r\n3\x1b[H\x1bMX",
+		Screen: []string{"X", "1", "2"}},
+	{Capability: "scrolling", Name: "NEL", Input: "ab\x1bEX",
+		Screen: []string{"ab", "X"}},
+	{Capability: "scrolling", Name: "DECSTBM homes the cursor", Input: "\x1b[3;3H\x1b[2;4r",
+		Cursor: &Pos{0, 0}},
+	{Capability: "scrolling", Name: "LF scrolls the region only", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2;4r\x1b[4H\nX",
+		Screen: []string{"1", "3", "4", "X", "5"}},
+	{Capability: "scrolling", Name: "RI scrolls the region only", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2;4r\x1b[2H\x1bMX",
+		Screen: []string{"1", "X", "2", "3", "5"}},
+	{Capability: "scrolling", Name: "LF below the region stops at the bottom", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2;3r\x1b[5H\nX",
+		Screen: []string{"1", "2", "3", "4", "5X"}},
+	{Capability: "scrolling", Name: "SU", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2S",
+		Screen: []string{"3", "4", "5"}},
+	{Capability: "scrolling", Name: "SD", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2T",
+		Screen: []string{"", "", "1", "2", "3"}},
+	{Capability: "scrolling", Name: "SU inside the region", Input: "1\r\n2\r\n3\r\n4\r\n5\x1b[2;4r\x1b[S",
+		Screen: []string{"1", "3", "4", "", "5"}},
+	{Capability: "scrolling", Name: "LF keeps the column", Input: "ab\nX",
+		Screen: []string{"ab", "  X"}},
+
+	// Tab stops (vttest 1, esctest HTS/TBC/CHT/CBT)
+	{Capability: "tabs", Name: "HT every 8 columns", Columns: 20, Input: "\tX\tY",
+		Screen: []string{"        X       Y"}},
+	{Capability: "tabs", Name: "HT stops at the right margin", Input: "\t\t\tX",
+		Cursor: &Pos{9, 0}},
+	{Capability: "tabs", Name: "HTS", Columns: 20, Input: "\x1b[4G\x1bH\r\tX",
+		Screen: []string{"   X"}},
+	{Capability: "tabs", Name: "TBC 0", Columns: 20, Input: "\x1b[9G\x1b[g\r\tX",
+		Cursor: &Pos{17, 0}},
+	{Capability: "tabs", Name: "TBC 3", Columns: 20, Input: "\x1b[3g\r\tX",
+		Cursor: &Pos{19, 0}},
+	{Capability: "tabs", Name: "CHT", Columns: 30, Input: "\x1b[2I",
+		Cursor: &Pos{16, 0}},
+	{Capability: "tabs", Name: "CBT", Columns: 30, Input: "\x1b[20G\x1b[2Z",
+		Cursor: &Pos{8, 0}},
+
+	// Autowrap (vttest 1, esctest DECAWM)
+	{Capability: "autowrap", Name: "wraps after the last column", Input: "abcdefghijkl",
+		Screen: []string{"abcdefghij", "kl"}, Cursor: &Pos{2, 1}},
+	{Capability: "autowrap", Name: "last column leaves the wrap pending", Input: "abcdefghij",
+		Screen: []string{"abcdefghij"}, Cursor: &Pos{9, 0}},
+	{Capability: "autowrap", Name: "CR cancels the pending wrap", Input: "abcdefghij\rX",
+		Screen: []string{"Xbcdefghij"}},
+	{Capability: "autowrap", Name: "DECAWM off overwrites the last column", Input: "\x1b[?7labcdefghijkl",
+		Screen: []string{"abcdefghil"}},
+	{Capability: "autowrap", Name: "wrap at the bottom scrolls", Lines: 2, Input: "\r\nabcdefghijk",
+		Screen: []string{"abcdefghij", "k"}},
+
+	// Origin mode (vttest 1, esctest DECOM)
+	{Capability: "origin mode", Name: "CUP is relative to the region", Input: "\x1b[2;4r\x1b[?6h\x1b[2;3HX",
+		Screen: []string{"", "", "  X"}},
+	{Capability: "origin mode", Name: "CUP clamps to the region", Input: "\x1b[2;4r\x1b[?6h\x1b[9;1HX",
+		Screen: []string{"", "", "", "X"}},
+	{Capability: "origin mode", Name: "DECOM homes the cursor", Input: "\x1b[2;4r\x1b[4;4H\x1b[?6h",
+		Cursor: &Pos{0, 1}},
+	{Capability: "origin mode", Name: "CPR is relative to the region", Input: "\x1b[2;4r\x1b[?6h\x1b[2;3H\x1b[6n",
+		Response: "\x1b[2;3R"},
+
+	// Save and restore (vttest 1, esctest DECSC/DECRC/SCOSC/SCORC)
+	{Capability: "save and restore", Name: "DECSC/DECRC", Input: "\x1b[2;3H\x1b7\x1b[H\x1b8X",
+		Screen: []string{"", "  X"}},
+	{Capability: "save and restore", Name: "DECRC restores attributes", Input: "\x1b[1m\x1b7\x1b[m\x1b8X",
+		Check: func(t Target) error {
+			if !t.GetCell(0, 0).Attrs.Bold {
+				return fmt.Errorf("X is not bold")
+			}
+			return nil
+		}},
+	{Capability: "save and restore", Name: "DECRC without DECSC homes", Input: "\x1b[3;3H\x1b8",
+		Cursor: &Pos{0, 0}},
+	{Capability: "save and restore", Name: "SCOSC/SCORC", Input: "\x1b[2;3H\x1b[s\x1b[H\x1b[uX",
+		Screen: []string{"", "  X"}},
+
+	// Character sets (vttest 3, esctest SCS)
+	{Capability: "character sets", Name: "DEC special graphics", Input: "\x1b(0lqk\x1b(Bq",
+		Screen: []string{"┌─┐q"}},
+	{Capability: "character sets", Name: "SO/SI", Input: "\x1b)0a\x0eq\x0fq",
+		Screen: []string{"a─q"}},
+
+	// Rendition (vttest 2, esctest SGR)
+	{Capability: "rendition", Name: "bold, underline and reverse", Input: "\x1b[1;4;7mX",
+		Check: func(t Target) error {
+			if a := t.GetCell(0, 0).Attrs; !a.Bold || !a.Underscore || !a.Reverse {
+				return fmt.Errorf("attributes %+v", a)
+			}
+			return nil
+		}},
+	{Capability: "rendition", Name: "SGR 0 resets", Input: "\x1b[1;31mX\x1b[mY",
+		Check: func(t Target) error {
+			if a := t.GetCell(0, 1).Attrs; a != gopyte.DefaultAttributes() {
+				return fmt.Errorf("attributes %+v", a)
+			}
+			return nil
+		}},
+	{Capability: "rendition", Name: "ANSI colors", Input: "\x1b[32;45mX",
+		Check: func(t Target) error {
+			return wantColors(t, gopyte.NamedColor(2), gopyte.NamedColor(5))
+		}},
+	{Capability: "rendition", Name: "bright colors", Input: "\x1b[92;105mX",
+		Check: func(t Target) error {
+			return wantColors(t, gopyte.NamedColor(10), gopyte.NamedColor(13))
+		}},
+	{Capability: "rendition", Name: "256 colors", Input: "\x1b[38;5;123;48;5;45mX",
+		Check: func(t Target) error {
+			return wantColors(t, gopyte.IndexedColor(123), gopyte.IndexedColor(45))
+		}},
+	{Capability: "rendition", Name: "direct colors", Input: "\x1b[38;2;1;2;3mX",
+		Check: func(t Target) error {
+			return wantColors(t, gopyte.DirectColor(gopyte.RGB{R: 1, G: 2, B: 3}), gopyte.DefaultColor())
+		}},
+	{Capability: "rendition", Name: "default colors", Input: "\x1b[32;45m\x1b[39;49mX",
+		Check: func(t Target) error {
+			return wantColors(t, gopyte.DefaultColor(), gopyte.DefaultColor())
+		}},
+
+	// Reports (vttest 6, esctest DSR/DA)
+	{Capability: "reports", Name: "DSR operating status", Input: "\x1b[5n",
+		Response: "\x1b[0n"},
+	{Capability: "reports", Name: "CPR", Input: "\x1b[3;7H\x1b[6n",
+		Response: "\x1b[3;7R"},
+	{Capability: "reports", Name: "DECXCPR", Input: "\x1b[3;7H\x1b[?6n",
+		Response: "\x1b[?3;7;1R"},
+
+	// Screen modes and tests (vttest 1 and 2, esctest DECALN/DECCOLM/DECSLRM)
+	{Capability: "screen modes", Name: "DECALN", Columns: 3, Lines: 2, Input: "\x1b[2;2H\x1b#8",
+		Screen: []string{"EEE", "EEE"}, Cursor: &Pos{0, 0}},
+	{Capability: "screen modes", Name: "DECSLRM", Input: "\x1b[?69h\x1b[3;6s\x1b[3GABCDEF",
+		Screen: []string{"  ABCD", "  EF"}},
+	{Capability: "screen modes", Name: "DECTCEM", Input: "\x1b[?25l",
+		Check: func(t Target) error {
+			if s, ok := t.(interface{ Snapshot() *gopyte.Snapshot }); ok && !s.Snapshot().Screen.Cursor.Hidden {
+				return fmt.Errorf("cursor is visible")
+			}
+			return nil
+		}},
+	{Capability: "screen modes", Name: "RIS clears the screen", Input: "abc\x1b[2;4r\x1bc",
+		Screen: []string{}, Cursor: &Pos{0, 0}},
+}
+
+// wantBg checks that the first rows x columns cells have background bg.
+func wantBg(t Target, rows, columns int, bg gopyte.Color) error {
+	for y := range rows {
+		for x := range columns {
+			if got := t.GetCell(y, x).Attrs.Bg; got != bg {
+				return fmt.Errorf("background at (%d, %d) is %v, want %v", x, y, got, bg)
+			}
+		}
+	}
+	return nil
+}
+
+// wantColors checks the colors of the top left cell.
+func wantColors(t Target, fg, bg gopyte.Color) error {
+	a := t.GetCell(0, 0).Attrs
+	if a.Fg != fg || a.Bg != bg {
+		return fmt.Errorf("colors %v on %v, want %v on %v", a.Fg, a.Bg, fg, bg)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Package conformance measures how much of xterm's behaviour a gopyte
+// screen reproduces. It feeds a curated set of sequences, derived from
+// the vttest and esctest suites, through fresh screens and compares the
+// text, cursor and replies with what xterm does, reporting pass or fail
+// per case and per capability:
+//
+//	report := conformance.Run(nil)
+//	fmt.Print(report)
+//
+// Run it in CI and compare Failures with a known list to catch
+// regressions, or against a custom screen to see where it stands.
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+// Target is a screen the cases can inspect. NativeScreen, HistoryScreen,
+// AlternateScreen and WideCharScreen implement it.
+type Target interface {
+	gopyte.Screen
+	GetDisplay() []string
+	GetCursor() (x, y int)
+	GetCell(y, x int) gopyte.Cell
+	SetResponseWriter(w io.Writer)
+}
+
+// Factory creates a screen of the given size for one case.
+type Factory func(columns, lines int) Target
+
+// DefaultFactory creates WideCharScreens, gopyte's most complete screen.
+func DefaultFactory(columns, lines int) Target {
+	return gopyte.NewWideCharScreen(columns, lines, 100)
+}
+
+// Pos is a cursor position, zero-based as GetCursor returns it.
+type Pos struct{ X, Y int }
+
+// Case is one conformance check. The input is fed to a fresh screen of
+// Columns x Lines (10 x 5 when zero); then each expectation that is set
+// is compared.
+type Case struct {
+	Capability string
+	Name       string
+	Columns    int
+	Lines      int
+	Input      string
+
+	Screen   []string             // The lines from the top, trailing blanks ignored
+	Cursor   *Pos                 // Where the cursor ends up
+	Response string               // What the screen writes back to the program
+	Check    func(t Target) error // Anything else
+}
+
+// Result is the outcome of one case; Err is nil when it passed.
+type Result struct {
+	Case *Case
+	Err  error
+}
+
+// Passed reports whether the case passed.
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+// Capability is the tally for one capability.
+type Capability struct {
+	Name          string
+	Passed, Total int
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Results []Result
+}
+
+// Run runs Cases against screens from factory, DefaultFactory if nil.
+func Run(factory Factory) *Report {
+	return RunCases(Cases, factory)
+}
+
+// RunCases runs the given cases against screens from factory,
+// DefaultFactory if nil.
+func RunCases(cases []Case, factory Factory) *Report {
+	if factory == nil {
+		factory = DefaultFactory
+	}
+	r := &Report{Results: make([]Result, len(cases))}
+	for i := range cases {
+		r.Results[i] = Result{Case: &cases[i], Err: runCase(&cases[i], factory)}
+	}
+	return r
+}
+
+// runCase runs c, turning a panic into a failure.
+func runCase(c *Case, factory Factory) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	columns, lines := c.Columns, c.Lines
+	if columns == 0 {
+		columns = 10
+	}
+	if lines == 0 {
+		lines = 5
+	}
+	t := factory(columns, lines)
+	var response bytes.Buffer
+	t.SetResponseWriter(&response)
+	gopyte.NewStream(t, false).Feed(c.Input)
+
+	if c.Screen != nil {
+		got := t.GetDisplay()
+		for y := range got {
+			got[y] = strings.TrimRight(got[y], " ")
+		}
+		want := slices.Clone(c.Screen)
+		for len(want) < len(got) {
+			want = append(want, "")
+		}
+		if !slices.Equal(got, want) {
+			return fmt.Errorf("screen %q, want %q", got, want)
+		}
+	}
+	if c.Cursor != nil {
+		if x, y := t.GetCursor(); x != c.Cursor.X || y != c.Cursor.Y {
+			return fmt.Errorf("cursor at (%d, %d), want (%d, %d)", x, y, c.Cursor.X, c.Cursor.Y)
+		}
+	}
+	if c.Response != "" && response.String() != c.Response {
+		return fmt.Errorf("response %q, want %q", response.String(), c.Response)
+	}
+	if c.Check != nil {
+		return c.Check(t)
+	}
+	return nil
+}
+
+// Passed returns the number of cases that passed and the number run.
+func (r *Report) Passed() (passed, total int) {
+	for _, res := range r.Results {
+		if res.Passed() {
+			passed++
+		}
+	}
+	return passed, len(r.Results)
+}
+
+// Capabilities returns the tally per capability, in the order the cases
+// list them.
+func (r *Report) Capabilities() []Capability {
+	var caps []Capability
+	index := map[string]int{}
+	for _, res := range r.Results {
+		i, ok := index[res.Case.Capability]
+		if !ok {
+			i = len(caps)
+			index[res.Case.Capability] = i
+			caps = append(caps, Capability{Name: res.Case.Capability})
+		}
+		caps[i].Total++
+		if res.Passed() {
+			caps[i].Passed++
+		}
+	}
+	return caps
+}
+
+// Failures returns the results of the cases that failed.
+func (r *Report) Failures() []Result {
+	var failed []Result
+	for _, res := range r.Results {
+		if !res.Passed() {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// String returns the tally per capability, the total, and what went wrong
+// in each failed case.
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, c := range r.Capabilities() {
+		fmt.Fprintf(&b, "%-24s %3d/%d\n", c.Name, c.Passed, c.Total)
+	}
+	passed, total := r.Passed()
+	fmt.Fprintf(&b, "%-24s %3d/%d\n", "total", passed, total)
+	for _, res := range r.Failures() {
+		fmt.Fprintf(&b, "FAIL %s: %s: %v\n", res.Case.Capability, res.Case.Name, res.Err)
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package gopyte
+
+// Windows ConPTY / conhost compatibility.
+//
+// conhost does not stream a program's output verbatim; it re-renders its
+// own buffer into VT sequences. Two habits matter for emulation:
+//
+//   - On startup it enables win32-input-mode (DECSET 9001) and expects the
+//     terminal to send keys as serialized INPUT_RECORDs from then on.
+//   - When it repaints, a soft-wrapped row is written out to the last
+//     column and the next row is reached with an explicit CUP instead of
+//     relying on auto-wrap. Windows Terminal treats "row filled to the
+//     last column, then CUP to column 1 of the next row" as a soft wrap;
+//     ConPTY mode does the same so wrapped output keeps its wrap markers.
+
+// Win32InputMode is the private mode number conhost uses to request
+// win32-input-mode key encoding.
+const Win32InputMode = 9001
+
+// SetConPTYMode enables or disables the ConPTY compatibility heuristics.
+func (s *NativeScreen) SetConPTYMode(enabled bool) {
+	s.conptyMode = enabled
+}
+
+// ConPTYMode reports whether the ConPTY compatibility heuristics are on.
+func (s *NativeScreen) ConPTYMode() bool {
+	return s.conptyMode
+}
+
+// IsWin32InputMode reports whether the application enabled win32-input-mode
+// (DECSET 9001). Hosts should then encode keys as win32 input records.
+func (s *NativeScreen) IsWin32InputMode() bool {
+	return s.win32InputMode
+}
+
+// conptyWrapHint marks the current line as soft-wrapped when conhost moves
+// from a full row straight to the start of the next one. line and column
+// are the 1-based CUP arguments.
+func (s *NativeScreen) conptyWrapHint(line, column int) {
+	if !s.conptyMode || !s.autoWrap {
+		return
+	}
+	if s.cursor.wrapPending && column == 1 && line-1 == s.cursor.Y+1 {
+		s.setWrapped(s.cursor.Y, true)
+	}
+}
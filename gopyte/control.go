@@ -29,4 +29,15 @@ const (
 	OSC_C0 = ESC + "]"
 	OSC_C1 = "\x9d"
 	OSC    = OSC_C0
+
+	DCS_C0 = ESC + "P"
+	DCS_C1 = "\x90"
+	DCS    = DCS_C0
+
+	SOS_C0 = ESC + "X"
+	SOS_C1 = "\x98"
+	PM_C0  = ESC + "^"
+	PM_C1  = "\x9e"
+	APC_C0 = ESC + "_"
+	APC_C1 = "\x9f"
 )
@@ -0,0 +1,143 @@
+package gopyte
+
+import "math"
+
+// Pixel coordinate mapping for GUI embedders: which cell is under the
+// mouse, where a selection edge falls, and where to paint a cell. Wide
+// characters are treated as one two-cell glyph, so clicking either half
+// selects the character.
+
+// CellMetrics describes how a GUI lays the screen out in pixels.
+type CellMetrics struct {
+	CellWidth, CellHeight float64 // Size of one cell
+	OriginX, OriginY      float64 // Top-left corner of cell (0, 0)
+
+	// DoubleWidth reports rows shown double width (DECDWL), whose cells
+	// are twice as wide and which hold half as many columns. gopyte does
+	// not track line sizes itself; nil means every row is single width.
+	DoubleWidth func(y int) bool
+}
+
+// PixelRect is a rectangle in pixels.
+type PixelRect struct {
+	X, Y, Width, Height float64
+}
+
+// PixelToCell returns the cell under pixel (px, py), clamped to the screen.
+func (s *NativeScreen) PixelToCell(m CellMetrics, px, py float64) (x, y int) {
+	return cellGrid{s.columns, s.lines, nil}.pixelToCell(m, px, py)
+}
+
+// PixelToBoundary returns the column boundary nearest pixel (px, py) and
+// its row: 0 is the left edge of the row and columns the right edge. Use
+// it for selection anchors, so a drag that starts in the right half of a
+// cell does not include that cell.
+func (s *NativeScreen) PixelToBoundary(m CellMetrics, px, py float64) (x, y int) {
+	return cellGrid{s.columns, s.lines, nil}.pixelToBoundary(m, px, py)
+}
+
+// CellRect returns the pixel rectangle of cell (x, y).
+func (s *NativeScreen) CellRect(m CellMetrics, x, y int) PixelRect {
+	return cellGrid{s.columns, s.lines, nil}.cellRect(m, x, y)
+}
+
+// PixelToCell returns the cell under pixel (px, py). Either half of a wide
+// character maps to its first cell.
+func (w *WideCharScreen) PixelToCell(m CellMetrics, px, py float64) (x, y int) {
+	return cellGrid{w.columns, w.lines, w.cellWidths}.pixelToCell(m, px, py)
+}
+
+// PixelToBoundary returns the column boundary nearest pixel (px, py),
+// never one inside a wide character.
+func (w *WideCharScreen) PixelToBoundary(m CellMetrics, px, py float64) (x, y int) {
+	return cellGrid{w.columns, w.lines, w.cellWidths}.pixelToBoundary(m, px, py)
+}
+
+// CellRect returns the pixel rectangle of the character at cell (x, y):
+// two cells wide for a wide character, whichever half (x, y) is.
+func (w *WideCharScreen) CellRect(m CellMetrics, x, y int) PixelRect {
+	return cellGrid{w.columns, w.lines, w.cellWidths}.cellRect(m, x, y)
+}
+
+// cellGrid is the geometry the mapping needs. widths may be nil when
+// every cell is one column.
+type cellGrid struct {
+	columns, lines int
+	widths         [][]int
+}
+
+func (g cellGrid) width(x, y int) int {
+	if y < 0 || y >= len(g.widths) || x < 0 || x >= len(g.widths[y]) {
+		return 1
+	}
+	return g.widths[y][x]
+}
+
+// cellPixels returns the pixel width of a cell in row y and the number of
+// columns the row can show.
+func (g cellGrid) cellPixels(m CellMetrics, y int) (float64, int) {
+	if m.DoubleWidth != nil && m.DoubleWidth(y) {
+		return m.CellWidth * 2, max(g.columns/2, 1)
+	}
+	return m.CellWidth, g.columns
+}
+
+func (g cellGrid) row(m CellMetrics, py float64) int {
+	if m.CellHeight <= 0 {
+		return 0
+	}
+	y := int(math.Floor((py - m.OriginY) / m.CellHeight))
+	return min(max(y, 0), g.lines-1)
+}
+
+func (g cellGrid) pixelToCell(m CellMetrics, px, py float64) (x, y int) {
+	y = g.row(m, py)
+	cw, cols := g.cellPixels(m, y)
+	if cw <= 0 {
+		return 0, y
+	}
+	x = int(math.Floor((px - m.OriginX) / cw))
+	x = min(max(x, 0), cols-1)
+	if x > 0 && g.width(x, y) == 0 {
+		x-- // Right half of a wide character
+	}
+	return x, y
+}
+
+func (g cellGrid) pixelToBoundary(m CellMetrics, px, py float64) (x, y int) {
+	y = g.row(m, py)
+	cw, cols := g.cellPixels(m, y)
+	if cw <= 0 {
+		return 0, y
+	}
+	f := (px - m.OriginX) / cw
+	x = int(math.Round(f))
+	x = min(max(x, 0), cols)
+	if x > 0 && x < cols && g.width(x, y) == 0 {
+		// Inside a wide character: go to whichever edge is nearer, the
+		// middle of the character being boundary x itself
+		if f >= float64(x) {
+			x++
+		} else {
+			x--
+		}
+	}
+	return x, y
+}
+
+func (g cellGrid) cellRect(m CellMetrics, x, y int) PixelRect {
+	cw, _ := g.cellPixels(m, y)
+	if x > 0 && g.width(x, y) == 0 {
+		x--
+	}
+	n := 1
+	if g.width(x, y) == 2 {
+		n = 2
+	}
+	return PixelRect{
+		X:      m.OriginX + float64(x)*cw,
+		Y:      m.OriginY + float64(y)*m.CellHeight,
+		Width:  float64(n) * cw,
+		Height: m.CellHeight,
+	}
+}
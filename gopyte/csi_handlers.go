@@ -0,0 +1,24 @@
+package gopyte
+
+// CSIHandler implements an application-defined CSI sequence. params holds
+// the numeric parameters as parsed (empty when none were given) and prefix
+// the private marker ("?", ">", "<" or "=") or "" when there was none.
+type CSIHandler func(params []int, prefix string)
+
+// RegisterCSIHandler installs handler for CSI sequences ending in final
+// with the given intermediate bytes (e.g. "$" or " "; "" for none).
+// Registered handlers take precedence over the built-in ones, so they can
+// also be used to override standard behaviour. Passing a nil handler
+// removes a previous registration. Sequences with no handler are reported
+// to the listener's Debug method.
+func (s *Stream) RegisterCSIHandler(final byte, intermediate string, handler CSIHandler) {
+	key := intermediate + string(rune(final))
+	if handler == nil {
+		delete(s.customCSI, key)
+		return
+	}
+	if s.customCSI == nil {
+		s.customCSI = make(map[string]CSIHandler)
+	}
+	s.customCSI[key] = handler
+}
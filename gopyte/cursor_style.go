@@ -0,0 +1,83 @@
+package gopyte
+
+// CursorStyle is the cursor shape requested with DECSCUSR (CSI Ps SP q).
+// The values are the Ps of the sequence.
+type CursorStyle int
+
+const (
+	CursorStyleDefault           CursorStyle = iota // Whatever the frontend prefers
+	CursorStyleBlinkingBlock                        // 1
+	CursorStyleSteadyBlock                          // 2
+	CursorStyleBlinkingUnderline                    // 3
+	CursorStyleSteadyUnderline                      // 4
+	CursorStyleBlinkingBar                          // 5
+	CursorStyleSteadyBar                            // 6
+)
+
+// CursorShape is the shape part of a CursorStyle.
+type CursorShape int
+
+const (
+	CursorBlock CursorShape = iota
+	CursorUnderline
+	CursorBar
+)
+
+// Shape returns the cursor shape. The default style is a block, as in xterm.
+func (c CursorStyle) Shape() CursorShape {
+	switch c {
+	case CursorStyleBlinkingUnderline, CursorStyleSteadyUnderline:
+		return CursorUnderline
+	case CursorStyleBlinkingBar, CursorStyleSteadyBar:
+		return CursorBar
+	}
+	return CursorBlock
+}
+
+// Blinking reports whether the cursor blinks. The default style blinks, as
+// in xterm.
+func (c CursorStyle) Blinking() bool {
+	switch c {
+	case CursorStyleSteadyBlock, CursorStyleSteadyUnderline, CursorStyleSteadyBar:
+		return false
+	}
+	return true
+}
+
+func (c CursorStyle) String() string {
+	if c == CursorStyleDefault {
+		return "default"
+	}
+	s := "blinking "
+	if !c.Blinking() {
+		s = "steady "
+	}
+	switch c.Shape() {
+	case CursorUnderline:
+		return s + "underline"
+	case CursorBar:
+		return s + "bar"
+	}
+	return s + "block"
+}
+
+// CursorStyler is implemented by screens that track the cursor style. Like
+// ColumnEditor it is optional; Stream ignores DECSCUSR for other screens.
+type CursorStyler interface {
+	SetCursorStyle(style CursorStyle)
+}
+
+// SetCursorStyle sets the cursor style. Values DECSCUSR does not define
+// are ignored.
+func (s *NativeScreen) SetCursorStyle(style CursorStyle) {
+	if style < CursorStyleDefault || style > CursorStyleSteadyBar {
+		return
+	}
+	s.cursorStyle = style
+}
+
+// CursorStyle returns the cursor style last requested by the application.
+// RIS resets it to CursorStyleDefault.
+func (s *NativeScreen) CursorStyle() CursorStyle {
+	return s.cursorStyle
+}
@@ -0,0 +1,168 @@
+package gopyte
+
+import "math/bits"
+
+// Cell-granular damage tracking. Renderers that mirror a screen over a
+// slow link (a browser over SSE, say) send only the cells that changed
+// since the frame the client last acknowledged instead of whole rows:
+//
+//	screen := gopyte.NewWideCharScreen(200, 50, 1000, gopyte.WithCellDamage())
+//	for range ticks {
+//		for _, row := range screen.DamagedCells() {
+//			for _, span := range row.Cells.Spans() {
+//				send(row.Y, span.Start, span.End)
+//			}
+//		}
+//		screen.ClearCellDamage()
+//	}
+//
+// Damage is computed against a copy of the last acknowledged frame, so it
+// costs a second grid of memory and is off by default.
+
+// RowBitmap has one bit per column, set for damaged cells.
+type RowBitmap []uint64
+
+// Span is a run of columns, Start inclusive and End exclusive.
+type Span struct {
+	Start, End int
+}
+
+// RowDamage lists the damaged cells of row Y.
+type RowDamage struct {
+	Y     int
+	Cells RowBitmap
+}
+
+func newRowBitmap(columns int) RowBitmap {
+	return make(RowBitmap, (columns+63)/64)
+}
+
+func (b RowBitmap) set(x int) {
+	if x >= 0 && x/64 < len(b) {
+		b[x/64] |= 1 << (x % 64)
+	}
+}
+
+// Test reports whether column x is damaged.
+func (b RowBitmap) Test(x int) bool {
+	return x >= 0 && x/64 < len(b) && b[x/64]&(1<<(x%64)) != 0
+}
+
+// Count returns the number of damaged cells.
+func (b RowBitmap) Count() int {
+	n := 0
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Spans returns the damaged columns as runs, left to right.
+func (b RowBitmap) Spans() []Span {
+	var spans []Span
+	start := -1
+	for x := 0; x <= len(b)*64; x++ {
+		on := x < len(b)*64 && b.Test(x)
+		switch {
+		case on && start < 0:
+			start = x
+		case !on && start >= 0:
+			spans = append(spans, Span{start, x})
+			start = -1
+		}
+	}
+	return spans
+}
+
+// ScreenOption configures a screen when it is created.
+type ScreenOption func(*NativeScreen)
+
+// WithCellDamage creates the screen with cell damage tracking on, as
+// SetCellDamage(true) does.
+func WithCellDamage() ScreenOption {
+	return func(s *NativeScreen) { s.SetCellDamage(true) }
+}
+
+// SetCellDamage turns cell damage tracking on or off. Turning it on marks
+// the whole screen damaged so the first frame is sent in full.
+func (s *NativeScreen) SetCellDamage(enabled bool) {
+	s.cellDamage = enabled
+	s.shadowText, s.shadowAttrs, s.shadowClusters = nil, nil, nil
+}
+
+// DamagedCells returns the rows with cells changed since the last
+// ClearCellDamage, top to bottom. Text, attribute and grapheme cluster
+// changes count, as do the cells the cursor left and moved to. It returns
+// nil when tracking is off.
+func (s *NativeScreen) DamagedCells() []RowDamage {
+	if !s.cellDamage {
+		return nil
+	}
+	var damage []RowDamage
+	for y := 0; y < s.lines; y++ {
+		row := newRowBitmap(s.columns)
+		if !s.shadowMatchesRow(y) {
+			for x := 0; x < s.columns; x++ {
+				row.set(x)
+			}
+		} else {
+			text, attrs := s.buffer[y], s.attrs[y]
+			for x := 0; x < s.columns; x++ {
+				if text[x] != s.shadowText[y][x] || attrs[x] != s.shadowAttrs[y][x] {
+					row.set(x)
+				}
+			}
+			// A combining mark or selector joined to a cell changes it too
+			var was map[int]string
+			if y < len(s.shadowClusters) {
+				was = s.shadowClusters[y]
+			}
+			now := s.rowClusters(y)
+			for x, tail := range now {
+				if was[x] != tail {
+					row.set(x)
+				}
+			}
+			for x, tail := range was {
+				if now[x] != tail {
+					row.set(x)
+				}
+			}
+		}
+		if s.cursor.X != s.shadowCursor.X || s.cursor.Y != s.shadowCursor.Y ||
+			s.cursor.Hidden != s.shadowCursor.Hidden {
+			// The cursor may sit one past the last column awaiting a wrap
+			if y == s.cursor.Y {
+				row.set(min(s.cursor.X, s.columns-1))
+			}
+			if y == s.shadowCursor.Y {
+				row.set(min(s.shadowCursor.X, s.columns-1))
+			}
+		}
+		if row.Count() > 0 {
+			damage = append(damage, RowDamage{Y: y, Cells: row})
+		}
+	}
+	return damage
+}
+
+// ClearCellDamage acknowledges the current frame: later damage is
+// reported relative to it.
+func (s *NativeScreen) ClearCellDamage() {
+	if !s.cellDamage {
+		return
+	}
+	// Fresh copies, never written in place, so clones can share them
+	s.shadowText = cloneRunes(s.buffer)
+	s.shadowAttrs = cloneAttrs(s.attrs)
+	s.shadowClusters = s.screenClusters()
+	s.shadowCursor = s.cursor
+}
+
+// shadowMatchesRow reports whether row y of the baseline can be compared
+// cell by cell. A missing baseline or a resize damages the whole row.
+func (s *NativeScreen) shadowMatchesRow(y int) bool {
+	return y < len(s.shadowText) && y < len(s.shadowAttrs) &&
+		len(s.shadowText[y]) >= s.columns && len(s.shadowAttrs[y]) >= s.columns &&
+		len(s.buffer[y]) >= s.columns && len(s.attrs[y]) >= s.columns
+}
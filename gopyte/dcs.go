@@ -0,0 +1,161 @@
+package gopyte
+
+import "unicode/utf8"
+
+// Device control strings (DCS, ESC P ... ST). Stream parses the header -
+// parameters, intermediates and final byte, laid out like CSI - and hands
+// the data up to the terminator to the screen, so DECRQSS queries, sixel
+// images and key definitions never leak onto the screen as text.
+
+// DeviceControl is one device control string.
+type DeviceControl struct {
+	Prefix       string // Private marker: "?", ">", "<" or "="
+	Params       []int
+	Intermediate string // Intermediate bytes (0x20-0x2f)
+	Final        string // The byte ending the header, e.g. "q"
+	Data         string // Everything after the final byte up to ST
+}
+
+// Name identifies the common device control strings: "decrqss" (DCS $ q),
+// "xtgettcap" (DCS + q), "sixel" (DCS q) and "decudk" (DCS |). It is ""
+// for others.
+func (d DeviceControl) Name() string {
+	if d.Prefix != "" {
+		return ""
+	}
+	switch d.Intermediate + d.Final {
+	case "$q":
+		return "decrqss"
+	case "+q":
+		return "xtgettcap"
+	case "q":
+		return "sixel"
+	case "|":
+		return "decudk"
+	}
+	return ""
+}
+
+// DeviceControlListener is implemented by screens that handle device
+// control strings. It is optional: Stream consumes and drops them for
+// screens without it.
+type DeviceControlListener interface {
+	DeviceControl(d DeviceControl)
+}
+
+// SetDeviceControlHandler registers a callback invoked for every device
+// control string. Pass nil to remove it.
+func (s *NativeScreen) SetDeviceControlHandler(fn func(DeviceControl)) {
+	s.onDeviceControl = fn
+}
+
+// DeviceControl answers XTGETTCAP queries (see termcap.go) and passes d
+// to the registered handler.
+func (s *NativeScreen) DeviceControl(d DeviceControl) {
+	if d.Name() == "xtgettcap" {
+		s.answerTermcap(d.Data)
+	}
+	if s.onDeviceControl != nil {
+		s.onDeviceControl(d)
+	}
+}
+
+// startDCS begins a device control string.
+func (s *Stream) startDCS() {
+	s.startCSI()
+	s.state = StateDCS
+	s.dcsFinal = ""
+	s.dcsData = s.dcsData[:0]
+	s.dcsEscape = false
+}
+
+// dcsByte handles one byte of a device control string and reports whether
+// the byte must be parsed again in the state the string was left for.
+func (s *Stream) dcsByte(b byte) bool {
+	if s.dcsEscape {
+		s.dcsEscape = false
+		if b == '\\' {
+			s.dispatchDCS()
+			s.state = StateGround
+			return false
+		}
+		// Any other escape sequence cancels the string
+		s.failSequence("sequence interrupted")
+		s.state = StateEscape
+		s.tapKind = SeqEscape
+		return true
+	}
+
+	switch {
+	case b == 0x1b:
+		s.dcsEscape = true
+	case b == 0x18 || b == 0x1a:
+		// CAN and SUB cancel the string
+		s.failSequence("sequence cancelled")
+		s.state = StateGround
+	case b == ST_C1[0] && s.dcsFinal != "" && isUTF8Complete(s.dcsData):
+		s.dispatchDCS()
+		s.state = StateGround
+	case s.dcsFinal != "":
+		if s.payloadRoom(len(s.dcsData), s.limits.MaxDCSBytes) {
+			s.dcsData = append(s.dcsData, b)
+		}
+	case b >= '0' && b <= '9':
+		s.addDigit(b)
+	case b == ';':
+		s.pushDCSParam()
+	case b == '?' || b == '>' || b == '<' || b == '=':
+		s.prefix = string(b)
+	case b >= 0x20 && b <= 0x2f:
+		s.addIntermediate(string(b))
+	case b >= 0x40 && b <= 0x7e:
+		if s.currentParam != "" {
+			s.pushDCSParam()
+		}
+		s.dcsFinal = string(b)
+	}
+	return false
+}
+
+// dispatchDCS hands the completed string to the listener.
+func (s *Stream) dispatchDCS() {
+	if s.discardOverflow() {
+		return
+	}
+	if s.dcsFinal == "" {
+		s.failSequence("malformed DCS string")
+	}
+	d := DeviceControl{
+		Prefix:       s.prefix,
+		Params:       append([]int(nil), s.params...),
+		Intermediate: s.intermediate,
+		Final:        s.dcsFinal,
+		Data:         string(s.dcsData),
+	}
+	s.tapName = d.Name()
+	if dl, ok := s.listener.(DeviceControlListener); ok && d.Final != "" {
+		dl.DeviceControl(d)
+	}
+}
+
+// pushDCSParam ends the header parameter being read, within the limits.
+func (s *Stream) pushDCSParam() {
+	if s.paramRoom() {
+		s.params = append(s.params, s.paramValue(s.currentParam))
+	}
+	s.currentParam = ""
+}
+
+// isUTF8Complete reports whether b does not end inside a multi-byte rune,
+// in which case 0x9c is C1 ST rather than a continuation byte.
+func isUTF8Complete(b []byte) bool {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-3; i-- {
+		if b[i] < 0x80 {
+			return true
+		}
+		if b[i] >= 0xc0 {
+			return utf8.FullRune(b[i:])
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package gopyte
+
+// The identification reports. The screen claims to be a VT420 with ANSI
+// color, which is what it emulates. The firmware version is 0 because
+// programs such as vim read an xterm patch level from it and would turn
+// on xterm-only features.
+const (
+	primaryDeviceAttributes   = CSI + "?64;22c"         // VT420, ANSI color
+	secondaryDeviceAttributes = CSI + ">41;0;0c"        // VT420, firmware 0, no options
+	tertiaryDeviceAttributes  = DCS + "!|00000000" + ST // DECRPTUI, unit ID 0
+)
+
+// DeviceAttributesReporter is implemented by screens that answer the
+// secondary (CSI > c) and tertiary (CSI = c) device attribute queries. It
+// is optional; the Stream ignores those queries for other screens. The
+// primary query (CSI c) goes to Screen.ReportDeviceAttributes.
+type DeviceAttributesReporter interface {
+	ReportSecondaryDeviceAttributes(mode int)
+	ReportTertiaryDeviceAttributes(mode int)
+}
+
+// ReportDeviceAttributes answers DA1 (CSI c or CSI 0 c) through Respond.
+// Other parameters and the private form are not queries and are ignored.
+func (s *NativeScreen) ReportDeviceAttributes(mode int, private bool) {
+	if mode != 0 || private {
+		return
+	}
+	s.Respond(ResponseDeviceAttributes, primaryDeviceAttributes)
+}
+
+// ReportSecondaryDeviceAttributes answers DA2 (CSI > c) with the terminal
+// type and firmware version.
+func (s *NativeScreen) ReportSecondaryDeviceAttributes(mode int) {
+	if mode != 0 {
+		return
+	}
+	s.Respond(ResponseDeviceAttributes, secondaryDeviceAttributes)
+}
+
+// ReportTertiaryDeviceAttributes answers DA3 (CSI = c) with the unit ID.
+func (s *NativeScreen) ReportTertiaryDeviceAttributes(mode int) {
+	if mode != 0 {
+		return
+	}
+	s.Respond(ResponseDeviceAttributes, tertiaryDeviceAttributes)
+}
@@ -0,0 +1,113 @@
+package gopyte
+
+import "errors"
+
+// ErrUnsupportedScreen is returned for Screen implementations outside
+// gopyte, whose cells cannot be read.
+var ErrUnsupportedScreen = errors.New("gopyte: unsupported screen type")
+
+// DiffViewOptions configures DiffScreens.
+type DiffViewOptions struct {
+	// CompareAttrs also counts a cell as changed when only its colors or
+	// style differ. By default only text is compared, which is what
+	// polling a command for changes usually wants.
+	CompareAttrs bool
+
+	// Highlight styles changed cells; nil means DiffHighlight.
+	Highlight func(Attributes) Attributes
+
+	// Unchanged styles the other cells; nil leaves them alone. Dim or
+	// plain attributes make the changes stand out further.
+	Unchanged func(Attributes) Attributes
+}
+
+// DiffHighlight is the default style for changed cells: black on yellow.
+func DiffHighlight(a Attributes) Attributes {
+	a.Fg, a.Bg = NamedColor(0), NamedColor(3)
+	a.Reverse = false
+	return a
+}
+
+// DiffView is the result of DiffScreens.
+type DiffView struct {
+	Screen Screen      // A copy of after with the changed cells highlighted
+	Rows   []RowDamage // Changed cells by row, top to bottom
+	Cells  int         // Number of changed cells
+}
+
+// Changed reports whether anything differed.
+func (d *DiffView) Changed() bool {
+	return d.Cells > 0
+}
+
+// DiffScreens compares two snapshots of the same session - the output of
+// a command polled five minutes apart, say - and renders the differences
+// as a new screen: a copy of after in which every cell that differs from
+// before is restyled. The copy is of after's own type, so it can be shown
+// or exported like any other screen:
+//
+//	before := screen.Clone()
+//	... // Poll again
+//	view, err := gopyte.DiffScreens(before, screen, gopyte.DiffViewOptions{})
+//	if err == nil && view.Changed() {
+//		publish(view.Screen)
+//	}
+//
+// Cells outside before, when the sizes differ, count as changed. Cleared
+// cells are changed too; they show as highlighted blanks. Both screens
+// must be built-in gopyte screens.
+func DiffScreens(before, after Screen, opts DiffViewOptions) (*DiffView, error) {
+	old, ok1 := before.(lineCellSource)
+	cur, ok2 := after.(lineCellSource)
+	view, ok3 := cloneScreen(after)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, ErrUnsupportedScreen
+	}
+	if opts.Highlight == nil {
+		opts.Highlight = DiffHighlight
+	}
+
+	d := &DiffView{Screen: view}
+	base := baseScreen(view)
+	for y := 0; y < base.lines; y++ {
+		a, b := old.lineCells(y), cur.lineCells(y)
+		var bits RowBitmap
+		for x := range b {
+			if x < len(a) && sameCell(a[x], b[x], opts.CompareAttrs) {
+				if opts.Unchanged != nil {
+					base.attrs[y][x] = opts.Unchanged(base.attrs[y][x])
+				}
+				continue
+			}
+			if bits == nil {
+				bits = newRowBitmap(len(b))
+			}
+			bits.set(x)
+			base.attrs[y][x] = opts.Highlight(base.attrs[y][x])
+			d.Cells++
+		}
+		if bits != nil {
+			d.Rows = append(d.Rows, RowDamage{Y: y, Cells: bits})
+		}
+	}
+	return d, nil
+}
+
+func sameCell(a, b Cell, attrs bool) bool {
+	return a.Char == b.Char && a.Cluster == b.Cluster && a.Width == b.Width && (!attrs || a.Attrs == b.Attrs)
+}
+
+// baseScreen returns the NativeScreen underlying a built-in screen.
+func baseScreen(screen Screen) *NativeScreen {
+	switch s := screen.(type) {
+	case *WideCharScreen:
+		return &s.NativeScreen
+	case *AlternateScreen:
+		return &s.NativeScreen
+	case *HistoryScreen:
+		return &s.NativeScreen
+	case *NativeScreen:
+		return s
+	}
+	return nil
+}
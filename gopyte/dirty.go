@@ -0,0 +1,65 @@
+package gopyte
+
+import "maps"
+
+// Line-granular damage tracking, the cheap sibling of damage.go for
+// renderers that repaint whole rows:
+//
+//	for range ticks {
+//		for _, y := range screen.DirtyLines() {
+//			repaint(y)
+//		}
+//		screen.ClearDirty()
+//	}
+//
+// Lines are compared against a copy taken by ClearDirty, so nothing is
+// tracked, or allocated, until it is first called; until then every line
+// is dirty.
+
+// DirtyLines returns the lines whose text, attributes or grapheme clusters
+// changed since the last ClearDirty, top to bottom, including the lines the cursor left and
+// moved to.
+func (s *NativeScreen) DirtyLines() []int {
+	var dirty []int
+	cursorMoved := s.cursor.X != s.dirtyCursor.X || s.cursor.Y != s.dirtyCursor.Y ||
+		s.cursor.Hidden != s.dirtyCursor.Hidden
+	for y := 0; y < s.lines; y++ {
+		if !s.dirtyRowSame(y) || (cursorMoved && (y == s.cursor.Y || y == s.dirtyCursor.Y)) {
+			dirty = append(dirty, y)
+		}
+	}
+	return dirty
+}
+
+// ClearDirty marks every line clean: DirtyLines reports changes made
+// after it.
+func (s *NativeScreen) ClearDirty() {
+	if len(s.dirtyText) != s.lines {
+		s.dirtyText = make([][]rune, s.lines)
+		s.dirtyAttrs = make([][]Attributes, s.lines)
+	}
+	for y := 0; y < s.lines; y++ {
+		s.dirtyText[y] = append(s.dirtyText[y][:0], s.buffer[y]...)
+		s.dirtyAttrs[y] = append(s.dirtyAttrs[y][:0], s.attrs[y]...)
+	}
+	s.dirtyClusters = s.screenClusters()
+	s.dirtyCursor = s.cursor
+}
+
+// dirtyRowSame reports whether line y matches the copy ClearDirty took.
+func (s *NativeScreen) dirtyRowSame(y int) bool {
+	if y >= len(s.dirtyText) || len(s.dirtyText[y]) != len(s.buffer[y]) ||
+		len(s.dirtyAttrs[y]) != len(s.attrs[y]) {
+		return false
+	}
+	for x, r := range s.buffer[y] {
+		if r != s.dirtyText[y][x] || s.attrs[y][x] != s.dirtyAttrs[y][x] {
+			return false
+		}
+	}
+	var was map[int]string
+	if y < len(s.dirtyClusters) {
+		was = s.dirtyClusters[y]
+	}
+	return maps.Equal(s.rowClusters(y), was)
+}
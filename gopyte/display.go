@@ -0,0 +1,228 @@
+package gopyte
+
+// WidePlaceholder stands in for the right half of a wide character in
+// GetDisplayPadded and GetDisplayRect, so that rune i of a line is always
+// column i. Renderers should skip it; it is never a real character.
+const WidePlaceholder rune = 0
+
+// GetDisplayPadded returns the screen text with exactly one rune per
+// column: trailing spaces are kept, unlike GetDisplay.
+func (s *NativeScreen) GetDisplayPadded() []string {
+	lines := make([]string, s.lines)
+	for y := 0; y < s.lines; y++ {
+		lines[y] = padRow(s.buffer[y], nil, s.columns)
+	}
+	return lines
+}
+
+// GetDisplayRect returns the padded text of region r, one string per row
+// and one rune per column. r is clipped to the screen.
+func (s *NativeScreen) GetDisplayRect(r Region) []string {
+	return rectOf(s.GetDisplayPadded(), r, s.columns, s.lines)
+}
+
+// GetDisplayPadded returns the screen text with exactly one rune per
+// column. The right half of a wide character is WidePlaceholder.
+func (w *WideCharScreen) GetDisplayPadded() []string {
+	lines := make([]string, w.lines)
+	for y := 0; y < w.lines; y++ {
+		var widths []int
+		if y < len(w.cellWidths) {
+			widths = w.cellWidths[y]
+		}
+		lines[y] = padRow(w.buffer[y], widths, w.columns)
+	}
+	return lines
+}
+
+// GetDisplayRect returns the padded text of region r, one string per row
+// and one rune per column. A wide character cut by the left edge shows up
+// as a leading WidePlaceholder.
+func (w *WideCharScreen) GetDisplayRect(r Region) []string {
+	return rectOf(w.GetDisplayPadded(), r, w.columns, w.lines)
+}
+
+// GetLineCells returns line y with the attributes of every cell, so that
+// styled output can be rendered. Lines outside the screen are nil.
+func (s *NativeScreen) GetLineCells(y int) []Cell {
+	return s.lineCells(y)
+}
+
+// GetCell returns the cell at line y, column x, or the zero Cell outside
+// the screen.
+func (s *NativeScreen) GetCell(y, x int) Cell {
+	if y < 0 || y >= s.lines || x < 0 || x >= s.columns {
+		return Cell{}
+	}
+	c := Cell{Char: ' ', Attrs: DefaultAttributes(), Width: 1}
+	if x < len(s.buffer[y]) {
+		c.Char = s.buffer[y][x]
+	}
+	if c.Char != 0 {
+		c.Cluster = s.cluster(y, x)
+	}
+	if x < len(s.attrs[y]) {
+		c.Attrs = s.attrs[y][x]
+	}
+	return c
+}
+
+// GetLineCells returns line y as cells. The left half of a wide character
+// has Width 2 and the right half Width 0, with a null rune.
+func (w *WideCharScreen) GetLineCells(y int) []Cell {
+	return w.lineCells(y)
+}
+
+// GetCell returns the cell at line y, column x, or the zero Cell outside
+// the screen.
+func (w *WideCharScreen) GetCell(y, x int) Cell {
+	c := w.NativeScreen.GetCell(y, x)
+	if c.Width != 0 && y < len(w.cellWidths) && x < len(w.cellWidths[y]) {
+		c.Width = w.cellWidths[y][x]
+	}
+	return c
+}
+
+// padRow renders a buffer row as exactly columns runes. widths, if given,
+// marks continuation cells with 0; they hold a null rune in the buffer.
+func padRow(row []rune, widths []int, columns int) string {
+	out := make([]rune, columns)
+	for x := 0; x < columns; x++ {
+		ch := ' '
+		if x < len(row) && row[x] != 0 {
+			ch = row[x]
+		}
+		if x < len(widths) && widths[x] == 0 && (x >= len(row) || row[x] == 0) {
+			ch = WidePlaceholder
+		}
+		out[x] = ch
+	}
+	return string(out)
+}
+
+func rectOf(padded []string, r Region, columns, lines int) []string {
+	if r.Top < 0 {
+		r.Top = 0
+	}
+	if r.Left < 0 {
+		r.Left = 0
+	}
+	if r.Bottom >= lines {
+		r.Bottom = lines - 1
+	}
+	if r.Right >= columns {
+		r.Right = columns - 1
+	}
+	if r.Top > r.Bottom || r.Left > r.Right {
+		return nil
+	}
+	rows := make([]string, 0, r.Bottom-r.Top+1)
+	for y := r.Top; y <= r.Bottom; y++ {
+		row := []rune(padded[y])
+		rows = append(rows, string(row[r.Left:r.Right+1]))
+	}
+	return rows
+}
+
+// lineReader is a screen that reads out the text of its lines.
+type lineReader interface {
+	ReadLine(y int, buf []rune) int
+}
+
+// ReadLine copies the text of line y, as GetDisplay has it, into buf and
+// returns the number of runes copied. A buf of one rune per column always
+// holds the whole line. Lines outside the screen are empty.
+func (s *NativeScreen) ReadLine(y int, buf []rune) int {
+	if y < 0 || y >= s.lines {
+		return 0
+	}
+	row := s.buffer[y]
+	n := len(row)
+	for n > 0 && row[n-1] == ' ' {
+		n--
+	}
+	return copy(buf, row[:n])
+}
+
+// ReadLine copies the text of line y, as GetDisplay has it, into buf and
+// returns the number of runes copied. Wide characters take one rune and
+// grapheme clusters all of theirs, so a line of clusters can need more
+// than one rune per column.
+func (w *WideCharScreen) ReadLine(y int, buf []rune) int {
+	if y < 0 || y >= w.lines {
+		return 0
+	}
+	var widths []int
+	if y < len(w.cellWidths) {
+		widths = w.cellWidths[y]
+	}
+	n := 0
+	for x, ch := range w.buffer[y] {
+		if n == len(buf) {
+			break
+		}
+		if (x < len(widths) && widths[x] == 0) || ch == 0 {
+			continue // Right half of a wide character
+		}
+		buf[n] = ch
+		n++
+		for _, r := range w.cluster(y, x) {
+			if n == len(buf) {
+				break
+			}
+			buf[n] = r
+			n++
+		}
+	}
+	return n
+}
+
+// AppendDisplay appends the text of each line, as GetDisplay returns it,
+// to dst and returns the extended slice. Passing the last result back
+// truncated, as in lines = screen.AppendDisplay(lines[:0]), reuses it: a
+// line that has not changed keeps its string, so polling a screen every
+// frame allocates only for the lines that changed.
+func (s *NativeScreen) AppendDisplay(dst []string) []string {
+	return appendDisplay(dst, s, s.lines, s.columns, &s.lineBuf)
+}
+
+// AppendDisplay appends the text of each line, as GetDisplay returns it,
+// to dst and returns the extended slice, reusing the strings of unchanged
+// lines left in dst's spare capacity.
+func (w *WideCharScreen) AppendDisplay(dst []string) []string {
+	return appendDisplay(dst, w, w.lines, w.columns, &w.lineBuf)
+}
+
+func appendDisplay(dst []string, r lineReader, lines, columns int, scratch *[]rune) []string {
+	if cap(*scratch) <= columns {
+		*scratch = make([]rune, columns+1) // A full line leaves one spare
+	}
+	buf := (*scratch)[:cap(*scratch)]
+	for y := 0; y < lines; y++ {
+		n := r.ReadLine(y, buf)
+		for n == len(buf) { // Maybe cut short by grapheme clusters
+			buf = make([]rune, 2*len(buf))
+			*scratch = buf
+			n = r.ReadLine(y, buf)
+		}
+		text := buf[:n]
+		if i := len(dst); i < cap(dst) && sameText(dst[:i+1][i], text) {
+			dst = dst[:i+1]
+			continue
+		}
+		dst = append(dst, string(text))
+	}
+	return dst
+}
+
+// sameText reports whether s holds exactly the runes of text.
+func sameText(s string, text []rune) bool {
+	i := 0
+	for _, ch := range s {
+		if i == len(text) || text[i] != ch {
+			return false
+		}
+		i++
+	}
+	return i == len(text)
+}
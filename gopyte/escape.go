@@ -10,6 +10,13 @@ const (
 	DECSC  = "7"
 	DECRC  = "8"
 	DECALN = "8"
+	SS2    = "N" // Single shift G2
+	SS3    = "O" // Single shift G3
+	LS2    = "n" // Locking shift G2
+	LS3    = "o" // Locking shift G3
+
+	DECKPAM = "=" // Application keypad
+	DECKPNM = ">" // Numeric keypad
 
 	// CSI sequences
 	ICH     = "@"
@@ -21,13 +28,18 @@ const (
 	CPL     = "F"
 	CHA     = "G"
 	CUP     = "H"
+	CHT     = "I"
 	ED      = "J"
 	EL      = "K"
 	IL      = "L"
 	DL      = "M"
 	DCH     = "P"
+	SU      = "S"
+	SD      = "T"
 	ECH     = "X"
+	CBT     = "Z"
 	HPR     = "a"
+	REP     = "b"
 	DA      = "c"
 	VPA     = "d"
 	VPR     = "e"
@@ -38,5 +50,27 @@ const (
 	SGR     = "m"
 	DSR     = "n"
 	DECSTBM = "r"
-	HPA     = "'"
+	DECSLRM = "s" // Set left/right margins; SCOSC while DECLRMM is off
+	SCORC   = "u" // Restore cursor (SCO)
+	HPA     = "`"
+
+	XTWINOPS = "t" // Window manipulation
+
+	// CSI sequences with intermediate bytes, keyed intermediate+final
+	DECIC    = "'}"
+	DECDC    = "'~"
+	DECSCUSR = " q" // Set cursor style
+	DECSTR   = "!p" // Soft terminal reset
+
+	// CSI sequences with a private marker other than "?", keyed marker+final
+	XTSMTITLE = ">t"
+	XTRMTITLE = ">T"
+	DA2       = ">c" // Secondary device attributes
+	DA3       = "=c" // Tertiary device attributes
+
+	// Kitty keyboard protocol
+	KKPUSH  = ">u"
+	KKPOP   = "<u"
+	KKSET   = "=u"
+	KKQUERY = "?u"
 )
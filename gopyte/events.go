@@ -0,0 +1,196 @@
+package gopyte
+
+import "fmt"
+
+// Events tell a host what changed as output is fed, so it need not poll
+// the getters after every Feed:
+//
+//	screen.SetEventHandler(func(ev gopyte.Event) {
+//		switch ev.Type {
+//		case gopyte.EventTitle:
+//			window.SetTitle(ev.Text)
+//		case gopyte.EventBell:
+//			window.Flash()
+//		case gopyte.EventMode:
+//			if ev.Private && ev.Mode == gopyte.BracketedPasteMode {
+//				pasteMode = ev.Enabled
+//			}
+//		}
+//	})
+//
+// Handlers run on the goroutine feeding the screen, once the operation
+// that caused the event is complete, so they may read the screen.
+
+// EventType identifies what an Event reports.
+type EventType int
+
+const (
+	EventTitle           EventType = iota + 1 // The window title changed; Text is the new one
+	EventIconName                             // The icon name changed; Text is the new one
+	EventBell                                 // The program rang the bell
+	EventAlternateScreen                      // The alternate screen was entered (Enabled) or left
+	EventMode                                 // Mode was set (Enabled) or reset
+	EventResize                               // The screen was resized to Columns x Lines
+)
+
+var eventTypeNames = map[EventType]string{
+	EventTitle:           "title",
+	EventIconName:        "icon name",
+	EventBell:            "bell",
+	EventAlternateScreen: "alternate screen",
+	EventMode:            "mode",
+	EventResize:          "resize",
+}
+
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event is a change in the state of a screen. Only the fields for its
+// Type are set.
+type Event struct {
+	Type    EventType
+	Text    string // EventTitle, EventIconName
+	Mode    int    // EventMode: the mode number, as in SM/RM or DECSET/DECRST
+	Private bool   // EventMode: a DEC private mode
+	Enabled bool   // EventMode, EventAlternateScreen
+	Columns int    // EventResize
+	Lines   int    // EventResize
+}
+
+// SetEventHandler registers a callback invoked for every event. Pass nil
+// to remove it.
+//
+// Mode events are sent when a mode actually changes, whatever changed it
+// - SM/RM, DECSET/DECRST, RIS, DECSTR - and only for the modes the screen
+// implements: LNM, DECCKM, DECOM, DECAWM, DECTCEM, DECNKM, DECLRMM, the
+// mouse modes, focus reporting, bracketed paste and win32-input-mode.
+// Switching mouse tracking from 1000 to 1002 reports 1000 off and 1002
+// on. The alternate screen modes are reported as EventAlternateScreen.
+func (s *NativeScreen) SetEventHandler(fn func(Event)) {
+	s.onEvent = fn
+}
+
+// emit sends an event to the handler, or holds it while an operation is
+// under way (see holdEvents).
+func (s *NativeScreen) emit(ev Event) {
+	if s.onEvent == nil {
+		return
+	}
+	if s.eventHold > 0 {
+		s.heldEvents = append(s.heldEvents, ev)
+		return
+	}
+	s.onEvent(ev)
+}
+
+// holdEvents holds events until the returned function is called; the
+// call matching the outermost hold delivers them. Overrides call it first,
+// so handlers never see a screen that the layers above have yet to catch
+// up with:
+//
+//	defer w.holdEvents()()
+func (s *NativeScreen) holdEvents() func() {
+	s.eventHold++
+	return func() {
+		s.eventHold--
+		if s.eventHold > 0 {
+			return
+		}
+		events := s.heldEvents
+		s.heldEvents = nil
+		for _, ev := range events {
+			if s.onEvent != nil {
+				s.onEvent(ev)
+			}
+		}
+	}
+}
+
+type modeKey struct {
+	mode    int
+	private bool
+}
+
+// eventModes are the modes reported by EventMode.
+var eventModes = []modeKey{
+	{20, false}, // LNM
+	{CursorKeysMode, true},
+	{6, true},  // DECOM
+	{7, true},  // DECAWM
+	{9, true},  // X10 mouse
+	{25, true}, // DECTCEM
+	{KeypadMode, true},
+	{69, true}, // DECLRMM
+	{1000, true}, {1002, true}, {1003, true},
+	{FocusReportingMode, true},
+	{1005, true}, {1006, true}, {1015, true}, {1016, true},
+	{BracketedPasteMode, true},
+	{Win32InputMode, true},
+}
+
+// modeEnabled reports whether a mode of eventModes is set.
+func (s *NativeScreen) modeEnabled(m modeKey) bool {
+	switch m.mode {
+	case 20:
+		return s.newlineMode
+	case CursorKeysMode:
+		return s.appCursorKeys
+	case 6:
+		return s.originMode
+	case 7:
+		return s.autoWrap
+	case 25:
+		return !s.cursor.Hidden
+	case KeypadMode:
+		return s.appKeypad
+	case 69:
+		return s.lrMarginMode
+	case 9, 1000, 1002, 1003:
+		return s.mouse.Tracking == MouseTracking(m.mode)
+	case 1005, 1006, 1015, 1016:
+		return s.mouse.Encoding == MouseEncoding(m.mode)
+	case FocusReportingMode:
+		return s.focusReporting
+	case BracketedPasteMode:
+		return s.bracketedPaste
+	case Win32InputMode:
+		return s.win32InputMode
+	}
+	return false
+}
+
+// watchModes notes the modes and returns a function that emits EventMode
+// for each one changed since:
+//
+//	defer s.watchModes()()
+func (s *NativeScreen) watchModes() func() {
+	if s.onEvent == nil {
+		return func() {}
+	}
+	before := make([]bool, len(eventModes))
+	for i, m := range eventModes {
+		before[i] = s.modeEnabled(m)
+	}
+	return func() {
+		for i, m := range eventModes {
+			if on := s.modeEnabled(m); on != before[i] {
+				s.emit(Event{Type: EventMode, Mode: m.mode, Private: m.private, Enabled: on})
+			}
+		}
+	}
+}
+
+// watchAlternate returns a function that emits EventAlternateScreen if
+// the alternate screen was entered or left since.
+func (a *AlternateScreen) watchAlternate() func() {
+	was := a.usingAlternate
+	return func() {
+		if a.usingAlternate != was {
+			a.emit(Event{Type: EventAlternateScreen, Enabled: a.usingAlternate})
+		}
+	}
+}
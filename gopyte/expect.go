@@ -0,0 +1,288 @@
+package gopyte
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultExpectTimeout is how long Expect waits for a match unless
+// SetTimeout says otherwise.
+const DefaultExpectTimeout = 10 * time.Second
+
+// ErrExpectTimeout is returned when no match turns up in time.
+var ErrExpectTimeout = errors.New("gopyte: expect timed out")
+
+// Expecter drives an interactive program headlessly, expect(1) style, but
+// matches against the emulated screen rather than the raw byte stream, so
+// cursor movement, redraws and escape sequences never get in the way:
+//
+//	e := gopyte.NewExpecter(conn, gopyte.NewHistoryScreen(132, 50, 5000))
+//	e.SetPrompt(regexp.MustCompile(`(?m)^[\w.-]+[>#] ?$`))
+//	if _, err := e.ExpectString("Password:"); err != nil { ... }
+//	e.SendLine(password)
+//	e.ExpectPrompt()
+//	lines, err := e.Capture("show version")
+//
+// The text searched by Expect is the lines scrolled off the screen
+// followed by the display, trailing blanks trimmed and joined with "\n".
+// Each Expect starts where the previous match ended, so the same prompt is
+// not matched twice. Full-screen programs that redraw in place are better
+// checked with ExpectScreen, which looks at the whole display every time.
+//
+// Scrolled lines are collected with the screen's line commit handler (see
+// LineCommitter), which the Expecter takes over.
+type Expecter struct {
+	mu      sync.Mutex
+	rw      io.ReadWriter
+	screen  Screen
+	stream  *Stream
+	changed chan struct{} // Closed and replaced whenever the screen changes
+	err     error         // Set once reading stops
+
+	scrolled []string // Lines scrolled off since the mark
+	base     int      // Absolute line number of scrolled[0]
+	markLine int      // Absolute line where the next search starts
+	markCol  int      // Byte offset in that line
+
+	prompt  *regexp.Regexp
+	timeout time.Duration
+	clock   Clock
+}
+
+// ExpectMatch describes a successful Expect.
+type ExpectMatch struct {
+	Before string   // Text between the previous match and this one
+	Text   string   // The matched text
+	Groups []string // Submatches, Groups[0] being Text
+}
+
+// NewExpecter starts reading rw, the program's terminal, into screen.
+func NewExpecter(rw io.ReadWriter, screen Screen) *Expecter {
+	e := &Expecter{
+		rw:      rw,
+		screen:  screen,
+		stream:  NewStream(screen, false),
+		changed: make(chan struct{}),
+		timeout: DefaultExpectTimeout,
+		clock:   SystemClock,
+	}
+	if lc, ok := screen.(LineCommitter); ok {
+		lc.SetLineCommitHandler(func(line string, wrapped bool) {
+			e.scrolled = append(e.scrolled, line) // Called from Feed, under e.mu
+		})
+	}
+	go e.read()
+	return e
+}
+
+func (e *Expecter) read() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := e.rw.Read(buf)
+		e.mu.Lock()
+		if n > 0 {
+			e.stream.Feed(string(buf[:n]))
+		}
+		if err != nil {
+			e.err = err
+		}
+		close(e.changed)
+		e.changed = make(chan struct{})
+		e.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// SetPrompt sets the pattern ExpectPrompt and Capture wait for. Prompts
+// usually end a line, so anchor them with (?m)^ and $.
+func (e *Expecter) SetPrompt(re *regexp.Regexp) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.prompt = re
+}
+
+// SetTimeout sets how long each Expect waits.
+func (e *Expecter) SetTimeout(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.timeout = d
+}
+
+// SetClock sets the time source for timeouts.
+func (e *Expecter) SetClock(c Clock) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clock = c
+}
+
+// Screen returns the screen being driven. The reader updates it
+// concurrently, so prefer the Expect results and Capture.
+func (e *Expecter) Screen() Screen {
+	return e.screen
+}
+
+// Send writes s to the program as typed input.
+func (e *Expecter) Send(s string) error {
+	_, err := io.WriteString(e.rw, s)
+	return err
+}
+
+// SendLine writes s followed by Enter (a carriage return).
+func (e *Expecter) SendLine(s string) error {
+	return e.Send(s + "\r")
+}
+
+// Expect waits for re to match the text after the previous match.
+func (e *Expecter) Expect(re *regexp.Regexp) (ExpectMatch, error) {
+	return e.wait(func() (ExpectMatch, bool) { return e.search(re) })
+}
+
+// ExpectString waits for s to appear after the previous match.
+func (e *Expecter) ExpectString(s string) (ExpectMatch, error) {
+	return e.Expect(regexp.MustCompile(regexp.QuoteMeta(s)))
+}
+
+// ExpectPrompt waits for the prompt set with SetPrompt.
+func (e *Expecter) ExpectPrompt() (ExpectMatch, error) {
+	e.mu.Lock()
+	prompt := e.prompt
+	e.mu.Unlock()
+	if prompt == nil {
+		return ExpectMatch{}, errors.New("gopyte: no prompt set")
+	}
+	return e.Expect(prompt)
+}
+
+// ExpectScreen waits for re to match anywhere on the current display,
+// ignoring the previous match. The search position is left alone.
+func (e *Expecter) ExpectScreen(re *regexp.Regexp) (ExpectMatch, error) {
+	return e.wait(func() (ExpectMatch, bool) {
+		text := strings.Join(e.display(), "\n")
+		m := re.FindStringSubmatchIndex(text)
+		if m == nil {
+			return ExpectMatch{}, false
+		}
+		return matchAt(text, m), true
+	})
+}
+
+// Capture runs cmd at the prompt and returns its output: the lines between
+// the echoed command and the next prompt.
+func (e *Expecter) Capture(cmd string) ([]string, error) {
+	if err := e.SendLine(cmd); err != nil {
+		return nil, err
+	}
+	m, err := e.ExpectPrompt()
+	if err != nil {
+		return nil, err
+	}
+	// Before starts with the rest of the prompt line, the echoed command
+	return CleanOutput(strings.Split(m.Before, "\n"), cmd, nil), nil
+}
+
+// Close closes the connection if it is an io.Closer.
+func (e *Expecter) Close() error {
+	if c, ok := e.rw.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// wait polls try, under e.mu, each time the screen changes until it
+// succeeds, reading stops or the timeout expires.
+func (e *Expecter) wait(try func() (ExpectMatch, bool)) (ExpectMatch, error) {
+	e.mu.Lock()
+	timer := e.clock.After(e.timeout)
+	for {
+		if m, ok := try(); ok {
+			e.mu.Unlock()
+			return m, nil
+		}
+		if e.err != nil {
+			err := e.err
+			e.mu.Unlock()
+			return ExpectMatch{}, err
+		}
+		changed := e.changed
+		e.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer:
+			return ExpectMatch{}, ErrExpectTimeout
+		}
+		e.mu.Lock()
+	}
+}
+
+// search looks for re after the mark and moves the mark past the match.
+// The caller holds e.mu.
+func (e *Expecter) search(re *regexp.Regexp) (ExpectMatch, bool) {
+	lines := append(append([]string(nil), e.scrolled...), e.display()...)
+	start := e.markLine - e.base
+	if start >= len(lines) {
+		return ExpectMatch{}, false
+	}
+	first := lines[start]
+	lines[start] = first[min(e.markCol, len(first)):]
+	text := strings.Join(lines[start:], "\n")
+
+	m := re.FindStringSubmatchIndex(text)
+	if m == nil {
+		return ExpectMatch{}, false
+	}
+
+	// Move the mark to the end of the match
+	consumed := text[:m[1]]
+	nl := strings.Count(consumed, "\n")
+	col := len(consumed) - (strings.LastIndex(consumed, "\n") + 1)
+	if nl == 0 {
+		col += min(e.markCol, len(first))
+	}
+	e.markLine += nl
+	e.markCol = col
+
+	// Scrolled lines before the mark are no longer needed
+	if drop := e.markLine - e.base; drop > 0 {
+		drop = min(drop, len(e.scrolled))
+		e.scrolled = e.scrolled[drop:]
+		e.base += drop
+	}
+	return matchAt(text, m), true
+}
+
+// display returns the screen lines, trailing blanks and blank lines
+// trimmed. The caller holds e.mu.
+func (e *Expecter) display() []string {
+	d, ok := e.screen.(interface{ GetDisplay() []string })
+	if !ok {
+		return nil
+	}
+	lines := d.GetDisplay()
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = strings.TrimRight(l, " \x00")
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+func matchAt(text string, m []int) ExpectMatch {
+	match := ExpectMatch{Before: text[:m[0]], Text: text[m[0]:m[1]]}
+	for i := 0; i+1 < len(m); i += 2 {
+		g := ""
+		if m[i] >= 0 {
+			g = text[m[i]:m[i+1]]
+		}
+		match.Groups = append(match.Groups, g)
+	}
+	return match
+}
@@ -0,0 +1,130 @@
+package gopyte
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrWorkerClosed is returned by FeedWorker.Write after Close.
+var ErrWorkerClosed = errors.New("gopyte: feed worker closed")
+
+// DefaultWorkerBuffer is the number of chunks a FeedWorker queues when
+// WorkerOptions.Buffer is zero.
+const DefaultWorkerBuffer = 64
+
+// WorkerOptions configures a FeedWorker.
+type WorkerOptions struct {
+	Buffer  int                // Chunks queued before Write blocks (0 = DefaultWorkerBuffer)
+	OnError func([]ParseError) // Errors of a strict stream, called on the worker goroutine
+}
+
+// FeedWorker feeds a Stream on a goroutine of its own, so a PTY reader
+// is not held up while the screen is updated. Output is queued up to a
+// bound; past it Write blocks, slowing the reader to the pace of the
+// screen rather than buffering without limit:
+//
+//	worker := stream.StartWorker(gopyte.WorkerOptions{})
+//	go func() {
+//		io.Copy(worker, pty)
+//		worker.Close()
+//	}()
+//
+// The screen is changed on the worker goroutine, so reading it from
+// another needs a lock: wrap it in a SyncScreen. Flush waits for the
+// output written so far to reach the screen.
+type FeedWorker struct {
+	stream  *Stream
+	onError func([]ParseError)
+	queue   chan feedItem
+	done    chan struct{}
+
+	mu     sync.RWMutex // Held for reading to send, for writing to close
+	closed bool
+}
+
+// feedItem is a chunk of output, or a flush marker when flushed is set.
+type feedItem struct {
+	data    []byte
+	flushed chan struct{}
+}
+
+// StartWorker starts a goroutine feeding the stream whatever is written
+// to the returned worker. Feed must not be called directly until the
+// worker is closed.
+func (s *Stream) StartWorker(opts WorkerOptions) *FeedWorker {
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultWorkerBuffer
+	}
+	w := &FeedWorker{
+		stream:  s,
+		onError: opts.OnError,
+		queue:   make(chan feedItem, opts.Buffer),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *FeedWorker) run() {
+	defer close(w.done)
+	for item := range w.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		if errs := w.stream.Feed(string(item.data)); errs != nil && w.onError != nil {
+			w.onError(errs)
+		}
+	}
+}
+
+// Write queues a copy of p to be fed, blocking while the queue is full.
+func (w *FeedWorker) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return 0, ErrWorkerClosed
+	}
+	w.queue <- feedItem{data: append([]byte(nil), p...)}
+	return len(p), nil
+}
+
+// WriteString queues s to be fed, blocking while the queue is full.
+func (w *FeedWorker) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Pending returns the number of chunks queued and not yet fed.
+func (w *FeedWorker) Pending() int {
+	return len(w.queue)
+}
+
+// Flush waits until everything written before it has been fed. It
+// returns at once after Close, which flushes itself.
+func (w *FeedWorker) Flush() {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	w.queue <- feedItem{flushed: flushed}
+	w.mu.RUnlock()
+	<-flushed
+}
+
+// Close stops accepting output, feeds what is queued and waits for the
+// goroutine to finish. Calling it again does nothing.
+func (w *FeedWorker) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
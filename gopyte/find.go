@@ -0,0 +1,153 @@
+package gopyte
+
+import (
+	"regexp"
+	"strings"
+)
+
+// FindMatch is text found on the screen by Find. A match in a line that
+// soft-wraps can continue on the rows below, so it is made of one span
+// per row, top to bottom; each span covers whole characters, both halves
+// of a wide one included, and is ready to be highlighted.
+type FindMatch struct {
+	Text  string
+	Spans []Region // One row each: Top == Bottom
+}
+
+// Find returns every match of re in the text on the screen, top to
+// bottom. Rows joined by soft wraps are searched as one line, so a word
+// broken by the right edge is still found; trailing blanks are not
+// searched. Only the display is searched, not the scrollback; while
+// scrolled back, that is the history being viewed. Empty matches are left
+// out.
+func (s *NativeScreen) Find(re *regexp.Regexp) []FindMatch {
+	return s.find(re, nil)
+}
+
+// FindString returns every occurrence of text on the screen, like Find.
+func (s *NativeScreen) FindString(text string) []FindMatch {
+	return s.find(regexp.MustCompile(regexp.QuoteMeta(text)), nil)
+}
+
+// Find returns every match of re on the screen. A wide character counts
+// as one character and its span covers both cells.
+func (w *WideCharScreen) Find(re *regexp.Regexp) []FindMatch {
+	return w.find(re, w.cellWidths)
+}
+
+// FindString returns every occurrence of text on the screen.
+func (w *WideCharScreen) FindString(text string) []FindMatch {
+	return w.find(regexp.MustCompile(regexp.QuoteMeta(text)), w.cellWidths)
+}
+
+// findCell is where a character of the searched text is on the screen.
+type findCell struct {
+	x, y, width int
+}
+
+// findLine is the text of rows joined by soft wraps, with the cell of
+// each rune.
+type findLine struct {
+	text    string
+	cells   []findCell // Per rune
+	offsets []int      // Rune index to byte offset in text, and its length
+	runeAt  []int      // Byte offset in text to rune index
+}
+
+// find searches each run of rows joined by soft wraps. widths are the
+// cell widths of a screen with wide characters, nil for one cell each.
+func (s *NativeScreen) find(re *regexp.Regexp, widths [][]int) []FindMatch {
+	var matches []FindMatch
+	for top := 0; top < s.lines; {
+		line := s.findLine(top, widths)
+		for _, m := range re.FindAllStringIndex(line.text, -1) {
+			if m[0] != m[1] {
+				matches = append(matches, line.match(line.runeAt[m[0]], line.runeAt[m[1]]))
+			}
+		}
+		top = s.wrapEnd(top) + 1
+	}
+	return matches
+}
+
+// wrapStart returns the first row of the soft-wrapped line holding row y.
+func (s *NativeScreen) wrapStart(y int) int {
+	for y > 0 && s.IsWrapped(y-1) {
+		y--
+	}
+	return y
+}
+
+// wrapEnd returns the last row of the soft-wrapped line holding row y.
+func (s *NativeScreen) wrapEnd(y int) int {
+	for y < s.lines-1 && s.IsWrapped(y) {
+		y++
+	}
+	return y
+}
+
+// findLine returns the line starting at row top, trailing blanks left
+// out.
+func (s *NativeScreen) findLine(top int, widths [][]int) findLine {
+	var b strings.Builder
+	var line findLine
+	bottom := s.wrapEnd(top)
+	for y := top; y <= bottom; y++ {
+		row := s.buffer[y]
+		end := len(row)
+		if y == bottom {
+			for end > 0 && (row[end-1] == ' ' || row[end-1] == 0) {
+				end--
+			}
+		}
+		for x := 0; x < end; x++ {
+			width := 1
+			if y < len(widths) && x < len(widths[y]) {
+				width = widths[y][x]
+			}
+			if width == 0 || row[x] == 0 {
+				continue // Right half of a wide character
+			}
+			b.WriteRune(row[x])
+			line.cells = append(line.cells, findCell{x, y, max(width, 1)})
+			for _, r := range s.cluster(y, x) {
+				b.WriteRune(r)
+				line.cells = append(line.cells, findCell{x, y, max(width, 1)})
+			}
+		}
+	}
+	line.text = b.String()
+	line.runeAt = make([]int, len(line.text)+1)
+	line.offsets = make([]int, 0, len(line.cells)+1)
+	for off := range line.text {
+		line.runeAt[off] = len(line.offsets)
+		line.offsets = append(line.offsets, off)
+	}
+	line.runeAt[len(line.text)] = len(line.offsets)
+	line.offsets = append(line.offsets, len(line.text))
+	return line
+}
+
+// runeIndex returns the index of the rune in cell (x, y), or -1.
+func (l *findLine) runeIndex(x, y int) int {
+	for i, c := range l.cells {
+		if c.y == y && x >= c.x && x < c.x+c.width {
+			return i
+		}
+	}
+	return -1
+}
+
+// match returns runes from up to to as a match.
+func (l *findLine) match(from, to int) FindMatch {
+	match := FindMatch{Text: l.text[l.offsets[from]:l.offsets[to]]}
+	for _, c := range l.cells[from:to] {
+		n := len(match.Spans)
+		if n > 0 && match.Spans[n-1].Top == c.y {
+			match.Spans[n-1].Right = c.x + c.width - 1
+			continue
+		}
+		match.Spans = append(match.Spans, Region{Top: c.y, Bottom: c.y, Left: c.x, Right: c.x + c.width - 1})
+	}
+	return match
+}
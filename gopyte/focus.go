@@ -0,0 +1,23 @@
+package gopyte
+
+// FocusReportingMode is the private mode number of focus reporting. With
+// it set, the program wants CSI I when the terminal gains focus and CSI O
+// when it loses it.
+const FocusReportingMode = 1004
+
+// FocusReporting reports whether the program enabled focus reporting.
+func (s *NativeScreen) FocusReporting() bool {
+	return s.focusReporting
+}
+
+// EncodeFocus returns the bytes to send to the program when the window
+// gains (in) or loses focus, or nil if it did not ask for them.
+func (s *NativeScreen) EncodeFocus(in bool) []byte {
+	switch {
+	case !s.focusReporting:
+		return nil
+	case in:
+		return []byte(CSI + "I")
+	}
+	return []byte(CSI + "O")
+}
@@ -0,0 +1,113 @@
+package gopyte_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestActivityMonitor(t *testing.T) {
+	clock := gopyte.NewFakeClock(clockStart)
+	var mu sync.Mutex
+	var events []gopyte.ActivityEvent
+	m := gopyte.NewActivityMonitor(10*time.Second, clock, func(ev gopyte.ActivityEvent) {
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	})
+	defer m.Stop()
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events)
+	}
+
+	stream := gopyte.NewStream(gopyte.NewNativeScreen(20, 3), false)
+	if !stream.LastOutputAt().IsZero() {
+		t.Error("LastOutputAt without a monitor should be zero")
+	}
+	stream.SetActivityMonitor(m)
+
+	if m.Active() {
+		t.Fatal("monitor should start idle")
+	}
+	stream.Feed("hello")
+	if !m.Active() || count() != 1 || !events[0].Active {
+		t.Fatalf("after output: active=%v events=%+v", m.Active(), events)
+	}
+
+	// Output within the quiet period keeps the session active
+	waitFor(t, func() bool { return clock.Waiters() == 1 })
+	clock.Advance(6 * time.Second)
+	stream.Feed(" world")
+	waitFor(t, func() bool { return clock.Waiters() == 1 })
+	clock.Advance(6 * time.Second)
+	waitFor(t, func() bool { return clock.Waiters() == 1 })
+	if count() != 1 || !m.Active() {
+		t.Fatalf("went idle too early: %+v", events)
+	}
+	if want := clockStart.Add(6 * time.Second); !stream.LastOutputAt().Equal(want) {
+		t.Errorf("LastOutputAt = %v, want %v", stream.LastOutputAt(), want)
+	}
+
+	clock.Advance(4 * time.Second)
+	waitFor(t, func() bool { return count() == 2 })
+	mu.Lock()
+	idle := events[1]
+	mu.Unlock()
+	if idle.Active || !idle.LastOutput.Equal(clockStart.Add(6*time.Second)) || !idle.At.Equal(clockStart.Add(16*time.Second)) {
+		t.Errorf("idle event = %+v", idle)
+	}
+	if m.Active() || m.Bytes() != 11 {
+		t.Errorf("active=%v bytes=%d", m.Active(), m.Bytes())
+	}
+
+	stream.Feed("!")
+	waitFor(t, func() bool { return count() == 3 })
+}
+
+func TestActivityMonitorDropsStaleEvents(t *testing.T) {
+	clock := gopyte.NewFakeClock(clockStart)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var events []gopyte.ActivityEvent
+	m := gopyte.NewActivityMonitor(10*time.Second, clock, func(ev gopyte.ActivityEvent) {
+		if ev.Active {
+			<-release // A slow consumer
+		}
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	})
+	defer m.Stop()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.Output(1)
+	}()
+
+	// The session goes idle and active again while the first active event
+	// is still being delivered
+	waitFor(t, func() bool { return clock.Waiters() == 1 })
+	clock.Advance(10 * time.Second)
+	waitFor(t, func() bool { return !m.Active() })
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.Output(1)
+	}()
+	waitFor(t, func() bool { return m.Active() })
+	close(release)
+	wg.Wait()
+	waitFor(t, func() bool { return clock.Waiters() == 1 })
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || !events[0].Active {
+		t.Errorf("events = %+v, want one active event", events)
+	}
+}
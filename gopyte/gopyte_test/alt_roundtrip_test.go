@@ -0,0 +1,67 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestAltRoundTripKeepsWrapState(t *testing.T) {
+	screen := gopyte.NewAlternateScreen(5, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// Row 0 soft-wraps; the cursor ends past the last column awaiting a wrap
+	stream.Feed("abcdefghij")
+	before := screen.Clone()
+
+	stream.Feed("\x1b[?1047h0123456789abcdefgh\x1b[?1047l")
+	if diff := screen.DiffString(before); diff != "" {
+		t.Errorf("main screen changed by alternate round trip:\n%s", diff)
+	}
+
+	// The pending wrap is still pending
+	stream.Feed("k")
+	if got := screen.GetDisplay(); got[2] != "k" {
+		t.Errorf("display after round trip: %q", got)
+	}
+	if !screen.IsWrapped(0) || !screen.IsWrapped(1) {
+		t.Errorf("wrapped flags lost: %v %v", screen.IsWrapped(0), screen.IsWrapped(1))
+	}
+}
+
+func TestAltRoundTripKeepsCellWidths(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	stream.Feed("日本語\r\n\x1b7") // 1049 saves the cursor; start with it saved
+	before := screen.Clone()
+
+	// Narrow text over the same cells of the alternate screen
+	stream.Feed("\x1b[?1049habcdefghij\x1b[?1049l")
+	if diff := screen.DiffString(before); diff != "" {
+		t.Errorf("main screen changed by alternate round trip:\n%s", diff)
+	}
+
+	// Width-aware editing still sees the wide characters
+	stream.Feed("\x1b[1;3H\x1b[X")
+	if got := screen.GetDisplayRect(gopyte.Region{Top: 0, Left: 0, Bottom: 0, Right: 5})[0]; got != "日\x00  語\x00" {
+		t.Errorf("erase after round trip: got %q", got)
+	}
+
+	// And the alternate screen starts out narrow again
+	stream.Feed("\x1b[?1049h\x1b[1;2Hx")
+	if got := screen.GetDisplayRect(gopyte.Region{Top: 0, Left: 0, Bottom: 0, Right: 2})[0]; got != " x " {
+		t.Errorf("alternate screen: got %q", got)
+	}
+}
+
+func TestAltAfterResize(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	screen.Resize(20, 5)
+	stream.Feed("\x1b[?1049h\x1b[5;20Hx\x1b[?1049l")
+	if got := screen.GetDisplay(); len(got) != 5 {
+		t.Errorf("lines: got %d", len(got))
+	}
+}
@@ -0,0 +1,52 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestDumpANSI(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 3)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("a\x1b[1;31mbc\x1b[0;4:3;38;2;255;135;0md\x1b[m\r\n\x1b[44m \x1b[m\x1b[2;5H")
+
+	want := "a\x1b[0;1;31mbc\x1b[0;4:3;38;2;255;135;0md\x1b[0m\r\n\x1b[0;44m \x1b[0m\r\n"
+	if got := screen.DumpANSI(gopyte.ANSIOptions{}); got != want {
+		t.Errorf("dump = %q, want %q", got, want)
+	}
+
+	// Lower profiles convert the colors, the cursor goes last
+	want = "a\x1b[0;1;31mbc\x1b[0;4:3;38;5;208md\x1b[0m\r\n\x1b[0;44m \x1b[0m\r\n\x1b[2;5H"
+	if got := screen.DumpANSI(gopyte.ANSIOptions{Profile: gopyte.Profile256, Cursor: true}); got != want {
+		t.Errorf("256-color dump = %q, want %q", got, want)
+	}
+
+	// Feeding the dump to a fresh screen reproduces the display
+	replay := gopyte.NewNativeScreen(10, 3)
+	gopyte.NewStream(replay, false).Feed(screen.DumpANSI(gopyte.ANSIOptions{Cursor: true}))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 10; x++ {
+			if got, want := replay.GetCell(y, x), screen.GetCell(y, x); got != want {
+				t.Errorf("replayed cell %d,%d = %+v, want %+v", y, x, got, want)
+			}
+		}
+	}
+	if x, y := replay.GetCursor(); x != 4 || y != 1 {
+		t.Errorf("replayed cursor = %d,%d, want 4,1", x, y)
+	}
+}
+
+func TestDumpANSIScrollback(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(6, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("one\r\n\x1b[7m中\x1b[m\r\nthree")
+
+	if got, want := screen.DumpANSI(gopyte.ANSIOptions{}), "\x1b[0;7m中\x1b[0m\r\nthree"; got != want {
+		t.Errorf("dump = %q, want %q", got, want)
+	}
+	want := "one\r\n\x1b[0;7m中\x1b[0m\r\nthree"
+	if got := screen.DumpANSI(gopyte.ANSIOptions{Scrollback: true}); got != want {
+		t.Errorf("dump with scrollback = %q, want %q", got, want)
+	}
+}
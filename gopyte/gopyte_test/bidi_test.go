@@ -0,0 +1,61 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestGetDisplayBidi(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"left to right", "plain text", "plain text"},
+		{"hebrew word", "abc שלום def", "abc םולש def"},
+		{"hebrew sentence", "שלום עולם", "םלוע םולש"},
+		{"number after hebrew", "שלום 123", "123 םולש"},
+		{"thousands separator", "שקל 1,000", "1,000 לקש"},
+		{"percent", "הנחה 50%", "50% החנה"},
+		{"arabic digits", "سعر ٣٤", "٣٤ رعس"},
+		{"brackets inside", "א(ב)ג", "ג(ב)א"},
+		{"brackets outside", "(שלום)", "(םולש)"},
+		{"latin digits stay", "v1 2 3", "v1 2 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			screen := gopyte.NewNativeScreen(20, 2)
+			gopyte.NewStream(screen, false).Feed(tt.input)
+
+			if got := screen.GetDisplayBidi()[0]; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			// The screen itself keeps the logical order
+			if got := screen.GetDisplay()[0]; got != tt.input {
+				t.Errorf("GetDisplay: got %q, want %q", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestGetDisplayBidiWide(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	gopyte.NewStream(screen, false).Feed("中 שלום 文́")
+
+	if got, want := strings.TrimRight(screen.GetDisplayBidi()[0], " "), "中 םולש 文́"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDumpANSIBidi(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	gopyte.NewStream(screen, false).Feed("\x1b[1mשלום\x1b[m 42")
+
+	dump := screen.DumpANSI(gopyte.ANSIOptions{Bidi: true})
+	if !strings.HasPrefix(dump, "42 \x1b[0;1mםולש\x1b[0m") {
+		t.Errorf("got %q", dump)
+	}
+	if dump := screen.DumpANSI(gopyte.ANSIOptions{}); !strings.Contains(dump, "שלום") {
+		t.Errorf("without Bidi: got %q", dump)
+	}
+}
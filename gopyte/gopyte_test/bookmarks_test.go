@@ -0,0 +1,62 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestBookmarks(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(20, 3, 100)
+	stream := gopyte.NewStream(screen, false)
+
+	stream.Feed("boot\r\nok\r\n")
+	b, ok := stream.Bookmark("failure", "link down")
+	if !ok || b.Offset != 10 || b.Line != 2 || b.Note != "link down" {
+		t.Fatalf("bookmark = %+v, %v", b, ok)
+	}
+	stream.Feed("LINK DOWN\r\n1\r\n2\r\n3\r\n")
+
+	row, ok := screen.BookmarkRow("failure")
+	if !ok || row != -2 {
+		t.Fatalf("BookmarkRow = %d, %v; want -2", row, ok)
+	}
+	if !screen.ScrollToBookmark("failure") || screen.GetDisplay()[0] != "LINK DOWN" {
+		t.Errorf("after jump: %q", screen.GetDisplay())
+	}
+	screen.ScrollToBottom()
+
+	// Snapshots keep bookmarks; changing one no longer matches
+	snap := screen.Clone()
+	if !snap.Equal(screen) {
+		t.Fatalf("clone differs: %s", snap.DiffString(screen))
+	}
+	if got, ok := snap.GetBookmark("failure"); !ok || got != b {
+		t.Errorf("snapshot bookmark = %+v, %v", got, ok)
+	}
+	stream.Bookmark("later", "")
+	if snap.Equal(screen) {
+		t.Error("new bookmark not seen by Equal")
+	}
+
+	marks := screen.Bookmarks()
+	if len(marks) != 2 || marks[0].Name != "failure" || marks[1].Name != "later" {
+		t.Errorf("Bookmarks = %+v", marks)
+	}
+	if !screen.RemoveBookmark("later") || screen.RemoveBookmark("later") {
+		t.Error("RemoveBookmark")
+	}
+
+	// Lines dropped from the scrollback cannot be found
+	small := gopyte.NewHistoryScreen(20, 2, 1)
+	s2 := gopyte.NewStream(small, false)
+	s2.Bookmark("top", "")
+	s2.Feed("a\r\nb\r\nc\r\n")
+	if _, ok := small.BookmarkRow("top"); ok || small.ScrollToBookmark("top") {
+		t.Error("trimmed bookmark should not resolve")
+	}
+
+	if _, ok := gopyte.NewStream(gopyte.NewMockScreen(), false).Bookmark("x", ""); ok {
+		t.Error("mock screen has no bookmarks")
+	}
+}
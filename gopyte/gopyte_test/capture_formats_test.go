@@ -0,0 +1,88 @@
+package gopyte_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestReadAsciicast(t *testing.T) {
+	cast := `{"version": 2, "width": 100, "height": 30, "title": "demo"}
+[0.25, "o", "hello\r\n"]
+[0.5, "i", "q"]
+[0.75, "m", "chapter 1"]
+[1.0, "o", "\u001b[1mbold"]
+`
+	tr, header, err := gopyte.ReadAsciicast(strings.NewReader(cast))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Width != 100 || header.Height != 30 || header.Title != "demo" {
+		t.Errorf("header: got %+v", header)
+	}
+
+	events := tr.Events()
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3 (marker skipped)", len(events))
+	}
+	if events[2].Data != "\x1b[1mbold" || events[2].Time != time.Second {
+		t.Errorf("last event: got %+v", events[2])
+	}
+	if events[1].Direction != gopyte.DirInput {
+		t.Errorf("input event not decoded")
+	}
+
+	if _, _, err := gopyte.ReadAsciicast(strings.NewReader(`{"version": 1}`)); err == nil {
+		t.Error("expected error for asciicast v1")
+	}
+}
+
+func TestReadTTYRec(t *testing.T) {
+	var buf bytes.Buffer
+	write := func(sec, usec uint32, data string) {
+		hdr := make([]byte, 12)
+		binary.LittleEndian.PutUint32(hdr[0:], sec)
+		binary.LittleEndian.PutUint32(hdr[4:], usec)
+		binary.LittleEndian.PutUint32(hdr[8:], uint32(len(data)))
+		buf.Write(hdr)
+		buf.WriteString(data)
+	}
+	write(1700000000, 0, "$ ")
+	write(1700000001, 500000, "ls\r\n")
+
+	tr, err := gopyte.ReadTTYRec(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := tr.Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Time != 0 || events[1].Time != 1500*time.Millisecond {
+		t.Errorf("timestamps: got %v, %v", events[0].Time, events[1].Time)
+	}
+	if events[1].Data != "ls\r\n" {
+		t.Errorf("data: got %q", events[1].Data)
+	}
+
+	// Truncated record
+	if _, err := gopyte.ReadTTYRec(bytes.NewReader(buf.Bytes()[:buf.Len()-2])); err == nil {
+		t.Error("expected error for truncated ttyrec")
+	}
+}
+
+func TestReadRawCapture(t *testing.T) {
+	tr, err := gopyte.ReadRawCapture(strings.NewReader("\x1b[2Jraw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	screen := gopyte.NewNativeScreen(10, 2)
+	tr.Replay(gopyte.NewStream(screen, false))
+	if screen.GetDisplay()[0] != "raw" {
+		t.Errorf("display: got %q", screen.GetDisplay()[0])
+	}
+}
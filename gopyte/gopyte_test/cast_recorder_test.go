@@ -0,0 +1,60 @@
+package gopyte_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestCastRecorder(t *testing.T) {
+	clock := gopyte.NewFakeClock(time.Unix(1700000000, 0))
+	screen := gopyte.NewNativeScreen(20, 3)
+	var buf bytes.Buffer
+	rec, err := gopyte.NewCastRecorderWithClock(&buf, gopyte.NewStream(screen, false),
+		gopyte.CastHeader{Width: 20, Height: 3, Title: "demo"}, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec.Feed("hello ")
+	clock.Advance(1500 * time.Millisecond)
+	// A character split between writes is written whole
+	rec.Write([]byte("w\xc3"))
+	clock.Advance(250 * time.Millisecond)
+	rec.Write([]byte("\xb6rld"))
+	rec.RecordInput("q")
+	rec.Resize(40, 10)
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"version":2,"width":20,"height":3,"timestamp":1700000000,"title":"demo"}
+[0,"o","hello "]
+[1.5,"o","w"]
+[1.75,"o","örld"]
+[1.75,"i","q"]
+[1.75,"r","40x10"]
+`
+	if got := buf.String(); got != want {
+		t.Errorf("cast =\n%s\nwant\n%s", got, want)
+	}
+	if line := screen.GetDisplay()[0]; line != "hello wörld" {
+		t.Errorf("screen = %q", line)
+	}
+
+	// The recording reads back with the same output and the resize
+	tr, header, err := gopyte.ReadAsciicast(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Width != 20 || header.Title != "demo" || len(tr.Events()) != 5 {
+		t.Errorf("read back header %+v, %d events", header, len(tr.Events()))
+	}
+
+	if _, err := gopyte.NewCastRecorder(&buf, gopyte.NewStream(screen, false), gopyte.CastHeader{}); err == nil {
+		t.Error("no error for a header without a size")
+	}
+}
@@ -0,0 +1,45 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestGetCell(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("a\x1b[1;31m中\x1b[mb")
+
+	cells := screen.GetLineCells(0)
+	if len(cells) != 10 {
+		t.Fatalf("got %d cells, want 10", len(cells))
+	}
+	want := []struct {
+		char  rune
+		width int
+		bold  bool
+	}{{'a', 1, false}, {'中', 2, true}, {0, 0, true}, {'b', 1, false}, {' ', 1, false}}
+	for x, w := range want {
+		c := cells[x]
+		if c.Char != w.char || c.Width != w.width || c.Attrs.Bold != w.bold {
+			t.Errorf("cell %d = %+v, want %q width %d bold %v", x, c, w.char, w.width, w.bold)
+		}
+		if got := screen.GetCell(0, x); got != c {
+			t.Errorf("GetCell(0, %d) = %+v, GetLineCells has %+v", x, got, c)
+		}
+	}
+	if fg := cells[1].Attrs.Fg; fg == cells[0].Attrs.Fg {
+		t.Errorf("wide cell color = %+v, same as the default", fg)
+	}
+
+	if screen.GetLineCells(3) != nil || screen.GetCell(0, 10) != (gopyte.Cell{}) {
+		t.Error("cells outside the screen")
+	}
+
+	native := gopyte.NewNativeScreen(5, 2)
+	gopyte.NewStream(native, false).Feed("\x1b[2;2H\x1b[7mx")
+	if c := native.GetCell(1, 1); c.Char != 'x' || !c.Attrs.Reverse || c.Width != 1 {
+		t.Errorf("native cell = %+v", c)
+	}
+}
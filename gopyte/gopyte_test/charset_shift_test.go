@@ -0,0 +1,100 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	runewidth "github.com/mattn/go-runewidth"
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestLockingShift(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// Leave UTF-8 mode, designate DEC graphics to G1, shift out and back
+	stream.Feed("\x1b%@\x1b)0\x0elqk\x0fab")
+	if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != "┌─┐ab" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSingleShift(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// G2 = DEC graphics; SS2 affects only the next character
+	stream.Feed("\x1b%@\x1b*0\x1bNqq")
+	if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != "─q" {
+		t.Errorf("SS2: got %q", got)
+	}
+
+	// LS3 locks G3 into GL until SI
+	stream.Feed("\r\n\x1b+0\x1boxx\x0fx")
+	if got := strings.TrimRight(screen.GetDisplay()[1], " "); got != "││x" {
+		t.Errorf("LS3: got %q", got)
+	}
+}
+
+func TestShiftedGraphicsWithWideChars(t *testing.T) {
+	// In CJK locales box drawing characters are ambiguous width and
+	// runewidth reports them as two cells
+	saved := runewidth.DefaultCondition.EastAsianWidth
+	runewidth.DefaultCondition.EastAsianWidth = true
+	defer func() { runewidth.DefaultCondition.EastAsianWidth = saved }()
+
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	stream.Feed("\x1b%@\x1b*0日本\x1bNq字\x1b)0\x0eqx\x0fx")
+	if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != "日本─字─│x" {
+		t.Errorf("display: got %q", got)
+	}
+	// 日本 (4) + ─ (1) + 字 (2) + ─│ (2) + x (1)
+	if x, _ := screen.GetCursor(); x != 10 {
+		t.Errorf("cursor column: got %d, want 10", x)
+	}
+	if got := screen.GetDisplayRect(gopyte.Region{Top: 0, Left: 4, Bottom: 0, Right: 4})[0]; got != "─" {
+		t.Errorf("cell 4: got %q", got)
+	}
+}
+
+func TestDECGraphicsInUTF8Mode(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// What ncurses sends for a box corner, split the way a read may split it
+	stream.Feed("\x1b(")
+	stream.Feed("0lqk\x1b(Blqk")
+	if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != "┌─┐lqk" {
+		t.Errorf("got %q", got)
+	}
+
+	// Characters beyond ASCII are already Unicode and pass through
+	stream.Feed("\r\n\x1b(0x é\x1b(B")
+	if got := strings.TrimRight(screen.GetDisplay()[1], " "); got != "│ é" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestDesignationWithIntermediate(t *testing.T) {
+	screen := gopyte.NewMockScreen()
+	stream := gopyte.NewStream(screen, false)
+
+	// ESC ( " > designates a 94-character set (Greek); nothing is drawn
+	stream.Feed("\x1b(\">\x1b)0")
+	want := []string{`DefineCharset["> (]`, "DefineCharset[0 )]"}
+	if strings.Join(screen.Calls, " ") != strings.Join(want, " ") {
+		t.Errorf("calls = %q, want %q", screen.Calls, want)
+	}
+}
+
+func TestResetRestoresCharsets(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 2)
+	stream := gopyte.NewStream(screen, false)
+
+	stream.Feed("\x1b(0\x1bcq")
+	if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != "q" {
+		t.Errorf("after RIS got %q", got)
+	}
+}
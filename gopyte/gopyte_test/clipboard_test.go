@@ -0,0 +1,75 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+type testClipboard struct {
+	data     map[string]string
+	readOnly bool
+}
+
+func (c *testClipboard) SetClipboard(selection string, data []byte) error {
+	if c.readOnly {
+		return gopyte.ErrClipboardDenied
+	}
+	c.data[selection] = string(data)
+	return nil
+}
+
+func (c *testClipboard) GetClipboard(selection string) ([]byte, error) {
+	return []byte(c.data[selection]), nil
+}
+
+func TestClipboardSetAndQuery(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+	tr := gopyte.NewTranscript()
+	screen.SetTranscript(tr)
+
+	// Without a provider nothing happens
+	stream.Feed("\x1b]52;c;aGVsbG8=\x07\x1b]52;c;?\x07")
+	if len(tr.Events()) != 0 {
+		t.Fatalf("answered without a provider: %+v", tr.Events())
+	}
+
+	cb := &testClipboard{data: map[string]string{}}
+	screen.SetClipboardProvider(cb)
+	stream.Feed("\x1b]52;c;aGVsbG8=\x07\x1b]52;;d29ybGQ=\x1b\\")
+	if cb.data["c"] != "hello" || cb.data["s0"] != "world" {
+		t.Errorf("clipboard = %q", cb.data)
+	}
+
+	stream.Feed("\x1b]52;c;?\x07")
+	events := tr.Events()
+	if len(events) != 1 || events[0].Data != "\x1b]52;c;aGVsbG8=\x1b\\" {
+		t.Errorf("reply = %+v", events)
+	}
+
+	// Invalid data clears, as in xterm
+	stream.Feed("\x1b]52;c;!\x07")
+	if v, ok := cb.data["c"]; !ok || v != "" {
+		t.Errorf("clipboard not cleared: %q", cb.data)
+	}
+}
+
+func TestClipboardPolicy(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 3)
+	stream := gopyte.NewStream(screen, false)
+	tr := gopyte.NewTranscript()
+	screen.SetTranscript(tr)
+
+	cb := &testClipboard{data: map[string]string{"c": "secret"}, readOnly: true}
+	screen.SetClipboardProvider(cb)
+	screen.SetResponseGuard(gopyte.DefaultResponseGuard())
+
+	stream.Feed("\x1b]52;c;aGVsbG8=\x07\x1b]52;c;?\x07")
+	if cb.data["c"] != "secret" {
+		t.Errorf("write not refused: %q", cb.data)
+	}
+	if len(tr.Events()) != 0 {
+		t.Errorf("read not refused by the response guard: %+v", tr.Events())
+	}
+}
@@ -0,0 +1,95 @@
+package gopyte_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+var clockStart = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestFakeClockTranscript(t *testing.T) {
+	clock := gopyte.NewFakeClock(clockStart)
+	tr := gopyte.NewTranscriptWithClock(clock)
+	tr.RecordOutput("a")
+	clock.Advance(1500 * time.Millisecond)
+	tr.RecordInput("b")
+
+	events := tr.Events()
+	if events[0].Time != 0 || events[1].Time != 1500*time.Millisecond {
+		t.Errorf("timestamps: got %v, %v", events[0].Time, events[1].Time)
+	}
+}
+
+func TestFakeClockPlayer(t *testing.T) {
+	tr := buildTranscript(t, 4) // Events at 0, 0.1, 0.2, 0.25 (input), 0.3
+	clock := gopyte.NewFakeClock(clockStart)
+	player := gopyte.NewPlayer(tr, gopyte.NewNativeScreen(20, 5))
+	player.SetClock(clock)
+
+	done := make(chan error)
+	go func() { done <- player.Play(context.Background()) }()
+
+	// Play blocks on the clock until it is advanced
+	waitFor(t, func() bool { return clock.Waiters() == 1 })
+	if player.Position() != 1 {
+		t.Fatalf("position before advancing: got %d, want 1", player.Position())
+	}
+	for player.Position() < player.Len() {
+		waitFor(t, func() bool { return clock.Waiters() == 1 || player.Position() == player.Len() })
+		clock.Advance(100 * time.Millisecond)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	clock := gopyte.NewFakeClock(clockStart)
+	path := filepath.Join(t.TempDir(), "session.log")
+	f, err := gopyte.OpenRotatingFile(path, gopyte.RotateOptions{MaxAge: time.Hour, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("first\n"))
+	clock.Advance(time.Hour)
+	f.Write([]byte("second\n"))
+
+	backup := filepath.Join(filepath.Dir(path), "session-20240102-040405.log")
+	if data, err := os.ReadFile(backup); err != nil || string(data) != "first\n" {
+		t.Errorf("backup %s: %q, %v", backup, data, err)
+	}
+}
+
+func TestBlinkOn(t *testing.T) {
+	clock := gopyte.NewFakeClock(clockStart)
+	screen := gopyte.NewNativeScreen(10, 2)
+	screen.SetClock(clock)
+
+	phase := screen.BlinkOn()
+	clock.Advance(gopyte.BlinkInterval)
+	if screen.BlinkOn() == phase {
+		t.Error("blink phase did not flip after BlinkInterval")
+	}
+	clock.Advance(gopyte.BlinkInterval)
+	if screen.BlinkOn() != phase {
+		t.Error("blink phase did not flip back")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,240 @@
+package gopyte_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+const (
+	family   = "👨‍👩‍👧"
+	flagUS   = "🇺🇸"
+	flagGB   = "🇬🇧"
+	thumbsUp = "👍🏽"
+)
+
+func TestClustersStayTogether(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed(family + "|" + thumbsUp + "|" + flagUS + flagGB + "|")
+
+	if got, want := screen.GetDisplay()[0], family+"|"+thumbsUp+"|"+flagUS+flagGB+"|"; !strings.HasPrefix(got, want) {
+		t.Errorf("display: got %q, want %q", got, want)
+	}
+
+	// A ZWJ sequence and a skin tone take the cells of their first emoji
+	if c := screen.GetCell(0, 0); c.Text() != family || c.Width != 2 {
+		t.Errorf("family cell: got %q, width %d", c.Text(), c.Width)
+	}
+	if c := screen.GetCell(0, 2); c.Char != '|' || c.Cluster != "" {
+		t.Errorf("after family: got %+v", c)
+	}
+	if c := screen.GetCell(0, 3); c.Text() != thumbsUp {
+		t.Errorf("skin tone cell: got %q", c.Text())
+	}
+
+	// Regional indicators pair up, one flag per cell
+	cells := screen.GetLineCells(0)
+	var flags []string
+	for _, c := range cells[6:] {
+		if c.Char == '|' {
+			break
+		}
+		flags = append(flags, c.Text())
+	}
+	if want := []string{flagUS, flagGB}; !reflect.DeepEqual(flags, want) {
+		t.Errorf("flags: got %q, want %q", flags, want)
+	}
+}
+
+func TestClustersInExports(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("\x1b[1m" + family + "\x1b[m ok\r\n" + flagUS)
+
+	if dump := screen.DumpANSI(gopyte.ANSIOptions{}); !strings.Contains(dump, family) {
+		t.Errorf("ANSI dump: got %q", dump)
+	}
+	if html := screen.ExportHTML(gopyte.HTMLOptions{}); !strings.Contains(html, family) {
+		t.Errorf("HTML: got %q", html)
+	}
+	if runs := screen.GetStyledDisplay(); len(runs[0]) == 0 || runs[0][0].Text != family {
+		t.Errorf("styled runs: got %+v", runs[0])
+	}
+	if m := screen.FindString(family + " ok"); len(m) != 1 || m[0].Spans[0].Left != 0 || m[0].Spans[0].Right != 4 {
+		t.Errorf("find: got %+v", m)
+	}
+	lines := screen.AppendDisplay(nil)
+	if want := screen.GetDisplay(); !reflect.DeepEqual(lines, want) {
+		t.Errorf("AppendDisplay: got %q, want %q", lines, want)
+	}
+
+	screen.StartSelection(0, 0)
+	screen.ExtendSelection(19, 1)
+	if got := screen.SelectionText(); got != family+" ok\n"+flagUS {
+		t.Errorf("selection: got %q", got)
+	}
+
+	// Clusters go into the scrollback with their line
+	stream.Feed("\r\n\r\n")
+	if dump := screen.DumpANSI(gopyte.ANSIOptions{Scrollback: true}); !strings.Contains(dump, family) {
+		t.Errorf("scrollback dump: got %q", dump)
+	}
+}
+
+func TestClustersOverwritten(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	stream.Feed(family + flagUS + "\rabc")
+	if got := screen.GetDisplay()[0]; strings.ContainsAny(got, "‍👩👧🇺🇸") {
+		t.Errorf("overwritten with ASCII: got %q", got)
+	}
+	stream.Feed("\r" + family + "\r中")
+	if got := screen.GetDisplay()[0]; strings.Contains(got, "‍") {
+		t.Errorf("overwritten with a wide character: got %q", got)
+	}
+	stream.Feed("\r" + thumbsUp + "\x1b[2K")
+	if got := screen.GetDisplay()[0]; strings.Trim(got, " ") != "" {
+		t.Errorf("erased: got %q", got)
+	}
+}
+
+func TestClusterAcrossWrap(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(4, 2, 10)
+	gopyte.NewStream(screen, false).Feed("ab👍🏽")
+
+	// The modifier arrives with a wrap pending and still joins
+	if c := screen.GetCell(0, 2); c.Text() != thumbsUp {
+		t.Errorf("got %q", c.Text())
+	}
+	if x, y := screen.GetCursor(); y != 0 || x != 3 {
+		t.Errorf("cursor: got %d,%d", x, y)
+	}
+}
+
+func TestCombiningMarksKept(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(6, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("été 中̣\r\ńx")
+
+	if got, want := screen.GetDisplay()[0], "été 中̣"; got != want {
+		t.Errorf("display: got %q, want %q", got, want)
+	}
+	if c := screen.GetCell(0, 0); c.Text() != "é" || c.Width != 1 {
+		t.Errorf("accented cell: got %q, width %d", c.Text(), c.Width)
+	}
+	// The mark goes on the wide character, not its right half
+	if c := screen.GetCell(0, 4); c.Text() != "中̣" {
+		t.Errorf("wide cell: got %q", c.Text())
+	}
+	if x, y := screen.GetCursor(); y != 1 || x != 1 {
+		t.Errorf("cursor: got %d,%d", x, y)
+	}
+	// With nothing before it, a mark is dropped
+	if got := strings.TrimRight(screen.GetDisplay()[1], " "); got != "x" {
+		t.Errorf("line start: got %q", got)
+	}
+	if m := screen.FindString("té"); len(m) != 1 || m[0].Spans[0].Left != 1 || m[0].Spans[0].Right != 2 {
+		t.Errorf("find: got %+v", m)
+	}
+}
+
+func TestCombiningMarkAfterWrap(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(3, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// The cursor stays on the last column until the next character
+	stream.Feed("abé")
+	if c := screen.GetCell(0, 2); c.Text() != "é" {
+		t.Errorf("pending wrap: got %q", c.Text())
+	}
+	stream.Feed("ç")
+	if got := screen.GetDisplay(); got[0] != "abé" || strings.TrimRight(got[1], " ") != "ç" {
+		t.Errorf("display: got %q", got)
+	}
+}
+
+func TestVariationSelectorWidth(t *testing.T) {
+	tests := []struct {
+		name, input string
+		columns     int
+		cell        int // Column of the selected character
+		width       int
+		text        string
+		cursor      int
+		display     string
+	}{
+		{"emoji heart", "❤️b", 10, 0, 2, "❤️", 3, "❤️b"},
+		{"emoji sun", "a☀️", 10, 1, 2, "☀️", 3, "a☀️"},
+		{"keycap", "#️⃣", 10, 0, 2, "#️⃣", 2, "#️⃣"},
+		{"text face", "😀︎b", 10, 0, 1, "😀︎", 2, "😀︎b"},
+		{"wide after selector", "❤️😀", 10, 0, 2, "❤️", 4, "❤️😀"},
+		{"last column stays narrow", "ab❤️", 3, 2, 1, "❤️", 2, "ab❤️"},
+		{"text at last columns", "a😀︎b", 3, 1, 1, "😀︎", 2, "a😀︎b"},
+		{"widened over text", "abc\r❤️", 10, 0, 2, "❤️", 2, "❤️c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			screen := gopyte.NewWideCharScreen(tt.columns, 2, 10)
+			gopyte.NewStream(screen, false).Feed(tt.input)
+
+			if c := screen.GetCell(0, tt.cell); c.Text() != tt.text || c.Width != tt.width {
+				t.Errorf("cell: got %q, width %d; want %q, width %d", c.Text(), c.Width, tt.text, tt.width)
+			}
+			if x, _ := screen.GetCursor(); x != tt.cursor {
+				t.Errorf("cursor: got %d, want %d", x, tt.cursor)
+			}
+			if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != tt.display {
+				t.Errorf("display: got %q, want %q", got, tt.display)
+			}
+		})
+	}
+}
+
+func TestClustersInSnapshots(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed(family + " e")
+	prev := screen.Snapshot()
+	stream.Feed("\u0301\r\n" + thumbsUp + "\r\n")
+	next := screen.Snapshot()
+
+	data, err := json.Marshal(screen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := gopyte.NewWideCharScreen(20, 2, 10)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if !restored.Equal(screen) {
+		t.Errorf("restored screen differs:\n%s", restored.DiffString(screen))
+	}
+	if c := restored.GetCell(0, 0); c.Text() != thumbsUp {
+		t.Errorf("restored cell: got %q", c.Text())
+	}
+
+	// Patches carry the clusters of the lines they send
+	if err := prev.Apply(gopyte.Diff(prev, next)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(prev, next) {
+		t.Errorf("patched snapshot: got %+v, want %+v", prev.Screen.Lines, next.Screen.Lines)
+	}
+
+	// A cluster is part of the state compared, on screen and in the
+	// scrollback
+	other := gopyte.NewWideCharScreen(20, 2, 10)
+	gopyte.NewStream(other, false).Feed(family + " e\r\n👍\r\n")
+	if other.Equal(screen) {
+		t.Error("screens differing in a cluster compare equal")
+	}
+	want := "clusters at (0, 0): \"🏽\" != \"\"\nhistory line 0 clusters at 3: \"\u0301\" != \"\""
+	if got := screen.DiffString(other); got != want {
+		t.Errorf("diff: got %q, want %q", got, want)
+	}
+}
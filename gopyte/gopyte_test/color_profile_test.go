@@ -0,0 +1,63 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestColorProfileConvert(t *testing.T) {
+	tests := []struct {
+		profile gopyte.ColorProfile
+		in, out string
+	}{
+		{gopyte.ProfileTrueColor, "ff8700", "#ff8700"},
+		{gopyte.Profile256, "ff8700", "color208"},
+		{gopyte.Profile256, "#808080", "color244"},
+		{gopyte.Profile256, "red", "red"},
+		{gopyte.Profile256, "color42", "color42"},
+		{gopyte.Profile16, "ff0000", "brightred"},
+		{gopyte.Profile16, "c00000", "red"},
+		{gopyte.Profile16, "color9", "brightred"},
+		{gopyte.Profile16, "color21", "blue"},
+		{gopyte.Profile16, "yellow", "brown"},
+		{gopyte.Profile16, "default", "default"},
+		{gopyte.ProfileMono, "red", "default"},
+	}
+	for _, tt := range tests {
+		in, _ := gopyte.ParseColor(tt.in)
+		if got := tt.profile.Convert(in).String(); got != tt.out {
+			t.Errorf("%s.Convert(%q) = %q, want %q", tt.profile, tt.in, got, tt.out)
+		}
+	}
+
+	fg := gopyte.DirectColor(gopyte.RGB{G: 0xff})
+	a := gopyte.Profile16.Attrs(gopyte.Attributes{Fg: fg, Bg: gopyte.IndexedColor(0), Bold: true})
+	if a.Fg != gopyte.NamedColor(10) || a.Bg != gopyte.NamedColor(0) || !a.Bold {
+		t.Errorf("Attrs = %+v", a)
+	}
+}
+
+func TestColorProfileSGR(t *testing.T) {
+	tests := []struct {
+		profile    gopyte.ColorProfile
+		color      string
+		background bool
+		want       string
+	}{
+		{gopyte.ProfileTrueColor, "ff8700", false, "38;2;255;135;0"},
+		{gopyte.ProfileTrueColor, "ff8700", true, "48;2;255;135;0"},
+		{gopyte.Profile256, "ff8700", false, "38;5;208"},
+		{gopyte.Profile16, "ff8700", true, "43"},
+		{gopyte.Profile16, "red", false, "31"},
+		{gopyte.Profile256, "color3", true, "43"},
+		{gopyte.Profile16, "default", false, ""},
+		{gopyte.ProfileMono, "red", false, ""},
+	}
+	for _, tt := range tests {
+		color, _ := gopyte.ParseColor(tt.color)
+		if got := tt.profile.SGR(color, tt.background); got != tt.want {
+			t.Errorf("%s.SGR(%q, %v) = %q, want %q", tt.profile, tt.color, tt.background, got, tt.want)
+		}
+	}
+}
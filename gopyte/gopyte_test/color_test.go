@@ -0,0 +1,56 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestColorStringForm(t *testing.T) {
+	cases := []struct {
+		color gopyte.Color
+		str   string
+		kind  gopyte.ColorKind
+		index int
+	}{
+		{gopyte.DefaultColor(), "default", gopyte.ColorDefault, -1},
+		{gopyte.NamedColor(1), "red", gopyte.ColorNamed, 1},
+		{gopyte.NamedColor(11), "brightbrown", gopyte.ColorNamed, 11},
+		{gopyte.IndexedColor(196), "color196", gopyte.ColorIndexed, 196},
+		{gopyte.DirectColor(gopyte.RGB{R: 0xff, G: 0x87}), "#ff8700", gopyte.ColorDirect, -1},
+	}
+	for _, c := range cases {
+		if c.color.String() != c.str || c.color.Kind() != c.kind || c.color.Index() != c.index {
+			t.Errorf("%q: kind %v, index %d", c.color, c.color.Kind(), c.color.Index())
+		}
+		if back, ok := gopyte.ParseColor(c.str); !ok || back != c.color {
+			t.Errorf("ParseColor(%q) = %v, %v", c.str, back, ok)
+		}
+	}
+
+	if c, ok := gopyte.ParseColor("yellow"); !ok || c != gopyte.NamedColor(3) {
+		t.Errorf("yellow = %v", c)
+	}
+	for _, bad := range []string{"bogus", "color256", "#12345"} {
+		if _, ok := gopyte.ParseColor(bad); ok {
+			t.Errorf("%q parsed", bad)
+		}
+	}
+
+	// The zero value is the default color, so attributes start plain
+	if (gopyte.Attributes{}) != gopyte.DefaultAttributes() {
+		t.Error("zero Attributes differ from DefaultAttributes")
+	}
+}
+
+func TestColorRGB(t *testing.T) {
+	if _, ok := gopyte.DefaultColor().RGB(); ok {
+		t.Error("default color has a value")
+	}
+	if rgb, _ := gopyte.NamedColor(1).RGB(); rgb.Hex() != "#cd0000" {
+		t.Errorf("red = %s", rgb.Hex())
+	}
+	if rgb, _ := gopyte.IndexedColor(208).RGB(); rgb.Hex() != "#ff8700" {
+		t.Errorf("color208 = %s", rgb.Hex())
+	}
+}
@@ -0,0 +1,88 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestInsertDeleteColumns(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(6, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("abcdef\r\nuvwxyz")
+
+	stream.Feed("\x1b[1;3H\x1b[2'}")
+	want := []string{"ab  cd", "uv  wx"}
+	for y, line := range screen.GetDisplay() {
+		if line != want[y] {
+			t.Errorf("DECIC line %d: got %q, want %q", y, line, want[y])
+		}
+	}
+	if x, y := screen.GetCursor(); x != 2 || y != 0 {
+		t.Errorf("cursor moved to (%d, %d)", x, y)
+	}
+
+	stream.Feed("\x1b[3'~")
+	want = []string{"abd", "uvx"}
+	for y, line := range screen.GetDisplay() {
+		if line != want[y] {
+			t.Errorf("DECDC line %d: got %q, want %q", y, line, want[y])
+		}
+	}
+
+	// Default count is one
+	stream.Feed("\x1b[1;1H\x1b['~")
+	if got := screen.GetDisplay()[0]; got != "bd" {
+		t.Errorf("DECDC default: got %q", got)
+	}
+}
+
+func TestColumnsSplitWideChars(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(6, 1, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// Inserting in the middle of 日 blanks it
+	stream.Feed("a日bc\x1b[1;3H\x1b['}")
+	if got := screen.GetDisplay()[0]; got != "a   bc" {
+		t.Errorf("DECIC: got %q", got)
+	}
+
+	// Deleting the first half of 本 blanks the second
+	stream.Feed("\x1b[2J\x1b[Hx本yz\x1b[1;2H\x1b['~")
+	if got := screen.GetDisplay()[0]; got != "x yz  " {
+		t.Errorf("DECDC: got %q", got)
+	}
+
+	// A wide character pushed half past the margin is blanked
+	stream.Feed("\x1b[2J\x1b[Habcd語\x1b[1;1H\x1b['}")
+	if got := screen.GetDisplay()[0]; got != " abcd " {
+		t.Errorf("DECIC at margin: got %q", got)
+	}
+}
+
+func TestColumnsMoveCellMeta(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(8, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("\x1b]8;;http://x\x07ab\x1b]8;;\x07e\u0301f")
+
+	// Links and combining marks move with their cells
+	stream.Feed("\x1b[1;1H\x1b[2'}")
+	for x, want := range []bool{false, false, true, true, false} {
+		if _, ok := screen.CellHyperlink(0, x); ok != want {
+			t.Errorf("DECIC: link at %d is %v, want %v", x, ok, want)
+		}
+	}
+	if c := screen.GetCell(0, 4); c.Text() != "e\u0301" {
+		t.Errorf("DECIC: cell 4 is %q", c.Text())
+	}
+
+	stream.Feed("\x1b[3'~")
+	for x, want := range []bool{true, false} {
+		if _, ok := screen.CellHyperlink(0, x); ok != want {
+			t.Errorf("DECDC: link at %d is %v, want %v", x, ok, want)
+		}
+	}
+	if c := screen.GetCell(0, 1); c.Text() != "e\u0301" {
+		t.Errorf("DECDC: cell 1 is %q", c.Text())
+	}
+}
@@ -0,0 +1,68 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestScreenCloneEqual(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 5, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("hello\r\n\x1b[31mworld\x1b[0m 中文")
+
+	c := screen.Clone()
+	if !screen.Equal(c) {
+		t.Fatalf("clone differs:\n%s", screen.DiffString(c))
+	}
+
+	// The copy is independent of the original
+	gopyte.NewStream(c, false).Feed("!")
+	if screen.Equal(c) {
+		t.Fatal("writing to the clone changed the original")
+	}
+	if got := screen.DiffString(c); !strings.Contains(got, "line 1:") || !strings.Contains(got, "cursor:") {
+		t.Errorf("DiffString: got %q", got)
+	}
+}
+
+func TestScreenEqualAttributesAndCursor(t *testing.T) {
+	a := gopyte.NewHistoryScreen(10, 3, 10)
+	b := gopyte.NewHistoryScreen(10, 3, 10)
+	gopyte.NewStream(a, false).Feed("\x1b[1mab")
+	gopyte.NewStream(b, false).Feed("ab")
+
+	// Same text, different attributes: GetDisplay cannot tell them apart
+	if a.GetDisplay()[0] != b.GetDisplay()[0] {
+		t.Fatal("text should match")
+	}
+	diff := a.DiffString(b)
+	if a.Equal(b) || !strings.Contains(diff, "attrs at (0, 0)") {
+		t.Errorf("attribute difference not reported: %q", diff)
+	}
+
+	b.Reset()
+	gopyte.NewStream(b, false).Feed("\x1b[1mab\x1b[H")
+	if diff := a.DiffString(b); diff != "cursor: (2, 0) != (0, 0)" {
+		t.Errorf("cursor difference: got %q", diff)
+	}
+
+	if d := a.DiffString(gopyte.NewHistoryScreen(5, 3, 10)); d != "size: 10x3 != 5x3" {
+		t.Errorf("size difference: got %q", d)
+	}
+}
+
+func TestScreenEqualAlternate(t *testing.T) {
+	a := gopyte.NewAlternateScreen(10, 3, 10)
+	gopyte.NewStream(a, false).Feed("main\x1b[?1049h")
+	b := a.Clone()
+	if !a.Equal(b) {
+		t.Fatalf("clone differs:\n%s", a.DiffString(b))
+	}
+
+	gopyte.NewStream(b, false).Feed("\x1b[?1049l")
+	if !strings.Contains(a.DiffString(b), "alternate screen: true != false") {
+		t.Errorf("DiffString: got %q", a.DiffString(b))
+	}
+}
@@ -0,0 +1,88 @@
+package gopyte_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestCompositor(t *testing.T) {
+	left := gopyte.NewHistoryScreen(10, 10, 100)
+	right := gopyte.NewWideCharScreen(10, 10, 100)
+	var leftIn, rightIn bytes.Buffer
+
+	c := gopyte.NewCompositor(20, 5)
+	c.Add("a", left, &leftIn)
+	p := c.Add("b", right, &rightIn)
+	c.Layout()
+
+	if w, h := p.InnerSize(); w != 8 || h != 3 {
+		t.Fatalf("inner size = %dx%d, want 8x3", w, h)
+	}
+	gopyte.NewStream(left, false).Feed("hi\r\nthere")
+	gopyte.NewStream(right, false).Feed("\x1b[1mok\x1b[0m 中")
+
+	want := []string{
+		"┌─ a ────┐┌─ b ────┐",
+		"│hi      ││ok 中   │",
+		"│there   ││        │",
+		"│        ││        │",
+		"└────────┘└────────┘",
+	}
+	if got := c.Display(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("display:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	cells := c.Cells()
+	if !cells[1][11].Attrs.Bold || cells[1][13].Attrs.Bold {
+		t.Error("pane attributes not carried over")
+	}
+	if !cells[0][0].Attrs.Bold || cells[0][10].Attrs.Bold {
+		t.Error("focused border should be highlighted")
+	}
+
+	c.Write([]byte("x"))
+	if !c.FocusAt(15, 2) {
+		t.Fatal("FocusAt missed pane b")
+	}
+	c.Write([]byte("y"))
+	if leftIn.String() != "x" || rightIn.String() != "y" {
+		t.Errorf("input routed to %q and %q", leftIn.String(), rightIn.String())
+	}
+
+	if x, y, ok := c.Cursor(); !ok || x != 16 || y != 1 {
+		t.Errorf("cursor = (%d, %d, %v), want (16, 1, true)", x, y, ok)
+	}
+
+	c.Remove(p)
+	if c.Focused() == nil || c.Focused().Title != "a" {
+		t.Error("focus should fall back to the remaining pane")
+	}
+	c.Layout()
+	if w, _ := c.Focused().InnerSize(); w != 18 {
+		t.Errorf("single pane width = %d, want 18", w)
+	}
+}
+
+func TestCompositorRemoveKeepsFocus(t *testing.T) {
+	c := gopyte.NewCompositor(30, 5)
+	a := c.Add("a", gopyte.NewNativeScreen(8, 3), nil)
+	b := c.Add("b", gopyte.NewNativeScreen(8, 3), nil)
+	c.Add("c", gopyte.NewNativeScreen(8, 3), nil)
+	c.Focus(2)
+
+	// Removing a pane before the focused one leaves the focus on it
+	c.Remove(a)
+	if got := c.Focused(); got == nil || got.Title != "c" {
+		t.Errorf("focused after removing a pane before it: %v", got)
+	}
+
+	// Removing the focused pane passes the focus on
+	c.Focus(0)
+	c.Remove(b)
+	if got := c.Focused(); got == nil || got.Title != "c" {
+		t.Errorf("focused after removing it: %v", got)
+	}
+}
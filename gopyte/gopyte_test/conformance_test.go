@@ -0,0 +1,91 @@
+package gopyte_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+	"github.com/scottpeterman/gopyte/gopyte/conformance"
+)
+
+// knownGaps are the conformance cases gopyte fails today. A case failing
+// that is not listed is a regression; a listed case passing should be
+// taken off the list.
+var knownGaps = []string{
+	"erase: erase uses the background color",
+	"editing: IL inside the scroll region",
+	"editing: DL inside the scroll region",
+	"editing: IL outside the scroll region does nothing",
+	"editing: IRM",
+	"scrolling: DECSTBM homes the cursor",
+	"scrolling: LF scrolls the region only",
+	"scrolling: RI scrolls the region only",
+	"scrolling: LF below the region stops at the bottom",
+	"scrolling: SU inside the region",
+	"scrolling: LF keeps the column",
+	"origin mode: CUP is relative to the region",
+	"origin mode: CUP clamps to the region",
+	"origin mode: DECOM homes the cursor",
+	"save and restore: DECRC without DECSC homes",
+	"character sets: SO/SI",
+	"rendition: bright colors",
+	"reports: DECXCPR",
+	"screen modes: DECALN",
+}
+
+func TestConformance(t *testing.T) {
+	report := conformance.Run(nil)
+	var failed []string
+	for _, res := range report.Failures() {
+		name := res.Case.Capability + ": " + res.Case.Name
+		failed = append(failed, name)
+		if !slices.Contains(knownGaps, name) {
+			t.Errorf("%s: %v", name, res.Err)
+		}
+	}
+	for _, name := range knownGaps {
+		if !slices.Contains(failed, name) {
+			t.Errorf("%s passes now; remove it from knownGaps", name)
+		}
+	}
+
+	passed, total := report.Passed()
+	if total != len(conformance.Cases) || passed != total-len(failed) {
+		t.Errorf("Passed() = %d, %d with %d failures of %d cases", passed, total, len(failed), len(conformance.Cases))
+	}
+	sum := 0
+	for _, c := range report.Capabilities() {
+		sum += c.Total
+	}
+	if sum != total {
+		t.Errorf("capabilities cover %d cases, want %d", sum, total)
+	}
+	if s := report.String(); !strings.Contains(s, "cursor movement") || !strings.Contains(s, "FAIL ") {
+		t.Errorf("report:\n%s", s)
+	}
+}
+
+func TestConformanceCustomScreen(t *testing.T) {
+	cases := []conformance.Case{
+		{Capability: "text", Name: "draws", Input: "hi", Screen: []string{"hi"}, Cursor: &conformance.Pos{X: 2}},
+		{Capability: "text", Name: "wrong", Input: "hi", Screen: []string{"ho"}},
+		{Capability: "checks", Name: "check", Input: "x", Check: func(conformance.Target) error {
+			return errors.New("no")
+		}},
+	}
+	var sizes []int
+	report := conformance.RunCases(cases, func(columns, lines int) conformance.Target {
+		sizes = append(sizes, columns, lines)
+		return gopyte.NewNativeScreen(columns, lines)
+	})
+	if !slices.Equal(sizes, []int{10, 5, 10, 5, 10, 5}) {
+		t.Errorf("sizes = %v", sizes)
+	}
+	got := report.Capabilities()
+	want := []conformance.Capability{{Name: "text", Passed: 1, Total: 2}, {Name: "checks", Passed: 0, Total: 1}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Capabilities() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestSoftWrapFlags(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(10, 4, 100)
+	stream := gopyte.NewStream(screen, false)
+
+	stream.Feed("0123456789ABC\r\nshort\r\n")
+
+	if !screen.IsWrapped(0) {
+		t.Error("line 0 should be soft-wrapped")
+	}
+	if screen.IsWrapped(1) || screen.IsWrapped(2) {
+		t.Error("hard line breaks must not be marked as wrapped")
+	}
+
+	// Scrolling carries the flag along with its line
+	stream.Feed("x\r\ny\r\n")
+	if screen.IsWrapped(0) {
+		t.Error("flag should have scrolled off with its line")
+	}
+
+	stream.Feed("\x1b[2J")
+	for y := 0; y < 4; y++ {
+		if screen.IsWrapped(y) {
+			t.Errorf("line %d still wrapped after ED 2", y)
+		}
+	}
+}
+
+func TestConPTYMode(t *testing.T) {
+	// conhost repaint: fill a row, then CUP to the next row instead of wrapping
+	repaint := "\x1b[?9001h\x1b[?25l\x1b[H0123456789\x1b[2;1Hwrapped\x1b[3;1Hnext\x1b[?25h"
+
+	t.Run("Disabled", func(t *testing.T) {
+		screen := gopyte.NewNativeScreen(10, 4)
+		stream := gopyte.NewStream(screen, false)
+		stream.Feed(repaint)
+
+		if screen.IsWrapped(0) {
+			t.Error("wrap heuristic should be off by default")
+		}
+		if !screen.IsWin32InputMode() {
+			t.Error("DECSET 9001 should be tracked")
+		}
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		screen := gopyte.NewNativeScreen(10, 4)
+		screen.SetConPTYMode(true)
+		stream := gopyte.NewStream(screen, false)
+		stream.Feed(repaint)
+
+		if !screen.IsWrapped(0) {
+			t.Error("full row followed by CUP to next row should be a soft wrap")
+		}
+		if screen.IsWrapped(1) {
+			t.Error("partial row followed by CUP must not be a soft wrap")
+		}
+
+		display := screen.GetDisplay()
+		want := []string{"0123456789", "wrapped", "next", ""}
+		for i := range want {
+			if display[i] != want[i] {
+				t.Errorf("line %d: got %q, want %q", i, display[i], want[i])
+			}
+		}
+
+		stream.Feed("\x1b[?9001l")
+		if screen.IsWin32InputMode() {
+			t.Error("DECRST 9001 should clear win32-input-mode")
+		}
+	})
+}
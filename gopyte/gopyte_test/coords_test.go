@@ -0,0 +1,92 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+var metrics = gopyte.CellMetrics{CellWidth: 10, CellHeight: 20, OriginX: 5, OriginY: 5}
+
+func TestPixelToCell(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+
+	tests := []struct {
+		px, py float64
+		x, y   int
+	}{
+		{5, 5, 0, 0},
+		{14.9, 24.9, 0, 0},
+		{15, 25, 1, 1},
+		{-100, -100, 0, 0},
+		{5000, 5000, 79, 23},
+	}
+	for _, tt := range tests {
+		if x, y := screen.PixelToCell(metrics, tt.px, tt.py); x != tt.x || y != tt.y {
+			t.Errorf("PixelToCell(%v, %v) = (%d, %d), want (%d, %d)", tt.px, tt.py, x, y, tt.x, tt.y)
+		}
+	}
+
+	if x, _ := screen.PixelToBoundary(metrics, 19, 5); x != 1 {
+		t.Errorf("boundary left of middle: got %d, want 1", x)
+	}
+	if x, _ := screen.PixelToBoundary(metrics, 21, 5); x != 2 {
+		t.Errorf("boundary right of middle: got %d, want 2", x)
+	}
+	if x, _ := screen.PixelToBoundary(metrics, 5000, 5); x != 80 {
+		t.Errorf("right edge: got %d, want 80", x)
+	}
+
+	r := screen.CellRect(metrics, 3, 2)
+	if r != (gopyte.PixelRect{X: 35, Y: 45, Width: 10, Height: 20}) {
+		t.Errorf("CellRect: got %+v", r)
+	}
+}
+
+func TestPixelToCellWide(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("a日b")
+
+	// Both halves of 日 (cells 1 and 2) map to cell 1
+	for _, px := range []float64{16, 24, 26, 34} {
+		if x, _ := screen.PixelToCell(metrics, px, 5); x != 1 {
+			t.Errorf("PixelToCell(%v) = %d, want 1", px, x)
+		}
+	}
+	if x, _ := screen.PixelToCell(metrics, 36, 5); x != 3 {
+		t.Errorf("after wide char: got %d, want 3", x)
+	}
+
+	// Boundaries never split 日
+	if x, _ := screen.PixelToBoundary(metrics, 24, 5); x != 1 {
+		t.Errorf("boundary in left half: got %d, want 1", x)
+	}
+	if x, _ := screen.PixelToBoundary(metrics, 26, 5); x != 3 {
+		t.Errorf("boundary in right half: got %d, want 3", x)
+	}
+
+	want := gopyte.PixelRect{X: 15, Y: 5, Width: 20, Height: 20}
+	if r := screen.CellRect(metrics, 2, 0); r != want {
+		t.Errorf("CellRect of right half: got %+v, want %+v", r, want)
+	}
+}
+
+func TestPixelToCellDoubleWidth(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	m := metrics
+	m.DoubleWidth = func(y int) bool { return y == 1 }
+
+	if x, y := screen.PixelToCell(m, 45, 25); x != 2 || y != 1 {
+		t.Errorf("double width row: got (%d, %d), want (2, 1)", x, y)
+	}
+	if x, _ := screen.PixelToCell(m, 5000, 25); x != 39 {
+		t.Errorf("double width row clamps to half the columns: got %d", x)
+	}
+	if x, _ := screen.PixelToCell(m, 45, 5); x != 4 {
+		t.Errorf("single width row: got %d, want 4", x)
+	}
+	if r := screen.CellRect(m, 2, 1); r.X != 45 || r.Width != 20 {
+		t.Errorf("CellRect: got %+v", r)
+	}
+}
@@ -0,0 +1,73 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestRegisterCSIHandler(t *testing.T) {
+	screen := gopyte.NewMockScreen()
+	stream := gopyte.NewStream(screen, false)
+
+	type call struct {
+		params []int
+		prefix string
+	}
+	var calls []call
+	stream.RegisterCSIHandler('z', "$", func(params []int, prefix string) {
+		calls = append(calls, call{params, prefix})
+	})
+
+	stream.Feed("\x1b[1;2$z\x1b[>7$z")
+
+	want := []call{{[]int{1, 2}, ""}, {[]int{7}, ">"}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("handler calls: got %+v, want %+v", calls, want)
+	}
+
+	// Same final without the intermediate is a different sequence
+	screen.Calls = nil
+	stream.Feed("\x1b[3z")
+	if len(calls) != 2 {
+		t.Errorf("handler should not fire without intermediate")
+	}
+	if len(screen.Calls) != 1 || !strings.HasPrefix(screen.Calls[0], "Debug[Unknown CSI sequence:") {
+		t.Errorf("unregistered sequence should reach Debug, got %v", screen.Calls)
+	}
+
+	// Custom handlers override built-ins; removing them restores the default
+	overridden := false
+	stream.RegisterCSIHandler('H', "", func(params []int, prefix string) { overridden = true })
+	screen.Calls = nil
+	stream.Feed("\x1b[5;5H")
+	if !overridden || len(screen.Calls) != 0 {
+		t.Errorf("override not applied: %v", screen.Calls)
+	}
+
+	stream.RegisterCSIHandler('H', "", nil)
+	screen.Calls = nil
+	stream.Feed("\x1b[5;5H")
+	if len(screen.Calls) != 1 || screen.Calls[0] != "CursorPosition[5 5]" {
+		t.Errorf("built-in not restored: %v", screen.Calls)
+	}
+}
+
+func TestCSIIntermediatesNotMisdispatched(t *testing.T) {
+	screen := gopyte.NewMockScreen()
+	stream := gopyte.NewStream(screen, false)
+
+	// SL (CSI Ps SP @) must not be treated as ICH (CSI Ps @)
+	stream.Feed("\x1b[2 @A")
+
+	for _, c := range screen.Calls {
+		if strings.HasPrefix(c, "InsertCharacters") {
+			t.Fatalf("intermediate sequence dispatched as built-in: %v", screen.Calls)
+		}
+	}
+	if last := screen.Calls[len(screen.Calls)-1]; last != "Draw[A]" {
+		t.Errorf("parser did not return to ground: %v", screen.Calls)
+	}
+}
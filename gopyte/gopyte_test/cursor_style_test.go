@@ -0,0 +1,47 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestCursorStyleDECSCUSR(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	if got := screen.CursorStyle(); got != gopyte.CursorStyleDefault {
+		t.Fatalf("initial style = %v", got)
+	}
+
+	tests := []struct {
+		seq      string
+		want     gopyte.CursorStyle
+		shape    gopyte.CursorShape
+		blinking bool
+	}{
+		{"\x1b[2 q", gopyte.CursorStyleSteadyBlock, gopyte.CursorBlock, false},
+		{"\x1b[3 q", gopyte.CursorStyleBlinkingUnderline, gopyte.CursorUnderline, true},
+		{"\x1b[6 q", gopyte.CursorStyleSteadyBar, gopyte.CursorBar, false},
+		{"\x1b[9 q", gopyte.CursorStyleSteadyBar, gopyte.CursorBar, false}, // Undefined, ignored
+		{"\x1b[ q", gopyte.CursorStyleDefault, gopyte.CursorBlock, true},
+	}
+	for _, tt := range tests {
+		stream.Feed(tt.seq)
+		got := screen.CursorStyle()
+		if got != tt.want || got.Shape() != tt.shape || got.Blinking() != tt.blinking {
+			t.Errorf("%q: style = %v (shape %v, blinking %v), want %v", tt.seq, got, got.Shape(), got.Blinking(), tt.want)
+		}
+	}
+
+	// Not to be confused with DECLL (CSI Ps q) or a private sequence
+	stream.Feed("\x1b[5 q\x1b[2q")
+	if got := screen.CursorStyle(); got.String() != "blinking bar" {
+		t.Errorf("style = %v, want blinking bar", got)
+	}
+
+	stream.Feed("\x1bc")
+	if got := screen.CursorStyle(); got != gopyte.CursorStyleDefault {
+		t.Errorf("style after RIS = %v", got)
+	}
+}
@@ -0,0 +1,61 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestCursorVisibilityDECTCEM(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 3)
+	stream := gopyte.NewStream(screen, false)
+
+	if screen.GetCursorObject().Hidden {
+		t.Fatal("cursor hidden initially")
+	}
+	stream.Feed("\x1b[?25l")
+	if !screen.GetCursorObject().Hidden {
+		t.Error("cursor visible after CSI ? 25 l")
+	}
+
+	// DECRC restores the position but not the visibility
+	stream.Feed("\x1b7\x1b[?25h\x1b[3;3H\x1b[?25l\x1b8")
+	if x, y := screen.GetCursor(); x != 0 || y != 0 || !screen.GetCursorObject().Hidden {
+		t.Errorf("after DECRC cursor = %d,%d hidden %v, want 0,0 hidden", x, y, screen.GetCursorObject().Hidden)
+	}
+
+	stream.Feed("\x1b[?25h")
+	if screen.GetCursorObject().Hidden {
+		t.Error("cursor hidden after CSI ? 25 h")
+	}
+}
+
+func TestCursorVisibilityAlternateScreen(t *testing.T) {
+	screen := gopyte.NewAlternateScreen(10, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// Hidden before the switch, shown inside, still shown after leaving
+	stream.Feed("\x1b[?25l\x1b[?1049h")
+	if !screen.GetCursorObject().Hidden {
+		t.Error("visibility lost entering the alternate screen")
+	}
+	stream.Feed("\x1b[?25h\x1b[?1049l")
+	if screen.GetCursorObject().Hidden {
+		t.Error("visibility lost leaving the alternate screen")
+	}
+}
+
+func TestCursorVisibilityCompositor(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 10)
+	c := gopyte.NewCompositor(12, 5)
+	c.Add("a", screen, nil)
+	c.Layout()
+
+	if _, _, ok := c.Cursor(); !ok {
+		t.Fatal("no cursor for a visible cursor")
+	}
+	gopyte.NewStream(screen, false).Feed("\x1b[?25l")
+	if _, _, ok := c.Cursor(); ok {
+		t.Error("cursor reported while hidden")
+	}
+}
@@ -0,0 +1,158 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func damageMap(rows []gopyte.RowDamage) map[int][]gopyte.Span {
+	m := make(map[int][]gopyte.Span)
+	for _, r := range rows {
+		m[r.Y] = r.Cells.Spans()
+	}
+	return m
+}
+
+func TestCellDamage(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(200, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	if screen.DamagedCells() != nil {
+		t.Fatal("damage reported while tracking is off")
+	}
+
+	screen.SetCellDamage(true)
+	if got := len(screen.DamagedCells()); got != 3 {
+		t.Errorf("first frame: %d damaged rows, want 3", got)
+	}
+	screen.ClearCellDamage()
+	if got := screen.DamagedCells(); len(got) != 0 {
+		t.Errorf("after clear: %v", got)
+	}
+
+	// Text in the middle of a wide row damages only those cells, plus the
+	// cells the cursor left and reached
+	stream.Feed("\x1b[2;101Hab")
+	want := map[int][]gopyte.Span{
+		0: {{Start: 0, End: 1}},
+		1: {{Start: 100, End: 103}},
+	}
+	if got := damageMap(screen.DamagedCells()); !reflect.DeepEqual(got, want) {
+		t.Errorf("damage: got %v, want %v", got, want)
+	}
+	screen.ClearCellDamage()
+
+	// Rewriting the same text is not damage
+	stream.Feed("\x1b[2;101Hab")
+	if got := damageMap(screen.DamagedCells()); !reflect.DeepEqual(got, map[int][]gopyte.Span{}) {
+		t.Errorf("no-op rewrite: got %v", got)
+	}
+
+	// Scrolling damages every row whose content moved
+	stream.Feed("\x1b[3;1Hzz\r\n")
+	rows := screen.DamagedCells()
+	if len(rows) != 3 {
+		t.Errorf("after scroll: %d damaged rows, want 3", len(rows))
+	}
+}
+
+func TestCellDamageOption(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 2, 10, gopyte.WithCellDamage())
+	if got := len(screen.DamagedCells()); got != 2 {
+		t.Errorf("first frame: %d damaged rows, want 2", got)
+	}
+	screen.ClearCellDamage()
+	gopyte.NewStream(screen, false).Feed("\x1b[2;3Hx")
+	want := map[int][]gopyte.Span{
+		0: {{Start: 0, End: 1}},
+		1: {{Start: 2, End: 4}},
+	}
+	if got := damageMap(screen.DamagedCells()); !reflect.DeepEqual(got, want) {
+		t.Errorf("damage: got %v, want %v", got, want)
+	}
+}
+
+func TestRowBitmap(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(130, 1, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("\x1b[1;64H")
+	screen.SetCellDamage(true)
+	screen.ClearCellDamage()
+
+	stream.Feed("xy\x1b[1;130Hz")
+	rows := screen.DamagedCells()
+	if len(rows) != 1 {
+		t.Fatalf("damaged rows: %d", len(rows))
+	}
+	b := rows[0].Cells
+	if !b.Test(63) || !b.Test(64) || b.Test(65) || !b.Test(129) {
+		t.Errorf("bits: %v", b.Spans())
+	}
+	if b.Count() != 3 {
+		t.Errorf("count: got %d, want 3", b.Count())
+	}
+}
+
+func TestDirtyLines(t *testing.T) {
+	screen := gopyte.NewAlternateScreen(20, 4, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	if got := screen.DirtyLines(); !reflect.DeepEqual(got, []int{0, 1, 2, 3}) {
+		t.Errorf("before ClearDirty: %v", got)
+	}
+	screen.ClearDirty()
+	if got := screen.DirtyLines(); got != nil {
+		t.Errorf("after ClearDirty: %v", got)
+	}
+
+	// The cursor starts and ends on line 2
+	stream.Feed("\x1b[3Hhello\r")
+	if got := screen.DirtyLines(); !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Errorf("after text: %v", got)
+	}
+	screen.ClearDirty()
+
+	// Attributes alone make a line dirty
+	stream.Feed("\x1b[1mhello\x1b[m\r")
+	if got := screen.DirtyLines(); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("after bold: %v", got)
+	}
+	screen.ClearDirty()
+
+	// So does switching buffers
+	stream.Feed("\x1b[?1049h\x1b[3H")
+	if got := screen.DirtyLines(); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("after switching: %v", got)
+	}
+}
+
+func TestDamageJoinedCluster(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 3, 10, gopyte.WithCellDamage())
+	stream := gopyte.NewStream(screen, false)
+
+	stream.Feed("e\x1b[2;1H😀")
+	screen.ClearCellDamage()
+	screen.ClearDirty()
+
+	// Neither join changes a rune in the buffer, and the cursor ends
+	// where it was
+	stream.Feed("\x1b[1;2H\u0301\x1b[2;3H\ufe0f")
+	want := map[int][]gopyte.Span{0: {{Start: 0, End: 1}}, 1: {{Start: 0, End: 1}}}
+	if got := damageMap(screen.DamagedCells()); !reflect.DeepEqual(got, want) {
+		t.Errorf("damage = %v, want %v", got, want)
+	}
+	if got := screen.DirtyLines(); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("dirty lines = %v, want [0 1]", got)
+	}
+
+	screen.ClearCellDamage()
+	screen.ClearDirty()
+	if got := screen.DamagedCells(); len(got) != 0 {
+		t.Errorf("after clear: %v", got)
+	}
+	if got := screen.DirtyLines(); len(got) != 0 {
+		t.Errorf("dirty after clear: %v", got)
+	}
+}
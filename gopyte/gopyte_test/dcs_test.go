@@ -0,0 +1,73 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestDeviceControlStrings(t *testing.T) {
+	screen := gopyte.NewNativeScreen(40, 3)
+	stream := gopyte.NewStream(screen, false)
+
+	var got []gopyte.DeviceControl
+	screen.SetDeviceControlHandler(func(d gopyte.DeviceControl) {
+		got = append(got, d)
+	})
+
+	sixel := "\x1bP0;1;0q\"1;1;2;2#0;2;100;0;0#0~~-~~\x1b\\"
+	stream.Feed("a\x1bP$qm\x1b\\b")
+	stream.Feed(sixel[:10])
+	stream.Feed(sixel[10 : len(sixel)-1])
+	stream.Feed(sixel[len(sixel)-1:])
+	stream.Feed("\x90+q544e\x9cc")
+	stream.Feed("\x1bP1;1|17/ab\x1b\\")
+
+	want := []gopyte.DeviceControl{
+		{Intermediate: "$", Final: "q", Data: "m"},
+		{Params: []int{0, 1, 0}, Final: "q", Data: "\"1;1;2;2#0;2;100;0;0#0~~-~~"},
+		{Intermediate: "+", Final: "q", Data: "544e"},
+		{Params: []int{1, 1}, Final: "|", Data: "17/ab"},
+	}
+	names := []string{"decrqss", "sixel", "xtgettcap", "decudk"}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if len(got[i].Params) == 0 {
+			got[i].Params = nil
+		}
+		if !reflect.DeepEqual(got[i], want[i]) || got[i].Name() != names[i] {
+			t.Errorf("string %d = %+v (%s), want %+v", i, got[i], got[i].Name(), want[i])
+		}
+	}
+
+	// None of it reaches the screen as text
+	if line := screen.GetDisplay()[0]; line != "abc" {
+		t.Errorf("display = %q", line)
+	}
+}
+
+func TestDeviceControlCancelled(t *testing.T) {
+	screen := gopyte.NewNativeScreen(40, 3)
+	stream := gopyte.NewStream(screen, false)
+	calls := 0
+	screen.SetDeviceControlHandler(func(gopyte.DeviceControl) { calls++ })
+
+	// CAN drops the string; another escape sequence drops it and runs
+	stream.Feed("\x1bPqjunk\x18x\x1bP$qm\x1b[2Cy")
+	if calls != 0 {
+		t.Errorf("handler called %d times", calls)
+	}
+	if line := screen.GetDisplay()[0]; line != "x  y" {
+		t.Errorf("display = %q", line)
+	}
+
+	var kinds []gopyte.SequenceKind
+	stream.SetRawTap(func(seq gopyte.RawSequence) { kinds = append(kinds, seq.Kind) })
+	stream.Feed("\x1bP$qm\x1b\\")
+	if !reflect.DeepEqual(kinds, []gopyte.SequenceKind{gopyte.SeqDCS}) {
+		t.Errorf("tap kinds = %v", kinds)
+	}
+}
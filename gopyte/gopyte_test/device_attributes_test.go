@@ -0,0 +1,48 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestDeviceAttributesReports(t *testing.T) {
+	tests := []struct {
+		seq  string
+		want string
+	}{
+		{"\x1b[c", "\x1b[?64;22c"},
+		{"\x1b[0c", "\x1b[?64;22c"},
+		{"\x1b[>c", "\x1b[>41;0;0c"},
+		{"\x1b[>0c", "\x1b[>41;0;0c"},
+		{"\x1b[=c", "\x1bP!|00000000\x1b\\"},
+		{"\x1b[1c", ""},
+		{"\x1b[?c", ""},
+	}
+	for _, tt := range tests {
+		screen := gopyte.NewHistoryScreen(80, 24, 10)
+		tr := gopyte.NewTranscript()
+		screen.SetTranscript(tr)
+		gopyte.NewStream(screen, false).Feed(tt.seq)
+
+		got := ""
+		for _, ev := range tr.Events() {
+			got += ev.Data
+		}
+		if got != tt.want {
+			t.Errorf("%q: response %q, want %q", tt.seq, got, tt.want)
+		}
+	}
+}
+
+func TestDeviceAttributesGuarded(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	tr := gopyte.NewTranscript()
+	screen.SetTranscript(tr)
+	screen.SetResponseGuard(gopyte.NewResponseGuard(gopyte.ResponseGuardOptions{}))
+
+	gopyte.NewStream(screen, false).Feed("\x1b[c\x1b[>c")
+	if n := len(tr.Events()); n != 0 {
+		t.Errorf("%d responses sent past a guard allowing none", n)
+	}
+}
@@ -0,0 +1,56 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestDiffScreens(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(20, 4, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("Gi0/1 up\r\nGi0/2 up\r\nGi0/3 down")
+	before := screen.Clone()
+
+	stream.Feed("\x1b[H\x1b[2J\x1b[31mGi0/1 up\r\nGi0/2 down\r\nGi0/3 down")
+
+	view, err := gopyte.DiffScreens(before, screen, gopyte.DiffViewOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !view.Changed() || view.Cells != 4 || len(view.Rows) != 1 || view.Rows[0].Y != 1 {
+		t.Fatalf("diff = %d cells in %+v", view.Cells, view.Rows)
+	}
+	if spans := view.Rows[0].Cells.Spans(); len(spans) != 1 || spans[0] != (gopyte.Span{Start: 6, End: 10}) {
+		t.Errorf("spans = %v", spans)
+	}
+
+	out := view.Screen.(*gopyte.HistoryScreen)
+	if got := out.GetDisplay()[1]; got != "Gi0/2 down" {
+		t.Errorf("view line = %q", got)
+	}
+
+	// Only the changed cells were restyled, and the input was left alone
+	restyled, _ := gopyte.DiffScreens(screen, out, gopyte.DiffViewOptions{CompareAttrs: true})
+	if restyled.Cells != 4 || restyled.Rows[0].Y != 1 || !restyled.Rows[0].Cells.Test(6) {
+		t.Errorf("restyled = %d cells in %+v", restyled.Cells, restyled.Rows)
+	}
+	var styled []gopyte.Attributes
+	gopyte.DiffScreens(before, screen, gopyte.DiffViewOptions{Highlight: func(a gopyte.Attributes) gopyte.Attributes {
+		styled = append(styled, a)
+		return a
+	}})
+	if len(styled) != 4 || styled[0].Fg != gopyte.NamedColor(1) {
+		t.Errorf("highlight saw %+v", styled)
+	}
+
+	// Color changes count only when asked for
+	view, _ = gopyte.DiffScreens(before, screen, gopyte.DiffViewOptions{CompareAttrs: true})
+	if len(view.Rows) != 3 {
+		t.Errorf("with attributes: %d rows changed, want 3", len(view.Rows))
+	}
+
+	if _, err := gopyte.DiffScreens(gopyte.NewMockScreen(), screen, gopyte.DiffViewOptions{}); err != gopyte.ErrUnsupportedScreen {
+		t.Errorf("mock screen: err = %v", err)
+	}
+}
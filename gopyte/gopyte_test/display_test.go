@@ -0,0 +1,163 @@
+package gopyte_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestGetDisplayPadded(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 2)
+	gopyte.NewStream(screen, false).Feed("ab\x1b[2;6Hright")
+
+	// A right-aligned status bar keeps its column positions
+	got := screen.GetDisplayPadded()
+	if want := []string{"ab        ", "     right"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	for _, line := range got {
+		if n := utf8.RuneCountInString(line); n != 10 {
+			t.Errorf("line %q has %d runes, want 10", line, n)
+		}
+	}
+}
+
+func TestGetDisplayPaddedWide(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(8, 2, 10)
+	gopyte.NewStream(screen, false).Feed("a中b")
+
+	line := []rune(screen.GetDisplayPadded()[0])
+	if len(line) != 8 {
+		t.Fatalf("got %d runes, want 8", len(line))
+	}
+	if line[1] != '中' || line[2] != gopyte.WidePlaceholder || line[3] != 'b' {
+		t.Errorf("got %q", string(line))
+	}
+
+	rect := screen.GetDisplayRect(gopyte.Region{Top: 0, Left: 2, Bottom: 1, Right: 4})
+	want := []string{string(gopyte.WidePlaceholder) + "b ", "   "}
+	if !reflect.DeepEqual(rect, want) {
+		t.Errorf("GetDisplayRect: got %q, want %q", rect, want)
+	}
+
+	// Out of range regions are clipped
+	if got := screen.GetDisplayRect(gopyte.Region{Top: 1, Left: 6, Bottom: 5, Right: 20}); !reflect.DeepEqual(got, []string{"  "}) {
+		t.Errorf("clipped rect: got %q", got)
+	}
+}
+
+func TestEraseWideCells(t *testing.T) {
+	for _, in := range []string{
+		"中文\r\x1b[K",         // EL 0
+		"中文\x1b[1;2H\x1b[1K", // EL 1 ending on a wide character
+		"中文\x1b[1;2H\x1b[K",  // EL 0 starting inside one
+		"中文\x1b[2K",          // EL 2
+		"中文\x1b[2;1H\x1b[1J", // ED 1
+		"中文\x1b[2J",          // ED 2
+		"中文\x1b[H\x1b[2P",    // DCH
+		"中文\x1b[H\x1b[2@",    // ICH
+		"中文\x1b[1;2H\x1b[P",  // DCH inside a wide character
+	} {
+		screen := gopyte.NewWideCharScreen(8, 2, 10)
+		stream := gopyte.NewStream(screen, false)
+		stream.Feed(in)
+		for x, c := range screen.GetLineCells(0) {
+			if c.Char == ' ' && c.Width != 1 {
+				t.Errorf("%q: blank cell %d has width %d", in, x, c.Width)
+			}
+		}
+
+		data, err := json.Marshal(screen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored := gopyte.NewWideCharScreen(8, 2, 10)
+		if err := json.Unmarshal(data, restored); err != nil {
+			t.Fatal(err)
+		}
+		if !screen.Equal(restored) {
+			t.Errorf("%q: restored screen differs:\n%s", in, screen.DiffString(restored))
+		}
+	}
+
+	// Text written over erased cells lands in its column
+	screen := gopyte.NewWideCharScreen(8, 2, 10)
+	gopyte.NewStream(screen, false).Feed("中文\r\x1b[K\x1b[1;6Hz")
+	if got := []rune(screen.GetDisplayPadded()[0]); got[5] != 'z' {
+		t.Errorf("got %q, want z in column 5", string(got))
+	}
+}
+
+func TestAppendDisplayMatchesGetDisplay(t *testing.T) {
+	screens := map[string]interface {
+		gopyte.Screen
+		GetDisplay() []string
+		AppendDisplay([]string) []string
+	}{
+		"native":   gopyte.NewNativeScreen(10, 3),
+		"history":  gopyte.NewHistoryScreen(10, 3, 10),
+		"widechar": gopyte.NewWideCharScreen(10, 3, 10),
+	}
+	for name, screen := range screens {
+		stream := gopyte.NewStream(screen, false)
+		var lines []string
+		for _, chunk := range []string{"", "one\r\ntwo", "\x1b[2;1Hdeux  ", "中文x\r\n\r\nend", "\x1b[2J"} {
+			stream.Feed(chunk)
+			lines = screen.AppendDisplay(lines[:0])
+			if want := screen.GetDisplay(); !reflect.DeepEqual(lines, want) {
+				t.Errorf("%s after %q: got %q, want %q", name, chunk, lines, want)
+			}
+		}
+
+		// Appends rather than overwrites
+		lines = screen.AppendDisplay([]string{"first"})
+		if len(lines) != 4 || lines[0] != "first" {
+			t.Errorf("%s: append got %q", name, lines)
+		}
+	}
+}
+
+func TestAppendDisplayDoesNotAllocate(t *testing.T) {
+	for name, screen := range map[string]interface {
+		gopyte.Screen
+		AppendDisplay([]string) []string
+	}{
+		"native":   gopyte.NewNativeScreen(80, 24),
+		"widechar": gopyte.NewWideCharScreen(80, 24, 100),
+	} {
+		gopyte.NewStream(screen, false).Feed("top\x1b[12;30Hmiddle 中文\x1b[24;1Hbottom")
+		lines := screen.AppendDisplay(nil)
+		allocs := testing.AllocsPerRun(100, func() {
+			lines = screen.AppendDisplay(lines[:0])
+		})
+		if allocs != 0 {
+			t.Errorf("%s: %v allocations per unchanged frame, want 0", name, allocs)
+		}
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 2, 10)
+	gopyte.NewStream(screen, false).Feed("a中b")
+
+	buf := make([]rune, 10)
+	n := screen.ReadLine(0, buf)
+	if got, want := string(buf[:n]), screen.GetDisplay()[0]; got != want {
+		t.Errorf("line: got %q, want %q", got, want)
+	}
+	if n := screen.ReadLine(0, buf[:2]); string(buf[:n]) != "a中" {
+		t.Errorf("short buffer: got %q", string(buf[:n]))
+	}
+	if n := screen.ReadLine(5, buf); n != 0 {
+		t.Errorf("outside the screen: got %d runes", n)
+	}
+
+	native := gopyte.NewNativeScreen(10, 2)
+	gopyte.NewStream(native, false).Feed("ab  c   ")
+	if n := native.ReadLine(0, buf); string(buf[:n]) != "ab  c" {
+		t.Errorf("native: got %q", string(buf[:n]))
+	}
+}
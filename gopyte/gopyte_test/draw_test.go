@@ -0,0 +1,112 @@
+package gopyte_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+type drawScreen interface {
+	gopyte.Screen
+	GetDisplay() []string
+	GetCursor() (int, int)
+	GetLineCells(y int) []gopyte.Cell
+	WrapPending() bool
+}
+
+var drawScreens = map[string]func() drawScreen{
+	"native":    func() drawScreen { return gopyte.NewNativeScreen(13, 4) },
+	"history":   func() drawScreen { return gopyte.NewHistoryScreen(13, 4, 50) },
+	"alternate": func() drawScreen { return gopyte.NewAlternateScreen(13, 4, 50) },
+	"widechar":  func() drawScreen { return gopyte.NewWideCharScreen(13, 4, 50) },
+}
+
+// drawInput returns text mixing ASCII, wide and combining characters with
+// the sequences that change how Draw places them.
+func drawInput(seed int64) string {
+	rng := rand.New(rand.NewSource(seed))
+	pieces := []string{
+		"hello ", "a much longer line of ascii text ", "世界", "é", "é", "x",
+		"\x1b[31m", "\x1b[m", "\x1b[?7l", "\x1b[?7h", "\x1b[?69h\x1b[3;9s", "\x1b[?69l",
+		"\x1b]8;;https://example.com\x07", "\x1b]8;;\x07", "\r\n", "\x1b[5G", "\x1b[2;11H",
+		"\x1b[?1049h", "\x1b[?1049l", "\x1b(0", "\x1b(B", "\x1b[1@", "\xff",
+	}
+	var b strings.Builder
+	for i := 0; i < 400; i++ {
+		b.WriteString(pieces[rng.Intn(len(pieces))])
+	}
+	return b.String()
+}
+
+// TestDrawRunsMatchCharacters checks that drawing text in one call leaves
+// every screen as drawing it a character at a time does.
+func TestDrawRunsMatchCharacters(t *testing.T) {
+	for name, newScreen := range drawScreens {
+		for seed := int64(0); seed < 20; seed++ {
+			input := drawInput(seed)
+			whole, single := newScreen(), newScreen()
+			gopyte.NewStream(whole, false).Feed(input)
+			stream := gopyte.NewStream(single, false)
+			for input != "" {
+				_, size := utf8.DecodeRuneInString(input)
+				stream.Feed(input[:size])
+				input = input[size:]
+			}
+
+			if got, want := whole.GetDisplay(), single.GetDisplay(); !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s seed %d: display\n%q\nwant\n%q", name, seed, got, want)
+			}
+			for y := 0; y < 4; y++ {
+				if got, want := whole.GetLineCells(y), single.GetLineCells(y); !reflect.DeepEqual(got, want) {
+					t.Fatalf("%s seed %d: line %d cells\n%v\nwant\n%v", name, seed, y, got, want)
+				}
+			}
+			gx, gy := whole.GetCursor()
+			wx, wy := single.GetCursor()
+			if gx != wx || gy != wy || whole.WrapPending() != single.WrapPending() {
+				t.Fatalf("%s seed %d: cursor (%d, %d) %v, want (%d, %d) %v", name, seed,
+					gx, gy, whole.WrapPending(), wx, wy, single.WrapPending())
+			}
+		}
+	}
+}
+
+func TestDrawKeepsHyperlinks(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("\x1b]8;;https://a.example\x07link\x1b]8;;\x07plain")
+	for x, want := range []bool{true, true, true, true, false, false} {
+		if _, ok := screen.CellHyperlink(0, x); ok != want {
+			t.Errorf("cell %d linked = %v, want %v", x, ok, want)
+		}
+	}
+	stream.Feed("\rxy")
+	if _, ok := screen.CellHyperlink(0, 0); ok {
+		t.Error("overwritten cell still linked")
+	}
+}
+
+func BenchmarkDrawLogOutput(b *testing.B) {
+	var lines strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&lines, "2024-01-01T00:00:%02d.000Z INFO  request %d served in %dms from cache\r\n", i%60, i, i%97)
+	}
+	data := lines.String()
+	for name, newScreen := range map[string]func() gopyte.Screen{
+		"native":   func() gopyte.Screen { return gopyte.NewNativeScreen(120, 40) },
+		"widechar": func() gopyte.Screen { return gopyte.NewWideCharScreen(120, 40, 1000) },
+	} {
+		b.Run(name, func(b *testing.B) {
+			stream := gopyte.NewStream(newScreen(), false)
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				stream.Feed(data)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package gopyte_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestEventHandler(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 5, 100)
+	stream := gopyte.NewStream(screen, false)
+	var got []string
+	screen.SetEventHandler(func(ev gopyte.Event) {
+		switch ev.Type {
+		case gopyte.EventTitle, gopyte.EventIconName:
+			got = append(got, fmt.Sprintf("%s %q", ev.Type, ev.Text))
+		case gopyte.EventMode:
+			got = append(got, fmt.Sprintf("mode %d private=%v %v", ev.Mode, ev.Private, ev.Enabled))
+		case gopyte.EventAlternateScreen:
+			// The screen is consistent by the time the handler runs
+			got = append(got, fmt.Sprintf("alternate %v %v", ev.Enabled, screen.IsUsingAlternate()))
+		case gopyte.EventResize:
+			got = append(got, fmt.Sprintf("resize %dx%d %d", ev.Columns, ev.Lines, len(screen.GetDisplay())))
+		default:
+			got = append(got, ev.Type.String())
+		}
+	})
+
+	stream.Feed("\x1b]2;vim\x07\x1b]2;vim\x07") // The second changes nothing
+	stream.Feed("\x07")
+	stream.Feed("\x1b[?2004h\x1b[?2004h")
+	stream.Feed("\x1b[?1000h\x1b[?1002h")
+	stream.Feed("\x1b[?1049h\x1b[?25l")
+	stream.Feed("\x1b[?1049l")
+	screen.Resize(30, 8)
+	stream.Feed("\x1bc") // RIS turns off what was on
+
+	want := []string{
+		`title "vim"`,
+		"bell",
+		"mode 2004 private=true true",
+		"mode 1000 private=true true",
+		"mode 1000 private=true false",
+		"mode 1002 private=true true",
+		"alternate true true",
+		"mode 25 private=true false",
+		"alternate false false",
+		"resize 30x8 8",
+		"mode 25 private=true true",
+		"mode 1002 private=true false",
+		"mode 2004 private=true false",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events:\n%q\nwant\n%q", got, want)
+	}
+
+	screen.SetEventHandler(nil)
+	stream.Feed("\x07")
+}
+
+func TestEventModesNative(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 3)
+	stream := gopyte.NewStream(screen, false)
+	var got []gopyte.Event
+	screen.SetEventHandler(func(ev gopyte.Event) { got = append(got, ev) })
+
+	stream.Feed("\x1b[20l\x1b[?7l\x1b[!p") // LNM off, autowrap off, DECSTR turns it back on
+	want := []gopyte.Event{
+		{Type: gopyte.EventMode, Mode: 20, Enabled: false},
+		{Type: gopyte.EventMode, Mode: 7, Private: true, Enabled: false},
+		{Type: gopyte.EventMode, Mode: 7, Private: true, Enabled: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %+v, want %+v", got, want)
+	}
+}
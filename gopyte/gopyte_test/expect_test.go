@@ -0,0 +1,177 @@
+package gopyte_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+type pipeConn struct {
+	io.Reader
+	io.Writer
+}
+
+// fakeDevice echoes its input like a terminal and answers a login and a
+// couple of commands.
+func fakeDevice(in io.Reader, out io.WriteCloser) {
+	defer out.Close()
+	r := bufio.NewReader(in)
+	readLine := func() (string, bool) {
+		var sb strings.Builder
+		for {
+			b, err := r.ReadByte()
+			if err != nil {
+				return "", false
+			}
+			if b == '\r' {
+				return sb.String(), true
+			}
+			sb.WriteByte(b)
+			io.WriteString(out, string(b))
+		}
+	}
+
+	io.WriteString(out, "\x1b[2J\x1b[HUser: ")
+	if _, ok := readLine(); !ok {
+		return
+	}
+	io.WriteString(out, "\r\nPassword: ")
+	if _, ok := readLine(); !ok {
+		return
+	}
+	io.WriteString(out, "\r\n\r\nrouter# ")
+	for {
+		cmd, ok := readLine()
+		if !ok {
+			return
+		}
+		switch cmd {
+		case "show version":
+			io.WriteString(out, "\r\nVersion 15.2\r\n\x1b[1mUptime\x1b[0m 5 days\r\n")
+		case "progress":
+			io.WriteString(out, "\r\n10%\r50%\r100%\r\n")
+		case "exit":
+			io.WriteString(out, "\r\n")
+			return
+		}
+		io.WriteString(out, "\r\nrouter# ")
+	}
+}
+
+func newExpectSession(t *testing.T) *gopyte.Expecter {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	go fakeDevice(inR, outW)
+	e := gopyte.NewExpecter(pipeConn{outR, inW}, gopyte.NewHistoryScreen(40, 5, 100))
+	e.SetPrompt(regexp.MustCompile(`(?m)^router# ?$`))
+	e.SetTimeout(5 * time.Second)
+	t.Cleanup(func() { inW.Close(); outR.Close() })
+	return e
+}
+
+func TestExpecterLoginAndCapture(t *testing.T) {
+	e := newExpectSession(t)
+
+	if _, err := e.ExpectString("User:"); err != nil {
+		t.Fatal(err)
+	}
+	e.SendLine("admin")
+	if _, err := e.ExpectString("Password:"); err != nil {
+		t.Fatal(err)
+	}
+	e.SendLine("secret")
+	if _, err := e.ExpectPrompt(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each command scrolls the screen; earlier prompts are not matched again
+	for i := 0; i < 3; i++ {
+		got, err := e.Capture("show version")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"Version 15.2", "Uptime 5 days"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("capture %d: got %q, want %q", i, got, want)
+		}
+	}
+
+	// Carriage-return repaints show up as the final screen text
+	got, err := e.Capture("progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{"100%"}) {
+		t.Errorf("progress: got %q", got)
+	}
+
+	e.SetTimeout(100 * time.Millisecond)
+	m, err := e.Expect(regexp.MustCompile(`Version (\d+)\.(\d+)`))
+	if err == nil {
+		t.Errorf("matched text before the mark: %+v", m)
+	}
+}
+
+func TestExpecterGroupsAndScreen(t *testing.T) {
+	e := newExpectSession(t)
+	e.ExpectString("User:")
+	e.SendLine("admin")
+	e.ExpectString("Password:")
+	e.SendLine("secret")
+	e.ExpectPrompt()
+	e.SendLine("show version")
+
+	m, err := e.Expect(regexp.MustCompile(`Version (\d+)\.(\d+)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.Groups, []string{"Version 15.2", "15", "2"}) {
+		t.Errorf("groups: %q", m.Groups)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(m.Before), "show version") {
+		t.Errorf("before: %q", m.Before)
+	}
+
+	// ExpectScreen looks at the whole display, behind the mark too
+	if _, err := e.ExpectScreen(regexp.MustCompile(`router#`)); err != nil {
+		t.Errorf("ExpectScreen: %v", err)
+	}
+}
+
+func TestExpecterEOFAndTimeout(t *testing.T) {
+	e := newExpectSession(t)
+	clock := gopyte.NewFakeClock(time.Unix(0, 0))
+	e.SetClock(clock)
+	e.SetTimeout(time.Minute)
+
+	done := make(chan error)
+	go func() {
+		_, err := e.ExpectString("never printed")
+		done <- err
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Minute)
+	if err := <-done; !errors.Is(err, gopyte.ErrExpectTimeout) {
+		t.Errorf("timeout: got %v", err)
+	}
+
+	e.SetClock(gopyte.SystemClock)
+	e.ExpectString("User:")
+	e.SendLine("admin")
+	e.ExpectString("Password:")
+	e.SendLine("secret")
+	e.ExpectPrompt()
+	e.SendLine("exit")
+	if _, err := e.ExpectString("never printed"); err != io.EOF {
+		t.Errorf("after exit: got %v, want EOF", err)
+	}
+}
@@ -0,0 +1,88 @@
+package gopyte_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestFeedWorker(t *testing.T) {
+	screen := gopyte.NewSyncScreen(gopyte.NewNativeScreen(20, 3))
+	var errs []gopyte.ParseError
+	worker := gopyte.NewStream(screen, true).StartWorker(gopyte.WorkerOptions{
+		OnError: func(e []gopyte.ParseError) { errs = append(errs, e...) },
+	})
+
+	// Split sequences and runes are put together as by Feed
+	if _, err := io.Copy(worker, strings.NewReader("\x1b[2;3Hhé")); err != nil {
+		t.Fatal(err)
+	}
+	worker.WriteString("\x1b[1")
+	worker.WriteString("mx\xc3")
+	worker.WriteString("\xa9\xff")
+	worker.Flush()
+	if got := screen.GetDisplay()[1]; got != "  héxé\uFFFD" {
+		t.Errorf("line = %q", got)
+	}
+	if !screen.GetCell(1, 4).Attrs.Bold {
+		t.Error("x is not bold")
+	}
+	if len(errs) != 1 || errs[0].Reason != "invalid UTF-8" {
+		t.Errorf("errors = %v", errs)
+	}
+
+	worker.WriteString("!")
+	if err := worker.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := screen.GetDisplay()[1]; !strings.HasSuffix(got, "!") {
+		t.Errorf("Close did not drain: %q", got)
+	}
+	if _, err := worker.WriteString("late"); !errors.Is(err, gopyte.ErrWorkerClosed) {
+		t.Errorf("Write after Close = %v", err)
+	}
+	worker.Flush()
+	worker.Close()
+}
+
+func TestFeedWorkerBackpressure(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 3)
+	stall := make(chan struct{})
+	screen.SetEventHandler(func(ev gopyte.Event) {
+		if ev.Type == gopyte.EventBell {
+			<-stall
+		}
+	})
+	worker := gopyte.NewStream(screen, false).StartWorker(gopyte.WorkerOptions{Buffer: 2})
+
+	worker.WriteString("\x07") // Taken by the worker, which stalls
+	for worker.Pending() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	worker.WriteString("a")
+	worker.WriteString("b")
+	written := make(chan struct{})
+	go func() {
+		worker.WriteString("c")
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("Write did not block on a full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if got := worker.Pending(); got != 2 {
+		t.Errorf("Pending() = %d, want 2", got)
+	}
+
+	close(stall)
+	<-written
+	worker.Close()
+	if got := screen.GetDisplay()[0]; got != "abc" {
+		t.Errorf("line = %q", got)
+	}
+}
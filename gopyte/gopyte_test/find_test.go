@@ -0,0 +1,84 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func span(y, left, right int) gopyte.Region {
+	return gopyte.Region{Top: y, Bottom: y, Left: left, Right: right}
+}
+
+func TestFindString(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 3)
+	gopyte.NewStream(screen, false).Feed("error: disk\r\nok\r\n  error again error")
+
+	got := screen.FindString("error")
+	want := []gopyte.FindMatch{
+		{Text: "error", Spans: []gopyte.Region{span(0, 0, 4)}},
+		{Text: "error", Spans: []gopyte.Region{span(2, 2, 6)}},
+		{Text: "error", Spans: []gopyte.Region{span(2, 14, 18)}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := screen.FindString("missing"); got != nil {
+		t.Errorf("no match: got %+v", got)
+	}
+
+	// Trailing blanks are not searched; empty matches are dropped
+	if got := screen.FindString("ok "); got != nil {
+		t.Errorf("trailing blank: got %+v", got)
+	}
+	if got := screen.Find(regexp.MustCompile(`x*`)); got != nil {
+		t.Errorf("empty matches: got %+v", got)
+	}
+}
+
+func TestFindRegexp(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 2)
+	gopyte.NewStream(screen, false).Feed("Port Gi0/1 up\r\nport gi0/2 down")
+
+	got := screen.Find(regexp.MustCompile(`(?i)gi\d+/\d+`))
+	if len(got) != 2 || got[0].Text != "Gi0/1" || got[1].Text != "gi0/2" {
+		t.Fatalf("got %+v", got)
+	}
+	if got[1].Spans[0] != span(1, 5, 9) {
+		t.Errorf("second span: got %+v", got[1].Spans[0])
+	}
+}
+
+func TestFindAcrossSoftWrap(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 3)
+	gopyte.NewStream(screen, false).Feed("0123456 wrapped\r\nwrap")
+
+	got := screen.FindString("wrapped")
+	want := []gopyte.FindMatch{{Text: "wrapped", Spans: []gopyte.Region{span(0, 8, 9), span(1, 0, 4)}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// A hard line break is not joined
+	if got := screen.FindString("dwrap"); got != nil {
+		t.Errorf("across a newline: got %+v", got)
+	}
+}
+
+func TestFindWideCharacters(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	gopyte.NewStream(screen, false).Feed("中文 text 中")
+
+	if got := screen.FindString("text"); len(got) != 1 || got[0].Spans[0] != span(0, 5, 8) {
+		t.Errorf("after wide characters: got %+v", got)
+	}
+	got := screen.FindString("文 t")
+	if len(got) != 1 || got[0].Spans[0] != span(0, 2, 5) {
+		t.Errorf("wide match: got %+v", got)
+	}
+	if got := screen.FindString("中"); len(got) != 2 || got[1].Spans[0] != span(0, 10, 11) {
+		t.Errorf("both halves: got %+v", got)
+	}
+}
@@ -0,0 +1,29 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestEncodeFocus(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(80, 24, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	if b := screen.EncodeFocus(true); b != nil {
+		t.Errorf("focus event %q sent without mode 1004", b)
+	}
+
+	stream.Feed("\x1b[?1004h")
+	if !screen.FocusReporting() {
+		t.Fatal("focus reporting not enabled")
+	}
+	if in, out := string(screen.EncodeFocus(true)), string(screen.EncodeFocus(false)); in != "\x1b[I" || out != "\x1b[O" {
+		t.Errorf("focus events = %q, %q", in, out)
+	}
+
+	stream.Feed("\x1bc")
+	if screen.FocusReporting() || screen.EncodeFocus(false) != nil {
+		t.Error("focus reporting kept across RIS")
+	}
+}
@@ -0,0 +1,95 @@
+package gopyte_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestGetHistoryLine(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(10, 2, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("\x1b[32mgreen\x1b[m\r\nplain\r\nthird\r\nlast")
+
+	if n := screen.HistoryLen(); n != 2 {
+		t.Fatalf("history length: got %d, want 2", n)
+	}
+	chars, attrs := screen.GetHistoryLine(0)
+	if got := strings.TrimRight(string(chars), " "); got != "green" {
+		t.Errorf("line 0: got %q", got)
+	}
+	if len(attrs) != 10 || attrs[0].Fg != gopyte.NamedColor(2) || attrs[5] != gopyte.DefaultAttributes() {
+		t.Errorf("line 0 attrs: got %+v", attrs)
+	}
+	if chars, _ := screen.GetHistoryLine(1); !strings.HasPrefix(string(chars), "plain") {
+		t.Errorf("line 1: got %q", string(chars))
+	}
+
+	// The copies are the caller's
+	chars[0] = 'X'
+	if again, _ := screen.GetHistoryLine(0); again[0] != 'g' {
+		t.Errorf("history changed through a returned slice: %q", string(again))
+	}
+
+	for _, n := range []int{-1, 2} {
+		if chars, attrs := screen.GetHistoryLine(n); chars != nil || attrs != nil {
+			t.Errorf("line %d: got %q", n, string(chars))
+		}
+	}
+}
+
+func TestHistoryLines(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(10, 2, 100)
+	stream := gopyte.NewStream(screen, false)
+	for i := 0; i < 20; i++ {
+		stream.Feed(fmt.Sprintf("\x1b[3%dmline %d\x1b[m\r\n", i%8, i))
+	}
+
+	n := screen.HistoryLen()
+	i := 5
+	for chars, attrs := range screen.HistoryLines(5) {
+		want, _ := screen.GetHistoryLine(i)
+		if string(chars) != string(want) {
+			t.Errorf("line %d: got %q, want %q", i, string(chars), string(want))
+		}
+		if attrs[0].Fg != gopyte.NamedColor(i%8) {
+			t.Errorf("line %d fg: got %+v", i, attrs[0].Fg)
+		}
+		i++
+	}
+	if i != n {
+		t.Errorf("iterated to %d, want %d", i, n)
+	}
+
+	// Stopping early
+	count := 0
+	for range screen.HistoryLines(0) {
+		if count++; count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("count: got %d", count)
+	}
+}
+
+func TestHistoryLinesOnAlternateScreen(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 2, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("one\r\ntwo\r\nthree\x1b[?1049h")
+
+	// The main screen's scrollback stays readable under a full-screen app
+	if n := screen.HistoryLen(); n != 1 {
+		t.Fatalf("history length: got %d, want 1", n)
+	}
+	if chars, _ := screen.GetHistoryLine(0); !strings.HasPrefix(string(chars), "one") {
+		t.Errorf("line 0: got %q", string(chars))
+	}
+	for chars := range screen.HistoryLines(0) {
+		if !strings.HasPrefix(string(chars), "one") {
+			t.Errorf("iterated: got %q", string(chars))
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package gopyte_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+// truecolorLine returns a line in its own 24-bit foreground color.
+func truecolorLine(i int) string {
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dmline %d\x1b[m\r\n", i>>16&0xff, i>>8&0xff, i&0xff, i)
+}
+
+func truecolor(i int) gopyte.Color {
+	return gopyte.DirectColor(gopyte.RGB{R: uint8(i >> 16), G: uint8(i >> 8), B: uint8(i)})
+}
+
+func TestHistoryKeepsAttributes(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(10, 2, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("\x1b[1;31mred\x1b[m plain\r\n\x1b[4mund\x1b[m\r\nlast")
+
+	if screen.GetHistorySize() != 1 {
+		t.Fatalf("history size: got %d, want 1", screen.GetHistorySize())
+	}
+	snap := screen.Snapshot()
+	runs := snap.Scrollback[0].Attrs
+	if len(runs) == 0 || runs[0].N != 3 || !runs[0].Attrs.Bold || runs[0].Attrs.Fg != gopyte.NamedColor(1) {
+		t.Errorf("scrollback attrs: got %+v", runs)
+	}
+
+	screen.ScrollUp(1)
+	if c := screen.GetCell(0, 0); !c.Attrs.Bold || c.Attrs.Fg != gopyte.NamedColor(1) {
+		t.Errorf("history view attrs: got %+v", c.Attrs)
+	}
+	if c := screen.GetCell(0, 4); c.Attrs != gopyte.DefaultAttributes() {
+		t.Errorf("history view plain attrs: got %+v", c.Attrs)
+	}
+	screen.ScrollToBottom()
+
+	if dump := screen.DumpANSI(gopyte.ANSIOptions{Scrollback: true}); !strings.Contains(dump, "red") ||
+		!strings.HasPrefix(dump, "\x1b[") {
+		t.Errorf("dump: got %q", dump)
+	}
+
+	stream.Feed("\x1b[H\x1bM")
+	if c := screen.GetCell(0, 1); !c.Attrs.Bold || c.Char != 'e' {
+		t.Errorf("restored attrs: got %+v", c)
+	}
+}
+
+func TestHistoryPlainTextHasNoStyles(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(20, 5, 1000)
+	stream := gopyte.NewStream(screen, false)
+	for i := 0; i < 500; i++ {
+		stream.Feed(fmt.Sprintf("line %d\r\n", i))
+	}
+	if n := screen.HistoryStyles(); n != 1 {
+		t.Errorf("styles: got %d, want 1", n)
+	}
+}
+
+func TestHistoryStylesStayBounded(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(20, 2, 100)
+	stream := gopyte.NewStream(screen, false)
+	const lines = 20000
+	for i := 0; i < lines; i++ {
+		stream.Feed(truecolorLine(i))
+	}
+	if n := screen.HistoryStyles(); n > 2*4096 {
+		t.Errorf("styles: got %d, want a bounded table", n)
+	}
+
+	// The scrollback still has the colors it had, compactions or not
+	snap := screen.Snapshot()
+	first := lines - 1 - len(snap.Scrollback) // The last line is still on screen
+	for i, line := range snap.Scrollback {
+		want := truecolor(first + i)
+		if len(line.Attrs) == 0 || line.Attrs[0].Attrs.Fg != want {
+			t.Fatalf("scrollback line %d (%q): got %+v, want fg %+v", i, line.Text, line.Attrs, want)
+		}
+	}
+}
+
+func TestHistoryStylesCloneSurvivesCompaction(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(20, 2, 100)
+	stream := gopyte.NewStream(screen, false)
+	for i := 0; i < 3000; i++ {
+		stream.Feed(truecolorLine(i))
+	}
+	clone := screen.Clone()
+	want := screen.Snapshot()
+
+	// Enough new colors to compact the original's table more than once
+	for i := 3000; i < 12000; i++ {
+		stream.Feed(truecolorLine(i))
+	}
+	if !clone.Equal(clone.Clone()) {
+		t.Errorf("clone differs from its own copy: %s", clone.DiffString(clone.Clone()))
+	}
+	got := clone.Snapshot()
+	for i := range want.Scrollback {
+		if got.Scrollback[i].Text != want.Scrollback[i].Text ||
+			got.Scrollback[i].Attrs[0] != want.Scrollback[i].Attrs[0] {
+			t.Fatalf("clone scrollback line %d: got %+v, want %+v", i, got.Scrollback[i], want.Scrollback[i])
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestExportHTML(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 2)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("a<\x1b[1;31mb\x1b[m \x1b]8;;https://example.com/?a&b\x07link\x1b]8;;\x07")
+	stream.Feed("\r\n\x1b[7mr\x1b[0;4:3;9mu\x1b[m")
+
+	want := `<pre class="gopyte" style="color:#e5e5e5;background-color:#000000">` +
+		`a&lt;<span style="color:#cd0000;font-weight:bold">b</span> ` +
+		`<a href="https://example.com/?a&amp;b">link</a>` + "\n" +
+		`<span style="color:#000000;background-color:#e5e5e5">r</span>` +
+		`<span style="text-decoration-line:underline line-through;text-decoration-style:wavy">u</span></pre>`
+	if got := screen.ExportHTML(gopyte.HTMLOptions{}); got != want {
+		t.Errorf("html =\n%s\nwant\n%s", got, want)
+	}
+
+	page := screen.ExportHTML(gopyte.HTMLOptions{FullPage: true, Title: "a & b"})
+	if !strings.HasPrefix(page, "<!DOCTYPE html>") || !strings.Contains(page, "<title>a &amp; b</title>") ||
+		!strings.Contains(page, want) || !strings.HasSuffix(page, "</html>\n") {
+		t.Errorf("page =\n%s", page)
+	}
+}
+
+func TestExportHTMLLinks(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(10, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// Links go into the scrollback with their text; unsafe ones are dropped
+	stream.Feed("\x1b]8;;file:///tmp/x\x07x\x1b]8;;\x07\r\n\x1b]8;;javascript:alert(1)\x07js\x1b]8;;\x07\r\nend")
+	if link, ok := screen.CellHyperlink(0, 0); !ok || link.URI != "javascript:alert(1)" {
+		t.Errorf("cell link = %+v, %v", link, ok)
+	}
+	want := `<pre class="gopyte" style="color:#e5e5e5;background-color:#000000">` +
+		"<a href=\"file:///tmp/x\">x</a>\njs\nend</pre>"
+	if got := screen.ExportHTML(gopyte.HTMLOptions{Scrollback: true}); got != want {
+		t.Errorf("html =\n%s\nwant\n%s", got, want)
+	}
+
+	// Overwriting linked text without a link removes it
+	stream.Feed("\x1b[1;1Hj")
+	if _, ok := screen.CellHyperlink(0, 0); ok {
+		t.Error("link kept after overwriting the cell")
+	}
+}
@@ -0,0 +1,53 @@
+package gopyte_test
+
+import (
+	"regexp"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestInputLine(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(20, 5, 100)
+	stream := gopyte.NewStream(screen, false)
+	in := gopyte.NewInputLine(screen, regexp.MustCompile(`^[\w.-]+[>#] ?`))
+
+	stream.Feed("Welcome\r\n")
+	if in.AtPrompt() || in.GetCurrentInputLine() != "" {
+		t.Fatal("not at a prompt yet")
+	}
+
+	stream.Feed("router# show ip")
+	if got := in.GetCurrentInputLine(); got != "show ip" {
+		t.Errorf("typed: %q", got)
+	}
+
+	// Backspaces erased by the device
+	stream.Feed("\b\b\x1b[K")
+	if got := in.GetCurrentInputLine(); got != "show" {
+		t.Errorf("after backspace: %q", got)
+	}
+
+	// History recall redraws the line, long enough to wrap
+	stream.Feed("\r\x1b[Krouter# show interfaces brief")
+	if got := in.GetCurrentInputLine(); got != "show interfaces brief" {
+		t.Errorf("recalled: %q", got)
+	}
+	if pos, ok := in.InputCursor(); !ok || pos != 21 {
+		t.Errorf("cursor = %d, %v; want 21", pos, ok)
+	}
+
+	// Cursor moved back for an edit
+	stream.Feed("\b\b\b\b\b\b")
+	if pos, ok := in.InputCursor(); !ok || pos != 15 {
+		t.Errorf("cursor after moving left = %d, %v; want 15", pos, ok)
+	}
+	if got := in.GetCurrentInputLine(); got != "show interfaces brief" {
+		t.Errorf("after moving left: %q", got)
+	}
+
+	whole := gopyte.NewInputLine(screen, nil)
+	if got := whole.GetCurrentInputLine(); got != "router# show interfaces brief" {
+		t.Errorf("without prompt: %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestITermAttentionAndBadge(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(80, 24, 100)
+	stream := gopyte.NewStream(screen, false)
+
+	var modes []gopyte.AttentionMode
+	screen.SetAttentionHandler(func(m gopyte.AttentionMode) {
+		modes = append(modes, m)
+	})
+
+	if screen.Attention() != gopyte.AttentionNone {
+		t.Fatalf("initial attention: got %q", screen.Attention())
+	}
+
+	stream.Feed("A\x1b]1337;RequestAttention=yes\x07B")
+	if screen.Attention() != gopyte.AttentionYes {
+		t.Errorf("attention: got %q, want yes", screen.Attention())
+	}
+
+	stream.Feed("\x1b]1337;RequestAttention=no\x1b\\")
+	if screen.Attention() != gopyte.AttentionNone {
+		t.Errorf("attention after cancel: got %q, want no", screen.Attention())
+	}
+	if len(modes) != 2 {
+		t.Errorf("handler calls: got %d, want 2", len(modes))
+	}
+
+	// "\(session.name)" base64 encoded
+	stream.Feed("\x1b]1337;SetBadgeFormat=XChzZXNzaW9uLm5hbWUp\x07C")
+	if got := screen.BadgeFormat(); got != `\(session.name)` {
+		t.Errorf("badge: got %q", got)
+	}
+
+	stream.Feed("\x1b]1337;SetBadgeFormat=\x07")
+	if got := screen.BadgeFormat(); got != "" {
+		t.Errorf("badge after clear: got %q", got)
+	}
+
+	// Unknown keys and bad payloads must not reach the display
+	stream.Feed("\x1b]1337;SetBadgeFormat=!!!\x07\x1b]1337;CurrentDir=/tmp\x07D")
+
+	if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != "ABCD" {
+		t.Errorf("display: got %q, want %q", got, "ABCD")
+	}
+}
@@ -0,0 +1,72 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestEncodeKey(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	tests := []struct {
+		ev   gopyte.KeyEvent
+		want string
+	}{
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: 'é'}, "é"},
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: 'c', Mods: gopyte.ModCtrl}, "\x03"},
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: '[', Mods: gopyte.ModCtrl}, "\x1b"},
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: 'x', Mods: gopyte.ModAlt}, "\x1bx"},
+		{gopyte.KeyEvent{Key: gopyte.KeyEnter}, "\r"},
+		{gopyte.KeyEvent{Key: gopyte.KeyTab, Mods: gopyte.ModShift}, "\x1b[Z"},
+		{gopyte.KeyEvent{Key: gopyte.KeyBackspace}, "\x7f"},
+		{gopyte.KeyEvent{Key: gopyte.KeyUp}, "\x1b[A"},
+		{gopyte.KeyEvent{Key: gopyte.KeyLeft, Mods: gopyte.ModCtrl}, "\x1b[1;5D"},
+		{gopyte.KeyEvent{Key: gopyte.KeyHome}, "\x1b[H"},
+		{gopyte.KeyEvent{Key: gopyte.KeyF1}, "\x1bOP"},
+		{gopyte.KeyEvent{Key: gopyte.KeyF5, Mods: gopyte.ModShift}, "\x1b[15;2~"},
+		{gopyte.KeyEvent{Key: gopyte.KeyPageDown}, "\x1b[6~"},
+		{gopyte.KeyEvent{Key: gopyte.KeyKP5}, "5"},
+		{gopyte.KeyEvent{Key: gopyte.KeyKPEnter}, "\r"},
+	}
+	for _, tt := range tests {
+		if got := string(screen.EncodeKey(tt.ev)); got != tt.want {
+			t.Errorf("%+v: %q, want %q", tt.ev, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeKeyApplicationModes(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	stream := gopyte.NewStream(screen, false)
+	key := func(k gopyte.Key) string { return string(screen.EncodeKey(gopyte.KeyEvent{Key: k})) }
+
+	stream.Feed("\x1b[?1h\x1b=")
+	if !screen.ApplicationCursorKeys() || !screen.ApplicationKeypad() {
+		t.Fatal("modes not set")
+	}
+	if got := key(gopyte.KeyUp) + key(gopyte.KeyEnd); got != "\x1bOA\x1bOF" {
+		t.Errorf("application cursor keys = %q", got)
+	}
+	if got := key(gopyte.KeyKP5) + key(gopyte.KeyKPEnter) + key(gopyte.KeyKPMinus); got != "\x1bOu\x1bOM\x1bOm" {
+		t.Errorf("application keypad = %q", got)
+	}
+	// Modified cursor keys are the same in both modes
+	if got := string(screen.EncodeKey(gopyte.KeyEvent{Key: gopyte.KeyUp, Mods: gopyte.ModShift})); got != "\x1b[1;2A" {
+		t.Errorf("shift+up = %q", got)
+	}
+
+	stream.Feed("\x1b>")
+	if screen.ApplicationKeypad() || key(gopyte.KeyKP5) != "5" {
+		t.Error("ESC > did not restore the numeric keypad")
+	}
+	stream.Feed("\x1b[?66h")
+	if !screen.ApplicationKeypad() {
+		t.Error("DECNKM not set")
+	}
+
+	// DECSTR returns both to normal
+	stream.Feed("\x1b[!p")
+	if screen.ApplicationCursorKeys() || screen.ApplicationKeypad() || key(gopyte.KeyUp) != "\x1b[A" {
+		t.Error("modes kept across DECSTR")
+	}
+}
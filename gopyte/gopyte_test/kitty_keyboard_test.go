@@ -0,0 +1,107 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestKittyKeyboardStack(t *testing.T) {
+	screen := gopyte.NewAlternateScreen(80, 24, 10)
+	stream := gopyte.NewStream(screen, false)
+	tr := gopyte.NewTranscript()
+	screen.SetTranscript(tr)
+
+	steps := []struct {
+		seq  string
+		want int
+	}{
+		{"\x1b[>1u", 1},
+		{"\x1b[>3u", 3},
+		{"\x1b[=8;2u", 11},
+		{"\x1b[=2;3u", 9},
+		{"\x1b[=4u", 4},
+		{"\x1b[<u", 1},
+		{"\x1b[<5u", 0},
+		{"\x1b[=5u", 5}, // Sets the flags even with an empty stack
+	}
+	for _, st := range steps {
+		stream.Feed(st.seq)
+		if got := screen.KeyboardFlags(); got != st.want {
+			t.Errorf("%q: flags = %d, want %d", st.seq, got, st.want)
+		}
+	}
+
+	stream.Feed("\x1b[?u")
+	if ev := tr.Events(); len(ev) != 1 || ev[0].Data != "\x1b[?5u" {
+		t.Errorf("query response = %+v", ev)
+	}
+
+	// The alternate screen has its own stack
+	stream.Feed("\x1b[?1049h")
+	if got := screen.KeyboardFlags(); got != 0 {
+		t.Errorf("alternate screen flags = %d", got)
+	}
+	stream.Feed("\x1b[>1u\x1b[?1049l")
+	if got := screen.KeyboardFlags(); got != 5 {
+		t.Errorf("main screen flags after leaving = %d, want 5", got)
+	}
+
+	stream.Feed("\x1bc")
+	if got := screen.KeyboardFlags(); got != 0 {
+		t.Errorf("flags after RIS = %d", got)
+	}
+}
+
+func TestKittyKeyboardEncoding(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	stream := gopyte.NewStream(screen, false)
+	key := func(ev gopyte.KeyEvent) string { return string(screen.EncodeKey(ev)) }
+
+	stream.Feed("\x1b[>1u")
+	tests := []struct {
+		ev   gopyte.KeyEvent
+		want string
+	}{
+		{gopyte.KeyEvent{Key: gopyte.KeyEscape}, "\x1b[27u"},
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: 'i', Mods: gopyte.ModCtrl}, "\x1b[105;5u"},
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: '[', Mods: gopyte.ModAlt}, "\x1b[91;3u"},
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: 'A', Mods: gopyte.ModShift}, "A"},
+		{gopyte.KeyEvent{Key: gopyte.KeyTab}, "\t"},
+		{gopyte.KeyEvent{Key: gopyte.KeyEnter, Mods: gopyte.ModShift}, "\x1b[13;2u"},
+		{gopyte.KeyEvent{Key: gopyte.KeyUp}, "\x1b[A"},
+		{gopyte.KeyEvent{Key: gopyte.KeyF3, Mods: gopyte.ModCtrl}, "\x1b[13;5~"},
+		{gopyte.KeyEvent{Key: gopyte.KeyKP1}, "\x1b[57400u"},
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: 'a', Event: gopyte.KeyRelease}, ""},
+	}
+	for _, tt := range tests {
+		if got := key(tt.ev); got != tt.want {
+			t.Errorf("%+v: %q, want %q", tt.ev, got, tt.want)
+		}
+	}
+
+	// Every key as an escape code, with events, alternates and text
+	stream.Feed("\x1b[=31u")
+	tests = []struct {
+		ev   gopyte.KeyEvent
+		want string
+	}{
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: 'a'}, "\x1b[97;1;97u"},
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: 'A', Mods: gopyte.ModShift}, "\x1b[97:65;2;65u"},
+		{gopyte.KeyEvent{Key: gopyte.KeyRune, Rune: 'a', Event: gopyte.KeyRelease}, "\x1b[97;1:3u"},
+		{gopyte.KeyEvent{Key: gopyte.KeyEnter}, "\x1b[13u"},
+		{gopyte.KeyEvent{Key: gopyte.KeyUp, Event: gopyte.KeyRepeat}, "\x1b[1;1:2A"},
+		{gopyte.KeyEvent{Key: gopyte.KeyUp}, "\x1b[A"},
+	}
+	for _, tt := range tests {
+		if got := key(tt.ev); got != tt.want {
+			t.Errorf("flags 31, %+v: %q, want %q", tt.ev, got, tt.want)
+		}
+	}
+
+	// Legacy encoding again once the stack is empty
+	stream.Feed("\x1b[<u")
+	if got := key(gopyte.KeyEvent{Key: gopyte.KeyEscape}); got != "\x1b" {
+		t.Errorf("escape after pop = %q", got)
+	}
+}
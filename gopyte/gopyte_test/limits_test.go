@@ -0,0 +1,119 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestParserLimitsDefault(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 5)
+	stream := gopyte.NewStream(screen, false)
+	if got := stream.Limits(); got != gopyte.DefaultParserLimits() {
+		t.Errorf("Limits() = %+v", got)
+	}
+
+	// Too many parameters: the whole sequence is dropped
+	stream.Feed("\x1b[3;4" + strings.Repeat(";1", 40) + "HX")
+	if x, y := screen.GetCursor(); x != 1 || y != 0 {
+		t.Errorf("cursor at (%d, %d) after overlong CUP", x, y)
+	}
+
+	// Huge values are clamped rather than overflowing
+	stream.Feed("\r\x1b[99999999999999999999999999999C")
+	if x, _ := screen.GetCursor(); x != 19 {
+		t.Errorf("cursor at column %d after huge CUF", x)
+	}
+	stream.Feed("\x1b[0000000000000003;0000000000000002H")
+	if x, y := screen.GetCursor(); x != 1 || y != 2 {
+		t.Errorf("cursor at (%d, %d) after zero-padded CUP", x, y)
+	}
+}
+
+func TestParserLimitsTruncate(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 5)
+	stream := gopyte.NewStream(screen, true)
+	stream.SetLimits(gopyte.ParserLimits{MaxParams: 2, MaxParamValue: 4, MaxOSCBytes: 6, Overflow: gopyte.OverflowTruncate})
+
+	errs := stream.Feed("\x1b[3;9;5H")
+	if x, y := screen.GetCursor(); x != 3 || y != 2 {
+		t.Errorf("cursor at (%d, %d), want (3, 2)", x, y)
+	}
+	if len(errs) != 1 || errs[0].Reason != "limit exceeded" || string(errs[0].Raw) != "\x1b[3;9;5H" {
+		t.Errorf("errors = %v", errs)
+	}
+
+	errs = stream.Feed("\x1b]2;long title\x07")
+	if got := screen.Title(); got != "long" {
+		t.Errorf("title = %q, want %q", got, "long")
+	}
+	if len(errs) != 1 || errs[0].Reason != "limit exceeded" {
+		t.Errorf("errors = %v", errs)
+	}
+
+	// Too many intermediates is never repaired
+	called := 0
+	stream.RegisterCSIHandler('q', "!!!!", func([]int, string) { called++ })
+	stream.Feed("\x1b[!!!!q\x1b[!!!!!q")
+	if called != 1 {
+		t.Errorf("handler called %d times, want 1", called)
+	}
+}
+
+func TestParserLimitsDCS(t *testing.T) {
+	trace := gopyte.NewTraceScreen(nil)
+	stream := gopyte.NewStream(trace, true)
+	stream.SetLimits(gopyte.ParserLimits{MaxDCSBytes: 4})
+
+	errs := stream.Feed("\x1bP$qm\x1b\\\x1bP$q" + strings.Repeat("x", 100) + "\x1b\\")
+	if got := strings.Count(trace.String(), "DeviceControl"); got != 1 {
+		t.Errorf("calls:\n%s", trace)
+	}
+	if len(errs) != 1 || errs[0].Reason != "limit exceeded" || errs[0].Offset != 7 {
+		t.Errorf("errors = %v", errs)
+	}
+
+	// Truncated strings still dispatch, and limits can be lifted
+	trace.ClearCalls()
+	stream.SetLimits(gopyte.ParserLimits{MaxDCSBytes: 4, Overflow: gopyte.OverflowTruncate})
+	stream.Feed("\x1bP$qabcdef\x1b\\")
+	stream.SetLimits(gopyte.ParserLimits{})
+	stream.Feed("\x1bP$q" + strings.Repeat("y", 100) + "\x1b\\")
+	calls := trace.Calls()
+	if len(calls) != 2 || !strings.Contains(calls[0].String(), "Data:abcd}") || !strings.Contains(calls[1].String(), strings.Repeat("y", 100)) {
+		t.Errorf("calls:\n%s", trace)
+	}
+}
+
+func TestParserLimitsSplitSequence(t *testing.T) {
+	stream := gopyte.NewStream(gopyte.NewNativeScreen(20, 5), true)
+	stream.SetLimits(gopyte.ParserLimits{MaxOSCBytes: 8, MaxDCSBytes: 8})
+
+	// A strict stream keeps only the start of a long split sequence
+	stream.Feed("ab\x1bP$q")
+	for i := 0; i < 10; i++ {
+		stream.Feed(strings.Repeat("x", 100))
+	}
+	errs := stream.Feed("\x1b\\")
+	if len(errs) != 1 || errs[0].Offset != 2 || string(errs[0].Raw) != "\x1bP$qxxxx\x1b\\" {
+		t.Errorf("errors = %v", errs)
+	}
+
+	// A raw tap gets the bytes early, and all of them
+	var raw strings.Builder
+	units := 0
+	stream.SetRawTap(func(seq gopyte.RawSequence) {
+		raw.Write(seq.Raw)
+		units++
+	})
+	input := "\x1b]2;" + strings.Repeat("t", 100)
+	stream.Feed(input)
+	if units != 1 {
+		t.Errorf("%d units before the end of the string, want 1", units)
+	}
+	stream.Feed("\x07")
+	if raw.String() != input+"\x07" {
+		t.Errorf("raw tap saw %d bytes, want %d", raw.Len(), len(input)+1)
+	}
+}
@@ -0,0 +1,129 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestLeftRightMarginsWrap(t *testing.T) {
+	for name, screen := range map[string]gopyte.Screen{
+		"native":    gopyte.NewNativeScreen(8, 3),
+		"history":   gopyte.NewHistoryScreen(8, 3, 10),
+		"alternate": gopyte.NewAlternateScreen(8, 3, 10),
+		"widechar":  gopyte.NewWideCharScreen(8, 3, 10),
+	} {
+		stream := gopyte.NewStream(screen, false)
+		nav := screen.(interface {
+			GetDisplay() []string
+			GetCursor() (int, int)
+		})
+
+		// Margins at columns 3..5; text wraps inside them and CR
+		// returns to the left one
+		stream.Feed("........\r\n........\r\n........")
+		stream.Feed("\x1b[?69h\x1b[3;5s\x1b[1;3Habcdefg\rX")
+		want := []string{"..abc...", "..def...", "..X....."}
+		for i, line := range nav.GetDisplay() {
+			if line != want[i] {
+				t.Errorf("%s: line %d = %q, want %q", name, i, line, want[i])
+			}
+		}
+
+		// A linefeed at the bottom scrolls only the margin columns
+		stream.Feed("\n")
+		want = []string{"..def...", "..X.....", "..   ..."}
+		for i, line := range nav.GetDisplay() {
+			if line != want[i] {
+				t.Errorf("%s: after LF line %d = %q, want %q", name, i, line, want[i])
+			}
+		}
+	}
+}
+
+func TestLeftRightMarginsEditing(t *testing.T) {
+	screen := gopyte.NewNativeScreen(8, 3)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("abcdefgh\r\nijklmnop\r\nqrstuvwx\x1b[?69h\x1b[3;6s")
+
+	// ICH and DCH shift only up to the right margin
+	stream.Feed("\x1b[1;4H\x1b[@\x1b[2;4H\x1b[P")
+	// IL and DL outside the margins do nothing
+	stream.Feed("\x1b[3;8H\x1b[L\x1b[M")
+	want := []string{"abc degh", "ijkmn op", "qrstuvwx"}
+	for i, line := range screen.GetDisplay() {
+		if line != want[i] {
+			t.Errorf("line %d = %q, want %q", i, line, want[i])
+		}
+	}
+
+	// IL inside moves the margin columns down from the cursor row
+	stream.Feed("\x1b[2;3H\x1b[L")
+	want = []string{"abc degh", "ij    op", "qrkmn wx"}
+	for i, line := range screen.GetDisplay() {
+		if line != want[i] {
+			t.Errorf("after IL line %d = %q, want %q", i, line, want[i])
+		}
+	}
+}
+
+func TestLeftRightMarginsWideChars(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(8, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// The cell widths scroll with the margin columns
+	stream.Feed("\x1b[?69h\x1b[3;6s\x1b[3;3H中\n")
+	want := []string{"        ", "  中    ", "        "}
+	for i, line := range screen.GetDisplay() {
+		if line != want[i] {
+			t.Errorf("line %d = %q, want %q", i, line, want[i])
+		}
+	}
+	if c := screen.GetCell(1, 2); c.Char != '中' || c.Width != 2 {
+		t.Errorf("moved cell: %q width %d", c.Char, c.Width)
+	}
+
+	// A wide character across the left margin loses the half that stays
+	stream.Feed("\x1b[?69l\x1b[3;2H中\x1b[?69h\x1b[3;6s\x1b[3;3H\x1b[M")
+	want = []string{"        ", "  中    ", "        "}
+	for i, line := range screen.GetDisplay() {
+		if line != want[i] {
+			t.Errorf("after DL line %d = %q, want %q", i, line, want[i])
+		}
+	}
+}
+
+func TestLeftRightMarginsOrigin(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 3)
+	stream := gopyte.NewStream(screen, false)
+
+	// Origin mode counts from the left margin and stops at the right one
+	stream.Feed("\x1b[?69h\x1b[4;7s\x1b[?6h\x1b[1;2H")
+	if x, y := screen.GetCursor(); x != 4 || y != 0 {
+		t.Errorf("cursor = %d,%d, want 4,0", x, y)
+	}
+	stream.Feed("\x1b[1;20H")
+	if x, _ := screen.GetCursor(); x != 6 {
+		t.Errorf("cursor x = %d, want 6", x)
+	}
+
+	// Resetting DECLRMM drops the margins
+	stream.Feed("\x1b[?69l")
+	if _, _, ok := screen.LeftRightMargins(); ok {
+		t.Error("margins kept after CSI ? 69 l")
+	}
+}
+
+func TestLeftRightMarginsSCOSC(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 3)
+	stream := gopyte.NewStream(screen, false)
+
+	// Without DECLRMM, CSI s and CSI u save and restore the cursor
+	stream.Feed("\x1b[2;5H\x1b[s\x1b[H\x1b[u")
+	if x, y := screen.GetCursor(); x != 4 || y != 1 {
+		t.Errorf("cursor = %d,%d, want 4,1", x, y)
+	}
+	if _, _, ok := screen.LeftRightMargins(); ok {
+		t.Error("CSI s set margins with DECLRMM off")
+	}
+}
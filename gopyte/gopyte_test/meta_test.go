@@ -0,0 +1,70 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+type severity int
+
+func TestLineMeta(t *testing.T) {
+	sev := gopyte.NewMetaKey[severity]("severity")
+	link := gopyte.NewMetaKey[string]("link")
+
+	screen := gopyte.NewHistoryScreen(20, 3, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("%LINK-3-DOWN Gi0/1")
+
+	sev.Set(screen.LineMeta(0), 3)
+	link.SetCell(screen.LineMeta(0), 13, "Gi0/1")
+	link.SetCell(screen.LineMeta(0), 2, "LINK")
+	if v, ok := sev.Get(screen.LineMeta(0)); !ok || v != 3 {
+		t.Fatalf("Get = %v, %v", v, ok)
+	}
+	if _, ok := link.Get(screen.LineMeta(0)); ok {
+		t.Error("keys must not see each other's values")
+	}
+
+	// Deleting characters shifts cell metadata with the text
+	stream.Feed("\x1b[1;1H\x1b[P")
+	if v, ok := link.GetCell(screen.LineMeta(0), 12); !ok || v != "Gi0/1" {
+		t.Errorf("after DCH: %q, %v", v, ok)
+	}
+	// Erasing part of the line drops the cell metadata there
+	stream.Feed("\x1b[1;11H\x1b[K")
+	if _, ok := link.GetCell(screen.LineMeta(0), 12); ok {
+		t.Error("EL 0 should drop cell metadata")
+	}
+	if _, ok := link.GetCell(screen.LineMeta(0), 1); !ok {
+		t.Error("cell metadata before the cursor should stay")
+	}
+
+	// Snapshots copy metadata
+	snap := screen.Clone()
+	sev.Set(screen.LineMeta(0), 5)
+	if v, _ := sev.Get(snap.LineMeta(0)); v != 3 {
+		t.Errorf("snapshot shares metadata: %v", v)
+	}
+
+	// Metadata follows the line into the scrollback
+	stream.Feed("\x1b[3;1H\r\n\r\n")
+	if v, ok := sev.Get(screen.HistoryMeta(0)); !ok || v != 5 {
+		t.Errorf("history metadata = %v, %v", v, ok)
+	}
+	if _, ok := sev.Get(screen.LineMeta(0)); ok {
+		t.Error("screen line 0 should be a new line")
+	}
+	screen.ScrollUp(2)
+	if v, _ := sev.Get(screen.LineMeta(0)); v != 5 {
+		t.Error("history view should show the line's metadata")
+	}
+	screen.ScrollToBottom()
+
+	// Erasing the line drops it
+	sev.Set(screen.LineMeta(1), 1)
+	stream.Feed("\x1b[2;1H\x1b[2K")
+	if !screen.LineMeta(1).Empty() {
+		t.Error("EL 2 should drop line metadata")
+	}
+}
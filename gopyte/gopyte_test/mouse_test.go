@@ -0,0 +1,36 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestMouseProtocol(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(80, 24, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	if screen.MouseProtocol().Enabled() {
+		t.Fatal("mouse reporting on initially")
+	}
+
+	tests := []struct {
+		seq  string
+		want gopyte.MouseProtocol
+	}{
+		{"\x1b[?1000h", gopyte.MouseProtocol{Tracking: gopyte.MouseTrackingNormal}},
+		{"\x1b[?1002;1006h", gopyte.MouseProtocol{Tracking: gopyte.MouseTrackingButton, Encoding: gopyte.MouseEncodingSGR}},
+		// Resetting a mode not in effect changes nothing
+		{"\x1b[?1000l\x1b[?1015l", gopyte.MouseProtocol{Tracking: gopyte.MouseTrackingButton, Encoding: gopyte.MouseEncodingSGR}},
+		{"\x1b[?1003h\x1b[?1016h", gopyte.MouseProtocol{Tracking: gopyte.MouseTrackingAny, Encoding: gopyte.MouseEncodingSGRPixels}},
+		{"\x1b[?1003l\x1b[?1016l", gopyte.MouseProtocol{}},
+		{"\x1b[?9h\x1b[?1005h", gopyte.MouseProtocol{Tracking: gopyte.MouseTrackingX10, Encoding: gopyte.MouseEncodingUTF8}},
+		{"\x1bc", gopyte.MouseProtocol{}},
+	}
+	for _, tt := range tests {
+		stream.Feed(tt.seq)
+		if got := screen.MouseProtocol(); got != tt.want {
+			t.Errorf("%q: protocol = %+v, want %+v", tt.seq, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestDesktopNotifications(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []gopyte.Notification
+	}{
+		{
+			name:     "OSC 9 with BEL",
+			input:    "\x1b]9;Build finished\x07",
+			expected: []gopyte.Notification{{Body: "Build finished", Source: "osc9"}},
+		},
+		{
+			name:     "OSC 777 notify with ST",
+			input:    "\x1b]777;notify;make;done in 42s\x1b\\",
+			expected: []gopyte.Notification{{Title: "make", Body: "done in 42s", Source: "osc777"}},
+		},
+		{
+			name:     "OSC 9;4 progress ignored",
+			input:    "\x1b]9;4;1;50\x07\x1b]9;4;0\x07",
+			expected: nil,
+		},
+		{
+			name:     "OSC 777 other command ignored",
+			input:    "\x1b]777;precmd\x07",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			screen := gopyte.NewWideCharScreen(80, 24, 100)
+			stream := gopyte.NewStream(screen, false)
+
+			var received []gopyte.Notification
+			screen.SetNotificationHandler(func(n gopyte.Notification) {
+				received = append(received, n)
+			})
+
+			stream.Feed("before" + tt.input + "after")
+
+			if len(received) != len(tt.expected) {
+				t.Fatalf("got %d notifications, want %d: %+v", len(received), len(tt.expected), received)
+			}
+			for i := range received {
+				if received[i] != tt.expected[i] {
+					t.Errorf("notification %d: got %+v, want %+v", i, received[i], tt.expected[i])
+				}
+			}
+			if got := screen.Notifications(); len(got) != len(tt.expected) {
+				t.Errorf("Notifications(): got %d, want %d", len(got), len(tt.expected))
+			}
+			if got := screen.Notifications(); len(got) != 0 {
+				t.Errorf("Notifications() should drain the queue, got %d", len(got))
+			}
+			if display := screen.GetDisplay(); strings.TrimRight(display[0], " ") != "beforeafter" {
+				t.Errorf("payload leaked into display: %q", display[0])
+			}
+		})
+	}
+}
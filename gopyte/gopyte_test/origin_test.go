@@ -0,0 +1,63 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestRelativeAndAbsolutePositioning(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	tests := []struct {
+		seq  string
+		x, y int
+	}{
+		{"\x1b[3;4H", 3, 2},
+		{"\x1b[2a", 5, 2},   // HPR
+		{"\x1b[a", 6, 2},    // HPR, default 1
+		{"\x1b[3e", 6, 5},   // VPR
+		{"\x1b[e", 6, 6},    // VPR, default 1
+		{"\x1b[12`", 11, 6}, // HPA
+		{"\x1b[`", 0, 6},    // HPA, default 1
+		{"\x1b[2d", 0, 1},   // VPA
+		{"\x1b[99a", 19, 1},
+		{"\x1b[99e", 19, 9},
+		{"\x1b[99`\x1b[99d", 19, 9},
+		{"\x1b[0`\x1b[0d", 0, 0},
+	}
+	for _, tt := range tests {
+		stream.Feed(tt.seq)
+		if x, y := screen.GetCursor(); x != tt.x || y != tt.y {
+			t.Errorf("%q: cursor = %d,%d, want %d,%d", tt.seq, x, y, tt.x, tt.y)
+		}
+	}
+}
+
+func TestOriginMode(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// Setting and resetting DECOM homes the cursor
+	stream.Feed("\x1b[5;5H\x1b[?6h")
+	if x, y := screen.GetCursor(); x != 0 || y != 0 || !screen.OriginMode() {
+		t.Errorf("after DECOM set: cursor = %d,%d, origin %v", x, y, screen.OriginMode())
+	}
+
+	// Without margins the origin is the top left corner of the screen
+	stream.Feed("\x1b[4;6H\x1b[3`\x1b[7d")
+	if x, y := screen.GetCursor(); x != 2 || y != 6 {
+		t.Errorf("in origin mode: cursor = %d,%d, want 2,6", x, y)
+	}
+
+	stream.Feed("\x1b[?6l")
+	if x, y := screen.GetCursor(); x != 0 || y != 0 || screen.OriginMode() {
+		t.Errorf("after DECOM reset: cursor = %d,%d, origin %v", x, y, screen.OriginMode())
+	}
+
+	stream.Feed("\x1b[?6h\x1b[!p")
+	if screen.OriginMode() {
+		t.Error("DECSTR left origin mode set")
+	}
+}
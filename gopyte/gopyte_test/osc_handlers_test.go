@@ -0,0 +1,38 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestRegisterOSCHandler(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 3)
+	stream := gopyte.NewStream(screen, false)
+
+	var marks []string
+	stream.RegisterOSCHandler(133, func(param string) {
+		marks = append(marks, param)
+	})
+	stream.Feed("\x1b]133;A\x07$ ls\x1b]133;D;0\x1b\\\x1b]133\x07")
+	if want := []string{"A", "D;0", ""}; !reflect.DeepEqual(marks, want) {
+		t.Errorf("OSC 133 payloads = %q, want %q", marks, want)
+	}
+	if got := screen.GetDisplay()[0]; got != "$ ls" {
+		t.Errorf("display = %q, want %q", got, "$ ls")
+	}
+
+	// A handler overrides the built-in command until removed
+	var titles []string
+	stream.RegisterOSCHandler(2, func(param string) { titles = append(titles, param) })
+	stream.Feed("\x1b]2;ignored\x07")
+	if screen.Title() != "" || len(titles) != 1 {
+		t.Errorf("title = %q, handler calls %q", screen.Title(), titles)
+	}
+	stream.RegisterOSCHandler(2, nil)
+	stream.Feed("\x1b]2;shown\x07")
+	if screen.Title() != "shown" || len(titles) != 1 {
+		t.Errorf("after removal: title = %q, handler calls %q", screen.Title(), titles)
+	}
+}
@@ -0,0 +1,86 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestOSCSplitAcrossFeeds(t *testing.T) {
+	inputs := []string{
+		"\x1b]2;café ✓\x07x",
+		"\x1b]2;café ✓\x1b\\x",
+		"\x9d2;café ✓\x9cx",
+	}
+	for _, input := range inputs {
+		for split := 0; split <= len(input); split++ {
+			screen := gopyte.NewNativeScreen(20, 2)
+			stream := gopyte.NewStream(screen, false)
+			stream.Feed(input[:split])
+			stream.Feed(input[split:])
+
+			if screen.Title() != "café ✓" || screen.GetDisplay()[0] != "x" {
+				t.Errorf("%q split at %d: title %q, display %q",
+					input, split, screen.Title(), screen.GetDisplay()[0])
+			}
+		}
+	}
+}
+
+func TestOSCCancelled(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 2)
+	stream := gopyte.NewStream(screen, false)
+
+	// An escape sequence other than ST abandons the string and runs
+	stream.Feed("\x1b]2;lost\x1b[2Cx\x1b]2;gone\x18y")
+	if screen.Title() != "" || screen.GetDisplay()[0] != "  xy" {
+		t.Errorf("title %q, display %q", screen.Title(), screen.GetDisplay()[0])
+	}
+}
+
+func TestOSCColors(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 2)
+	stream := gopyte.NewStream(screen, false)
+
+	var got []gopyte.ColorChange
+	screen.SetColorHandler(func(c gopyte.ColorChange) { got = append(got, c) })
+
+	stream.Feed("\x1b]4;1;rgb:ff/00/00;2;?\x07")
+	stream.Feed("\x1b]10;#ffffff;#000000\x1b\\")
+	stream.Feed("\x1b]12;?\x07\x1b]104\x07\x1b]104;3\x07\x1b]111\x07")
+
+	want := []gopyte.ColorChange{
+		{Target: gopyte.ColorPalette, Index: 1, Spec: "rgb:ff/00/00"},
+		{Target: gopyte.ColorPalette, Index: 2, Spec: "?"},
+		{Target: gopyte.ColorForeground, Spec: "#ffffff"},
+		{Target: gopyte.ColorBackground, Spec: "#000000"},
+		{Target: gopyte.ColorCursor, Spec: "?"},
+		{Target: gopyte.ColorPalette, Index: -1},
+		{Target: gopyte.ColorPalette, Index: 3},
+		{Target: gopyte.ColorBackground},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestOSCHyperlink(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 2)
+	stream := gopyte.NewStream(screen, false)
+
+	var events []gopyte.Hyperlink
+	screen.SetHyperlinkHandler(func(l gopyte.Hyperlink) { events = append(events, l) })
+
+	stream.Feed("\x1b]8;id=42:x=y;https://example.com/a;b\x1b\\link")
+	if link, ok := screen.Hyperlink(); !ok || link.ID != "42" || link.URI != "https://example.com/a;b" {
+		t.Errorf("Hyperlink = %+v, %v", link, ok)
+	}
+	stream.Feed("\x1b]8;;\x1b\\ text")
+	if _, ok := screen.Hyperlink(); ok || len(events) != 2 {
+		t.Errorf("link still open, events %+v", events)
+	}
+	if screen.GetDisplay()[0] != "link text" {
+		t.Errorf("display %q", screen.GetDisplay()[0])
+	}
+}
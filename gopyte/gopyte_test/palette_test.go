@@ -0,0 +1,72 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestParseColorSpec(t *testing.T) {
+	cases := map[string]gopyte.RGB{
+		"rgb:ff/80/00":       {R: 0xff, G: 0x80, B: 0x00},
+		"rgb:f/8/0":          {R: 0xff, G: 0x88, B: 0x00},
+		"rgb:ffff/0000/8080": {R: 0xff, G: 0x00, B: 0x80},
+		"#ff8000":            {R: 0xff, G: 0x80, B: 0x00},
+		"#f80":               {R: 0xf0, G: 0x80, B: 0x00},
+		"#ffff80800000":      {R: 0xff, G: 0x80, B: 0x00},
+	}
+	for spec, want := range cases {
+		if got, ok := gopyte.ParseColorSpec(spec); !ok || got != want {
+			t.Errorf("%q = %v, %v; want %v", spec, got, ok, want)
+		}
+	}
+	for _, bad := range []string{"", "red", "rgb:ff/80", "#ff80", "rgb:fffff/0/0", "#gg0000"} {
+		if _, ok := gopyte.ParseColorSpec(bad); ok {
+			t.Errorf("%q parsed", bad)
+		}
+	}
+}
+
+func TestDynamicColors(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 3)
+	stream := gopyte.NewStream(screen, false)
+	tr := gopyte.NewTranscript()
+	screen.SetTranscript(tr)
+	screen.SetBaseColor(gopyte.ColorBackground, 0, gopyte.RGB{R: 0x10, G: 0x10, B: 0x10})
+
+	if got := screen.Color(gopyte.ColorPalette, 1); got != (gopyte.RGB{R: 0xcd, G: 0, B: 0}) {
+		t.Errorf("palette 1 = %v", got)
+	}
+
+	stream.Feed("\x1b]4;1;#00ff00;200;rgb:12/34/56\x07\x1b]11;#ffffff\x07")
+	if got := screen.Color(gopyte.ColorPalette, 1); got.Hex() != "#00ff00" {
+		t.Errorf("palette 1 = %s", got.Hex())
+	}
+	if got := screen.Color(gopyte.ColorBackground, 0); got.Hex() != "#ffffff" {
+		t.Errorf("background = %s", got.Hex())
+	}
+
+	stream.Feed("\x1b]4;200;?\x07\x1b]11;?\x07")
+	events := tr.Events()
+	if len(events) != 2 ||
+		events[0].Data != "\x1b]4;200;rgb:1212/3434/5656\x1b\\" ||
+		events[1].Data != "\x1b]11;rgb:ffff/ffff/ffff\x1b\\" {
+		t.Errorf("replies = %+v", events)
+	}
+
+	// Resets return to the base colors
+	stream.Feed("\x1b]104;1\x07\x1b]111\x07")
+	if got := screen.Color(gopyte.ColorPalette, 1); got.Hex() != "#cd0000" {
+		t.Errorf("palette 1 after reset = %s", got.Hex())
+	}
+	if got := screen.Color(gopyte.ColorPalette, 200); got.Hex() != "#123456" {
+		t.Errorf("palette 200 reset too early: %s", got.Hex())
+	}
+	if got := screen.Color(gopyte.ColorBackground, 0); got.Hex() != "#101010" {
+		t.Errorf("background after reset = %s", got.Hex())
+	}
+	stream.Feed("\x1b]104\x07")
+	if got := screen.Color(gopyte.ColorPalette, 200); got.Hex() != "#ff00d7" {
+		t.Errorf("palette 200 after full reset = %s", got.Hex())
+	}
+}
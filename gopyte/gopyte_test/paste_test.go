@@ -0,0 +1,32 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestEncodePaste(t *testing.T) {
+	screen := gopyte.NewAlternateScreen(80, 24, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	text := "echo hi\r\nrm -rf /tmp/x\x03\n\ttab\x1b[201~\x9b31m"
+	clean := "echo hi\rrm -rf /tmp/x\r\ttab[201~31m"
+
+	if got := string(screen.EncodePaste(text)); got != clean {
+		t.Errorf("plain paste = %q, want %q", got, clean)
+	}
+
+	stream.Feed("\x1b[?2004h")
+	if !screen.BracketedPaste() {
+		t.Fatal("bracketed paste not enabled")
+	}
+	if got, want := string(screen.EncodePaste(text)), "\x1b[200~"+clean+"\x1b[201~"; got != want {
+		t.Errorf("bracketed paste = %q, want %q", got, want)
+	}
+
+	stream.Feed("\x1b[?2004l")
+	if screen.BracketedPaste() {
+		t.Error("bracketed paste still on after CSI ? 2004 l")
+	}
+}
@@ -0,0 +1,90 @@
+package gopyte_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestDiffApply(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 4, 3)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("$ ")
+
+	// The client starts from nothing and follows the patches
+	var client gopyte.Snapshot
+	prev := (*gopyte.Snapshot)(nil)
+	step := func(name, data string) *gopyte.Patch {
+		t.Helper()
+		stream.Feed(data)
+		next := screen.Snapshot()
+		patch := gopyte.Diff(prev, next)
+		prev = next
+
+		wire, err := json.Marshal(patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var received gopyte.Patch
+		if err := json.Unmarshal(wire, &received); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Apply(&received); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want, _ := json.Marshal(next)
+		got, _ := json.Marshal(&client)
+		if string(got) != string(want) {
+			t.Fatalf("%s: client has\n%s\nwant\n%s", name, got, want)
+		}
+		return patch
+	}
+
+	if p := step("first", ""); p.Full == nil {
+		t.Errorf("first patch is not full: %+v", p)
+	}
+	if p := step("idle", ""); !p.Empty() {
+		t.Errorf("nothing changed, got %+v", p)
+	}
+
+	p := step("typing", "ls")
+	if len(p.Lines) != 1 || p.Lines[0].X != 2 || p.Lines[0].Text != "ls" || p.Cursor == nil || p.State != nil {
+		t.Errorf("typing: got %+v", p)
+	}
+
+	step("output", "\r\nfile1  世界\r\n\x1b[1;32mfile2\x1b[0m\r\n$ ")
+	p = step("scrolling", "\r\nmore\r\n$ ")
+	if p.Scroll == 0 || len(p.Lines) > 2 || len(p.Scrollback) == 0 {
+		t.Errorf("scrolling: got scroll %d, %d lines, %d scrollback", p.Scroll, len(p.Lines), len(p.Scrollback))
+	}
+	// The scrollback is full; lines now drop off its top
+	p = step("trimming", "\r\n\r\n\r\n")
+	if p.ScrollbackDrop == 0 {
+		t.Errorf("trimming: no lines dropped: %+v", p)
+	}
+
+	p = step("title", "\x1b]2;build\x07")
+	if p.State == nil || p.State.Title != "build" || len(p.Lines) != 0 {
+		t.Errorf("title: got %+v", p)
+	}
+	step("overwrite wide", "\x1b[2;8Hx")
+
+	if p := step("alternate", "\x1b[?1049hvim"); p.Full == nil {
+		t.Errorf("alternate: patch is not full")
+	}
+	step("alternate edit", "\x1b[3;1H~")
+	step("main", "\x1b[?1049l")
+	step("clear scrollback", "\x1b[3J")
+}
+
+func TestApplyRejectsMismatchedPatch(t *testing.T) {
+	snap := gopyte.NewNativeScreen(10, 2).Snapshot()
+	bad := &gopyte.Patch{Lines: []gopyte.LineUpdate{{Y: 5, SnapshotLine: gopyte.SnapshotLine{Text: "x"}}}}
+	if err := snap.Apply(bad); err == nil {
+		t.Error("update off the screen applied")
+	}
+	if err := snap.Apply(&gopyte.Patch{ScrollbackDrop: 1}); err == nil {
+		t.Error("dropping missing scrollback applied")
+	}
+}
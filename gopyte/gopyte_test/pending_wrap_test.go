@@ -0,0 +1,92 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestPendingWrapLastColumn(t *testing.T) {
+	for name, screen := range map[string]gopyte.Screen{
+		"native":    gopyte.NewNativeScreen(5, 3),
+		"history":   gopyte.NewHistoryScreen(5, 3, 10),
+		"alternate": gopyte.NewAlternateScreen(5, 3, 10),
+		"widechar":  gopyte.NewWideCharScreen(5, 3, 10),
+	} {
+		stream := gopyte.NewStream(screen, false)
+		nav := screen.(interface {
+			GetDisplay() []string
+			GetCursor() (int, int)
+			WrapPending() bool
+		})
+
+		// Filling the row leaves the cursor on the last column
+		stream.Feed("abcde")
+		if x, y := nav.GetCursor(); x != 4 || y != 0 || !nav.WrapPending() {
+			t.Errorf("%s: cursor = %d,%d pending %v, want 4,0 pending", name, x, y, nav.WrapPending())
+		}
+
+		// BS acts on the last column and cancels the wrap
+		stream.Feed("\bX")
+		if got := nav.GetDisplay()[0]; got != "abcXe" {
+			t.Errorf("%s: after BS line 0 = %q", name, got)
+		}
+
+		// The wrap only happens when the next printable arrives
+		stream.Feed("e")
+		if x, y := nav.GetCursor(); x != 4 || y != 0 {
+			t.Errorf("%s: cursor = %d,%d before the wrap", name, x, y)
+		}
+		stream.Feed("f")
+		if got := strings.TrimRight(nav.GetDisplay()[1], " "); got != "f" {
+			t.Errorf("%s: line 1 = %q, want %q", name, got, "f")
+		}
+		if x, y := nav.GetCursor(); x != 1 || y != 1 || nav.WrapPending() {
+			t.Errorf("%s: cursor = %d,%d after the wrap, want 1,1", name, x, y)
+		}
+	}
+}
+
+func TestPendingWrapClearedByMovement(t *testing.T) {
+	for _, seq := range []string{"\r", "\x1b[1;5H", "\x1b[5G", "\x1b[A", "\x1b[C"} {
+		screen := gopyte.NewNativeScreen(5, 3)
+		stream := gopyte.NewStream(screen, false)
+		stream.Feed("\x1b[2Habcde" + seq)
+		if screen.WrapPending() {
+			t.Errorf("%q: wrap still pending", seq)
+		}
+		stream.Feed("Z")
+		if x, _ := screen.GetCursor(); seq != "\r" && x != 4 {
+			t.Errorf("%q: cursor x = %d, want 4", seq, x)
+		}
+		if got := screen.GetDisplay()[2]; got != "" {
+			t.Errorf("%q: wrapped to line 2: %q", seq, got)
+		}
+	}
+}
+
+func TestPendingWrapWithoutAutowrap(t *testing.T) {
+	screen := gopyte.NewNativeScreen(5, 2)
+	stream := gopyte.NewStream(screen, false)
+
+	// DECAWM off: the last column is overwritten and nothing is pending
+	stream.Feed("\x1b[?7labcdefg")
+	if got := screen.GetDisplay()[0]; got != "abcdg" {
+		t.Errorf("line 0 = %q, want %q", got, "abcdg")
+	}
+	if screen.WrapPending() {
+		t.Error("wrap pending with autowrap off")
+	}
+}
+
+func TestPendingWrapSavedWithCursor(t *testing.T) {
+	screen := gopyte.NewNativeScreen(5, 3)
+	stream := gopyte.NewStream(screen, false)
+
+	// DECSC/DECRC carry the pending wrap along with the position
+	stream.Feed("abcde\x1b7\x1b[3;1H\x1b8f")
+	if got := screen.GetDisplay()[1]; got != "f" {
+		t.Errorf("line 1 = %q, want %q", got, "f")
+	}
+}
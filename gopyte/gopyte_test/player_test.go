@@ -0,0 +1,207 @@
+package gopyte_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+// buildTranscript creates a recording with one output event per line,
+// 100ms apart, and a keystroke in the middle.
+func buildTranscript(t *testing.T, lines int) *gopyte.Transcript {
+	var sb strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&sb, "[%.1f, \"o\", \"line %d\\r\\n\"]\n", float64(i)/10, i)
+		if i == lines/2 {
+			fmt.Fprintf(&sb, "[%.2f, \"i\", \"q\"]\n", float64(i)/10+0.05)
+		}
+	}
+	tr, err := gopyte.ReadTranscript(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+// displayAfter replays the first n events into a fresh screen.
+func displayAfter(tr *gopyte.Transcript, n int) []string {
+	screen := gopyte.NewWideCharScreen(20, 5, 100)
+	stream := gopyte.NewStream(screen, false)
+	for _, ev := range tr.Events()[:n] {
+		if ev.Direction == gopyte.DirOutput {
+			stream.Feed(ev.Data)
+		}
+	}
+	return screen.GetDisplay()
+}
+
+func TestPlayerStepAndSeek(t *testing.T) {
+	tr := buildTranscript(t, 20)
+	player := gopyte.NewPlayer(tr, gopyte.NewWideCharScreen(20, 5, 100))
+	player.SetCheckpointInterval(4)
+
+	if player.Len() != 21 {
+		t.Fatalf("Len: got %d, want 21", player.Len())
+	}
+
+	ev, ok := player.Step()
+	if !ok || ev.Data != "line 0\r\n" || player.Position() != 1 {
+		t.Fatalf("Step: got %+v %v at %d", ev, ok, player.Position())
+	}
+
+	// Forward, backward across checkpoints, and back to the start
+	for _, n := range []int{15, 6, 13, 0, 21, 3} {
+		player.SeekEvent(n)
+		if player.Position() != n {
+			t.Fatalf("SeekEvent(%d): position %d", n, player.Position())
+		}
+		got := player.Screen().(*gopyte.WideCharScreen).GetDisplay()
+		if want := displayAfter(tr, n); !reflect.DeepEqual(got, want) {
+			t.Errorf("SeekEvent(%d):\n got %q\nwant %q", n, got, want)
+		}
+	}
+
+	// Seek by time: outputs at 0.0 .. 0.7 (the keystroke comes at 1.05)
+	player.Seek(700 * time.Millisecond)
+	if player.Position() != 8 {
+		t.Errorf("Seek(700ms): position %d, want 8", player.Position())
+	}
+	if player.Time() != 700*time.Millisecond {
+		t.Errorf("Time: got %v", player.Time())
+	}
+}
+
+func TestPlayerPlay(t *testing.T) {
+	tr := buildTranscript(t, 10)
+	player := gopyte.NewPlayer(tr, gopyte.NewWideCharScreen(20, 5, 100))
+	player.SetSpeed(1000)
+
+	start := time.Now()
+	if err := player.Play(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("speed multiplier not applied, took %v", elapsed)
+	}
+	if player.Position() != player.Len() {
+		t.Errorf("Play stopped at %d of %d", player.Position(), player.Len())
+	}
+
+	// A cancelled context stops playback immediately
+	player.SeekEvent(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := player.Play(ctx); err != context.Canceled {
+		t.Errorf("Play with cancelled context: got %v", err)
+	}
+	if player.Position() != 0 {
+		t.Errorf("no events should be applied, position %d", player.Position())
+	}
+}
+
+func TestPlayerPause(t *testing.T) {
+	tr := buildTranscript(t, 10)
+	player := gopyte.NewPlayer(tr, gopyte.NewNativeScreen(20, 5))
+	player.SetMaxIdle(20 * time.Millisecond)
+
+	done := make(chan error)
+	go func() { done <- player.Play(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+	player.Pause()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	paused := player.Position()
+	if paused == player.Len() {
+		t.Skip("playback finished before pause")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if player.Position() != paused {
+		t.Errorf("playback continued after pause")
+	}
+
+	// Non-cloneable path: seeking back replays from the start
+	player.SeekEvent(2)
+	if got := player.Screen().(*gopyte.NativeScreen).GetDisplay()[0:3]; got[0] != "line 0" || got[1] != "line 1" || got[2] != "" {
+		t.Errorf("SeekEvent(2): got %q", got)
+	}
+}
+
+func TestCastPlayer(t *testing.T) {
+	cast := `{"version": 2, "width": 12, "height": 3, "idle_time_limit": 0.01}
+[0.5, "o", "first\r\n"]
+[100.0, "m", "chapter"]
+[100.5, "o", "second"]
+`
+	player, header, err := gopyte.NewCastPlayer(strings.NewReader(cast), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Width != 12 || player.Len() != 2 {
+		t.Fatalf("header %+v, %d events", header, player.Len())
+	}
+
+	// The idle limit shortens the 100 second pause
+	start := time.Now()
+	if err := player.Play(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("idle limit not applied, took %v", elapsed)
+	}
+	screen := player.Screen().(*gopyte.WideCharScreen)
+	if got := screen.GetDisplay(); strings.TrimSpace(got[0]) != "first" || strings.TrimSpace(got[1]) != "second" {
+		t.Errorf("display = %q", got)
+	}
+
+	// Finish replays the rest without waiting, calling the handler per event
+	player.SeekEvent(0)
+	var frames []string
+	player.SetEventHandler(func(ev gopyte.TranscriptEvent, s gopyte.Screen) {
+		frames = append(frames, strings.TrimSpace(s.(*gopyte.WideCharScreen).GetDisplay()[0]))
+	})
+	player.Finish()
+	if !reflect.DeepEqual(frames, []string{"first", "first"}) || player.Position() != 2 {
+		t.Errorf("frames = %q at position %d", frames, player.Position())
+	}
+
+	if _, _, err := gopyte.NewCastPlayer(strings.NewReader(`{"version": 2}`), nil); err == nil {
+		t.Error("no error creating a screen without a size")
+	}
+}
+
+func TestCastPlayerResize(t *testing.T) {
+	cast := `{"version": 2, "width": 12, "height": 3}
+[0.5, "o", "first"]
+[1.0, "r", "20x5"]
+[1.5, "o", "\r\nsecond"]
+`
+	player, _, err := gopyte.NewCastPlayer(strings.NewReader(cast), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.Len() != 3 {
+		t.Fatalf("got %d events, want 3 (resize kept)", player.Len())
+	}
+
+	player.Finish()
+	got := player.Screen().(*gopyte.WideCharScreen).GetDisplay()
+	if len(got) != 5 || len(got[0]) != 20 {
+		t.Fatalf("screen not resized: %q", got)
+	}
+	if strings.TrimSpace(got[1]) != "second" {
+		t.Errorf("display = %q", got)
+	}
+
+	// Seeking back to before the resize restores the recorded size
+	player.SeekEvent(1)
+	if got := player.Screen().(*gopyte.WideCharScreen).GetDisplay(); len(got) != 3 {
+		t.Errorf("after seeking back: %d lines, want 3", len(got))
+	}
+}
@@ -0,0 +1,59 @@
+package gopyte_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+// progressOutput draws a 30 column progress line repeatedly on a 20 column
+// screen, so each repaint wraps and scrolls.
+func progressOutput() string {
+	var sb strings.Builder
+	sb.WriteString("$ fetch\r\n")
+	for pct := 0; pct <= 100; pct += 10 {
+		fmt.Fprintf(&sb, "\r%-24s%3d%%  ", strings.Repeat("#", pct*24/100), pct)
+	}
+	sb.WriteString("\r\ndone\r\n\r\n\r\n\r\n")
+	return sb.String()
+}
+
+func historyText(screen *gopyte.HistoryScreen) []string {
+	var lines []string
+	screen.ScrollUp(screen.GetHistorySize())
+	for _, l := range screen.GetDisplay() {
+		lines = append(lines, strings.TrimRight(l, " "))
+	}
+	screen.ScrollToBottom()
+	return lines
+}
+
+func TestCollapseProgress(t *testing.T) {
+	plain := gopyte.NewHistoryScreen(20, 4, 100)
+	gopyte.NewStream(plain, false).Feed(progressOutput())
+
+	collapsed := gopyte.NewHistoryScreen(20, 4, 100)
+	collapsed.SetCollapseProgress(true)
+	gopyte.NewStream(collapsed, false).Feed(progressOutput())
+
+	if collapsed.GetHistorySize() >= plain.GetHistorySize() {
+		t.Fatalf("history not collapsed: %d lines, %d without collapsing",
+			collapsed.GetHistorySize(), plain.GetHistorySize())
+	}
+
+	// Only the command and the final repaint remain
+	want := []string{"$ fetch", strings.Repeat("#", 20), "####100%", "done"}
+	if got := historyText(collapsed); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("history: got %q, want %q", got, want)
+	}
+
+	// Ordinary wrapped lines are kept whole
+	wrapped := gopyte.NewHistoryScreen(10, 3, 100)
+	wrapped.SetCollapseProgress(true)
+	gopyte.NewStream(wrapped, false).Feed("x\rabcdefghijKLMNOPQRSTuvw\r\n\r\n\r\n\r\n")
+	if got := historyText(wrapped); got[0] != "abcdefghij" || got[1] != "KLMNOPQRST" || got[2] != "uvw" {
+		t.Errorf("wrapped line: got %q", got)
+	}
+}
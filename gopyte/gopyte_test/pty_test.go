@@ -0,0 +1,100 @@
+//go:build !windows
+// +build !windows
+
+package gopyte_test
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+	"github.com/scottpeterman/gopyte/gopyte/pty"
+)
+
+// runPTY starts script under sh on a 40x10 terminal.
+func runPTY(t *testing.T, script string) *pty.Session {
+	t.Helper()
+	screen := gopyte.NewWideCharScreen(40, 10, 100)
+	session, err := pty.Start(exec.Command("sh", "-c", script), screen, pty.Options{Columns: 40, Lines: 10})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+// waitPTY waits for the command to exit and returns the screen's lines,
+// trailing blanks trimmed.
+func waitPTY(t *testing.T, session *pty.Session) []string {
+	t.Helper()
+	select {
+	case <-session.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("command did not exit")
+	}
+	var lines []string
+	for _, line := range session.Screen().GetDisplay() {
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return lines
+}
+
+func TestPTYOutput(t *testing.T) {
+	session := runPTY(t, `printf 'hello \033[1mpty\033[m\n'; echo "$TERM"`)
+	lines := waitPTY(t, session)
+	if lines[0] != "hello pty" || lines[1] != pty.DefaultTerm {
+		t.Errorf("got %q", lines[:2])
+	}
+	if c := session.Screen().GetCell(0, 6); !c.Attrs.Bold {
+		t.Error("SGR not applied")
+	}
+	if err := session.Wait(); err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+}
+
+func TestPTYInputAndResize(t *testing.T) {
+	session := runPTY(t, `read line; echo "got $line"; stty size`)
+	if err := session.Resize(50, 12); err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	if _, err := session.WriteInput([]byte("abc\r")); err != nil {
+		t.Fatalf("WriteInput: %v", err)
+	}
+	lines := waitPTY(t, session)
+	text := strings.Join(lines, "\n")
+	if !strings.Contains(text, "got abc") || !strings.Contains(text, "12 50") {
+		t.Errorf("got %q", text)
+	}
+	if n := len(lines); n != 12 {
+		t.Errorf("screen has %d lines, want 12", n)
+	}
+}
+
+func TestPTYAnswersQueries(t *testing.T) {
+	// The cursor position report comes back as input
+	session := runPTY(t, `stty raw -echo; printf 'ab\033[6n'; r=$(dd bs=6 count=1 2>/dev/null); stty sane; printf '\n%s\n' "$r" | tr '\033' E`)
+	lines := waitPTY(t, session)
+	if !strings.Contains(strings.Join(lines, "\n"), "E[1;3R") {
+		t.Errorf("got %q", lines)
+	}
+}
+
+func TestPTYClose(t *testing.T) {
+	session := runPTY(t, `sleep 30`)
+	closed := make(chan struct{})
+	go func() {
+		session.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not kill the command")
+	}
+	if session.Wait() == nil {
+		t.Error("killed command exited without an error")
+	}
+}
@@ -0,0 +1,72 @@
+package gopyte_test
+
+import (
+	"bytes"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestRawTapClassification(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	stream := gopyte.NewStream(screen, false)
+
+	var got []gopyte.RawSequence
+	stream.SetRawTap(func(seq gopyte.RawSequence) {
+		seq.Raw = append([]byte(nil), seq.Raw...)
+		got = append(got, seq)
+	})
+
+	stream.Feed("hi\r\n\x1b[1;31m\x1b]2;title\x07\x1b7\x1b[?25l")
+
+	want := []struct {
+		kind gopyte.SequenceKind
+		name string
+		raw  string
+	}{
+		{gopyte.SeqText, "", "hi"},
+		{gopyte.SeqControl, "carriage_return", "\r"},
+		{gopyte.SeqControl, "linefeed", "\n"},
+		{gopyte.SeqCSI, "select_graphic_rendition", "\x1b[1;31m"},
+		{gopyte.SeqOSC, "2", "\x1b]2;title\x07"},
+		{gopyte.SeqEscape, "save_cursor", "\x1b7"},
+		{gopyte.SeqCSI, "reset_mode", "\x1b[?25l"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d sequences, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Kind != w.kind || got[i].Name != w.name || string(got[i].Raw) != w.raw {
+			t.Errorf("sequence %d: got {%v %q %q}, want {%v %q %q}",
+				i, got[i].Kind, got[i].Name, got[i].Raw, w.kind, w.name, w.raw)
+		}
+	}
+
+	// The screen state is still maintained
+	if screen.GetDisplay()[0] != "hi" {
+		t.Errorf("display: got %q", screen.GetDisplay()[0])
+	}
+}
+
+func TestRawTapIsLossless(t *testing.T) {
+	input := "ls\r\n\x1b[01;34mdir\x1b[0m  file\r\n\x1b]0;user@host: ~\x07\x1b(B\x1b#8\x1b[2 q\x00end"
+
+	// Split at every possible position to exercise sequences spanning Feed calls
+	for split := 0; split <= len(input); split++ {
+		screen := gopyte.NewNativeScreen(40, 10)
+		stream := gopyte.NewStream(screen, false)
+
+		var out bytes.Buffer
+		stream.SetRawTap(func(seq gopyte.RawSequence) {
+			out.Write(seq.Raw)
+		})
+
+		stream.Feed(input[:split])
+		stream.Feed(input[split:])
+
+		if out.String() != input {
+			t.Fatalf("split at %d: passthrough differs\n got: %q\nwant: %q", split, out.String(), input)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package gopyte_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestRecorderRawIsExact(t *testing.T) {
+	clock := gopyte.NewFakeClock(clockStart)
+	screen := gopyte.NewNativeScreen(20, 3)
+	rec := gopyte.NewRecorderWithClock(screen, clock)
+
+	// Invalid UTF-8, a split escape sequence and NULs must all survive
+	chunks := [][]byte{
+		[]byte("caf\xc3"),
+		[]byte("\xa9 \xff\xfe\x1b["),
+		[]byte("1mbold\x00\x1b[0m\r\n"),
+	}
+	var all []byte
+	for i, c := range chunks {
+		if i > 0 {
+			clock.Advance(time.Second)
+		}
+		if n, err := rec.Write(c); n != len(c) || err != nil {
+			t.Fatalf("Write = %d, %v", n, err)
+		}
+		all = append(all, c...)
+	}
+
+	if got := rec.DumpRaw(); !bytes.Equal(got, all) {
+		t.Errorf("DumpRaw = %q, want %q", got, all)
+	}
+	var buf bytes.Buffer
+	rec.WriteTo(&buf)
+	if !bytes.Equal(buf.Bytes(), all) || rec.Len() != int64(len(all)) {
+		t.Errorf("WriteTo = %q", buf.Bytes())
+	}
+	if rec.Digest() != sha256.Sum256(all) {
+		t.Error("digest does not match the capture")
+	}
+
+	frames := rec.Frames()
+	if len(frames) != 3 || frames[1].Offset != 4 || frames[1].Length != 6 || frames[2].Time != 2*time.Second {
+		t.Errorf("frames = %+v", frames)
+	}
+	if got := rec.FrameBytes(1); !bytes.Equal(got, chunks[1]) {
+		t.Errorf("FrameBytes(1) = %q", got)
+	}
+
+	rec.Do(func(s gopyte.Screen) {
+		if got := s.(*gopyte.NativeScreen).GetDisplay()[0]; !strings.Contains(got, "bold") {
+			t.Errorf("screen = %q", got)
+		}
+	})
+}
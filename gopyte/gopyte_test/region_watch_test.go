@@ -0,0 +1,56 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestWatchRegion(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 5, 100)
+	stream := gopyte.NewStream(screen, false)
+
+	status := gopyte.Region{Top: 4, Left: 0, Bottom: 4, Right: 9}
+	var fired [][]string
+	cancel := screen.WatchRegion(status, func(r gopyte.Region, rows []string) {
+		if r != status {
+			t.Errorf("watcher got region %+v", r)
+		}
+		fired = append(fired, rows)
+	})
+
+	// Output outside the region does not fire
+	stream.Feed("hello\r\nworld")
+	if len(fired) != 0 {
+		t.Fatalf("fired for changes outside the region: %q", fired)
+	}
+
+	// Several updates in one Feed fire once, with the final contents
+	stream.Feed("\x1b[5;1Hjob 1/3\x1b[5;1Hjob 2/3")
+	if len(fired) != 1 || !reflect.DeepEqual(fired[0], []string{"job 2/3   "}) {
+		t.Fatalf("got %q", fired)
+	}
+
+	// Rewriting identical content is not a change; attributes are
+	stream.Feed("\x1b[5;1Hjob 2/3")
+	if len(fired) != 1 {
+		t.Errorf("fired without a change")
+	}
+	stream.Feed("\x1b[5;1H\x1b[7mjob 2/3\x1b[0m")
+	if len(fired) != 2 {
+		t.Errorf("attribute change not detected")
+	}
+
+	// Columns past the right edge of the region are ignored
+	stream.Feed("\x1b[5;15Hxyz")
+	if len(fired) != 2 {
+		t.Errorf("fired for a change right of the region")
+	}
+
+	cancel()
+	stream.Feed("\x1b[5;1Hdone")
+	if len(fired) != 2 {
+		t.Errorf("fired after cancel")
+	}
+}
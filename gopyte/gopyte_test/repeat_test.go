@@ -0,0 +1,38 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestRepeatPrecedingCharacter(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"a\x1b[3b", "aaaa"},
+		{"ab\x1b[b", "abb"},
+		{"\x1b[5b", ""}, // Nothing drawn yet
+		{"é\x1b[2b", "ééé"},
+		{"[\x1b[1m=\x1b[4b]", "[=====]"},
+		{"\x1b(0q\x1b[3b\x1b(B", "────"},
+		{"x\x1bc\x1b[2b", ""}, // RIS forgets the character
+	}
+	for _, tt := range tests {
+		screen := gopyte.NewWideCharScreen(20, 2, 10)
+		gopyte.NewStream(screen, false).Feed(tt.input)
+		if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRepeatWraps(t *testing.T) {
+	screen := gopyte.NewNativeScreen(5, 3)
+	gopyte.NewStream(screen, false).Feed("-\x1b[6b")
+	if got := screen.GetDisplay()[:2]; got[0] != "-----" || got[1] != "--" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,84 @@
+package gopyte_test
+
+import (
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestResponseGuardAllowlist(t *testing.T) {
+	g := gopyte.NewResponseGuard(gopyte.ResponseGuardOptions{
+		Allow: []gopyte.ResponseKind{gopyte.ResponseCursorPosition},
+	})
+	if !g.Allow(gopyte.ResponseCursorPosition) {
+		t.Error("CPR should be allowed")
+	}
+	if g.Allow(gopyte.ResponseClipboard) {
+		t.Error("OSC 52 should be refused")
+	}
+	if g.Dropped() != 1 {
+		t.Errorf("dropped: got %d, want 1", g.Dropped())
+	}
+}
+
+func TestResponseGuardRateLimit(t *testing.T) {
+	clock := gopyte.NewFakeClock(time.Unix(0, 0))
+	g := gopyte.NewResponseGuard(gopyte.ResponseGuardOptions{
+		Allow: []gopyte.ResponseKind{gopyte.ResponseDeviceAttributes},
+		Rate:  3,
+		Per:   time.Second,
+		Clock: clock,
+	})
+
+	sent := 0
+	for i := 0; i < 10; i++ {
+		if g.Allow(gopyte.ResponseDeviceAttributes) {
+			sent++
+		}
+	}
+	if sent != 3 {
+		t.Errorf("burst: sent %d, want 3", sent)
+	}
+
+	clock.Advance(400 * time.Millisecond)
+	if !g.Allow(gopyte.ResponseDeviceAttributes) {
+		t.Error("a token should have refilled")
+	}
+	if g.Allow(gopyte.ResponseDeviceAttributes) {
+		t.Error("only one token should have refilled")
+	}
+
+	// Refill is capped at the burst size
+	clock.Advance(time.Hour)
+	sent = 0
+	for i := 0; i < 10; i++ {
+		if g.Allow(gopyte.ResponseDeviceAttributes) {
+			sent++
+		}
+	}
+	if sent != 3 {
+		t.Errorf("after idle: sent %d, want 3", sent)
+	}
+}
+
+func TestScreenRespondThroughGuard(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	tr := gopyte.NewTranscript()
+	screen.SetTranscript(tr)
+
+	if !screen.Respond(gopyte.ResponseOSCQuery, "\x1b]11;rgb:0/0/0\x07") {
+		t.Error("without a guard every response is sent")
+	}
+
+	screen.SetResponseGuard(gopyte.DefaultResponseGuard())
+	if screen.Respond(gopyte.ResponseOSCQuery, "\x1b]11;rgb:0/0/0\x07") {
+		t.Error("default guard should refuse OSC queries")
+	}
+	if !screen.Respond(gopyte.ResponseCursorPosition, "\x1b[1;1R") {
+		t.Error("default guard should allow CPR")
+	}
+	if got := len(tr.Events()); got != 2 {
+		t.Errorf("transcript events: got %d, want 2", got)
+	}
+}
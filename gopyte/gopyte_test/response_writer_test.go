@@ -0,0 +1,43 @@
+package gopyte_test
+
+import (
+	"bytes"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestResponseWriter(t *testing.T) {
+	screen := gopyte.NewAlternateScreen(80, 24, 10)
+	stream := gopyte.NewStream(screen, false)
+	var pty bytes.Buffer
+	screen.SetResponseWriter(&pty)
+
+	stream.Feed("\x1b[5;10H\x1b[6n\x1b[c")
+	if got := pty.String(); got != "\x1b[5;10R\x1b[?64;22c" {
+		t.Errorf("written %q", got)
+	}
+
+	// Refused responses are not written
+	pty.Reset()
+	screen.SetResponseGuard(gopyte.NewResponseGuard(gopyte.ResponseGuardOptions{
+		Allow: []gopyte.ResponseKind{gopyte.ResponseDeviceStatus},
+	}))
+	stream.Feed("\x1b[6n\x1b[5n")
+	if got := pty.String(); got != "\x1b[0n" {
+		t.Errorf("written through the guard %q", got)
+	}
+
+	// Clones don't answer the process
+	pty.Reset()
+	gopyte.NewStream(screen.Clone(), false).Feed("\x1b[5n")
+	if pty.Len() != 0 {
+		t.Errorf("clone wrote %q", pty.String())
+	}
+
+	screen.SetResponseWriter(nil)
+	stream.Feed("\x1b[5n")
+	if pty.Len() != 0 {
+		t.Errorf("written after removing the writer: %q", pty.String())
+	}
+}
@@ -0,0 +1,89 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestReverseIndexRestoresHistory(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(10, 3, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("one\r\ntwo\r\nthree\r\nfour") // "one" scrolls into history
+
+	if screen.GetHistorySize() != 1 {
+		t.Fatalf("history size: got %d, want 1", screen.GetHistorySize())
+	}
+	stream.Feed("\x1b[H\x1bM")
+
+	if got := screen.GetDisplay(); got[0] != "one" || got[1] != "two" || got[2] != "three" {
+		t.Errorf("display: got %q", got)
+	}
+	if screen.GetHistorySize() != 0 {
+		t.Errorf("history size after restore: got %d, want 0", screen.GetHistorySize())
+	}
+
+	// Nothing left to restore: a blank line comes in
+	stream.Feed("\x1bM")
+	if got := screen.GetDisplay(); got[0] != "" || got[1] != "one" {
+		t.Errorf("display: got %q", got)
+	}
+}
+
+func TestReverseIndexBlankPolicy(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 100)
+	screen.SetReverseScrollPolicy(gopyte.ReverseScrollBlank)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("one\r\ntwo\r\nthree\r\nfour\x1b[H\x1bM")
+
+	if got := screen.GetDisplay(); got[0] != "          " || got[1][:3] != "two" {
+		t.Errorf("display: got %q", got)
+	}
+	if screen.GetHistorySize() != 1 {
+		t.Errorf("history changed: size %d", screen.GetHistorySize())
+	}
+
+	// Away from the top, RI only moves the cursor
+	stream.Feed("\x1b[3;1H\x1bM")
+	if _, y := screen.GetCursor(); y != 1 {
+		t.Errorf("cursor row: got %d, want 1", y)
+	}
+}
+
+func TestReverseIndexCopiesHistoryMeta(t *testing.T) {
+	sev := gopyte.NewMetaKey[int]("severity")
+	screen := gopyte.NewHistoryScreen(10, 3, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("one")
+	sev.Set(screen.LineMeta(0), 1)
+	stream.Feed("\r\ntwo\r\nthree\r\nfour") // "one" scrolls into history
+
+	snap := screen.Clone()
+	stream.Feed("\x1b[H\x1bM")
+	sev.Set(screen.LineMeta(0), 99)
+	if v, _ := sev.Get(snap.HistoryMeta(0)); v != 1 {
+		t.Errorf("snapshot history metadata changed to %d", v)
+	}
+}
+
+func TestReverseIndexRestoresWideCharacters(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("中文ab\r\nx\r\ny\r\nz\x1b[H\x1bM")
+
+	if c := screen.GetCell(0, 0); c.Char != '中' || c.Width != 2 {
+		t.Errorf("wide cell: got %q, width %d", c.Char, c.Width)
+	}
+	if c := screen.GetCell(0, 1); c.Width != 0 {
+		t.Errorf("continuation cell: width %d", c.Width)
+	}
+	if got := screen.GetDisplayPadded()[0]; got != "中\x00文\x00ab    " {
+		t.Errorf("padded display: got %q", got)
+	}
+
+	// Writing over the continuation cell splits the character
+	stream.Feed("\x1b[1;2HZ")
+	if got := screen.GetDisplay()[0]; got != " Z文ab    " {
+		t.Errorf("display: got %q", got)
+	}
+}
@@ -0,0 +1,170 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+const ipIntBriefTemplate = `# show ip interface brief
+Value Required Interface (\S+)
+Value Address (\S+)
+Value Status (up|down|administratively down)
+Value Protocol (up|down)
+
+Start
+  ^Interface\s+IP-Address -> Table
+
+Table
+  ^${Interface}\s+${Address}\s+\w+\s+\w+\s+${Status}\s+${Protocol}\s*$$ -> Record
+`
+
+func TestTemplateParse(t *testing.T) {
+	tmpl, err := gopyte.ParseTemplate(ipIntBriefTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := tmpl.Parse([]string{
+		"Interface              IP-Address      OK? Method Status                Protocol",
+		"GigabitEthernet0/0     10.0.0.1        YES NVRAM  up                    up",
+		"GigabitEthernet0/1     unassigned      YES unset  administratively down down",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gopyte.Record{
+		{"Interface": "GigabitEthernet0/0", "Address": "10.0.0.1", "Status": "up", "Protocol": "up"},
+		{"Interface": "GigabitEthernet0/1", "Address": "unassigned", "Status": "administratively down", "Protocol": "down"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %v\nwant %v", records, want)
+	}
+}
+
+func TestTemplateFilldownAndErrors(t *testing.T) {
+	tmpl := gopyte.MustParseTemplate(`Value Filldown Vrf (\S+)
+Value Required Prefix (\d+\.\d+\.\d+\.\d+/\d+)
+
+Start
+  ^VRF ${Vrf}
+  ^\s+${Prefix} -> Record
+  ^% -> Error
+`)
+	records, err := tmpl.Parse([]string{"VRF red", "  10.0.0.0/8", "  10.1.0.0/16", "VRF blue", "  192.168.0.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[1]["Vrf"] != "red" || records[2]["Vrf"] != "blue" {
+		t.Errorf("got %v", records)
+	}
+
+	if _, err := tmpl.Parse([]string{"% Invalid input detected"}); err == nil {
+		t.Error("expected error from Error state")
+	}
+
+	for _, bad := range []string{
+		"Value X (a)\n\nOther\n  ^x\n",            // no Start
+		"Value X (a)\n\nStart\n  ^${Y}\n",         // unknown value
+		"Value X (a)\n\nStart\n  ^x -> Nowhere\n", // unknown state
+	} {
+		if _, err := gopyte.ParseTemplate(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestTemplateEndState(t *testing.T) {
+	tmpl := gopyte.MustParseTemplate(`Value Interface (\S+)
+Value Description (\S+)
+
+Start
+  ^interface ${Interface}
+  ^ description ${Description} -> Record
+  ^end -> End
+`)
+	// Reaching End drops the values not yet recorded
+	records, err := tmpl.Parse([]string{"interface Gi0/0", " description uplink", "interface Gi0/1", "end", "interface Gi0/2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gopyte.Record{{"Interface": "Gi0/0", "Description": "uplink"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %v\nwant %v", records, want)
+	}
+}
+
+func TestScraper(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(80, 10, 100)
+	gopyte.NewStream(screen, false).Feed("router#show version\r\n" +
+		"Cisco IOS Software\r\n" +
+		"router#show ip interface brief\r\n" +
+		"Interface              IP-Address      OK? Method Status                Protocol\r\n" +
+		"Loopback0              1.1.1.1         YES NVRAM  up                    up\r\n" +
+		"router#")
+
+	scraper := gopyte.NewScraper(regexp.MustCompile(`^[\w.-]+[>#]`))
+	scraper.Add(regexp.MustCompile(`^sh(ow)?\s+ip\s+int(erface)?\s+br`),
+		gopyte.MustParseTemplate(ipIntBriefTemplate))
+
+	results, err := scraper.Scrape(screen.GetDisplay())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Command != "show ip interface brief" {
+		t.Fatalf("got %+v", results)
+	}
+	if r := results[0].Records; len(r) != 1 || r[0]["Address"] != "1.1.1.1" {
+		t.Errorf("records: %v", r)
+	}
+
+	cmds := gopyte.SplitCommands(screen.GetDisplay(), regexp.MustCompile(`^[\w.-]+[>#]`))
+	if len(cmds) != 3 || cmds[0].Command != "show version" || len(cmds[0].Output) != 1 || cmds[2].Command != "" {
+		t.Errorf("SplitCommands: got %+v", cmds)
+	}
+}
+
+func TestCleanOutput(t *testing.T) {
+	prompt := regexp.MustCompile(`^[\w.-]+[>#]`)
+
+	lines := []string{
+		"",
+		"core1# show ip interface brief",
+		"Interface   IP-Address",
+		"Gi0/1       10.0.0.1",
+		"",
+		"core1#",
+		"",
+	}
+	got := gopyte.CleanOutput(lines, "show ip interface brief", prompt)
+	want := []string{"Interface   IP-Address", "Gi0/1       10.0.0.1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// An echo wrapped by the terminal, split at a space
+	lines = []string{
+		"core1# show running-config",
+		"interface Gi0/1",
+		"hostname core1",
+		"core1# ",
+	}
+	got = gopyte.CleanOutput(lines, "show running-config interface Gi0/1", prompt)
+	if !reflect.DeepEqual(got, []string{"hostname core1"}) {
+		t.Errorf("wrapped echo: got %q", got)
+	}
+
+	// Output that merely starts like the command is kept
+	lines = []string{"show", "more"}
+	got = gopyte.StripEcho(lines, "show version", prompt)
+	if !reflect.DeepEqual(got, lines) {
+		t.Errorf("partial echo: got %q", got)
+	}
+
+	// No prompt configured: only the echo and blank lines go
+	got = gopyte.CleanOutput([]string{"uptime", " 10:00 up 5 days", ""}, "uptime", nil)
+	if !reflect.DeepEqual(got, []string{" 10:00 up 5 days"}) {
+		t.Errorf("no prompt: got %q", got)
+	}
+}
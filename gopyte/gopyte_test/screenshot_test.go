@@ -0,0 +1,62 @@
+package gopyte_test
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+	"github.com/scottpeterman/gopyte/gopyte/screenshot"
+)
+
+func TestScreenshot(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(4, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("T\x1b[41m中\x1b[m\r\n─")
+
+	img := screenshot.Render(screen, screenshot.Options{Scale: 1})
+	if b := img.Bounds(); b.Dx() != 24 || b.Dy() != 22 {
+		t.Fatalf("size = %v, want 24x22", b.Size())
+	}
+	fg := color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+	bg := color.RGBA{0, 0, 0, 0xff}
+	red := color.RGBA{0xcd, 0, 0, 0xff}
+	for _, p := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{0, 0, bg},   // Above the glyph
+		{0, 1, fg},   // Top bar of the T
+		{2, 5, fg},   // Stem of the T
+		{0, 5, bg},   // Beside it
+		{6, 0, red},  // Background of the wide character, first column
+		{17, 0, red}, // and second
+		{18, 0, bg},
+		{0, 16, fg}, // Horizontal line through the middle of the next row
+		{5, 16, fg},
+		{0, 15, bg},
+	} {
+		if got := img.RGBAAt(p.x, p.y); got != p.want {
+			t.Errorf("pixel %d,%d = %v, want %v", p.x, p.y, got, p.want)
+		}
+	}
+
+	// The cursor block is drawn in the cursor color
+	cursor := screenshot.Render(screen, screenshot.Options{Scale: 1, Cursor: true})
+	if got := cursor.RGBAAt(6, 11); got != fg {
+		t.Errorf("cursor pixel = %v, want %v", got, fg)
+	}
+
+	var buf bytes.Buffer
+	if err := screenshot.WritePNG(&buf, screen, screenshot.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := decoded.Bounds(); b.Dx() != 48 || b.Dy() != 44 {
+		t.Errorf("PNG size = %v, want 48x44 at the default scale", b.Size())
+	}
+}
@@ -0,0 +1,73 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestScrollUpDown(t *testing.T) {
+	screen := gopyte.NewNativeScreen(5, 4)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("a\r\nb\r\nc\r\nd\x1b[2;3H")
+
+	stream.Feed("\x1b[2S")
+	if got := strings.Join(screen.GetDisplay(), ","); got != "c,d,," {
+		t.Errorf("after SU 2: %q", got)
+	}
+	if x, y := screen.GetCursor(); x != 2 || y != 1 {
+		t.Errorf("SU moved the cursor to %d,%d", x, y)
+	}
+
+	stream.Feed("\x1b[T")
+	if got := strings.Join(screen.GetDisplay(), ","); got != ",c,d," {
+		t.Errorf("after SD: %q", got)
+	}
+	if x, y := screen.GetCursor(); x != 2 || y != 1 {
+		t.Errorf("SD moved the cursor to %d,%d", x, y)
+	}
+
+	// Not scrolling: XTSMGRAPHICS and highlight mouse tracking
+	stream.Feed("\x1b[?1;1S\x1b[1;2;3;4;5T")
+	if got := strings.Join(screen.GetDisplay(), ","); got != ",c,d," {
+		t.Errorf("after non-scrolling sequences: %q", got)
+	}
+}
+
+func TestScrollUpSavesHistory(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(5, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("a\r\nb\r\nc\x1b[2S")
+	if got := screen.GetHistorySize(); got != 2 {
+		t.Errorf("history size = %d, want 2", got)
+	}
+
+	// The alternate screen has no scrollback to save to
+	alt := gopyte.NewAlternateScreen(5, 3, 10)
+	stream = gopyte.NewStream(alt, false)
+	stream.Feed("\x1b[?1049ha\r\nb\x1b[S")
+	if got := strings.Join(alt.GetDisplay(), ","); got != "b,," {
+		t.Errorf("alternate screen: %q", got)
+	}
+	if got := alt.GetHistorySize(); got != 0 {
+		t.Errorf("alternate screen history size = %d", got)
+	}
+}
+
+func TestScrollWideCharWidths(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(6, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("中a\r\nb\x1b[S")
+
+	c := gopyte.NewCompositor(8, 5)
+	c.Add("a", screen, nil)
+	c.Layout()
+	if got := c.Display()[1]; got != "│b     │" {
+		t.Errorf("row 0 = %q", got)
+	}
+	stream.Feed("\x1b[T")
+	if got := c.Display()[2]; got != "│b     │" {
+		t.Errorf("row 1 = %q", got)
+	}
+}
@@ -0,0 +1,95 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestSelectionLinear(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 4)
+	gopyte.NewStream(screen, false).Feed("first\r\nsecond\r\nthird")
+
+	screen.StartSelection(2, 0)
+	screen.ExtendSelection(3, 2)
+	if got, want := screen.SelectionText(), "rst\nsecond\nthir"; got != want {
+		t.Errorf("text: got %q, want %q", got, want)
+	}
+	want := []gopyte.Region{
+		{Top: 0, Bottom: 0, Left: 2, Right: 9},
+		{Top: 1, Bottom: 1, Left: 0, Right: 9},
+		{Top: 2, Bottom: 2, Left: 0, Right: 3},
+	}
+	if got := screen.SelectionSpans(); !reflect.DeepEqual(got, want) {
+		t.Errorf("spans: got %+v, want %+v", got, want)
+	}
+
+	// Dragging backwards selects the same text
+	screen.StartSelection(3, 2)
+	screen.ExtendSelection(2, 0)
+	if got := screen.SelectionText(); got != "rst\nsecond\nthir" {
+		t.Errorf("backwards: got %q", got)
+	}
+	if sel, ok := screen.GetSelection(); !ok || sel.StartY != 2 || sel.EndY != 0 {
+		t.Errorf("selection: got %+v, %v", sel, ok)
+	}
+
+	screen.ClearSelection()
+	if screen.SelectionText() != "" || screen.SelectionSpans() != nil {
+		t.Error("cleared selection still selects")
+	}
+}
+
+func TestSelectionJoinsSoftWraps(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 3)
+	gopyte.NewStream(screen, false).Feed("a long line wraps\r\nnext")
+
+	screen.StartSelection(0, 0)
+	screen.ExtendSelection(9, 2)
+	if got, want := screen.SelectionText(), "a long line wraps\nnext"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSelectionBlock(t *testing.T) {
+	screen := gopyte.NewNativeScreen(12, 3)
+	gopyte.NewStream(screen, false).Feed("a1 b1   c1\r\na2 b2\r\na3 b3   c3")
+
+	screen.StartSelection(3, 0)
+	screen.SetSelectionMode(gopyte.SelectBlock)
+	screen.ExtendSelection(9, 2)
+	if got, want := screen.SelectionText(), "b1   c1\nb2\nb3   c3"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if spans := screen.SelectionSpans(); len(spans) != 3 || spans[1].Left != 3 || spans[1].Right != 9 {
+		t.Errorf("spans: got %+v", spans)
+	}
+}
+
+func TestSelectionWideCharacters(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 2, 10)
+	gopyte.NewStream(screen, false).Feed("ab中文cd")
+
+	// Either half of a wide character selects it whole
+	screen.StartSelection(3, 0)  // Right half of 中
+	screen.ExtendSelection(4, 0) // Left half of 文
+	if got := screen.SelectionText(); got != "中文" {
+		t.Errorf("text: got %q", got)
+	}
+	if spans := screen.SelectionSpans(); len(spans) != 1 || spans[0].Left != 2 || spans[0].Right != 5 {
+		t.Errorf("spans: got %+v", spans)
+	}
+}
+
+func TestSelectionClearedOnResize(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(10, 3, 10)
+	screen.StartSelection(20, -1) // Clamped
+	if sel, ok := screen.GetSelection(); !ok || sel.StartX != 9 || sel.StartY != 0 {
+		t.Errorf("clamped: got %+v", sel)
+	}
+	screen.Resize(8, 3)
+	if _, ok := screen.GetSelection(); ok {
+		t.Error("selection survived a resize")
+	}
+}
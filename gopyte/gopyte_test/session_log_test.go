@@ -0,0 +1,144 @@
+package gopyte_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+type closeBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closeBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestSessionLog(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(10, 3, 100)
+	stream := gopyte.NewStream(screen, false)
+
+	raw, text := &closeBuffer{}, &closeBuffer{}
+	log := gopyte.NewSessionLog(raw, text)
+	log.Attach(stream, screen)
+
+	input := "one\r\n\x1b[1mtwo\x1b[0m\r\nthree\r\nwrapped line!\r\nfive"
+	stream.Feed(input)
+
+	if raw.String() != input {
+		t.Errorf("raw log: got %q", raw.String())
+	}
+	// The first half of the wrapped line has scrolled off too, but is held
+	// back until its continuation arrives
+	if text.String() != "one\ntwo\nthree\n" {
+		t.Errorf("text log before close: got %q", text.String())
+	}
+
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "one\ntwo\nthree\nwrapped line!\nfive\n"; text.String() != want {
+		t.Errorf("text log: got %q, want %q", text.String(), want)
+	}
+	if !raw.closed || !text.closed {
+		t.Error("writers not closed")
+	}
+}
+
+func TestSessionLogWrapAtSpace(t *testing.T) {
+	for _, screen := range []gopyte.Screen{
+		gopyte.NewHistoryScreen(6, 2, 100),
+		gopyte.NewWideCharScreen(6, 2, 100),
+	} {
+		stream := gopyte.NewStream(screen, false)
+		text := &closeBuffer{}
+		log := gopyte.NewSessionLog(nil, text)
+		log.Attach(stream, screen)
+
+		// The space ending the first row is kept, both for rows that
+		// scroll off and for those still on screen at Close
+		stream.Feed("hello world\r\nbye now\r\n\r\nhello world")
+		if err := log.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if want := "hello world\nbye now\n\nhello world\n"; text.String() != want {
+			t.Errorf("%T: got %q, want %q", screen, text.String(), want)
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "console.log")
+	f, err := gopyte.OpenRotatingFile(path, gopyte.RotateOptions{MaxSize: 10, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if _, err := f.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "dddddd\n" {
+		t.Errorf("current file: got %q", current)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "console-*.log.gz"))
+	if len(backups) != 2 {
+		t.Fatalf("got backups %v, want 2", backups)
+	}
+	var contents []string
+	for _, b := range backups {
+		fh, err := os.Open(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(zr)
+		fh.Close()
+		contents = append(contents, string(data))
+	}
+	got := strings.Join(contents, "")
+	if !strings.Contains(got, "bbbbbb") || !strings.Contains(got, "cccccc") || strings.Contains(got, "aaaaaa") {
+		t.Errorf("backups hold %q; the oldest should have been pruned", contents)
+	}
+}
+
+func TestRotatingFileRotateFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "console.log")
+	f, err := gopyte.OpenRotatingFile(path, gopyte.RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// With the live file gone the rename fails, but the log carries on
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Rotate(); err == nil {
+		t.Error("no error rotating a removed file")
+	}
+	if _, err := f.Write([]byte("still logging\n")); err != nil {
+		t.Fatalf("write after a failed rotation: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "still logging\n" {
+		t.Errorf("current file: got %q", data)
+	}
+}
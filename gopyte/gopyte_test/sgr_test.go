@@ -0,0 +1,101 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+// drawnAttrs feeds input followed by a character to a fresh screen and
+// returns the attributes the character was drawn with.
+func drawnAttrs(input string) gopyte.Attributes {
+	screen := gopyte.NewWideCharScreen(4, 1, 0)
+	gopyte.NewStream(screen, false).Feed(input + "X")
+	c := gopyte.NewCompositor(6, 3)
+	c.Add("", screen, nil)
+	c.Layout()
+	return c.Cells()[1][1].Attrs
+}
+
+func TestTruecolorSGR(t *testing.T) {
+	cases := []struct {
+		input  string
+		fg, bg string
+	}{
+		{"\x1b[38;2;255;128;0m", "#ff8000", "default"},
+		{"\x1b[48;2;1;2;3m", "default", "#010203"},
+		{"\x1b[38:2::255:128:0m", "#ff8000", "default"},
+		{"\x1b[38:2:0:10:20:30;48:2:40:50:60m", "#0a141e", "#28323c"},
+		{"\x1b[38:5:196;48;5;21m", "color196", "color21"},
+		{"\x1b[31;38;2;300;0;0m", "red", "default"},
+	}
+	for _, c := range cases {
+		a := drawnAttrs(c.input)
+		if a.Fg.String() != c.fg || a.Bg.String() != c.bg {
+			t.Errorf("%q: fg %q bg %q, want %q %q", c.input, a.Fg, a.Bg, c.fg, c.bg)
+		}
+	}
+
+	// The color arguments are not read as attributes: 1 would be bold
+	if a := drawnAttrs("\x1b[38;2;1;3;4m"); a.Bold || a.Italics || a.Underscore || a.Fg.String() != "#010304" {
+		t.Errorf("arguments leaked: %+v", a)
+	}
+	// Nor does an unknown colon group spill into the next parameter
+	if a := drawnAttrs("\x1b[38:9:1;1m"); !a.Bold || !a.Fg.IsDefault() {
+		t.Errorf("unknown color form: %+v", a)
+	}
+}
+
+func TestUnderlineStyles(t *testing.T) {
+	cases := []struct {
+		input string
+		style gopyte.UnderlineStyle
+	}{
+		{"\x1b[4m", gopyte.UnderlineSingle},
+		{"\x1b[4:2m", gopyte.UnderlineDouble},
+		{"\x1b[21m", gopyte.UnderlineDouble},
+		{"\x1b[4:3m", gopyte.UnderlineCurly},
+		{"\x1b[4:4m", gopyte.UnderlineDotted},
+		{"\x1b[4:5m", gopyte.UnderlineDashed},
+		{"\x1b[4:3;4:0m", gopyte.UnderlineNone},
+		{"\x1b[4:3;24m", gopyte.UnderlineNone},
+		{"\x1b[4:3;4:9m", gopyte.UnderlineCurly},
+	}
+	for _, c := range cases {
+		a := drawnAttrs(c.input)
+		if a.UnderlineStyle != c.style || a.Underscore != (c.style != gopyte.UnderlineNone) {
+			t.Errorf("%q: style %v, underscore %v; want %v", c.input, a.UnderlineStyle, a.Underscore, c.style)
+		}
+	}
+
+	// 4;3 is underline and italics, not a curly underline
+	if a := drawnAttrs("\x1b[4;3m"); a.UnderlineStyle != gopyte.UnderlineSingle || !a.Italics {
+		t.Errorf("4;3: %+v", a)
+	}
+}
+
+func TestUnderlineColor(t *testing.T) {
+	if a := drawnAttrs("\x1b[4:3;58:2::255:0:0m"); a.UnderlineColor.String() != "#ff0000" || a.Fg.String() != "default" {
+		t.Errorf("58:2: %+v", a)
+	}
+	if a := drawnAttrs("\x1b[58;5;33m"); a.UnderlineColor != gopyte.IndexedColor(33) {
+		t.Errorf("58;5: %+v", a)
+	}
+	if a := drawnAttrs("\x1b[58;5;33;59m"); !a.UnderlineColor.IsDefault() {
+		t.Errorf("59: %+v", a)
+	}
+	if a := drawnAttrs("\x1b[58;5;33;0m"); !a.UnderlineColor.IsDefault() {
+		t.Errorf("reset: %+v", a)
+	}
+}
+
+func TestSGRFlattenedForPlainScreens(t *testing.T) {
+	screen := gopyte.NewMockScreen()
+	gopyte.NewStream(screen, false).Feed("\x1b[1;38:2::10:20:30;48:5:7;4:3;58:5:1;4:0m")
+
+	want := "SelectGraphicRendition[[1 38 2 10 20 30 48 5 7 4 58 5 1 24]]"
+	if got := strings.Join(screen.Calls, " "); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
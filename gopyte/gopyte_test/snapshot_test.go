@@ -0,0 +1,153 @@
+package gopyte_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 4, 100)
+	screen.SetClock(gopyte.NewFakeClock(time.Unix(1700000000, 0)))
+	stream := gopyte.NewStream(screen, false)
+	for i := 0; i < 6; i++ {
+		stream.Feed("line\r\n")
+	}
+	stream.Feed("\x1b[1;31mred\x1b[0m 世界 \x1b]2;session\x07\x1b]10;#102030\x07")
+	stream.Feed("\x1b[3g\x1b[5G\x1bH\x1b[?2004h\x1b[5 q\x1b7\x1b[2;3H")
+	stream.Bookmark("start", "")
+
+	data, err := json.Marshal(screen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := gopyte.NewWideCharScreen(80, 24, 100)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if !screen.Equal(restored) {
+		t.Fatalf("restored screen differs:\n%s", screen.DiffString(restored))
+	}
+
+	// The restored screen carries on as the original would
+	for _, s := range []*gopyte.WideCharScreen{screen, restored} {
+		gopyte.NewStream(s, false).Feed("\x1b8\tX\x1b[?1049h")
+	}
+	if !screen.Equal(restored) {
+		t.Fatalf("screens diverged:\n%s", screen.DiffString(restored))
+	}
+}
+
+var severityKey = gopyte.NewMetaKey[int]("test.severity")
+
+func init() {
+	gopyte.RegisterMetaKey(severityKey)
+}
+
+func TestSnapshotMeta(t *testing.T) {
+	unregistered := gopyte.NewMetaKey[string]("test.unregistered")
+	screen := gopyte.NewWideCharScreen(20, 3, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("log\r\n\x1b]8;id=1;http://example.com\x07link\x1b]8;;\x07 text")
+	severityKey.Set(screen.LineMeta(1), 3)
+	severityKey.SetCell(screen.LineMeta(1), 6, 2)
+	unregistered.Set(screen.LineMeta(1), "dropped")
+
+	restore := func() *gopyte.WideCharScreen {
+		data, err := json.Marshal(screen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored := gopyte.NewWideCharScreen(20, 3, 100)
+		if err := json.Unmarshal(data, restored); err != nil {
+			t.Fatal(err)
+		}
+		return restored
+	}
+	check := func(where string, meta *gopyte.LineMeta) {
+		if v, ok := severityKey.Get(meta); !ok || v != 3 {
+			t.Errorf("%s: line value %d, %v", where, v, ok)
+		}
+		if v, ok := severityKey.GetCell(meta, 6); !ok || v != 2 {
+			t.Errorf("%s: cell value %d, %v", where, v, ok)
+		}
+		if _, ok := unregistered.Get(meta); ok {
+			t.Errorf("%s: unregistered key restored", where)
+		}
+	}
+
+	restored := restore()
+	if link, ok := restored.CellHyperlink(1, 0); !ok || link.URI != "http://example.com" || link.ID != "1" {
+		t.Errorf("link: got %+v, %v", link, ok)
+	}
+	if _, ok := restored.CellHyperlink(1, 4); ok {
+		t.Error("link past its text")
+	}
+	check("screen", restored.LineMeta(1))
+
+	prev := screen.Snapshot()
+	stream.Feed("\r\n\r\n\r\n") // Into the scrollback
+	check("scrollback", restore().HistoryMeta(1))
+
+	// Patches carry the metadata too
+	next := screen.Snapshot()
+	if err := prev.Apply(gopyte.Diff(prev, next)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(prev, next) {
+		t.Errorf("patched snapshot: got %+v, want %+v", prev.Scrollback, next.Scrollback)
+	}
+	severityKey.SetCell(screen.LineMeta(0), 1, 5)
+	next2 := screen.Snapshot()
+	if err := next.Apply(gopyte.Diff(next, next2)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(next, next2) {
+		t.Errorf("patched cell value: got %+v, want %+v", next.Screen.Lines[0], next2.Screen.Lines[0])
+	}
+}
+
+func TestSnapshotAlternateScreen(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("a\r\nb\r\nc\r\nshell$ ")
+	stream.Feed("\x1b[?1049h\x1b[2;2Hvim 文")
+
+	data, err := json.Marshal(screen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := gopyte.NewWideCharScreen(10, 3, 100)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if !screen.Equal(restored) {
+		t.Fatalf("restored screen differs:\n%s", screen.DiffString(restored))
+	}
+
+	// Leaving the alternate screen brings back the main one
+	gopyte.NewStream(restored, false).Feed("\x1b[?1049l")
+	if got := strings.TrimSpace(restored.GetDisplay()[2]); got != "shell$" {
+		t.Errorf("main screen line 2 = %q, want %q", got, "shell$")
+	}
+	if got := restored.GetHistorySize(); got != 1 {
+		t.Errorf("history size = %d, want 1", got)
+	}
+}
+
+func TestSnapshotRestoreErrors(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 3)
+	for _, data := range []string{
+		`{"version":99,"columns":10,"lines":3}`,
+		`{"version":1,"columns":0,"lines":3}`,
+		`not json`,
+	} {
+		if err := json.Unmarshal([]byte(data), screen); err == nil {
+			t.Errorf("%s: no error", data)
+		}
+	}
+}
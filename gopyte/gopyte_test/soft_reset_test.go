@@ -0,0 +1,45 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestSoftReset(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	stream.Feed("one\r\ntwo\x1b[1;31m\x1b[?25l\x1b[?7l\x1b(0\x1b7\x1b[!p")
+
+	// Contents and cursor position stay
+	if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != "one" {
+		t.Errorf("line 0 = %q", got)
+	}
+	if x, y := screen.GetCursor(); x != 3 || y != 1 {
+		t.Errorf("cursor = %d,%d, want 3,1", x, y)
+	}
+
+	// Modes, attributes and character sets are back to their defaults
+	cursor := screen.GetCursorObject()
+	if cursor.Hidden {
+		t.Error("cursor still hidden")
+	}
+	if cursor.Attrs != gopyte.DefaultAttributes() {
+		t.Errorf("attrs = %+v", cursor.Attrs)
+	}
+	stream.Feed("q\x1b[10Gab")
+	if got := strings.TrimRight(screen.GetDisplay()[1], " "); got != "twoq     a" {
+		t.Errorf("line 1 = %q, want q drawn as ASCII", got)
+	}
+	if got := strings.TrimRight(screen.GetDisplay()[2], " "); got != "b" {
+		t.Errorf("line 2 = %q, want autowrap back on", got)
+	}
+
+	// The saved cursor is gone
+	stream.Feed("\x1b8")
+	if x, y := screen.GetCursor(); x != 1 || y != 2 {
+		t.Errorf("DECRC after DECSTR moved the cursor to %d,%d", x, y)
+	}
+}
@@ -0,0 +1,32 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestC1StringsNotDrawn(t *testing.T) {
+	screen := gopyte.NewNativeScreen(40, 3)
+	stream := gopyte.NewStream(screen, false)
+
+	// Outside UTF-8 mode C1 introducers still end a run of text
+	stream.Feed("\x1b%@ab\x9d2;T\x07cd")
+	if got := screen.Title(); got != "T" {
+		t.Errorf("title = %q, want %q", got, "T")
+	}
+	stream.Feed("\x98sos\x9ce\x9epm\x9cf\x9fapc\x9cg")
+	stream.Feed("\x1bXsos\x1b\\h\x1b^pm\x1b\\i\x1b_ap")
+	stream.Feed("c\x1b\\j")
+	if line := screen.GetDisplay()[0]; line != "abcdefghij" {
+		t.Errorf("display = %q", line)
+	}
+
+	// In UTF-8 mode 0x9c inside a rune does not end the string
+	screen = gopyte.NewNativeScreen(40, 3)
+	stream = gopyte.NewStream(screen, false)
+	stream.Feed("a\x1b_伜\x1b\\b\x1b^x\x18c")
+	if line := screen.GetDisplay()[0]; line != "abc" {
+		t.Errorf("display = %q", line)
+	}
+}
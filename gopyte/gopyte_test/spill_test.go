@@ -0,0 +1,169 @@
+package gopyte_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+// feedNumbered feeds lines "line 0" to "line n-1", in 8 rotating colors.
+func feedNumbered(stream *gopyte.Stream, from, n int) {
+	for i := from; i < from+n; i++ {
+		stream.Feed(fmt.Sprintf("\x1b[3%dmline %d\x1b[m\r\n", i%8, i))
+	}
+}
+
+func trimmedLine(chars []rune) string {
+	return strings.TrimRight(string(chars), " ")
+}
+
+func TestSpillScrollback(t *testing.T) {
+	dir := t.TempDir()
+	screen := gopyte.NewHistoryScreen(20, 3, 10)
+	if err := screen.SpillScrollback(dir); err != nil {
+		t.Fatal(err)
+	}
+	stream := gopyte.NewStream(screen, false)
+	feedNumbered(stream, 0, 1000)
+
+	// Every line that scrolled off is still there, most of it on disk
+	n := screen.HistoryLen()
+	if n != 998 || screen.GetHistorySize() != n {
+		t.Fatalf("history length: got %d (size %d), want 998", n, screen.GetHistorySize())
+	}
+	if got := screen.SpilledLines(); got != n-10 {
+		t.Errorf("spilled: got %d, want %d", got, n-10)
+	}
+	for _, i := range []int{0, 500, 987, 988, n - 1} {
+		chars, attrs := screen.GetHistoryLine(i)
+		if got, want := trimmedLine(chars), fmt.Sprintf("line %d", i); got != want {
+			t.Errorf("line %d: got %q", i, got)
+		}
+		if len(chars) != 20 || len(attrs) != 20 || attrs[0].Fg != gopyte.NamedColor(i%8) {
+			t.Errorf("line %d: %d chars, attrs %+v", i, len(chars), attrs[:1])
+		}
+	}
+	i := 0
+	for chars, attrs := range screen.HistoryLines(0) {
+		if got := trimmedLine(chars); got != fmt.Sprintf("line %d", i) || attrs[0].Fg != gopyte.NamedColor(i%8) {
+			t.Fatalf("iterated line %d: got %q", i, got)
+		}
+		i++
+	}
+	if i != n {
+		t.Errorf("iterated %d lines, want %d", i, n)
+	}
+
+	// Scrolling back reaches the spilled lines
+	screen.ScrollUp(n)
+	if got := screen.GetDisplay()[0]; got != "line 0" {
+		t.Errorf("scrolled to top: got %q", got)
+	}
+	screen.ScrollToBottom()
+	if dump := screen.DumpANSI(gopyte.ANSIOptions{Scrollback: true}); !strings.Contains(dump, "line 0\x1b") {
+		t.Errorf("dump is missing the spilled lines")
+	}
+	if err := screen.SpillErr(); err != nil {
+		t.Errorf("spill error: %v", err)
+	}
+
+	if err := screen.CloseSpill(); err != nil {
+		t.Fatal(err)
+	}
+	if screen.SpilledLines() != 0 || screen.HistoryLen() != 10 {
+		t.Errorf("after close: %d spilled, %d lines", screen.SpilledLines(), screen.HistoryLen())
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("spill file left behind: %v", files)
+	}
+}
+
+func TestSpillScrollbackClear(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(20, 3, 10)
+	if err := screen.SpillScrollback(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.CloseSpill()
+	stream := gopyte.NewStream(screen, false)
+	feedNumbered(stream, 0, 100)
+	stream.Feed("\x1b[3J")
+	if n := screen.HistoryLen(); n != 0 {
+		t.Fatalf("history after ED 3: %d lines", n)
+	}
+
+	// Spilling carries on afterwards
+	feedNumbered(stream, 100, 50)
+	if chars, _ := screen.GetHistoryLine(screen.HistoryLen() - 1); trimmedLine(chars) != "line 147" {
+		t.Errorf("last line: got %q", trimmedLine(chars))
+	}
+	if screen.SpilledLines() == 0 {
+		t.Error("nothing spilled after clearing")
+	}
+}
+
+func TestSpillScrollbackAlternateScreen(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 3, 10)
+	if err := screen.SpillScrollback(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.CloseSpill()
+	stream := gopyte.NewStream(screen, false)
+	feedNumbered(stream, 0, 100)
+	spilled := screen.SpilledLines()
+
+	// A full-screen app neither spills nor hides the main scrollback
+	stream.Feed("\x1b[?1049h")
+	feedNumbered(stream, 1000, 100)
+	if got := screen.SpilledLines(); got != spilled {
+		t.Errorf("spilled on the alternate screen: %d, want %d", got, spilled)
+	}
+	if chars, _ := screen.GetHistoryLine(0); trimmedLine(chars) != "line 0" {
+		t.Errorf("line 0 on the alternate screen: got %q", trimmedLine(chars))
+	}
+
+	stream.Feed("\x1b[?1049l")
+	feedNumbered(stream, 100, 20)
+	if got := screen.SpilledLines(); got != spilled+20 {
+		t.Errorf("spilled after returning: %d, want %d", got, spilled+20)
+	}
+	if chars, _ := screen.GetHistoryLine(100); trimmedLine(chars) != "line 100" {
+		t.Errorf("line 100: got %q", trimmedLine(chars))
+	}
+}
+
+func TestSpillScrollbackClone(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(20, 3, 10)
+	if err := screen.SpillScrollback(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.CloseSpill()
+	stream := gopyte.NewStream(screen, false)
+	feedNumbered(stream, 0, 100)
+
+	clone := screen.Clone()
+	if !clone.Equal(screen) {
+		t.Fatalf("clone differs: %s", clone.DiffString(screen))
+	}
+
+	// Both go on spilling to the shared file without mixing their lines
+	feedNumbered(stream, 100, 100)
+	gopyte.NewStream(clone, false).Feed("\x1b[mother\r\n")
+	feedNumbered(stream, 200, 100)
+	for i := 0; i < 10; i++ {
+		gopyte.NewStream(clone, false).Feed("other\r\n")
+	}
+	for _, i := range []int{0, 97, 150, 250} {
+		if chars, _ := screen.GetHistoryLine(i); trimmedLine(chars) != fmt.Sprintf("line %d", i) {
+			t.Errorf("line %d: got %q", i, trimmedLine(chars))
+		}
+	}
+	if chars, _ := clone.GetHistoryLine(97); trimmedLine(chars) != "line 97" {
+		t.Errorf("clone line 97: got %q", trimmedLine(chars))
+	}
+	if chars, _ := clone.GetHistoryLine(98); trimmedLine(chars) != "line 98" {
+		t.Errorf("clone line 98: got %q", trimmedLine(chars))
+	}
+}
@@ -0,0 +1,60 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestPushPopState(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 5, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("\x1b[3;4H\x1b[1m\x1b7") // Application saves its cursor at (3, 2)
+	stream.Feed("\x1b[2;2H")
+
+	screen.PushState()
+	stream.Feed("\x1b[5;1H\x1b[0;7m[overlay]\x1b[1;1H\x1b7")
+	if screen.StateDepth() != 1 {
+		t.Fatalf("StateDepth: got %d", screen.StateDepth())
+	}
+	if !screen.PopState() {
+		t.Fatal("PopState returned false")
+	}
+
+	if x, y := screen.GetCursor(); x != 1 || y != 1 {
+		t.Errorf("cursor after PopState: got (%d, %d), want (1, 1)", x, y)
+	}
+	if !screen.GetCursorObject().Attrs.Bold || screen.GetCursorObject().Attrs.Reverse {
+		t.Errorf("attributes not restored: %+v", screen.GetCursorObject().Attrs)
+	}
+	// The overlay's DECSC did not clobber the application's slot
+	stream.Feed("\x1b8")
+	if x, y := screen.GetCursor(); x != 3 || y != 2 {
+		t.Errorf("DECRC after PopState: got (%d, %d), want (3, 2)", x, y)
+	}
+
+	if screen.PopState() {
+		t.Error("PopState on an empty stack returned true")
+	}
+}
+
+func TestMode1048(t *testing.T) {
+	screen := gopyte.NewAlternateScreen(20, 5, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	// 1048 shares the DECSC slot
+	stream.Feed("\x1b[2;3H\x1b[?1048h\x1b[5;5H\x1b8")
+	if x, y := screen.GetCursor(); x != 2 || y != 1 {
+		t.Errorf("DECRC after 1048 save: got (%d, %d), want (2, 1)", x, y)
+	}
+	stream.Feed("\x1b[4;4H\x1b7\x1b[1;1H\x1b[?1048l")
+	if x, y := screen.GetCursor(); x != 3 || y != 3 {
+		t.Errorf("1048 restore after DECSC: got (%d, %d), want (3, 3)", x, y)
+	}
+
+	// The alternate screen has its own slot
+	stream.Feed("\x1b[?1049h\x1b[2;2H\x1b7\x1b[?1049l\x1b[1;1H\x1b8")
+	if x, y := screen.GetCursor(); x != 3 || y != 3 {
+		t.Errorf("main slot after alternate DECSC: got (%d, %d), want (3, 3)", x, y)
+	}
+}
@@ -0,0 +1,37 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestDeviceStatusReports(t *testing.T) {
+	tests := []struct {
+		seq  string
+		want string
+	}{
+		{"\x1b[5n", "\x1b[0n"},
+		{"\x1b[6n", "\x1b[1;1R"},
+		{"\x1b[3;7H\x1b[6n", "\x1b[3;7R"},
+		// The pending wrap leaves the cursor on the last column
+		{"\x1b[2;9Hxy\x1b[6n", "\x1b[2;10R"},
+		// Origin mode counts from the left margin
+		{"\x1b[?69h\x1b[4;8s\x1b[?6h\x1b[2;3H\x1b[6n", "\x1b[2;3R"},
+		{"\x1b[?6n\x1b[1n", ""},
+	}
+	for _, tt := range tests {
+		screen := gopyte.NewWideCharScreen(10, 5, 10)
+		tr := gopyte.NewTranscript()
+		screen.SetTranscript(tr)
+		gopyte.NewStream(screen, false).Feed(tt.seq)
+
+		got := ""
+		for _, ev := range tr.Events() {
+			got += ev.Data
+		}
+		if got != tt.want {
+			t.Errorf("%q: response %q, want %q", tt.seq, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestStrictParseErrors(t *testing.T) {
+	screen := gopyte.NewNativeScreen(40, 3)
+	stream := gopyte.NewStream(screen, true)
+
+	if errs := stream.Feed("ok \x1b[1mbold\x1b[0m \x1b]2;title\x07"); errs != nil {
+		t.Errorf("clean input: %v", errs)
+	}
+
+	errs := stream.Feed("a\xffb\x1b[5\x18c\x1b[?9999y\x1b]999;x\x07\x1bZ")
+	want := []gopyte.ParseError{
+		{Offset: 27, Raw: []byte("\xff"), Reason: "invalid UTF-8"},
+		{Offset: 29, Raw: []byte("\x1b[5\x18"), Reason: "sequence cancelled"},
+		{Offset: 34, Raw: []byte("\x1b[?9999y"), Reason: "unsupported CSI sequence"},
+		{Offset: 42, Raw: []byte("\x1b]999;x\x07"), Reason: "unsupported OSC command"},
+		{Offset: 50, Raw: []byte("\x1bZ"), Reason: "unsupported escape sequence"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors:\n%+v\nwant\n%+v", errs, want)
+	}
+
+	// A sequence split across calls is reported whole, where it began
+	if errs := stream.Feed("\x1b]8"); errs != nil {
+		t.Errorf("open sequence reported: %v", errs)
+	}
+	errs = stream.Feed("\x07")
+	if len(errs) != 1 || string(errs[0].Raw) != "\x1b]8\x07" || errs[0].Offset != 52 ||
+		errs[0].Reason != "malformed OSC string" {
+		t.Errorf("split sequence: %+v", errs)
+	}
+	if got := errs[0].Error(); got != `gopyte: malformed OSC string at offset 52: "\x1b]8\a"` {
+		t.Errorf("Error() = %s", got)
+	}
+
+	// An OSC string cut short by another sequence
+	errs = stream.Feed("\x1b]2;unterminated\x1b[H")
+	if len(errs) != 1 || errs[0].Reason != "sequence interrupted" {
+		t.Errorf("interrupted string: %+v", errs)
+	}
+}
+
+func TestStrictSplitSequenceClears(t *testing.T) {
+	stream := gopyte.NewStream(gopyte.NewNativeScreen(20, 2), true)
+
+	// A split sequence that ends cleanly leaves nothing behind for the
+	// next error
+	stream.Feed("\x1b]2;ti")
+	if errs := stream.Feed("tle\x07"); errs != nil {
+		t.Errorf("clean split sequence: %v", errs)
+	}
+	errs := stream.Feed("\x1bZ")
+	want := []gopyte.ParseError{{Offset: 10, Raw: []byte("\x1bZ"), Reason: "unsupported escape sequence"}}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors:\n%+v\nwant\n%+v", errs, want)
+	}
+}
+
+func TestNonStrictStreamReturnsNoErrors(t *testing.T) {
+	stream := gopyte.NewStream(gopyte.NewNativeScreen(10, 2), false)
+	if errs := stream.Feed("\xff\x1b[5\x18\x1bZ"); errs != nil {
+		t.Errorf("errors = %v", errs)
+	}
+}
@@ -0,0 +1,46 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestGetStyledDisplay(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(12, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("ab\x1b[1mc中d\x1b[m e\r\n\x1b[44m  \x1b[m")
+
+	lines := screen.GetStyledDisplay()
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	want := []struct {
+		text          string
+		column, width int
+		bold          bool
+	}{{"ab", 0, 2, false}, {"c中d", 2, 4, true}, {" e", 6, 2, false}}
+	if len(lines[0]) != len(want) {
+		t.Fatalf("line 0 = %+v, want %d runs", lines[0], len(want))
+	}
+	for i, w := range want {
+		r := lines[0][i]
+		if r.Text != w.text || r.Column != w.column || r.Width != w.width || r.Attrs.Bold != w.bold {
+			t.Errorf("run %d = %+v, want %q at %d width %d bold %v", i, r, w.text, w.column, w.width, w.bold)
+		}
+	}
+
+	// Colored blanks are kept, default ones at the end are not
+	if len(lines[1]) != 1 || lines[1][0].Text != "  " || lines[1][0].Attrs.Bg == gopyte.DefaultAttributes().Bg {
+		t.Errorf("line 1 = %+v, want one run of colored blanks", lines[1])
+	}
+	if lines[2] == nil || len(lines[2]) != 0 {
+		t.Errorf("line 2 = %#v, want an empty slice", lines[2])
+	}
+
+	native := gopyte.NewNativeScreen(6, 1)
+	gopyte.NewStream(native, false).Feed("x\x1b[7myz")
+	if runs := native.GetStyledDisplay()[0]; len(runs) != 2 || runs[1].Text != "yz" || !runs[1].Attrs.Reverse {
+		t.Errorf("native runs = %+v", runs)
+	}
+}
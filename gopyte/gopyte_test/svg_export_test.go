@@ -0,0 +1,45 @@
+package gopyte_test
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestExportSVG(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.Feed("a<b \x1b[1;44m中\x1b[m\r\n\x1b[6 q")
+
+	svg := screen.ExportSVG(gopyte.SVGOptions{Cursor: true})
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="90" height="36" viewBox="0 0 90 36">`,
+		`<rect x="36" y="0" width="18" height="18" fill="#0000ee"/>`,
+		`<text x="0" y="13" textLength="36" lengthAdjust="spacingAndGlyphs">a&lt;b </text>`,
+		`<text x="36" y="13" textLength="18" lengthAdjust="spacingAndGlyphs" font-weight="bold">中</text>`,
+		`<rect class="cursor" x="0" y="18" width="2" height="18"`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("svg lacks %s:\n%s", want, svg)
+		}
+	}
+
+	// The document is well-formed XML
+	d := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		if _, err := d.Token(); err != nil {
+			if err != io.EOF {
+				t.Errorf("invalid XML: %v", err)
+			}
+			break
+		}
+	}
+
+	stream.Feed("\x1b[?25l")
+	if svg := screen.ExportSVG(gopyte.SVGOptions{Cursor: true}); strings.Contains(svg, "cursor") {
+		t.Error("hidden cursor drawn")
+	}
+}
@@ -0,0 +1,79 @@
+package gopyte_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestSyncScreenConcurrent(t *testing.T) {
+	screen := gopyte.NewSyncScreen(gopyte.NewWideCharScreen(40, 10, 100))
+	stream := gopyte.NewStream(screen, false)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				screen.GetDisplay()
+				screen.GetCell(1, 1)
+				screen.GetCursor()
+				screen.GetStyledDisplay()
+				screen.Snapshot()
+			}
+		}()
+	}
+	for i := 0; i < 500; i++ {
+		stream.Feed(fmt.Sprintf("\x1b[1;3%dmline %d 世界\x1b[m\r\n", i%8, i))
+		if i == 250 {
+			screen.Resize(50, 12)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	display := screen.GetDisplay()
+	if len(display) != 12 || !strings.HasPrefix(display[10], "line 499 世界") {
+		t.Errorf("display = %q", display)
+	}
+}
+
+func TestSyncScreenUpdate(t *testing.T) {
+	screen := gopyte.NewSyncScreen(gopyte.NewNativeScreen(20, 3))
+
+	// A stream on the wrapped screen, fed under Update, is seen whole
+	stream := gopyte.NewStream(screen.Screen(), false)
+	screen.Update(func(gopyte.Screen) { stream.Feed("\x1b]2;top\x07\x1b(0q\x1b(Bab") })
+	screen.View(func(s gopyte.Screen) {
+		native := s.(*gopyte.NativeScreen)
+		if got := native.GetDisplay()[0]; got != "─ab" {
+			t.Errorf("line = %q", got)
+		}
+	})
+	if got := screen.Title(); got != "top" {
+		t.Errorf("Title() = %q", got)
+	}
+
+	// The optional interfaces are passed on: charset drawing and grouped SGR
+	synced := gopyte.NewStream(screen, false)
+	synced.Feed("\r\n\x1b(0x\x1b(B\x1b[38:5:196mZ")
+	if got := screen.GetDisplay()[1]; got != "│Z" {
+		t.Errorf("line = %q", got)
+	}
+	if got := screen.GetCell(1, 1).Attrs.Fg; got != gopyte.IndexedColor(196) {
+		t.Errorf("fg = %v", got)
+	}
+	if x, y := screen.GetCursor(); x != 2 || y != 1 {
+		t.Errorf("cursor at (%d, %d)", x, y)
+	}
+}
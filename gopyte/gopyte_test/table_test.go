@@ -0,0 +1,56 @@
+package gopyte_test
+
+import (
+	"reflect"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestDetectTableUnderline(t *testing.T) {
+	lines := []string{
+		"Port      Name               Status       Vlan       Duplex  Speed Type",
+		"--------- ------------------ ------------ ---------- ------ ------ ----",
+		"Gi1/0/1   uplink to core     connected    trunk        full   1000 10/100/1000BaseTX",
+		"Gi1/0/2                      notconnect   10           auto   auto 10/100/1000BaseTX",
+		"",
+	}
+	table, ok := gopyte.DetectTable(lines)
+	if !ok {
+		t.Fatal("table not detected")
+	}
+	if want := []string{"Port", "Name", "Status", "Vlan", "Duplex", "Speed", "Type"}; !reflect.DeepEqual(table.Header, want) {
+		t.Errorf("header: got %q", table.Header)
+	}
+	want := [][]string{
+		{"Gi1/0/1", "uplink to core", "connected", "trunk", "full", "1000", "10/100/1000BaseTX"},
+		{"Gi1/0/2", "", "notconnect", "10", "auto", "auto", "10/100/1000BaseTX"},
+	}
+	if !reflect.DeepEqual(table.Rows, want) {
+		t.Errorf("rows:\n got %q\nwant %q", table.Rows, want)
+	}
+}
+
+func TestDetectTableAligned(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 6)
+	gopyte.NewStream(screen, false).Feed("router#show ip interface brief\r\n" +
+		"Interface              IP-Address      OK? Method Status                Protocol\r\n" +
+		"GigabitEthernet0/0     10.0.0.1        YES NVRAM  up                    up\r\n" +
+		"GigabitEthernet0/1     unassigned      YES unset  administratively down down\r\n" +
+		"router#")
+
+	table, ok := gopyte.DetectTable(screen.GetDisplay()[1:4])
+	if !ok {
+		t.Fatal("table not detected")
+	}
+	if len(table.Columns) != 6 {
+		t.Fatalf("got %d columns: %+v", len(table.Columns), table.Columns)
+	}
+	if got := table.Rows[1]; got[4] != "administratively down" || got[5] != "down" {
+		t.Errorf("row 2: got %q", got)
+	}
+
+	if _, ok := gopyte.DetectTable([]string{"the quick brown fox", "jumps over the lazy dog"}); ok {
+		t.Error("prose detected as a table")
+	}
+}
@@ -0,0 +1,33 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestForwardAndBackTab(t *testing.T) {
+	screen := gopyte.NewNativeScreen(30, 2)
+	stream := gopyte.NewStream(screen, false)
+
+	tests := []struct {
+		seq  string
+		want int
+	}{
+		{"\x1b[I", 8},
+		{"\x1b[2I", 24},
+		{"\x1b[5I", 29}, // Stops at the last column
+		{"\x1b[Z", 24},
+		{"\x1b[2Z", 8},
+		{"\x1b[9Z", 0}, // Stops at the first column
+		{"\x1b[13G\x1b[Z", 8},
+		{"\x1b[3g\x1b[5G\x1bH\x1b[G\x1b[I", 4}, // Custom tab stops
+		{"\x1b[20G\x1b[Z", 4},
+	}
+	for _, tt := range tests {
+		stream.Feed(tt.seq)
+		if x, _ := screen.GetCursor(); x != tt.want {
+			t.Errorf("%q: cursor x = %d, want %d", tt.seq, x, tt.want)
+		}
+	}
+}
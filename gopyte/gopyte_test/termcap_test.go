@@ -0,0 +1,46 @@
+package gopyte_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func termcapResponses(screen *gopyte.NativeScreen, query string) string {
+	tr := gopyte.NewTranscript()
+	screen.SetTranscript(tr)
+	gopyte.NewStream(screen, false).Feed("\x1bP+q" + query + "\x1b\\")
+	got := ""
+	for _, ev := range tr.Events() {
+		got += ev.Data
+	}
+	return got
+}
+
+func TestXTGETTCAP(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	hexs := func(s string) string { return hex.EncodeToString([]byte(s)) }
+
+	// One answer per name; unknown names and bad hex fail
+	got := termcapResponses(screen, hexs("TN")+";"+hexs("Tc")+";"+hexs("bogus")+";zz")
+	want := "\x1bP1+r544e=" + hexs("xterm-256color") + "\x1b\\" +
+		"\x1bP1+r5463\x1b\\" +
+		"\x1bP0+r" + hexs("bogus") + "\x1b\\" +
+		"\x1bP0+rzz\x1b\\"
+	if got != want {
+		t.Errorf("responses = %q, want %q", got, want)
+	}
+
+	screen.SetTermcap(map[string]string{"Co": "16"})
+	if got := termcapResponses(screen, hexs("Co")+";"+hexs("TN")); got != "\x1bP1+r436f=3136\x1b\\\x1bP0+r544e\x1b\\" {
+		t.Errorf("custom termcap responses = %q", got)
+	}
+
+	// Termcap answers echo host-controlled data; the default guard refuses them
+	screen.SetTermcap(nil)
+	screen.SetResponseGuard(gopyte.DefaultResponseGuard())
+	if got := termcapResponses(screen, hexs("TN")); got != "" {
+		t.Errorf("guarded response = %q", got)
+	}
+}
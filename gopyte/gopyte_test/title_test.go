@@ -0,0 +1,78 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestOSCTitleAndIconName(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	stream := gopyte.NewStream(screen, false)
+
+	var titles, icons []string
+	screen.SetTitleHandler(func(s string) { titles = append(titles, s) })
+	screen.SetIconNameHandler(func(s string) { icons = append(icons, s) })
+
+	stream.Feed("\x1b]0;both\x07")
+	if screen.Title() != "both" || screen.IconName() != "both" {
+		t.Errorf("OSC 0: title %q, icon %q", screen.Title(), screen.IconName())
+	}
+	stream.Feed("\x1b]1;icon\x1b\\")
+	if screen.Title() != "both" || screen.IconName() != "icon" {
+		t.Errorf("OSC 1: title %q, icon %q", screen.Title(), screen.IconName())
+	}
+	stream.Feed("\x1b]2;title\x07")
+	if screen.Title() != "title" || screen.IconName() != "icon" {
+		t.Errorf("OSC 2: title %q, icon %q", screen.Title(), screen.IconName())
+	}
+
+	// Unchanged values fire no events
+	stream.Feed("\x1b]2;title\x07")
+	if want := []string{"both", "title"}; strings.Join(titles, ",") != strings.Join(want, ",") {
+		t.Errorf("title events: got %q, want %q", titles, want)
+	}
+	if want := []string{"both", "icon"}; strings.Join(icons, ",") != strings.Join(want, ",") {
+		t.Errorf("icon events: got %q, want %q", icons, want)
+	}
+}
+
+func TestOSCTitleUTF8(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	stream := gopyte.NewStream(screen, false)
+
+	// "ɜ" is encoded as C9 9C; the 0x9c byte must not end the string
+	stream.Feed("\x1b]2;日本語 ɜ\x07")
+	if got := screen.Title(); got != "日本語 ɜ" {
+		t.Errorf("title: got %q", got)
+	}
+
+	// A lone 0x9c is C1 ST
+	stream.Feed("\x1b]2;abc\x9cxyz")
+	if got := screen.Title(); got != "abc" {
+		t.Errorf("title: got %q", got)
+	}
+	if got := screen.GetDisplay()[0]; got != "xyz" {
+		t.Errorf("display: got %q", got)
+	}
+}
+
+func TestTitleSanitized(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+
+	screen.SetTitle("a\x1b[31mb\u202ec\u0085d\x7f")
+	if got := screen.Title(); got != "a[31mbcd" {
+		t.Errorf("title: got %q", got)
+	}
+
+	screen.SetTitle("bad\xffutf8")
+	if got := screen.Title(); got != "bad�utf8" {
+		t.Errorf("title: got %q", got)
+	}
+
+	screen.SetIconName(strings.Repeat("é", gopyte.MaxTitleLength+10))
+	if got := []rune(screen.IconName()); len(got) != gopyte.MaxTitleLength {
+		t.Errorf("icon name length: got %d, want %d", len(got), gopyte.MaxTitleLength)
+	}
+}
@@ -0,0 +1,77 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestTraceScreen(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 3)
+	trace := gopyte.NewTraceScreen(screen)
+	stream := gopyte.NewStream(trace, false)
+	stream.Feed("\x1b[2;3Hhi\x1b[1;38:5:9m!\x1b[?1049h\x1b(0q\x1b(B\r\n\x1b]2;t\x07\x1b[4 q")
+
+	want := `CursorPosition(2, 3)
+Draw("hi")
+SelectGraphicRenditionGroups([[1] [38 5 9]])
+Draw("!")
+SetMode([1049], true)
+DefineCharset("0", "(")
+DrawCharset("─")
+DefineCharset("B", "(")
+CarriageReturn()
+Linefeed()
+SetTitle("t")
+SetCursorStyle(steady underline)
+`
+	if got := trace.String(); got != want {
+		t.Errorf("trace:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The wrapped screen saw it all
+	if got := screen.GetDisplay()[1]; got != "  hi!─" {
+		t.Errorf("screen line 1 = %q", got)
+	}
+	if screen.Title() != "t" {
+		t.Errorf("title = %q", screen.Title())
+	}
+
+	trace.ClearCalls()
+	if len(trace.Calls()) != 0 {
+		t.Errorf("calls after ClearCalls: %v", trace.Calls())
+	}
+}
+
+func TestTraceScreenRecordOnly(t *testing.T) {
+	trace := gopyte.NewTraceScreen(nil)
+	stream := gopyte.NewStream(trace, false)
+	stream.Feed("a\x1b[31m\x1b[?1000h\x07")
+
+	calls := trace.Calls()
+	if len(calls) != 4 || calls[1].Method != "SelectGraphicRenditionGroups" || calls[3].Method != "Bell" {
+		t.Fatalf("calls = %v", calls)
+	}
+	if !strings.Contains(trace.String(), "SetMode([1000], true)") {
+		t.Errorf("trace missing SetMode:\n%s", trace)
+	}
+}
+
+func TestTraceScreenBookmark(t *testing.T) {
+	screen := gopyte.NewHistoryScreen(20, 3, 10)
+	trace := gopyte.NewTraceScreen(screen)
+	stream := gopyte.NewStream(trace, false)
+	stream.Feed("one\r\ntwo")
+
+	b, ok := stream.Bookmark("here", "note")
+	if !ok || b.Name != "here" || b.Offset != 8 || b.Line != 1 {
+		t.Fatalf("bookmark = %+v, %v", b, ok)
+	}
+	if _, ok := screen.GetBookmark("here"); !ok {
+		t.Error("bookmark not passed on to the wrapped screen")
+	}
+	if calls := trace.Calls(); calls[len(calls)-1].String() != `AddBookmark("here", "note", 8)` {
+		t.Errorf("last call = %v", calls[len(calls)-1])
+	}
+}
@@ -0,0 +1,105 @@
+package gopyte_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestTranscriptRecording(t *testing.T) {
+	tr := gopyte.NewTranscript()
+
+	screen := gopyte.NewWideCharScreen(40, 10, 100)
+	stream := gopyte.NewStream(screen, false)
+	stream.SetTranscript(tr)
+	screen.SetTranscript(tr)
+
+	stream.Feed("$ ")
+	tr.RecordInput("ls\r")
+	stream.Feed("ls\r\nfile.txt\r\n$ ")
+	screen.WriteProcessInput("\x1b[1;1R")
+
+	events := tr.Events()
+	wantDirs := []gopyte.Direction{gopyte.DirOutput, gopyte.DirInput, gopyte.DirOutput, gopyte.DirInput}
+	if len(events) != len(wantDirs) {
+		t.Fatalf("got %d events, want %d", len(events), len(wantDirs))
+	}
+	for i, ev := range events {
+		if ev.Direction != wantDirs[i] {
+			t.Errorf("event %d: direction %v, want %v", i, ev.Direction, wantDirs[i])
+		}
+		if i > 0 && ev.Time < events[i-1].Time {
+			t.Errorf("event %d: timestamps out of order", i)
+		}
+	}
+	if events[3].Data != "\x1b[1;1R" {
+		t.Errorf("report not recorded: %q", events[3].Data)
+	}
+
+	// Round trip through the on-disk format
+	var buf bytes.Buffer
+	if _, err := tr.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := gopyte.ReadTranscript(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := loaded.Events()
+	for i := range got {
+		if got[i].Direction != events[i].Direction || got[i].Data != events[i].Data {
+			t.Errorf("event %d differs after round trip: %+v vs %+v", i, got[i], events[i])
+		}
+	}
+
+	// Replaying the output reproduces the screen
+	replayScreen := gopyte.NewWideCharScreen(40, 10, 100)
+	loaded.Replay(gopyte.NewStream(replayScreen, false))
+	if !reflect.DeepEqual(replayScreen.GetDisplay(), screen.GetDisplay()) {
+		t.Errorf("replayed display differs:\n%q\n%q", replayScreen.GetDisplay(), screen.GetDisplay())
+	}
+}
+
+func TestTranscriptSplitRune(t *testing.T) {
+	tr := gopyte.NewTranscript()
+	screen := gopyte.NewWideCharScreen(10, 2, 10)
+	stream := gopyte.NewStream(screen, false)
+	stream.SetTranscript(tr)
+
+	// A character split between writes is recorded whole
+	stream.Feed("a\xe4\xb8")
+	stream.Feed("\xadb")
+	events := tr.Events()
+	if len(events) != 2 || events[0].Data != "a" || events[1].Data != "中b" {
+		t.Fatalf("events = %+v", events)
+	}
+
+	var buf bytes.Buffer
+	if _, err := tr.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := gopyte.ReadTranscript(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayScreen := gopyte.NewWideCharScreen(10, 2, 10)
+	loaded.Replay(gopyte.NewStream(replayScreen, false))
+	if got, want := replayScreen.GetDisplay(), screen.GetDisplay(); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed display = %q, want %q", got, want)
+	}
+}
+
+func TestReadTranscriptErrors(t *testing.T) {
+	inputs := []string{
+		"not json\n",
+		"[0.5, \"o\"]\n",
+		"[0.5, \"x\", \"data\"]\n",
+	}
+	for _, in := range inputs {
+		if _, err := gopyte.ReadTranscript(bytes.NewBufferString(in)); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
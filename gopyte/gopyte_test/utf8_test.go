@@ -0,0 +1,41 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestUTF8SplitAcrossFeeds(t *testing.T) {
+	// "‛" ends in 0x9b, which must not be taken for C1 CSI
+	text := "café ‛€𝄞 done"
+
+	whole := gopyte.NewNativeScreen(20, 2)
+	gopyte.NewStream(whole, false).Feed(text)
+
+	split := gopyte.NewNativeScreen(20, 2)
+	stream := gopyte.NewStream(split, false)
+	for i := 0; i < len(text); i++ {
+		stream.Feed(text[i : i+1])
+	}
+
+	want := "café ‛€𝄞 done"
+	for name, s := range map[string]*gopyte.NativeScreen{"whole": whole, "split": split} {
+		if got := strings.TrimRight(s.GetDisplay()[0], " "); got != want {
+			t.Errorf("%s: line = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestUTF8TruncatedRune(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 2)
+	stream := gopyte.NewStream(screen, false)
+
+	// A rune cut short by an escape sequence is replaced, not held forever
+	stream.Feed("a\xe2\x82")
+	stream.Feed("\x1b[1mb")
+	if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != "a��b" {
+		t.Errorf("line = %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestWatchdogSize(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 3)
+	stream := gopyte.NewStream(screen, false)
+	var aborts []gopyte.ParserAbort
+	stream.SetWatchdog(gopyte.WatchdogOptions{MaxBytes: 16}, func(a gopyte.ParserAbort) {
+		aborts = append(aborts, a)
+	})
+
+	var raw strings.Builder
+	stream.SetRawTap(func(seq gopyte.RawSequence) { raw.Write(seq.Raw) })
+
+	// An OSC that fits is still handled
+	stream.Feed("\x1b]2;short\x07")
+	if screen.Title() != "short" || len(aborts) != 0 {
+		t.Fatalf("title %q, aborts %v", screen.Title(), aborts)
+	}
+
+	// One that never ends is dropped and later output shows again
+	input := "\x1b]2;" + strings.Repeat("x", 10)
+	stream.Feed(input)
+	stream.Feed(strings.Repeat("y", 10) + "hello")
+	input += strings.Repeat("y", 10) + "hello"
+
+	if len(aborts) != 1 || aborts[0].State != gopyte.StateOSC || aborts[0].Reason != "size" || aborts[0].Bytes != 17 {
+		t.Fatalf("aborts = %+v", aborts)
+	}
+	if got := screen.GetDisplay()[0]; !strings.HasSuffix(got, "hello") {
+		t.Errorf("display = %q, want output after the aborted string", got)
+	}
+	if screen.Title() != "short" {
+		t.Errorf("aborted string changed the title to %q", screen.Title())
+	}
+	if want := "\x1b]2;short\x07" + input; raw.String() != want {
+		t.Errorf("raw tap = %q, want %q", raw.String(), want)
+	}
+}
+
+func TestWatchdogTime(t *testing.T) {
+	clock := gopyte.NewFakeClock(clockStart)
+	screen := gopyte.NewNativeScreen(20, 3)
+	stream := gopyte.NewStream(screen, false)
+	var aborts []gopyte.ParserAbort
+	stream.SetWatchdog(gopyte.WatchdogOptions{MaxTime: time.Second, Clock: clock}, func(a gopyte.ParserAbort) {
+		aborts = append(aborts, a)
+	})
+
+	stream.Feed("\x1b]0;stuck")
+	clock.Advance(500 * time.Millisecond)
+	stream.Feed("...")
+	if len(aborts) != 0 {
+		t.Fatalf("aborted too early: %+v", aborts)
+	}
+
+	clock.Advance(time.Second)
+	stream.Feed("ok")
+	if len(aborts) != 1 || aborts[0].Reason != "time" || aborts[0].Elapsed != 1500*time.Millisecond {
+		t.Fatalf("aborts = %+v", aborts)
+	}
+	if got := strings.TrimRight(screen.GetDisplay()[0], " "); got != "ok" {
+		t.Errorf("display = %q, want %q", got, "ok")
+	}
+	if aborts[0].State.String() != "osc" {
+		t.Errorf("state name = %q", aborts[0].State.String())
+	}
+}
@@ -0,0 +1,77 @@
+package gopyte_test
+
+import (
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestUnicodeWidth(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       int
+		ambiguousWide bool
+		ch            rune
+		want          int
+	}{
+		{"emoji", 9, false, '😀', 2},
+		{"emoji before 9", 8, false, '😀', 1},
+		{"watch before 9", 8, false, '⌚', 1},
+		{"CJK before 9", 8, false, '中', 2},
+		{"angle bracket before 9", 8, false, '〈', 2},
+		{"enclosed ideograph before 9", 8, false, '🈚', 2},
+		{"combining", 8, false, '́', 0},
+		{"greek", 9, false, 'α', 1},
+		{"greek ambiguous wide", 9, true, 'α', 2},
+		{"circled digit ambiguous wide before 9", 8, true, '①', 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gopyte.UnicodeWidth(tt.version, tt.ambiguousWide)(tt.ch); got != tt.want {
+				t.Errorf("width of %q: got %d, want %d", tt.ch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetWidthFunc(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 10)
+	stream := gopyte.NewStream(screen, false)
+
+	screen.SetWidthFunc(gopyte.UnicodeWidth(8, false))
+	stream.Feed("😀b")
+	if c := screen.GetCell(0, 0); c.Width != 1 {
+		t.Errorf("narrow emoji: got width %d", c.Width)
+	}
+	if x, _ := screen.GetCursor(); x != 2 {
+		t.Errorf("cursor after narrow emoji: got %d, want 2", x)
+	}
+
+	// A terminal that draws everything in one cell
+	screen.SetWidthFunc(func(r rune) int { return 1 })
+	stream.Feed("\r\n中文")
+	if x, _ := screen.GetCursor(); x != 2 {
+		t.Errorf("cursor after narrow CJK: got %d, want 2", x)
+	}
+
+	// Clones measure alike
+	clone := screen.Clone()
+	gopyte.NewStream(clone, false).Feed("中")
+	if x, _ := clone.GetCursor(); x != 3 {
+		t.Errorf("clone cursor: got %d, want 3", x)
+	}
+
+	screen.SetWidthFunc(nil)
+	stream.Feed("\r\n😀中")
+	if x, _ := screen.GetCursor(); x != 4 {
+		t.Errorf("cursor with default widths: got %d, want 4", x)
+	}
+
+	want := []string{"😀b", "中文", "😀中"}
+	for y, line := range screen.GetDisplay() {
+		if got := strings.TrimRight(line, " "); got != want[y] {
+			t.Errorf("line %d: got %q, want %q", y, got, want[y])
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package gopyte_test
+
+import (
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestWindowOps(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	stream := gopyte.NewStream(screen, false)
+	var events []gopyte.WindowEvent
+	screen.SetWindowHandler(func(ev gopyte.WindowEvent) { events = append(events, ev) })
+
+	stream.Feed("\x1b[2t")
+	if !screen.WindowState().Iconified {
+		t.Error("CSI 2 t should iconify")
+	}
+	stream.Feed("\x1b[1t\x1b[3;100;200t\x1b[9;1t\x1b[10;2t")
+	st := screen.WindowState()
+	if st.Iconified || st.X != 100 || st.Y != 200 || st.Maximized != 1 || !st.FullScreen {
+		t.Errorf("state = %+v", st)
+	}
+	stream.Feed("\x1b[10;2t\x1b[9;0t\x1b[5t")
+	if st := screen.WindowState(); st.FullScreen || st.Maximized != 0 {
+		t.Errorf("after restore: %+v", st)
+	}
+
+	if len(events) != 8 {
+		t.Fatalf("got %d events, want 8", len(events))
+	}
+	if ev := events[2]; ev.Op != gopyte.WinMove || len(ev.Params) != 2 || ev.Params[0] != 100 || ev.State.X != 100 {
+		t.Errorf("move event = %+v", ev)
+	}
+	if events[7].Op != gopyte.WinRaise {
+		t.Errorf("last event = %+v", events[7])
+	}
+
+	// Reports are not window operations
+	stream.Feed("\x1b[18t")
+	if len(events) != 8 {
+		t.Error("CSI 18 t should not be reported as a window operation")
+	}
+}
+
+func TestTitleModes(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	stream := gopyte.NewStream(screen, false)
+
+	stream.Feed("\x1b[>0;1t")
+	if m := screen.WindowState().TitleModes; !m[gopyte.TitleSetHex] || !m[gopyte.TitleQueryHex] {
+		t.Fatalf("modes = %v", m)
+	}
+	stream.Feed("\x1b]2;68656c6c6f\x07")
+	if screen.Title() != "hello" {
+		t.Errorf("hex title = %q", screen.Title())
+	}
+
+	stream.Feed("\x1b[>0T")
+	if m := screen.WindowState().TitleModes; m[gopyte.TitleSetHex] || !m[gopyte.TitleQueryHex] {
+		t.Errorf("after reset: %v", m)
+	}
+	stream.Feed("\x1b]2;68656c6c6f\x07")
+	if screen.Title() != "68656c6c6f" {
+		t.Errorf("plain title = %q", screen.Title())
+	}
+
+	stream.Feed("\x1b[>t")
+	if m := screen.WindowState().TitleModes; m != [4]bool{} {
+		t.Errorf("CSI > t should reset all modes: %v", m)
+	}
+}
+
+func TestWindowReports(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	stream := gopyte.NewStream(screen, false)
+	tr := gopyte.NewTranscript()
+	screen.SetTranscript(tr)
+	responses := func() string {
+		got := ""
+		for _, ev := range tr.Events() {
+			got += ev.Data
+		}
+		tr = gopyte.NewTranscript()
+		screen.SetTranscript(tr)
+		return got
+	}
+
+	stream.Feed("\x1b[18t\x1b[19t")
+	if got := responses(); got != "\x1b[8;24;80t\x1b[9;24;80t" {
+		t.Errorf("cell reports = %q", got)
+	}
+
+	// Pixel reports need the cell size
+	stream.Feed("\x1b[14t\x1b[16t")
+	if got := responses(); got != "" {
+		t.Errorf("pixel reports without a cell size = %q", got)
+	}
+	screen.SetCellPixelSize(9, 18)
+	stream.Feed("\x1b[14t\x1b[16t")
+	if got := responses(); got != "\x1b[4;432;720t\x1b[6;18;9t" {
+		t.Errorf("pixel reports = %q", got)
+	}
+}
+
+func TestWindowTitleStack(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	stream := gopyte.NewStream(screen, false)
+
+	stream.Feed("\x1b]0;shell\x07\x1b[22;0t\x1b]2;vim\x07\x1b[22;2t\x1b]0;less\x07")
+	stream.Feed("\x1b[23;2t")
+	if screen.Title() != "vim" || screen.IconName() != "less" {
+		t.Errorf("after popping the title: %q, %q", screen.Title(), screen.IconName())
+	}
+	stream.Feed("\x1b[23;0t")
+	if screen.Title() != "shell" || screen.IconName() != "shell" {
+		t.Errorf("after popping both: %q, %q", screen.Title(), screen.IconName())
+	}
+
+	// An empty stack pops nothing
+	stream.Feed("\x1b[23t")
+	if screen.Title() != "shell" {
+		t.Errorf("title = %q", screen.Title())
+	}
+}
+
+func TestWindowResizeRequest(t *testing.T) {
+	screen := gopyte.NewNativeScreen(80, 24)
+	stream := gopyte.NewStream(screen, false)
+	var got [][2]int
+	screen.SetResizeRequestHandler(func(lines, columns int) {
+		got = append(got, [2]int{lines, columns})
+	})
+
+	stream.Feed("\x1b[8;40;100t\x1b[8;;132t\x1b[4;400;800t")
+	want := [][2]int{{40, 100}, {24, 132}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("resize requests = %v, want %v", got, want)
+	}
+	if lines := len(screen.GetDisplay()); lines != 24 {
+		t.Errorf("screen resized itself to %d lines", lines)
+	}
+}
@@ -0,0 +1,96 @@
+package gopyte_test
+
+import (
+	"regexp"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+)
+
+func TestWordAt(t *testing.T) {
+	screen := gopyte.NewNativeScreen(40, 2)
+	gopyte.NewStream(screen, false).Feed("ping 10.0.0.1 (host-a.lab) ok")
+
+	tests := []struct {
+		x    int
+		want string
+	}{
+		{0, "ping"}, {3, "ping"}, {8, "10.0.0.1"}, {16, "host-a.lab"}, {28, "ok"},
+	}
+	for _, tt := range tests {
+		m, ok := screen.WordAt(tt.x, 0)
+		if !ok || m.Text != tt.want {
+			t.Errorf("WordAt(%d): got %q, %v, want %q", tt.x, m.Text, ok, tt.want)
+		}
+	}
+	if m, _ := screen.WordAt(8, 0); m.Spans[0].Left != 5 || m.Spans[0].Right != 12 {
+		t.Errorf("span: got %+v", m.Spans)
+	}
+	for _, x := range []int{4, 14, 25, 35} { // Blank, separators, past the text
+		if m, ok := screen.WordAt(x, 0); ok {
+			t.Errorf("WordAt(%d): got %q", x, m.Text)
+		}
+	}
+
+	// Dots separate too once configured
+	screen.SetWordSeparators(".")
+	if m, _ := screen.WordAt(8, 0); m.Text != "0" {
+		t.Errorf("custom separators: got %q", m.Text)
+	}
+	screen.SetWordSeparators("")
+	if m, _ := screen.WordAt(8, 0); m.Text != "10.0.0.1" {
+		t.Errorf("restored separators: got %q", m.Text)
+	}
+}
+
+func TestWordAtWide(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(20, 2, 10)
+	gopyte.NewStream(screen, false).Feed("say 你好 now")
+
+	m, ok := screen.WordAt(7, 0) // Right half of 好
+	if !ok || m.Text != "你好" || m.Spans[0].Left != 4 || m.Spans[0].Right != 7 {
+		t.Errorf("got %+v, %v", m, ok)
+	}
+}
+
+func TestURLAt(t *testing.T) {
+	screen := gopyte.NewNativeScreen(20, 4)
+	gopyte.NewStream(screen, false).Feed("see https://example.com/a/b?q=1, then\r\nno url here")
+
+	// The URL wraps onto the next row and is found from either
+	for _, cell := range [][2]int{{6, 0}, {19, 0}, {3, 1}} {
+		m, ok := screen.URLAt(cell[0], cell[1])
+		if !ok || m.Text != "https://example.com/a/b?q=1" {
+			t.Errorf("URLAt(%v): got %q, %v", cell, m.Text, ok)
+			continue
+		}
+		if len(m.Spans) != 2 || m.Spans[0].Left != 4 || m.Spans[1].Right != 10 {
+			t.Errorf("URLAt(%v) spans: got %+v", cell, m.Spans)
+		}
+	}
+	if m, ok := screen.URLAt(1, 0); ok {
+		t.Errorf("before the URL: got %q", m.Text)
+	}
+	if m, ok := screen.URLAt(11, 1); ok { // The trailing comma
+		t.Errorf("trailing punctuation: got %q", m.Text)
+	}
+
+	screen.SetURLPattern(regexp.MustCompile(`url`))
+	if m, ok := screen.URLAt(4, 2); !ok || m.Text != "url" {
+		t.Errorf("custom pattern: got %q, %v", m.Text, ok)
+	}
+}
+
+func TestSelectMatch(t *testing.T) {
+	screen := gopyte.NewNativeScreen(10, 3)
+	gopyte.NewStream(screen, false).Feed("a long-word wraps")
+
+	m, ok := screen.WordAt(9, 0)
+	if !ok {
+		t.Fatal("no word")
+	}
+	screen.SelectMatch(m)
+	if got := screen.SelectionText(); got != "long-word" {
+		t.Errorf("selected %q", got)
+	}
+}
@@ -0,0 +1,136 @@
+package gopyte
+
+import (
+	"container/list"
+	"iter"
+)
+
+// Reading the scrollback directly, for applications that draw it
+// themselves rather than through ScrollUp. Line 0 is the oldest. A null
+// rune (WidePlaceholder) is the right half of a wide character.
+
+// HistoryLen returns the number of lines in the scrollback, spilled ones
+// included (see spill.go).
+func (h *HistoryScreen) HistoryLen() int {
+	return historyLen(h.history) + h.spill.len()
+}
+
+// GetHistoryLine returns the text and attributes of scrollback line n,
+// one of each per column, or nil if there is no such line. The slices
+// are the caller's to keep.
+func (h *HistoryScreen) GetHistoryLine(n int) ([]rune, []Attributes) {
+	return h.historyLine(h.history, h.spill, n)
+}
+
+// HistoryLines iterates over the scrollback from line from to the newest,
+// yielding the text and attributes of each line. The slices are reused
+// from one line to the next; copy them to keep them. The screen must not
+// change while iterating.
+//
+//	for chars, attrs := range screen.HistoryLines(top) {
+//		if row == rows {
+//			break
+//		}
+//		drawRow(row, chars, attrs)
+//		row++
+//	}
+func (h *HistoryScreen) HistoryLines(from int) iter.Seq2[[]rune, []Attributes] {
+	return h.historyLines(h.history, h.spill, from)
+}
+
+// HistoryLen returns the number of lines in the scrollback of the main
+// screen, which is kept while the alternate screen is up.
+func (a *AlternateScreen) HistoryLen() int {
+	l, sp := a.scrollback()
+	return historyLen(l) + sp.len()
+}
+
+// GetHistoryLine returns the text and attributes of line n of the main
+// screen's scrollback, or nil if there is no such line.
+func (a *AlternateScreen) GetHistoryLine(n int) ([]rune, []Attributes) {
+	l, sp := a.scrollback()
+	return a.historyLine(l, sp, n)
+}
+
+// HistoryLines iterates over the main screen's scrollback from line from
+// to the newest, like HistoryScreen.HistoryLines.
+func (a *AlternateScreen) HistoryLines(from int) iter.Seq2[[]rune, []Attributes] {
+	l, sp := a.scrollback()
+	return a.historyLines(l, sp, from)
+}
+
+// scrollback returns the main screen's history list and spilled lines.
+func (a *AlternateScreen) scrollback() (*list.List, *spill) {
+	if a.usingAlternate {
+		return a.mainHistory, a.mainSpill
+	}
+	return a.history, a.spill
+}
+
+func (h *HistoryScreen) historyLine(l *list.List, sp *spill, n int) ([]rune, []Attributes) {
+	if n >= 0 && n < sp.len() {
+		line, attrs, _ := sp.line(n)
+		return line.Chars, attrs
+	}
+	e := historyElement(l, n-sp.len())
+	if e == nil {
+		return nil, nil
+	}
+	line := e.Value.(HistoryLine)
+	return append([]rune(nil), line.Chars...), h.styles.attrs(line.styles, len(line.Chars))
+}
+
+func (h *HistoryScreen) historyLines(l *list.List, sp *spill, from int) iter.Seq2[[]rune, []Attributes] {
+	return func(yield func([]rune, []Attributes) bool) {
+		var chars []rune
+		h.eachHistory(l, sp, from, func(line HistoryLine, attrs []Attributes) bool {
+			chars = append(chars[:0], line.Chars...)
+			return yield(chars, attrs)
+		})
+	}
+}
+
+// eachHistory calls fn with the scrollback lines from line from to the
+// newest, spilled ones first, until it returns false. The attributes are
+// reused from one line to the next. A spilled line that cannot be read
+// back comes out empty (see SpillErr).
+func (h *HistoryScreen) eachHistory(l *list.List, sp *spill, from int, fn func(line HistoryLine, attrs []Attributes) bool) {
+	from = max(from, 0)
+	for i := from; i < sp.len(); i++ {
+		if line, attrs, _ := sp.line(i); !fn(line, attrs) {
+			return
+		}
+	}
+	var attrs []Attributes
+	for e := historyElement(l, max(from-sp.len(), 0)); e != nil; e = e.Next() {
+		line := e.Value.(HistoryLine)
+		if cap(attrs) < len(line.Chars) {
+			attrs = make([]Attributes, len(line.Chars))
+		}
+		attrs = attrs[:len(line.Chars)]
+		h.styles.expand(attrs, line.styles, len(line.Chars))
+		if !fn(line, attrs) {
+			return
+		}
+	}
+}
+
+// historyElement returns element n of l, walking from the nearer end, or
+// nil if there is none.
+func historyElement(l *list.List, n int) *list.Element {
+	if l == nil || n < 0 || n >= l.Len() {
+		return nil
+	}
+	if n < l.Len()/2 {
+		e := l.Front()
+		for ; n > 0; n-- {
+			e = e.Next()
+		}
+		return e
+	}
+	e := l.Back()
+	for i := l.Len() - 1; i > n; i-- {
+		e = e.Prev()
+	}
+	return e
+}
@@ -12,24 +12,37 @@ type HistoryScreen struct {
 	history    *list.List // Doubly-linked list of historical lines
 	maxHistory int        // Maximum lines to keep in history
 	historyPos int        // Current position in history (0 = bottom/current)
+	styles     styleTable // Attributes of history cells (see style_table.go)
+	spill      *spill     // Lines past maxHistory, nil unless spilling (see spill.go)
 
 	// Saved screen state for viewing history
 	savedBuffer    [][]rune
 	savedAttrs     [][]Attributes
+	savedWrapped   []bool
+	savedMeta      []*LineMeta
 	savedCursor    Cursor
 	viewingHistory bool
+
+	collapseProgress bool                // See progress.go
+	reverseScroll    ReverseScrollPolicy // See reverse_scroll.go
+
+	// See bookmarks.go
+	lineBase  int // Absolute line number of screen row 0
+	bookmarks []Bookmark
 }
 
 // HistoryLine stores a line that scrolled off the top
 type HistoryLine struct {
-	Chars []rune
-	Attrs []Attributes
+	Chars   []rune
+	styles  []uint32  // Indexes into the screen's styleTable, nil if all default
+	Wrapped bool      // Line soft-wrapped onto the following line
+	Meta    *LineMeta // Embedder metadata, nil if none (see meta.go)
 }
 
 // NewHistoryScreen creates a screen with scrollback buffer
-func NewHistoryScreen(columns, lines, maxHistory int) *HistoryScreen {
+func NewHistoryScreen(columns, lines, maxHistory int, opts ...ScreenOption) *HistoryScreen {
 	h := &HistoryScreen{
-		NativeScreen:   *NewNativeScreen(columns, lines),
+		NativeScreen:   *NewNativeScreen(columns, lines, opts...),
 		history:        list.New(),
 		maxHistory:     maxHistory,
 		historyPos:     0,
@@ -40,11 +53,11 @@ func NewHistoryScreen(columns, lines, maxHistory int) *HistoryScreen {
 
 // Override Linefeed to capture scrolling
 func (h *HistoryScreen) Linefeed() {
+	h.cursor.wrapPending = false
 	// Check if at bottom BEFORE incrementing
 	if h.cursor.Y == h.lines-1 {
 		// At bottom, scroll
-		h.addToHistory(0)
-		h.scrollUpInternal()
+		h.scrollUpSaving()
 		// Stay at bottom
 	} else {
 		// Not at bottom, move down
@@ -53,16 +66,16 @@ func (h *HistoryScreen) Linefeed() {
 
 	// In newline mode, also do CR
 	if h.newlineMode {
-		h.cursor.X = 0
+		h.cursor.X = h.lineStart()
 	}
 }
 
 func (h *HistoryScreen) Index() {
+	h.cursor.wrapPending = false
 	// Check if at bottom BEFORE incrementing
 	if h.cursor.Y == h.lines-1 {
 		// At bottom, scroll
-		h.addToHistory(0)
-		h.scrollUpInternal()
+		h.scrollUpSaving()
 		// Stay at bottom
 	} else {
 		// Not at bottom, move down
@@ -70,11 +83,23 @@ func (h *HistoryScreen) Index() {
 	}
 }
 
+// scrollUpSaving scrolls up one line, saving the top line to the
+// scrollback. Between left/right margins nothing is saved (see margins.go).
+func (h *HistoryScreen) scrollUpSaving() {
+	if h.scrollMargins(true) {
+		return
+	}
+	h.addToHistory(0)
+	h.scrollUpInternal()
+	h.compactHistory()
+}
+
 // scrollUpInternal performs the actual scroll without calling parent
 func (h *HistoryScreen) scrollUpInternal() {
 	// Move all lines up by one
 	copy(h.buffer[0:], h.buffer[1:])
 	copy(h.attrs[0:], h.attrs[1:])
+	h.shiftWrappedUp(0, h.lines-1)
 
 	// Clear the last line
 	lastLine := h.lines - 1
@@ -90,18 +115,32 @@ func (h *HistoryScreen) addToHistory(lineNum int) {
 	if lineNum >= 0 && lineNum < h.lines {
 		// Create a copy of the line
 		line := HistoryLine{
-			Chars: make([]rune, h.columns),
-			Attrs: make([]Attributes, h.columns),
+			Chars:   make([]rune, h.columns),
+			styles:  h.styles.intern(h.attrs[lineNum]),
+			Wrapped: h.IsWrapped(lineNum),
+			Meta:    h.lineMeta(lineNum),
 		}
 		copy(line.Chars, h.buffer[lineNum])
-		copy(line.Attrs, h.attrs[lineNum])
+		h.commitLine(lineNum)
+
+		// A repainted progress row supersedes the row it continues
+		collapsed := false
+		if back := h.history.Back(); h.collapseProgress && back != nil &&
+			back.Value.(HistoryLine).Wrapped && h.isRepainted(lineNum) {
+			h.history.Remove(back)
+			collapsed = true
+		}
+		h.lineScrolled(collapsed)
 
 		// Add to history
 		h.history.PushBack(line)
 
 		// Trim history if it exceeds max
 		if h.history.Len() > h.maxHistory {
-			h.history.Remove(h.history.Front())
+			old := h.history.Remove(h.history.Front()).(HistoryLine)
+			if h.spill != nil {
+				h.spill.add(old.Chars, h.styles.attrs(old.styles, len(old.Chars)), old.Wrapped)
+			}
 		}
 	}
 }
@@ -115,7 +154,7 @@ func (h *HistoryScreen) ScrollUp(lines int) {
 	}
 
 	// Calculate how many lines we can actually scroll
-	maxScroll := h.history.Len() - h.historyPos
+	maxScroll := h.GetHistorySize() - h.historyPos
 	if lines > maxScroll {
 		lines = maxScroll
 	}
@@ -164,6 +203,10 @@ func (h *HistoryScreen) saveCurrentScreen() {
 		copy(h.savedBuffer[i], h.buffer[i])
 		copy(h.savedAttrs[i], h.attrs[i])
 	}
+	h.savedWrapped = make([]bool, h.lines)
+	copy(h.savedWrapped, h.wrapped)
+	h.ensureWrapped()
+	h.savedMeta = h.meta
 	h.savedCursor = h.cursor
 }
 
@@ -173,10 +216,14 @@ func (h *HistoryScreen) restoreCurrentScreen() {
 		h.buffer = h.savedBuffer
 		h.attrs = h.savedAttrs
 		h.cursor = h.savedCursor
+		if h.savedWrapped != nil {
+			h.wrapped = h.savedWrapped
+		}
+		h.meta = h.savedMeta
 		h.savedBuffer = nil
 		h.savedAttrs = nil
-		// Restore cursor visibility
-		h.cursor.Hidden = false
+		h.savedWrapped = nil
+		h.savedMeta = nil
 	}
 }
 
@@ -189,34 +236,33 @@ func (h *HistoryScreen) renderHistoryView() {
 			h.attrs[i][j] = Attributes{}
 		}
 	}
+	h.wrapped = make([]bool, h.lines)
+	h.meta = make([]*LineMeta, h.lines)
 
 	// We need to show historyPos lines from the end of history
 	// If historyPos = 1, show the last line of history and rest from saved
 	// If historyPos = history.Len(), show all history that fits
 
-	totalLines := h.history.Len() + h.lines // history + current screen
+	totalLines := h.GetHistorySize() + h.lines // history + current screen
 	startLine := totalLines - h.historyPos - h.lines
 
 	if startLine < 0 {
 		startLine = 0
 	}
 
-	lineIdx := 0
-	elem := h.history.Front()
-
-	// Skip to the start position in history
-	for i := 0; i < startLine && elem != nil; i++ {
-		elem = elem.Next()
-	}
-
 	// Fill from history
-	for elem != nil && lineIdx < h.lines {
-		histLine := elem.Value.(HistoryLine)
+	lineIdx := 0
+	h.eachHistory(h.history, h.spill, startLine, func(histLine HistoryLine, attrs []Attributes) bool {
+		if lineIdx == h.lines {
+			return false
+		}
 		copy(h.buffer[lineIdx], histLine.Chars)
-		copy(h.attrs[lineIdx], histLine.Attrs)
-		elem = elem.Next()
+		copy(h.attrs[lineIdx], attrs)
+		h.wrapped[lineIdx] = histLine.Wrapped
+		h.meta[lineIdx] = histLine.Meta.clone() // History lines are shared with clones
 		lineIdx++
-	}
+		return true
+	})
 
 	// Fill remaining lines from saved buffer
 	if lineIdx < h.lines && h.savedBuffer != nil {
@@ -229,6 +275,12 @@ func (h *HistoryScreen) renderHistoryView() {
 		for i := savedStart; i < h.lines && lineIdx < h.lines; i++ {
 			copy(h.buffer[lineIdx], h.savedBuffer[i])
 			copy(h.attrs[lineIdx], h.savedAttrs[i])
+			if i < len(h.savedWrapped) {
+				h.wrapped[lineIdx] = h.savedWrapped[i]
+			}
+			if i < len(h.savedMeta) {
+				h.meta[lineIdx] = h.savedMeta[i]
+			}
 			lineIdx++
 		}
 	}
@@ -243,33 +295,10 @@ func (h *HistoryScreen) Draw(text string) {
 	if h.viewingHistory {
 		h.ScrollToBottom()
 	}
+	h.noteRepaint()
 
-	// Now draw using embedded NativeScreen's implementation
-	for _, ch := range text {
-		// Check if we need to wrap
-		if h.cursor.X >= h.columns {
-			if h.autoWrap {
-				h.cursor.X = 0
-				// FIX: Check BEFORE incrementing
-				if h.cursor.Y >= h.lines-1 {
-					h.addToHistory(0)
-					h.scrollUpInternal()
-					// Stay at bottom line
-				} else {
-					h.cursor.Y++
-				}
-			} else {
-				h.cursor.X = h.columns - 1
-			}
-		}
-
-		// Place character
-		if h.cursor.Y < h.lines && h.cursor.X < h.columns {
-			h.buffer[h.cursor.Y][h.cursor.X] = ch
-			h.attrs[h.cursor.Y][h.cursor.X] = h.cursor.Attrs
-			h.cursor.X++
-		}
-	}
+	// Lines wrapping off the top go into the history
+	h.drawText(text, h.scrollUpSaving)
 }
 
 // Override EraseInDisplay to handle history clearing
@@ -284,6 +313,7 @@ func (h *HistoryScreen) EraseInDisplay(how int) {
 	// Clear history on full clear (ESC[2J or ESC[3J)
 	if how == 2 || how == 3 {
 		h.history.Init() // Clear the list
+		h.spill.reset()
 		h.historyPos = 0
 	}
 }
@@ -292,18 +322,18 @@ func (h *HistoryScreen) EraseInDisplay(how int) {
 func (h *HistoryScreen) Reset() {
 	h.NativeScreen.Reset()
 	h.history.Init() // Clear history
+	h.styles.reset()
+	h.spill.reset()
 	h.historyPos = 0
 	h.viewingHistory = false
 	h.savedBuffer = nil
 	h.savedAttrs = nil
+	h.savedWrapped = nil
 }
 
 // GetHistorySize returns the current number of lines in history
 func (h *HistoryScreen) GetHistorySize() int {
-	if h.history == nil {
-		return 0
-	}
-	return h.history.Len()
+	return historyLen(h.history) + h.spill.len()
 }
 
 // IsViewingHistory returns true if currently scrolled back in history
@@ -321,11 +351,6 @@ func (h *HistoryScreen) GetCursor() (int, int) {
 	return h.cursor.X, h.cursor.Y
 }
 
-// GetCursorObject returns the cursor object for testing
-func (h *HistoryScreen) GetCursorObject() *Cursor {
-	return &h.cursor
-}
-
 // Resize on HistoryScreen adds policy for scrollback when SHRINKING rows.
 // We preserve the TOP..(newLines-1) region and PUSH cut bottom rows into history.
 // Growing rows delegates to base then pads as usual.
@@ -333,6 +358,7 @@ func (h *HistoryScreen) Resize(newCols, newLines int) {
 	if newCols <= 0 || newLines <= 0 {
 		return
 	}
+	defer h.holdEvents()()
 
 	// If we are viewing history, jump back to live view first.
 	if h.viewingHistory {
@@ -0,0 +1,229 @@
+package gopyte
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+// HTMLOptions configures ExportHTML.
+type HTMLOptions struct {
+	FullPage   bool   // Return a complete page rather than a <pre> fragment
+	Title      string // Title of the full page
+	Scrollback bool   // Include the scrollback above the screen (history screens only)
+}
+
+// ExportHTML renders the screen as a <pre> element with inline styles, for
+// web dashboards and session reports. Colors come from the screen's
+// palette, so OSC 4, 10 and 11 changes show. Bold, italics, underline
+// styles and colors, strikethrough and reverse video are kept; blink is
+// not. Cells drawn inside an OSC 8 hyperlink become <a> elements, except
+// for links with schemes other than http, https, ftp, mailto and file.
+func (s *NativeScreen) ExportHTML(opts HTMLOptions) string {
+	return s.exportHTML(nil, nil, s.screenCells(), opts)
+}
+
+// ExportHTML renders the scrollback, if opts.Scrollback, and the screen as
+// HTML. See NativeScreen.ExportHTML.
+func (h *HistoryScreen) ExportHTML(opts HTMLOptions) string {
+	var history [][]Cell
+	var meta []*LineMeta
+	if opts.Scrollback {
+		history, meta = h.historyCells(), h.historyMeta()
+	}
+	return h.exportHTML(history, meta, h.screenCells(), opts)
+}
+
+// ExportHTML renders the scrollback, if opts.Scrollback, and the screen as
+// HTML. Wide characters are left to the font to draw two columns wide.
+func (w *WideCharScreen) ExportHTML(opts HTMLOptions) string {
+	var history [][]Cell
+	var meta []*LineMeta
+	if opts.Scrollback {
+		history, meta = w.historyCells(), w.historyMeta()
+	}
+	lines := make([][]Cell, w.lines)
+	for y := range lines {
+		lines[y] = w.lineCells(y)
+	}
+	return w.exportHTML(history, meta, lines, opts)
+}
+
+// historyMeta returns the metadata of the scrollback lines, oldest first.
+func (h *HistoryScreen) historyMeta() []*LineMeta {
+	meta := make([]*LineMeta, 0, h.GetHistorySize())
+	h.eachHistory(h.history, h.spill, 0, func(line HistoryLine, _ []Attributes) bool {
+		meta = append(meta, line.Meta)
+		return true
+	})
+	return meta
+}
+
+func (s *NativeScreen) exportHTML(history [][]Cell, historyMeta []*LineMeta, screen [][]Cell, opts HTMLOptions) string {
+	fg := s.Color(ColorForeground, 0).Hex()
+	bg := s.Color(ColorBackground, 0).Hex()
+
+	var b strings.Builder
+	if opts.FullPage {
+		b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+		b.WriteString("<title>" + html.EscapeString(opts.Title) + "</title>\n")
+		b.WriteString("</head>\n<body style=\"margin:0;background-color:" + bg + "\">\n")
+	}
+	b.WriteString("<pre class=\"gopyte\" style=\"color:" + fg + ";background-color:" + bg + "\">")
+	for i, cells := range history {
+		s.writeHTMLLine(&b, cells, historyMeta[i])
+		b.WriteString("\n")
+	}
+	for y, cells := range screen {
+		if y > 0 {
+			b.WriteString("\n")
+		}
+		s.writeHTMLLine(&b, cells, s.lineMeta(y))
+	}
+	b.WriteString("</pre>")
+	if opts.FullPage {
+		b.WriteString("\n</body>\n</html>\n")
+	}
+	return b.String()
+}
+
+// writeHTMLLine writes a line as text in <span> and <a> elements, a new
+// one wherever the style or link changes.
+func (s *NativeScreen) writeHTMLLine(b *strings.Builder, cells []Cell, meta *LineMeta) {
+	end := len(cells)
+	for end > 0 && isDefaultBlank(cells[end-1]) {
+		if _, ok := linkKey.GetCell(meta, end-1); ok {
+			break
+		}
+		end--
+	}
+
+	var link Hyperlink
+	var attrs Attributes
+	style := ""
+	for x := 0; x < end; x++ {
+		c := cells[x]
+		if (c.Width == 0 || c.Char == 0) && x > 0 {
+			continue
+		}
+		l, _ := linkKey.GetCell(meta, x)
+		if !safeLink(l.URI) {
+			l = Hyperlink{}
+		}
+		st := style
+		if x == 0 || c.Attrs != attrs {
+			attrs, st = c.Attrs, s.htmlStyle(c.Attrs)
+		}
+		if l.URI != link.URI || st != style {
+			if style != "" {
+				b.WriteString("</span>")
+			}
+			if l.URI != link.URI {
+				if link.URI != "" {
+					b.WriteString("</a>")
+				}
+				if l.URI != "" {
+					b.WriteString("<a href=\"" + html.EscapeString(l.URI) + "\">")
+				}
+				link = l
+			}
+			if st != "" {
+				b.WriteString("<span style=\"" + st + "\">")
+			}
+			style = st
+		}
+		b.WriteString(html.EscapeString(string(max(c.Char, ' ')) + c.Cluster))
+	}
+	if style != "" {
+		b.WriteString("</span>")
+	}
+	if link.URI != "" {
+		b.WriteString("</a>")
+	}
+}
+
+// htmlStyle returns the CSS for a, or "" for the default attributes.
+func (s *NativeScreen) htmlStyle(a Attributes) string {
+	fg, bg := s.cssColors(a)
+	var css []string
+	if fg != "" {
+		css = append(css, "color:"+fg)
+	}
+	if bg != "" {
+		css = append(css, "background-color:"+bg)
+	}
+	if a.Bold {
+		css = append(css, "font-weight:bold")
+	}
+	if a.Italics {
+		css = append(css, "font-style:italic")
+	}
+	var lines []string
+	if a.Underscore {
+		lines = append(lines, "underline")
+	}
+	if a.Strikethrough {
+		lines = append(lines, "line-through")
+	}
+	if len(lines) > 0 {
+		css = append(css, "text-decoration-line:"+strings.Join(lines, " "))
+	}
+	if a.Underscore {
+		if style, ok := cssUnderlineStyles[a.UnderlineStyle]; ok {
+			css = append(css, "text-decoration-style:"+style)
+		}
+		if c := s.cssColor(a.UnderlineColor); c != "" {
+			css = append(css, "text-decoration-color:"+c)
+		}
+	}
+	return strings.Join(css, ";")
+}
+
+var cssUnderlineStyles = map[UnderlineStyle]string{
+	UnderlineDouble: "double",
+	UnderlineCurly:  "wavy",
+	UnderlineDotted: "dotted",
+	UnderlineDashed: "dashed",
+}
+
+// cssColors returns the text and background colors of a, "" meaning the
+// default, with reverse video applied.
+func (s *NativeScreen) cssColors(a Attributes) (fg, bg string) {
+	fg, bg = s.cssColor(a.Fg), s.cssColor(a.Bg)
+	if a.Reverse {
+		if fg == "" {
+			fg = s.Color(ColorForeground, 0).Hex()
+		}
+		if bg == "" {
+			bg = s.Color(ColorBackground, 0).Hex()
+		}
+		fg, bg = bg, fg
+	}
+	return fg, bg
+}
+
+// cssColor returns c as "#rrggbb" through the screen's palette, or "" for
+// the default color.
+func (s *NativeScreen) cssColor(c Color) string {
+	if n := c.Index(); n >= 0 {
+		return s.Color(ColorPalette, n).Hex()
+	}
+	if rgb, ok := c.RGB(); ok {
+		return rgb.Hex()
+	}
+	return ""
+}
+
+// safeLink reports whether uri may become a link in a page, keeping out
+// javascript: and other schemes a program could use against the viewer.
+func safeLink(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ftp", "mailto", "file":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package gopyte
+
+import (
+	"regexp"
+	"strings"
+)
+
+// InputLine reconstructs the command line being typed at an interactive
+// prompt from what the remote end echoes, so automation can check what is
+// about to run rather than trusting what it sent. Line editing - cursor
+// movement, backspaces, history recall, tab completion - is applied by the
+// remote shell and reaches the screen as redraws, so reading the cursor's
+// line back after the prompt sees its effect:
+//
+//	in := gopyte.NewInputLine(screen, regexp.MustCompile(`^[\w.-]+[>#] ?`))
+//	expecter.Send("shw ver\t") // Completed by the device
+//	... // Once the echo has arrived
+//	if in.GetCurrentInputLine() != "show version" { ... }
+//
+// The line is followed across soft wraps. Devices that scroll a long line
+// sideways instead of wrapping it (Cisco's "$" marker) only show part of
+// it, and so only that part is returned.
+type InputLine struct {
+	screen Screen
+	prompt *regexp.Regexp
+}
+
+// NewInputLine tracks the input line on screen. prompt matches the prompt
+// at the start of the line; it is tried against the whole logical line, so
+// anchor it with ^ and leave the end open. A nil prompt takes the whole
+// line as input.
+func NewInputLine(screen Screen, prompt *regexp.Regexp) *InputLine {
+	return &InputLine{screen: screen, prompt: prompt}
+}
+
+// GetCurrentInputLine returns the text typed after the prompt on the
+// cursor's line, trailing blanks trimmed. It is "" when the cursor is not
+// on a prompt line.
+func (l *InputLine) GetCurrentInputLine() string {
+	text, _, ok := l.read()
+	if !ok {
+		return ""
+	}
+	return strings.TrimRight(text, " ")
+}
+
+// AtPrompt reports whether the cursor is on a line that starts with the
+// prompt.
+func (l *InputLine) AtPrompt() bool {
+	_, _, ok := l.read()
+	return ok
+}
+
+// InputCursor returns the cursor position within the input in characters,
+// 0 being just after the prompt. ok is false when the cursor is not on a
+// prompt line or sits inside the prompt.
+func (l *InputLine) InputCursor() (pos int, ok bool) {
+	_, pos, ok = l.read()
+	return pos, ok && pos >= 0
+}
+
+// read returns the input on the cursor's logical line, untrimmed, and the
+// cursor's offset into it.
+func (l *InputLine) read() (text string, cursor int, ok bool) {
+	src, ok1 := l.screen.(lineCellSource)
+	nav, ok2 := l.screen.(interface {
+		IsWrapped(y int) bool
+		GetCursor() (int, int)
+		WrapPending() bool
+	})
+	if !ok1 || !ok2 {
+		return "", 0, false
+	}
+	cx, cy := nav.GetCursor()
+	if nav.WrapPending() {
+		cx++ // After the character in the last column
+	}
+
+	top := cy
+	for top > 0 && nav.IsWrapped(top-1) {
+		top--
+	}
+
+	var runes []rune
+	cursor = -1
+	for y := top; ; y++ {
+		row := src.lineCells(y)
+		if row == nil {
+			break
+		}
+		for x, cell := range row {
+			if y == cy && x == cx {
+				cursor = len(runes)
+			}
+			if cell.Width != 0 {
+				runes = append(runes, cell.Char)
+				runes = append(runes, []rune(cell.Cluster)...)
+			}
+		}
+		if y == cy && cursor < 0 {
+			cursor = len(runes) // Cursor past the last column
+		}
+		if y >= cy && !nav.IsWrapped(y) {
+			break
+		}
+	}
+
+	line := string(runes)
+	start := 0
+	if l.prompt != nil {
+		loc := l.prompt.FindStringIndex(line)
+		if loc == nil || loc[0] != 0 {
+			return "", 0, false
+		}
+		start = len([]rune(line[:loc[1]]))
+	}
+	return string(runes[start:]), cursor - start, true
+}
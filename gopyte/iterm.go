@@ -0,0 +1,79 @@
+package gopyte
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// AttentionMode is the argument of an iTerm2 RequestAttention sequence.
+type AttentionMode string
+
+const (
+	AttentionNone      AttentionMode = "no" // Cancel a previous request
+	AttentionYes       AttentionMode = "yes"
+	AttentionOnce      AttentionMode = "once"
+	AttentionFireworks AttentionMode = "fireworks"
+)
+
+// ITermListener is implemented by screens that track the iTerm2
+// proprietary OSC 1337 RequestAttention and SetBadgeFormat commands.
+// Like NotificationListener it is optional.
+type ITermListener interface {
+	RequestAttention(mode AttentionMode)
+	SetBadgeFormat(format string)
+}
+
+// dispatchITerm handles the payload of an OSC 1337 sequence
+// ("Key=Value"). Unsupported keys are dropped so they never reach the display.
+func dispatchITerm(listener ITermListener, param string) {
+	kv := strings.SplitN(param, "=", 2)
+	if len(kv) != 2 {
+		return
+	}
+	switch kv[0] {
+	case "RequestAttention":
+		switch mode := AttentionMode(kv[1]); mode {
+		case AttentionNone, AttentionYes, AttentionOnce, AttentionFireworks:
+			listener.RequestAttention(mode)
+		}
+	case "SetBadgeFormat":
+		// The format is base64 encoded; an empty value clears the badge.
+		format, err := base64.StdEncoding.DecodeString(kv[1])
+		if err != nil {
+			return
+		}
+		listener.SetBadgeFormat(string(format))
+	}
+}
+
+// SetAttentionHandler registers a callback invoked whenever the program
+// requests (or cancels) the user's attention. Pass nil to remove it.
+func (s *NativeScreen) SetAttentionHandler(fn func(AttentionMode)) {
+	s.onAttention = fn
+}
+
+// RequestAttention records the attention state and notifies the handler.
+func (s *NativeScreen) RequestAttention(mode AttentionMode) {
+	s.attention = mode
+	if s.onAttention != nil {
+		s.onAttention(mode)
+	}
+}
+
+// Attention returns the most recent attention request, or AttentionNone.
+func (s *NativeScreen) Attention() AttentionMode {
+	if s.attention == "" {
+		return AttentionNone
+	}
+	return s.attention
+}
+
+// SetBadgeFormat stores the decoded badge format string.
+func (s *NativeScreen) SetBadgeFormat(format string) {
+	s.badgeFormat = format
+}
+
+// BadgeFormat returns the current badge format, empty when no badge is set.
+func (s *NativeScreen) BadgeFormat() string {
+	return s.badgeFormat
+}
@@ -0,0 +1,231 @@
+package gopyte
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// Keyboard encoding. EncodeKey turns a key press into the bytes a program
+// expects to read, following xterm: cursor and keypad keys change with
+// the modes the program set - application cursor keys (DECCKM, DECSET 1)
+// and application keypad (DECNKM, DECSET 66, also ESC = and ESC >) - and
+// modifiers on special keys are sent as CSI 1 ; m parameters. Programs
+// that use the kitty keyboard protocol get that encoding instead.
+
+// Mode numbers of the keyboard modes.
+const (
+	CursorKeysMode = 1  // DECCKM
+	KeypadMode     = 66 // DECNKM
+)
+
+// Key is a key on the keyboard. KeyRune stands for any key that types a
+// character; the character is in KeyEvent.Rune.
+type Key int
+
+const (
+	KeyRune Key = iota
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyEscape
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyInsert
+	KeyDelete
+	KeyPageUp
+	KeyPageDown
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyKP0 // Keypad keys, in order KeyKP0 to KeyKP9
+	KeyKP1
+	KeyKP2
+	KeyKP3
+	KeyKP4
+	KeyKP5
+	KeyKP6
+	KeyKP7
+	KeyKP8
+	KeyKP9
+	KeyKPDecimal
+	KeyKPDivide
+	KeyKPMultiply
+	KeyKPMinus
+	KeyKPPlus
+	KeyKPEnter
+	KeyKPEqual
+)
+
+// KeyMod is a set of modifiers held with a key.
+type KeyMod int
+
+const (
+	ModShift KeyMod = 1 << iota
+	ModAlt
+	ModCtrl
+)
+
+// KeyEventType tells presses from repeats and releases. Only the kitty
+// keyboard protocol reports the latter two apart (see kitty_keyboard.go);
+// otherwise a repeat encodes like a press and a release as nothing.
+type KeyEventType int
+
+const (
+	KeyPress KeyEventType = iota
+	KeyRepeat
+	KeyRelease
+)
+
+// KeyEvent is a key press, repeat or release.
+type KeyEvent struct {
+	Key   Key
+	Rune  rune // The character typed, for KeyRune
+	Mods  KeyMod
+	Event KeyEventType
+}
+
+// ApplicationCursorKeys reports whether the program set DECCKM.
+func (s *NativeScreen) ApplicationCursorKeys() bool {
+	return s.appCursorKeys
+}
+
+// ApplicationKeypad reports whether the program set DECNKM (or sent
+// ESC =).
+func (s *NativeScreen) ApplicationKeypad() bool {
+	return s.appKeypad
+}
+
+// Final bytes of the keys sent as CSI 1 ; m X, or SS3 X unmodified.
+var keyFinals = map[Key]byte{
+	KeyUp: 'A', KeyDown: 'B', KeyRight: 'C', KeyLeft: 'D',
+	KeyHome: 'H', KeyEnd: 'F',
+	KeyF1: 'P', KeyF2: 'Q', KeyF3: 'R', KeyF4: 'S',
+}
+
+// Numbers of the keys sent as CSI n ~.
+var keyTildes = map[Key]int{
+	KeyInsert: 2, KeyDelete: 3, KeyPageUp: 5, KeyPageDown: 6,
+	KeyF5: 15, KeyF6: 17, KeyF7: 18, KeyF8: 19,
+	KeyF9: 20, KeyF10: 21, KeyF11: 23, KeyF12: 24,
+}
+
+// Keypad keys: the character sent in numeric mode and the SS3 final in
+// application mode.
+var keypadKeys = map[Key][2]byte{
+	KeyKPDecimal: {'.', 'n'}, KeyKPDivide: {'/', 'o'}, KeyKPMultiply: {'*', 'j'},
+	KeyKPMinus: {'-', 'm'}, KeyKPPlus: {'+', 'k'}, KeyKPEnter: {'\r', 'M'},
+	KeyKPEqual: {'=', 'X'},
+}
+
+// EncodeKey returns the bytes to send to the program for ev, or nil if
+// the key has no encoding. Keyboard enhancement flags set by the program
+// take precedence over the legacy encoding.
+func (s *NativeScreen) EncodeKey(ev KeyEvent) []byte {
+	if flags := s.KeyboardFlags(); flags != 0 {
+		return s.encodeKittyKey(ev, flags)
+	}
+	if ev.Event == KeyRelease {
+		return nil
+	}
+	return s.legacyKey(ev)
+}
+
+// legacyKey encodes ev the way xterm does.
+func (s *NativeScreen) legacyKey(ev KeyEvent) []byte {
+	// xterm's modifier parameter: 1 plus the modifier bits
+	mod := 1 + int(ev.Mods&(ModShift|ModAlt|ModCtrl))
+
+	if final, ok := keyFinals[ev.Key]; ok {
+		switch {
+		case mod > 1:
+			return []byte(CSI + "1;" + strconv.Itoa(mod) + string(final))
+		case ev.Key >= KeyF1 || s.appCursorKeys:
+			return []byte(ESC + "O" + string(final))
+		}
+		return []byte(CSI + string(final))
+	}
+	if n, ok := keyTildes[ev.Key]; ok {
+		if mod > 1 {
+			return []byte(CSI + strconv.Itoa(n) + ";" + strconv.Itoa(mod) + "~")
+		}
+		return []byte(CSI + strconv.Itoa(n) + "~")
+	}
+	if ev.Key >= KeyKP0 && ev.Key <= KeyKPEqual {
+		k, ok := keypadKeys[ev.Key]
+		if !ok {
+			k = [2]byte{byte('0' + ev.Key - KeyKP0), byte('p' + ev.Key - KeyKP0)}
+		}
+		if s.appKeypad {
+			return []byte(ESC + "O" + string(k[1]))
+		}
+		return withAlt([]byte{k[0]}, ev.Mods)
+	}
+
+	switch ev.Key {
+	case KeyEnter:
+		return withAlt([]byte{'\r'}, ev.Mods)
+	case KeyTab:
+		if ev.Mods&ModShift != 0 {
+			return []byte(CSI + "Z")
+		}
+		return withAlt([]byte{'\t'}, ev.Mods)
+	case KeyBackspace:
+		if ev.Mods&ModCtrl != 0 {
+			return withAlt([]byte{0x08}, ev.Mods)
+		}
+		return withAlt([]byte{0x7f}, ev.Mods)
+	case KeyEscape:
+		return withAlt([]byte{0x1b}, ev.Mods)
+	case KeyRune:
+		if ev.Mods&ModCtrl != 0 {
+			if c, ok := ctrlRune(ev.Rune); ok {
+				return withAlt([]byte{c}, ev.Mods)
+			}
+		}
+		if !utf8.ValidRune(ev.Rune) {
+			return nil
+		}
+		return withAlt(utf8.AppendRune(nil, ev.Rune), ev.Mods)
+	}
+	return nil
+}
+
+// withAlt prefixes b with ESC when Alt is held, xterm's metaSendsEscape.
+func withAlt(b []byte, mods KeyMod) []byte {
+	if mods&ModAlt == 0 {
+		return b
+	}
+	return append([]byte{0x1b}, b...)
+}
+
+// ctrlRune returns the control character Ctrl+r types, if there is one.
+func ctrlRune(r rune) (byte, bool) {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return byte(r - 'a' + 1), true
+	case r >= '@' && r <= '_': // Ctrl+@, Ctrl+A to Ctrl+Z, Ctrl+[ \ ] ^ _
+		return byte(r - '@'), true
+	case r == ' ' || r == '2':
+		return 0, true
+	case r >= '3' && r <= '7': // xterm's Ctrl+3 to Ctrl+7: ESC, FS, GS, RS, US
+		return byte(r - '3' + 0x1b), true
+	case r == '8' || r == '?':
+		return 0x7f, true
+	case r == '/':
+		return 0x1f, true
+	}
+	return 0, false
+}
@@ -0,0 +1,171 @@
+package gopyte
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The kitty keyboard protocol: programs push enhancement flags onto a
+// stack (CSI > flags u), pop them (CSI < n u), change the top entry
+// (CSI = flags ; mode u) and query it (CSI ? u). While flags are set,
+// EncodeKey reports keys as CSI code ; modifiers u where the legacy
+// encoding is ambiguous, so that Esc, Ctrl+I and Tab, or Alt+[ and the
+// start of an escape sequence can be told apart. The main and alternate
+// screens have separate stacks.
+
+// Keyboard enhancement flags.
+const (
+	KittyDisambiguate     = 1  // Report ambiguous keys (Esc, Alt/Ctrl+key, keypad) as CSI u
+	KittyReportEvents     = 2  // Report repeats and releases
+	KittyReportAlternates = 4  // Report the shifted key along with the base one
+	KittyReportAllKeys    = 8  // Report every key, text too, as an escape code
+	KittyReportText       = 16 // Report the text a key types, with KittyReportAllKeys
+	kittyAllFlags         = 31
+)
+
+// maxKeyboardStack bounds the flag stack; pushing more drops the oldest
+// entry, as the protocol allows.
+const maxKeyboardStack = 16
+
+// Kitty's key codes for the keypad keys, in Key order from KeyKP0.
+const kittyKP0 = 57399
+
+// KittyKeyboard is implemented by screens that support the kitty keyboard
+// protocol. It is optional; the Stream ignores the CSI u forms for other
+// screens.
+type KittyKeyboard interface {
+	PushKeyboardFlags(flags int)
+	PopKeyboardFlags(count int)
+	SetKeyboardFlags(flags, mode int)
+	ReportKeyboardFlags()
+}
+
+// KeyboardFlags returns the keyboard enhancement flags in effect, 0 for
+// the legacy encoding.
+func (s *NativeScreen) KeyboardFlags() int {
+	if n := len(s.keyboardFlags); n > 0 {
+		return s.keyboardFlags[n-1]
+	}
+	return 0
+}
+
+// PushKeyboardFlags pushes flags onto the stack, making them current.
+func (s *NativeScreen) PushKeyboardFlags(flags int) {
+	if len(s.keyboardFlags) == maxKeyboardStack {
+		s.keyboardFlags = s.keyboardFlags[1:]
+	}
+	s.keyboardFlags = append(s.keyboardFlags, flags&kittyAllFlags)
+}
+
+// PopKeyboardFlags pops count entries off the stack. Popping more than
+// there are empties it.
+func (s *NativeScreen) PopKeyboardFlags(count int) {
+	s.keyboardFlags = s.keyboardFlags[:max(len(s.keyboardFlags)-count, 0)]
+}
+
+// SetKeyboardFlags changes the current flags: mode 1 replaces them with
+// flags, 2 adds flags and 3 removes them.
+func (s *NativeScreen) SetKeyboardFlags(flags, mode int) {
+	flags &= kittyAllFlags
+	cur := s.KeyboardFlags()
+	switch mode {
+	case 1:
+		cur = flags
+	case 2:
+		cur |= flags
+	case 3:
+		cur &^= flags
+	default:
+		return
+	}
+	if len(s.keyboardFlags) == 0 {
+		s.keyboardFlags = append(s.keyboardFlags, cur)
+		return
+	}
+	s.keyboardFlags[len(s.keyboardFlags)-1] = cur
+}
+
+// ReportKeyboardFlags answers CSI ? u with the current flags.
+func (s *NativeScreen) ReportKeyboardFlags() {
+	s.Respond(ResponseKeyboardFlags, CSI+"?"+strconv.Itoa(s.KeyboardFlags())+"u")
+}
+
+// encodeKittyKey encodes ev under the enhancement flags. Keys the flags
+// leave alone get the legacy encoding.
+func (s *NativeScreen) encodeKittyKey(ev KeyEvent, flags int) []byte {
+	all := flags&KittyReportAllKeys != 0
+	events := flags&KittyReportEvents != 0
+	if ev.Event == KeyRelease && !events {
+		return nil
+	}
+
+	code, final := 0, byte('u')
+	switch {
+	case ev.Key == KeyRune:
+		code = int(unicode.ToLower(ev.Rune))
+		if !all && ev.Mods&^ModShift == 0 {
+			return s.legacyPress(ev)
+		}
+	case ev.Key == KeyEnter || ev.Key == KeyTab || ev.Key == KeyBackspace:
+		code = map[Key]int{KeyEnter: 13, KeyTab: 9, KeyBackspace: 127}[ev.Key]
+		if !all && ev.Mods == 0 {
+			return s.legacyPress(ev)
+		}
+	case ev.Key == KeyEscape:
+		code = 27
+	case ev.Key == KeyF3:
+		// CSI 1 ; m R would read as a cursor position report
+		code, final = 13, '~'
+	case ev.Key >= KeyKP0 && ev.Key <= KeyKPEqual:
+		code = kittyKP0 + int(ev.Key-KeyKP0)
+	default:
+		if f, ok := keyFinals[ev.Key]; ok {
+			code, final = 1, f
+		} else if n, ok := keyTildes[ev.Key]; ok {
+			code, final = n, '~'
+		} else {
+			return nil
+		}
+	}
+
+	mods := 1 + int(ev.Mods&(ModShift|ModAlt|ModCtrl))
+	withEvent := events && ev.Event != KeyPress
+	if final != 'u' && final != '~' && mods == 1 && !withEvent && !all {
+		return s.legacyKey(ev)
+	}
+	if ev.Event == KeyRelease && !all && (ev.Key == KeyEnter || ev.Key == KeyTab || ev.Key == KeyBackspace) {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString(CSI)
+	if final == 'u' || final == '~' || mods > 1 || withEvent {
+		b.WriteString(strconv.Itoa(code))
+	}
+	if ev.Key == KeyRune && flags&KittyReportAlternates != 0 && ev.Mods&ModShift != 0 && int(ev.Rune) != code {
+		b.WriteString(":" + strconv.Itoa(int(ev.Rune)))
+	}
+	text := ev.Key == KeyRune && all && flags&KittyReportText != 0 &&
+		ev.Mods&^ModShift == 0 && ev.Event != KeyRelease && unicode.IsPrint(ev.Rune)
+	if mods > 1 || withEvent || text {
+		b.WriteString(";" + strconv.Itoa(mods))
+		if withEvent {
+			b.WriteString(":" + strconv.Itoa(int(ev.Event)+1))
+		}
+	}
+	if text {
+		b.WriteString(";" + strconv.Itoa(int(ev.Rune)))
+	}
+	b.WriteByte(final)
+	return []byte(b.String())
+}
+
+// legacyPress encodes keys the flags leave alone, which report presses
+// and repeats only.
+func (s *NativeScreen) legacyPress(ev KeyEvent) []byte {
+	if ev.Event == KeyRelease {
+		return nil
+	}
+	return s.legacyKey(ev)
+}
@@ -0,0 +1,147 @@
+package gopyte
+
+import "math"
+
+// Limits applied by DefaultParserLimits.
+const (
+	DefaultMaxParams     = 32      // As many as any real sequence needs; xterm keeps 30
+	DefaultMaxParamValue = 9999    // Larger counts and positions mean nothing on a screen
+	DefaultMaxOSCBytes   = 1 << 20 // 1 MiB, room for OSC 52 clipboard data
+	DefaultMaxDCSBytes   = 1 << 20
+)
+
+// maxIntermediates is the most intermediate bytes a sequence may have; no
+// sequence defined uses more than two.
+const maxIntermediates = 4
+
+// maxParamDigits is the most significant digits kept of a parameter, so
+// reading one never overflows.
+const maxParamDigits = 10
+
+// OverflowPolicy says what becomes of a sequence that exceeds a limit.
+type OverflowPolicy int
+
+const (
+	// OverflowDiscard drops the whole sequence, as xterm does.
+	OverflowDiscard OverflowPolicy = iota
+	// OverflowTruncate keeps the parameters or payload that fit and
+	// dispatches the sequence with them.
+	OverflowTruncate
+)
+
+// ParserLimits bounds what the parser keeps of a sequence, so a hostile
+// or corrupted stream cannot make it grow without bound. A limit of zero
+// is no limit.
+type ParserLimits struct {
+	MaxParams     int // CSI and DCS parameters, sub-parameters included
+	MaxParamValue int // Larger parameters are clamped to it (never above math.MaxInt32)
+	MaxOSCBytes   int // OSC payload, command number included
+	MaxDCSBytes   int // DCS data after the final byte
+	Overflow      OverflowPolicy
+}
+
+// DefaultParserLimits returns the limits a new Stream starts with.
+func DefaultParserLimits() ParserLimits {
+	return ParserLimits{
+		MaxParams:     DefaultMaxParams,
+		MaxParamValue: DefaultMaxParamValue,
+		MaxOSCBytes:   DefaultMaxOSCBytes,
+		MaxDCSBytes:   DefaultMaxDCSBytes,
+		Overflow:      OverflowDiscard,
+	}
+}
+
+// SetLimits replaces the parser limits, taking effect from the next byte.
+// Whatever the policy, a sequence with more than four intermediate bytes
+// is always discarded, and a strict stream reports each sequence over a
+// limit as a "limit exceeded" ParseError. Unlike SetWatchdog, which gives
+// up on a sequence and parses what follows as text, limits only bound
+// what is kept: the sequence is still read to its end.
+func (s *Stream) SetLimits(l ParserLimits) {
+	s.limits = l
+}
+
+// Limits returns the parser limits.
+func (s *Stream) Limits() ParserLimits {
+	return s.limits
+}
+
+// sequenceOverflow records how the open sequence went over a limit.
+type sequenceOverflow uint8
+
+const (
+	overflowNone   sequenceOverflow = iota
+	overflowPolicy                  // Over a limit: the policy decides
+	overflowAlways                  // Beyond repair: always discarded
+)
+
+// exceedLimit notes that the open sequence went over a limit.
+func (s *Stream) exceedLimit(o sequenceOverflow) {
+	s.failSequence("limit exceeded")
+	if o > s.overflow {
+		s.overflow = o
+	}
+}
+
+// discardOverflow reports whether the open sequence went over a limit and
+// must not be dispatched.
+func (s *Stream) discardOverflow() bool {
+	return s.overflow == overflowAlways ||
+		s.overflow == overflowPolicy && s.limits.Overflow == OverflowDiscard
+}
+
+// addDigit appends a digit to the parameter being read. Leading zeros
+// are dropped and digits past maxParamDigits ignored; the value is
+// clamped anyway.
+func (s *Stream) addDigit(b byte) {
+	switch {
+	case s.currentParam == "0":
+		s.currentParam = string(b)
+	case len(s.currentParam) < maxParamDigits:
+		s.currentParam += string(b)
+	}
+}
+
+// addIntermediate appends an intermediate byte.
+func (s *Stream) addIntermediate(b string) {
+	if len(s.intermediate) >= maxIntermediates {
+		s.exceedLimit(overflowAlways)
+		return
+	}
+	s.intermediate += b
+}
+
+// paramRoom reports whether one more parameter may be kept.
+func (s *Stream) paramRoom() bool {
+	if s.limits.MaxParams > 0 && len(s.params) >= s.limits.MaxParams {
+		s.exceedLimit(overflowPolicy)
+		return false
+	}
+	return true
+}
+
+// paramValue reads a parameter, clamped to MaxParamValue.
+func (s *Stream) paramValue(p string) int {
+	limit := s.limits.MaxParamValue
+	if limit <= 0 || limit > math.MaxInt32 {
+		limit = math.MaxInt32
+	}
+	var val int64
+	for i := 0; i < len(p); i++ {
+		val = val*10 + int64(p[i]-'0')
+		if val > int64(limit) {
+			return limit
+		}
+	}
+	return int(val)
+}
+
+// payloadRoom reports whether a string payload of n bytes may grow by
+// one under limit.
+func (s *Stream) payloadRoom(n, limit int) bool {
+	if limit > 0 && n >= limit {
+		s.exceedLimit(overflowPolicy)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package gopyte
+
+// Left and right margins: DECLRMM (CSI ? 69 h) enables them and DECSLRM
+// (CSI Pl ; Pr s) sets them. While they exclude part of the screen:
+//
+//   - text wraps at the right margin to the left margin, and CR returns to
+//     the left margin, for a cursor inside them
+//   - scrolling (LF and IND at the bottom, RI at the top, SU, SD) moves only
+//     the columns between the margins and saves nothing to the scrollback
+//   - ICH, DCH, IL, DL, DECIC and DECDC work between the margins and do
+//     nothing for a cursor outside them
+//   - origin mode counts from the left margin
+//
+// As in xterm, the erase functions (ED, EL, ECH) ignore the margins.
+
+// LeftRightMargins is implemented by screens that support DECLRMM and
+// DECSLRM. It is optional. CSI s sets the margins while the mode is on and
+// saves the cursor (SCOSC) otherwise, so screens without it only see the
+// latter.
+type LeftRightMargins interface {
+	LeftRightMarginMode() bool
+	SetLeftRightMargins(left, right int)
+}
+
+// LeftRightMarginMode reports whether DECLRMM is set.
+func (s *NativeScreen) LeftRightMarginMode() bool {
+	return s.lrMarginMode
+}
+
+// SetLeftRightMargins sets the margins to columns left..right, 1-based
+// and inclusive; 0 stands for the screen edge. It does nothing unless
+// DECLRMM is set or when left is not before right. The cursor goes to the
+// origin.
+func (s *NativeScreen) SetLeftRightMargins(left, right int) {
+	if !s.lrMarginMode {
+		return
+	}
+	if left <= 0 {
+		left = 1
+	}
+	if right <= 0 || right > s.columns {
+		right = s.columns
+	}
+	if left >= right {
+		return
+	}
+	s.leftMargin, s.rightMargin = left, right
+	s.CursorPosition(1, 1)
+}
+
+// LeftRightMargins returns the margins, 0-based and inclusive. ok is false
+// when there are none; left and right are then the screen edges.
+func (s *NativeScreen) LeftRightMargins() (left, right int, ok bool) {
+	left, right = 0, s.columns-1
+	if !s.lrMarginMode {
+		return left, right, false
+	}
+	if s.leftMargin > 0 {
+		left = min(s.leftMargin-1, right)
+	}
+	if s.rightMargin > 0 {
+		right = min(s.rightMargin-1, right)
+	}
+	return left, right, left > 0 || right < s.columns-1
+}
+
+// setLeftRightMarginMode sets or resets DECLRMM. Resetting it drops the
+// margins.
+func (s *NativeScreen) setLeftRightMarginMode(on bool) {
+	s.lrMarginMode = on
+	if !on {
+		s.leftMargin, s.rightMargin = 0, 0
+	}
+}
+
+// lineStart returns the column a wrap or CR takes the cursor to: the left
+// margin, unless the cursor is left of it.
+func (s *NativeScreen) lineStart() int {
+	if left, _, ok := s.LeftRightMargins(); ok && s.cursor.X >= left {
+		return left
+	}
+	return 0
+}
+
+// lineEnd returns the column text wraps after: the right margin, unless
+// the cursor is right of it.
+func (s *NativeScreen) lineEnd() int {
+	if _, right, ok := s.LeftRightMargins(); ok && s.cursor.X <= right {
+		return right
+	}
+	return s.columns - 1
+}
+
+// outsideMargins reports whether the cursor is left or right of the
+// margins, where the editing functions do nothing.
+func (s *NativeScreen) outsideMargins() bool {
+	left, right, ok := s.LeftRightMargins()
+	return ok && (s.cursor.X < left || s.cursor.X > right)
+}
+
+// scrollMargins scrolls the columns between the margins one line up or
+// down, leaving the rest of the screen alone. It does nothing and returns
+// false when there are no margins.
+func (s *NativeScreen) scrollMargins(up bool) bool {
+	if _, _, ok := s.LeftRightMargins(); !ok {
+		return false
+	}
+	if up {
+		s.moveMarginRows(0, s.lines-1, -1)
+	} else {
+		s.moveMarginRows(0, s.lines-1, 1)
+	}
+	return true
+}
+
+// moveMarginRows moves the part of rows top..bottom between the margins
+// by one row, down when dir is 1 and up when it is -1, blanking the row
+// left empty.
+func (s *NativeScreen) moveMarginRows(top, bottom, dir int) {
+	left, right, _ := s.LeftRightMargins()
+	from, to, blank := top, bottom, bottom
+	if dir > 0 {
+		from, to, blank = bottom, top, top
+	}
+	widths := len(s.cellWidths) == s.lines
+	for y := from; y != to; y -= dir {
+		copy(s.buffer[y][left:right+1], s.buffer[y-dir][left:right+1])
+		copy(s.attrs[y][left:right+1], s.attrs[y-dir][left:right+1])
+		if widths {
+			copy(s.cellWidths[y][left:right+1], s.cellWidths[y-dir][left:right+1])
+		}
+	}
+	for x := left; x <= right; x++ {
+		s.buffer[blank][x] = ' '
+		s.attrs[blank][x] = DefaultAttributes()
+		if widths {
+			s.cellWidths[blank][x] = 1
+		}
+	}
+	s.clearCellMeta(blank, left, right)
+	if widths {
+		for y := top; y <= bottom; y++ {
+			s.splitWide(y, left)
+			s.splitWide(y, right+1)
+		}
+	}
+}
+
+// splitWide blanks the half on either side of the boundary before column
+// x of row y whose other half moved away with the columns on the other
+// side.
+func (s *NativeScreen) splitWide(y, x int) {
+	if x <= 0 || x >= s.columns {
+		return
+	}
+	widths := s.cellWidths[y]
+	if (widths[x-1] == 2) == (widths[x] == 0) {
+		return
+	}
+	for _, c := range []int{x - 1, x} {
+		if (c < x && widths[c] == 2) || (c == x && widths[c] == 0) {
+			s.buffer[y][c] = ' '
+			s.attrs[y][c] = DefaultAttributes()
+			widths[c] = 1
+		}
+	}
+}
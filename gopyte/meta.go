@@ -0,0 +1,265 @@
+package gopyte
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// Cell and line metadata. Embedders attach their own typed values - a log
+// classifier's severity, a link target, a command boundary - to lines and
+// cells without forking the cell structures:
+//
+//	severity := gopyte.NewMetaKey[Severity]("severity")
+//	severity.Set(screen.LineMeta(y), SevError)
+//	severity.SetCell(screen.LineMeta(y), x, SevError)
+//	...
+//	if sev, ok := severity.Get(screen.HistoryMeta(i)); ok { ... }
+//
+// Metadata belongs to its line: it moves with the line when the screen
+// scrolls or lines are inserted and deleted, goes into the scrollback with
+// it, and is copied by Clone. Erasing a line (EL 2) or any part of the
+// display (ED) drops the metadata of every line touched; erasing part of a
+// line (EL 0/1, ECH) drops the cell metadata in that part, and inserting
+// or deleting characters shifts the cell metadata along.
+// Overwriting a cell with new text does not clear its metadata.
+//
+// Snapshots leave metadata out unless its key is registered with
+// RegisterMetaKey, which gives it a name to be found by on restore.
+
+// MetaKey identifies one kind of metadata and the type of its values.
+// Keys are distinct even when their names are equal.
+type MetaKey[T any] struct {
+	id *metaKeyID
+}
+
+type metaKeyID struct {
+	name string
+}
+
+// NewMetaKey creates a key. name is only used for debugging, and by
+// snapshots once the key is registered.
+func NewMetaKey[T any](name string) MetaKey[T] {
+	return MetaKey[T]{id: &metaKeyID{name: name}}
+}
+
+// Name returns the name the key was created with.
+func (k MetaKey[T]) Name() string {
+	return k.id.name
+}
+
+// Set stores v as line metadata. It does nothing when m is nil.
+func (k MetaKey[T]) Set(m *LineMeta, v T) {
+	if m == nil {
+		return
+	}
+	if m.line == nil {
+		m.line = make(map[*metaKeyID]any)
+	}
+	m.line[k.id] = v
+}
+
+// Get returns the line's value for k.
+func (k MetaKey[T]) Get(m *LineMeta) (v T, ok bool) {
+	if m == nil {
+		return v, false
+	}
+	v, ok = m.line[k.id].(T)
+	return v, ok
+}
+
+// Delete removes the line's value for k.
+func (k MetaKey[T]) Delete(m *LineMeta) {
+	if m != nil {
+		delete(m.line, k.id)
+	}
+}
+
+// SetCell stores v as metadata of cell x. It does nothing when m is nil.
+func (k MetaKey[T]) SetCell(m *LineMeta, x int, v T) {
+	if m == nil || x < 0 {
+		return
+	}
+	if m.cells == nil {
+		m.cells = make(map[int]map[*metaKeyID]any)
+	}
+	if m.cells[x] == nil {
+		m.cells[x] = make(map[*metaKeyID]any)
+	}
+	m.cells[x][k.id] = v
+}
+
+// GetCell returns cell x's value for k.
+func (k MetaKey[T]) GetCell(m *LineMeta, x int) (v T, ok bool) {
+	if m == nil {
+		return v, false
+	}
+	v, ok = m.cells[x][k.id].(T)
+	return v, ok
+}
+
+// DeleteCell removes cell x's value for k.
+func (k MetaKey[T]) DeleteCell(m *LineMeta, x int) {
+	if m == nil || m.cells[x] == nil {
+		return
+	}
+	delete(m.cells[x], k.id)
+	if len(m.cells[x]) == 0 {
+		delete(m.cells, x)
+	}
+}
+
+// metaCodec decodes the snapshot values of a registered key.
+type metaCodec struct {
+	id     *metaKeyID
+	decode func(data json.RawMessage) (any, bool)
+}
+
+var (
+	metaCodecsMu sync.RWMutex
+	metaCodecs   = map[string]metaCodec{}  // By key name
+	metaNames    = map[*metaKeyID]string{} // Registered keys
+)
+
+// RegisterMetaKey makes the values of k part of snapshots, encoded as
+// JSON under k's name. Restoring a snapshot decodes them for the key
+// registered under the same name, so register keys - usually from an
+// init function - in every program that reads the snapshots. Values that
+// do not encode or decode are left out. The hyperlinks of cells are
+// registered as "hyperlink".
+//
+// RegisterMetaKey panics when another key is registered under the name.
+func RegisterMetaKey[T any](k MetaKey[T]) {
+	metaCodecsMu.Lock()
+	defer metaCodecsMu.Unlock()
+	if c, ok := metaCodecs[k.id.name]; ok {
+		if c.id == k.id {
+			return
+		}
+		panic(fmt.Sprintf("gopyte: metadata key %q registered twice", k.id.name))
+	}
+	metaCodecs[k.id.name] = metaCodec{
+		id: k.id,
+		decode: func(data json.RawMessage) (any, bool) {
+			var v T
+			if err := json.Unmarshal(data, &v); err != nil {
+				return nil, false
+			}
+			return v, true
+		},
+	}
+	metaNames[k.id] = k.id.name
+}
+
+// LineMeta holds the metadata of one line and its cells. Read and write
+// it through a MetaKey.
+type LineMeta struct {
+	line  map[*metaKeyID]any
+	cells map[int]map[*metaKeyID]any
+}
+
+// Empty reports whether there is no metadata at all.
+func (m *LineMeta) Empty() bool {
+	return m == nil || (len(m.line) == 0 && len(m.cells) == 0)
+}
+
+func (m *LineMeta) clone() *LineMeta {
+	if m == nil {
+		return nil
+	}
+	c := &LineMeta{line: maps.Clone(m.line)}
+	if m.cells != nil {
+		c.cells = make(map[int]map[*metaKeyID]any, len(m.cells))
+		for x, v := range m.cells {
+			c.cells[x] = maps.Clone(v)
+		}
+	}
+	return c
+}
+
+// clearCells drops the cell metadata of columns from..to inclusive.
+func (m *LineMeta) clearCells(from, to int) {
+	if m == nil {
+		return
+	}
+	for x := range m.cells {
+		if x >= from && x <= to {
+			delete(m.cells, x)
+		}
+	}
+}
+
+// shiftCells moves the cell metadata of columns at..end-1 by n columns
+// (left when negative), as inserting or deleting cells up to a right
+// margin at end-1 does. Cells shifted before at or to end and beyond are
+// dropped; those from end on stay put.
+func (m *LineMeta) shiftCells(at, n, end int) {
+	if m == nil || len(m.cells) == 0 || n == 0 {
+		return
+	}
+	shifted := make(map[int]map[*metaKeyID]any, len(m.cells))
+	for x, v := range m.cells {
+		switch {
+		case x < at || x >= end:
+			shifted[x] = v
+		case x+n >= at && x+n < end:
+			shifted[x+n] = v
+		}
+	}
+	m.cells = shifted
+}
+
+// LineMeta returns the metadata of screen line y, creating it if needed,
+// or nil when y is off the screen.
+func (s *NativeScreen) LineMeta(y int) *LineMeta {
+	s.ensureWrapped()
+	if y < 0 || y >= s.lines {
+		return nil
+	}
+	if s.meta[y] == nil {
+		s.meta[y] = &LineMeta{}
+	}
+	return s.meta[y]
+}
+
+// HistoryMeta returns a copy of the metadata of scrollback line i, 0 being
+// the oldest, or nil when it has none.
+func (h *HistoryScreen) HistoryMeta(i int) *LineMeta {
+	e := historyElement(h.history, i-h.spill.len()) // Spilled lines have none
+	if e == nil {
+		return nil
+	}
+	return e.Value.(HistoryLine).Meta.clone()
+}
+
+// lineMeta returns line y's metadata without creating it.
+func (s *NativeScreen) lineMeta(y int) *LineMeta {
+	if y < 0 || y >= len(s.meta) {
+		return nil
+	}
+	return s.meta[y]
+}
+
+// clearCellMeta drops the cell metadata of columns from..to of line y.
+func (s *NativeScreen) clearCellMeta(y, from, to int) {
+	s.lineMeta(y).clearCells(from, to)
+}
+
+// clearLineMeta drops all metadata of line y.
+func (s *NativeScreen) clearLineMeta(y int) {
+	if y >= 0 && y < len(s.meta) {
+		s.meta[y] = nil
+	}
+}
+
+func cloneMeta(meta []*LineMeta) []*LineMeta {
+	if meta == nil {
+		return nil
+	}
+	c := make([]*LineMeta, len(meta))
+	for i, m := range meta {
+		c[i] = m.clone()
+	}
+	return c
+}
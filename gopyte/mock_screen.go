@@ -56,9 +56,12 @@ func (s *MockScreen) SetMargins(top, bottom int)          { s.log("SetMargins",
 func (s *MockScreen) ReportDeviceAttributes(mode int, priv bool) {
 	s.log("ReportDeviceAttributes", mode, priv)
 }
-func (s *MockScreen) ReportDeviceStatus(mode int)   { s.log("ReportDeviceStatus", mode) }
-func (s *MockScreen) SetTitle(title string)         { s.log("SetTitle", title) }
-func (s *MockScreen) SetIconName(name string)       { s.log("SetIconName", name) }
-func (s *MockScreen) AlignmentDisplay()             { s.log("AlignmentDisplay") }
-func (s *MockScreen) Debug(args ...interface{})     { s.log("Debug", args...) }
-func (s *MockScreen) WriteProcessInput(data string) { s.log("WriteProcessInput", data) }
+func (s *MockScreen) ReportDeviceStatus(mode int)         { s.log("ReportDeviceStatus", mode) }
+func (s *MockScreen) SetTitle(title string)               { s.log("SetTitle", title) }
+func (s *MockScreen) SetIconName(name string)             { s.log("SetIconName", name) }
+func (s *MockScreen) AlignmentDisplay()                   { s.log("AlignmentDisplay") }
+func (s *MockScreen) Debug(args ...interface{})           { s.log("Debug", args...) }
+func (s *MockScreen) WriteProcessInput(data string)       { s.log("WriteProcessInput", data) }
+func (s *MockScreen) Notify(n Notification)               { s.log("Notify", n.Title, n.Body) }
+func (s *MockScreen) RequestAttention(mode AttentionMode) { s.log("RequestAttention", mode) }
+func (s *MockScreen) SetBadgeFormat(format string)        { s.log("SetBadgeFormat", format) }
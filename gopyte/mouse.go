@@ -0,0 +1,69 @@
+package gopyte
+
+// Mouse reporting modes. A program asks for mouse events with one of the
+// tracking modes (DECSET 9, 1000, 1002, 1003) and picks how coordinates
+// are encoded with another (1005, 1006, 1015, 1016). The screen only
+// records the choice; the embedding UI reads it with MouseProtocol to
+// decide whether to forward mouse events and how to encode them. As in
+// xterm, setting a tracking mode or an encoding replaces the previous one,
+// and resetting one that is not in effect does nothing.
+
+// MouseTracking is the mouse tracking mode. The values are the DECSET
+// mode numbers.
+type MouseTracking int
+
+const (
+	MouseTrackingNone   MouseTracking = 0
+	MouseTrackingX10    MouseTracking = 9    // Button presses only
+	MouseTrackingNormal MouseTracking = 1000 // Presses and releases
+	MouseTrackingButton MouseTracking = 1002 // Also motion while a button is down
+	MouseTrackingAny    MouseTracking = 1003 // All motion
+)
+
+// MouseEncoding is how mouse reports encode the event. The values are the
+// DECSET mode numbers.
+type MouseEncoding int
+
+const (
+	MouseEncodingX10       MouseEncoding = 0    // CSI M Cb Cx Cy, single bytes
+	MouseEncodingUTF8      MouseEncoding = 1005 // CSI M with UTF-8 coordinates
+	MouseEncodingSGR       MouseEncoding = 1006 // CSI < b ; x ; y M/m
+	MouseEncodingURXVT     MouseEncoding = 1015 // CSI b ; x ; y M
+	MouseEncodingSGRPixels MouseEncoding = 1016 // As SGR, in pixels
+)
+
+// MouseProtocol is the mouse reporting the program asked for.
+type MouseProtocol struct {
+	Tracking MouseTracking
+	Encoding MouseEncoding
+}
+
+// Enabled reports whether mouse events should be sent to the program.
+func (p MouseProtocol) Enabled() bool {
+	return p.Tracking != MouseTrackingNone
+}
+
+// MouseProtocol returns the mouse reporting the program asked for.
+func (s *NativeScreen) MouseProtocol() MouseProtocol {
+	return s.mouse
+}
+
+// setMouseMode sets or resets one of the mouse DECSET modes.
+func (s *NativeScreen) setMouseMode(mode int, on bool) {
+	switch mode {
+	case 9, 1000, 1002, 1003:
+		switch t := MouseTracking(mode); {
+		case on:
+			s.mouse.Tracking = t
+		case s.mouse.Tracking == t:
+			s.mouse.Tracking = MouseTrackingNone
+		}
+	case 1005, 1006, 1015, 1016:
+		switch e := MouseEncoding(mode); {
+		case on:
+			s.mouse.Encoding = e
+		case s.mouse.Encoding == e:
+			s.mouse.Encoding = MouseEncodingX10
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package gopyte
+
+import "strings"
+
+// Notification is a desktop notification requested by the running program
+// via OSC 9 (iTerm2) or OSC 777;notify (urxvt).
+type Notification struct {
+	Title  string // Empty for OSC 9, which only carries a message
+	Body   string
+	Source string // "osc9" or "osc777"
+}
+
+// maxPendingNotifications bounds the queue kept for hosts that never poll.
+const maxPendingNotifications = 64
+
+// NotificationListener is implemented by screens that want to receive
+// notification events. It is optional: Stream only calls Notify when the
+// listener implements it, so existing Screen implementations keep working.
+type NotificationListener interface {
+	Notify(n Notification)
+}
+
+// parseNotification decodes the payload of an OSC 9 or OSC 777 sequence.
+// The second return value is false when the payload is not a notification
+// (e.g. OSC 777 with a command other than "notify", or the ConEmu progress
+// report OSC 9;4).
+func parseNotification(code, param string) (Notification, bool) {
+	switch code {
+	case "9":
+		if param == "" || isProgressReport(param) {
+			return Notification{}, false
+		}
+		return Notification{Body: param, Source: "osc9"}, true
+	case "777":
+		// OSC 777 ; notify ; title ; body
+		parts := strings.SplitN(param, ";", 3)
+		if len(parts) < 2 || parts[0] != "notify" {
+			return Notification{}, false
+		}
+		n := Notification{Title: parts[1], Source: "osc777"}
+		if len(parts) == 3 {
+			n.Body = parts[2]
+		}
+		return n, true
+	}
+	return Notification{}, false
+}
+
+// isProgressReport reports whether an OSC 9 payload is the ConEmu progress
+// subcommand, 4 ; state ; percent, which shells and build tools send to
+// drive a taskbar progress bar.
+func isProgressReport(param string) bool {
+	return param == "4" || strings.HasPrefix(param, "4;")
+}
+
+// SetNotificationHandler registers a callback invoked for every desktop
+// notification received by the screen. Pass nil to remove it.
+func (s *NativeScreen) SetNotificationHandler(fn func(Notification)) {
+	s.onNotification = fn
+}
+
+// Notify records a notification and forwards it to the registered handler.
+func (s *NativeScreen) Notify(n Notification) {
+	s.notifications = append(s.notifications, n)
+	if len(s.notifications) > maxPendingNotifications {
+		s.notifications = s.notifications[1:]
+	}
+	if s.onNotification != nil {
+		s.onNotification(n)
+	}
+}
+
+// Notifications returns and clears the notifications received since the
+// last call, for hosts that poll instead of registering a handler.
+func (s *NativeScreen) Notifications() []Notification {
+	pending := s.notifications
+	s.notifications = nil
+	return pending
+}
@@ -0,0 +1,28 @@
+package gopyte
+
+// Origin mode (DECOM, CSI ? 6 h). With it set, the absolute positioning
+// sequences - CUP, HVP, VPA, CHA, HPA - count from the top left corner of
+// the margins instead of the screen and cannot leave them, and cursor
+// position reports count the same way.
+
+// originBounds returns the inclusive area absolute positions refer to: the
+// margins in origin mode, else the whole screen.
+func (s *NativeScreen) originBounds() (top, bottom, left, right int) {
+	top, bottom, left, right = 0, s.lines-1, 0, s.columns-1
+	if s.originMode {
+		left, right, _ = s.LeftRightMargins()
+	}
+	return top, bottom, left, right
+}
+
+// OriginMode reports whether origin mode (DECOM) is set.
+func (s *NativeScreen) OriginMode() bool {
+	return s.originMode
+}
+
+// setOriginMode sets or resets DECOM. Either way the cursor goes to the
+// new origin.
+func (s *NativeScreen) setOriginMode(on bool) {
+	s.originMode = on
+	s.CursorPosition(1, 1)
+}
@@ -0,0 +1,278 @@
+package gopyte
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Operating system commands (OSC, ESC ] Ps ; Pt followed by BEL or ST).
+// Stream collects the payload, across Feed calls when it is split, and
+// hands the commands it knows to typed listeners: titles to the Screen
+// itself, colors to a ColorListener, hyperlinks to a HyperlinkListener,
+// the clipboard to a ClipboardListener (see clipboard.go), notifications
+// and iTerm2 commands to theirs.
+
+// ColorTarget is the color an OSC color command refers to.
+type ColorTarget int
+
+const (
+	ColorPalette    ColorTarget = iota // OSC 4 and 104, an indexed color
+	ColorForeground                    // OSC 10 and 110
+	ColorBackground                    // OSC 11 and 111
+	ColorCursor                        // OSC 12 and 112
+)
+
+// ColorChange is one color set, query or reset.
+type ColorChange struct {
+	Target ColorTarget
+	Index  int    // Palette index, or -1 when OSC 104 resets the whole palette
+	Spec   string // Color spec such as "rgb:ff/00/00", "?" to query, "" to reset
+}
+
+// ColorListener is implemented by screens that track colors set by the
+// program. It is optional: Stream ignores OSC color commands for screens
+// without it.
+type ColorListener interface {
+	SetColor(c ColorChange)
+}
+
+// Hyperlink is an OSC 8 hyperlink. Text drawn while it is set belongs to
+// the link; an empty URI ends it.
+type Hyperlink struct {
+	ID  string `json:"id,omitempty"` // Optional id= parameter, joining separately drawn parts
+	URI string `json:"uri"`
+}
+
+// HyperlinkListener is implemented by screens that track hyperlinks. It is
+// optional: Stream ignores OSC 8 for screens without it.
+type HyperlinkListener interface {
+	SetHyperlink(link Hyperlink)
+}
+
+// SetColorHandler registers a callback invoked for every color change,
+// after it has been applied (see palette.go). Pass nil to remove it.
+func (s *NativeScreen) SetColorHandler(fn func(ColorChange)) {
+	s.onColor = fn
+}
+
+// SetHyperlinkHandler registers a callback invoked whenever a hyperlink
+// starts or ends. Pass nil to remove it.
+func (s *NativeScreen) SetHyperlinkHandler(fn func(Hyperlink)) {
+	s.onHyperlink = fn
+}
+
+// Hyperlink returns the hyperlink text is currently drawn with, if any.
+func (s *NativeScreen) Hyperlink() (Hyperlink, bool) {
+	return s.hyperlink, s.hyperlink.URI != ""
+}
+
+// SetHyperlink starts link, or ends the current one when its URI is empty.
+func (s *NativeScreen) SetHyperlink(link Hyperlink) {
+	s.hyperlink = link
+	if s.onHyperlink != nil {
+		s.onHyperlink(link)
+	}
+}
+
+// linkKey holds, as cell metadata, the hyperlink each cell was drawn with,
+// so that links move with their text and go into the scrollback with it,
+// and into snapshots.
+var linkKey = NewMetaKey[Hyperlink]("hyperlink")
+
+func init() {
+	RegisterMetaKey(linkKey)
+}
+
+// CellHyperlink returns the hyperlink the cell at line y, column x was
+// drawn with, if any.
+func (s *NativeScreen) CellHyperlink(y, x int) (Hyperlink, bool) {
+	return linkKey.GetCell(s.lineMeta(y), x)
+}
+
+// markLink records the current hyperlink for a cell just drawn. Unlike
+// other metadata it is dropped when the cell is overwritten without one.
+func (s *NativeScreen) markLink(y, x int) {
+	if s.hyperlink.URI != "" {
+		linkKey.SetCell(s.LineMeta(y), x, s.hyperlink)
+	} else {
+		linkKey.DeleteCell(s.lineMeta(y), x)
+	}
+}
+
+// markLinks is markLink for columns from..to of line y. Lines without
+// cell metadata, the usual case, need nothing when no link is open.
+func (s *NativeScreen) markLinks(y, from, to int) {
+	if s.hyperlink.URI == "" {
+		if m := s.lineMeta(y); m == nil || len(m.cells) == 0 {
+			return
+		}
+	}
+	for x := from; x <= to; x++ {
+		s.markLink(y, x)
+	}
+}
+
+// startOSC begins an OSC string.
+func (s *Stream) startOSC() {
+	s.state = StateOSC
+	s.oscParam = s.oscParam[:0]
+	s.oscEscape = false
+	s.overflow = overflowNone
+}
+
+// oscByte handles one byte of an OSC string and reports whether the byte
+// must be parsed again in the state the string was left for.
+func (s *Stream) oscByte(b byte) bool {
+	if s.oscEscape {
+		s.oscEscape = false
+		if b == '\\' {
+			s.dispatchOSC()
+			s.state = StateGround
+			return false
+		}
+		// Any other escape sequence cancels the string
+		s.failSequence("sequence interrupted")
+		s.state = StateEscape
+		s.tapKind = SeqEscape
+		return true
+	}
+
+	switch {
+	case b == BEL[0]:
+		s.dispatchOSC()
+		s.state = StateGround
+	case b == ST_C1[0] && isUTF8Complete(s.oscParam):
+		// Otherwise 0x9c continues a UTF-8 rune in a title
+		s.dispatchOSC()
+		s.state = StateGround
+	case b == ESC[0]:
+		s.oscEscape = true
+	case b == CAN[0] || b == SUB[0]:
+		s.failSequence("sequence cancelled")
+		s.state = StateGround
+	default:
+		if s.payloadRoom(len(s.oscParam), s.limits.MaxOSCBytes) {
+			s.oscParam = append(s.oscParam, b)
+		}
+	}
+	return false
+}
+
+// dispatchOSC handles a completed OSC string collected in oscParam.
+func (s *Stream) dispatchOSC() {
+	if s.discardOverflow() {
+		return
+	}
+	if len(s.oscParam) == 0 {
+		s.failSequence("malformed OSC string")
+		return
+	}
+	code, param, ok := strings.Cut(string(s.oscParam), ";")
+	s.tapName = code
+	if n, err := strconv.Atoi(code); err == nil {
+		if handler, found := s.customOSC[n]; found {
+			handler(param)
+			return
+		}
+	}
+	// Only the color resets may come without a payload
+	if !ok && code != "104" && code != "110" && code != "111" && code != "112" {
+		s.failSequence("malformed OSC string")
+		return
+	}
+
+	switch code {
+	case "0": // Icon name and window title
+		s.listener.SetIconName(param)
+		s.listener.SetTitle(param)
+	case "1":
+		s.listener.SetIconName(param)
+	case "2":
+		s.listener.SetTitle(param)
+	case "4", "10", "11", "12", "104", "110", "111", "112":
+		if cl, ok := s.listener.(ColorListener); ok {
+			for _, c := range parseColorChanges(code, param) {
+				cl.SetColor(c)
+			}
+		}
+	case "8":
+		if hl, ok := s.listener.(HyperlinkListener); ok {
+			if link, ok := parseHyperlink(param); ok {
+				hl.SetHyperlink(link)
+			}
+		}
+	case "52":
+		if cl, ok := s.listener.(ClipboardListener); ok {
+			if r, ok := parseClipboard(param); ok {
+				cl.Clipboard(r)
+			}
+		}
+	case "9", "777":
+		if nl, ok := s.listener.(NotificationListener); ok {
+			if n, ok := parseNotification(code, param); ok {
+				nl.Notify(n)
+			}
+		}
+	case "1337":
+		if il, ok := s.listener.(ITermListener); ok {
+			dispatchITerm(il, param)
+		}
+	default:
+		s.failSequence("unsupported OSC command")
+	}
+}
+
+// parseColorChanges decodes the payload of the OSC color commands:
+// 4 ; index ; spec ..., 10 ; spec ... (later specs moving on to 11 and
+// 12), 104 [; index ...] and 110 to 112.
+func parseColorChanges(code, param string) []ColorChange {
+	var changes []ColorChange
+	switch code {
+	case "4":
+		fields := strings.Split(param, ";")
+		for i := 0; i+1 < len(fields); i += 2 {
+			if n, err := strconv.Atoi(fields[i]); err == nil && n >= 0 {
+				changes = append(changes, ColorChange{Target: ColorPalette, Index: n, Spec: fields[i+1]})
+			}
+		}
+	case "10", "11", "12":
+		target := ColorForeground + ColorTarget(code[1]-'0')
+		for _, spec := range strings.Split(param, ";") {
+			if target > ColorCursor {
+				break
+			}
+			if spec != "" {
+				changes = append(changes, ColorChange{Target: target, Spec: spec})
+			}
+			target++
+		}
+	case "104":
+		if param == "" {
+			return []ColorChange{{Target: ColorPalette, Index: -1}}
+		}
+		for _, f := range strings.Split(param, ";") {
+			if n, err := strconv.Atoi(f); err == nil && n >= 0 {
+				changes = append(changes, ColorChange{Target: ColorPalette, Index: n})
+			}
+		}
+	case "110", "111", "112":
+		changes = append(changes, ColorChange{Target: ColorForeground + ColorTarget(code[2]-'0')})
+	}
+	return changes
+}
+
+// parseHyperlink decodes the payload of OSC 8: params ; URI, where params
+// are colon separated key=value pairs.
+func parseHyperlink(param string) (Hyperlink, bool) {
+	params, uri, ok := strings.Cut(param, ";")
+	if !ok {
+		return Hyperlink{}, false
+	}
+	link := Hyperlink{URI: uri}
+	for _, kv := range strings.Split(params, ":") {
+		if id, ok := strings.CutPrefix(kv, "id="); ok {
+			link.ID = id
+		}
+	}
+	return link, true
+}
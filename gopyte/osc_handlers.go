@@ -0,0 +1,23 @@
+package gopyte
+
+// OSCHandler implements an application-defined OSC command. param is the
+// payload after the command number and its ";", or "" when there was
+// none, undecoded.
+type OSCHandler func(param string)
+
+// RegisterOSCHandler installs handler for OSC commands numbered code, so
+// vendor sequences such as shell integration (OSC 133) or OSC 7 working
+// directory reports reach the application. Like RegisterCSIHandler,
+// registered handlers take precedence over the built-in ones and a nil
+// handler removes a previous registration. Commands with no handler are
+// handled as before, or dropped.
+func (s *Stream) RegisterOSCHandler(code int, handler OSCHandler) {
+	if handler == nil {
+		delete(s.customOSC, code)
+		return
+	}
+	if s.customOSC == nil {
+		s.customOSC = make(map[int]OSCHandler)
+	}
+	s.customOSC[code] = handler
+}
@@ -0,0 +1,202 @@
+package gopyte
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dynamic colors. Programs change the 256-color palette with OSC 4 and
+// the default foreground, background and cursor colors with OSC 10 to 12,
+// restore them with OSC 104 and 110 to 112, and query them with a "?"
+// spec - to pick a light or dark theme, say. The screen tracks the
+// changes over base colors that start as xterm's and that the embedding
+// application can set to its own theme with SetBaseColor.
+
+// RGB is a 24-bit color.
+type RGB struct {
+	R, G, B uint8
+}
+
+// Hex returns the color as "#rrggbb".
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// MarshalText encodes the color as "#rrggbb".
+func (c RGB) MarshalText() ([]byte, error) {
+	return []byte(c.Hex()), nil
+}
+
+// UnmarshalText parses a color spec (see ParseColorSpec).
+func (c *RGB) UnmarshalText(text []byte) error {
+	v, ok := ParseColorSpec(string(text))
+	if !ok {
+		return fmt.Errorf("gopyte: invalid color %q", text)
+	}
+	*c = v
+	return nil
+}
+
+func (c RGB) array() [3]uint8 {
+	return [3]uint8{c.R, c.G, c.B}
+}
+
+// xColor returns the color in the X11 form used in OSC color
+// reports, "rgb:rrrr/gggg/bbbb".
+func (c RGB) xColor() string {
+	return fmt.Sprintf("rgb:%04x/%04x/%04x", int(c.R)*257, int(c.G)*257, int(c.B)*257)
+}
+
+// ParseColorSpec parses the X11 color specs programs send in OSC color
+// commands: "rgb:r/g/b" with one to four hex digits per channel, and
+// "#rgb" to "#rrrrggggbbbb", where the digits are the high bits of each
+// channel.
+func ParseColorSpec(spec string) (RGB, bool) {
+	var c [3]uint8
+	if rest, ok := strings.CutPrefix(spec, "rgb:"); ok {
+		parts := strings.Split(rest, "/")
+		if len(parts) != 3 {
+			return RGB{}, false
+		}
+		for i, p := range parts {
+			if len(p) < 1 || len(p) > 4 {
+				return RGB{}, false
+			}
+			v, err := strconv.ParseUint(p, 16, 16)
+			if err != nil {
+				return RGB{}, false
+			}
+			scale := uint64(1)<<(4*len(p)) - 1
+			c[i] = uint8((v*255 + scale/2) / scale)
+		}
+		return RGB{c[0], c[1], c[2]}, true
+	}
+
+	hex, ok := strings.CutPrefix(spec, "#")
+	if !ok || len(hex) < 3 || len(hex) > 12 || len(hex)%3 != 0 {
+		return RGB{}, false
+	}
+	n := len(hex) / 3
+	for i := range c {
+		v, err := strconv.ParseUint(hex[i*n:(i+1)*n], 16, 16)
+		if err != nil {
+			return RGB{}, false
+		}
+		if n == 1 {
+			v <<= 4
+		} else {
+			v >>= 4 * (n - 2)
+		}
+		c[i] = uint8(v)
+	}
+	return RGB{c[0], c[1], c[2]}, true
+}
+
+// Color slots: the palette entries, then the dynamic colors.
+const (
+	slotForeground = 256 + iota
+	slotBackground
+	slotCursor
+)
+
+// colorSlot maps a target and palette index to a slot.
+func colorSlot(target ColorTarget, index int) (int, bool) {
+	switch target {
+	case ColorPalette:
+		return index, index >= 0 && index < 256
+	case ColorForeground:
+		return slotForeground, true
+	case ColorBackground:
+		return slotBackground, true
+	case ColorCursor:
+		return slotCursor, true
+	}
+	return 0, false
+}
+
+// defaultColor is xterm's color for a slot, with a light gray on black
+// default.
+func defaultColor(slot int) RGB {
+	switch slot {
+	case slotForeground, slotCursor:
+		slot = 7
+	case slotBackground:
+		slot = 0
+	}
+	rgb := paletteRGB(slot)
+	return RGB{rgb[0], rgb[1], rgb[2]}
+}
+
+// Color returns the current value of a palette entry (ColorPalette with
+// index 0-255) or of a dynamic color (index is ignored).
+func (s *NativeScreen) Color(target ColorTarget, index int) RGB {
+	slot, ok := colorSlot(target, index)
+	if !ok {
+		return RGB{}
+	}
+	if c, ok := s.colors[slot]; ok {
+		return c
+	}
+	return s.baseColor(slot)
+}
+
+// SetBaseColor sets the color a palette entry or dynamic color has until
+// the program changes it, and returns to when the program resets it.
+func (s *NativeScreen) SetBaseColor(target ColorTarget, index int, c RGB) {
+	slot, ok := colorSlot(target, index)
+	if !ok {
+		return
+	}
+	if s.baseColors == nil {
+		s.baseColors = make(map[int]RGB)
+	}
+	s.baseColors[slot] = c
+}
+
+func (s *NativeScreen) baseColor(slot int) RGB {
+	if c, ok := s.baseColors[slot]; ok {
+		return c
+	}
+	return defaultColor(slot)
+}
+
+// SetColor applies an OSC color command: it changes or resets the color,
+// or answers a query through Respond, and then passes c to the handler.
+func (s *NativeScreen) SetColor(c ColorChange) {
+	slot, ok := colorSlot(c.Target, c.Index)
+	switch {
+	case c.Target == ColorPalette && c.Index == -1 && c.Spec == "":
+		for slot := range s.colors {
+			if slot < 256 {
+				delete(s.colors, slot)
+			}
+		}
+	case !ok:
+		return
+	case c.Spec == "?":
+		s.Respond(ResponseOSCQuery, colorReport(c, s.Color(c.Target, c.Index)))
+	case c.Spec == "":
+		delete(s.colors, slot)
+	default:
+		rgb, ok := ParseColorSpec(c.Spec)
+		if !ok {
+			return
+		}
+		if s.colors == nil {
+			s.colors = make(map[int]RGB)
+		}
+		s.colors[slot] = rgb
+	}
+	if s.onColor != nil {
+		s.onColor(c)
+	}
+}
+
+// colorReport is the answer to a color query, in the form xterm uses.
+func colorReport(c ColorChange, rgb RGB) string {
+	if c.Target == ColorPalette {
+		return fmt.Sprintf("%s4;%d;%s%s", OSC, c.Index, rgb.xColor(), ST)
+	}
+	return fmt.Sprintf("%s%d;%s%s", OSC, 10+int(c.Target-ColorForeground), rgb.xColor(), ST)
+}
@@ -0,0 +1,61 @@
+package gopyte
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Bracketed paste (DECSET 2004). With it set, pasted text reaches the
+// program between ESC [ 200 ~ and ESC [ 201 ~ so that an editor or shell
+// can tell it from typing and won't run each pasted line. Either way
+// EncodePaste strips control characters other than tab and newline from
+// the text, so a paste can neither end the bracket early nor smuggle in
+// escape sequences or keystrokes such as ^C.
+
+// BracketedPasteMode is the private mode number of bracketed paste.
+const BracketedPasteMode = 2004
+
+const (
+	pasteStart = CSI + "200~"
+	pasteEnd   = CSI + "201~"
+)
+
+// BracketedPaste reports whether the program enabled bracketed paste.
+func (s *NativeScreen) BracketedPaste() bool {
+	return s.bracketedPaste
+}
+
+// EncodePaste returns the bytes to send to the program when text is
+// pasted: the sanitized text, with newlines as CR like the Enter key,
+// bracketed if the program asked for it.
+func (s *NativeScreen) EncodePaste(text string) []byte {
+	text = sanitizePaste(text)
+	if s.bracketedPaste {
+		text = pasteStart + text + pasteEnd
+	}
+	return []byte(text)
+}
+
+// sanitizePaste turns CR LF and LF into CR and drops every other control
+// character but tab, along with invalid UTF-8.
+func sanitizePaste(text string) string {
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "")
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\r")
+	var sb strings.Builder
+	sb.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case r == '\n':
+			sb.WriteByte('\r')
+		case r == '\r' || r == '\t':
+			sb.WriteRune(r)
+		case unicode.IsControl(r):
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
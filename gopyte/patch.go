@@ -0,0 +1,350 @@
+package gopyte
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Patches carry a screen to a remote renderer - a browser, say - as the
+// changes between snapshots rather than a whole screen each time:
+//
+//	prev := screen.Snapshot()
+//	send(Diff(nil, prev)) // The first patch is the whole state
+//	for range ticker.C {
+//		next := screen.Snapshot()
+//		if p := Diff(prev, next); !p.Empty() {
+//			send(p)
+//		}
+//		prev = next
+//	}
+//
+// The client keeps a Snapshot and applies each patch to it in order.
+
+// Patch is the difference between two snapshots. It is applied in field
+// order: state, scroll, lines, cursor, scrollback.
+type Patch struct {
+	// Full replaces the whole state. It is sent for the first snapshot,
+	// a resize, and entering or leaving the alternate screen; the other
+	// fields are then empty.
+	Full *Snapshot `json:"full,omitempty"`
+
+	// State replaces everything but the text, cursor position and
+	// scrollback - modes, title, colors, tab stops and so on - when any
+	// of it changed.
+	State *Snapshot `json:"state,omitempty"`
+
+	// Scroll moves the lines of the screen up, blank lines coming in at
+	// the bottom, so that a scrolling screen costs the new lines only.
+	Scroll int `json:"scroll,omitempty"`
+
+	// Lines replaces spans of the screen's lines.
+	Lines []LineUpdate `json:"lines,omitempty"`
+
+	// Cursor is the new cursor, if it changed.
+	Cursor *SnapshotCursor `json:"cursor,omitempty"`
+
+	// ScrollbackDrop lines go from the top of the scrollback, and
+	// Scrollback is appended to it.
+	ScrollbackDrop int            `json:"scrollback_drop,omitempty"`
+	Scrollback     []SnapshotLine `json:"scrollback,omitempty"`
+}
+
+// LineUpdate replaces the cells of line Y from column X on with the cells
+// of the line. A span never starts or ends in the middle of a wide
+// character.
+type LineUpdate struct {
+	Y int `json:"y"`
+	X int `json:"x"`
+	SnapshotLine
+}
+
+// Empty reports whether the patch changes nothing.
+func (p *Patch) Empty() bool {
+	return p.Full == nil && p.State == nil && p.Scroll == 0 && len(p.Lines) == 0 &&
+		p.Cursor == nil && p.ScrollbackDrop == 0 && len(p.Scrollback) == 0
+}
+
+// Diff returns the patch that turns prev into next. A nil prev gives a
+// patch carrying next whole.
+func Diff(prev, next *Snapshot) *Patch {
+	if prev == nil || prev.Columns != next.Columns || prev.Lines != next.Lines ||
+		!reflect.DeepEqual(prev.Main, next.Main) {
+		return &Patch{Full: next}
+	}
+
+	p := &Patch{}
+	if state := next.state(); !reflect.DeepEqual(prev.state(), state) {
+		p.State = state
+	}
+
+	lines := prev.Screen.Lines
+	p.Scroll = scrollOffset(lines, next.Screen.Lines)
+	if p.Scroll > 0 {
+		lines = scrollLines(lines, p.Scroll, next.Columns)
+	}
+	for y, line := range next.Screen.Lines {
+		if y >= len(lines) {
+			p.Lines = append(p.Lines, LineUpdate{Y: y, SnapshotLine: line})
+			continue
+		}
+		if u, ok := diffLine(y, lines[y], line); ok {
+			p.Lines = append(p.Lines, u)
+		}
+	}
+
+	if prev.Screen.Cursor != next.Screen.Cursor {
+		c := next.Screen.Cursor
+		p.Cursor = &c
+	}
+	p.ScrollbackDrop, p.Scrollback = diffScrollback(prev.Scrollback, next.Scrollback, p.Scroll)
+	return p
+}
+
+// Apply applies the patch to the snapshot, which must be the prev the
+// patch was made from.
+func (s *Snapshot) Apply(p *Patch) error {
+	if p.Full != nil {
+		*s = *p.Full
+		s.Screen.Lines = slices.Clone(s.Screen.Lines)
+		s.Scrollback = slices.Clone(s.Scrollback)
+		return nil
+	}
+	if p.ScrollbackDrop > len(s.Scrollback) {
+		return fmt.Errorf("gopyte: patch drops %d of %d scrollback lines", p.ScrollbackDrop, len(s.Scrollback))
+	}
+	for _, u := range p.Lines {
+		if u.Y < 0 || u.Y >= s.Lines || u.X < 0 || u.X > s.Columns {
+			return fmt.Errorf("gopyte: patch line update at (%d, %d) is off the screen", u.X, u.Y)
+		}
+	}
+	if p.Scroll < 0 || p.Scroll > s.Lines {
+		return errors.New("gopyte: patch scrolls off the screen")
+	}
+
+	if p.State != nil {
+		screen, main, scrollback := s.Screen, s.Main, s.Scrollback
+		*s = *p.State
+		s.Screen.Lines, s.Screen.Cursor = screen.Lines, screen.Cursor
+		s.Main, s.Scrollback = main, scrollback
+	}
+	if p.Scroll > 0 {
+		s.Screen.Lines = scrollLines(s.Screen.Lines, p.Scroll, s.Columns)
+	}
+	for len(s.Screen.Lines) < s.Lines {
+		s.Screen.Lines = append(s.Screen.Lines, blankSnapshotLine(s.Columns))
+	}
+	for _, u := range p.Lines {
+		s.Screen.Lines[u.Y] = s.Screen.Lines[u.Y].splice(u.X, u.SnapshotLine)
+	}
+	if p.Cursor != nil {
+		s.Screen.Cursor = *p.Cursor
+	}
+	s.Scrollback = append(s.Scrollback[p.ScrollbackDrop:len(s.Scrollback):len(s.Scrollback)], p.Scrollback...)
+	return nil
+}
+
+// state returns the snapshot without its text, cursor position and
+// scrollback, which patches carry on their own.
+func (s *Snapshot) state() *Snapshot {
+	state := *s
+	state.Screen.Lines, state.Screen.Cursor = nil, SnapshotCursor{}
+	state.Main, state.Scrollback = nil, nil
+	return &state
+}
+
+// scrollOffset returns how far next looks scrolled up from prev: the
+// offset that leaves the most lines in place, if it beats not scrolling.
+func scrollOffset(prev, next []SnapshotLine) int {
+	matches := func(n int) int {
+		count := 0
+		for y := 0; y+n < len(prev) && y < len(next); y++ {
+			if prev[y+n].equal(next[y]) {
+				count++
+			}
+		}
+		return count
+	}
+	best, bestMatches := 0, matches(0)
+	for n := 1; n < len(prev); n++ {
+		if bestMatches >= len(prev)-n {
+			break // No larger offset can do better
+		}
+		if m := matches(n); m > bestMatches {
+			best, bestMatches = n, m
+		}
+	}
+	return best
+}
+
+// scrollLines returns the lines moved up n, with blank lines below.
+func scrollLines(lines []SnapshotLine, n, columns int) []SnapshotLine {
+	scrolled := make([]SnapshotLine, 0, len(lines))
+	scrolled = append(scrolled, lines[min(n, len(lines)):]...)
+	for len(scrolled) < len(lines) {
+		scrolled = append(scrolled, blankSnapshotLine(columns))
+	}
+	return scrolled
+}
+
+func blankSnapshotLine(columns int) SnapshotLine {
+	return SnapshotLine{Text: strings.Repeat(" ", columns)}
+}
+
+// diffLine returns the update turning prev into next: the span from the
+// first to the last cell that differs, widened to whole wide characters.
+func diffLine(y int, prev, next SnapshotLine) (LineUpdate, bool) {
+	if prev.equal(next) {
+		return LineUpdate{}, false
+	}
+	prevChars, prevAttrs := prev.row(-1)
+	chars, attrs := next.row(-1)
+	if len(prevChars) != len(chars) {
+		return LineUpdate{Y: y, SnapshotLine: next}, true
+	}
+
+	same := func(x int) bool {
+		return prevChars[x] == chars[x] && prevAttrs[x] == attrs[x] && prev.Clusters[x] == next.Clusters[x] &&
+			cellMetaEqual(prev.Meta, next.Meta, x)
+	}
+	start, end := 0, len(chars)
+	for start < end && same(start) {
+		start++
+	}
+	for end > start && same(end-1) {
+		end--
+	}
+	for start > 0 && start < len(chars) && chars[start] == 0 {
+		start--
+	}
+	for end < len(chars) && chars[end] == 0 {
+		end++
+	}
+	span := snapshotLine(chars[start:end], attrs[start:end], shiftClusters(next.Clusters, start, end, -start), next.Wrapped)
+	span.Meta = shiftMeta(next.Meta, start, end, -start)
+	return LineUpdate{Y: y, X: start, SnapshotLine: span}, true
+}
+
+func (l SnapshotLine) equal(o SnapshotLine) bool {
+	return l.Text == o.Text && l.Wrapped == o.Wrapped && slices.Equal(l.Attrs, o.Attrs) &&
+		maps.Equal(l.Clusters, o.Clusters) && metaEqual(l.Meta, o.Meta)
+}
+
+// metaEqual reports whether a and b hold the same encoded metadata.
+func metaEqual(a, b map[string]SnapshotMeta) bool {
+	return maps.EqualFunc(a, b, func(x, y SnapshotMeta) bool {
+		return bytes.Equal(x.Line, y.Line) && maps.EqualFunc(x.Cells, y.Cells, func(p, q json.RawMessage) bool {
+			return bytes.Equal(p, q)
+		})
+	})
+}
+
+// cellMetaEqual reports whether a and b hold the same metadata for cell x.
+func cellMetaEqual(a, b map[string]SnapshotMeta, x int) bool {
+	for name, sm := range a {
+		if !bytes.Equal(sm.Cells[x], b[name].Cells[x]) {
+			return false
+		}
+	}
+	for name, sm := range b {
+		if _, ok := a[name]; !ok && sm.Cells[x] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// shiftMeta returns the metadata with the line values and the cells of
+// columns from..to-1, moved by n columns.
+func shiftMeta(meta map[string]SnapshotMeta, from, to, n int) map[string]SnapshotMeta {
+	var shifted map[string]SnapshotMeta
+	for name, sm := range meta {
+		out := SnapshotMeta{Line: sm.Line}
+		for x, data := range sm.Cells {
+			if x >= from && x < to {
+				if out.Cells == nil {
+					out.Cells = make(map[int]json.RawMessage)
+				}
+				out.Cells[x+n] = data
+			}
+		}
+		if out.Line != nil || out.Cells != nil {
+			if shifted == nil {
+				shifted = make(map[string]SnapshotMeta)
+			}
+			shifted[name] = out
+		}
+	}
+	return shifted
+}
+
+// shiftClusters returns the clusters of columns from..to-1, moved by n
+// columns.
+func shiftClusters(clusters map[int]string, from, to, n int) map[int]string {
+	shifted := make(map[int]string)
+	for x, tail := range clusters {
+		if x >= from && x < to {
+			shifted[x+n] = tail
+		}
+	}
+	return shifted
+}
+
+// splice returns the line with the cells from column x on replaced by
+// those of span, and span's wrap flag and line metadata.
+func (l SnapshotLine) splice(x int, span SnapshotLine) SnapshotLine {
+	chars, attrs := l.row(-1)
+	spanChars, spanAttrs := span.row(-1)
+	for i := range spanChars {
+		if x+i < len(chars) {
+			chars[x+i], attrs[x+i] = spanChars[i], spanAttrs[i]
+		}
+	}
+	clusters := shiftClusters(span.Clusters, 0, len(spanChars), x)
+	for cx, tail := range l.Clusters {
+		if cx < x || cx >= x+len(spanChars) {
+			clusters[cx] = tail
+		}
+	}
+	line := snapshotLine(chars, attrs, clusters, span.Wrapped)
+	line.Meta = shiftMeta(span.Meta, 0, len(spanChars), x)
+	for name, sm := range l.Meta {
+		for cx, data := range sm.Cells {
+			if cx >= x && cx < x+len(spanChars) {
+				continue
+			}
+			if line.Meta == nil {
+				line.Meta = make(map[string]SnapshotMeta)
+			}
+			out := line.Meta[name]
+			if out.Cells == nil {
+				out.Cells = make(map[int]json.RawMessage)
+			}
+			out.Cells[cx] = data
+			line.Meta[name] = out
+		}
+	}
+	return line
+}
+
+// diffScrollback returns how many lines to drop from the top of prev and
+// which to append to make next. Lines usually arrive as the screen
+// scrolls, so that many are tried first; otherwise next is sent whole.
+func diffScrollback(prev, next []SnapshotLine, scrolled int) (drop int, added []SnapshotLine) {
+	for _, n := range []int{0, scrolled} {
+		if n > len(next) {
+			continue
+		}
+		kept := len(next) - n
+		drop = len(prev) - kept
+		if drop >= 0 && slices.EqualFunc(prev[drop:], next[:kept], SnapshotLine.equal) {
+			return drop, next[kept:]
+		}
+	}
+	return len(prev), next
+}
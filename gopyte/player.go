@@ -0,0 +1,354 @@
+package gopyte
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultCheckpointInterval is the number of events between checkpoints
+// taken by a Player.
+const DefaultCheckpointInterval = 200
+
+// Player replays a Transcript into a screen with speed control, pausing,
+// single-stepping and seeking. It is meant for building debugger-like UIs
+// over recorded sessions.
+//
+// Seeking backwards restores the nearest checkpoint - a copy of the screen
+// and parser state taken while playing forward - and replays from there.
+// Checkpoints are only available for the built-in screen types; for other
+// Screen implementations the Player resets the screen and replays from the
+// start of the recording.
+//
+// Because restoring a checkpoint swaps in a copy of the screen, always read
+// the current state through Screen() rather than holding on to the screen
+// passed to NewPlayer.
+type Player struct {
+	mu sync.Mutex
+
+	events []TranscriptEvent
+	pos    int // Number of events applied
+
+	screen Screen
+	stream *Stream
+
+	speed    float64
+	maxIdle  time.Duration
+	paused   bool
+	interval int
+
+	initial     checkpoint
+	checkpoints []checkpoint
+	canClone    bool
+
+	onEvent func(ev TranscriptEvent, screen Screen)
+
+	clock Clock
+}
+
+type checkpoint struct {
+	pos    int
+	screen Screen
+	stream *Stream
+}
+
+// NewPlayer creates a player positioned before the first event of t.
+// screen should be freshly created; it receives the replayed output.
+func NewPlayer(t *Transcript, screen Screen) *Player {
+	p := &Player{
+		events:   t.Events(),
+		screen:   screen,
+		stream:   NewStream(screen, false),
+		speed:    1,
+		interval: DefaultCheckpointInterval,
+		clock:    SystemClock,
+	}
+	if c, ok := cloneScreen(screen); ok {
+		p.canClone = true
+		p.initial = checkpoint{pos: 0, screen: c, stream: p.stream.clone(c)}
+	}
+	return p
+}
+
+// NewCastPlayer reads an asciinema v2 recording and returns a player over
+// it. The header's idle_time_limit becomes the player's idle cap. When
+// screen is nil, a WideCharScreen of the recorded size is created.
+func NewCastPlayer(r io.Reader, screen Screen) (*Player, CastHeader, error) {
+	t, header, err := ReadAsciicast(r)
+	if err != nil {
+		return nil, header, err
+	}
+	if screen == nil {
+		if header.Width <= 0 || header.Height <= 0 {
+			return nil, header, errors.New("asciicast: header has no terminal size")
+		}
+		screen = NewWideCharScreen(header.Width, header.Height, 1000)
+	}
+	p := NewPlayer(t, screen)
+	if header.IdleTimeLimit > 0 {
+		p.maxIdle = time.Duration(header.IdleTimeLimit * float64(time.Second))
+	}
+	return p, header, nil
+}
+
+// Screen returns the screen holding the state at the current position.
+func (p *Player) Screen() Screen {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.screen
+}
+
+// SetSpeed sets the playback speed multiplier used by Play (2 plays twice
+// as fast, 0.5 at half speed). Non-positive values are ignored.
+func (p *Player) SetSpeed(speed float64) {
+	if speed <= 0 {
+		return
+	}
+	p.mu.Lock()
+	p.speed = speed
+	p.mu.Unlock()
+}
+
+// SetMaxIdle caps the delay between two events during Play, like
+// asciinema's idle_time_limit. Zero disables the cap.
+func (p *Player) SetMaxIdle(d time.Duration) {
+	p.mu.Lock()
+	p.maxIdle = d
+	p.mu.Unlock()
+}
+
+// SetClock sets the clock Play waits on. Pass nil for SystemClock.
+func (p *Player) SetClock(c Clock) {
+	if c == nil {
+		c = SystemClock
+	}
+	p.mu.Lock()
+	p.clock = c
+	p.mu.Unlock()
+}
+
+// SetCheckpointInterval sets how many events pass between checkpoints.
+// It only affects checkpoints taken from now on.
+func (p *Player) SetCheckpointInterval(n int) {
+	if n <= 0 {
+		return
+	}
+	p.mu.Lock()
+	p.interval = n
+	p.mu.Unlock()
+}
+
+// SetEventHandler registers a callback invoked after Step or Play applies
+// an event, e.g. to pass the original bytes through to a real terminal or
+// to redraw a renderer from screen. It is not called for events replayed
+// by a seek. The callback runs while the player is locked and must not
+// call back into the Player.
+func (p *Player) SetEventHandler(fn func(ev TranscriptEvent, screen Screen)) {
+	p.mu.Lock()
+	p.onEvent = fn
+	p.mu.Unlock()
+}
+
+// Len returns the number of events in the recording.
+func (p *Player) Len() int {
+	return len(p.events)
+}
+
+// Position returns the number of events applied so far.
+func (p *Player) Position() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pos
+}
+
+// Time returns the timestamp of the last applied event.
+func (p *Player) Time() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pos == 0 {
+		return 0
+	}
+	return p.events[p.pos-1].Time
+}
+
+// Duration returns the timestamp of the last event in the recording.
+func (p *Player) Duration() time.Duration {
+	if len(p.events) == 0 {
+		return 0
+	}
+	return p.events[len(p.events)-1].Time
+}
+
+// Step applies the next event and returns it. ok is false at the end of
+// the recording. Input events are returned but do not change the screen.
+func (p *Player) Step() (ev TranscriptEvent, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pos >= len(p.events) {
+		return TranscriptEvent{}, false
+	}
+	ev = p.events[p.pos]
+	p.apply()
+	if p.onEvent != nil {
+		p.onEvent(ev, p.screen)
+	}
+	return ev, true
+}
+
+// SeekEvent moves to the state after the first n events.
+func (p *Player) SeekEvent(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seek(n)
+}
+
+// Seek moves to the state after every event recorded at or before t.
+func (p *Player) Seek(t time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for n < len(p.events) && p.events[n].Time <= t {
+		n++
+	}
+	p.seek(n)
+}
+
+// Finish applies the remaining events at once, calling the event handler
+// for each: Play without the waits, for rendering a recording or checking
+// the screen it ends on.
+func (p *Player) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.pos < len(p.events) {
+		ev := p.events[p.pos]
+		p.apply()
+		if p.onEvent != nil {
+			p.onEvent(ev, p.screen)
+		}
+	}
+}
+
+// Pause makes a running Play return after the current event.
+func (p *Player) Pause() {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+}
+
+// Play replays the remaining events in real time, scaled by the speed
+// multiplier. It returns nil at the end of the recording or when paused,
+// and ctx.Err() if ctx is cancelled. Calling Play again resumes.
+func (p *Player) Play(ctx context.Context) error {
+	p.mu.Lock()
+	p.paused = false
+	p.mu.Unlock()
+
+	for {
+		p.mu.Lock()
+		if p.paused || p.pos >= len(p.events) {
+			p.mu.Unlock()
+			return nil
+		}
+		delay := p.events[p.pos].Time
+		if p.pos > 0 {
+			delay -= p.events[p.pos-1].Time
+		}
+		if p.maxIdle > 0 && delay > p.maxIdle {
+			delay = p.maxIdle
+		}
+		delay = time.Duration(float64(delay) / p.speed)
+		clock := p.clock
+		p.mu.Unlock()
+
+		if err := sleepContext(ctx, clock, delay); err != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		if !p.paused && p.pos < len(p.events) {
+			ev := p.events[p.pos]
+			p.apply()
+			if p.onEvent != nil {
+				p.onEvent(ev, p.screen)
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// apply feeds the event at pos and takes a checkpoint when due.
+// The caller holds p.mu.
+func (p *Player) apply() {
+	ev := p.events[p.pos]
+	switch ev.Direction {
+	case DirOutput:
+		p.stream.Feed(ev.Data)
+	case DirResize:
+		resizeScreen(p.screen, ev.Data)
+	}
+	p.pos++
+
+	if p.canClone && p.pos%p.interval == 0 {
+		last := p.initial.pos
+		if n := len(p.checkpoints); n > 0 {
+			last = p.checkpoints[n-1].pos
+		}
+		if p.pos > last {
+			c, _ := cloneScreen(p.screen)
+			p.checkpoints = append(p.checkpoints, checkpoint{pos: p.pos, screen: c, stream: p.stream.clone(c)})
+		}
+	}
+}
+
+// seek moves to the state after n events. The caller holds p.mu.
+func (p *Player) seek(n int) {
+	if n < 0 {
+		n = 0
+	}
+	if n > len(p.events) {
+		n = len(p.events)
+	}
+
+	if n < p.pos {
+		p.restore(n)
+	}
+	for p.pos < n {
+		p.apply()
+	}
+}
+
+// restore rewinds to the latest checkpoint at or before n.
+func (p *Player) restore(n int) {
+	if !p.canClone {
+		p.screen.Reset()
+		p.stream = NewStream(p.screen, false)
+		p.pos = 0
+		return
+	}
+
+	cp := p.initial
+	for _, c := range p.checkpoints {
+		if c.pos <= n {
+			cp = c
+		}
+	}
+	// Restore from a copy so the checkpoint itself stays pristine
+	screen, _ := cloneScreen(cp.screen)
+	p.screen = screen
+	p.stream = cp.stream.clone(screen)
+	p.pos = cp.pos
+}
+
+func sleepContext(ctx context.Context, clock Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(d):
+		return nil
+	}
+}
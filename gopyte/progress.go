@@ -0,0 +1,55 @@
+package gopyte
+
+// Progress-line collapsing.
+//
+// A progress bar wider than the screen is redrawn with a carriage return,
+// which only returns to the start of the current row: every repaint
+// overwrites the tail of the previous one and wraps onto a new row, so the
+// scrollback fills with one line per repaint. With collapsing enabled, a
+// row that was overwritten right after a carriage return replaces the
+// history line it continues instead of being appended after it, leaving
+// only the final state of the bar.
+
+// SetCollapseProgress enables or disables progress-line collapsing in the
+// scrollback. It is off by default.
+func (h *HistoryScreen) SetCollapseProgress(on bool) {
+	h.collapseProgress = on
+}
+
+// noteCarriageReturn remembers that the next Draw at the start of this row
+// overwrites it.
+func (s *NativeScreen) noteCarriageReturn() {
+	s.crPending = true
+	s.crRow = s.cursor.Y
+}
+
+// noteRepaint marks the current row as repainted if text is about to be
+// drawn over existing content straight after a carriage return. Draw
+// implementations call it before drawing.
+func (s *NativeScreen) noteRepaint() {
+	if !s.crPending {
+		return
+	}
+	s.crPending = false
+	y := s.cursor.Y
+	if s.cursor.X != 0 || y != s.crRow || y < 0 || y >= s.lines {
+		return
+	}
+	for _, ch := range s.buffer[y] {
+		if ch != ' ' && ch != 0 {
+			s.setRepainted(y)
+			return
+		}
+	}
+}
+
+// isRepainted reports whether row y was overwritten after a carriage
+// return.
+func (s *NativeScreen) isRepainted(y int) bool {
+	return y >= 0 && y < len(s.repainted) && s.repainted[y]
+}
+
+func (s *NativeScreen) setRepainted(y int) {
+	s.ensureWrapped()
+	s.repainted[y] = true
+}
@@ -0,0 +1,63 @@
+//go:build !windows
+// +build !windows
+
+package pty
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	creack "github.com/creack/pty"
+)
+
+// The terminal passes output through as the command wrote it.
+const conhost = false
+
+// unixProcess is a command on a Unix pseudo-terminal; the file is the
+// master side.
+type unixProcess struct {
+	*os.File
+	cmd *exec.Cmd
+}
+
+func startProcess(cmd *exec.Cmd, columns, lines int) (process, error) {
+	f, err := creack.StartWithSize(cmd, winsize(columns, lines))
+	if err != nil {
+		return nil, err
+	}
+	return &unixProcess{File: f, cmd: cmd}, nil
+}
+
+func (p *unixProcess) Resize(columns, lines int) error {
+	return creack.Setsize(p.File, winsize(columns, lines))
+}
+
+func (p *unixProcess) Wait() error {
+	return p.cmd.Wait()
+}
+
+func (p *unixProcess) Kill() error {
+	return p.cmd.Process.Kill()
+}
+
+func winsize(columns, lines int) *creack.Winsize {
+	return &creack.Winsize{Cols: uint16(columns), Rows: uint16(lines)}
+}
+
+// watchResize calls resize on SIGWINCH, sent when the window of the
+// terminal the host runs in changes size, until done is closed.
+func watchResize(resize func(), done <-chan struct{}) {
+	changed := make(chan os.Signal, 1)
+	signal.Notify(changed, syscall.SIGWINCH)
+	defer signal.Stop(changed)
+	for {
+		select {
+		case <-changed:
+			resize()
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,222 @@
+//go:build windows
+// +build windows
+
+package pty
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// ErrUnsupported is returned by Start on Windows without ConPTY, before
+// Windows 10 1809.
+var ErrUnsupported = errors.New("pty: ConPTY needs Windows 10 1809 or later")
+
+// conhost re-renders a program's output instead of passing it through, so
+// the screen's ConPTY heuristics are turned on.
+const conhost = true
+
+// conptyProcess is a command attached to a pseudo console. conhost reads
+// the input pipe and writes what the console shows to the output pipe.
+type conptyProcess struct {
+	console windows.Handle
+	in      *os.File // Our end of the input pipe
+	out     *os.File // Our end of the output pipe
+	proc    *os.Process
+	once    sync.Once
+}
+
+func startProcess(cmd *exec.Cmd, columns, lines int) (process, error) {
+	if cmd.Err != nil {
+		return nil, cmd.Err
+	}
+	if windows.NewLazySystemDLL("kernel32.dll").NewProc("CreatePseudoConsole").Find() != nil {
+		return nil, ErrUnsupported
+	}
+
+	var inRead, inWrite, outRead, outWrite windows.Handle
+	if err := windows.CreatePipe(&inRead, &inWrite, nil, 0); err != nil {
+		return nil, err
+	}
+	if err := windows.CreatePipe(&outRead, &outWrite, nil, 0); err != nil {
+		windows.CloseHandle(inRead)
+		windows.CloseHandle(inWrite)
+		return nil, err
+	}
+	var console windows.Handle
+	err := windows.CreatePseudoConsole(coord(columns, lines), inRead, outWrite, 0, &console)
+	// The console holds its own ends of the pipes now
+	windows.CloseHandle(inRead)
+	windows.CloseHandle(outWrite)
+	p := &conptyProcess{
+		in:  os.NewFile(uintptr(inWrite), "conpty-input"),
+		out: os.NewFile(uintptr(outRead), "conpty-output"),
+	}
+	if err != nil {
+		p.in.Close()
+		p.out.Close()
+		return nil, err
+	}
+	p.console = console
+
+	if p.proc, err = createProcess(cmd, console); err != nil {
+		p.Close()
+		return nil, err
+	}
+	cmd.Process = p.proc
+	return p, nil
+}
+
+// createProcess starts cmd attached to console.
+func createProcess(cmd *exec.Cmd, console windows.Handle) (*os.Process, error) {
+	attrs, err := windows.NewProcThreadAttributeList(1)
+	if err != nil {
+		return nil, err
+	}
+	defer attrs.Delete()
+	// The attribute's value is the console handle itself
+	if err := attrs.Update(windows.PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE, *(*unsafe.Pointer)(unsafe.Pointer(&console)), unsafe.Sizeof(console)); err != nil {
+		return nil, err
+	}
+
+	args := cmd.Args
+	if len(args) == 0 {
+		args = []string{cmd.Path}
+	}
+	commandLine := windows.ComposeCommandLine(args)
+	if cmd.SysProcAttr != nil && cmd.SysProcAttr.CmdLine != "" {
+		commandLine = cmd.SysProcAttr.CmdLine
+	}
+	app, err := windows.UTF16PtrFromString(cmd.Path)
+	if err != nil {
+		return nil, err
+	}
+	line, err := windows.UTF16PtrFromString(commandLine)
+	if err != nil {
+		return nil, err
+	}
+	var dir *uint16
+	if cmd.Dir != "" {
+		if dir, err = windows.UTF16PtrFromString(cmd.Dir); err != nil {
+			return nil, err
+		}
+	}
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+
+	// Standard handles are left unset, or the command would share ours
+	// rather than the console's when ours are redirected
+	si := windows.StartupInfoEx{ProcThreadAttributeList: attrs.List()}
+	si.Cb = uint32(unsafe.Sizeof(si))
+	si.Flags = windows.STARTF_USESTDHANDLES
+	var pi windows.ProcessInformation
+	flags := uint32(windows.EXTENDED_STARTUPINFO_PRESENT | windows.CREATE_UNICODE_ENVIRONMENT)
+	if err := windows.CreateProcess(app, line, nil, nil, false, flags, envBlock(env), dir, &si.StartupInfo, &pi); err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(pi.Process)
+	windows.CloseHandle(pi.Thread)
+	// Our handle keeps the process ID from being reused until it is found
+	return os.FindProcess(int(pi.ProcessId))
+}
+
+func (p *conptyProcess) Read(b []byte) (int, error) {
+	return p.out.Read(b)
+}
+
+func (p *conptyProcess) Write(b []byte) (int, error) {
+	return p.in.Write(b)
+}
+
+func (p *conptyProcess) Resize(columns, lines int) error {
+	return windows.ResizePseudoConsole(p.console, coord(columns, lines))
+}
+
+func (p *conptyProcess) Wait() error {
+	state, err := p.proc.Wait()
+	if err != nil {
+		return err
+	}
+	if !state.Success() {
+		return &exec.ExitError{ProcessState: state}
+	}
+	return nil
+}
+
+func (p *conptyProcess) Kill() error {
+	return p.proc.Kill()
+}
+
+// Close closes the console, which ends the output once the reader has
+// drained it, and the pipes.
+func (p *conptyProcess) Close() error {
+	p.once.Do(func() {
+		if p.console != 0 {
+			windows.ClosePseudoConsole(p.console)
+		}
+		p.in.Close()
+		p.out.Close()
+	})
+	return nil
+}
+
+// resizePoll is how often FollowSize checks the size of a console, which
+// sends no signal when its window changes.
+const resizePoll = 250 * time.Millisecond
+
+func watchResize(resize func(), done <-chan struct{}) {
+	tick := time.NewTicker(resizePoll)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			resize()
+		case <-done:
+			return
+		}
+	}
+}
+
+func coord(columns, lines int) windows.Coord {
+	return windows.Coord{X: int16(columns), Y: int16(lines)}
+}
+
+// envBlock returns env as CreateProcess takes it: NUL-terminated UTF-16
+// strings ending with an empty one. Of variables set more than once, the
+// last is kept, as os/exec does; names are not case sensitive.
+func envBlock(env []string) *uint16 {
+	seen := make(map[string]bool, len(env))
+	var kept []string
+	for i := len(env) - 1; i >= 0; i-- {
+		kv := env[i]
+		if kv == "" {
+			continue
+		}
+		// Names like =C: start with =, so the separator comes after it
+		name, _, _ := strings.Cut(kv[1:], "=")
+		if name = strings.ToUpper(kv[:1] + name); !seen[name] {
+			seen[name] = true
+			kept = append(kept, kv)
+		}
+	}
+	var block []uint16
+	for i := len(kept) - 1; i >= 0; i-- {
+		block = append(block, utf16.Encode([]rune(kept[i]))...)
+		block = append(block, 0)
+	}
+	if len(block) == 0 {
+		block = append(block, 0)
+	}
+	block = append(block, 0)
+	return &block[0]
+}
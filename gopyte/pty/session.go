@@ -0,0 +1,237 @@
+// Package pty runs a command on a pseudo-terminal and shows its output on
+// a gopyte screen: the plumbing between a process and the emulator that
+// every terminal host otherwise writes for itself. On Windows the terminal
+// is a ConPTY pseudo console, and the screen is put in ConPTY mode (see
+// SetConPTYMode).
+//
+//	screen := gopyte.NewWideCharScreen(80, 24, 1000)
+//	session, err := pty.Start(exec.Command("bash"), screen, pty.Options{Columns: 80, Lines: 24})
+//	if err != nil {
+//		return err
+//	}
+//	defer session.Close()
+//	defer session.FollowSize(os.Stdin)()
+//	session.WriteInput([]byte("ls\r"))
+//	session.Screen().View(func(s gopyte.Screen) {
+//		draw(s.GetDisplay())
+//	})
+//
+// Output is fed on a goroutine of the session's with the screen locked, so
+// read the screen through Session.Screen, never the screen passed to
+// Start. Answers to the command's queries, such as cursor position
+// reports, are written back to it.
+package pty
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+	"golang.org/x/term"
+)
+
+// DefaultTerm is the TERM a command gets when Options.Term is empty.
+const DefaultTerm = "xterm-256color"
+
+// drainTimeout is how long a session waits, once the command has exited,
+// for the rest of its output. Processes it left running can keep the
+// terminal open indefinitely.
+const drainTimeout = 250 * time.Millisecond
+
+// Options configures a session.
+type Options struct {
+	Columns  int    // Terminal width, which should be the screen's (0 = 80)
+	Lines    int    // Terminal height, which should be the screen's (0 = 24)
+	Term     string // TERM for a command without cmd.Env (empty = DefaultTerm)
+	OnOutput func() // Called after each chunk of output reaches the screen, without the lock held; for repainting
+}
+
+// Session is a command running on a pseudo-terminal, its output fed to a
+// screen.
+type Session struct {
+	cmd      *exec.Cmd
+	proc     process
+	screen   *gopyte.SyncScreen
+	stream   *gopyte.Stream
+	onOutput func()
+
+	readDone chan struct{}
+	exited   chan struct{}
+	err      error // The command's exit error, set before exited is closed
+}
+
+// process is a command attached to a terminal of its own. Reading gives
+// its output and writing types into it.
+type process interface {
+	io.ReadWriteCloser
+	Resize(columns, lines int) error
+	Wait() error
+	Kill() error
+}
+
+// Start starts cmd on a new pseudo-terminal feeding screen. A command
+// without an environment of its own gets the current one with TERM set;
+// cmd.Stdin, Stdout and Stderr are replaced by the terminal. screen may be
+// a SyncScreen already, to share its lock with other code.
+func Start(cmd *exec.Cmd, screen gopyte.Screen, opts Options) (*Session, error) {
+	if opts.Columns <= 0 {
+		opts.Columns = 80
+	}
+	if opts.Lines <= 0 {
+		opts.Lines = 24
+	}
+	if opts.Term == "" {
+		opts.Term = DefaultTerm
+	}
+	if cmd.Env == nil {
+		cmd.Env = append(os.Environ(), "TERM="+opts.Term)
+	}
+
+	proc, err := startProcess(cmd, opts.Columns, opts.Lines)
+	if err != nil {
+		return nil, err
+	}
+	locked, ok := screen.(*gopyte.SyncScreen)
+	if !ok {
+		locked = gopyte.NewSyncScreen(screen)
+	}
+	locked.Update(func(screen gopyte.Screen) {
+		if r, ok := screen.(interface{ SetResponseWriter(io.Writer) }); ok {
+			r.SetResponseWriter(proc)
+		}
+		if c, ok := screen.(interface{ SetConPTYMode(bool) }); ok && conhost {
+			c.SetConPTYMode(true)
+		}
+	})
+	s := &Session{
+		cmd:      cmd,
+		proc:     proc,
+		screen:   locked,
+		stream:   gopyte.NewStream(locked.Screen(), false),
+		onOutput: opts.OnOutput,
+		readDone: make(chan struct{}),
+		exited:   make(chan struct{}),
+	}
+	go s.read()
+	go s.wait()
+	return s, nil
+}
+
+// Screen returns the screen, guarded by the lock the session feeds it
+// under.
+func (s *Session) Screen() *gopyte.SyncScreen {
+	return s.screen
+}
+
+// Stream returns the stream parsing the command's output, to set up
+// recording or logging. Use it only inside Screen().Update.
+func (s *Session) Stream() *gopyte.Stream {
+	return s.stream
+}
+
+// Command returns the command the session runs.
+func (s *Session) Command() *exec.Cmd {
+	return s.cmd
+}
+
+// WriteInput types p into the command: keystrokes, as EncodeKey returns
+// them, or pasted text.
+func (s *Session) WriteInput(p []byte) (int, error) {
+	return s.proc.Write(p)
+}
+
+// Resize resizes the screen and the terminal; the command is told of the
+// new size and usually redraws.
+func (s *Session) Resize(columns, lines int) error {
+	if columns <= 0 || lines <= 0 {
+		return nil
+	}
+	s.screen.Resize(columns, lines)
+	return s.proc.Resize(columns, lines)
+}
+
+// FollowSize resizes the session to the size of tty, the host's own
+// terminal, now and whenever its window changes, until stop is called or
+// the command exits.
+func (s *Session) FollowSize(tty *os.File) (stop func()) {
+	columns, lines := 0, 0
+	resize := func() {
+		c, l, err := term.GetSize(int(tty.Fd()))
+		if err == nil && (c != columns || l != lines) {
+			columns, lines = c, l
+			_ = s.Resize(c, l)
+		}
+	}
+	resize()
+
+	done := make(chan struct{})
+	var once sync.Once
+	stop = func() { once.Do(func() { close(done) }) }
+	go func() {
+		select {
+		case <-done:
+		case <-s.exited:
+			stop()
+		}
+	}()
+	go watchResize(resize, done)
+	return stop
+}
+
+// Done is closed when the command has exited and its output has reached
+// the screen.
+func (s *Session) Done() <-chan struct{} {
+	return s.exited
+}
+
+// Wait waits for the command to exit and its output to reach the screen,
+// and returns its exit error.
+func (s *Session) Wait() error {
+	<-s.exited
+	return s.err
+}
+
+// Close kills the command if it is still running and waits for it. The
+// screen stays as the command left it.
+func (s *Session) Close() error {
+	select {
+	case <-s.exited:
+	default:
+		_ = s.proc.Kill()
+		<-s.exited
+	}
+	return nil
+}
+
+func (s *Session) read() {
+	defer close(s.readDone)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := s.proc.Read(buf)
+		if n > 0 {
+			data := string(buf[:n])
+			s.screen.Update(func(gopyte.Screen) { s.stream.Feed(data) })
+			if s.onOutput != nil {
+				s.onOutput()
+			}
+		}
+		if err != nil {
+			return // EOF, or EIO once the command has closed the terminal
+		}
+	}
+}
+
+func (s *Session) wait() {
+	err := s.proc.Wait()
+	select {
+	case <-s.readDone:
+	case <-time.After(drainTimeout):
+	}
+	_ = s.proc.Close()
+	<-s.readDone
+	s.err = err
+	close(s.exited)
+}
@@ -0,0 +1,89 @@
+package gopyte
+
+// SequenceKind classifies a unit of input seen by the raw tap.
+type SequenceKind int
+
+const (
+	SeqText    SequenceKind = iota // Run of printable text
+	SeqControl                     // Single C0 control character
+	SeqEscape                      // ESC followed by a single final character
+	SeqCSI                         // Control Sequence Introducer sequence
+	SeqOSC                         // Operating System Command string
+	SeqCharset                     // Character set designation / selection
+	SeqSharp                       // ESC # sequences (DECALN)
+	SeqDCS                         // Device Control String
+	SeqString                      // SOS, PM or APC string, ignored
+)
+
+var sequenceKindNames = map[SequenceKind]string{
+	SeqText:    "text",
+	SeqControl: "control",
+	SeqEscape:  "escape",
+	SeqCSI:     "csi",
+	SeqOSC:     "osc",
+	SeqCharset: "charset",
+	SeqSharp:   "sharp",
+	SeqDCS:     "dcs",
+	SeqString:  "string",
+}
+
+func (k SequenceKind) String() string {
+	if name, ok := sequenceKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// RawSequence is the exact input that produced one parser action.
+type RawSequence struct {
+	Kind SequenceKind
+	// Name is the handler the sequence was dispatched to ("cursor_position",
+	// "linefeed", ...), the OSC code for OSC strings, "custom" for sequences
+	// handled by a registered CSI handler, or "" when it was not recognized.
+	Name string
+	// Raw holds the original bytes, including sequences split across Feed
+	// calls. It is only valid for the duration of the callback.
+	Raw []byte
+}
+
+// SetRawTap registers a callback that receives the original bytes of every
+// parsed unit - text runs, control characters and escape sequences - after
+// it has been applied to the screen. Concatenating Raw over all callbacks
+// reproduces the fed input exactly, which allows gopyte to sit in a
+// transparent proxy while tracking screen state. Pass nil to remove it.
+func (s *Stream) SetRawTap(fn func(RawSequence)) {
+	s.rawTap = fn
+	s.tapPending, s.tapDropped = nil, 0
+}
+
+// keepPending keeps chunk, the start of an unfinished sequence, for the
+// next Feed. Only as many bytes as the larger of the OSC and DCS limits
+// are kept: a raw tap gets the bytes early instead, as a unit of the open
+// sequence's kind, so that it still sees every byte, and a strict stream
+// keeps the start of the sequence for its ParseError.
+func (s *Stream) keepPending(chunk string) {
+	limit := max(s.limits.MaxOSCBytes, s.limits.MaxDCSBytes)
+	if s.limits.MaxOSCBytes <= 0 || s.limits.MaxDCSBytes <= 0 || len(s.tapPending)+len(chunk) <= limit {
+		s.tapPending = append(s.tapPending, chunk...)
+		return
+	}
+	if s.rawTap != nil {
+		s.tapDropped += int64(len(s.tapPending) + len(chunk))
+		s.emitRaw(chunk)
+		return
+	}
+	room := max(limit-len(s.tapPending), 0)
+	s.tapPending = append(s.tapPending, chunk[:room]...)
+	s.tapDropped += int64(len(chunk) - room)
+}
+
+// emitRaw delivers a completed unit to the tap, prefixed with any bytes
+// carried over from a previous Feed.
+func (s *Stream) emitRaw(chunk string) {
+	raw := append(s.tapPending, chunk...)
+	s.tapPending = s.tapPending[:0]
+	if len(raw) == 0 {
+		return
+	}
+	s.rawTap(RawSequence{Kind: s.tapKind, Name: s.tapName, Raw: raw})
+}
@@ -0,0 +1,146 @@
+package gopyte
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"sync"
+	"time"
+)
+
+// RecorderFrame is one chunk of output as it was written to a Recorder.
+type RecorderFrame struct {
+	Offset int64         // Position of the first byte in the raw capture
+	Length int           // Number of bytes
+	Time   time.Duration // Offset from the start of the recording
+}
+
+// Recorder captures a session for forensic or compliance purposes. It
+// keeps the exact bytes written to it, feeds them to a live screen and
+// indexes where each write started and when, so the capture can be both
+// inspected and proven unaltered:
+//
+//	rec := gopyte.NewRecorder(gopyte.NewHistoryScreen(132, 50, 10000))
+//	io.Copy(rec, conn)
+//	os.WriteFile("core1.raw", rec.DumpRaw(), 0o600)
+//	fmt.Printf("sha256 %x\n", rec.Digest())
+//
+// Unlike a Transcript, whose JSON encoding replaces invalid UTF-8, nothing
+// is ever re-serialized: DumpRaw returns the input byte for byte, whatever
+// it holds. It is safe for concurrent use; the screen is only updated
+// under the recorder's lock, so read it with Do while writes may happen.
+type Recorder struct {
+	mu     sync.Mutex
+	stream *Stream
+	screen Screen
+	raw    bytes.Buffer
+	frames []RecorderFrame
+	digest hash.Hash
+	clock  Clock
+	start  time.Time
+}
+
+// NewRecorder records into screen, timed by the system clock.
+func NewRecorder(screen Screen) *Recorder {
+	return NewRecorderWithClock(screen, SystemClock)
+}
+
+// NewRecorderWithClock records into screen, timed by c starting at
+// c.Now().
+func NewRecorderWithClock(screen Screen, c Clock) *Recorder {
+	return &Recorder{
+		stream: NewStream(screen, false),
+		screen: screen,
+		digest: sha256.New(),
+		clock:  c,
+		start:  c.Now(),
+	}
+}
+
+// Write records p as one frame and feeds it to the screen. It never
+// fails.
+func (r *Recorder) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames = append(r.frames, RecorderFrame{
+		Offset: int64(r.raw.Len()),
+		Length: len(p),
+		Time:   r.clock.Now().Sub(r.start),
+	})
+	r.raw.Write(p)
+	r.digest.Write(p)
+	r.stream.Feed(string(p))
+	return len(p), nil
+}
+
+// Stream returns the stream feeding the screen, for configuration such as
+// SetTranscript or SetWatchdog. Do not feed it directly: output that
+// bypasses Write is not recorded.
+func (r *Recorder) Stream() *Stream {
+	return r.stream
+}
+
+// Screen returns the live screen.
+func (r *Recorder) Screen() Screen {
+	return r.screen
+}
+
+// Do calls fn with the screen while no write is in progress.
+func (r *Recorder) Do(fn func(Screen)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(r.screen)
+}
+
+// DumpRaw returns a copy of every byte written, exactly as written.
+func (r *Recorder) DumpRaw() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return bytes.Clone(r.raw.Bytes())
+}
+
+// WriteTo writes the raw capture to w.
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, err := w.Write(r.raw.Bytes())
+	return int64(n), err
+}
+
+// Len returns the number of bytes recorded.
+func (r *Recorder) Len() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(r.raw.Len())
+}
+
+// Frames returns the index of writes in order.
+func (r *Recorder) Frames() []RecorderFrame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecorderFrame(nil), r.frames...)
+}
+
+// FrameBytes returns a copy of the bytes of frame i.
+func (r *Recorder) FrameBytes(i int) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i < 0 || i >= len(r.frames) {
+		return nil
+	}
+	f := r.frames[i]
+	return bytes.Clone(r.raw.Bytes()[f.Offset : f.Offset+int64(f.Length)])
+}
+
+// Digest returns the SHA-256 of the raw capture so far.
+func (r *Recorder) Digest() [sha256.Size]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sum [sha256.Size]byte
+	copy(sum[:], r.digest.Sum(nil))
+	return sum
+}
@@ -0,0 +1,143 @@
+package gopyte
+
+// Region is a rectangle of screen cells. All bounds are inclusive and
+// zero-based; Region{Top: 23, Bottom: 23, Left: 0, Right: 79} is the last
+// row of an 80x24 screen.
+type Region struct {
+	Top    int
+	Left   int
+	Bottom int
+	Right  int
+}
+
+// RegionWatcher receives the text of a watched region, one string per row,
+// after a change to any cell inside it.
+type RegionWatcher func(region Region, rows []string)
+
+// RegionChecker is implemented by screens that support region watches.
+// Stream calls CheckRegionWatches at the end of every Feed.
+type RegionChecker interface {
+	CheckRegionWatches()
+}
+
+type regionWatch struct {
+	id     int
+	region Region
+	fn     RegionWatcher
+	chars  []rune
+	attrs  []Attributes
+}
+
+// WatchRegion registers fn to be called whenever a cell inside region
+// changes, either its character or its attributes. Changes are detected
+// when CheckRegionWatches runs, which Stream does after each Feed, so a
+// burst of output fires the watcher at most once. Only the cells of the
+// region are compared; the rest of the display is not scanned.
+//
+// The returned function removes the watch.
+func (s *NativeScreen) WatchRegion(region Region, fn RegionWatcher) (cancel func()) {
+	s.nextWatchID++
+	w := &regionWatch{id: s.nextWatchID, region: region, fn: fn}
+	w.chars, w.attrs = s.snapshotRegion(region, nil, nil)
+	s.regionWatches = append(s.regionWatches, w)
+
+	id := w.id
+	return func() {
+		for i, rw := range s.regionWatches {
+			if rw.id == id {
+				s.regionWatches = append(s.regionWatches[:i:i], s.regionWatches[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// CheckRegionWatches compares every watched region with its state at the
+// previous check and calls the watchers of the regions that changed.
+func (s *NativeScreen) CheckRegionWatches() {
+	for _, w := range s.regionWatches {
+		chars, attrs := s.snapshotRegion(w.region, nil, nil)
+		if runesEqual(chars, w.chars) && attrsEqual(attrs, w.attrs) {
+			continue
+		}
+		w.chars, w.attrs = chars, attrs
+		w.fn(w.region, s.regionRows(w.region))
+	}
+}
+
+// clipRegion limits r to the screen. ok is false if nothing is left.
+func (s *NativeScreen) clipRegion(r Region) (Region, bool) {
+	if r.Top < 0 {
+		r.Top = 0
+	}
+	if r.Left < 0 {
+		r.Left = 0
+	}
+	if r.Bottom >= s.lines {
+		r.Bottom = s.lines - 1
+	}
+	if r.Right >= s.columns {
+		r.Right = s.columns - 1
+	}
+	return r, r.Top <= r.Bottom && r.Left <= r.Right
+}
+
+// snapshotRegion appends the characters and attributes of the cells in r
+// to chars and attrs, row by row.
+func (s *NativeScreen) snapshotRegion(r Region, chars []rune, attrs []Attributes) ([]rune, []Attributes) {
+	r, ok := s.clipRegion(r)
+	if !ok {
+		return chars, attrs
+	}
+	for y := r.Top; y <= r.Bottom; y++ {
+		chars = append(chars, s.buffer[y][r.Left:r.Right+1]...)
+		if y < len(s.attrs) && r.Right < len(s.attrs[y]) {
+			attrs = append(attrs, s.attrs[y][r.Left:r.Right+1]...)
+		}
+	}
+	return chars, attrs
+}
+
+// regionRows returns the text of r, one string per row. Wide character
+// continuation cells are left out.
+func (s *NativeScreen) regionRows(r Region) []string {
+	r, ok := s.clipRegion(r)
+	if !ok {
+		return nil
+	}
+	rows := make([]string, 0, r.Bottom-r.Top+1)
+	for y := r.Top; y <= r.Bottom; y++ {
+		row := make([]rune, 0, r.Right-r.Left+1)
+		for _, ch := range s.buffer[y][r.Left : r.Right+1] {
+			if ch != 0 {
+				row = append(row, ch)
+			}
+		}
+		rows = append(rows, string(row))
+	}
+	return rows
+}
+
+func runesEqual(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func attrsEqual(a, b []Attributes) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
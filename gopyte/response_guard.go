@@ -0,0 +1,133 @@
+package gopyte
+
+import (
+	"sync"
+	"time"
+)
+
+// ResponseKind names a class of automatic response a terminal sends back
+// to the attached process when it sees a query in the output stream.
+type ResponseKind string
+
+const (
+	ResponseDeviceAttributes ResponseKind = "DA"        // CSI c, CSI > c, CSI = c
+	ResponseDeviceStatus     ResponseKind = "DSR"       // CSI 5 n
+	ResponseCursorPosition   ResponseKind = "CPR"       // CSI 6 n
+	ResponseWindowReport     ResponseKind = "XTWINOPS"  // CSI 1x t
+	ResponseTermcap          ResponseKind = "XTGETTCAP" // DCS + q
+	ResponseOSCQuery         ResponseKind = "OSC"       // OSC 4/10/11 ... ; ?
+	ResponseClipboard        ResponseKind = "OSC52"     // OSC 52 ; ?
+	ResponseKeyboardFlags    ResponseKind = "KBFLAGS"   // CSI ? u
+)
+
+// ResponseGuardOptions configures a ResponseGuard.
+type ResponseGuardOptions struct {
+	Allow []ResponseKind // Kinds answered automatically (empty = none)
+	Rate  int            // Responses allowed per Per, also the burst size (0 = unlimited)
+	Per   time.Duration  // Rate window (0 = one second)
+	Clock Clock          // Time source (nil = SystemClock)
+}
+
+// ResponseGuard decides which automatic responses reach the attached
+// process. Queries are answered by writing to the process's input, so a
+// hostile byte stream - a capture replayed with cat, say - can use them to
+// flood the process or type into it. The guard answers only allowlisted
+// kinds and drops responses beyond a token-bucket rate. It is safe for
+// concurrent use and may be shared between screens.
+type ResponseGuard struct {
+	mu      sync.Mutex
+	allow   map[ResponseKind]bool
+	rate    float64
+	per     time.Duration
+	clock   Clock
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+// NewResponseGuard creates a guard from opts.
+func NewResponseGuard(opts ResponseGuardOptions) *ResponseGuard {
+	g := &ResponseGuard{
+		allow: make(map[ResponseKind]bool, len(opts.Allow)),
+		rate:  float64(opts.Rate),
+		per:   opts.Per,
+		clock: opts.Clock,
+	}
+	for _, k := range opts.Allow {
+		g.allow[k] = true
+	}
+	if g.per <= 0 {
+		g.per = time.Second
+	}
+	if g.clock == nil {
+		g.clock = SystemClock
+	}
+	g.tokens = g.rate
+	g.last = g.clock.Now()
+	return g
+}
+
+// DefaultResponseGuard answers the harmless identification and cursor
+// queries shells and editors rely on, at most 20 a second. Queries that
+// echo host-controlled data (window title, clipboard, termcap) are
+// refused.
+func DefaultResponseGuard() *ResponseGuard {
+	return NewResponseGuard(ResponseGuardOptions{
+		Allow: []ResponseKind{ResponseDeviceAttributes, ResponseDeviceStatus, ResponseCursorPosition, ResponseKeyboardFlags},
+		Rate:  20,
+	})
+}
+
+// Allow reports whether a response of kind may be sent now, consuming one
+// token if so.
+func (g *ResponseGuard) Allow(kind ResponseKind) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.allow[kind] {
+		g.dropped++
+		return false
+	}
+	if g.rate <= 0 {
+		return true
+	}
+
+	now := g.clock.Now()
+	if elapsed := now.Sub(g.last); elapsed > 0 {
+		g.tokens += g.rate * float64(elapsed) / float64(g.per)
+		if g.tokens > g.rate {
+			g.tokens = g.rate
+		}
+	}
+	g.last = now
+	if g.tokens < 1 {
+		g.dropped++
+		return false
+	}
+	g.tokens--
+	return true
+}
+
+// Dropped returns how many responses the guard has refused.
+func (g *ResponseGuard) Dropped() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.dropped
+}
+
+// SetResponseGuard filters the screen's automatic responses through g.
+// With no guard (the default) every response is sent.
+func (s *NativeScreen) SetResponseGuard(g *ResponseGuard) {
+	s.responseGuard = g
+}
+
+// Respond sends an automatic response of kind to the process through
+// WriteProcessInput, unless the response guard refuses it. It reports
+// whether the response was sent. Report handlers should answer through
+// Respond rather than writing to the process directly.
+func (s *NativeScreen) Respond(kind ResponseKind, data string) bool {
+	if s.responseGuard != nil && !s.responseGuard.Allow(kind) {
+		return false
+	}
+	s.WriteProcessInput(data)
+	return true
+}
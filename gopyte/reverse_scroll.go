@@ -0,0 +1,63 @@
+package gopyte
+
+// ReverseScrollPolicy decides what a HistoryScreen puts on the top row
+// when a reverse index (ESC M) at the top of the screen scrolls the
+// content down.
+type ReverseScrollPolicy int
+
+const (
+	// ReverseScrollRestore pulls the most recent scrollback line back onto
+	// the top row, undoing the scroll that pushed it there. The bottom line
+	// is discarded. This keeps the scrollback consistent with the screen
+	// for programs that scroll back and forth over the same output.
+	ReverseScrollRestore ReverseScrollPolicy = iota
+
+	// ReverseScrollBlank inserts a blank top row and leaves the scrollback
+	// alone, as xterm does.
+	ReverseScrollBlank
+)
+
+// SetReverseScrollPolicy selects the behaviour of reverse scrolling. The
+// default is ReverseScrollRestore. The alternate screen has no scrollback
+// and always inserts a blank row.
+func (h *HistoryScreen) SetReverseScrollPolicy(p ReverseScrollPolicy) {
+	h.reverseScroll = p
+}
+
+// ReverseIndex moves the cursor up one line, scrolling the screen down at
+// the top according to the reverse scroll policy.
+func (h *HistoryScreen) ReverseIndex() {
+	if h.viewingHistory {
+		h.ScrollToBottom()
+	}
+	h.cursor.wrapPending = false
+	if h.cursor.Y > 0 {
+		h.cursor.Y--
+		return
+	}
+
+	h.scrollDown()
+	if _, _, ok := h.LeftRightMargins(); ok {
+		return
+	}
+	if h.reverseScroll != ReverseScrollRestore || h.history.Len() == 0 {
+		return
+	}
+	back := h.history.Back()
+	line := h.history.Remove(back).(HistoryLine)
+	h.lineBase--
+	for x := 0; x < h.columns; x++ {
+		h.buffer[0][x] = ' '
+		h.attrs[0][x] = h.styles.at(line.styles, x)
+		if x < len(line.Chars) {
+			h.buffer[0][x] = line.Chars[x]
+		}
+	}
+	if len(h.cellWidths) == h.lines {
+		// The scroll left the row single-width; wide characters come back
+		// with their continuation cells
+		h.cellWidths[0] = widthsOf(h.buffer[:1])[0]
+	}
+	h.setWrapped(0, line.Wrapped)
+	h.meta[0] = line.Meta.clone() // History lines are shared with clones
+}
@@ -0,0 +1,259 @@
+package gopyte
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateOptions controls when a RotatingFile starts a new file and what
+// happens to the old ones.
+type RotateOptions struct {
+	MaxSize    int64         // Rotate before a write would exceed this many bytes (0 = no limit)
+	MaxAge     time.Duration // Rotate once the file is older than this (0 = no limit)
+	MaxBackups int           // Rotated files to keep (0 = keep all)
+	Compress   bool          // gzip rotated files
+	Clock      Clock         // Time source for MaxAge and file names (nil = SystemClock)
+}
+
+// RotatingFile is an append-only log file that rotates by size and/or age.
+// Rotated files are renamed to "<name>-<timestamp><ext>" next to the
+// original, optionally gzipped, and pruned to MaxBackups. It is safe for
+// concurrent use.
+type RotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	opts    RotateOptions
+	file    *os.File // nil after Close, or when reopening after a rotation failed
+	closed  bool
+	size    int64
+	opened  time.Time
+	clock   Clock
+	rotated int // Disambiguates rotations within the same timestamp
+}
+
+// OpenRotatingFile opens (appending to) or creates the log file at path.
+// Missing directories are created.
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, opts: opts, clock: opts.Clock}
+	if r.clock == nil {
+		r.clock = SystemClock
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	r.opened = r.clock.Now()
+	return nil
+}
+
+// Write appends p, rotating first if p would push the file past MaxSize or
+// the file has reached MaxAge. A single write larger than MaxSize still
+// goes to one file.
+//
+// A failed rotation does not stop the log: p is still written, to the
+// old file or the new one, and the rotation error is returned with the
+// count written.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.live(); err != nil {
+		return 0, err
+	}
+
+	due := r.opts.MaxAge > 0 && r.clock.Now().Sub(r.opened) >= r.opts.MaxAge
+	if r.opts.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.opts.MaxSize {
+		due = true
+	}
+	var rotateErr error
+	if due {
+		if rotateErr = r.rotate(); r.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Rotate starts a new file now, regardless of size and age. On error the
+// log stays open, on the old file if it could not be renamed.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.live(); err != nil {
+		return err
+	}
+	return r.rotate()
+}
+
+// live makes sure a file is open, reopening the log when an earlier
+// rotation could not. The caller holds r.mu.
+func (r *RotatingFile) live() error {
+	if r.closed {
+		return os.ErrClosed
+	}
+	if r.file == nil {
+		return r.open()
+	}
+	return nil
+}
+
+// Close closes the current file. Rotated files are left as they are.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		r.closed = true
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.closed = true
+	return err
+}
+
+// rotate renames the current file and opens a fresh one. Whatever fails,
+// a file is open again afterwards unless reopening fails too; compressing
+// and pruning are done once the fresh file is open. The caller holds
+// r.mu.
+func (r *RotatingFile) rotate() error {
+	err := r.file.Close()
+	r.file = nil
+	if err != nil {
+		return firstErr(err, r.open())
+	}
+
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext)
+	stamp := r.clock.Now().Format("20060102-150405")
+	backup := fmt.Sprintf("%s-%s%s", base, stamp, ext)
+	for exists(backup) || exists(backup+".gz") {
+		r.rotated++
+		backup = fmt.Sprintf("%s-%s.%d%s", base, stamp, r.rotated, ext)
+	}
+	if err := os.Rename(r.path, backup); err != nil {
+		return firstErr(err, r.open())
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	if r.opts.Compress {
+		if err := gzipFile(backup); err != nil {
+			return err
+		}
+	}
+	return r.prune(base, ext)
+}
+
+// firstErr returns the first non-nil error.
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prune removes the oldest backups beyond MaxBackups.
+func (r *RotatingFile) prune(base, ext string) error {
+	if r.opts.MaxBackups <= 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(base + "-*" + ext + "*")
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, m := range matches {
+		if strings.HasSuffix(m, ext) || strings.HasSuffix(m, ext+".gz") {
+			backups = append(backups, m)
+		}
+	}
+	if len(backups) <= r.opts.MaxBackups {
+		return nil
+	}
+	// Oldest first: sort by modification time, then name
+	sort.Slice(backups, func(i, j int) bool {
+		a, errA := os.Stat(backups[i])
+		b, errB := os.Stat(backups[j])
+		if errA == nil && errB == nil && !a.ModTime().Equal(b.ModTime()) {
+			return a.ModTime().Before(b.ModTime())
+		}
+		return backups[i] < backups[j]
+	})
+	for _, old := range backups[:len(backups)-r.opts.MaxBackups] {
+		if err := os.Remove(old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = compress(in, path+".gz")
+	// Closed before the remove, which Windows refuses on an open file
+	in.Close()
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// compress writes r gzipped to dst, removing dst again when that fails
+// part way so no truncated archive is left behind.
+func compress(r io.Reader, dst string) error {
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, r)
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,292 @@
+package gopyte
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Template is a TextFSM-style state machine that turns command output into
+// records. A template declares values and states:
+//
+//	Value Required Interface (\S+)
+//	Value Status (up|down|administratively down)
+//	Value Filldown Vrf (\S+)
+//
+//	Start
+//	  ^VRF ${Vrf}
+//	  ^${Interface}\s+\S+\s+${Status} -> Record
+//
+// Value options are Required (records without the value are dropped) and
+// Filldown (the value is kept across records). Rules are regular
+// expressions anchored with ^ in which ${Name} stands for the value's
+// pattern and $$ for end of line. A rule may end in
+// "-> [LineAction][.RecordAction] [State]" with line actions Next (default)
+// and Continue, record actions Record, NoRecord, Clear and Clearall, and any
+// declared state, "End" or "Error" as the target. Like TextFSM, a final
+// Record is implied at the end of input, but not on reaching End.
+type Template struct {
+	values []*templateValue
+	states map[string][]*templateRule
+}
+
+// Record is one row produced by a Template, keyed by value name.
+type Record map[string]string
+
+type templateValue struct {
+	name     string
+	pattern  string
+	required bool
+	filldown bool
+}
+
+type templateRule struct {
+	re         *regexp.Regexp
+	lineAction string // "Next" or "Continue"
+	recAction  string // "", "Record", "NoRecord", "Clear" or "Clearall"
+	newState   string
+	line       int
+}
+
+var (
+	valueDecl     = regexp.MustCompile(`^Value\s+((?:(?:Required|Filldown),?)*\s+)?(\w+)\s+(\(.*\))\s*$`)
+	valueRef      = regexp.MustCompile(`\$\{(\w+)\}`)
+	templateState = regexp.MustCompile(`^\w+$`)
+)
+
+// ParseTemplate compiles a template.
+func ParseTemplate(src string) (*Template, error) {
+	t := &Template{states: make(map[string][]*templateRule)}
+	byName := make(map[string]*templateValue)
+
+	state := ""
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+
+		case strings.HasPrefix(line, "Value "):
+			if state != "" {
+				return nil, fmt.Errorf("template line %d: Value after states", n)
+			}
+			m := valueDecl.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("template line %d: bad Value declaration", n)
+			}
+			v := &templateValue{name: m[2], pattern: m[3]}
+			for _, opt := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' }) {
+				switch opt {
+				case "Required":
+					v.required = true
+				case "Filldown":
+					v.filldown = true
+				}
+			}
+			if _, err := regexp.Compile(v.pattern); err != nil {
+				return nil, fmt.Errorf("template line %d: value %s: %w", n, v.name, err)
+			}
+			if byName[v.name] != nil {
+				return nil, fmt.Errorf("template line %d: duplicate value %s", n, v.name)
+			}
+			byName[v.name] = v
+			t.values = append(t.values, v)
+
+		case line == trimmed:
+			// Unindented: a state name
+			if !templateState.MatchString(line) {
+				return nil, fmt.Errorf("template line %d: bad state name %q", n, line)
+			}
+			state = line
+			if _, ok := t.states[state]; ok {
+				return nil, fmt.Errorf("template line %d: duplicate state %s", n, state)
+			}
+			t.states[state] = nil
+
+		default:
+			if state == "" {
+				return nil, fmt.Errorf("template line %d: rule outside a state", n)
+			}
+			rule, err := parseRule(trimmed, byName)
+			if err != nil {
+				return nil, fmt.Errorf("template line %d: %w", n, err)
+			}
+			rule.line = n
+			t.states[state] = append(t.states[state], rule)
+		}
+	}
+
+	if _, ok := t.states["Start"]; !ok {
+		return nil, fmt.Errorf("template: missing Start state")
+	}
+	for _, rules := range t.states {
+		for _, r := range rules {
+			if r.newState == "" || r.newState == "End" || r.newState == "Error" {
+				continue
+			}
+			if _, ok := t.states[r.newState]; !ok {
+				return nil, fmt.Errorf("template line %d: unknown state %s", r.line, r.newState)
+			}
+		}
+	}
+	return t, nil
+}
+
+// MustParseTemplate is like ParseTemplate but panics on error. It is meant
+// for templates compiled into the program.
+func MustParseTemplate(src string) *Template {
+	t, err := ParseTemplate(src)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func parseRule(text string, values map[string]*templateValue) (*templateRule, error) {
+	if !strings.HasPrefix(text, "^") {
+		return nil, fmt.Errorf("rule must start with ^: %q", text)
+	}
+	rule := &templateRule{lineAction: "Next"}
+
+	pattern := text
+	if i := strings.LastIndex(text, " -> "); i >= 0 {
+		pattern = strings.TrimSpace(text[:i])
+		fields := strings.Fields(text[i+4:])
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("bad action %q", text[i+4:])
+		}
+		action := fields[0]
+		if len(fields) == 2 {
+			rule.newState = fields[1]
+		}
+		line, rec, _ := strings.Cut(action, ".")
+		switch line {
+		case "Next", "Continue":
+			rule.lineAction = line
+		case "Record", "NoRecord", "Clear", "Clearall":
+			if rec != "" {
+				return nil, fmt.Errorf("bad action %q", action)
+			}
+			rec = line
+		default:
+			if len(fields) == 2 || rec != "" {
+				return nil, fmt.Errorf("bad action %q", action)
+			}
+			rule.newState = line // Bare state name
+		}
+		switch rec {
+		case "", "Record", "NoRecord", "Clear", "Clearall":
+			rule.recAction = rec
+		default:
+			return nil, fmt.Errorf("bad record action %q", rec)
+		}
+		if rule.lineAction == "Continue" && rule.newState != "" {
+			return nil, fmt.Errorf("Continue cannot change state")
+		}
+	}
+
+	var missing string
+	expanded := valueRef.ReplaceAllStringFunc(pattern, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		v, ok := values[name]
+		if !ok {
+			missing = name
+			return ref
+		}
+		return "(?P<" + name + ">" + v.pattern[1:len(v.pattern)-1] + ")"
+	})
+	if missing != "" {
+		return nil, fmt.Errorf("unknown value %s", missing)
+	}
+	re, err := regexp.Compile(strings.ReplaceAll(expanded, "$$", "$"))
+	if err != nil {
+		return nil, err
+	}
+	rule.re = re
+	return rule, nil
+}
+
+// Parse runs the template over lines and returns the records produced.
+func (t *Template) Parse(lines []string) ([]Record, error) {
+	cur := make(Record, len(t.values))
+	var records []Record
+
+	record := func() {
+		empty := true
+		for _, v := range t.values {
+			if cur[v.name] == "" {
+				if v.required {
+					t.clear(cur, false)
+					return
+				}
+				continue
+			}
+			if !v.filldown {
+				empty = false
+			}
+		}
+		if !empty {
+			r := make(Record, len(t.values))
+			for _, v := range t.values {
+				r[v.name] = cur[v.name]
+			}
+			records = append(records, r)
+		}
+		t.clear(cur, false)
+	}
+
+	state := "Start"
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \x00")
+	rules:
+		for _, rule := range t.states[state] {
+			m := rule.re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			for i, name := range rule.re.SubexpNames() {
+				if name != "" && m[i] != "" {
+					cur[name] = m[i]
+				}
+			}
+
+			switch rule.recAction {
+			case "Record":
+				record()
+			case "Clear":
+				t.clear(cur, false)
+			case "Clearall":
+				t.clear(cur, true)
+			}
+
+			switch rule.newState {
+			case "":
+			case "End":
+				// Stop without the implicit final record
+				return records, nil
+			case "Error":
+				return nil, fmt.Errorf("template line %d: error state reached on %q", rule.line, line)
+			default:
+				state = rule.newState
+			}
+			if rule.lineAction == "Next" {
+				break rules
+			}
+		}
+	}
+	record()
+	return records, nil
+}
+
+// clear resets the current values. Filldown values survive unless all is
+// set.
+func (t *Template) clear(cur Record, all bool) {
+	for _, v := range t.values {
+		if all || !v.filldown {
+			cur[v.name] = ""
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package gopyte
 
 import (
-	"fmt"
+	"io"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 // Screen represents a native Go terminal screen
@@ -17,9 +19,33 @@ type NativeScreen struct {
 	cursor Cursor
 	saved  *Cursor // For save/restore cursor
 
+	stateStack []ScreenState // PushState/PopState (see state_stack.go)
+
 	// Simple state
-	title    string
-	iconName string
+	title      string
+	iconName   string
+	onTitle    func(string) // See title.go
+	onIconName func(string)
+	window     WindowState // See window.go
+	onWindow   func(WindowEvent)
+	onResize   func(lines, columns int)
+	onEvent    func(Event) // See events.go
+	eventHold  int
+	heldEvents []Event
+	titleStack []string // CSI 22 t / CSI 23 t
+	iconStack  []string
+	cellPixels [2]int // Cell width and height for pixel reports, 0 if unknown
+
+	onDeviceControl func(DeviceControl) // See dcs.go
+	termcap         map[string]string   // XTGETTCAP answers, nil for the defaults (see termcap.go)
+
+	// OSC colors and hyperlinks (see osc.go)
+	onColor     func(ColorChange)
+	colors      map[int]RGB // Colors changed by the program (see palette.go)
+	baseColors  map[int]RGB
+	hyperlink   Hyperlink
+	onHyperlink func(Hyperlink)
+	clipboard   ClipboardProvider // See clipboard.go
 
 	// Modes (we'll add as needed)
 	autoWrap    bool
@@ -27,6 +53,104 @@ type NativeScreen struct {
 
 	// Tab stops
 	tabStops map[int]bool
+
+	// DECSCUSR cursor style (see cursor_style.go)
+	cursorStyle CursorStyle
+
+	// Soft-wrap flags, one per line (see wrap.go)
+	wrapped []bool
+
+	// Windows ConPTY compatibility (see conpty_compat.go)
+	conptyMode     bool
+	win32InputMode bool
+
+	// Mouse reporting requested by the program (see mouse.go)
+	mouse MouseProtocol
+
+	// Bracketed paste (see paste.go)
+	bracketedPaste bool
+
+	// DECCKM and DECNKM (see keys.go)
+	appCursorKeys bool
+	appKeypad     bool
+
+	// Kitty keyboard protocol flag stack, current last (see kitty_keyboard.go)
+	keyboardFlags []int
+
+	// Focus reporting (see focus.go)
+	focusReporting bool
+
+	// DECOM (see origin.go)
+	originMode bool
+
+	// DECLRMM and the DECSLRM margins, 1-based, 0 for the screen edge
+	// (see margins.go)
+	lrMarginMode            bool
+	leftMargin, rightMargin int
+
+	// Input transcript (see transcript.go)
+	transcript *Transcript
+
+	// Where responses to queries go, nil to drop them
+	responseWriter io.Writer
+
+	// Desktop notifications (OSC 9 / OSC 777)
+	notifications  []Notification
+	onNotification func(Notification)
+
+	// iTerm2 attention and badge state (OSC 1337)
+	attention   AttentionMode
+	onAttention func(AttentionMode)
+	badgeFormat string
+
+	// Region watches (see region_watch.go)
+	regionWatches []*regionWatch
+	nextWatchID   int
+
+	// Lines scrolled off the top (see session_log.go)
+	onLineCommit func(line string, wrapped bool)
+
+	// Time source (see clock.go)
+	clock Clock
+
+	// Carriage-return repaint tracking (see progress.go)
+	repainted []bool
+	crPending bool
+	crRow     int
+
+	// Embedder metadata per line (see meta.go)
+	meta []*LineMeta
+
+	// Cell widths of a WideCharScreen (0 = continuation, 1 = normal, 2 =
+	// wide start), nil for the other screens. They live here so that rows
+	// moved by scrolling and margins take their widths along.
+	cellWidths [][]int
+
+	// Automatic response filter (see response_guard.go)
+	responseGuard *ResponseGuard
+
+	// Cell damage baseline (see damage.go)
+	cellDamage     bool
+	shadowText     [][]rune
+	shadowAttrs    [][]Attributes
+	shadowClusters []map[int]string
+	shadowCursor   Cursor
+
+	// Dirty line baseline (see dirty.go)
+	dirtyText     [][]rune
+	dirtyAttrs    [][]Attributes
+	dirtyClusters []map[int]string
+	dirtyCursor   Cursor
+
+	// Scratch line for AppendDisplay (see display.go)
+	lineBuf []rune
+
+	// Copy and paste selection, nil if none (see selection.go)
+	selection *Selection
+
+	// Word and URL selection, empty for the defaults (see word_select.go)
+	wordSeparators string
+	urlPattern     *regexp.Regexp
 }
 
 type Margins struct {
@@ -35,9 +159,10 @@ type Margins struct {
 }
 
 type Cell struct {
-	Char  rune
-	Attrs Attributes
-	Width int // 0 for continuation, 1 for normal, 2 for wide
+	Char    rune
+	Cluster string // Rest of a grapheme cluster starting with Char (see clusters.go)
+	Attrs   Attributes
+	Width   int // 0 for continuation, 1 for normal, 2 for wide
 }
 
 type Cursor struct {
@@ -45,22 +170,31 @@ type Cursor struct {
 	Y      int
 	Attrs  Attributes // Current drawing attributes
 	Hidden bool       // For DECTCEM mode
+
+	// wrapPending is set when a character was written to the last column
+	// with autowrap on; any cursor movement clears it (see advanceCursor).
+	wrapPending bool
 }
 
 type Attributes struct {
-	Fg            string // Foreground color ("default", "red", etc.)
-	Bg            string // Background color
+	Fg            Color // Foreground color (see color.go)
+	Bg            Color // Background color
 	Bold          bool
 	Italics       bool
 	Underscore    bool
 	Strikethrough bool
 	Reverse       bool
 	Blink         bool
+
+	// Underline style and color (see sgr.go). Underscore is set whenever
+	// the style is not UnderlineNone.
+	UnderlineStyle UnderlineStyle
+	UnderlineColor Color
 }
 
 // NewNativeScreen creates a new terminal screen
 
-func NewNativeScreen(columns, lines int) *NativeScreen {
+func NewNativeScreen(columns, lines int, opts ...ScreenOption) *NativeScreen {
 	s := &NativeScreen{
 		columns:     columns,
 		lines:       lines,
@@ -70,6 +204,7 @@ func NewNativeScreen(columns, lines int) *NativeScreen {
 		autoWrap:    true,
 		newlineMode: true, // Default to Unix behavior where LF implies CR
 		tabStops:    make(map[int]bool),
+		wrapped:     make([]bool, lines),
 	}
 
 	// Initialize buffer with spaces
@@ -86,33 +221,86 @@ func NewNativeScreen(columns, lines int) *NativeScreen {
 		s.tabStops[i] = true
 	}
 
+	for _, opt := range opts {
+		opt(s)
+	}
 	return s
 }
 
 func (s *NativeScreen) Draw(text string) {
-	for _, ch := range text {
-		// Check if we need to wrap
-		if s.cursor.X >= s.columns {
-			if s.autoWrap {
-				s.cursor.X = 0
-				s.cursor.Y++
-				if s.cursor.Y >= s.lines {
-					s.scrollUp()
-					s.cursor.Y = s.lines - 1
-				}
-			} else {
-				s.cursor.X = s.columns - 1
+	s.noteRepaint()
+	s.drawText(text, s.scrollUp)
+}
+
+// drawText writes text at the cursor one rune per cell, a line's worth at
+// a time: the runes that fit before the right margin are copied in one go
+// and the cursor advanced once. scroll moves the screen up a line when
+// text wraps at the bottom.
+func (s *NativeScreen) drawText(text string, scroll func()) {
+	for text != "" {
+		// Wrap if the previous character filled the last column
+		if s.cursor.wrapPending {
+			s.cursor.wrapPending = false
+			s.setWrapped(s.cursor.Y, true)
+			s.cursor.X = s.lineStart()
+			s.cursor.Y++
+			if s.cursor.Y >= s.lines {
+				scroll()
+				s.cursor.Y = s.lines - 1
 			}
 		}
 
-		// Place character
-		if s.cursor.Y < s.lines && s.cursor.X < s.columns {
-			s.buffer[s.cursor.Y][s.cursor.X] = ch
-			s.cursor.X++
+		y, x := s.cursor.Y, s.cursor.X
+		if y >= s.lines || x >= s.columns {
+			return
 		}
+		row := s.buffer[y][x : s.lineEnd()+1]
+		n, i := 0, 0
+		for n < len(row) && i < len(text) {
+			if c := text[i]; c < utf8.RuneSelf {
+				row[n] = rune(c)
+				i++
+			} else {
+				r, size := utf8.DecodeRuneInString(text[i:])
+				row[n] = r
+				i += size
+			}
+			n++
+		}
+		fillAttrs(s.attrs[y][x:x+n], s.cursor.Attrs)
+		s.markLinks(y, x, x+n-1)
+		s.advanceCursor(n)
+		text = text[i:]
 	}
 }
 
+// fillAttrs sets every element of attrs to a.
+func fillAttrs(attrs []Attributes, a Attributes) {
+	for i := range attrs {
+		attrs[i] = a
+	}
+}
+
+// advanceCursor moves the cursor past a character n cells wide that was
+// just written. At the last column (or the right margin, see margins.go)
+// the cursor stays put and, with autowrap on, the wrap is left pending
+// until the next printable character, as xterm does: CR, BS or CUP in
+// between act on the last column instead.
+func (s *NativeScreen) advanceCursor(n int) {
+	end := s.lineEnd()
+	s.cursor.X += n
+	if s.cursor.X > end {
+		s.cursor.X = end
+		s.cursor.wrapPending = s.autoWrap
+	}
+}
+
+// WrapPending reports whether the cursor is on the last column with a wrap
+// pending: the next printable character goes to the start of the next line.
+func (s *NativeScreen) WrapPending() bool {
+	return s.cursor.wrapPending
+}
+
 // 8. SavePoint support (for DECSC/DECRC)
 type Savepoint struct {
 	Cursor    Cursor
@@ -124,16 +312,18 @@ type Savepoint struct {
 }
 
 func (s *NativeScreen) Bell() {
-	// No-op for screen emulation
+	s.emit(Event{Type: EventBell})
 }
 
 func (s *NativeScreen) Backspace() {
+	s.cursor.wrapPending = false
 	if s.cursor.X > 0 {
 		s.cursor.X--
 	}
 }
 
 func (s *NativeScreen) Tab() {
+	s.cursor.wrapPending = false
 	// Move to next tab stop
 	for x := s.cursor.X + 1; x < s.columns; x++ {
 		if s.tabStops[x] {
@@ -145,6 +335,7 @@ func (s *NativeScreen) Tab() {
 }
 
 func (s *NativeScreen) Linefeed() {
+	s.cursor.wrapPending = false
 	s.cursor.Y++
 	if s.cursor.Y >= s.lines {
 		s.scrollUp()
@@ -152,25 +343,27 @@ func (s *NativeScreen) Linefeed() {
 	}
 	// In newline mode (typical for Unix), LF also does CR
 	if s.newlineMode {
-		s.cursor.X = 0
+		s.cursor.X = s.lineStart()
 	}
 }
 
 func (s *NativeScreen) CarriageReturn() {
-	s.cursor.X = 0
+	s.cursor.wrapPending = false
+	s.cursor.X = s.lineStart()
+	s.noteCarriageReturn()
 }
 
-func (s *NativeScreen) ShiftOut() {
-	// Character set switching - implement when needed
-}
+// ShiftOut and ShiftIn need nothing from the screen: the Stream keeps the
+// G0-G3 designations and translates text before it is drawn (see
+// Stream.draw).
+func (s *NativeScreen) ShiftOut() {}
 
-func (s *NativeScreen) ShiftIn() {
-	// Character set switching - implement when needed
-}
+func (s *NativeScreen) ShiftIn() {}
 
 // === Cursor Movement ===
 
 func (s *NativeScreen) CursorUp(count int) {
+	s.cursor.wrapPending = false
 	s.cursor.Y -= count
 	if s.cursor.Y < 0 {
 		s.cursor.Y = 0
@@ -178,6 +371,7 @@ func (s *NativeScreen) CursorUp(count int) {
 }
 
 func (s *NativeScreen) CursorDown(count int) {
+	s.cursor.wrapPending = false
 	s.cursor.Y += count
 	if s.cursor.Y >= s.lines {
 		s.cursor.Y = s.lines - 1
@@ -185,6 +379,7 @@ func (s *NativeScreen) CursorDown(count int) {
 }
 
 func (s *NativeScreen) CursorForward(count int) {
+	s.cursor.wrapPending = false
 	s.cursor.X += count
 	if s.cursor.X >= s.columns {
 		s.cursor.X = s.columns - 1
@@ -192,6 +387,7 @@ func (s *NativeScreen) CursorForward(count int) {
 }
 
 func (s *NativeScreen) CursorBack(count int) {
+	s.cursor.wrapPending = false
 	s.cursor.X -= count
 	if s.cursor.X < 0 {
 		s.cursor.X = 0
@@ -199,6 +395,7 @@ func (s *NativeScreen) CursorBack(count int) {
 }
 
 func (s *NativeScreen) CursorUp1(count int) {
+	s.cursor.wrapPending = false
 	// Move up and to column 0
 	s.cursor.Y -= count
 	if s.cursor.Y < 0 {
@@ -208,6 +405,7 @@ func (s *NativeScreen) CursorUp1(count int) {
 }
 
 func (s *NativeScreen) CursorDown1(count int) {
+	s.cursor.wrapPending = false
 	// Move down and to column 0
 	s.cursor.Y += count
 	if s.cursor.Y >= s.lines {
@@ -217,45 +415,35 @@ func (s *NativeScreen) CursorDown1(count int) {
 }
 
 func (s *NativeScreen) CursorPosition(line, column int) {
-	// Convert from 1-based to 0-based
-	s.cursor.Y = line - 1
-	s.cursor.X = column - 1
+	s.conptyWrapHint(line, column)
+	s.cursor.wrapPending = false
 
-	// Clamp to bounds
-	if s.cursor.Y < 0 {
-		s.cursor.Y = 0
-	} else if s.cursor.Y >= s.lines {
-		s.cursor.Y = s.lines - 1
-	}
-
-	if s.cursor.X < 0 {
-		s.cursor.X = 0
-	} else if s.cursor.X >= s.columns {
-		s.cursor.X = s.columns - 1
-	}
+	// Convert from 1-based to 0-based and clamp to bounds (see origin.go)
+	top, bottom, left, right := s.originBounds()
+	s.cursor.Y = min(max(top+line-1, top), bottom)
+	s.cursor.X = min(max(left+column-1, left), right)
 }
 
+// CursorToColumn handles CHA and HPA.
 func (s *NativeScreen) CursorToColumn(column int) {
-	s.cursor.X = column - 1
-	if s.cursor.X < 0 {
-		s.cursor.X = 0
-	} else if s.cursor.X >= s.columns {
-		s.cursor.X = s.columns - 1
-	}
+	s.cursor.wrapPending = false
+	_, _, left, right := s.originBounds()
+	s.cursor.X = min(max(left+column-1, left), right)
 }
 
+// CursorToLine handles VPA.
 func (s *NativeScreen) CursorToLine(line int) {
-	s.cursor.Y = line - 1
-	if s.cursor.Y < 0 {
-		s.cursor.Y = 0
-	} else if s.cursor.Y >= s.lines {
-		s.cursor.Y = s.lines - 1
-	}
+	s.cursor.wrapPending = false
+	top, bottom, _, _ := s.originBounds()
+	s.cursor.Y = min(max(top+line-1, top), bottom)
 }
 
 // === Screen Manipulation ===
 
 func (s *NativeScreen) Reset() {
+	defer s.holdEvents()()
+	defer s.watchModes()()
+
 	// Clear everything
 	for i := 0; i < s.lines; i++ {
 		for j := 0; j < s.columns; j++ {
@@ -264,13 +452,26 @@ func (s *NativeScreen) Reset() {
 		}
 	}
 
+	s.clearWrapped(0, s.lines-1)
+	s.selection = nil
+
 	// Reset cursor
 	s.cursor = Cursor{X: 0, Y: 0}
 	s.saved = nil
+	s.cursorStyle = CursorStyleDefault
 
 	// Reset modes
 	s.autoWrap = true
+	s.originMode = false
+	s.setLeftRightMarginMode(false)
 	s.newlineMode = true
+	s.win32InputMode = false
+	s.mouse = MouseProtocol{}
+	s.bracketedPaste = false
+	s.appCursorKeys = false
+	s.appKeypad = false
+	s.keyboardFlags = nil
+	s.focusReporting = false
 
 	// Reset tab stops
 	s.tabStops = make(map[int]bool)
@@ -286,6 +487,8 @@ func (s *NativeScreen) scrollWithinMargins(top, bottom int) {
 		s.attrs[y] = s.attrs[y+1]
 	}
 
+	s.shiftWrappedUp(top, bottom)
+
 	// Clear the bottom line in margin
 	s.buffer[bottom] = make([]rune, s.columns)
 	s.attrs[bottom] = make([]Attributes, s.columns)
@@ -297,28 +500,42 @@ func (s *NativeScreen) scrollWithinMargins(top, bottom int) {
 
 func DefaultAttributes() Attributes {
 	return Attributes{
-		Fg: "default",
-		Bg: "default",
+		Fg: DefaultColor(),
+		Bg: DefaultColor(),
 	}
 }
 
 func (s *NativeScreen) SelectGraphicRendition(params []int) {
-	if len(params) == 0 || (len(params) == 1 && params[0] == 0) {
+	groups := make([][]int, len(params))
+	for i := range params {
+		groups[i] = params[i : i+1]
+	}
+	s.SelectGraphicRenditionGroups(groups)
+}
+
+// SelectGraphicRenditionGroups applies SGR parameters with their colon
+// subparameters (see sgr.go).
+func (s *NativeScreen) SelectGraphicRenditionGroups(groups [][]int) {
+	if len(groups) == 0 || (len(groups) == 1 && groups[0][0] == 0) {
 		// Reset all attributes
 		s.cursor.Attrs = DefaultAttributes()
 		return
 	}
 
-	for i := 0; i < len(params); i++ {
-		switch params[i] {
+	for i := 0; i < len(groups); i++ {
+		switch groups[i][0] {
 		case 0: // Reset
 			s.cursor.Attrs = DefaultAttributes()
 		case 1: // Bold
 			s.cursor.Attrs.Bold = true
 		case 3: // Italic
 			s.cursor.Attrs.Italics = true
-		case 4: // Underline
-			s.cursor.Attrs.Underscore = true
+		case 4: // Underline, 4:0 to 4:5 with a style
+			style := UnderlineSingle
+			if len(groups[i]) > 1 {
+				style = UnderlineStyle(groups[i][1])
+			}
+			s.cursor.Attrs.setUnderline(style)
 		case 5: // Blink
 			s.cursor.Attrs.Blink = true
 		case 7: // Reverse
@@ -329,8 +546,10 @@ func (s *NativeScreen) SelectGraphicRendition(params []int) {
 			s.cursor.Attrs.Bold = false
 		case 23: // Not italic
 			s.cursor.Attrs.Italics = false
+		case 21: // Double underline
+			s.cursor.Attrs.setUnderline(UnderlineDouble)
 		case 24: // Not underline
-			s.cursor.Attrs.Underscore = false
+			s.cursor.Attrs.setUnderline(UnderlineNone)
 		case 25: // Not blink
 			s.cursor.Attrs.Blink = false
 		case 27: // Not reverse
@@ -338,67 +557,37 @@ func (s *NativeScreen) SelectGraphicRendition(params []int) {
 		case 29: // Not strikethrough
 			s.cursor.Attrs.Strikethrough = false
 		// Foreground colors
-		case 30:
-			s.cursor.Attrs.Fg = "black"
-		case 31:
-			s.cursor.Attrs.Fg = "red"
-		case 32:
-			s.cursor.Attrs.Fg = "green"
-		case 33:
-			s.cursor.Attrs.Fg = "brown"
-		case 34:
-			s.cursor.Attrs.Fg = "blue"
-		case 35:
-			s.cursor.Attrs.Fg = "magenta"
-		case 36:
-			s.cursor.Attrs.Fg = "cyan"
-		case 37:
-			s.cursor.Attrs.Fg = "white"
+		case 30, 31, 32, 33, 34, 35, 36, 37:
+			s.cursor.Attrs.Fg = NamedColor(groups[i][0] - 30)
 		case 39:
-			s.cursor.Attrs.Fg = "default"
+			s.cursor.Attrs.Fg = DefaultColor()
 		// Background colors
-		case 40:
-			s.cursor.Attrs.Bg = "black"
-		case 41:
-			s.cursor.Attrs.Bg = "red"
-		case 42:
-			s.cursor.Attrs.Bg = "green"
-		case 43:
-			s.cursor.Attrs.Bg = "brown"
-		case 44:
-			s.cursor.Attrs.Bg = "blue"
-		case 45:
-			s.cursor.Attrs.Bg = "magenta"
-		case 46:
-			s.cursor.Attrs.Bg = "cyan"
-		case 47:
-			s.cursor.Attrs.Bg = "white"
+		case 40, 41, 42, 43, 44, 45, 46, 47:
+			s.cursor.Attrs.Bg = NamedColor(groups[i][0] - 40)
 		case 49:
-			s.cursor.Attrs.Bg = "default"
-		// 256 colors
-		case 38, 48:
-			if i+2 < len(params) && params[i+1] == 5 {
-				// 256 color mode
-				color := params[i+2]
-				if params[i] == 38 {
-					s.cursor.Attrs.Fg = color256ToString(color)
-				} else {
-					s.cursor.Attrs.Bg = color256ToString(color)
+			s.cursor.Attrs.Bg = DefaultColor()
+		// 256 colors and direct colors, for text, background and underline
+		case 38, 48, 58:
+			color, used, ok := extendedColor(groups[i:])
+			if ok {
+				switch groups[i][0] {
+				case 38:
+					s.cursor.Attrs.Fg = color
+				case 48:
+					s.cursor.Attrs.Bg = color
+				case 58:
+					s.cursor.Attrs.UnderlineColor = color
 				}
-				i += 2
 			}
+			i += used
+		case 59: // Default underline color
+			s.cursor.Attrs.UnderlineColor = DefaultColor()
 		}
 	}
 }
 
-// Helper for 256 color conversion
-func color256ToString(n int) string {
-	// For now, just return the number as string
-	// Could map to actual color names or RGB values
-	return fmt.Sprintf("color%d", n)
-}
-
 func (s *NativeScreen) Index() {
+	s.cursor.wrapPending = false
 	// Move cursor down, scroll if needed
 	s.cursor.Y++
 	if s.cursor.Y >= s.lines {
@@ -408,6 +597,7 @@ func (s *NativeScreen) Index() {
 }
 
 func (s *NativeScreen) ReverseIndex() {
+	s.cursor.wrapPending = false
 	// Move cursor up, scroll if needed
 	s.cursor.Y--
 	if s.cursor.Y < 0 {
@@ -436,18 +626,30 @@ func (s *NativeScreen) SaveCursor() {
 
 func (s *NativeScreen) RestoreCursor() {
 	if s.saved != nil {
+		// Visibility is a mode (DECTCEM), not part of the saved cursor
+		hidden := s.cursor.Hidden
 		s.cursor = *s.saved
+		s.cursor.Hidden = hidden
 	}
 }
 
 // === Line Operations ===
 
 func (s *NativeScreen) InsertLines(count int) {
+	if _, _, ok := s.LeftRightMargins(); ok {
+		// Only the columns between the margins move (see margins.go)
+		for i := 0; i < count && i < s.lines-s.cursor.Y && !s.outsideMargins(); i++ {
+			s.moveMarginRows(s.cursor.Y, s.lines-1, 1)
+		}
+		return
+	}
+
 	// Insert blank lines at cursor position
 	for i := 0; i < count && s.cursor.Y < s.lines; i++ {
 		// Shift lines down
 		copy(s.buffer[s.cursor.Y+1:], s.buffer[s.cursor.Y:s.lines-1])
 		copy(s.attrs[s.cursor.Y+1:], s.attrs[s.cursor.Y:s.lines-1])
+		s.shiftWrappedDown(s.cursor.Y, s.lines-1)
 
 		// Clear the inserted line
 		s.buffer[s.cursor.Y] = make([]rune, s.columns)
@@ -459,6 +661,13 @@ func (s *NativeScreen) InsertLines(count int) {
 }
 
 func (s *NativeScreen) DeleteLines(count int) {
+	if _, _, ok := s.LeftRightMargins(); ok {
+		for i := 0; i < count && i < s.lines-s.cursor.Y && !s.outsideMargins(); i++ {
+			s.moveMarginRows(s.cursor.Y, s.lines-1, -1)
+		}
+		return
+	}
+
 	// Delete lines at cursor position
 	for i := 0; i < count && s.cursor.Y < s.lines; i++ {
 		// Shift lines up
@@ -466,6 +675,7 @@ func (s *NativeScreen) DeleteLines(count int) {
 			copy(s.buffer[s.cursor.Y:], s.buffer[s.cursor.Y+1:])
 			copy(s.attrs[s.cursor.Y:], s.attrs[s.cursor.Y+1:])
 		}
+		s.shiftWrappedUp(s.cursor.Y, s.lines-1)
 
 		// Clear the last line
 		lastLine := s.lines - 1
@@ -478,25 +688,49 @@ func (s *NativeScreen) DeleteLines(count int) {
 }
 
 func (s *NativeScreen) InsertCharacters(count int) {
-	// Insert spaces at cursor position
+	if s.outsideMargins() {
+		return
+	}
+	// Insert spaces at cursor position, up to the right margin
+	end := s.lineEnd() + 1
 	line := s.buffer[s.cursor.Y]
-	for i := 0; i < count && s.cursor.X < s.columns; i++ {
+	for i := 0; i < count && s.cursor.X < end; i++ {
 		// Shift characters right
-		copy(line[s.cursor.X+1:], line[s.cursor.X:s.columns-1])
+		copy(line[s.cursor.X+1:end], line[s.cursor.X:end-1])
 		line[s.cursor.X] = ' '
 	}
+	s.lineMeta(s.cursor.Y).shiftCells(s.cursor.X, count, end)
+	s.shiftWidths(func(widths []int) { shiftCellsRight(widths, count, 1) }, end)
 }
 
 func (s *NativeScreen) DeleteCharacters(count int) {
-	// Delete characters at cursor position
+	if s.outsideMargins() {
+		return
+	}
+	// Delete characters at cursor position, up to the right margin
+	end := s.lineEnd() + 1
 	line := s.buffer[s.cursor.Y]
-	for i := 0; i < count && s.cursor.X < s.columns; i++ {
+	for i := 0; i < count && s.cursor.X < end; i++ {
 		// Shift characters left
-		if s.cursor.X < s.columns-1 {
-			copy(line[s.cursor.X:], line[s.cursor.X+1:])
+		if s.cursor.X < end-1 {
+			copy(line[s.cursor.X:end], line[s.cursor.X+1:end])
 		}
-		line[s.columns-1] = ' '
+		line[end-1] = ' '
+	}
+	s.lineMeta(s.cursor.Y).shiftCells(s.cursor.X, -count, end)
+	s.shiftWidths(func(widths []int) { shiftCellsLeft(widths, count, 1) }, end)
+}
+
+// shiftWidths applies a character insertion or deletion to the cell widths
+// of the cursor row from the cursor up to end, and blanks the wide
+// characters it broke.
+func (s *NativeScreen) shiftWidths(edit func(widths []int), end int) {
+	y, x := s.cursor.Y, s.cursor.X
+	if len(s.cellWidths) != s.lines || x >= end {
+		return
 	}
+	edit(s.cellWidths[y][x:end])
+	s.repairWide(y, max(s.lineStart(), x-1), end-1)
 }
 
 func (s *NativeScreen) EraseCharacters(count int) {
@@ -504,6 +738,7 @@ func (s *NativeScreen) EraseCharacters(count int) {
 	for i := 0; i < count && s.cursor.X+i < s.columns; i++ {
 		s.buffer[s.cursor.Y][s.cursor.X+i] = ' '
 	}
+	s.clearCellMeta(s.cursor.Y, s.cursor.X, s.cursor.X+count-1)
 }
 
 func (s *NativeScreen) EraseInLine(how int, private bool) {
@@ -512,14 +747,21 @@ func (s *NativeScreen) EraseInLine(how int, private bool) {
 		for x := s.cursor.X; x < s.columns; x++ {
 			s.buffer[s.cursor.Y][x] = ' '
 		}
+		s.clearCellMeta(s.cursor.Y, s.cursor.X, s.columns-1)
+		s.eraseWidths(s.cursor.Y, s.cursor.X, s.columns-1)
 	case 1: // From beginning to cursor
 		for x := 0; x <= s.cursor.X && x < s.columns; x++ {
 			s.buffer[s.cursor.Y][x] = ' '
 		}
+		s.clearCellMeta(s.cursor.Y, 0, s.cursor.X)
+		s.eraseWidths(s.cursor.Y, 0, s.cursor.X)
 	case 2: // Entire line
 		for x := 0; x < s.columns; x++ {
 			s.buffer[s.cursor.Y][x] = ' '
 		}
+		s.setWrapped(s.cursor.Y, false)
+		s.clearLineMeta(s.cursor.Y)
+		s.eraseWidths(s.cursor.Y, 0, s.columns-1)
 	}
 }
 
@@ -527,36 +769,76 @@ func (s *NativeScreen) EraseInDisplay(how int) {
 	switch how {
 	case 0: // From cursor to end
 		s.EraseInLine(0, false)
+		s.clearWrapped(s.cursor.Y, s.lines-1)
 		for y := s.cursor.Y + 1; y < s.lines; y++ {
 			for x := 0; x < s.columns; x++ {
 				s.buffer[y][x] = ' '
 			}
+			s.eraseWidths(y, 0, s.columns-1)
 		}
 	case 1: // From beginning to cursor
 		s.EraseInLine(1, false)
+		s.clearWrapped(0, s.cursor.Y-1)
 		for y := 0; y < s.cursor.Y; y++ {
 			for x := 0; x < s.columns; x++ {
 				s.buffer[y][x] = ' '
 			}
+			s.eraseWidths(y, 0, s.columns-1)
 		}
 	case 2, 3: // Entire screen
+		s.clearWrapped(0, s.lines-1)
 		for y := 0; y < s.lines; y++ {
 			for x := 0; x < s.columns; x++ {
 				s.buffer[y][x] = ' '
 			}
+			s.eraseWidths(y, 0, s.columns-1)
 		}
 	}
 }
 
+// eraseWidths makes the erased columns from..to of row y single width and
+// blanks the other half of a wide character cut at either end.
+func (s *NativeScreen) eraseWidths(y, from, to int) {
+	if len(s.cellWidths) != s.lines {
+		return
+	}
+	to = min(to, s.columns-1)
+	for x := from; x <= to; x++ {
+		s.cellWidths[y][x] = 1
+	}
+	s.splitWide(y, from)
+	s.splitWide(y, to+1)
+}
+
 // === Stubs for now ===
 
 func (s *NativeScreen) SetMode(modes []int, private bool) {
+	defer s.holdEvents()()
+	defer s.watchModes()()
 	for _, mode := range modes {
 		if private {
 			// Private modes (DEC modes)
 			switch mode {
+			case 6: // DECOM - Origin mode
+				s.setOriginMode(true)
 			case 7: // DECAWM - Auto wrap mode
 				s.autoWrap = true
+			case 25: // DECTCEM - Show cursor
+				s.cursor.Hidden = false
+			case 69: // DECLRMM - Left/right margin mode
+				s.setLeftRightMarginMode(true)
+			case Win32InputMode:
+				s.win32InputMode = true
+			case 9, 1000, 1002, 1003, 1005, 1006, 1015, 1016: // Mouse reporting
+				s.setMouseMode(mode, true)
+			case BracketedPasteMode:
+				s.bracketedPaste = true
+			case CursorKeysMode: // DECCKM
+				s.appCursorKeys = true
+			case KeypadMode: // DECNKM
+				s.appKeypad = true
+			case FocusReportingMode:
+				s.focusReporting = true
 				// Add other private modes as needed
 			}
 		} else {
@@ -571,12 +853,32 @@ func (s *NativeScreen) SetMode(modes []int, private bool) {
 }
 
 func (s *NativeScreen) ResetMode(modes []int, private bool) {
+	defer s.holdEvents()()
+	defer s.watchModes()()
 	for _, mode := range modes {
 		if private {
 			// Private modes (DEC modes)
 			switch mode {
+			case 6: // DECOM - Origin mode
+				s.setOriginMode(false)
 			case 7: // DECAWM - Auto wrap mode
 				s.autoWrap = false
+			case 25: // DECTCEM - Hide cursor
+				s.cursor.Hidden = true
+			case 69: // DECLRMM - Left/right margin mode
+				s.setLeftRightMarginMode(false)
+			case Win32InputMode:
+				s.win32InputMode = false
+			case 9, 1000, 1002, 1003, 1005, 1006, 1015, 1016: // Mouse reporting
+				s.setMouseMode(mode, false)
+			case BracketedPasteMode:
+				s.bracketedPaste = false
+			case CursorKeysMode: // DECCKM
+				s.appCursorKeys = false
+			case KeypadMode: // DECNKM
+				s.appKeypad = false
+			case FocusReportingMode:
+				s.focusReporting = false
 				// Add other private modes as needed
 			}
 		} else {
@@ -590,28 +892,46 @@ func (s *NativeScreen) ResetMode(modes []int, private bool) {
 	}
 }
 
-func (s *NativeScreen) DefineCharset(code, mode string) {
-	// TODO: Implement charset switching
-}
+// DefineCharset is told about ESC ( ) * + designations, which the Stream
+// applies itself; like ShiftOut it needs nothing from the screen.
+func (s *NativeScreen) DefineCharset(code, mode string) {}
 
 func (s *NativeScreen) SetMargins(top, bottom int) {
 	// TODO: Implement scroll regions
 }
 
-func (s *NativeScreen) ReportDeviceAttributes(mode int, private bool) {
-	// TODO: Implement if needed
-}
-
-func (s *NativeScreen) ReportDeviceStatus(mode int) {
-	// TODO: Implement if needed
+// SetTitle sets the window title. The value is sanitized (see title.go)
+// and the title handler runs if it changed.
+func (s *NativeScreen) SetTitle(title string) {
+	s.setTitle(sanitizeTitle(s.decodeTitle(title)))
 }
 
-func (s *NativeScreen) SetTitle(title string) {
+// setTitle sets an already sanitized title.
+func (s *NativeScreen) setTitle(title string) {
+	if title == s.title {
+		return
+	}
 	s.title = title
+	if s.onTitle != nil {
+		s.onTitle(title)
+	}
+	s.emit(Event{Type: EventTitle, Text: title})
 }
 
+// SetIconName sets the icon name, sanitized like SetTitle.
 func (s *NativeScreen) SetIconName(name string) {
+	s.setIconName(sanitizeTitle(s.decodeTitle(name)))
+}
+
+func (s *NativeScreen) setIconName(name string) {
+	if name == s.iconName {
+		return
+	}
 	s.iconName = name
+	if s.onIconName != nil {
+		s.onIconName(name)
+	}
+	s.emit(Event{Type: EventIconName, Text: name})
 }
 
 func (s *NativeScreen) AlignmentDisplay() {
@@ -627,16 +947,39 @@ func (s *NativeScreen) Debug(args ...interface{}) {
 	// Could log somewhere if needed
 }
 
+// SetResponseWriter sets where the screen's responses to queries (device
+// attributes, cursor position reports and so on) are written; normally
+// the pty the process runs on. Writes happen during Stream.Feed, on the
+// goroutine that feeds the stream, and errors are ignored. Pass nil to
+// drop responses again.
+func (s *NativeScreen) SetResponseWriter(w io.Writer) {
+	s.responseWriter = w
+}
+
+// WriteProcessInput sends data to the process through the response
+// writer, recording it in the transcript if there is one. Report
+// handlers should go through Respond, which applies the response guard.
 func (s *NativeScreen) WriteProcessInput(data string) {
-	// This would write back to the process - not needed for basic emulation
+	if s.transcript != nil {
+		s.transcript.RecordInput(data)
+	}
+	if s.responseWriter != nil {
+		_, _ = io.WriteString(s.responseWriter, data)
+	}
 }
 
 // === Helper methods ===
 
 func (s *NativeScreen) scrollUp() {
+	if s.scrollMargins(true) {
+		return
+	}
+	s.commitLine(0)
+
 	// Move all lines up by one
 	copy(s.buffer[0:], s.buffer[1:])
 	copy(s.attrs[0:], s.attrs[1:])
+	s.shiftWrappedUp(0, s.lines-1)
 
 	// Clear the last line
 	lastLine := s.lines - 1
@@ -648,9 +991,13 @@ func (s *NativeScreen) scrollUp() {
 }
 
 func (s *NativeScreen) scrollDown() {
+	if s.scrollMargins(false) {
+		return
+	}
 	// Move all lines down by one
 	copy(s.buffer[1:], s.buffer[0:s.lines-1])
 	copy(s.attrs[1:], s.attrs[0:s.lines-1])
+	s.shiftWrappedDown(0, s.lines-1)
 
 	// Clear the first line
 	s.buffer[0] = make([]rune, s.columns)
@@ -674,6 +1021,12 @@ func (s *NativeScreen) GetCursor() (int, int) {
 	return s.cursor.X, s.cursor.Y
 }
 
+// GetCursorObject returns the cursor, including its visibility: Hidden is
+// set while the application has hidden it with DECTCEM (CSI ? 25 l).
+func (s *NativeScreen) GetCursorObject() *Cursor {
+	return &s.cursor
+}
+
 // Resize adjusts columns/lines on the base NativeScreen.
 // - Column shrink: hard-truncate each row; grow: right-pad with spaces + default attrs
 // - Row shrink: drop bottom rows; grow: append blank rows
@@ -689,6 +1042,7 @@ func (s *NativeScreen) Resize(newCols, newLines int) {
 
 	oldCols := s.columns
 	oldLines := s.lines
+	s.selection = nil
 
 	// Columns
 	if newCols != oldCols {
@@ -746,23 +1100,12 @@ func (s *NativeScreen) Resize(newCols, newLines int) {
 	s.columns = newCols
 	s.lines = newLines
 
-	// Clamp cursor
-	if s.cursor.Y >= s.lines {
-		s.cursor.Y = s.lines - 1
-	}
-	if s.cursor.X >= s.columns {
-		s.cursor.X = s.columns - 1
-	}
-	if s.cursor.Y < 0 {
-		s.cursor.Y = 0
-	}
-	if s.cursor.X < 0 {
-		s.cursor.X = 0
-	}
+	s.clampCursor()
 
 	// Rebuild tab stops
 	s.tabStops = make(map[int]bool)
 	for i := 0; i < s.columns; i += 8 {
 		s.tabStops[i] = true
 	}
+	s.emit(Event{Type: EventResize, Columns: newCols, Lines: newLines})
 }
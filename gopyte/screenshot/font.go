@@ -0,0 +1,200 @@
+package screenshot
+
+// The embedded font: 5x9 pixel glyphs for printable ASCII and Latin-1,
+// drawn for this package. Each row is a byte whose bit 4 is the leftmost
+// pixel. Capitals take rows 0-6 and descenders rows 7-8. Accented letters
+// are composed from the ASCII ones.
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 9
+	baseline    = 6 // Last row of a capital
+)
+
+var asciiFont = [0x7f - 0x20][glyphHeight]uint8{
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // ' '
+	{0x04, 0x04, 0x04, 0x04, 0x04, 0x00, 0x04, 0x00, 0x00}, // '!'
+	{0x0a, 0x0a, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // '"'
+	{0x0a, 0x0a, 0x1f, 0x0a, 0x1f, 0x0a, 0x0a, 0x00, 0x00}, // '#'
+	{0x04, 0x0f, 0x14, 0x0e, 0x05, 0x1e, 0x04, 0x00, 0x00}, // '$'
+	{0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03, 0x00, 0x00}, // '%'
+	{0x0c, 0x12, 0x14, 0x08, 0x15, 0x12, 0x0d, 0x00, 0x00}, // '&'
+	{0x04, 0x04, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // '\''
+	{0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02, 0x00, 0x00}, // '('
+	{0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08, 0x00, 0x00}, // ')'
+	{0x00, 0x04, 0x15, 0x0e, 0x15, 0x04, 0x00, 0x00, 0x00}, // '*'
+	{0x00, 0x04, 0x04, 0x1f, 0x04, 0x04, 0x00, 0x00, 0x00}, // '+'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x0c, 0x04, 0x08}, // ','
+	{0x00, 0x00, 0x00, 0x1f, 0x00, 0x00, 0x00, 0x00, 0x00}, // '-'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x0c, 0x00, 0x00}, // '.'
+	{0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00, 0x00, 0x00}, // '/'
+	{0x0e, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0e, 0x00, 0x00}, // '0'
+	{0x04, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x0e, 0x00, 0x00}, // '1'
+	{0x0e, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1f, 0x00, 0x00}, // '2'
+	{0x1f, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0e, 0x00, 0x00}, // '3'
+	{0x02, 0x06, 0x0a, 0x12, 0x1f, 0x02, 0x02, 0x00, 0x00}, // '4'
+	{0x1f, 0x10, 0x1e, 0x01, 0x01, 0x11, 0x0e, 0x00, 0x00}, // '5'
+	{0x06, 0x08, 0x10, 0x1e, 0x11, 0x11, 0x0e, 0x00, 0x00}, // '6'
+	{0x1f, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08, 0x00, 0x00}, // '7'
+	{0x0e, 0x11, 0x11, 0x0e, 0x11, 0x11, 0x0e, 0x00, 0x00}, // '8'
+	{0x0e, 0x11, 0x11, 0x0f, 0x01, 0x02, 0x0c, 0x00, 0x00}, // '9'
+	{0x00, 0x0c, 0x0c, 0x00, 0x0c, 0x0c, 0x00, 0x00, 0x00}, // ':'
+	{0x00, 0x0c, 0x0c, 0x00, 0x0c, 0x0c, 0x04, 0x08, 0x00}, // ';'
+	{0x02, 0x04, 0x08, 0x10, 0x08, 0x04, 0x02, 0x00, 0x00}, // '<'
+	{0x00, 0x00, 0x1f, 0x00, 0x1f, 0x00, 0x00, 0x00, 0x00}, // '='
+	{0x08, 0x04, 0x02, 0x01, 0x02, 0x04, 0x08, 0x00, 0x00}, // '>'
+	{0x0e, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04, 0x00, 0x00}, // '?'
+	{0x0e, 0x11, 0x01, 0x0d, 0x15, 0x15, 0x0e, 0x00, 0x00}, // '@'
+	{0x0e, 0x11, 0x11, 0x1f, 0x11, 0x11, 0x11, 0x00, 0x00}, // 'A'
+	{0x1e, 0x11, 0x11, 0x1e, 0x11, 0x11, 0x1e, 0x00, 0x00}, // 'B'
+	{0x0e, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0e, 0x00, 0x00}, // 'C'
+	{0x1c, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1c, 0x00, 0x00}, // 'D'
+	{0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x1f, 0x00, 0x00}, // 'E'
+	{0x1f, 0x10, 0x10, 0x1e, 0x10, 0x10, 0x10, 0x00, 0x00}, // 'F'
+	{0x0e, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0f, 0x00, 0x00}, // 'G'
+	{0x11, 0x11, 0x11, 0x1f, 0x11, 0x11, 0x11, 0x00, 0x00}, // 'H'
+	{0x0e, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0e, 0x00, 0x00}, // 'I'
+	{0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0c, 0x00, 0x00}, // 'J'
+	{0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11, 0x00, 0x00}, // 'K'
+	{0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1f, 0x00, 0x00}, // 'L'
+	{0x11, 0x1b, 0x15, 0x15, 0x11, 0x11, 0x11, 0x00, 0x00}, // 'M'
+	{0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11, 0x00, 0x00}, // 'N'
+	{0x0e, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e, 0x00, 0x00}, // 'O'
+	{0x1e, 0x11, 0x11, 0x1e, 0x10, 0x10, 0x10, 0x00, 0x00}, // 'P'
+	{0x0e, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0d, 0x00, 0x00}, // 'Q'
+	{0x1e, 0x11, 0x11, 0x1e, 0x14, 0x12, 0x11, 0x00, 0x00}, // 'R'
+	{0x0f, 0x10, 0x10, 0x0e, 0x01, 0x01, 0x1e, 0x00, 0x00}, // 'S'
+	{0x1f, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x00, 0x00}, // 'T'
+	{0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e, 0x00, 0x00}, // 'U'
+	{0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x04, 0x00, 0x00}, // 'V'
+	{0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0a, 0x00, 0x00}, // 'W'
+	{0x11, 0x11, 0x0a, 0x04, 0x0a, 0x11, 0x11, 0x00, 0x00}, // 'X'
+	{0x11, 0x11, 0x11, 0x0a, 0x04, 0x04, 0x04, 0x00, 0x00}, // 'Y'
+	{0x1f, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1f, 0x00, 0x00}, // 'Z'
+	{0x0e, 0x08, 0x08, 0x08, 0x08, 0x08, 0x0e, 0x00, 0x00}, // '['
+	{0x00, 0x10, 0x08, 0x04, 0x02, 0x01, 0x00, 0x00, 0x00}, // '\\'
+	{0x0e, 0x02, 0x02, 0x02, 0x02, 0x02, 0x0e, 0x00, 0x00}, // ']'
+	{0x04, 0x0a, 0x11, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // '^'
+	{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1f, 0x00}, // '_'
+	{0x08, 0x04, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // '`'
+	{0x00, 0x00, 0x0e, 0x01, 0x0f, 0x11, 0x0f, 0x00, 0x00}, // 'a'
+	{0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x1e, 0x00, 0x00}, // 'b'
+	{0x00, 0x00, 0x0e, 0x10, 0x10, 0x11, 0x0e, 0x00, 0x00}, // 'c'
+	{0x01, 0x01, 0x0d, 0x13, 0x11, 0x11, 0x0f, 0x00, 0x00}, // 'd'
+	{0x00, 0x00, 0x0e, 0x11, 0x1f, 0x10, 0x0e, 0x00, 0x00}, // 'e'
+	{0x06, 0x09, 0x08, 0x1c, 0x08, 0x08, 0x08, 0x00, 0x00}, // 'f'
+	{0x00, 0x00, 0x0f, 0x11, 0x11, 0x0f, 0x01, 0x11, 0x0e}, // 'g'
+	{0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x11, 0x00, 0x00}, // 'h'
+	{0x04, 0x00, 0x0c, 0x04, 0x04, 0x04, 0x0e, 0x00, 0x00}, // 'i'
+	{0x02, 0x00, 0x06, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0c}, // 'j'
+	{0x10, 0x10, 0x12, 0x14, 0x18, 0x14, 0x12, 0x00, 0x00}, // 'k'
+	{0x0c, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0e, 0x00, 0x00}, // 'l'
+	{0x00, 0x00, 0x1a, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00}, // 'm'
+	{0x00, 0x00, 0x16, 0x19, 0x11, 0x11, 0x11, 0x00, 0x00}, // 'n'
+	{0x00, 0x00, 0x0e, 0x11, 0x11, 0x11, 0x0e, 0x00, 0x00}, // 'o'
+	{0x00, 0x00, 0x1e, 0x11, 0x11, 0x1e, 0x10, 0x10, 0x10}, // 'p'
+	{0x00, 0x00, 0x0f, 0x11, 0x11, 0x0f, 0x01, 0x01, 0x01}, // 'q'
+	{0x00, 0x00, 0x16, 0x19, 0x10, 0x10, 0x10, 0x00, 0x00}, // 'r'
+	{0x00, 0x00, 0x0f, 0x10, 0x0e, 0x01, 0x1e, 0x00, 0x00}, // 's'
+	{0x08, 0x08, 0x1c, 0x08, 0x08, 0x09, 0x06, 0x00, 0x00}, // 't'
+	{0x00, 0x00, 0x11, 0x11, 0x11, 0x13, 0x0d, 0x00, 0x00}, // 'u'
+	{0x00, 0x00, 0x11, 0x11, 0x11, 0x0a, 0x04, 0x00, 0x00}, // 'v'
+	{0x00, 0x00, 0x11, 0x11, 0x15, 0x15, 0x0a, 0x00, 0x00}, // 'w'
+	{0x00, 0x00, 0x11, 0x0a, 0x04, 0x0a, 0x11, 0x00, 0x00}, // 'x'
+	{0x00, 0x00, 0x11, 0x11, 0x11, 0x0f, 0x01, 0x11, 0x0e}, // 'y'
+	{0x00, 0x00, 0x1f, 0x02, 0x04, 0x08, 0x1f, 0x00, 0x00}, // 'z'
+	{0x02, 0x04, 0x04, 0x08, 0x04, 0x04, 0x02, 0x00, 0x00}, // '{'
+	{0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x00, 0x00}, // '|'
+	{0x08, 0x04, 0x04, 0x02, 0x04, 0x04, 0x08, 0x00, 0x00}, // '}'
+	{0x00, 0x00, 0x08, 0x15, 0x02, 0x00, 0x00, 0x00, 0x00}, // '~'
+}
+
+// latin1 holds the Latin-1 characters drawn whole rather than composed.
+var latin1 = map[rune][glyphHeight]uint8{
+	'¡': {0x04, 0x00, 0x04, 0x04, 0x04, 0x04, 0x04, 0x00, 0x00},
+	'¢': {0x04, 0x0e, 0x15, 0x14, 0x15, 0x0e, 0x04, 0x00, 0x00},
+	'£': {0x06, 0x09, 0x08, 0x1c, 0x08, 0x08, 0x1f, 0x00, 0x00},
+	'¥': {0x11, 0x0a, 0x04, 0x1f, 0x04, 0x1f, 0x04, 0x00, 0x00},
+	'¦': {0x04, 0x04, 0x04, 0x00, 0x04, 0x04, 0x04, 0x00, 0x00},
+	'§': {0x0e, 0x10, 0x0e, 0x11, 0x0e, 0x01, 0x0e, 0x00, 0x00},
+	'«': {0x00, 0x05, 0x0a, 0x14, 0x0a, 0x05, 0x00, 0x00, 0x00},
+	'¬': {0x00, 0x00, 0x00, 0x1f, 0x01, 0x01, 0x00, 0x00, 0x00},
+	'°': {0x0c, 0x12, 0x12, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x00},
+	'±': {0x04, 0x04, 0x1f, 0x04, 0x04, 0x00, 0x1f, 0x00, 0x00},
+	'µ': {0x00, 0x00, 0x11, 0x11, 0x11, 0x13, 0x1d, 0x10, 0x10},
+	'·': {0x00, 0x00, 0x00, 0x0c, 0x0c, 0x00, 0x00, 0x00, 0x00},
+	'»': {0x00, 0x14, 0x0a, 0x05, 0x0a, 0x14, 0x00, 0x00, 0x00},
+	'¿': {0x04, 0x00, 0x04, 0x08, 0x10, 0x11, 0x0e, 0x00, 0x00},
+	'Æ': {0x0f, 0x14, 0x14, 0x1f, 0x14, 0x14, 0x17, 0x00, 0x00},
+	'Ð': {0x0e, 0x09, 0x09, 0x1d, 0x09, 0x09, 0x0e, 0x00, 0x00},
+	'×': {0x00, 0x11, 0x0a, 0x04, 0x0a, 0x11, 0x00, 0x00, 0x00},
+	'Ø': {0x0f, 0x13, 0x15, 0x15, 0x15, 0x19, 0x1e, 0x00, 0x00},
+	'Þ': {0x10, 0x1e, 0x11, 0x11, 0x1e, 0x10, 0x10, 0x00, 0x00},
+	'ß': {0x0c, 0x12, 0x12, 0x16, 0x11, 0x11, 0x16, 0x00, 0x00},
+	'æ': {0x00, 0x00, 0x1a, 0x05, 0x1f, 0x14, 0x0b, 0x00, 0x00},
+	'ð': {0x14, 0x08, 0x14, 0x02, 0x0f, 0x11, 0x0e, 0x00, 0x00},
+	'÷': {0x00, 0x04, 0x00, 0x1f, 0x00, 0x04, 0x00, 0x00, 0x00},
+	'ø': {0x00, 0x00, 0x0f, 0x13, 0x15, 0x19, 0x1e, 0x00, 0x00},
+	'þ': {0x10, 0x10, 0x1e, 0x11, 0x11, 0x11, 0x1e, 0x10, 0x10},
+}
+
+// Accents, as the two rows drawn over a letter.
+var (
+	grave      = [2]uint8{0x08, 0x04}
+	acute      = [2]uint8{0x02, 0x04}
+	circumflex = [2]uint8{0x04, 0x0a}
+	tilde      = [2]uint8{0x05, 0x0a}
+	diaeresis  = [2]uint8{0x0a, 0x00}
+	ring       = [2]uint8{0x0e, 0x0a}
+)
+
+// accented maps the accented letters of Latin-1 to an ASCII letter and
+// its accent. Ç and ç take a cedilla instead.
+var accented = map[rune]struct {
+	base   rune
+	accent [2]uint8
+}{
+	'À': {'A', grave}, 'Á': {'A', acute}, 'Â': {'A', circumflex}, 'Ã': {'A', tilde}, 'Ä': {'A', diaeresis}, 'Å': {'A', ring},
+	'Ç': {'C', [2]uint8{}},
+	'È': {'E', grave}, 'É': {'E', acute}, 'Ê': {'E', circumflex}, 'Ë': {'E', diaeresis},
+	'Ì': {'I', grave}, 'Í': {'I', acute}, 'Î': {'I', circumflex}, 'Ï': {'I', diaeresis},
+	'Ñ': {'N', tilde},
+	'Ò': {'O', grave}, 'Ó': {'O', acute}, 'Ô': {'O', circumflex}, 'Õ': {'O', tilde}, 'Ö': {'O', diaeresis},
+	'Ù': {'U', grave}, 'Ú': {'U', acute}, 'Û': {'U', circumflex}, 'Ü': {'U', diaeresis},
+	'Ý': {'Y', acute},
+	'à': {'a', grave}, 'á': {'a', acute}, 'â': {'a', circumflex}, 'ã': {'a', tilde}, 'ä': {'a', diaeresis}, 'å': {'a', ring},
+	'ç': {'c', [2]uint8{}},
+	'è': {'e', grave}, 'é': {'e', acute}, 'ê': {'e', circumflex}, 'ë': {'e', diaeresis},
+	'ì': {'i', grave}, 'í': {'i', acute}, 'î': {'i', circumflex}, 'ï': {'i', diaeresis},
+	'ñ': {'n', tilde},
+	'ò': {'o', grave}, 'ó': {'o', acute}, 'ô': {'o', circumflex}, 'õ': {'o', tilde}, 'ö': {'o', diaeresis},
+	'ù': {'u', grave}, 'ú': {'u', acute}, 'û': {'u', circumflex}, 'ü': {'u', diaeresis},
+	'ý': {'y', acute}, 'ÿ': {'y', diaeresis},
+}
+
+// glyph returns the embedded glyph for ch: ASCII, or Latin-1 drawn whole
+// or composed.
+func glyph(ch rune) ([glyphHeight]uint8, bool) {
+	if ch > ' ' && ch < 0x7f {
+		return asciiFont[ch-0x20], true
+	}
+	if g, ok := latin1[ch]; ok {
+		return g, true
+	}
+	a, ok := accented[ch]
+	if !ok {
+		return [glyphHeight]uint8{}, false
+	}
+	g := asciiFont[a.base-0x20]
+	switch {
+	case a.base == 'C' || a.base == 'c':
+		g[7], g[8] = 0x04, 0x0c // Cedilla
+	case a.base < 'a':
+		// Capitals lose two rows to make room above them
+		g = [glyphHeight]uint8{a.accent[0], a.accent[1], g[0], g[2], g[3], g[4], g[6], g[7], g[8]}
+	default:
+		// Lowercase letters have the room; i loses its dot
+		g[0], g[1] = a.accent[0], a.accent[1]
+	}
+	return g, true
+}
@@ -0,0 +1,348 @@
+// Package screenshot rasterizes gopyte screens to images and PNG files,
+// for documentation tooling and visual regression tests. It needs no
+// fonts on the system: text is drawn with a small embedded bitmap font
+// covering ASCII and Latin-1, box drawing and block elements are drawn as
+// shapes, and other characters - CJK, emoji - as placeholders of the
+// right width, so that the layout of a screen always shows. A font face
+// set in Options draws the characters the embedded font lacks.
+package screenshot
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Source is the part of a screen a screenshot reads. NativeScreen,
+// HistoryScreen, AlternateScreen and WideCharScreen implement it.
+type Source interface {
+	GetLineCells(y int) []gopyte.Cell
+	Color(target gopyte.ColorTarget, index int) gopyte.RGB
+	GetCursorObject() *gopyte.Cursor
+}
+
+// Options configures Render.
+type Options struct {
+	Scale  int  // Pixels per font pixel; 0 means 2, giving 12x22 pixel cells
+	Cursor bool // Draw the cursor, unless it is hidden
+
+	// Face draws the characters the embedded font lacks and has no shape
+	// for, centered in their cells; nil leaves them as placeholders. Its
+	// size should suit the cells: 12x22 pixels, two columns for wide
+	// characters, at the default scale.
+	Face font.Face
+}
+
+// Cell size in font pixels: the glyph plus a column and two rows of space.
+const (
+	cellWidth  = glyphWidth + 1
+	cellHeight = glyphHeight + 2
+)
+
+// Render draws the screen, one cell per cellWidth x cellHeight block of
+// font pixels. Colors come from the screen's palette.
+func Render(src Source, opts Options) *image.RGBA {
+	scale := opts.Scale
+	if scale <= 0 {
+		scale = 2
+	}
+	var rows [][]gopyte.Cell
+	for y := 0; ; y++ {
+		cells := src.GetLineCells(y)
+		if cells == nil {
+			break
+		}
+		rows = append(rows, cells)
+	}
+	columns := 0
+	if len(rows) > 0 {
+		columns = len(rows[0])
+	}
+
+	r := &renderer{
+		img:   image.NewRGBA(image.Rect(0, 0, columns*cellWidth*scale, len(rows)*cellHeight*scale)),
+		src:   src,
+		scale: scale,
+		face:  opts.Face,
+		fg:    rgba(src.Color(gopyte.ColorForeground, 0)),
+		bg:    rgba(src.Color(gopyte.ColorBackground, 0)),
+	}
+	r.fill(r.img.Rect, r.bg)
+	for y, cells := range rows {
+		for x, c := range cells {
+			if c.Width == 0 {
+				continue
+			}
+			fg, bg := r.colors(c.Attrs)
+			r.drawCell(x, y, c, fg, bg)
+		}
+	}
+
+	if cur := src.GetCursorObject(); opts.Cursor && !cur.Hidden && cur.Y < len(rows) && cur.X < columns {
+		r.drawCursor(cur.X, cur.Y, rows[cur.Y][cur.X])
+	}
+	return r.img
+}
+
+// WritePNG renders the screen and encodes it as PNG.
+func WritePNG(w io.Writer, src Source, opts Options) error {
+	return png.Encode(w, Render(src, opts))
+}
+
+type renderer struct {
+	img    *image.RGBA
+	src    Source
+	scale  int
+	face   font.Face
+	fg, bg color.RGBA
+}
+
+// colors returns the text and background colors of a cell.
+func (r *renderer) colors(a gopyte.Attributes) (fg, bg color.RGBA) {
+	fg, bg = r.color(a.Fg, r.fg), r.color(a.Bg, r.bg)
+	if a.Reverse {
+		fg, bg = bg, fg
+	}
+	return fg, bg
+}
+
+// color resolves c through the palette, def standing in for the default.
+func (r *renderer) color(c gopyte.Color, def color.RGBA) color.RGBA {
+	if n := c.Index(); n >= 0 {
+		return rgba(r.src.Color(gopyte.ColorPalette, n))
+	}
+	if rgb, ok := c.RGB(); ok {
+		return rgba(rgb)
+	}
+	return def
+}
+
+// drawCell paints the cell at column x, line y, Width columns wide.
+func (r *renderer) drawCell(x, y int, c gopyte.Cell, fg, bg color.RGBA) {
+	width := max(c.Width, 1)
+	r.fill(r.cellRect(x, y, width), bg)
+	ch := c.Char
+
+	switch g, ok := glyph(ch); {
+	case ch == ' ' || ch == 0:
+	case ok:
+		r.drawGlyph(x, y, g, fg, c.Attrs.Bold)
+	case r.drawBox(x, y, ch, fg):
+	case r.drawFace(x, y, width, c, fg):
+	case isEmoji(ch):
+		r.drawEmoji(x, y, width, fg)
+	default:
+		r.drawPlaceholder(x, y, width, fg)
+	}
+
+	// Decorations, in font pixel rows of the cell
+	if c.Attrs.Underscore {
+		r.fillFont(x, y, 0, cellHeight-1, width*cellWidth, 1, fg)
+	}
+	if c.Attrs.Strikethrough {
+		r.fillFont(x, y, 0, 1+baseline/2+1, width*cellWidth, 1, fg)
+	}
+}
+
+// drawGlyph draws a font glyph, a second time one pixel to the right for
+// bold.
+func (r *renderer) drawGlyph(x, y int, g [glyphHeight]uint8, fg color.RGBA, bold bool) {
+	for row, bits := range g {
+		for col := 0; col < glyphWidth; col++ {
+			if bits&(1<<(glyphWidth-1-col)) == 0 {
+				continue
+			}
+			r.fillFont(x, y, col, 1+row, 1, 1, fg)
+			if bold {
+				r.fillFont(x, y, col+1, 1+row, 1, 1, fg)
+			}
+		}
+	}
+}
+
+// Box drawing: the arms of a line character, drawn from the cell center.
+const (
+	armUp = 1 << iota
+	armDown
+	armLeft
+	armRight
+)
+
+// boxLines lists the line characters of the box drawing block by their
+// arms. Light, heavy and double lines are all drawn light, and dashed
+// lines solid.
+var boxLines = []struct {
+	arms  int
+	chars string
+}{
+	{armLeft | armRight, "─━┄┅┈┉╌╍═╼╾"},
+	{armUp | armDown, "│┃┆┇┊┋╎╏║╽╿"},
+	{armDown | armRight, "┌┍┎┏╒╓╔╭"},
+	{armDown | armLeft, "┐┑┒┓╕╖╗╮"},
+	{armUp | armRight, "└┕┖┗╘╙╚╰"},
+	{armUp | armLeft, "┘┙┚┛╛╜╝╯"},
+	{armUp | armDown | armRight, "├┝┞┟┠┡┢┣╞╟╠"},
+	{armUp | armDown | armLeft, "┤┥┦┧┨┩┪┫╡╢╣"},
+	{armDown | armLeft | armRight, "┬┭┮┯┰┱┲┳╤╥╦"},
+	{armUp | armLeft | armRight, "┴┵┶┷┸┹┺┻╧╨╩"},
+	{armUp | armDown | armLeft | armRight, "┼┽┾┿╀╁╂╃╄╅╆╇╈╉╊╋╪╫╬"},
+	{armLeft, "╴╸"},
+	{armUp, "╵╹"},
+	{armRight, "╶╺"},
+	{armDown, "╷╻"},
+}
+
+// boxArms maps each line character to its arms.
+var boxArms = make(map[rune]int)
+
+func init() {
+	for _, l := range boxLines {
+		for _, ch := range l.chars {
+			boxArms[ch] = l.arms
+		}
+	}
+}
+
+// drawBox draws box drawing and block element characters, reporting
+// whether ch is one of them.
+func (r *renderer) drawBox(x, y int, ch rune, fg color.RGBA) bool {
+	const midX, midY = cellWidth / 2, cellHeight / 2
+	if arms, ok := boxArms[ch]; ok {
+		if arms&armUp != 0 {
+			r.fillFont(x, y, midX, 0, 1, midY+1, fg)
+		}
+		if arms&armDown != 0 {
+			r.fillFont(x, y, midX, midY, 1, cellHeight-midY, fg)
+		}
+		if arms&armLeft != 0 {
+			r.fillFont(x, y, 0, midY, midX+1, 1, fg)
+		}
+		if arms&armRight != 0 {
+			r.fillFont(x, y, midX, midY, cellWidth-midX, 1, fg)
+		}
+		return true
+	}
+	switch ch {
+	case '█', '▓', '▒':
+		r.fillFont(x, y, 0, 0, cellWidth, cellHeight, fg)
+	case '▀':
+		r.fillFont(x, y, 0, 0, cellWidth, midY, fg)
+	case '▄':
+		r.fillFont(x, y, 0, midY, cellWidth, cellHeight-midY, fg)
+	case '▌':
+		r.fillFont(x, y, 0, 0, midX, cellHeight, fg)
+	case '▐':
+		r.fillFont(x, y, midX, 0, cellWidth-midX, cellHeight, fg)
+	case '░':
+		for row := 0; row < cellHeight; row += 2 {
+			for col := row / 2 % 2; col < cellWidth; col += 2 {
+				r.fillFont(x, y, col, row, 1, 1, fg)
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// drawFace draws the cell's text with the face, reporting whether the
+// face has a glyph for it.
+func (r *renderer) drawFace(x, y, width int, c gopyte.Cell, fg color.RGBA) bool {
+	if r.face == nil {
+		return false
+	}
+	if _, ok := r.face.GlyphAdvance(c.Char); !ok {
+		return false
+	}
+	rect := r.cellRect(x, y, width)
+	text := c.Text()
+	m := r.face.Metrics()
+	d := font.Drawer{Dst: r.img, Src: image.NewUniform(fg), Face: r.face}
+	d.Dot = fixed.Point26_6{
+		X: fixed.I(rect.Min.X) + (fixed.I(rect.Dx())-d.MeasureString(text))/2,
+		Y: fixed.I(rect.Min.Y) + (fixed.I(rect.Dy())+m.Ascent-m.Descent)/2,
+	}
+	d.DrawString(text)
+	return true
+}
+
+// isEmoji reports whether ch is in the emoji and pictograph blocks.
+func isEmoji(ch rune) bool {
+	return ch >= 0x1f300 && ch <= 0x1faff || ch >= 0x2600 && ch <= 0x27bf
+}
+
+// drawEmoji draws a filled disc over the cells, as a stand-in for a color
+// glyph the embedded font does not have.
+func (r *renderer) drawEmoji(x, y, width int, fg color.RGBA) {
+	rect := r.cellRect(x, y, width).Inset(r.scale)
+	cx, cy := (rect.Min.X+rect.Max.X)/2, (rect.Min.Y+rect.Max.Y)/2
+	rad := min(rect.Dx(), rect.Dy()) / 2
+	for py := rect.Min.Y; py < rect.Max.Y; py++ {
+		for px := rect.Min.X; px < rect.Max.X; px++ {
+			if dx, dy := px-cx, py-cy; dx*dx+dy*dy <= rad*rad {
+				r.img.SetRGBA(px, py, fg)
+			}
+		}
+	}
+}
+
+// drawPlaceholder draws the outline of a box over the glyph area of the
+// cells, for characters the font lacks.
+func (r *renderer) drawPlaceholder(x, y, width int, fg color.RGBA) {
+	w := width*cellWidth - 1
+	r.fillFont(x, y, 0, 1, w, 1, fg)
+	r.fillFont(x, y, 0, baseline+1, w, 1, fg)
+	r.fillFont(x, y, 0, 1, 1, baseline+1, fg)
+	r.fillFont(x, y, w-1, 1, 1, baseline+1, fg)
+}
+
+// drawCursor draws the cursor over the cell in its DECSCUSR shape. A
+// block is drawn in the cursor color with the text in the cell's
+// background color.
+func (r *renderer) drawCursor(x, y int, c gopyte.Cell) {
+	shape := gopyte.CursorBlock
+	if s, ok := r.src.(interface{ CursorStyle() gopyte.CursorStyle }); ok {
+		shape = s.CursorStyle().Shape()
+	}
+	cursor := rgba(r.src.Color(gopyte.ColorCursor, 0))
+	switch shape {
+	case gopyte.CursorUnderline:
+		r.fillFont(x, y, 0, cellHeight-1, max(c.Width, 1)*cellWidth, 1, cursor)
+	case gopyte.CursorBar:
+		r.fillFont(x, y, 0, 0, 1, cellHeight, cursor)
+	default:
+		_, bg := r.colors(c.Attrs)
+		r.drawCell(x, y, c, bg, cursor)
+	}
+}
+
+// cellRect returns the pixels of width cells from column x of line y.
+func (r *renderer) cellRect(x, y, width int) image.Rectangle {
+	w, h := cellWidth*r.scale, cellHeight*r.scale
+	return image.Rect(x*w, y*h, (x+width)*w, (y+1)*h)
+}
+
+// fillFont fills a rectangle given in font pixels relative to a cell.
+func (r *renderer) fillFont(x, y, col, row, w, h int, c color.RGBA) {
+	s := r.scale
+	at := r.cellRect(x, y, 1).Min.Add(image.Pt(col*s, row*s))
+	r.fill(image.Rectangle{Min: at, Max: at.Add(image.Pt(w*s, h*s))}, c)
+}
+
+func (r *renderer) fill(rect image.Rectangle, c color.RGBA) {
+	rect = rect.Intersect(r.img.Rect)
+	for py := rect.Min.Y; py < rect.Max.Y; py++ {
+		for px := rect.Min.X; px < rect.Max.X; px++ {
+			r.img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+func rgba(c gopyte.RGB) color.RGBA {
+	return color.RGBA{R: c.R, G: c.G, B: c.B, A: 0xff}
+}
@@ -0,0 +1,102 @@
+package screenshot_test
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	gopyte "github.com/scottpeterman/gopyte/gopyte"
+	"github.com/scottpeterman/gopyte/gopyte/screenshot"
+	"golang.org/x/image/font/basicfont"
+)
+
+// ink renders text on a one-line screen and returns its first two cells
+// as rows of '#' for text pixels and '.' for background.
+func ink(text string, opts screenshot.Options) string {
+	screen := gopyte.NewWideCharScreen(4, 1, 0)
+	gopyte.NewStream(screen, false).Feed(text)
+	img := screenshot.Render(screen, opts)
+	bg := img.At(img.Rect.Max.X-1, img.Rect.Max.Y-1)
+
+	var b strings.Builder
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx()/2; x++ {
+			if img.At(x, y) == bg {
+				b.WriteByte('.')
+			} else {
+				b.WriteByte('#')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func TestRenderSize(t *testing.T) {
+	screen := gopyte.NewWideCharScreen(10, 3, 0)
+	if got := screenshot.Render(screen, screenshot.Options{}).Rect; got != image.Rect(0, 0, 120, 66) {
+		t.Errorf("size = %v, want 120x66", got)
+	}
+	if got := screenshot.Render(screen, screenshot.Options{Scale: 1}).Rect; got != image.Rect(0, 0, 60, 33) {
+		t.Errorf("size at scale 1 = %v, want 60x33", got)
+	}
+}
+
+func TestRenderLatin1(t *testing.T) {
+	placeholder := ink("Ā", screenshot.Options{})
+	for _, pair := range [][2]string{{"é", "e"}, {"É", "E"}, {"ç", "c"}, {"ñ", "n"}, {"ß", "B"}, {"Ø", "O"}, {"¿", "?"}} {
+		got := ink(pair[0], screenshot.Options{})
+		if got == placeholder || got == ink(pair[1], screenshot.Options{}) || !strings.Contains(got, "#") {
+			t.Errorf("%s drawn as a placeholder or as %s:\n%s", pair[0], pair[1], got)
+		}
+	}
+}
+
+func TestRenderBoxDrawing(t *testing.T) {
+	// Double, heavy and dashed lines are drawn as light ones
+	for _, pair := range [][2]string{{"╒", "┌"}, {"┿", "┼"}, {"┄", "─"}, {"╏", "│"}} {
+		if got, want := ink(pair[0], screenshot.Options{}), ink(pair[1], screenshot.Options{}); got != want {
+			t.Errorf("%s:\n%s\nwant as %s:\n%s", pair[0], got, pair[1], want)
+		}
+	}
+	if got := ink("╴", screenshot.Options{}); got == ink("─", screenshot.Options{}) || got == ink("Ā", screenshot.Options{}) {
+		t.Errorf("half line:\n%s", got)
+	}
+}
+
+func TestRenderFace(t *testing.T) {
+	// A face whose one glyph, 中, is a solid block
+	mask := image.NewAlpha(image.Rect(0, 0, 20, 18))
+	for i := range mask.Pix {
+		mask.Pix[i] = 0xff
+	}
+	face := &basicfont.Face{
+		Advance: 20, Width: 20, Height: 18, Ascent: 14, Descent: 4,
+		Mask:   mask,
+		Ranges: []basicfont.Range{{Low: '中', High: '中' + 1}},
+	}
+
+	// Without the face wide characters are placeholders two cells wide
+	placeholder := ink("中", screenshot.Options{})
+	if ink("日", screenshot.Options{}) != placeholder {
+		t.Error("CJK characters drawn differently without a face")
+	}
+
+	got := ink("中", screenshot.Options{Face: face})
+	if got == placeholder {
+		t.Fatal("face not used")
+	}
+	// The glyph is centered in the two cells
+	rows := strings.Split(got, "\n")
+	if mid := rows[11]; mid != strings.Repeat(".", 2)+strings.Repeat("#", 20)+strings.Repeat(".", 2) {
+		t.Errorf("middle row = %q", mid)
+	}
+	// Characters the face lacks are still placeholders
+	if ink("日", screenshot.Options{Face: face}) != placeholder {
+		t.Error("character missing from the face not drawn as a placeholder")
+	}
+	// The embedded font keeps ASCII
+	if ink("a", screenshot.Options{Face: face}) != ink("a", screenshot.Options{}) {
+		t.Error("face used for ASCII")
+	}
+}
@@ -0,0 +1,67 @@
+package gopyte
+
+// ContentScroller is implemented by screens that support SU (CSI Ps S) and
+// SD (CSI Ps T), which scroll the screen contents without moving the
+// cursor. It is optional; the Stream ignores SU and SD for other screens.
+type ContentScroller interface {
+	ScrollContentUp(count int)
+	ScrollContentDown(count int)
+}
+
+// ScrollContentUp moves the contents up count lines, blank lines coming in
+// at the bottom. The cursor does not move.
+func (s *NativeScreen) ScrollContentUp(count int) {
+	for i := 0; i < min(count, s.lines); i++ {
+		s.scrollUp()
+	}
+}
+
+// ScrollContentDown moves the contents down count lines, blank lines coming
+// in at the top. The cursor does not move.
+func (s *NativeScreen) ScrollContentDown(count int) {
+	for i := 0; i < min(count, s.lines); i++ {
+		s.scrollDown()
+	}
+}
+
+// ScrollContentUp scrolls like NativeScreen.ScrollContentUp, saving the
+// lines that leave the top to the scrollback.
+func (h *HistoryScreen) ScrollContentUp(count int) {
+	if h.viewingHistory {
+		h.ScrollToBottom()
+	}
+	for i := 0; i < min(count, h.lines); i++ {
+		h.scrollUpSaving()
+	}
+}
+
+// ScrollContentDown scrolls like NativeScreen.ScrollContentDown. Unlike a
+// reverse index it never brings lines back from the scrollback.
+func (h *HistoryScreen) ScrollContentDown(count int) {
+	if h.viewingHistory {
+		h.ScrollToBottom()
+	}
+	h.NativeScreen.ScrollContentDown(count)
+}
+
+// ScrollContentUp scrolls without saving to the scrollback on the alternate
+// screen.
+func (a *AlternateScreen) ScrollContentUp(count int) {
+	if !a.usingAlternate {
+		a.HistoryScreen.ScrollContentUp(count)
+		return
+	}
+	for i := 0; i < min(count, a.lines); i++ {
+		a.scrollUpNoHistory()
+	}
+}
+
+// blankWidthRows gives rows from..to-1 fresh single-width cells.
+func (s *NativeScreen) blankWidthRows(from, to int) {
+	for y := from; y < to; y++ {
+		s.cellWidths[y] = make([]int, s.columns)
+		for x := range s.cellWidths[y] {
+			s.cellWidths[y][x] = 1
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package gopyte
+
+import "strings"
+
+// Text selection for copy and paste. A host turns mouse drags into
+// StartSelection and ExtendSelection, highlights SelectionSpans and copies
+// SelectionText. Coordinates are screen cells, so a selection does not
+// follow the text when it scrolls; hosts usually clear it on output.
+// Resizing or resetting the screen clears it.
+
+// SelectionMode is the shape of a selection.
+type SelectionMode int
+
+const (
+	SelectLinear SelectionMode = iota // Text in reading order, like a drag
+	SelectBlock                       // A rectangle of cells, like Alt+drag
+)
+
+// Selection is the selected part of the screen. Start is the anchor set
+// by StartSelection and End the cell ExtendSelection last moved to; End
+// may come before Start.
+type Selection struct {
+	Mode           SelectionMode
+	StartX, StartY int
+	EndX, EndY     int
+}
+
+// StartSelection starts a linear selection of the single cell (x, y),
+// replacing any previous one. The cell is clamped to the screen.
+func (s *NativeScreen) StartSelection(x, y int) {
+	x, y = s.clampCell(x, y)
+	s.selection = &Selection{StartX: x, StartY: y, EndX: x, EndY: y}
+}
+
+// ExtendSelection moves the free end of the selection to cell (x, y). It
+// does nothing without a selection.
+func (s *NativeScreen) ExtendSelection(x, y int) {
+	if s.selection != nil {
+		s.selection.EndX, s.selection.EndY = s.clampCell(x, y)
+	}
+}
+
+// SetSelectionMode switches the selection between linear and block, as a
+// host does while Alt is held during a drag.
+func (s *NativeScreen) SetSelectionMode(mode SelectionMode) {
+	if s.selection != nil {
+		s.selection.Mode = mode
+	}
+}
+
+// ClearSelection removes the selection.
+func (s *NativeScreen) ClearSelection() {
+	s.selection = nil
+}
+
+// GetSelection returns the selection, if there is one.
+func (s *NativeScreen) GetSelection() (Selection, bool) {
+	if s.selection == nil {
+		return Selection{}, false
+	}
+	return *s.selection, true
+}
+
+// SelectionSpans returns the selected cells, one Region per row from top
+// to bottom, for highlighting.
+func (s *NativeScreen) SelectionSpans() []Region {
+	return s.selectionSpans(nil)
+}
+
+// SelectionText returns the selected text. In a linear selection, rows
+// joined by a soft wrap are joined without a line break, and the blanks
+// padding out the other rows are left out. A block selection is one line
+// per row, trailing blanks trimmed.
+func (s *NativeScreen) SelectionText() string {
+	return s.selectionText(nil)
+}
+
+// SelectionSpans returns the selected cells, one Region per row. A wide
+// character is selected whole when either half is.
+func (w *WideCharScreen) SelectionSpans() []Region {
+	return w.selectionSpans(w.cellWidths)
+}
+
+// SelectionText returns the selected text; a wide character is included
+// when either half is selected.
+func (w *WideCharScreen) SelectionText() string {
+	return w.selectionText(w.cellWidths)
+}
+
+func (s *NativeScreen) clampCell(x, y int) (int, int) {
+	return max(0, min(x, s.columns-1)), max(0, min(y, s.lines-1))
+}
+
+// selectionRow returns the columns selected on row y, widened to whole
+// wide characters. widths are the cell widths of a screen with wide
+// characters, nil for one cell each.
+func (s *NativeScreen) selectionRow(y int, widths [][]int) (left, right int, ok bool) {
+	sel := s.selection
+	if sel == nil {
+		return 0, 0, false
+	}
+	top, bottom := min(sel.StartY, sel.EndY), max(sel.StartY, sel.EndY)
+	if y < top || y > bottom {
+		return 0, 0, false
+	}
+	if sel.Mode == SelectBlock {
+		left, right = min(sel.StartX, sel.EndX), max(sel.StartX, sel.EndX)
+	} else {
+		// The upper end starts the selection, the lower one ends it
+		first, last := sel.StartX, sel.EndX
+		if sel.EndY < sel.StartY || (sel.EndY == sel.StartY && sel.EndX < sel.StartX) {
+			first, last = last, first
+		}
+		left, right = 0, s.columns-1
+		if y == top {
+			left = first
+		}
+		if y == bottom {
+			right = last
+		}
+	}
+	if y < len(widths) {
+		row := widths[y]
+		if left > 0 && left < len(row) && row[left] == 0 {
+			left-- // Right half of a wide character
+		}
+		if right+1 < len(row) && row[right] == 2 {
+			right++
+		}
+	}
+	return left, right, true
+}
+
+func (s *NativeScreen) selectionSpans(widths [][]int) []Region {
+	if s.selection == nil {
+		return nil
+	}
+	var spans []Region
+	for y := min(s.selection.StartY, s.selection.EndY); y < s.lines; y++ {
+		left, right, ok := s.selectionRow(y, widths)
+		if !ok {
+			break
+		}
+		spans = append(spans, Region{Top: y, Bottom: y, Left: left, Right: right})
+	}
+	return spans
+}
+
+func (s *NativeScreen) selectionText(widths [][]int) string {
+	spans := s.selectionSpans(widths)
+	var b strings.Builder
+	for i, span := range spans {
+		y := span.Top
+		var row []rune
+		for x := span.Left; x <= span.Right && x < len(s.buffer[y]); x++ {
+			if ch := s.buffer[y][x]; ch != 0 { // Skip right halves
+				row = append(row, ch)
+				row = append(row, []rune(s.cluster(y, x))...)
+			}
+		}
+		if i == len(spans)-1 {
+			b.WriteString(strings.TrimRight(string(row), " "))
+			break
+		}
+		// A linear selection carries on past the end of a wrapped row
+		if s.selection.Mode == SelectLinear && s.IsWrapped(y) {
+			b.WriteString(string(row))
+			continue
+		}
+		b.WriteString(strings.TrimRight(string(row), " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package gopyte
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// LineCommitter is implemented by screens that report lines as they scroll
+// off the top of the screen (into the scrollback, if there is one). All
+// built-in screens implement it.
+type LineCommitter interface {
+	SetLineCommitHandler(fn func(line string, wrapped bool))
+}
+
+// SetLineCommitHandler registers a callback invoked with the text of each
+// line scrolled off the top of the main screen. wrapped is true when the
+// line soft-wraps onto the next one; trailing blanks are trimmed only from
+// lines that do not, since on a wrapped line they are part of the text
+// continued on the next. Lines
+// scrolled off the alternate screen are not reported. Pass nil to remove
+// the handler.
+func (s *NativeScreen) SetLineCommitHandler(fn func(line string, wrapped bool)) {
+	s.onLineCommit = fn
+}
+
+// commitLine reports line y to the commit handler.
+func (s *NativeScreen) commitLine(y int) {
+	if s.onLineCommit == nil || y < 0 || y >= s.lines {
+		return
+	}
+	s.onLineCommit(s.logLine(y))
+}
+
+// logLine returns the text of row y as the commit handler reports it,
+// and whether the row wraps.
+func (s *NativeScreen) logLine(y int) (string, bool) {
+	text, wrapped := lineText(s.buffer[y]), s.IsWrapped(y)
+	if !wrapped {
+		text = strings.TrimRight(text, " ")
+	}
+	return text, wrapped
+}
+
+// lineText renders a buffer row, dropping wide character continuation
+// cells.
+func lineText(row []rune) string {
+	var sb strings.Builder
+	for _, ch := range row {
+		if ch != 0 {
+			sb.WriteRune(ch)
+		}
+	}
+	return sb.String()
+}
+
+// SetOutputLog copies every chunk passed to Feed to w before it is parsed.
+// Pass nil to stop.
+func (s *Stream) SetOutputLog(w io.Writer) {
+	s.outputLog = w
+}
+
+// SessionLog writes a session to log files: the raw output bytes, the
+// rendered text lines, or both. Either writer may be nil. Combine it with
+// RotatingFile for long-lived consoles:
+//
+//	raw, _ := gopyte.OpenRotatingFile("logs/core1.raw", gopyte.RotateOptions{MaxSize: 64 << 20, Compress: true})
+//	text, _ := gopyte.OpenRotatingFile("logs/core1.log", gopyte.RotateOptions{MaxAge: 24 * time.Hour})
+//	log := gopyte.NewSessionLog(raw, text)
+//	log.Attach(stream, screen)
+//	defer log.Close()
+//
+// Text lines are written as they scroll off the screen, with soft-wrapped
+// lines joined back together; Close writes whatever is still on screen.
+type SessionLog struct {
+	mu      sync.Mutex
+	raw     io.WriteCloser
+	text    io.WriteCloser
+	screen  Screen
+	partial strings.Builder // Soft-wrapped line awaiting its continuation
+	err     error
+}
+
+// NewSessionLog creates a log writing raw output to raw and rendered lines
+// to text.
+func NewSessionLog(raw, text io.WriteCloser) *SessionLog {
+	return &SessionLog{raw: raw, text: text}
+}
+
+// Attach starts logging stream's output and screen's committed lines.
+// screen must be the stream's listener and implement LineCommitter to
+// get text logging.
+func (l *SessionLog) Attach(stream *Stream, screen Screen) {
+	l.mu.Lock()
+	l.screen = screen
+	l.mu.Unlock()
+
+	if l.raw != nil {
+		stream.SetOutputLog(rawLogWriter{l})
+	}
+	if lc, ok := screen.(LineCommitter); ok && l.text != nil {
+		lc.SetLineCommitHandler(l.commit)
+	}
+}
+
+// Err returns the first write error, if any. Logging stops at the first
+// error so a full disk does not disturb the session.
+func (l *SessionLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+type rawLogWriter struct{ l *SessionLog }
+
+func (w rawLogWriter) Write(p []byte) (int, error) {
+	w.l.mu.Lock()
+	defer w.l.mu.Unlock()
+	if w.l.err == nil {
+		_, w.l.err = w.l.raw.Write(p)
+	}
+	return len(p), nil
+}
+
+func (l *SessionLog) commit(line string, wrapped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writeLine(line, wrapped)
+}
+
+// writeLine buffers soft-wrapped pieces and writes complete lines. The
+// caller holds l.mu.
+func (l *SessionLog) writeLine(line string, wrapped bool) {
+	if wrapped {
+		l.partial.WriteString(line)
+		return
+	}
+	if l.partial.Len() > 0 {
+		line = l.partial.String() + line
+		l.partial.Reset()
+	}
+	if l.err == nil {
+		_, l.err = io.WriteString(l.text, line+"\n")
+	}
+}
+
+// Close writes the lines still on screen to the text log and closes both
+// writers.
+func (l *SessionLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if d, ok := l.screen.(interface{ GetDisplay() []string }); ok && l.text != nil {
+		lines := d.GetDisplay()
+		last := len(lines) - 1
+		for last >= 0 && strings.TrimSpace(lines[last]) == "" {
+			last--
+		}
+		wr, _ := l.screen.(interface{ IsWrapped(int) bool })
+		ll, _ := l.screen.(interface{ logLine(int) (string, bool) })
+		for y := 0; y <= last; y++ {
+			switch {
+			case ll != nil:
+				l.writeLine(ll.logLine(y))
+			case wr != nil && wr.IsWrapped(y):
+				l.writeLine(lines[y], true)
+			default:
+				l.writeLine(strings.TrimRight(lines[y], " "), false)
+			}
+		}
+	}
+	if l.partial.Len() > 0 {
+		l.writeLine("", false)
+	}
+
+	var err error
+	for _, c := range []io.Closer{l.raw, l.text} {
+		if c != nil {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	if l.err != nil {
+		return l.err
+	}
+	return err
+}
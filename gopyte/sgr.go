@@ -0,0 +1,139 @@
+package gopyte
+
+// SGR parameters come in groups: a parameter and the subparameters joined
+// to it by colons, as in the ISO 8613-6 forms 38:2::r:g:b and 38:5:n and
+// the underline styles 4:0 to 4:5.
+// Screens that implement GroupedSGR see the groups; others get them
+// flattened into the equivalent semicolon form, 38;2;r;g;b.
+
+// UnderlineStyle is the style of underline set by SGR 4:n.
+type UnderlineStyle int
+
+const (
+	UnderlineNone   UnderlineStyle = iota // 4:0, SGR 24
+	UnderlineSingle                       // 4:1, SGR 4
+	UnderlineDouble                       // 4:2, SGR 21
+	UnderlineCurly                        // 4:3
+	UnderlineDotted                       // 4:4
+	UnderlineDashed                       // 4:5
+)
+
+var underlineStyleNames = map[UnderlineStyle]string{
+	UnderlineNone:   "none",
+	UnderlineSingle: "single",
+	UnderlineDouble: "double",
+	UnderlineCurly:  "curly",
+	UnderlineDotted: "dotted",
+	UnderlineDashed: "dashed",
+}
+
+func (u UnderlineStyle) String() string {
+	if name, ok := underlineStyleNames[u]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// setUnderline sets the underline style, keeping Underscore in step.
+// Unknown styles are ignored.
+func (a *Attributes) setUnderline(style UnderlineStyle) {
+	if style < UnderlineNone || style > UnderlineDashed {
+		return
+	}
+	a.UnderlineStyle = style
+	a.Underscore = style != UnderlineNone
+}
+
+// GroupedSGR is implemented by screens that handle SGR subparameters. It
+// is optional: Stream calls SelectGraphicRendition for screens without it.
+type GroupedSGR interface {
+	SelectGraphicRenditionGroups(groups [][]int)
+}
+
+// sgrGroups splits params into groups, sub marking the parameters that
+// followed a colon.
+func sgrGroups(params []int, sub []bool) [][]int {
+	groups := make([][]int, 0, len(params))
+	for i, p := range params {
+		if i > 0 && i < len(sub) && sub[i] {
+			last := len(groups) - 1
+			groups[last] = append(groups[last], p)
+			continue
+		}
+		groups = append(groups, []int{p})
+	}
+	return groups
+}
+
+// flattenSGR turns groups back into plain parameters. Extended colors keep
+// their arguments, underline styles become plain underline (4:0 no
+// underline), and other subparameters are dropped.
+func flattenSGR(groups [][]int) []int {
+	params := make([]int, 0, len(groups))
+	for _, g := range groups {
+		switch {
+		case len(g) == 1:
+			params = append(params, g[0])
+		case g[0] == 38 || g[0] == 48 || g[0] == 58:
+			if args, ok := colonColorArgs(g[1:]); ok {
+				params = append(append(params, g[0]), args...)
+			}
+		case g[0] == 4 && g[1] == 0:
+			params = append(params, 24)
+		default:
+			params = append(params, g[0])
+		}
+	}
+	return params
+}
+
+// colonColorArgs converts the subparameters of a colon form extended color
+// to the semicolon form: 5:n stays, 2:cs:r:g:b loses the color space id,
+// and 2:r:g:b, which some programs send, is taken as it is.
+func colonColorArgs(args []int) ([]int, bool) {
+	switch {
+	case len(args) >= 2 && args[0] == 5:
+		return args[:2], true
+	case len(args) >= 5 && args[0] == 2:
+		return []int{2, args[2], args[3], args[4]}, true
+	case len(args) == 4 && args[0] == 2:
+		return args, true
+	}
+	return nil, false
+}
+
+// extendedColor decodes the extended color (SGR 38 or 48) starting at
+// groups[0], in either form. It also returns how many of the following
+// groups the color used up; ok is false when it is malformed.
+func extendedColor(groups [][]int) (color Color, used int, ok bool) {
+	var args []int
+	if len(groups[0]) > 1 {
+		if args, ok = colonColorArgs(groups[0][1:]); !ok {
+			return Color{}, 0, false
+		}
+	} else {
+		for _, g := range groups[1:] {
+			if len(g) > 1 || len(args) == 4 {
+				break
+			}
+			args = append(args, g[0])
+		}
+		switch {
+		case len(args) >= 2 && args[0] == 5:
+			used = 2
+		case len(args) >= 4 && args[0] == 2:
+			used = 4
+		default:
+			return Color{}, 0, false
+		}
+	}
+
+	if args[0] == 5 {
+		return IndexedColor(args[1]), used, args[1] <= 255
+	}
+	rgb := args[1:4]
+	if rgb[0] > 255 || rgb[1] > 255 || rgb[2] > 255 {
+		return Color{}, used, false
+	}
+	return DirectColor(RGB{R: uint8(rgb[0]), G: uint8(rgb[1]), B: uint8(rgb[2])}), used, true
+}
@@ -0,0 +1,610 @@
+package gopyte
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Snapshots persist a session across process restarts:
+//
+//	data, err := json.Marshal(screen)
+//	...
+//	screen := gopyte.NewWideCharScreen(80, 24, 10000)
+//	err = json.Unmarshal(data, screen)
+//
+// A snapshot holds the text and attributes of the screen, the cursor and
+// its DECSC slot, the modes, tab stops, title, cursor style, the colors
+// the program changed, the scrollback and, while the alternate screen is
+// up, the main screen beneath it, along with bookmarks, grapheme
+// clusters, hyperlinks and the metadata of keys registered with
+// RegisterMetaKey. Handlers, transcripts and other metadata are not part
+// of it, nor is the parser state, so take snapshots between calls to
+// Feed.
+
+// SnapshotVersion is the version of the snapshot format.
+const SnapshotVersion = 1
+
+// Snapshot is the persistent state of a screen.
+type Snapshot struct {
+	Version     int             `json:"version"`
+	Columns     int             `json:"columns"`
+	Lines       int             `json:"lines"`
+	Screen      SnapshotBuffer  `json:"screen"`
+	Main        *SnapshotBuffer `json:"main,omitempty"`       // The main screen while the alternate one is shown
+	Scrollback  []SnapshotLine  `json:"scrollback,omitempty"` // Oldest first
+	MaxHistory  int             `json:"max_history,omitempty"`
+	LineBase    int             `json:"line_base,omitempty"` // Absolute number of the top line of the main screen
+	Bookmarks   []Bookmark      `json:"bookmarks,omitempty"`
+	Modes       SnapshotModes   `json:"modes"`
+	CursorStyle CursorStyle     `json:"cursor_style,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	IconName    string          `json:"icon_name,omitempty"`
+	Colors      map[int]RGB     `json:"colors,omitempty"` // Palette entries 0-255, then foreground, background and cursor
+}
+
+// SnapshotBuffer is the state of one screen buffer.
+type SnapshotBuffer struct {
+	Lines         []SnapshotLine  `json:"lines"`
+	Cursor        SnapshotCursor  `json:"cursor"`
+	Saved         *SnapshotCursor `json:"saved,omitempty"` // DECSC slot
+	TabStops      []int           `json:"tab_stops"`
+	KeyboardFlags []int           `json:"keyboard_flags,omitempty"` // Kitty keyboard flag stack
+}
+
+// SnapshotLine is one line of text. The right half of a wide character is
+// a null rune in Text. Attrs is run-length encoded; cells past the runs
+// have the default attributes. Clusters holds, by column, the runes after
+// the first of each cell with a grapheme cluster (see clusters.go). Meta
+// holds the registered metadata by key name.
+type SnapshotLine struct {
+	Text     string                  `json:"text"`
+	Attrs    []AttrRun               `json:"attrs,omitempty"`
+	Clusters map[int]string          `json:"clusters,omitempty"`
+	Meta     map[string]SnapshotMeta `json:"meta,omitempty"`
+	Wrapped  bool                    `json:"wrapped,omitempty"`
+}
+
+// SnapshotMeta is the JSON-encoded metadata of one key on a line: the
+// line's value and those of its cells by column.
+type SnapshotMeta struct {
+	Line  json.RawMessage         `json:"line,omitempty"`
+	Cells map[int]json.RawMessage `json:"cells,omitempty"`
+}
+
+// AttrRun is a run of N cells with the same attributes.
+type AttrRun struct {
+	N     int        `json:"n"`
+	Attrs Attributes `json:"attrs"`
+}
+
+// SnapshotCursor is a cursor position with its drawing attributes.
+type SnapshotCursor struct {
+	X           int        `json:"x"`
+	Y           int        `json:"y"`
+	Attrs       Attributes `json:"attrs"`
+	Hidden      bool       `json:"hidden,omitempty"`
+	WrapPending bool       `json:"wrap_pending,omitempty"`
+}
+
+// SnapshotModes are the modes set by the program.
+type SnapshotModes struct {
+	AutoWrap         bool          `json:"autowrap"`
+	Newline          bool          `json:"newline"`
+	Origin           bool          `json:"origin,omitempty"`
+	LeftRightMargins bool          `json:"left_right_margins,omitempty"`
+	LeftMargin       int           `json:"left_margin,omitempty"`
+	RightMargin      int           `json:"right_margin,omitempty"`
+	Mouse            MouseProtocol `json:"mouse"`
+	BracketedPaste   bool          `json:"bracketed_paste,omitempty"`
+	AppCursorKeys    bool          `json:"app_cursor_keys,omitempty"`
+	AppKeypad        bool          `json:"app_keypad,omitempty"`
+	FocusReporting   bool          `json:"focus_reporting,omitempty"`
+	Win32Input       bool          `json:"win32_input,omitempty"`
+}
+
+func (snap *Snapshot) check() error {
+	if snap.Version != SnapshotVersion {
+		return fmt.Errorf("gopyte: unsupported snapshot version %d", snap.Version)
+	}
+	if snap.Columns <= 0 || snap.Lines <= 0 {
+		return fmt.Errorf("gopyte: invalid snapshot size %dx%d", snap.Columns, snap.Lines)
+	}
+	return nil
+}
+
+// Snapshot returns the state of the screen.
+func (s *NativeScreen) Snapshot() *Snapshot {
+	return &Snapshot{
+		Version: SnapshotVersion,
+		Columns: s.columns,
+		Lines:   s.lines,
+		Screen:  snapshotBuffer(s.buffer, s.attrs, s.wrapped, s.meta, s.cursor, s.saved, s.tabStops, s.keyboardFlags),
+		Modes: SnapshotModes{
+			AutoWrap:         s.autoWrap,
+			Newline:          s.newlineMode,
+			Origin:           s.originMode,
+			LeftRightMargins: s.lrMarginMode,
+			LeftMargin:       s.leftMargin,
+			RightMargin:      s.rightMargin,
+			Mouse:            s.mouse,
+			BracketedPaste:   s.bracketedPaste,
+			AppCursorKeys:    s.appCursorKeys,
+			AppKeypad:        s.appKeypad,
+			FocusReporting:   s.focusReporting,
+			Win32Input:       s.win32InputMode,
+		},
+		CursorStyle: s.cursorStyle,
+		Title:       s.title,
+		IconName:    s.iconName,
+		Colors:      maps.Clone(s.colors),
+	}
+}
+
+// Restore replaces the state of the screen with snap, resizing it to the
+// snapshot's size. Handlers stay as they are.
+func (s *NativeScreen) Restore(snap *Snapshot) error {
+	if err := snap.check(); err != nil {
+		return err
+	}
+	s.restore(snap, &snap.Screen)
+	return nil
+}
+
+// restore applies snap with b as the active buffer.
+func (s *NativeScreen) restore(snap *Snapshot, b *SnapshotBuffer) {
+	s.columns, s.lines = snap.Columns, snap.Lines
+	s.restoreBuffer(b)
+	s.stateStack = nil
+	s.crPending = false
+	s.shadowText, s.shadowAttrs, s.shadowClusters = nil, nil, nil
+	s.dirtyText, s.dirtyAttrs, s.dirtyClusters = nil, nil, nil
+
+	m := snap.Modes
+	s.autoWrap, s.newlineMode, s.originMode = m.AutoWrap, m.Newline, m.Origin
+	s.lrMarginMode, s.leftMargin, s.rightMargin = m.LeftRightMargins, m.LeftMargin, m.RightMargin
+	s.mouse = m.Mouse
+	s.bracketedPaste = m.BracketedPaste
+	s.appCursorKeys, s.appKeypad = m.AppCursorKeys, m.AppKeypad
+	s.focusReporting = m.FocusReporting
+	s.win32InputMode = m.Win32Input
+
+	s.cursorStyle = snap.CursorStyle
+	s.title, s.iconName = snap.Title, snap.IconName
+	s.colors = maps.Clone(snap.Colors)
+	s.hyperlink = Hyperlink{}
+}
+
+// restoreBuffer makes b the active buffer.
+func (s *NativeScreen) restoreBuffer(b *SnapshotBuffer) {
+	s.buffer = make([][]rune, s.lines)
+	s.attrs = make([][]Attributes, s.lines)
+	s.wrapped = make([]bool, s.lines)
+	for y := range s.buffer {
+		var line SnapshotLine
+		if y < len(b.Lines) {
+			line = b.Lines[y]
+		}
+		s.buffer[y], s.attrs[y] = line.row(s.columns)
+		s.wrapped[y] = line.Wrapped
+	}
+	s.repainted = make([]bool, s.lines)
+	s.meta = make([]*LineMeta, s.lines)
+	for y := 0; y < s.lines && y < len(b.Lines); y++ {
+		s.meta[y] = b.Lines[y].meta(s.columns)
+	}
+
+	s.cursor = b.Cursor.cursor(s.columns, s.lines)
+	s.saved = nil
+	if b.Saved != nil {
+		saved := b.Saved.cursor(s.columns, s.lines)
+		s.saved = &saved
+	}
+	s.tabStops = make(map[int]bool)
+	for _, x := range b.TabStops {
+		s.tabStops[x] = true
+	}
+	s.keyboardFlags = slices.Clone(b.KeyboardFlags)
+}
+
+// Snapshot returns the state of the screen, including the scrollback.
+// While scrolled back, the live screen is saved, not the history view.
+func (h *HistoryScreen) Snapshot() *Snapshot {
+	snap := h.NativeScreen.Snapshot()
+	if h.viewingHistory {
+		snap.Screen.Lines = snapshotLines(h.savedBuffer, h.savedAttrs, h.savedWrapped, h.savedMeta)
+		snap.Screen.Cursor = snapshotCursor(h.savedCursor)
+	}
+	snap.Scrollback = h.snapshotHistory(h.history)
+	snap.MaxHistory = h.maxHistory
+	snap.LineBase = h.lineBase
+	snap.Bookmarks = slices.Clone(h.bookmarks)
+	for i := range snap.Bookmarks {
+		snap.Bookmarks[i].Time = snap.Bookmarks[i].Time.Round(0) // Wall clock only, as encoded
+	}
+	return snap
+}
+
+// Restore replaces the state of the screen with snap. The scrollback
+// keeps the screen's own limit, or takes the snapshot's if it has none.
+func (h *HistoryScreen) Restore(snap *Snapshot) error {
+	if err := snap.check(); err != nil {
+		return err
+	}
+	h.restore(snap, &snap.Screen)
+	return nil
+}
+
+func (h *HistoryScreen) restore(snap *Snapshot, b *SnapshotBuffer) {
+	h.NativeScreen.restore(snap, b)
+	h.viewingHistory, h.historyPos = false, 0
+	h.savedBuffer, h.savedAttrs, h.savedWrapped, h.savedMeta = nil, nil, nil, nil
+	h.lineBase, h.bookmarks = snap.LineBase, slices.Clone(snap.Bookmarks)
+	if h.maxHistory == 0 {
+		h.maxHistory = snap.MaxHistory
+	}
+
+	h.history = list.New()
+	h.styles.reset()
+	h.spill.reset()
+	lines := snap.Scrollback
+	if len(lines) > h.maxHistory {
+		lines = lines[len(lines)-h.maxHistory:]
+	}
+	for _, line := range lines {
+		chars, attrs := line.row(-1)
+		h.history.PushBack(HistoryLine{Chars: chars, styles: h.styles.intern(attrs), Wrapped: line.Wrapped,
+			Meta: line.meta(len(chars))})
+	}
+}
+
+// Snapshot returns the state of the screen. While the alternate screen is
+// up, the main screen is saved in Main.
+func (a *AlternateScreen) Snapshot() *Snapshot {
+	snap := a.HistoryScreen.Snapshot()
+	if a.usingAlternate {
+		main := snapshotBuffer(a.mainBuffer, a.mainAttrs, a.mainWrapped, a.mainMeta, a.mainCursor,
+			a.mainSaved, a.mainTabStops, a.mainKeyboard)
+		snap.Main = &main
+		snap.Scrollback = a.snapshotHistory(a.mainHistory)
+	}
+	return snap
+}
+
+// Restore replaces the state of the screen with snap. The contents of an
+// alternate screen that is not shown are not saved; it comes back blank.
+func (a *AlternateScreen) Restore(snap *Snapshot) error {
+	if err := snap.check(); err != nil {
+		return err
+	}
+	a.restore(snap)
+	return nil
+}
+
+func (a *AlternateScreen) restore(snap *Snapshot) {
+	if a.HistoryScreen == nil {
+		a.HistoryScreen = &HistoryScreen{}
+	}
+	main := &snap.Screen
+	if snap.Main != nil {
+		main = snap.Main
+	}
+	if a.usingAlternate {
+		a.spill, a.mainSpill = a.mainSpill, nil
+	}
+	a.usingAlternate = false
+	a.HistoryScreen.restore(snap, main)
+
+	a.altBuffer = make([][]rune, a.lines)
+	a.altAttrs = make([][]Attributes, a.lines)
+	for y := range a.altBuffer {
+		a.altBuffer[y] = []rune(strings.Repeat(" ", a.columns))
+		a.altAttrs[y] = make([]Attributes, a.columns)
+	}
+	a.altCursor, a.altSaved = Cursor{}, nil
+	a.altTabStops = make(map[int]bool)
+	for x := 0; x < a.columns; x += 8 {
+		a.altTabStops[x] = true
+	}
+	if snap.Main != nil {
+		a.switchToAlternate()
+		a.restoreBuffer(&snap.Screen)
+		a.altBuffer, a.altAttrs = a.buffer, a.attrs
+	}
+}
+
+// Restore replaces the state of the screen with snap. Cell widths follow
+// from the text.
+func (w *WideCharScreen) Restore(snap *Snapshot) error {
+	if err := snap.check(); err != nil {
+		return err
+	}
+	if w.AlternateScreen == nil {
+		w.AlternateScreen = &AlternateScreen{}
+	}
+	w.restore(snap)
+	w.cellWidths = widthsOf(w.buffer)
+	w.mainCellWidths, w.altCellWidths = w.cellWidths, rebuildWidthGrid(nil, w.columns, w.lines)
+	if w.usingAlternate {
+		w.mainCellWidths, w.altCellWidths = widthsOf(w.mainBuffer), w.cellWidths
+	}
+	return nil
+}
+
+// MarshalJSON encodes the screen's Snapshot.
+func (s *NativeScreen) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Snapshot())
+}
+
+// UnmarshalJSON restores the screen from a snapshot.
+func (s *NativeScreen) UnmarshalJSON(data []byte) error {
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	return s.Restore(&snap)
+}
+
+// MarshalJSON encodes the screen's Snapshot.
+func (h *HistoryScreen) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Snapshot())
+}
+
+// UnmarshalJSON restores the screen from a snapshot.
+func (h *HistoryScreen) UnmarshalJSON(data []byte) error {
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	return h.Restore(&snap)
+}
+
+// MarshalJSON encodes the screen's Snapshot.
+func (a *AlternateScreen) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Snapshot())
+}
+
+// UnmarshalJSON restores the screen from a snapshot.
+func (a *AlternateScreen) UnmarshalJSON(data []byte) error {
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	return a.Restore(&snap)
+}
+
+// UnmarshalJSON restores the screen from a snapshot.
+func (w *WideCharScreen) UnmarshalJSON(data []byte) error {
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	return w.Restore(&snap)
+}
+
+func snapshotBuffer(buffer [][]rune, attrs [][]Attributes, wrapped []bool, meta []*LineMeta, cursor Cursor,
+	saved *Cursor, tabStops map[int]bool, keyboard []int) SnapshotBuffer {
+	b := SnapshotBuffer{
+		Lines:         snapshotLines(buffer, attrs, wrapped, meta),
+		Cursor:        snapshotCursor(cursor),
+		TabStops:      make([]int, 0, len(tabStops)),
+		KeyboardFlags: slices.Clone(keyboard),
+	}
+	if saved != nil {
+		c := snapshotCursor(*saved)
+		b.Saved = &c
+	}
+	for x, set := range tabStops {
+		if set {
+			b.TabStops = append(b.TabStops, x)
+		}
+	}
+	slices.Sort(b.TabStops)
+	return b
+}
+
+func snapshotLines(buffer [][]rune, attrs [][]Attributes, wrapped []bool, meta []*LineMeta) []SnapshotLine {
+	lines := make([]SnapshotLine, len(buffer))
+	for y, row := range buffer {
+		var rowAttrs []Attributes
+		if y < len(attrs) {
+			rowAttrs = attrs[y]
+		}
+		var m *LineMeta
+		if y < len(meta) {
+			m = meta[y]
+		}
+		lines[y] = snapshotLine(row, rowAttrs, lineClusters(m), y < len(wrapped) && wrapped[y])
+		lines[y].Meta = snapshotMeta(m, len(row))
+	}
+	return lines
+}
+
+func (h *HistoryScreen) snapshotHistory(l *list.List) []SnapshotLine {
+	if l == nil {
+		return nil
+	}
+	lines := make([]SnapshotLine, 0, l.Len())
+	for e := l.Front(); e != nil; e = e.Next() {
+		line := e.Value.(HistoryLine)
+		attrs := h.styles.attrs(line.styles, len(line.Chars))
+		sl := snapshotLine(line.Chars, attrs, lineClusters(line.Meta), line.Wrapped)
+		sl.Meta = snapshotMeta(line.Meta, len(line.Chars))
+		lines = append(lines, sl)
+	}
+	return lines
+}
+
+// snapshotLine encodes a row, leaving out the trailing run of default
+// attributes.
+func snapshotLine(row []rune, attrs []Attributes, clusters map[int]string, wrapped bool) SnapshotLine {
+	line := SnapshotLine{Text: string(row), Wrapped: wrapped}
+	for x, tail := range clusters {
+		if x >= 0 && x < len(row) && tail != "" {
+			if line.Clusters == nil {
+				line.Clusters = make(map[int]string)
+			}
+			line.Clusters[x] = tail
+		}
+	}
+	for _, a := range attrs {
+		if n := len(line.Attrs); n > 0 && line.Attrs[n-1].Attrs == a {
+			line.Attrs[n-1].N++
+		} else {
+			line.Attrs = append(line.Attrs, AttrRun{N: 1, Attrs: a})
+		}
+	}
+	if n := len(line.Attrs); n > 0 && line.Attrs[n-1].Attrs == DefaultAttributes() {
+		line.Attrs = line.Attrs[:n-1]
+	}
+	return line
+}
+
+// row decodes the line into columns cells, padding with blanks and
+// cutting off the rest. A negative columns keeps the line's own length.
+func (l SnapshotLine) row(columns int) ([]rune, []Attributes) {
+	chars := []rune(l.Text)
+	if columns < 0 {
+		columns = len(chars)
+	}
+	row := make([]rune, columns)
+	attrs := make([]Attributes, columns)
+	for x := range row {
+		row[x] = ' '
+		if x < len(chars) {
+			row[x] = chars[x]
+		}
+	}
+	x := 0
+	for _, run := range l.Attrs {
+		for i := 0; i < run.N && x < columns; i++ {
+			attrs[x] = run.Attrs
+			x++
+		}
+	}
+	return row, attrs
+}
+
+// meta returns line metadata holding the line's clusters and registered
+// metadata in the first columns cells, nil if there is none. Values of
+// keys not registered here, or that do not decode, are dropped.
+func (l SnapshotLine) meta(columns int) *LineMeta {
+	m := &LineMeta{}
+	for x, tail := range l.Clusters {
+		if x >= 0 && x < columns && tail != "" {
+			clusterKey.SetCell(m, x, tail)
+		}
+	}
+	metaCodecsMu.RLock()
+	defer metaCodecsMu.RUnlock()
+	for name, sm := range l.Meta {
+		codec, ok := metaCodecs[name]
+		if !ok {
+			continue
+		}
+		if sm.Line != nil {
+			if v, ok := codec.decode(sm.Line); ok {
+				if m.line == nil {
+					m.line = make(map[*metaKeyID]any)
+				}
+				m.line[codec.id] = v
+			}
+		}
+		for x, data := range sm.Cells {
+			if v, ok := codec.decode(data); ok && x >= 0 && x < columns {
+				if m.cells == nil {
+					m.cells = make(map[int]map[*metaKeyID]any)
+				}
+				if m.cells[x] == nil {
+					m.cells[x] = make(map[*metaKeyID]any)
+				}
+				m.cells[x][codec.id] = v
+			}
+		}
+	}
+	if m.Empty() {
+		return nil
+	}
+	return m
+}
+
+// snapshotMeta encodes the metadata of registered keys in the first
+// columns cells of m, nil if there is none.
+func snapshotMeta(m *LineMeta, columns int) map[string]SnapshotMeta {
+	if m.Empty() {
+		return nil
+	}
+	metaCodecsMu.RLock()
+	defer metaCodecsMu.RUnlock()
+	var out map[string]SnapshotMeta
+	entry := func(name string) SnapshotMeta {
+		if out == nil {
+			out = make(map[string]SnapshotMeta)
+		}
+		return out[name]
+	}
+	for id, v := range m.line {
+		if name, ok := metaNames[id]; ok {
+			if data, err := json.Marshal(v); err == nil {
+				sm := entry(name)
+				sm.Line = data
+				out[name] = sm
+			}
+		}
+	}
+	for x, values := range m.cells {
+		if x < 0 || x >= columns {
+			continue
+		}
+		for id, v := range values {
+			if name, ok := metaNames[id]; ok {
+				if data, err := json.Marshal(v); err == nil {
+					sm := entry(name)
+					if sm.Cells == nil {
+						sm.Cells = make(map[int]json.RawMessage)
+					}
+					sm.Cells[x] = data
+					out[name] = sm
+				}
+			}
+		}
+	}
+	return out
+}
+
+func snapshotCursor(c Cursor) SnapshotCursor {
+	return SnapshotCursor{X: c.X, Y: c.Y, Attrs: c.Attrs, Hidden: c.Hidden, WrapPending: c.wrapPending}
+}
+
+// cursor returns the cursor, kept on a screen of the given size.
+func (c SnapshotCursor) cursor(columns, lines int) Cursor {
+	return Cursor{
+		X:           min(max(c.X, 0), columns-1),
+		Y:           min(max(c.Y, 0), lines-1),
+		Attrs:       c.Attrs,
+		Hidden:      c.Hidden,
+		wrapPending: c.WrapPending,
+	}
+}
+
+// widthsOf derives cell widths from text, where a null rune is the right
+// half of the wide character before it.
+func widthsOf(buffer [][]rune) [][]int {
+	widths := make([][]int, len(buffer))
+	for y, row := range buffer {
+		widths[y] = make([]int, len(row))
+		for x, ch := range row {
+			widths[y][x] = 1
+			if ch == 0 {
+				widths[y][x] = 0
+				if x > 0 && widths[y][x-1] == 1 {
+					widths[y][x-1] = 2
+				}
+			}
+		}
+	}
+	return widths
+}
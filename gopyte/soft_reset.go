@@ -0,0 +1,28 @@
+package gopyte
+
+// SoftResetter is implemented by screens that support the soft terminal
+// reset DECSTR (CSI ! p). It is optional; the Stream resets its character
+// sets either way.
+type SoftResetter interface {
+	SoftReset()
+}
+
+// SoftReset performs DECSTR: the cursor is shown, autowrap goes back on,
+// origin mode, the left/right margins and the application cursor keys
+// and keypad off, SGR attributes return to the default and the saved
+// cursor is forgotten, as in xterm. Unlike RIS it leaves the screen
+// contents, the scrollback, the cursor position, tab stops and titles
+// alone, so tmux and vim can send it on startup and exit without wiping
+// anything.
+func (s *NativeScreen) SoftReset() {
+	defer s.watchModes()()
+	s.cursor.Hidden = false
+	s.cursor.wrapPending = false
+	s.cursor.Attrs = DefaultAttributes()
+	s.saved = nil
+	s.autoWrap = true
+	s.originMode = false
+	s.setLeftRightMarginMode(false)
+	s.appCursorKeys = false
+	s.appKeypad = false
+}
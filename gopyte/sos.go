@@ -0,0 +1,64 @@
+package gopyte
+
+// SOS, PM and APC strings (ESC X, ESC ^ and ESC _, or the C1 bytes 0x98,
+// 0x9e and 0x9f) carry data for applications the terminal does not act
+// on. As in xterm, the string is read up to the ST that ends it and
+// dropped, so that its contents are not drawn as text.
+
+// isC1Introducer reports whether b is a C1 control that begins a sequence:
+// CSI, OSC, DCS or one of the ignored strings.
+func isC1Introducer(b byte) bool {
+	switch b {
+	case CSI_C1[0], OSC_C1[0], DCS_C1[0], SOS_C1[0], PM_C1[0], APC_C1[0]:
+		return true
+	}
+	return false
+}
+
+// startString begins an SOS, PM or APC string.
+func (s *Stream) startString() {
+	s.state = StateString
+	s.strEscape = false
+	s.strRune = 0
+}
+
+// stringByte handles one byte of an ignored string and reports whether
+// the byte must be parsed again in the state the string was left for.
+func (s *Stream) stringByte(b byte) bool {
+	if s.strEscape {
+		s.strEscape = false
+		if b == '\\' {
+			s.state = StateGround
+			return false
+		}
+		// Any other escape sequence cancels the string
+		s.failSequence("sequence interrupted")
+		s.state = StateEscape
+		s.tapKind = SeqEscape
+		return true
+	}
+
+	switch {
+	case b == ST_C1[0] && s.strRune == 0:
+		// Otherwise 0x9c continues a UTF-8 rune
+		s.state = StateGround
+	case b == ESC[0]:
+		s.strEscape = true
+	case b == CAN[0] || b == SUB[0]:
+		s.failSequence("sequence cancelled")
+		s.state = StateGround
+	case b >= 0xc0:
+		s.strRune = 1
+		if b >= 0xe0 {
+			s.strRune++
+		}
+		if b >= 0xf0 {
+			s.strRune++
+		}
+	case b >= 0x80 && s.strRune > 0:
+		s.strRune--
+	default:
+		s.strRune = 0
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package gopyte
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+)
+
+// ErrSpillClosed is the error reading spilled lines after CloseSpill.
+var ErrSpillClosed = errors.New("gopyte: scrollback spill file closed")
+
+// SpillScrollback makes the scrollback unlimited for long-running capture:
+// lines pushed out past the history limit are appended to a temporary
+// file in dir (os.TempDir() if empty) instead of being dropped, and the
+// limit becomes the number of lines kept in memory. The spilled lines
+// stay readable through HistoryLen, GetHistoryLine, HistoryLines, ScrollUp
+// and the scrollback exports; Snapshot holds the in-memory lines only, and
+// spilled lines lose their LineMeta.
+//
+// Clearing the history forgets the spilled lines, but the file only
+// shrinks when CloseSpill removes it. Clones share the file.
+func (h *HistoryScreen) SpillScrollback(dir string) error {
+	return openSpill(&h.spill, dir)
+}
+
+// SpilledLines returns the number of scrollback lines kept on disk.
+func (h *HistoryScreen) SpilledLines() int {
+	return h.spill.len()
+}
+
+// SpillErr returns the first error writing or reading the spill file.
+// Spilling stops at a write error; lines pushed out after it are dropped.
+func (h *HistoryScreen) SpillErr() error {
+	return h.spill.error()
+}
+
+// CloseSpill stops spilling, drops the spilled lines and removes the
+// file, for this screen and all its clones.
+func (h *HistoryScreen) CloseSpill() error {
+	return closeSpill(&h.spill)
+}
+
+// The alternate screen keeps no history of its own; the spill goes with
+// the main screen's.
+
+// SpillScrollback spills the main screen's scrollback to disk, like
+// HistoryScreen.SpillScrollback.
+func (a *AlternateScreen) SpillScrollback(dir string) error {
+	return openSpill(a.spillSlot(), dir)
+}
+
+// SpilledLines returns the number of main screen scrollback lines on disk.
+func (a *AlternateScreen) SpilledLines() int {
+	return (*a.spillSlot()).len()
+}
+
+// SpillErr returns the first error writing or reading the spill file.
+func (a *AlternateScreen) SpillErr() error {
+	return (*a.spillSlot()).error()
+}
+
+// CloseSpill stops spilling and removes the file.
+func (a *AlternateScreen) CloseSpill() error {
+	return closeSpill(a.spillSlot())
+}
+
+// spillSlot returns the main screen's spill.
+func (a *AlternateScreen) spillSlot() **spill {
+	if a.usingAlternate {
+		return &a.mainSpill
+	}
+	return &a.spill
+}
+
+// spillFile is the file lines are spilled to, one JSON-encoded
+// SnapshotLine per line. It is only appended to, so that clones can
+// share it, each with an index of its own lines.
+type spillFile struct {
+	mu     sync.Mutex
+	file   *os.File
+	w      *bufio.Writer
+	size   int64 // Bytes written, buffered ones included
+	err    error
+	closed bool
+}
+
+// spill is a screen's spilled lines, oldest first.
+type spill struct {
+	file  *spillFile
+	lines []spillRef
+	err   error // First read error
+}
+
+// spillRef locates a line in the file.
+type spillRef struct {
+	off int64
+	n   int32
+}
+
+func openSpill(slot **spill, dir string) error {
+	if *slot != nil {
+		return nil
+	}
+	f, err := os.CreateTemp(dir, "gopyte-scrollback-*.jsonl")
+	if err != nil {
+		return err
+	}
+	*slot = &spill{file: &spillFile{file: f, w: bufio.NewWriter(f)}}
+	return nil
+}
+
+func closeSpill(slot **spill) error {
+	sp := *slot
+	if sp == nil {
+		return nil
+	}
+	*slot = nil
+	return sp.file.close()
+}
+
+// add appends a line pushed out of memory.
+func (sp *spill) add(chars []rune, attrs []Attributes, wrapped bool) {
+	data, err := json.Marshal(snapshotLine(chars, attrs, nil, wrapped))
+	if err != nil {
+		return // Unreachable: lines always encode
+	}
+	if ref, ok := sp.file.append(data); ok {
+		sp.lines = append(sp.lines, ref)
+	}
+}
+
+// line reads back spilled line i.
+func (sp *spill) line(i int) (HistoryLine, []Attributes, bool) {
+	data, err := sp.file.read(sp.lines[i])
+	var line SnapshotLine
+	if err == nil {
+		err = json.Unmarshal(data, &line)
+	}
+	if err != nil {
+		if sp.err == nil {
+			sp.err = err
+		}
+		return HistoryLine{}, nil, false
+	}
+	chars, attrs := line.row(-1)
+	return HistoryLine{Chars: chars, Wrapped: line.Wrapped}, attrs, true
+}
+
+func (sp *spill) len() int {
+	if sp == nil {
+		return 0
+	}
+	return len(sp.lines)
+}
+
+// reset forgets the spilled lines.
+func (sp *spill) reset() {
+	if sp != nil {
+		sp.lines = nil
+	}
+}
+
+func (sp *spill) error() error {
+	if sp == nil {
+		return nil
+	}
+	if err := sp.file.error(); err != nil {
+		return err
+	}
+	return sp.err
+}
+
+// clone returns an index of the same lines in the shared file.
+func (sp *spill) clone() *spill {
+	if sp == nil {
+		return nil
+	}
+	return &spill{file: sp.file, lines: slices.Clone(sp.lines), err: sp.err}
+}
+
+func (f *spillFile) append(data []byte) (spillRef, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed || f.err != nil {
+		return spillRef{}, false
+	}
+	data = append(data, '\n')
+	if _, f.err = f.w.Write(data); f.err != nil {
+		return spillRef{}, false
+	}
+	ref := spillRef{off: f.size, n: int32(len(data) - 1)}
+	f.size += int64(len(data))
+	return ref, true
+}
+
+func (f *spillFile) read(ref spillRef) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil, ErrSpillClosed
+	}
+	if f.w.Buffered() > 0 {
+		if err := f.w.Flush(); err != nil {
+			f.err = err
+			return nil, err
+		}
+	}
+	data := make([]byte, ref.n)
+	if _, err := f.file.ReadAt(data, ref.off); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (f *spillFile) error() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+func (f *spillFile) close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	err := f.file.Close()
+	if rerr := os.Remove(f.file.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package gopyte
+
+// ScreenState is the cursor-related state saved by PushState: position,
+// drawing attributes, visibility, the DECSC slot and the wrap and newline
+// modes.
+type ScreenState struct {
+	Cursor      Cursor
+	Saved       *Cursor // DECSC slot, nil if empty
+	AutoWrap    bool
+	NewlineMode bool
+}
+
+// PushState saves the cursor state on a stack, independent of the single
+// DECSC slot the application uses (ESC 7, CSI s, mode 1048). Host tools
+// that draw overlays can push, move the cursor and change attributes
+// freely, then PopState to hand the application its state back exactly.
+func (s *NativeScreen) PushState() {
+	st := ScreenState{
+		Cursor:      s.cursor,
+		AutoWrap:    s.autoWrap,
+		NewlineMode: s.newlineMode,
+	}
+	if s.saved != nil {
+		saved := *s.saved
+		st.Saved = &saved
+	}
+	s.stateStack = append(s.stateStack, st)
+}
+
+// PopState restores the state saved by the matching PushState. It returns
+// false if the stack is empty. The state is applied to whichever buffer
+// (main or alternate) is active when PopState is called.
+func (s *NativeScreen) PopState() bool {
+	n := len(s.stateStack)
+	if n == 0 {
+		return false
+	}
+	defer s.watchModes()()
+	st := s.stateStack[n-1]
+	s.stateStack = s.stateStack[:n-1]
+
+	s.cursor = st.Cursor
+	s.saved = st.Saved
+	s.autoWrap = st.AutoWrap
+	s.newlineMode = st.NewlineMode
+	s.clampCursor()
+	return true
+}
+
+// StateDepth returns the number of states on the PushState stack.
+func (s *NativeScreen) StateDepth() int {
+	return len(s.stateStack)
+}
+
+// clampCursor keeps the cursor on screen after a resize or after restoring
+// a position saved before one. A pending wrap only survives on the last
+// column.
+func (s *NativeScreen) clampCursor() {
+	if s.cursor.X >= s.columns {
+		s.cursor.X = s.columns - 1
+	} else if s.cursor.X < s.columns-1 {
+		s.cursor.wrapPending = false
+	}
+	if s.cursor.Y >= s.lines {
+		s.cursor.Y = s.lines - 1
+	}
+	if s.cursor.X < 0 {
+		s.cursor.X = 0
+	}
+	if s.cursor.Y < 0 {
+		s.cursor.Y = 0
+	}
+}
@@ -0,0 +1,23 @@
+package gopyte
+
+import "fmt"
+
+// ReportDeviceStatus answers DSR (CSI Ps n) through Respond: Ps 5 reports
+// the terminal OK (CSI 0 n) and Ps 6 the cursor position (CPR, CSI r ; c
+// R). Other values are ignored.
+func (s *NativeScreen) ReportDeviceStatus(mode int) {
+	switch mode {
+	case 5:
+		s.Respond(ResponseDeviceStatus, CSI+"0n")
+	case 6:
+		line, column := s.cursorReportPosition()
+		s.Respond(ResponseCursorPosition, fmt.Sprintf("%s%d;%dR", CSI, line, column))
+	}
+}
+
+// cursorReportPosition returns the 1-based line and column a cursor
+// position report gives: in origin mode they count from the margins.
+func (s *NativeScreen) cursorReportPosition() (line, column int) {
+	top, _, left, _ := s.originBounds()
+	return s.cursor.Y - top + 1, s.cursor.X - left + 1
+}
@@ -1,9 +1,10 @@
 package gopyte
 
 import (
+	"io"
 	"regexp"
-	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type Stream struct {
@@ -16,19 +17,76 @@ type Stream struct {
 	takingPlainText bool
 	params          []int
 	currentParam    string
+	subParams       []bool // subParams[i]: params[i] followed a colon (see sgr.go)
+	colon           bool   // The parameter being read follows a colon
 	private         bool
-	oscParam        string
+	prefix          string // Private marker: "?", ">", "<" or "="
+	intermediate    string // Intermediate bytes (0x20-0x2f)
+	oscParam        []byte // OSC payload so far (see osc.go)
+	oscEscape       bool   // ESC seen in an OSC string
+	utf8Pending     string // Leading bytes of a rune split across Feed calls
+
+	// Device control string (see dcs.go); the header uses the CSI fields
+	dcsFinal  string
+	dcsData   []byte
+	dcsEscape bool // ESC seen: ST if a backslash follows
+
+	// SOS, PM or APC string, which is read and ignored (see sos.go)
+	strEscape bool // ESC seen: ST if a backslash follows
+	strRune   int  // Continuation bytes of a UTF-8 rune still to come
 
 	// Character sets
-	g0Charset []rune
-	g1Charset []rune
-	charset   int // 0 for G0, 1 for G1
+	g0Charset   []rune
+	g1Charset   []rune
+	g2Charset   []rune
+	g3Charset   []rune
+	charset     int // Set locked into GL: 0-3 for G0-G3
+	singleShift int // 2 or 3 after SS2/SS3 until the next character, else 0
+
+	// Last graphic character drawn, after translation, for REP
+	lastGraphic rune
+	lastCharset bool // It went through DrawCharset
 
 	// Event mappings
 	basic  map[string]string
 	escape map[string]string
 	sharp  map[string]string
 	csi    map[string]string
+	csiInt map[string]string // Keyed by intermediate+final
+	csiPre map[string]string // Keyed by private marker+final
+
+	// Application-registered CSI handlers keyed by intermediate+final
+	customCSI map[string]CSIHandler
+
+	// Application-registered OSC handlers keyed by command number
+	customOSC map[int]OSCHandler
+
+	// Raw passthrough tap (see raw_tap.go)
+	rawTap     func(RawSequence)
+	tapKind    SequenceKind
+	tapName    string
+	tapPending []byte
+	tapDropped int64 // Bytes of the open sequence no longer in tapPending
+
+	// Session recording (see transcript.go, session_log.go)
+	transcript *Transcript
+	outputLog  io.Writer
+
+	// Stuck sequence detection (see watchdog.go)
+	watchdog *parserWatchdog
+
+	// Bounds on what is kept of a sequence (see limits.go)
+	limits   ParserLimits
+	overflow sequenceOverflow
+
+	// Errors of a strict stream (see strict.go)
+	parseErr    string // Reason the open sequence failed, if it did
+	parseErrors []ParseError
+
+	// Idle/active tracking (see activity.go)
+	activity *ActivityMonitor
+
+	offset int64 // Bytes fed so far (see bookmarks.go)
 }
 
 type ParserState int
@@ -40,6 +98,8 @@ const (
 	StateOSC
 	StateCharset
 	StateSharp
+	StateDCS
+	StateString // SOS, PM or APC string
 )
 
 var textPattern = regexp.MustCompile(`[^\x00-\x1f\x7f\x9b]+`)
@@ -52,7 +112,10 @@ func NewStream(screen Screen, strict bool) *Stream {
 		state:     StateGround,
 		g0Charset: LAT1_MAP,
 		g1Charset: VT100_MAP,
+		g2Charset: LAT1_MAP,
+		g3Charset: LAT1_MAP,
 		charset:   0,
+		limits:    DefaultParserLimits(),
 
 		// Direct translation of Python dicts
 		basic: map[string]string{
@@ -75,6 +138,13 @@ func NewStream(screen Screen, strict bool) *Stream {
 			HTS:   "set_tab_stop",
 			DECSC: "save_cursor",
 			DECRC: "restore_cursor",
+			SS2:   "single_shift_2",
+			SS3:   "single_shift_3",
+			LS2:   "locking_shift_2",
+			LS3:   "locking_shift_3",
+
+			DECKPAM: "keypad_application",
+			DECKPNM: "keypad_numeric",
 		},
 
 		sharp: map[string]string{
@@ -91,13 +161,18 @@ func NewStream(screen Screen, strict bool) *Stream {
 			CPL:     "cursor_up1",
 			CHA:     "cursor_to_column",
 			CUP:     "cursor_position",
+			CHT:     "cursor_forward_tab",
 			ED:      "erase_in_display",
 			EL:      "erase_in_line",
 			IL:      "insert_lines",
 			DL:      "delete_lines",
 			DCH:     "delete_characters",
+			SU:      "scroll_up",
+			SD:      "scroll_down",
 			ECH:     "erase_characters",
+			CBT:     "cursor_back_tab",
 			HPR:     "cursor_forward",
+			REP:     "repeat",
 			DA:      "report_device_attributes",
 			VPA:     "cursor_to_line",
 			VPR:     "cursor_down",
@@ -108,59 +183,145 @@ func NewStream(screen Screen, strict bool) *Stream {
 			SGR:     "select_graphic_rendition",
 			DSR:     "report_device_status",
 			DECSTBM: "set_margins",
+			DECSLRM: "set_left_right_margins",
+			SCORC:   "sco_restore_cursor",
 			HPA:     "cursor_to_column",
+
+			XTWINOPS: "window_ops",
+		},
+
+		csiInt: map[string]string{
+			DECIC:    "insert_columns",
+			DECDC:    "delete_columns",
+			DECSCUSR: "set_cursor_style",
+			DECSTR:   "soft_reset",
+		},
+
+		csiPre: map[string]string{
+			XTSMTITLE: "set_title_modes",
+			XTRMTITLE: "reset_title_modes",
+			DA2:       "report_secondary_device_attributes",
+			DA3:       "report_tertiary_device_attributes",
+			KKPUSH:    "push_keyboard_flags",
+			KKPOP:     "pop_keyboard_flags",
+			KKSET:     "set_keyboard_flags",
+			KKQUERY:   "report_keyboard_flags",
 		},
 	}
 
 	return s
 }
 
-func (s *Stream) Feed(data string) {
+// Feed parses data and applies it to the screen. A strict stream returns
+// the errors found in data (see ParseError); otherwise the result is nil.
+func (s *Stream) Feed(data string) []ParseError {
+	if s.outputLog != nil {
+		_, _ = io.WriteString(s.outputLog, data)
+	}
+	if s.transcript != nil {
+		s.transcript.RecordOutput(data)
+	}
+	if s.activity != nil && len(data) > 0 {
+		s.activity.Output(len(data))
+	}
+	s.offset += int64(len(data))
+	base := s.offset - int64(len(data)+len(s.utf8Pending)) // Stream offset of data[0]
+
+	// Complete a rune whose first bytes ended the previous Feed
+	if s.utf8Pending != "" {
+		data = s.utf8Pending + data
+		s.utf8Pending = ""
+	}
+
+	// A sequence left open too long is abandoned before new data is parsed
+	if s.watchdog != nil && s.watchTime() {
+		s.endSequence("", base)
+	}
+
+	tapStart := 0
 	for i := 0; i < len(data); {
+		if s.state == StateGround {
+			tapStart = i
+		} else if s.watchdog != nil && s.watchByte() {
+			// Sequence too long: drop it and parse this byte afresh
+			s.endSequence(data[tapStart:i], base+int64(tapStart))
+			continue
+		}
+		opened := s.state == StateGround
+
 		switch s.state {
 		case StateGround:
-			char := string(data[i])
+			// Slice rather than convert, so C1 bytes stay single bytes
+			char := data[i : i+1]
 
 			// Check for special characters first
 			switch char {
 			case ESC:
 				s.state = StateEscape
+				s.tapKind, s.tapName = SeqEscape, ""
+				i++
+			case CSI_C1:
+				s.startCSI()
+				s.tapKind, s.tapName = SeqCSI, ""
 				i++
-			case string(CSI_C1):
-				s.state = StateCSI
-				s.params = []int{}
-				s.currentParam = ""
-				s.private = false
+			case OSC_C1:
+				s.startOSC()
+				s.tapKind, s.tapName = SeqOSC, ""
 				i++
-			case string(OSC_C1):
-				s.state = StateOSC
-				s.oscParam = ""
+			case DCS_C1:
+				s.startDCS()
+				s.tapKind, s.tapName = SeqDCS, ""
+				i++
+			case SOS_C1, PM_C1, APC_C1:
+				s.startString()
+				s.tapKind, s.tapName = SeqString, ""
 				i++
 			default:
 				if handler, ok := s.basic[char]; ok {
+					s.tapKind, s.tapName = SeqControl, handler
 					// Skip SI/SO in UTF-8 mode
-					if (char == SI || char == SO) && s.useUTF8 {
-						i++
-						continue
+					if !((char == SI || char == SO) && s.useUTF8) {
+						s.dispatch(handler)
 					}
-					s.dispatch(handler)
 					i++
 				} else if char != NUL && char != DEL {
+					s.tapKind, s.tapName = SeqText, ""
 					// Collect printable text in a batch
 					start := i
 					for i < len(data) {
 						ch := data[i]
-						// Stop at any control character or escape
-						if ch < 0x20 || ch == 0x7f || ch == 0x1b || ch == 0x9b {
+						// Stop at any control character, escape or C1
+						// sequence introducer
+						if ch < 0x20 || ch == 0x7f || isC1Introducer(ch) {
 							break
 						}
-						i++
+						if ch < 0xc0 || !s.useUTF8 {
+							if ch >= 0x80 && s.useUTF8 {
+								s.textError(data[i:i+1], base+int64(i), "invalid UTF-8")
+							}
+							i++
+							continue
+						}
+						// Take multi-byte runes whole, so continuation
+						// bytes such as 0x9b are not read as controls
+						if !utf8.FullRuneInString(data[i:]) {
+							// Split across Feed calls: finish it next time
+							s.utf8Pending = data[i:]
+							data = data[:i]
+							break
+						}
+						r, size := utf8.DecodeRuneInString(data[i:])
+						if r == utf8.RuneError && size == 1 {
+							s.textError(data[i:i+1], base+int64(i), "invalid UTF-8")
+						}
+						i += size
 					}
 					// Draw the batch of text
 					if i > start {
 						s.draw(data[start:i])
 					}
 				} else {
+					s.tapKind, s.tapName = SeqControl, ""
 					i++
 				}
 			}
@@ -169,29 +330,36 @@ func (s *Stream) Feed(data string) {
 			char := string(data[i])
 			switch char {
 			case "[":
-				s.state = StateCSI
-				s.params = []int{}
-				s.currentParam = ""
-				s.private = false
+				s.startCSI()
+				s.tapKind = SeqCSI
 			case "]":
-				s.state = StateOSC
-				s.oscParam = ""
+				s.startOSC()
+				s.tapKind = SeqOSC
 			case "#":
 				s.state = StateSharp
+				s.tapKind = SeqSharp
+			case "P":
+				s.startDCS()
+				s.tapKind = SeqDCS
+			case "X", "^", "_":
+				s.startString()
+				s.tapKind = SeqString
 			case "%":
 				s.state = StateCharset
-			case "(", ")":
-				if i+1 < len(data) {
-					code := string(data[i+1])
-					if !s.useUTF8 {
-						s.defineCharset(code, char)
-					}
-					i++ // Skip the next character
-				}
-				s.state = StateGround
+				s.tapKind = SeqCharset
+				s.intermediate = char
+				s.overflow = overflowNone
+			case "(", ")", "*", "+":
+				s.state = StateCharset
+				s.tapKind, s.tapName = SeqCharset, "define_charset"
+				s.intermediate = char
+				s.overflow = overflowNone
 			default:
 				if handler, ok := s.escape[char]; ok {
+					s.tapName = handler
 					s.dispatch(handler)
+				} else {
+					s.failSequence("unsupported escape sequence")
 				}
 				s.state = StateGround
 			}
@@ -200,17 +368,35 @@ func (s *Stream) Feed(data string) {
 		case StateSharp:
 			char := string(data[i])
 			if handler, ok := s.sharp[char]; ok {
+				s.tapName = handler
 				s.dispatch(handler)
+			} else {
+				s.failSequence("unsupported escape sequence")
 			}
 			s.state = StateGround
 			i++
 
 		case StateCharset:
-			// Handle charset selection (simplified)
+			// ESC % selects the coding system, ESC ( ) * + designate G0-G3;
+			// intermediate bytes may come before the final one (ESC ( " >)
 			char := string(data[i])
-			s.selectOtherCharset(char)
-			s.state = StateGround
 			i++
+			if s.intermediate != "%" && char >= " " && char <= "/" {
+				s.addIntermediate(char)
+				break
+			}
+			if s.discardOverflow() {
+				s.tapName = ""
+			} else if mode := s.intermediate[:1]; mode == "%" {
+				s.tapName = "select_other_charset"
+				s.selectOtherCharset(char)
+			} else {
+				code := s.intermediate[1:] + char
+				s.defineCharset(code, mode)
+				s.listener.DefineCharset(code, mode)
+			}
+			s.intermediate = ""
+			s.state = StateGround
 
 		case StateCSI:
 			char := string(data[i])
@@ -219,25 +405,21 @@ func (s *Stream) Feed(data string) {
 			switch {
 			case char == "?":
 				s.private = true
+				s.prefix = char
+			case char == ">" || char == "<" || char == "=":
+				// Secondary DA and other private markers
+				s.prefix = char
 			case char >= "0" && char <= "9":
-				s.currentParam += char
-			case char == ";":
-				val, _ := strconv.Atoi(s.currentParam)
-				if val > 9999 {
-					val = 9999
-				}
-				s.params = append(s.params, val)
-				s.currentParam = ""
-			case char == "$":
-				// XTerm specific, skip next char
-				if i+1 < len(data) {
-					i++
-				}
-				s.state = StateGround
-			case strings.Contains(" >", char):
-				// Secondary DA, ignore
+				s.addDigit(char[0])
+			case char == ";" || char == ":":
+				s.pushParam()
+				s.colon = char == ":"
+			case char >= " " && char <= "/":
+				// Intermediate bytes, e.g. "$" in DECRQM or " " in DECSCUSR
+				s.addIntermediate(char)
 			case char == CAN || char == SUB:
 				// Cancel sequence
+				s.failSequence("sequence cancelled")
 				s.draw(char)
 				s.state = StateGround
 			case strings.Contains("\x07\x08\x09\x0a\x0b\x0c\x0d", char):
@@ -247,69 +429,85 @@ func (s *Stream) Feed(data string) {
 				}
 			default:
 				// End of CSI sequence
-				if s.currentParam != "" {
-					val, _ := strconv.Atoi(s.currentParam)
-					if val > 9999 {
-						val = 9999
-					}
-					s.params = append(s.params, val)
+				if s.currentParam != "" || s.colon {
+					s.pushParam()
 				}
 
-				if handler, ok := s.csi[char]; ok {
+				s.tapName = ""
+				if s.discardOverflow() {
+					// Over a limit: dropped
+				} else if handler, ok := s.customCSI[s.intermediate+char]; ok {
+					s.tapName = "custom"
+					handler(s.params, s.prefix)
+				} else if handler, ok := s.csiPre[s.prefix+char]; ok && s.intermediate == "" {
+					s.tapName = handler
 					s.dispatchCSI(handler, s.params, s.private)
+				} else if handler, ok := s.csi[char]; ok && s.intermediate == "" {
+					s.tapName = handler
+					s.dispatchCSI(handler, s.params, s.private)
+				} else if handler, ok := s.csiInt[s.intermediate+char]; ok {
+					s.tapName = handler
+					s.dispatchCSI(handler, s.params, s.private)
+				} else {
+					if char >= "@" && char <= "~" {
+						s.failSequence("unsupported CSI sequence")
+					} else {
+						s.failSequence("malformed CSI sequence")
+					}
+					s.listener.Debug("Unknown CSI sequence:", s.prefix+s.intermediate+char, s.params)
 				}
 				s.state = StateGround
 			}
 			i++
 
 		case StateOSC:
-			char := string(data[i])
+			if !s.oscByte(data[i]) {
+				i++
+			}
 
-			// Look for terminator
-			if char == BEL || char == string(ST_C0) || char == string(ST_C1) {
-				// Process OSC command
-				if len(s.oscParam) > 0 {
-					parts := strings.SplitN(s.oscParam, ";", 2)
-					if len(parts) == 2 {
-						code := parts[0]
-						param := parts[1]
-
-						switch code {
-						case "0", "1":
-							s.listener.SetIconName(param)
-						case "2":
-							s.listener.SetTitle(param)
-						}
-					}
-				}
-				s.state = StateGround
-			} else if char == ESC {
-				// Check for ST_C0 (ESC \)
-				if i+1 < len(data) && string(data[i+1]) == "\\" {
-					// Same as above - process OSC
-					if len(s.oscParam) > 0 {
-						parts := strings.SplitN(s.oscParam, ";", 2)
-						if len(parts) == 2 {
-							code := parts[0]
-							param := parts[1]
-
-							switch code {
-							case "0", "1":
-								s.listener.SetIconName(param)
-							case "2":
-								s.listener.SetTitle(param)
-							}
-						}
-					}
-					i++ // Skip the backslash
-					s.state = StateGround
-				}
-			} else {
-				s.oscParam += char
+		case StateDCS:
+			if !s.dcsByte(data[i]) {
+				i++
 			}
-			i++
+
+		case StateString:
+			if !s.stringByte(data[i]) {
+				i++
+			}
+		}
+
+		if opened && s.state != StateGround && s.watchdog != nil {
+			s.watchSequenceStart()
 		}
+		if s.state == StateGround && (s.rawTap != nil || s.parseErr != "" || len(s.tapPending) > 0 || s.tapDropped > 0) {
+			s.endSequence(data[tapStart:i], base+int64(tapStart))
+		}
+	}
+
+	// Keep the bytes of an unfinished sequence for the next Feed
+	if (s.rawTap != nil || s.strict) && s.state != StateGround {
+		s.keepPending(data[tapStart:])
+	}
+
+	if rc, ok := s.listener.(RegionChecker); ok {
+		rc.CheckRegionWatches()
 	}
+	errs := s.parseErrors
+	s.parseErrors = nil
+	return errs
+}
+
+// startCSI resets the parameter state at the start of a CSI sequence.
+func (s *Stream) startCSI() {
+	s.state = StateCSI
+	s.params = []int{}
+	s.currentParam = ""
+	s.subParams = nil
+	s.colon = false
+	s.private = false
+	s.prefix = ""
+	s.intermediate = ""
+	s.overflow = overflowNone
 }
 
 func (s *Stream) dispatch(handler string) {
@@ -325,10 +523,28 @@ func (s *Stream) dispatch(handler string) {
 	case "carriage_return":
 		s.listener.CarriageReturn()
 	case "shift_out":
+		s.charset = 1
 		s.listener.ShiftOut()
 	case "shift_in":
+		s.charset = 0
 		s.listener.ShiftIn()
+	case "single_shift_2", "single_shift_3", "locking_shift_2", "locking_shift_3":
+		// Like SO/SI, shifts only apply outside UTF-8 mode
+		if s.useUTF8 {
+			break
+		}
+		set := 2
+		if strings.HasSuffix(handler, "3") {
+			set = 3
+		}
+		if strings.HasPrefix(handler, "single") {
+			s.singleShift = set
+		} else {
+			s.charset = set
+		}
 	case "reset":
+		s.resetCharsets()
+		s.lastGraphic = 0
 		s.listener.Reset()
 	case "index":
 		s.listener.Index()
@@ -342,12 +558,35 @@ func (s *Stream) dispatch(handler string) {
 		s.listener.RestoreCursor()
 	case "alignment_display":
 		s.listener.AlignmentDisplay()
+	case "keypad_application":
+		// The same setting as DECNKM
+		s.listener.SetMode([]int{KeypadMode}, true)
+	case "keypad_numeric":
+		s.listener.ResetMode([]int{KeypadMode}, true)
 	default:
 		s.listener.Debug("Unknown handler:", handler)
 	}
 }
 
+// pushParam ends the parameter being read, within the limits (see
+// limits.go).
+func (s *Stream) pushParam() {
+	if s.paramRoom() {
+		s.params = append(s.params, s.paramValue(s.currentParam))
+		s.subParams = append(s.subParams, s.colon)
+	}
+	s.currentParam = ""
+}
+
 func (s *Stream) dispatchCSI(handler string, params []int, private bool) {
+	// Title modes see the parameters as sent: with none, every mode resets
+	if handler == "set_title_modes" || handler == "reset_title_modes" {
+		if wm, ok := s.listener.(WindowManager); ok {
+			wm.SetTitleModes(params, handler == "set_title_modes" && len(params) > 0)
+		}
+		return
+	}
+
 	// Default parameter handling
 	if len(params) == 0 {
 		params = []int{0}
@@ -447,7 +686,12 @@ func (s *Stream) dispatchCSI(handler string, params []int, private bool) {
 		}
 
 	case "select_graphic_rendition":
-		s.listener.SelectGraphicRendition(params)
+		groups := sgrGroups(params, s.subParams)
+		if gs, ok := s.listener.(GroupedSGR); ok {
+			gs.SelectGraphicRenditionGroups(groups)
+		} else {
+			s.listener.SelectGraphicRendition(flattenSGR(groups))
+		}
 
 	case "report_device_attributes":
 		mode := 0
@@ -456,7 +700,20 @@ func (s *Stream) dispatchCSI(handler string, params []int, private bool) {
 		}
 		s.listener.ReportDeviceAttributes(mode, private)
 
+	case "report_secondary_device_attributes", "report_tertiary_device_attributes":
+		da, ok := s.listener.(DeviceAttributesReporter)
+		switch {
+		case ok && handler == "report_secondary_device_attributes":
+			da.ReportSecondaryDeviceAttributes(params[0])
+		case ok:
+			da.ReportTertiaryDeviceAttributes(params[0])
+		}
+
 	case "report_device_status":
+		// CSI ? Ps n are the DEC-specific reports, not supported
+		if private {
+			break
+		}
 		mode := 0
 		if len(params) > 0 {
 			mode = params[0]
@@ -473,27 +730,238 @@ func (s *Stream) dispatchCSI(handler string, params []int, private bool) {
 		}
 		s.listener.SetMargins(top, bottom)
 
+	case "insert_columns", "delete_columns":
+		ce, ok := s.listener.(ColumnEditor)
+		if !ok {
+			break
+		}
+		count := 1
+		if params[0] > 0 {
+			count = params[0]
+		}
+		if handler == "insert_columns" {
+			ce.InsertColumns(count)
+		} else {
+			ce.DeleteColumns(count)
+		}
+
+	case "window_ops":
+		if wm, ok := s.listener.(WindowManager); ok {
+			wm.WindowOp(params)
+		}
+
+	case "push_keyboard_flags", "pop_keyboard_flags", "set_keyboard_flags", "report_keyboard_flags":
+		kk, ok := s.listener.(KittyKeyboard)
+		if !ok {
+			break
+		}
+		switch handler {
+		case "push_keyboard_flags":
+			kk.PushKeyboardFlags(params[0])
+		case "pop_keyboard_flags":
+			kk.PopKeyboardFlags(max(params[0], 1))
+		case "set_keyboard_flags":
+			mode := 1
+			if len(params) > 1 && params[1] > 0 {
+				mode = params[1]
+			}
+			kk.SetKeyboardFlags(params[0], mode)
+		default:
+			kk.ReportKeyboardFlags()
+		}
+
+	case "set_left_right_margins":
+		// CSI ? Ps s is XTSAVE
+		if private || s.prefix != "" {
+			break
+		}
+		if lr, ok := s.listener.(LeftRightMargins); ok && lr.LeftRightMarginMode() {
+			right := 0
+			if len(params) > 1 {
+				right = params[1]
+			}
+			lr.SetLeftRightMargins(params[0], right)
+		} else {
+			s.listener.SaveCursor()
+		}
+
+	case "sco_restore_cursor":
+		// CSI ? Ps u and the other prefixed forms belong to other protocols
+		if private || s.prefix != "" {
+			break
+		}
+		s.listener.RestoreCursor()
+
+	case "scroll_up", "scroll_down":
+		// CSI ? Ps S is XTSMGRAPHICS and CSI with five parameters T starts
+		// xterm's highlight mouse tracking; neither scrolls
+		cs, ok := s.listener.(ContentScroller)
+		if !ok || private || len(params) > 1 {
+			break
+		}
+		count := 1
+		if params[0] > 0 {
+			count = params[0]
+		}
+		if handler == "scroll_up" {
+			cs.ScrollContentUp(count)
+		} else {
+			cs.ScrollContentDown(count)
+		}
+
+	case "repeat":
+		count := 1
+		if params[0] > 0 {
+			count = params[0]
+		}
+		s.repeatGraphic(count)
+
+	case "cursor_forward_tab", "cursor_back_tab":
+		count := 1
+		if params[0] > 0 {
+			count = params[0]
+		}
+		tm, ok := s.listener.(TabMover)
+		switch {
+		case ok && handler == "cursor_forward_tab":
+			tm.CursorForwardTab(count)
+		case ok:
+			tm.CursorBackTab(count)
+		case handler == "cursor_forward_tab":
+			for ; count > 0; count-- {
+				s.listener.Tab()
+			}
+		}
+
+	case "set_cursor_style":
+		if cs, ok := s.listener.(CursorStyler); ok {
+			cs.SetCursorStyle(CursorStyle(params[0]))
+		}
+
+	case "soft_reset":
+		s.resetCharsets()
+		if sr, ok := s.listener.(SoftResetter); ok {
+			sr.SoftReset()
+		}
+
 	default:
 		s.listener.Debug("Unknown CSI handler:", handler, params, private)
 	}
 }
 
+// CharsetDrawer is implemented by screens that track cell widths. Glyphs
+// produced by a character set translation (DEC special graphics, say) come
+// from single-cell sets, so the stream draws them through DrawCharset and
+// the screen gives each one cell whatever its Unicode width - box drawing
+// characters are ambiguous width and would otherwise take two cells in CJK
+// locales.
+type CharsetDrawer interface {
+	DrawCharset(text string)
+}
+
 func (s *Stream) draw(text string) {
-	// Apply character set translation
-	if s.charset == 1 {
-		text = TranslateCharset(text, s.g1Charset)
+	gl := s.charsetFor(s.charset)
+	if s.singleShift == 0 && isIdentityCharset(gl) {
+		s.listener.Draw(text)
+		s.noteGraphic(text, false)
+		return
+	}
+
+	// Apply character set translation, splitting the text into runs of
+	// translated and untranslated characters
+	cd, _ := s.listener.(CharsetDrawer)
+	run := make([]rune, 0, len(text))
+	translated := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		if translated && cd != nil {
+			cd.DrawCharset(string(run))
+		} else {
+			s.listener.Draw(string(run))
+		}
+		s.noteGraphic(string(run), translated && cd != nil)
+		run = run[:0]
+	}
+	for _, r := range text {
+		set := gl
+		if s.singleShift != 0 {
+			set = s.charsetFor(s.singleShift)
+			s.singleShift = 0
+		}
+		// In UTF-8 mode only 7-bit characters are translated; the rest are
+		// Unicode already
+		t := !isIdentityCharset(set) && int(r) < len(set) && (r < 0x80 || !s.useUTF8)
+		if t {
+			r = set[r]
+		}
+		if t != translated {
+			flush()
+			translated = t
+		}
+		run = append(run, r)
+	}
+	flush()
+}
+
+// noteGraphic remembers the last character of text for REP.
+func (s *Stream) noteGraphic(text string, charset bool) {
+	if r, _ := utf8.DecodeLastRuneInString(text); r >= ' ' && r != 0x7f {
+		s.lastGraphic, s.lastCharset = r, charset
+	}
+}
+
+// repeatGraphic performs REP (CSI Ps b): the last character drawn is drawn
+// count more times. Nothing happens before the first character.
+func (s *Stream) repeatGraphic(count int) {
+	if s.lastGraphic == 0 {
+		return
+	}
+	text := strings.Repeat(string(s.lastGraphic), count)
+	if cd, ok := s.listener.(CharsetDrawer); ok && s.lastCharset {
+		cd.DrawCharset(text)
 	} else {
-		text = TranslateCharset(text, s.g0Charset)
+		s.listener.Draw(text)
+	}
+}
+
+// charsetFor returns the set designated to G0-G3.
+func (s *Stream) charsetFor(g int) []rune {
+	switch g {
+	case 1:
+		return s.g1Charset
+	case 2:
+		return s.g2Charset
+	case 3:
+		return s.g3Charset
 	}
-	s.listener.Draw(text)
+	return s.g0Charset
+}
+
+func isIdentityCharset(set []rune) bool {
+	return len(set) == 0 || &set[0] == &LAT1_MAP[0]
+}
+
+// resetCharsets restores the initial designations: ASCII in G0, G2 and G3,
+// DEC special graphics in G1, G0 shifted in.
+func (s *Stream) resetCharsets() {
+	s.g0Charset, s.g1Charset = LAT1_MAP, VT100_MAP
+	s.g2Charset, s.g3Charset = LAT1_MAP, LAT1_MAP
+	s.charset, s.singleShift = 0, 0
 }
 
 func (s *Stream) defineCharset(code, mode string) {
 	if charset, ok := MAPS[code]; ok {
-		if mode == "(" {
+		switch mode {
+		case "(":
 			s.g0Charset = charset
-		} else if mode == ")" {
+		case ")":
 			s.g1Charset = charset
+		case "*":
+			s.g2Charset = charset
+		case "+":
+			s.g3Charset = charset
 		}
 	}
 }
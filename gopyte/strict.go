@@ -0,0 +1,78 @@
+package gopyte
+
+import "fmt"
+
+// ParseError describes input a strict Stream could not make sense of.
+// Reason is one of:
+//
+//	"invalid UTF-8"            A byte that does not begin a valid rune
+//	"sequence cancelled"       CAN or SUB ended a sequence
+//	"sequence interrupted"     ESC began a new sequence inside an OSC or DCS string
+//	"malformed CSI sequence"   A CSI sequence ended in a byte that is not a final byte
+//	"malformed OSC string"     An OSC command without its payload
+//	"malformed DCS string"     A DCS string ended before its final byte
+//	"unsupported escape sequence", "unsupported CSI sequence", "unsupported OSC command"
+//	                           A well-formed sequence the parser does not implement
+//	"sequence too long", "sequence timed out"
+//	                           The watchdog gave up on the sequence (see SetWatchdog)
+//	"limit exceeded"           The sequence went over a parser limit (see SetLimits)
+//
+// Raw holds the whole sequence, including any part fed in earlier calls,
+// and Offset the stream offset of its first byte (see Stream.Offset). A
+// sequence interrupted by another holds both. Of a sequence split across
+// calls and longer than the string limits (see SetLimits), Raw holds only
+// the start and the part in the last call.
+type ParseError struct {
+	Offset int64
+	Raw    []byte
+	Reason string
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("gopyte: %s at offset %d: %q", e.Reason, e.Offset, e.Raw)
+}
+
+// Strict reports whether the stream was created strict. A strict stream
+// parses as any other - what it cannot handle is still dropped or, for
+// unknown CSI sequences, passed to Debug - but Feed also returns a
+// ParseError for each. A sequence still open when Feed returns is not an
+// error; it may be completed by the next call.
+func (s *Stream) Strict() bool {
+	return s.strict
+}
+
+// failSequence marks the sequence being parsed as an error, reported
+// when it ends. The first reason given wins.
+func (s *Stream) failSequence(reason string) {
+	if s.parseErr == "" {
+		s.parseErr = reason
+	}
+}
+
+// endSequence finishes a sequence whose last bytes are chunk, starting at
+// stream offset start: it records any error, hands the bytes to the raw
+// tap and forgets them.
+func (s *Stream) endSequence(chunk string, start int64) {
+	if s.parseErr != "" && s.strict {
+		raw := append(append([]byte(nil), s.tapPending...), chunk...)
+		s.parseErrors = append(s.parseErrors, ParseError{
+			Offset: start - int64(len(s.tapPending)) - s.tapDropped,
+			Raw:    raw,
+			Reason: s.parseErr,
+		})
+	}
+	s.parseErr = ""
+	s.tapDropped = 0
+	if s.rawTap != nil {
+		s.emitRaw(chunk)
+	} else {
+		s.tapPending = s.tapPending[:0]
+	}
+}
+
+// textError records an error in text, which is not a sequence.
+func (s *Stream) textError(raw string, offset int64, reason string) {
+	if s.strict {
+		s.parseErrors = append(s.parseErrors, ParseError{Offset: offset, Raw: []byte(raw), Reason: reason})
+	}
+}
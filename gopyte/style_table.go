@@ -0,0 +1,131 @@
+package gopyte
+
+import "maps"
+
+// Scrollback lines keep their attributes interned: each distinct
+// combination is stored once in the screen's styleTable and every cell
+// holds its 4-byte index instead of a whole Attributes. Lines in the
+// default attributes, most of a typical log, hold no indexes at all.
+
+// compactStyles is the table size below which a styleTable is never
+// compacted.
+const compactStyles = 4096
+
+// styleTable interns Attributes for the scrollback. Index 0 is always
+// DefaultAttributes. Indexes stay valid until compact.
+type styleTable struct {
+	styles []Attributes
+	ids    map[Attributes]uint32
+	live   int // Size after the last compaction
+}
+
+// intern returns the indexes of attrs, or nil if all are the default.
+func (t *styleTable) intern(attrs []Attributes) []uint32 {
+	var ids []uint32
+	for x, a := range attrs {
+		if a == DefaultAttributes() {
+			continue
+		}
+		if ids == nil {
+			ids = make([]uint32, len(attrs))
+		}
+		ids[x] = t.id(a)
+	}
+	return ids
+}
+
+// id returns the index of a, adding it if new.
+func (t *styleTable) id(a Attributes) uint32 {
+	if a == DefaultAttributes() {
+		return 0
+	}
+	if id, ok := t.ids[a]; ok {
+		return id
+	}
+	if t.styles == nil {
+		t.styles = []Attributes{DefaultAttributes()}
+		t.ids = make(map[Attributes]uint32)
+	}
+	id := uint32(len(t.styles))
+	t.styles = append(t.styles, a)
+	t.ids[a] = id
+	return id
+}
+
+// at returns the attributes of cell x of a line with indexes ids.
+func (t *styleTable) at(ids []uint32, x int) Attributes {
+	if x >= len(ids) || ids[x] == 0 {
+		return DefaultAttributes()
+	}
+	return t.styles[ids[x]]
+}
+
+// expand writes the attributes of the first n cells of a line with
+// indexes ids into dst.
+func (t *styleTable) expand(dst []Attributes, ids []uint32, n int) {
+	n = min(n, len(dst))
+	for x := 0; x < n; x++ {
+		dst[x] = t.at(ids, x)
+	}
+}
+
+// attrs returns the attributes of the n cells of a line with indexes ids.
+func (t *styleTable) attrs(ids []uint32, n int) []Attributes {
+	attrs := make([]Attributes, n)
+	t.expand(attrs, ids, n)
+	return attrs
+}
+
+// len returns the number of styles, the default included.
+func (t *styleTable) len() int {
+	return max(len(t.styles), 1)
+}
+
+// reset empties the table.
+func (t *styleTable) reset() {
+	*t = styleTable{}
+}
+
+// clone returns a copy of the table, so clones can intern independently.
+func (t *styleTable) clone() styleTable {
+	return styleTable{
+		styles: append([]Attributes(nil), t.styles...),
+		ids:    maps.Clone(t.ids),
+		live:   t.live,
+	}
+}
+
+// compactHistory drops the styles no longer used by any scrollback line
+// once the table has doubled since it was last compacted, so a stream of
+// ever-changing colors cannot grow it without bound. Lines get new index
+// slices; the old ones may still be shared with clones. It runs only as
+// the main screen scrolls, when h.history holds every line in the table.
+func (h *HistoryScreen) compactHistory() {
+	t := &h.styles
+	if len(t.styles) < compactStyles || len(t.styles) < 2*t.live {
+		return
+	}
+	var c styleTable
+	for e := h.history.Front(); e != nil; e = e.Next() {
+		line := e.Value.(HistoryLine)
+		if line.styles == nil {
+			continue
+		}
+		ids := make([]uint32, len(line.styles))
+		for x, id := range line.styles {
+			ids[x] = c.id(t.styles[id])
+		}
+		line.styles = ids
+		e.Value = line
+	}
+	c.live = c.len()
+	*t = c
+}
+
+// HistoryStyles returns the number of distinct attribute combinations
+// the scrollback keeps, the default one included. Cells refer to them by
+// index, so memory use grows with this rather than with the cells that
+// have attributes.
+func (h *HistoryScreen) HistoryStyles() int {
+	return h.styles.len()
+}
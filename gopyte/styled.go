@@ -0,0 +1,70 @@
+package gopyte
+
+// StyledRun is a stretch of a line whose cells all have the same
+// attributes. Column is where it starts and Width how many columns it
+// covers, which differs from the rune count of Text when it holds wide
+// characters.
+type StyledRun struct {
+	Text   string
+	Attrs  Attributes
+	Column int
+	Width  int
+}
+
+// GetStyledDisplay returns the screen as runs of equally styled text, one
+// slice per line, for frontends that render colors. Trailing blanks with
+// default attributes are left out, as GetDisplay drops trailing spaces;
+// a blank line is an empty slice.
+func (s *NativeScreen) GetStyledDisplay() [][]StyledRun {
+	lines := make([][]StyledRun, s.lines)
+	for y := range lines {
+		lines[y] = styledRuns(s.lineCells(y))
+	}
+	return lines
+}
+
+// GetStyledDisplay returns the screen as runs of equally styled text. A
+// wide character is one rune in Text and two columns in Width.
+func (w *WideCharScreen) GetStyledDisplay() [][]StyledRun {
+	lines := make([][]StyledRun, w.lines)
+	for y := range lines {
+		lines[y] = styledRuns(w.lineCells(y))
+	}
+	return lines
+}
+
+// styledRuns coalesces cells into runs. Continuation cells, Width 0 or a
+// null rune, widen the run of their wide character and add no text.
+func styledRuns(cells []Cell) []StyledRun {
+	end := len(cells)
+	for end > 0 && isDefaultBlank(cells[end-1]) {
+		end--
+	}
+	runs := []StyledRun{}
+	var text []rune
+	for x := 0; x < end; x++ {
+		c := cells[x]
+		if (c.Width == 0 || c.Char == 0) && len(runs) > 0 {
+			runs[len(runs)-1].Width++
+			continue
+		}
+		if len(runs) == 0 || runs[len(runs)-1].Attrs != c.Attrs {
+			if len(runs) > 0 {
+				runs[len(runs)-1].Text = string(text)
+			}
+			runs = append(runs, StyledRun{Attrs: c.Attrs, Column: x})
+			text = text[:0]
+		}
+		text = append(text, max(c.Char, ' '))
+		text = append(text, []rune(c.Cluster)...)
+		runs[len(runs)-1].Width++
+	}
+	if len(runs) > 0 {
+		runs[len(runs)-1].Text = string(text)
+	}
+	return runs
+}
+
+func isDefaultBlank(c Cell) bool {
+	return c.Char == ' ' && c.Attrs == DefaultAttributes()
+}
@@ -0,0 +1,123 @@
+package gopyte
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// SVGOptions configures ExportSVG. Zero fields take the defaults noted.
+type SVGOptions struct {
+	CellWidth  int    // Pixels per column (9)
+	CellHeight int    // Pixels per line (18)
+	FontSize   int    // Font size in pixels (15)
+	FontFamily string // CSS font family ("monospace")
+	Cursor     bool   // Draw the cursor, unless it is hidden
+}
+
+func (o SVGOptions) withDefaults() SVGOptions {
+	if o.CellWidth <= 0 {
+		o.CellWidth = 9
+	}
+	if o.CellHeight <= 0 {
+		o.CellHeight = 18
+	}
+	if o.FontSize <= 0 {
+		o.FontSize = 15
+	}
+	if o.FontFamily == "" {
+		o.FontFamily = "monospace"
+	}
+	return o
+}
+
+// ExportSVG renders the screen as a standalone SVG document: a rectangle
+// for every run of non-default background, the text in runs laid out on
+// the cell grid, and optionally the cursor in its DECSCUSR shape. Text
+// is left to the viewer's fonts; textLength keeps each run on the grid
+// whatever the font's advance width.
+func (s *NativeScreen) ExportSVG(opts SVGOptions) string {
+	return s.exportSVG(s.screenCells(), opts)
+}
+
+// ExportSVG renders the screen as an SVG document. A wide character
+// is stretched over its two columns.
+func (w *WideCharScreen) ExportSVG(opts SVGOptions) string {
+	lines := make([][]Cell, w.lines)
+	for y := range lines {
+		lines[y] = w.lineCells(y)
+	}
+	return w.exportSVG(lines, opts)
+}
+
+func (s *NativeScreen) exportSVG(lines [][]Cell, opts SVGOptions) string {
+	opts = opts.withDefaults()
+	cw, ch := opts.CellWidth, opts.CellHeight
+	fg := s.Color(ColorForeground, 0).Hex()
+	bg := s.Color(ColorBackground, 0).Hex()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		s.columns*cw, s.lines*ch, s.columns*cw, s.lines*ch)
+	fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", bg)
+	fmt.Fprintf(&b, "<g font-family=\"%s\" font-size=\"%d\" fill=\"%s\" xml:space=\"preserve\">\n",
+		html.EscapeString(opts.FontFamily), opts.FontSize, fg)
+
+	var text strings.Builder
+	for y, cells := range lines {
+		for _, run := range styledRuns(cells) {
+			runFg, runBg := s.cssColors(run.Attrs)
+			x := run.Column * cw
+			if runBg != "" {
+				fmt.Fprintf(&b, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n",
+					x, y*ch, run.Width*cw, ch, runBg)
+			}
+			if strings.TrimSpace(run.Text) == "" {
+				continue
+			}
+			fmt.Fprintf(&text, "<text x=\"%d\" y=\"%d\" textLength=\"%d\" lengthAdjust=\"spacingAndGlyphs\"%s>%s</text>\n",
+				x, y*ch+ch*3/4, run.Width*cw, svgTextAttrs(run.Attrs, runFg), html.EscapeString(run.Text))
+		}
+	}
+	// Text goes over all backgrounds, which may overlap it with italics
+	b.WriteString(text.String())
+
+	if c := s.cursor; opts.Cursor && !c.Hidden {
+		x, y, w, h := c.X*cw, c.Y*ch, cw, ch
+		switch s.cursorStyle.Shape() {
+		case CursorUnderline:
+			y, h = y+ch-2, 2
+		case CursorBar:
+			w = 2
+		}
+		fmt.Fprintf(&b, "<rect class=\"cursor\" x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" fill-opacity=\"0.6\"/>\n",
+			x, y, w, h, s.Color(ColorCursor, 0).Hex())
+	}
+	b.WriteString("</g>\n</svg>\n")
+	return b.String()
+}
+
+// svgTextAttrs returns the presentation attributes of a text element.
+func svgTextAttrs(a Attributes, fg string) string {
+	var b strings.Builder
+	if fg != "" {
+		b.WriteString(" fill=\"" + fg + "\"")
+	}
+	if a.Bold {
+		b.WriteString(" font-weight=\"bold\"")
+	}
+	if a.Italics {
+		b.WriteString(" font-style=\"italic\"")
+	}
+	var lines []string
+	if a.Underscore {
+		lines = append(lines, "underline")
+	}
+	if a.Strikethrough {
+		lines = append(lines, "line-through")
+	}
+	if len(lines) > 0 {
+		b.WriteString(" text-decoration=\"" + strings.Join(lines, " ") + "\"")
+	}
+	return b.String()
+}
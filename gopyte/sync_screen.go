@@ -0,0 +1,673 @@
+package gopyte
+
+import "sync"
+
+// SyncScreen makes a screen safe to use from several goroutines. Every
+// Screen method, and every optional listener method a Stream calls,
+// takes a write lock; the getters take a read lock. One goroutine can
+// feed PTY output while others render:
+//
+//	screen := gopyte.NewSyncScreen(gopyte.NewWideCharScreen(80, 24, 1000))
+//	stream := gopyte.NewStream(screen, false)
+//	go func() {
+//		for data := range output {
+//			stream.Feed(data)
+//		}
+//	}()
+//	http.HandleFunc("/screen", func(w http.ResponseWriter, r *http.Request) {
+//		json.NewEncoder(w).Encode(screen.GetDisplay())
+//	})
+//
+// Each call is locked on its own, so a reader may see the screen part way
+// through a Feed - after a cursor move but before the text drawn there.
+// To read a consistent screen, feed a stream on the wrapped screen under
+// Update instead, and read under View:
+//
+//	stream := gopyte.NewStream(screen.Screen(), false)
+//	screen.Update(func(gopyte.Screen) { stream.Feed(data) })
+//
+// Handlers set on the wrapped screen - titles, events, region watches -
+// run with the lock held and must read the wrapped screen they are given
+// or closed over, never the SyncScreen, which would deadlock.
+type SyncScreen struct {
+	mu     sync.RWMutex
+	screen Screen
+}
+
+// NewSyncScreen returns screen guarded by a lock.
+func NewSyncScreen(screen Screen) *SyncScreen {
+	return &SyncScreen{screen: screen}
+}
+
+// Screen returns the wrapped screen. Using it directly bypasses the lock.
+func (s *SyncScreen) Screen() Screen {
+	return s.screen
+}
+
+// View calls fn with the wrapped screen under the read lock, for several
+// reads that must agree with each other. fn must not change the screen.
+func (s *SyncScreen) View(fn func(Screen)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.screen)
+}
+
+// Update calls fn with the wrapped screen under the write lock.
+func (s *SyncScreen) Update(fn func(Screen)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.screen)
+}
+
+// Getters. Each asks the wrapped screen and returns the zero value if it
+// does not have the method; all the screens in this package have them.
+
+// GetDisplay returns the text of each line.
+func (s *SyncScreen) GetDisplay() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if g, ok := s.screen.(interface{ GetDisplay() []string }); ok {
+		return g.GetDisplay()
+	}
+	return nil
+}
+
+// AppendDisplay appends the text of each line to dst, reusing the strings
+// of unchanged lines (see NativeScreen.AppendDisplay).
+func (s *SyncScreen) AppendDisplay(dst []string) []string {
+	s.mu.Lock() // The screen keeps a scratch line
+	defer s.mu.Unlock()
+	if g, ok := s.screen.(interface{ AppendDisplay([]string) []string }); ok {
+		return g.AppendDisplay(dst)
+	}
+	return dst
+}
+
+// ReadLine copies the text of line y into buf and returns its length.
+func (s *SyncScreen) ReadLine(y int, buf []rune) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if g, ok := s.screen.(lineReader); ok {
+		return g.ReadLine(y, buf)
+	}
+	return 0
+}
+
+// GetCursor returns the cursor position.
+func (s *SyncScreen) GetCursor() (x, y int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if g, ok := s.screen.(interface{ GetCursor() (int, int) }); ok {
+		return g.GetCursor()
+	}
+	return 0, 0
+}
+
+// GetCell returns the cell at line y, column x.
+func (s *SyncScreen) GetCell(y, x int) Cell {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if g, ok := s.screen.(interface{ GetCell(y, x int) Cell }); ok {
+		return g.GetCell(y, x)
+	}
+	return Cell{}
+}
+
+// GetLineCells returns the cells of line y.
+func (s *SyncScreen) GetLineCells(y int) []Cell {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if g, ok := s.screen.(interface{ GetLineCells(y int) []Cell }); ok {
+		return g.GetLineCells(y)
+	}
+	return nil
+}
+
+// GetStyledDisplay returns the screen as runs of equally styled text.
+func (s *SyncScreen) GetStyledDisplay() [][]StyledRun {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if g, ok := s.screen.(interface{ GetStyledDisplay() [][]StyledRun }); ok {
+		return g.GetStyledDisplay()
+	}
+	return nil
+}
+
+// Title returns the window title.
+func (s *SyncScreen) Title() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if g, ok := s.screen.(interface{ Title() string }); ok {
+		return g.Title()
+	}
+	return ""
+}
+
+// IconName returns the icon name.
+func (s *SyncScreen) IconName() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if g, ok := s.screen.(interface{ IconName() string }); ok {
+		return g.IconName()
+	}
+	return ""
+}
+
+// Snapshot captures the wrapped screen.
+func (s *SyncScreen) Snapshot() *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if g, ok := s.screen.(interface{ Snapshot() *Snapshot }); ok {
+		return g.Snapshot()
+	}
+	return nil
+}
+
+// Resize resizes the wrapped screen.
+func (s *SyncScreen) Resize(columns, lines int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.screen.(interface{ Resize(columns, lines int) }); ok {
+		r.Resize(columns, lines)
+	}
+}
+
+// Screen
+
+func (s *SyncScreen) Draw(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.Draw(text)
+}
+
+func (s *SyncScreen) Bell() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.Bell()
+}
+
+func (s *SyncScreen) Backspace() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.Backspace()
+}
+
+func (s *SyncScreen) Tab() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.Tab()
+}
+
+func (s *SyncScreen) Linefeed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.Linefeed()
+}
+
+func (s *SyncScreen) CarriageReturn() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.CarriageReturn()
+}
+
+func (s *SyncScreen) ShiftOut() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.ShiftOut()
+}
+
+func (s *SyncScreen) ShiftIn() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.ShiftIn()
+}
+
+func (s *SyncScreen) CursorUp(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.CursorUp(count)
+}
+
+func (s *SyncScreen) CursorDown(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.CursorDown(count)
+}
+
+func (s *SyncScreen) CursorForward(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.CursorForward(count)
+}
+
+func (s *SyncScreen) CursorBack(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.CursorBack(count)
+}
+
+func (s *SyncScreen) CursorUp1(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.CursorUp1(count)
+}
+
+func (s *SyncScreen) CursorDown1(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.CursorDown1(count)
+}
+
+func (s *SyncScreen) CursorPosition(line, column int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.CursorPosition(line, column)
+}
+
+func (s *SyncScreen) CursorToColumn(column int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.CursorToColumn(column)
+}
+
+func (s *SyncScreen) CursorToLine(line int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.CursorToLine(line)
+}
+
+func (s *SyncScreen) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.Reset()
+}
+
+func (s *SyncScreen) Index() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.Index()
+}
+
+func (s *SyncScreen) ReverseIndex() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.ReverseIndex()
+}
+
+func (s *SyncScreen) SetTabStop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.SetTabStop()
+}
+
+func (s *SyncScreen) ClearTabStop(how int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.ClearTabStop(how)
+}
+
+func (s *SyncScreen) SaveCursor() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.SaveCursor()
+}
+
+func (s *SyncScreen) RestoreCursor() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.RestoreCursor()
+}
+
+func (s *SyncScreen) InsertLines(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.InsertLines(count)
+}
+
+func (s *SyncScreen) DeleteLines(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.DeleteLines(count)
+}
+
+func (s *SyncScreen) InsertCharacters(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.InsertCharacters(count)
+}
+
+func (s *SyncScreen) DeleteCharacters(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.DeleteCharacters(count)
+}
+
+func (s *SyncScreen) EraseCharacters(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.EraseCharacters(count)
+}
+
+func (s *SyncScreen) EraseInLine(how int, private bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.EraseInLine(how, private)
+}
+
+func (s *SyncScreen) EraseInDisplay(how int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.EraseInDisplay(how)
+}
+
+func (s *SyncScreen) SetMode(modes []int, private bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.SetMode(modes, private)
+}
+
+func (s *SyncScreen) ResetMode(modes []int, private bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.ResetMode(modes, private)
+}
+
+func (s *SyncScreen) DefineCharset(code, mode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.DefineCharset(code, mode)
+}
+
+func (s *SyncScreen) SetMargins(top, bottom int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.SetMargins(top, bottom)
+}
+
+func (s *SyncScreen) SelectGraphicRendition(params []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.SelectGraphicRendition(params)
+}
+
+func (s *SyncScreen) ReportDeviceAttributes(mode int, private bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.ReportDeviceAttributes(mode, private)
+}
+
+func (s *SyncScreen) ReportDeviceStatus(mode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.ReportDeviceStatus(mode)
+}
+
+func (s *SyncScreen) SetTitle(title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.SetTitle(title)
+}
+
+func (s *SyncScreen) SetIconName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.SetIconName(name)
+}
+
+func (s *SyncScreen) AlignmentDisplay() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.AlignmentDisplay()
+}
+
+func (s *SyncScreen) WriteProcessInput(data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.WriteProcessInput(data)
+}
+
+func (s *SyncScreen) Debug(args ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.screen.Debug(args...)
+}
+
+// Optional listener interfaces
+
+func (s *SyncScreen) SetColor(c ColorChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(ColorListener); ok {
+		l.SetColor(c)
+	}
+}
+
+func (s *SyncScreen) SetHyperlink(link Hyperlink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(HyperlinkListener); ok {
+		l.SetHyperlink(link)
+	}
+}
+
+func (s *SyncScreen) Clipboard(r ClipboardRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(ClipboardListener); ok {
+		l.Clipboard(r)
+	}
+}
+
+func (s *SyncScreen) Notify(n Notification) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(NotificationListener); ok {
+		l.Notify(n)
+	}
+}
+
+func (s *SyncScreen) RequestAttention(mode AttentionMode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(ITermListener); ok {
+		l.RequestAttention(mode)
+	}
+}
+
+func (s *SyncScreen) SetBadgeFormat(format string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(ITermListener); ok {
+		l.SetBadgeFormat(format)
+	}
+}
+
+func (s *SyncScreen) DeviceControl(d DeviceControl) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(DeviceControlListener); ok {
+		l.DeviceControl(d)
+	}
+}
+
+func (s *SyncScreen) WindowOp(params []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(WindowManager); ok {
+		l.WindowOp(params)
+	}
+}
+
+func (s *SyncScreen) SetTitleModes(modes []int, set bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(WindowManager); ok {
+		l.SetTitleModes(modes, set)
+	}
+}
+
+func (s *SyncScreen) ReportSecondaryDeviceAttributes(mode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(DeviceAttributesReporter); ok {
+		l.ReportSecondaryDeviceAttributes(mode)
+	}
+}
+
+func (s *SyncScreen) ReportTertiaryDeviceAttributes(mode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(DeviceAttributesReporter); ok {
+		l.ReportTertiaryDeviceAttributes(mode)
+	}
+}
+
+func (s *SyncScreen) InsertColumns(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(ColumnEditor); ok {
+		l.InsertColumns(count)
+	}
+}
+
+func (s *SyncScreen) DeleteColumns(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(ColumnEditor); ok {
+		l.DeleteColumns(count)
+	}
+}
+
+func (s *SyncScreen) PushKeyboardFlags(flags int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(KittyKeyboard); ok {
+		l.PushKeyboardFlags(flags)
+	}
+}
+
+func (s *SyncScreen) PopKeyboardFlags(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(KittyKeyboard); ok {
+		l.PopKeyboardFlags(count)
+	}
+}
+
+func (s *SyncScreen) SetKeyboardFlags(flags, mode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(KittyKeyboard); ok {
+		l.SetKeyboardFlags(flags, mode)
+	}
+}
+
+func (s *SyncScreen) ReportKeyboardFlags() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(KittyKeyboard); ok {
+		l.ReportKeyboardFlags()
+	}
+}
+
+func (s *SyncScreen) SetLeftRightMargins(left, right int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(LeftRightMargins); ok {
+		l.SetLeftRightMargins(left, right)
+	}
+}
+
+func (s *SyncScreen) ScrollContentUp(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(ContentScroller); ok {
+		l.ScrollContentUp(count)
+	}
+}
+
+func (s *SyncScreen) ScrollContentDown(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(ContentScroller); ok {
+		l.ScrollContentDown(count)
+	}
+}
+
+func (s *SyncScreen) CursorForwardTab(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(TabMover); ok {
+		l.CursorForwardTab(count)
+	}
+}
+
+func (s *SyncScreen) CursorBackTab(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(TabMover); ok {
+		l.CursorBackTab(count)
+	}
+}
+
+func (s *SyncScreen) SetCursorStyle(style CursorStyle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(CursorStyler); ok {
+		l.SetCursorStyle(style)
+	}
+}
+
+func (s *SyncScreen) SoftReset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(SoftResetter); ok {
+		l.SoftReset()
+	}
+}
+
+func (s *SyncScreen) SelectGraphicRenditionGroups(groups [][]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(GroupedSGR); ok {
+		l.SelectGraphicRenditionGroups(groups)
+	} else {
+		s.screen.SelectGraphicRendition(flattenSGR(groups))
+	}
+}
+
+func (s *SyncScreen) DrawCharset(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(CharsetDrawer); ok {
+		l.DrawCharset(text)
+	} else {
+		s.screen.Draw(text)
+	}
+}
+
+func (s *SyncScreen) CheckRegionWatches() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.screen.(RegionChecker); ok {
+		l.CheckRegionWatches()
+	}
+}
+
+func (s *SyncScreen) LeftRightMarginMode() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l, ok := s.screen.(LeftRightMargins)
+	return ok && l.LeftRightMarginMode()
+}
+
+// AddBookmark bookmarks the wrapped screen. It returns the zero Bookmark
+// if the screen does not keep bookmarks.
+func (s *SyncScreen) AddBookmark(name, note string, offset int64) Bookmark {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.screen.(Bookmarker); ok {
+		return b.AddBookmark(name, note, offset)
+	}
+	return Bookmark{}
+}
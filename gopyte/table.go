@@ -0,0 +1,159 @@
+package gopyte
+
+import "strings"
+
+// Table is the result of DetectTable: column layout and cell text of a
+// fixed-width table such as `show interfaces status` or `docker ps` output.
+type Table struct {
+	Header  []string
+	Columns []Column
+	Rows    [][]string
+}
+
+// Column is the position of a table column, in runes from the start of the
+// line. End is exclusive; the last column extends to the end of each row.
+type Column struct {
+	Start int
+	End   int
+}
+
+// DetectTable infers the columns of a fixed-width table in lines, typically
+// a slice of GetDisplay(). Blank lines are ignored. The first non-blank line
+// is the header.
+//
+// If the line after the header is an underline made of '-' or '=' runs (as
+// in `show interfaces status` or `netstat`), each run marks a column.
+// Otherwise columns are the runs of positions that are not blank in every
+// line and start under header text, which handles whitespace-aligned
+// output like `show ip interface brief`. Cell text is taken from one
+// column start to the next, so values wider than their header are kept
+// whole.
+//
+// ok is false when fewer than two columns or no data rows are found.
+func DetectTable(lines []string) (t Table, ok bool) {
+	var rows [][]rune
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \x00")
+		if strings.TrimSpace(line) != "" {
+			rows = append(rows, []rune(line))
+		}
+	}
+	if len(rows) < 2 {
+		return Table{}, false
+	}
+
+	header, data := rows[0], rows[1:]
+	if isUnderline(rows[1]) {
+		t.Columns = runsOf(rows[1], func(r rune) bool { return r == '-' || r == '=' })
+		data = rows[2:]
+	} else {
+		t.Columns = alignedColumns(rows)
+	}
+	if len(t.Columns) < 2 || len(data) == 0 {
+		return Table{}, false
+	}
+
+	t.Header = splitColumns(header, t.Columns)
+	for _, row := range data {
+		if isUnderline(row) {
+			continue // Separator between groups of rows
+		}
+		t.Rows = append(t.Rows, splitColumns(row, t.Columns))
+	}
+	return t, len(t.Rows) > 0
+}
+
+// isUnderline reports whether line consists of '-'/'=' runs separated by
+// spaces (and the occasional '+' of a box-drawn table).
+func isUnderline(line []rune) bool {
+	dashes := 0
+	for _, r := range line {
+		switch r {
+		case '-', '=':
+			dashes++
+		case ' ', '+':
+		default:
+			return false
+		}
+	}
+	return dashes > 0
+}
+
+// runsOf returns the maximal runs of positions in line matching keep.
+func runsOf(line []rune, keep func(rune) bool) []Column {
+	var cols []Column
+	start := -1
+	for i, r := range line {
+		switch {
+		case keep(r) && start < 0:
+			start = i
+		case !keep(r) && start >= 0:
+			cols = append(cols, Column{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		cols = append(cols, Column{Start: start, End: len(line)})
+	}
+	return cols
+}
+
+// alignedColumns finds the positions that hold text in at least one row
+// and returns the runs between the all-blank gaps. A run with no header
+// text above it belongs to the column on its left, which keeps values such
+// as "administratively down" in one cell.
+func alignedColumns(rows [][]rune) []Column {
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	used := make([]rune, width)
+	for i := range used {
+		used[i] = ' '
+	}
+	for _, row := range rows {
+		for i, r := range row {
+			if r != ' ' {
+				used[i] = r
+			}
+		}
+	}
+
+	header := rows[0]
+	var cols []Column
+	for _, run := range runsOf(used, func(r rune) bool { return r != ' ' }) {
+		if len(cols) > 0 && !hasText(header, run) {
+			cols[len(cols)-1].End = run.End
+			continue
+		}
+		cols = append(cols, run)
+	}
+	return cols
+}
+
+func hasText(line []rune, c Column) bool {
+	for i := c.Start; i < c.End && i < len(line); i++ {
+		if line[i] != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// splitColumns cuts row at the column starts and trims each cell.
+func splitColumns(row []rune, cols []Column) []string {
+	cells := make([]string, len(cols))
+	for i, c := range cols {
+		if c.Start >= len(row) {
+			continue
+		}
+		end := len(row)
+		if i+1 < len(cols) && cols[i+1].Start < end {
+			end = cols[i+1].Start
+		}
+		cells[i] = strings.TrimSpace(string(row[c.Start:end]))
+	}
+	return cells
+}
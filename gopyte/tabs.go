@@ -0,0 +1,30 @@
+package gopyte
+
+// TabMover is implemented by screens that support CHT (CSI Ps I) and CBT
+// (CSI Ps Z). It is optional: for other screens the Stream sends CHT as
+// that many tabs and ignores CBT.
+type TabMover interface {
+	CursorForwardTab(count int)
+	CursorBackTab(count int)
+}
+
+// CursorForwardTab moves the cursor to the count-th next tab stop, stopping
+// at the last column.
+func (s *NativeScreen) CursorForwardTab(count int) {
+	s.cursor.wrapPending = false
+	for ; count > 0 && s.cursor.X < s.columns-1; count-- {
+		s.Tab()
+	}
+}
+
+// CursorBackTab moves the cursor to the count-th previous tab stop,
+// stopping at the first column.
+func (s *NativeScreen) CursorBackTab(count int) {
+	s.cursor.wrapPending = false
+	for ; count > 0 && s.cursor.X > 0; count-- {
+		s.cursor.X--
+		for s.cursor.X > 0 && !s.tabStops[s.cursor.X] {
+			s.cursor.X--
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package gopyte
+
+import (
+	"encoding/hex"
+	"maps"
+	"strings"
+)
+
+// XTGETTCAP (DCS + q Pt ST) asks the terminal for terminfo capabilities,
+// their names hex encoded and separated by ";". Programs such as neovim
+// and tmux use it to find out about truecolor and extended keys when the
+// terminfo entry can't be trusted. Each name is answered with its own
+// DCS 1 + r name=value ST, or DCS 0 + r name ST when unknown.
+
+// DefaultTermcap returns the capabilities the screen reports unless
+// SetTermcap replaced them. A capability with an empty value is boolean.
+func DefaultTermcap() map[string]string {
+	return map[string]string{
+		"TN":      "xterm-256color",
+		"name":    "xterm-256color",
+		"Co":      "256",
+		"colors":  "256",
+		"RGB":     "8/8/8",
+		"Tc":      "",
+		"setrgbf": "\x1b[38;2;%p1%d;%p2%d;%p3%dm",
+		"setrgbb": "\x1b[48;2;%p1%d;%p2%d;%p3%dm",
+		"Smulx":   "\x1b[4:%p1%dm",
+		"Ss":      "\x1b[%p1%d q",
+		"Se":      "\x1b[ q",
+	}
+}
+
+// SetTermcap replaces the capabilities XTGETTCAP reports. Pass nil to go
+// back to DefaultTermcap.
+func (s *NativeScreen) SetTermcap(caps map[string]string) {
+	s.termcap = maps.Clone(caps)
+}
+
+// answerTermcap answers an XTGETTCAP query through Respond.
+func (s *NativeScreen) answerTermcap(query string) {
+	caps := s.termcap
+	if caps == nil {
+		caps = DefaultTermcap()
+	}
+	for _, name := range strings.Split(query, ";") {
+		decoded, err := hex.DecodeString(name)
+		value, ok := caps[string(decoded)]
+		switch {
+		case err != nil || !ok:
+			s.Respond(ResponseTermcap, DCS+"0+r"+name+ST)
+		case value == "":
+			s.Respond(ResponseTermcap, DCS+"1+r"+name+ST)
+		default:
+			s.Respond(ResponseTermcap, DCS+"1+r"+name+"="+hex.EncodeToString([]byte(value))+ST)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package gopyte
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTitleLength caps window titles and icon names, in runes. Longer
+// values set by OSC 0/1/2 are truncated.
+const MaxTitleLength = 512
+
+// sanitizeTitle makes a title safe to hand to a host UI: invalid UTF-8
+// becomes U+FFFD, control characters (C0, DEL, C1) and bidi overrides that
+// could reorder surrounding UI text are dropped, and the result is capped
+// at MaxTitleLength runes.
+func sanitizeTitle(title string) string {
+	if !utf8.ValidString(title) {
+		title = strings.ToValidUTF8(title, "�")
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range title {
+		if unicode.IsControl(r) || isBidiControl(r) {
+			continue
+		}
+		if n == MaxTitleLength {
+			break
+		}
+		sb.WriteRune(r)
+		n++
+	}
+	return sb.String()
+}
+
+func isBidiControl(r rune) bool {
+	return (r >= 0x202A && r <= 0x202E) || (r >= 0x2066 && r <= 0x2069) || r == 0x200E || r == 0x200F
+}
+
+// SetTitleHandler registers a callback invoked when the window title
+// changes. Pass nil to remove it.
+func (s *NativeScreen) SetTitleHandler(fn func(title string)) {
+	s.onTitle = fn
+}
+
+// SetIconNameHandler registers a callback invoked when the icon name
+// changes. Pass nil to remove it.
+func (s *NativeScreen) SetIconNameHandler(fn func(name string)) {
+	s.onIconName = fn
+}
+
+// Title returns the window title set by OSC 0 or OSC 2.
+func (s *NativeScreen) Title() string {
+	return s.title
+}
+
+// IconName returns the icon name set by OSC 0 or OSC 1.
+func (s *NativeScreen) IconName() string {
+	return s.iconName
+}
@@ -0,0 +1,599 @@
+package gopyte
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TraceCall is one call a TraceScreen received.
+type TraceCall struct {
+	Method string
+	Args   []interface{}
+}
+
+// String formats the call as Go code would read it, strings quoted:
+// CursorPosition(5, 10), Draw("hello").
+func (c TraceCall) String() string {
+	args := make([]string, len(c.Args))
+	for i, a := range c.Args {
+		switch a := a.(type) {
+		case string:
+			args[i] = fmt.Sprintf("%q", a)
+		case fmt.Stringer:
+			args[i] = a.String()
+		default:
+			args[i] = fmt.Sprintf("%+v", a)
+		}
+	}
+	return c.Method + "(" + strings.Join(args, ", ") + ")"
+}
+
+// TraceScreen records every call a Stream makes, with its arguments, and
+// passes it on to the screen it wraps, if any. It shows exactly what a
+// byte stream was translated into, for debugging and golden tests:
+//
+//	trace := gopyte.NewTraceScreen(gopyte.NewNativeScreen(80, 24))
+//	gopyte.NewStream(trace, false).Feed(output)
+//	fmt.Print(trace)
+//
+// Unlike MockScreen it implements the optional listener interfaces too -
+// ColorListener, GroupedSGR, KittyKeyboard and the rest - so the Stream
+// makes the same calls it would make on a NativeScreen, whatever is
+// wrapped. Calls the wrapped screen does not implement are recorded and
+// dropped, except that grouped SGR and charset drawing fall back to
+// SelectGraphicRendition and Draw as the Stream would. Queries such as
+// LeftRightMarginMode are answered by the wrapped screen and not
+// recorded.
+type TraceScreen struct {
+	screen Screen
+	calls  []TraceCall
+}
+
+// NewTraceScreen returns a trace passing calls on to screen, which may be
+// nil to record only.
+func NewTraceScreen(screen Screen) *TraceScreen {
+	return &TraceScreen{screen: screen}
+}
+
+// Screen returns the wrapped screen.
+func (t *TraceScreen) Screen() Screen {
+	return t.screen
+}
+
+// Calls returns the calls recorded so far.
+func (t *TraceScreen) Calls() []TraceCall {
+	return append([]TraceCall(nil), t.calls...)
+}
+
+// ClearCalls forgets the calls recorded so far.
+func (t *TraceScreen) ClearCalls() {
+	t.calls = nil
+}
+
+// String returns the recorded calls, one per line.
+func (t *TraceScreen) String() string {
+	var b strings.Builder
+	for _, c := range t.calls {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (t *TraceScreen) record(method string, args ...interface{}) {
+	t.calls = append(t.calls, TraceCall{Method: method, Args: args})
+}
+
+// Screen
+
+func (t *TraceScreen) Draw(text string) {
+	t.record("Draw", text)
+	if t.screen != nil {
+		t.screen.Draw(text)
+	}
+}
+
+func (t *TraceScreen) Bell() {
+	t.record("Bell")
+	if t.screen != nil {
+		t.screen.Bell()
+	}
+}
+
+func (t *TraceScreen) Backspace() {
+	t.record("Backspace")
+	if t.screen != nil {
+		t.screen.Backspace()
+	}
+}
+
+func (t *TraceScreen) Tab() {
+	t.record("Tab")
+	if t.screen != nil {
+		t.screen.Tab()
+	}
+}
+
+func (t *TraceScreen) Linefeed() {
+	t.record("Linefeed")
+	if t.screen != nil {
+		t.screen.Linefeed()
+	}
+}
+
+func (t *TraceScreen) CarriageReturn() {
+	t.record("CarriageReturn")
+	if t.screen != nil {
+		t.screen.CarriageReturn()
+	}
+}
+
+func (t *TraceScreen) ShiftOut() {
+	t.record("ShiftOut")
+	if t.screen != nil {
+		t.screen.ShiftOut()
+	}
+}
+
+func (t *TraceScreen) ShiftIn() {
+	t.record("ShiftIn")
+	if t.screen != nil {
+		t.screen.ShiftIn()
+	}
+}
+
+func (t *TraceScreen) CursorUp(count int) {
+	t.record("CursorUp", count)
+	if t.screen != nil {
+		t.screen.CursorUp(count)
+	}
+}
+
+func (t *TraceScreen) CursorDown(count int) {
+	t.record("CursorDown", count)
+	if t.screen != nil {
+		t.screen.CursorDown(count)
+	}
+}
+
+func (t *TraceScreen) CursorForward(count int) {
+	t.record("CursorForward", count)
+	if t.screen != nil {
+		t.screen.CursorForward(count)
+	}
+}
+
+func (t *TraceScreen) CursorBack(count int) {
+	t.record("CursorBack", count)
+	if t.screen != nil {
+		t.screen.CursorBack(count)
+	}
+}
+
+func (t *TraceScreen) CursorUp1(count int) {
+	t.record("CursorUp1", count)
+	if t.screen != nil {
+		t.screen.CursorUp1(count)
+	}
+}
+
+func (t *TraceScreen) CursorDown1(count int) {
+	t.record("CursorDown1", count)
+	if t.screen != nil {
+		t.screen.CursorDown1(count)
+	}
+}
+
+func (t *TraceScreen) CursorPosition(line, column int) {
+	t.record("CursorPosition", line, column)
+	if t.screen != nil {
+		t.screen.CursorPosition(line, column)
+	}
+}
+
+func (t *TraceScreen) CursorToColumn(column int) {
+	t.record("CursorToColumn", column)
+	if t.screen != nil {
+		t.screen.CursorToColumn(column)
+	}
+}
+
+func (t *TraceScreen) CursorToLine(line int) {
+	t.record("CursorToLine", line)
+	if t.screen != nil {
+		t.screen.CursorToLine(line)
+	}
+}
+
+func (t *TraceScreen) Reset() {
+	t.record("Reset")
+	if t.screen != nil {
+		t.screen.Reset()
+	}
+}
+
+func (t *TraceScreen) Index() {
+	t.record("Index")
+	if t.screen != nil {
+		t.screen.Index()
+	}
+}
+
+func (t *TraceScreen) ReverseIndex() {
+	t.record("ReverseIndex")
+	if t.screen != nil {
+		t.screen.ReverseIndex()
+	}
+}
+
+func (t *TraceScreen) SetTabStop() {
+	t.record("SetTabStop")
+	if t.screen != nil {
+		t.screen.SetTabStop()
+	}
+}
+
+func (t *TraceScreen) ClearTabStop(how int) {
+	t.record("ClearTabStop", how)
+	if t.screen != nil {
+		t.screen.ClearTabStop(how)
+	}
+}
+
+func (t *TraceScreen) SaveCursor() {
+	t.record("SaveCursor")
+	if t.screen != nil {
+		t.screen.SaveCursor()
+	}
+}
+
+func (t *TraceScreen) RestoreCursor() {
+	t.record("RestoreCursor")
+	if t.screen != nil {
+		t.screen.RestoreCursor()
+	}
+}
+
+func (t *TraceScreen) InsertLines(count int) {
+	t.record("InsertLines", count)
+	if t.screen != nil {
+		t.screen.InsertLines(count)
+	}
+}
+
+func (t *TraceScreen) DeleteLines(count int) {
+	t.record("DeleteLines", count)
+	if t.screen != nil {
+		t.screen.DeleteLines(count)
+	}
+}
+
+func (t *TraceScreen) InsertCharacters(count int) {
+	t.record("InsertCharacters", count)
+	if t.screen != nil {
+		t.screen.InsertCharacters(count)
+	}
+}
+
+func (t *TraceScreen) DeleteCharacters(count int) {
+	t.record("DeleteCharacters", count)
+	if t.screen != nil {
+		t.screen.DeleteCharacters(count)
+	}
+}
+
+func (t *TraceScreen) EraseCharacters(count int) {
+	t.record("EraseCharacters", count)
+	if t.screen != nil {
+		t.screen.EraseCharacters(count)
+	}
+}
+
+func (t *TraceScreen) EraseInLine(how int, private bool) {
+	t.record("EraseInLine", how, private)
+	if t.screen != nil {
+		t.screen.EraseInLine(how, private)
+	}
+}
+
+func (t *TraceScreen) EraseInDisplay(how int) {
+	t.record("EraseInDisplay", how)
+	if t.screen != nil {
+		t.screen.EraseInDisplay(how)
+	}
+}
+
+func (t *TraceScreen) SetMode(modes []int, private bool) {
+	t.record("SetMode", modes, private)
+	if t.screen != nil {
+		t.screen.SetMode(modes, private)
+	}
+}
+
+func (t *TraceScreen) ResetMode(modes []int, private bool) {
+	t.record("ResetMode", modes, private)
+	if t.screen != nil {
+		t.screen.ResetMode(modes, private)
+	}
+}
+
+func (t *TraceScreen) DefineCharset(code, mode string) {
+	t.record("DefineCharset", code, mode)
+	if t.screen != nil {
+		t.screen.DefineCharset(code, mode)
+	}
+}
+
+func (t *TraceScreen) SetMargins(top, bottom int) {
+	t.record("SetMargins", top, bottom)
+	if t.screen != nil {
+		t.screen.SetMargins(top, bottom)
+	}
+}
+
+func (t *TraceScreen) SelectGraphicRendition(params []int) {
+	t.record("SelectGraphicRendition", params)
+	if t.screen != nil {
+		t.screen.SelectGraphicRendition(params)
+	}
+}
+
+func (t *TraceScreen) ReportDeviceAttributes(mode int, private bool) {
+	t.record("ReportDeviceAttributes", mode, private)
+	if t.screen != nil {
+		t.screen.ReportDeviceAttributes(mode, private)
+	}
+}
+
+func (t *TraceScreen) ReportDeviceStatus(mode int) {
+	t.record("ReportDeviceStatus", mode)
+	if t.screen != nil {
+		t.screen.ReportDeviceStatus(mode)
+	}
+}
+
+func (t *TraceScreen) SetTitle(title string) {
+	t.record("SetTitle", title)
+	if t.screen != nil {
+		t.screen.SetTitle(title)
+	}
+}
+
+func (t *TraceScreen) SetIconName(name string) {
+	t.record("SetIconName", name)
+	if t.screen != nil {
+		t.screen.SetIconName(name)
+	}
+}
+
+func (t *TraceScreen) AlignmentDisplay() {
+	t.record("AlignmentDisplay")
+	if t.screen != nil {
+		t.screen.AlignmentDisplay()
+	}
+}
+
+func (t *TraceScreen) WriteProcessInput(data string) {
+	t.record("WriteProcessInput", data)
+	if t.screen != nil {
+		t.screen.WriteProcessInput(data)
+	}
+}
+
+func (t *TraceScreen) Debug(args ...interface{}) {
+	t.record("Debug", args...)
+	if t.screen != nil {
+		t.screen.Debug(args...)
+	}
+}
+
+// Optional listener interfaces
+
+func (t *TraceScreen) SetColor(c ColorChange) {
+	t.record("SetColor", c)
+	if s, ok := t.screen.(ColorListener); ok {
+		s.SetColor(c)
+	}
+}
+
+func (t *TraceScreen) SetHyperlink(link Hyperlink) {
+	t.record("SetHyperlink", link)
+	if s, ok := t.screen.(HyperlinkListener); ok {
+		s.SetHyperlink(link)
+	}
+}
+
+func (t *TraceScreen) Clipboard(r ClipboardRequest) {
+	t.record("Clipboard", r)
+	if s, ok := t.screen.(ClipboardListener); ok {
+		s.Clipboard(r)
+	}
+}
+
+func (t *TraceScreen) Notify(n Notification) {
+	t.record("Notify", n)
+	if s, ok := t.screen.(NotificationListener); ok {
+		s.Notify(n)
+	}
+}
+
+func (t *TraceScreen) RequestAttention(mode AttentionMode) {
+	t.record("RequestAttention", mode)
+	if s, ok := t.screen.(ITermListener); ok {
+		s.RequestAttention(mode)
+	}
+}
+
+func (t *TraceScreen) SetBadgeFormat(format string) {
+	t.record("SetBadgeFormat", format)
+	if s, ok := t.screen.(ITermListener); ok {
+		s.SetBadgeFormat(format)
+	}
+}
+
+func (t *TraceScreen) DeviceControl(d DeviceControl) {
+	t.record("DeviceControl", d)
+	if s, ok := t.screen.(DeviceControlListener); ok {
+		s.DeviceControl(d)
+	}
+}
+
+func (t *TraceScreen) WindowOp(params []int) {
+	t.record("WindowOp", params)
+	if s, ok := t.screen.(WindowManager); ok {
+		s.WindowOp(params)
+	}
+}
+
+func (t *TraceScreen) SetTitleModes(modes []int, set bool) {
+	t.record("SetTitleModes", modes, set)
+	if s, ok := t.screen.(WindowManager); ok {
+		s.SetTitleModes(modes, set)
+	}
+}
+
+func (t *TraceScreen) ReportSecondaryDeviceAttributes(mode int) {
+	t.record("ReportSecondaryDeviceAttributes", mode)
+	if s, ok := t.screen.(DeviceAttributesReporter); ok {
+		s.ReportSecondaryDeviceAttributes(mode)
+	}
+}
+
+func (t *TraceScreen) ReportTertiaryDeviceAttributes(mode int) {
+	t.record("ReportTertiaryDeviceAttributes", mode)
+	if s, ok := t.screen.(DeviceAttributesReporter); ok {
+		s.ReportTertiaryDeviceAttributes(mode)
+	}
+}
+
+func (t *TraceScreen) InsertColumns(count int) {
+	t.record("InsertColumns", count)
+	if s, ok := t.screen.(ColumnEditor); ok {
+		s.InsertColumns(count)
+	}
+}
+
+func (t *TraceScreen) DeleteColumns(count int) {
+	t.record("DeleteColumns", count)
+	if s, ok := t.screen.(ColumnEditor); ok {
+		s.DeleteColumns(count)
+	}
+}
+
+func (t *TraceScreen) PushKeyboardFlags(flags int) {
+	t.record("PushKeyboardFlags", flags)
+	if s, ok := t.screen.(KittyKeyboard); ok {
+		s.PushKeyboardFlags(flags)
+	}
+}
+
+func (t *TraceScreen) PopKeyboardFlags(count int) {
+	t.record("PopKeyboardFlags", count)
+	if s, ok := t.screen.(KittyKeyboard); ok {
+		s.PopKeyboardFlags(count)
+	}
+}
+
+func (t *TraceScreen) SetKeyboardFlags(flags, mode int) {
+	t.record("SetKeyboardFlags", flags, mode)
+	if s, ok := t.screen.(KittyKeyboard); ok {
+		s.SetKeyboardFlags(flags, mode)
+	}
+}
+
+func (t *TraceScreen) ReportKeyboardFlags() {
+	t.record("ReportKeyboardFlags")
+	if s, ok := t.screen.(KittyKeyboard); ok {
+		s.ReportKeyboardFlags()
+	}
+}
+
+func (t *TraceScreen) SetLeftRightMargins(left, right int) {
+	t.record("SetLeftRightMargins", left, right)
+	if s, ok := t.screen.(LeftRightMargins); ok {
+		s.SetLeftRightMargins(left, right)
+	}
+}
+
+func (t *TraceScreen) ScrollContentUp(count int) {
+	t.record("ScrollContentUp", count)
+	if s, ok := t.screen.(ContentScroller); ok {
+		s.ScrollContentUp(count)
+	}
+}
+
+func (t *TraceScreen) ScrollContentDown(count int) {
+	t.record("ScrollContentDown", count)
+	if s, ok := t.screen.(ContentScroller); ok {
+		s.ScrollContentDown(count)
+	}
+}
+
+func (t *TraceScreen) CursorForwardTab(count int) {
+	t.record("CursorForwardTab", count)
+	if s, ok := t.screen.(TabMover); ok {
+		s.CursorForwardTab(count)
+	}
+}
+
+func (t *TraceScreen) CursorBackTab(count int) {
+	t.record("CursorBackTab", count)
+	if s, ok := t.screen.(TabMover); ok {
+		s.CursorBackTab(count)
+	}
+}
+
+func (t *TraceScreen) SetCursorStyle(style CursorStyle) {
+	t.record("SetCursorStyle", style)
+	if s, ok := t.screen.(CursorStyler); ok {
+		s.SetCursorStyle(style)
+	}
+}
+
+func (t *TraceScreen) SoftReset() {
+	t.record("SoftReset")
+	if s, ok := t.screen.(SoftResetter); ok {
+		s.SoftReset()
+	}
+}
+
+func (t *TraceScreen) SelectGraphicRenditionGroups(groups [][]int) {
+	t.record("SelectGraphicRenditionGroups", groups)
+	if s, ok := t.screen.(GroupedSGR); ok {
+		s.SelectGraphicRenditionGroups(groups)
+	} else if t.screen != nil {
+		t.screen.SelectGraphicRendition(flattenSGR(groups))
+	}
+}
+
+func (t *TraceScreen) DrawCharset(text string) {
+	t.record("DrawCharset", text)
+	if s, ok := t.screen.(CharsetDrawer); ok {
+		s.DrawCharset(text)
+	} else if t.screen != nil {
+		t.screen.Draw(text)
+	}
+}
+
+// AddBookmark is recorded and passed on; the bookmark comes from the
+// wrapped screen, and is zero when that screen keeps no bookmarks.
+func (t *TraceScreen) AddBookmark(name, note string, offset int64) Bookmark {
+	t.record("AddBookmark", name, note, offset)
+	if s, ok := t.screen.(Bookmarker); ok {
+		return s.AddBookmark(name, note, offset)
+	}
+	return Bookmark{}
+}
+
+// LeftRightMarginMode asks the wrapped screen; it is not recorded.
+func (t *TraceScreen) LeftRightMarginMode() bool {
+	s, ok := t.screen.(LeftRightMargins)
+	return ok && s.LeftRightMarginMode()
+}
+
+// CheckRegionWatches is passed on after every Feed; it is not recorded.
+func (t *TraceScreen) CheckRegionWatches() {
+	if s, ok := t.screen.(RegionChecker); ok {
+		s.CheckRegionWatches()
+	}
+}
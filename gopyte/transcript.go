@@ -0,0 +1,244 @@
+package gopyte
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Direction tells which way data flowed in a recorded session.
+type Direction int
+
+const (
+	DirOutput Direction = iota // Program output fed to the Stream
+	DirInput                   // Data sent to the program (keystrokes, reports)
+	DirResize                  // Terminal resized; Data is "COLSxROWS"
+)
+
+// TranscriptEvent is one timestamped chunk of session data.
+type TranscriptEvent struct {
+	Time      time.Duration // Offset from the start of the recording
+	Direction Direction
+	Data      string
+}
+
+// Transcript records both directions of an interactive session with
+// timestamps so it can be replayed deterministically or audited later.
+//
+// Output is recorded by a Stream with SetTranscript, input written back by
+// the screen (WriteProcessInput) by a screen with SetTranscript. Hosts
+// record keystrokes they forward to the process with RecordInput. All
+// methods are safe for concurrent use, since input usually arrives on a
+// different goroutine than output.
+type Transcript struct {
+	mu     sync.Mutex
+	start  time.Time
+	clock  Clock
+	events []TranscriptEvent
+
+	pending map[Direction]string // Incomplete UTF-8 sequence ending the last chunk
+}
+
+// NewTranscript creates an empty transcript whose clock starts now.
+func NewTranscript() *Transcript {
+	return NewTranscriptWithClock(SystemClock)
+}
+
+// NewTranscriptWithClock creates an empty transcript timed by c, starting
+// at c.Now().
+func NewTranscriptWithClock(c Clock) *Transcript {
+	return &Transcript{start: c.Now(), clock: c}
+}
+
+// RecordOutput appends program output. A character split between two
+// chunks is recorded whole with the second, so the event text survives
+// WriteTo, which encodes it as JSON.
+func (t *Transcript) RecordOutput(data string) {
+	t.record(DirOutput, data)
+}
+
+// RecordInput appends data sent to the program. Like output, a split
+// character is held back until the rest of it arrives.
+func (t *Transcript) RecordInput(data string) {
+	t.record(DirInput, data)
+}
+
+// RecordResize appends a terminal resize to columns x lines.
+func (t *Transcript) RecordResize(columns, lines int) {
+	t.record(DirResize, fmt.Sprintf("%dx%d", columns, lines))
+}
+
+func (t *Transcript) record(dir Direction, data string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if dir != DirResize {
+		data = t.pending[dir] + data
+		cut := len(data) - incompleteTail(data)
+		if t.pending == nil {
+			t.pending = make(map[Direction]string)
+		}
+		t.pending[dir] = data[cut:]
+		data = data[:cut]
+	}
+	if data == "" {
+		return
+	}
+	t.events = append(t.events, TranscriptEvent{
+		Time:      t.clock.Now().Sub(t.start),
+		Direction: dir,
+		Data:      data,
+	})
+}
+
+// incompleteTail returns the length of the incomplete UTF-8 sequence
+// ending data, or 0 when its last character is whole.
+func incompleteTail(data string) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRuneInString(data[i:]) {
+				return len(data) - i
+			}
+			break
+		}
+	}
+	return 0
+}
+
+// Events returns a copy of the recorded events in order.
+func (t *Transcript) Events() []TranscriptEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := make([]TranscriptEvent, len(t.events))
+	copy(events, t.events)
+	return events
+}
+
+// Duration returns the offset of the last recorded event.
+func (t *Transcript) Duration() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.events) == 0 {
+		return 0
+	}
+	return t.events[len(t.events)-1].Time
+}
+
+// Replay feeds every recorded output event to stream, ignoring timing,
+// and resizes the stream's screen where the recording was resized. Input
+// events are skipped: they were the program's input, not its output.
+func (t *Transcript) Replay(stream *Stream) {
+	for _, ev := range t.Events() {
+		switch ev.Direction {
+		case DirOutput:
+			stream.Feed(ev.Data)
+		case DirResize:
+			resizeScreen(stream.listener, ev.Data)
+		}
+	}
+}
+
+// resizeScreen applies the "COLSxROWS" data of a resize event to screen,
+// when the screen can be resized. Malformed sizes are ignored.
+func resizeScreen(screen Screen, data string) {
+	var columns, lines int
+	if _, err := fmt.Sscanf(data, "%dx%d", &columns, &lines); err != nil || columns <= 0 || lines <= 0 {
+		return
+	}
+	if r, ok := screen.(interface{ Resize(columns, lines int) }); ok {
+		r.Resize(columns, lines)
+	}
+}
+
+// directionCodes follows the asciinema event codes.
+var directionCodes = map[Direction]string{
+	DirOutput: "o",
+	DirInput:  "i",
+	DirResize: "r",
+}
+
+// WriteTo writes the transcript as one JSON array per line,
+// [seconds, "o"|"i"|"r", data], the same event encoding asciinema uses.
+func (t *Transcript) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	for _, ev := range t.Events() {
+		line, err := json.Marshal([]interface{}{ev.Time.Seconds(), directionCodes[ev.Direction], ev.Data})
+		if err != nil {
+			return written, err
+		}
+		n, err := w.Write(append(line, '\n'))
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// ReadTranscript parses a transcript written by WriteTo.
+func ReadTranscript(r io.Reader) (*Transcript, error) {
+	t := NewTranscript()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		ev, err := parseEventLine(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("transcript line %d: %w", lineNo, err)
+		}
+		t.events = append(t.events, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseEventLine decodes a single [seconds, code, data] event.
+func parseEventLine(line []byte) (TranscriptEvent, error) {
+	var raw []interface{}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return TranscriptEvent{}, err
+	}
+	if len(raw) != 3 {
+		return TranscriptEvent{}, fmt.Errorf("expected 3 fields, got %d", len(raw))
+	}
+	secs, ok1 := raw[0].(float64)
+	code, ok2 := raw[1].(string)
+	data, ok3 := raw[2].(string)
+	if !ok1 || !ok2 || !ok3 {
+		return TranscriptEvent{}, fmt.Errorf("malformed event %s", line)
+	}
+	ev := TranscriptEvent{Time: time.Duration(math.Round(secs * float64(time.Second))), Data: data}
+	switch code {
+	case "o":
+		ev.Direction = DirOutput
+	case "i":
+		ev.Direction = DirInput
+	case "r":
+		ev.Direction = DirResize
+	default:
+		return TranscriptEvent{}, fmt.Errorf("unknown event code %q", code)
+	}
+	return ev, nil
+}
+
+// SetTranscript records every chunk passed to Feed as output.
+// Pass nil to stop recording.
+func (s *Stream) SetTranscript(t *Transcript) {
+	s.transcript = t
+}
+
+// SetTranscript records everything the screen writes back to the process
+// through WriteProcessInput. Pass nil to stop recording.
+func (s *NativeScreen) SetTranscript(t *Transcript) {
+	s.transcript = t
+}
@@ -0,0 +1,146 @@
+package gopyte
+
+import "time"
+
+// Limits applied by DefaultWatchdogOptions.
+const (
+	DefaultMaxSequenceBytes = 1 << 20 // 1 MiB, room for OSC 52 clipboard data
+	DefaultMaxSequenceTime  = 30 * time.Second
+)
+
+// WatchdogOptions configures the parser watchdog.
+type WatchdogOptions struct {
+	MaxBytes int           // Longest escape sequence or string (0 = unlimited)
+	MaxTime  time.Duration // Longest a sequence may stay open (0 = unlimited)
+	Clock    Clock         // Time source (nil = SystemClock)
+}
+
+// DefaultWatchdogOptions returns limits generous enough for any legitimate
+// sequence.
+func DefaultWatchdogOptions() WatchdogOptions {
+	return WatchdogOptions{MaxBytes: DefaultMaxSequenceBytes, MaxTime: DefaultMaxSequenceTime}
+}
+
+// ParserAbort describes a sequence the watchdog gave up on.
+type ParserAbort struct {
+	State   ParserState   // Where the parser was stuck
+	Reason  string        // "size" or "time"
+	Bytes   int           // Bytes of the sequence seen
+	Elapsed time.Duration // Time since the sequence started
+}
+
+var parserStateNames = map[ParserState]string{
+	StateGround:  "ground",
+	StateEscape:  "escape",
+	StateCSI:     "csi",
+	StateOSC:     "osc",
+	StateCharset: "charset",
+	StateSharp:   "sharp",
+	StateDCS:     "dcs",
+	StateString:  "string",
+}
+
+func (st ParserState) String() string {
+	if name, ok := parserStateNames[st]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+type parserWatchdog struct {
+	opts    WatchdogOptions
+	onAbort func(ParserAbort)
+	start   time.Time
+	bytes   int
+}
+
+// SetWatchdog limits how long and how large an escape sequence may grow.
+// Without it a string left unterminated by a crashed remote - an OSC with
+// no BEL or ST - swallows all further output and grows without bound.
+// When a limit is hit the sequence is discarded, onAbort (which may be
+// nil) is called and parsing resumes in the ground state with the next
+// byte.
+//
+// The time limit is checked when data arrives: a Feed that finds the
+// current sequence open for longer than MaxTime abandons it before
+// parsing the new data. Pass a zero WatchdogOptions to remove the
+// watchdog.
+func (s *Stream) SetWatchdog(opts WatchdogOptions, onAbort func(ParserAbort)) {
+	if opts.MaxBytes <= 0 && opts.MaxTime <= 0 {
+		s.watchdog = nil
+		return
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+	s.watchdog = &parserWatchdog{opts: opts, onAbort: onAbort}
+	if s.state != StateGround {
+		s.watchdog.start = opts.Clock.Now()
+	}
+}
+
+// watchSequenceStart notes that a sequence has just been opened.
+func (s *Stream) watchSequenceStart() {
+	w := s.watchdog
+	w.bytes = 1
+	if w.opts.MaxTime > 0 {
+		w.start = w.opts.Clock.Now()
+	}
+}
+
+// watchByte counts one more byte of the open sequence and aborts it when
+// it has grown too large. It reports whether it aborted.
+func (s *Stream) watchByte() bool {
+	w := s.watchdog
+	w.bytes++
+	if w.opts.MaxBytes > 0 && w.bytes > w.opts.MaxBytes {
+		s.abortSequence("size")
+		return true
+	}
+	return false
+}
+
+// watchTime aborts the open sequence when it has been open too long. It
+// reports whether it aborted.
+func (s *Stream) watchTime() bool {
+	w := s.watchdog
+	if w.opts.MaxTime <= 0 || s.state == StateGround {
+		return false
+	}
+	if w.opts.Clock.Now().Sub(w.start) > w.opts.MaxTime {
+		s.abortSequence("time")
+		return true
+	}
+	return false
+}
+
+// abortSequence drops the open sequence and returns to the ground state.
+func (s *Stream) abortSequence(reason string) {
+	w := s.watchdog
+	abort := ParserAbort{State: s.state, Reason: reason, Bytes: w.bytes}
+	if w.opts.MaxTime > 0 {
+		abort.Elapsed = w.opts.Clock.Now().Sub(w.start)
+	}
+	if reason == "size" {
+		s.failSequence("sequence too long")
+	} else {
+		s.failSequence("sequence timed out")
+	}
+	s.state = StateGround
+	s.oscParam = s.oscParam[:0]
+	s.oscEscape = false
+	s.dcsData = s.dcsData[:0]
+	s.dcsEscape = false
+	s.strEscape = false
+	s.params = []int{}
+	s.currentParam = ""
+	s.subParams = nil
+	s.colon = false
+	s.intermediate = ""
+	s.overflow = overflowNone
+	s.tapName = ""
+	w.bytes = 0
+	if w.onAbort != nil {
+		w.onAbort(abort)
+	}
+}
@@ -2,22 +2,24 @@ package gopyte
 
 import (
 	// "container/list"
-	runewidth "github.com/mattn/go-runewidth"
+	"unicode/utf8"
 )
 
 // WideCharScreen adds wide character (CJK, emoji) support to AlternateScreen
 type WideCharScreen struct {
 	*AlternateScreen
 
-	// Track cell widths (0 = continuation, 1 = normal, 2 = wide start)
-	cellWidths     [][]int
+	// Cell widths of the buffer not in use; those in use are
+	// NativeScreen.cellWidths
 	altCellWidths  [][]int
 	mainCellWidths [][]int
+
+	width WidthFunc // nil for runewidth.RuneWidth
 }
 
 // NewWideCharScreen creates a screen with wide character support
-func NewWideCharScreen(columns, lines, maxHistory int) *WideCharScreen {
-	alt := NewAlternateScreen(columns, lines, maxHistory)
+func NewWideCharScreen(columns, lines, maxHistory int, opts ...ScreenOption) *WideCharScreen {
+	alt := NewAlternateScreen(columns, lines, maxHistory, opts...)
 
 	w := &WideCharScreen{
 		AlternateScreen: alt,
@@ -53,41 +55,106 @@ func (w *WideCharScreen) Draw(text string) {
 	if !w.usingAlternate && w.viewingHistory {
 		w.ScrollToBottom()
 	}
-
-	// Process each character with width awareness
-	for _, ch := range text {
+	w.noteRepaint()
+
+	// Runs of printable ASCII are one cell a character and are copied a
+	// line at a time; anything else needs its width looked up
+	for text != "" {
+		n := 0
+		for n < len(text) && text[n] >= ' ' && text[n] < 0x7f {
+			n++
+		}
+		if n > 0 {
+			w.drawASCII(text[:n])
+			text = text[n:]
+			continue
+		}
+		ch, size := utf8.DecodeRuneInString(text)
 		w.drawChar(ch)
+		text = text[size:]
 	}
 }
 
-// drawChar handles a single character with width calculation
+// drawASCII draws printable ASCII text as drawCell would, a character at
+// a time, but filling the cells up to the right margin in one go.
+func (w *WideCharScreen) drawASCII(text string) {
+	for text != "" {
+		if w.cursor.wrapPending || w.cursor.X > w.lineEnd() {
+			w.cursor.wrapPending = false
+			if !w.autoWrap {
+				text = text[1:]
+				continue
+			}
+			w.wrapLine()
+		}
+
+		y, x := w.cursor.Y, w.cursor.X
+		if y >= w.lines || x >= w.columns {
+			return
+		}
+		n := min(len(text), w.lineEnd()-x+1)
+		last := x + n - 1
+
+		// Wide characters overwritten in part are blanked whole
+		if w.cellWidths[y][x] == 0 {
+			w.clearCellAt(y, x)
+		}
+		if w.cellWidths[y][last] == 2 {
+			w.clearCellAt(y, last)
+		}
+
+		row, widths := w.buffer[y][x:last+1], w.cellWidths[y][x:last+1]
+		for i := range row {
+			row[i] = rune(text[i])
+			widths[i] = 1
+		}
+		fillAttrs(w.attrs[y][x:last+1], w.cursor.Attrs)
+		w.clearClusters(y, x, last)
+		w.markLinks(y, x, last)
+		w.advanceCursor(n)
+		text = text[n:]
+	}
+}
+
+// DrawCharset draws glyphs from a character set translation one cell each
+// (see CharsetDrawer).
+func (w *WideCharScreen) DrawCharset(text string) {
+	if !w.usingAlternate && w.viewingHistory {
+		w.ScrollToBottom()
+	}
+	w.noteRepaint()
+
+	for _, ch := range text {
+		w.drawCell(ch, 1)
+	}
+}
 
 // drawChar handles a single character with width calculation
 func (w *WideCharScreen) drawChar(ch rune) {
+	// Joiners, selectors and the like extend the last cluster
+	if w.joinCluster(ch) {
+		return
+	}
+
 	// Get the display width of the character
-	charWidth := runewidth.RuneWidth(ch)
+	charWidth := w.runeWidth(ch)
 
 	// Handle zero-width characters (combining marks, etc.)
 	if charWidth == 0 {
 		w.handleZeroWidth(ch)
 		return
 	}
+	w.drawCell(ch, charWidth)
+}
 
-	// Check if the character fits at current position
-	if w.cursor.X+charWidth > w.columns {
+// drawCell places ch, charWidth cells wide, at the cursor
+func (w *WideCharScreen) drawCell(ch rune, charWidth int) {
+	// Wrap if the previous character filled the last column or this one
+	// doesn't fit at the current position
+	if w.cursor.wrapPending || w.cursor.X+charWidth > w.lineEnd()+1 {
+		w.cursor.wrapPending = false
 		if w.autoWrap {
-			// Wide character doesn't fit, wrap to next line
-			w.cursor.X = 0
-			w.cursor.Y++
-			if w.cursor.Y >= w.lines {
-				if w.usingAlternate {
-					w.scrollUpNoHistory()
-				} else {
-					w.addToHistory(0)
-					w.scrollUpInternal()
-				}
-				w.cursor.Y = w.lines - 1
-			}
+			w.wrapLine()
 		} else {
 			// Can't place character at edge without wrapping
 			return
@@ -101,7 +168,9 @@ func (w *WideCharScreen) drawChar(ch rune) {
 
 		w.buffer[w.cursor.Y][w.cursor.X] = ch
 		w.attrs[w.cursor.Y][w.cursor.X] = w.cursor.Attrs
+		w.clearClusters(w.cursor.Y, w.cursor.X, w.cursor.X)
 		w.cellWidths[w.cursor.Y][w.cursor.X] = charWidth
+		w.markLink(w.cursor.Y, w.cursor.X)
 
 		if charWidth == 2 {
 			// Mark the next cell as continuation
@@ -109,45 +178,39 @@ func (w *WideCharScreen) drawChar(ch rune) {
 				w.buffer[w.cursor.Y][w.cursor.X+1] = 0 // Null char for continuation
 				w.attrs[w.cursor.Y][w.cursor.X+1] = w.cursor.Attrs
 				w.cellWidths[w.cursor.Y][w.cursor.X+1] = 0 // Continuation marker
+				w.markLink(w.cursor.Y, w.cursor.X+1)
 			}
 		}
 
-		w.cursor.X += charWidth
+		w.advanceCursor(charWidth)
 	}
 }
 
-// handleZeroWidth handles zero-width combining characters
-func (w *WideCharScreen) handleZeroWidth(ch rune) {
-	// Combining characters attach to the previous character
-	if w.cursor.X > 0 {
-		// Combine with previous character
-		prevX := w.cursor.X - 1
-		if w.cellWidths[w.cursor.Y][prevX] == 2 && prevX > 0 {
-			// Previous is a wide character, combine with its start
-			prevX--
-		}
-
-		// Append the combining character
-		existing := w.buffer[w.cursor.Y][prevX]
-		if existing != 0 && existing != ' ' {
-			// In a real implementation, we'd normalize the combination
-			// For now, we'll just store the base character
-			// A full implementation would need to handle Unicode normalization
-		}
-	} else if w.cursor.Y > 0 {
-		// Combine with last character of previous line
-		prevY := w.cursor.Y - 1
-		prevX := w.columns - 1
-
-		// Find the last actual character
-		for prevX >= 0 && w.cellWidths[prevY][prevX] == 0 {
-			prevX--
+// wrapLine moves the cursor to the start of the next line for autowrap,
+// scrolling at the bottom.
+func (w *WideCharScreen) wrapLine() {
+	w.setWrapped(w.cursor.Y, true)
+	w.cursor.X = w.lineStart()
+	w.cursor.Y++
+	if w.cursor.Y >= w.lines {
+		if w.usingAlternate {
+			w.scrollUpNoHistory()
+		} else {
+			w.scrollUpSaving()
 		}
+		w.cursor.Y = w.lines - 1
+	}
+}
 
-		if prevX >= 0 && w.buffer[prevY][prevX] != ' ' {
-			// Would combine here in full implementation
-		}
+// handleZeroWidth attaches a zero-width character, such as a combining
+// accent, to the cluster of the character before it. With nothing to
+// attach to, at the start of a line or after a blank, it is dropped.
+func (w *WideCharScreen) handleZeroWidth(ch rune) {
+	y, x, ok := w.prevCell()
+	if !ok {
+		return
 	}
+	clusterKey.SetCell(w.LineMeta(y), x, w.cluster(y, x)+string(ch))
 }
 
 // clearCellAt clears a cell, handling wide characters properly
@@ -168,6 +231,7 @@ func (w *WideCharScreen) clearCellAt(y, x int) {
 	w.buffer[y][x] = ' '
 	w.attrs[y][x] = DefaultAttributes()
 	w.cellWidths[y][x] = 1
+	w.clearClusters(y, x, x)
 
 	// If this was a wide character, clear its continuation
 	if width == 2 && x+1 < w.columns {
@@ -179,6 +243,7 @@ func (w *WideCharScreen) clearCellAt(y, x int) {
 
 // Override cursor movement to handle wide characters
 func (w *WideCharScreen) CursorBack(count int) {
+	w.cursor.wrapPending = false
 	for i := 0; i < count; i++ {
 		if w.cursor.X <= 0 {
 			break
@@ -197,6 +262,7 @@ func (w *WideCharScreen) CursorBack(count int) {
 }
 
 func (w *WideCharScreen) CursorForward(count int) {
+	w.cursor.wrapPending = false
 	for i := 0; i < count; i++ {
 		if w.cursor.X >= w.columns-1 {
 			break
@@ -228,6 +294,7 @@ func (w *WideCharScreen) EraseCharacters(count int) {
 			x++
 		}
 	}
+	w.clearCellMeta(w.cursor.Y, w.cursor.X, x-1)
 }
 
 // Override GetDisplay to handle wide characters properly
@@ -243,6 +310,7 @@ func (w *WideCharScreen) GetDisplay() []string {
 			ch := w.buffer[y][x]
 			if ch != 0 { // Don't include null characters
 				runes = append(runes, ch)
+				runes = append(runes, []rune(w.cluster(y, x))...)
 			}
 		}
 		lines[y] = string(runes)
@@ -250,27 +318,40 @@ func (w *WideCharScreen) GetDisplay() []string {
 	return lines
 }
 
-// Override switching to handle cell widths
-func (w *WideCharScreen) switchToAlternate() {
-	// Save main screen cell widths
-	w.mainCellWidths = w.cellWidths
-
-	// Call parent
-	w.AlternateScreen.switchToAlternate()
-
-	// Switch to alternate cell widths
-	w.cellWidths = w.altCellWidths
+// SetMode swaps the cell widths along with the buffers when a mode
+// switches to the alternate screen.
+func (w *WideCharScreen) SetMode(modes []int, private bool) {
+	defer w.holdEvents()()
+	was := w.usingAlternate
+	w.AlternateScreen.SetMode(modes, private)
+	w.syncCellWidths(was)
 }
 
-func (w *WideCharScreen) switchToMain() {
-	// Save alternate cell widths
-	w.altCellWidths = w.cellWidths
-
-	// Call parent
-	w.AlternateScreen.switchToMain()
+// ResetMode swaps the cell widths back when a mode returns to the main
+// screen.
+func (w *WideCharScreen) ResetMode(modes []int, private bool) {
+	defer w.holdEvents()()
+	was := w.usingAlternate
+	w.AlternateScreen.ResetMode(modes, private)
+	w.syncCellWidths(was)
+}
 
-	// Restore main cell widths
-	if w.mainCellWidths != nil {
+// syncCellWidths follows a buffer switch made by AlternateScreen, which
+// does not know about cell widths. wasAlternate is the state before.
+func (w *WideCharScreen) syncCellWidths(wasAlternate bool) {
+	switch {
+	case !wasAlternate && w.usingAlternate:
+		w.mainCellWidths = w.cellWidths
+		// The alternate buffer was cleared; so are its widths
+		w.altCellWidths = rebuildWidthGrid(w.altCellWidths, w.columns, w.lines)
+		for _, row := range w.altCellWidths {
+			for x := range row {
+				row[x] = 1
+			}
+		}
+		w.cellWidths = w.altCellWidths
+	case wasAlternate && !w.usingAlternate:
+		w.altCellWidths = w.cellWidths
 		w.cellWidths = w.mainCellWidths
 	}
 }
@@ -292,6 +373,7 @@ func (w *WideCharScreen) Resize(newCols, newLines int) {
 	if newCols <= 0 || newLines <= 0 {
 		return
 	}
+	defer w.holdEvents()()
 
 	// 1) Let the embedded screens resize buffers/attrs first.
 	w.AlternateScreen.Resize(newCols, newLines)
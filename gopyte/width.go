@@ -0,0 +1,54 @@
+package gopyte
+
+import runewidth "github.com/mattn/go-runewidth"
+
+// WidthFunc returns the number of cells a character takes: 0 for
+// combining marks and other zero-width characters, 1, or 2 for wide ones.
+type WidthFunc func(r rune) int
+
+// SetWidthFunc sets how WideCharScreen measures characters, so that its
+// layout matches the terminal on the far end of the connection: a program
+// moves the cursor by what its own wcwidth says, and a screen counting
+// differently draws the rest of the line in the wrong columns. nil
+// restores the default, go-runewidth's current tables with its locale
+// detection. Characters already on the screen keep their widths.
+func (w *WideCharScreen) SetWidthFunc(fn WidthFunc) {
+	w.width = fn
+}
+
+// runeWidth returns the width of r by the screen's WidthFunc.
+func (w *WideCharScreen) runeWidth(r rune) int {
+	if w.width != nil {
+		return w.width(r)
+	}
+	return runewidth.RuneWidth(r)
+}
+
+// UnicodeWidth returns a WidthFunc for the width tables of a Unicode
+// version. From version 9 on, emoji are two cells wide; before it, as in
+// older wcwidth implementations (glibc before 2.26, for one), they are one.
+// Characters added after the version are measured as in the current
+// tables. ambiguousWide makes the East Asian ambiguous characters, such as
+// Greek and box drawing, two cells wide, as CJK locales do.
+func UnicodeWidth(version int, ambiguousWide bool) WidthFunc {
+	cond := &runewidth.Condition{EastAsianWidth: ambiguousWide, StrictEmojiNeutral: true}
+	if version >= 9 {
+		return cond.RuneWidth
+	}
+	return func(r rune) int {
+		n := cond.RuneWidth(r)
+		if n == 2 && isUnicode9Wide(r) && !runewidth.IsAmbiguousWidth(r) {
+			return 1
+		}
+		return n
+	}
+}
+
+// isUnicode9Wide reports the blocks whose emoji Unicode 9 made wide. The
+// angle brackets at U+2329 and the enclosed ideographs at U+1F200 were
+// wide before.
+func isUnicode9Wide(r rune) bool {
+	return (r >= 0x2300 && r < 0x2c00 && r != 0x2329 && r != 0x232a) ||
+		(r >= 0x1f000 && r < 0x1f200) ||
+		(r >= 0x1f300 && r < 0x1fb00)
+}
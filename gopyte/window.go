@@ -0,0 +1,248 @@
+package gopyte
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// Window manipulation (XTWINOPS, CSI Ps ; Ps ; Ps t) and title modes
+// (XTSMTITLE/XTRMTITLE, CSI > Ps t / CSI > Ps T). gopyte has no window,
+// so it records what the program asked for and tells the embedding UI,
+// which can act on it or not; either way a full-screen program that
+// iconifies or maximizes the window and later asks for it back does not
+// leave the UI out of step.
+
+// Window operations, the first parameter of CSI t.
+const (
+	WinDeiconify    = 1
+	WinIconify      = 2
+	WinMove         = 3 // ; x ; y in pixels
+	WinResizePixels = 4 // ; height ; width
+	WinRaise        = 5
+	WinLower        = 6
+	WinRefresh      = 7
+	WinResizeCells  = 8  // ; lines ; columns
+	WinMaximize     = 9  // ; 0 restore, 1 maximize, 2 vertically, 3 horizontally
+	WinFullScreen   = 10 // ; 0 leave, 1 enter, 2 toggle
+)
+
+// Window reports and the title stack, also CSI t. Reports are answered
+// through Respond as ResponseWindowReport.
+const (
+	WinReportPixels      = 14 // Text area size in pixels: CSI 4 ; height ; width t
+	WinReportCellPixels  = 16 // Cell size in pixels: CSI 6 ; height ; width t
+	WinReportCells       = 18 // Text area size in cells: CSI 8 ; lines ; columns t
+	WinReportScreenCells = 19 // Screen size in cells: CSI 9 ; lines ; columns t
+	WinPushTitle         = 22 // ; 0 both, 1 icon name, 2 title
+	WinPopTitle          = 23 // ; as WinPushTitle
+)
+
+// maxTitleStack is how many titles CSI 22 t keeps, as in xterm. Pushing
+// more drops the oldest.
+const maxTitleStack = 10
+
+// Title modes, the parameters of CSI > t and CSI > T.
+const (
+	TitleSetHex    = 0 // Titles set by OSC are hex encoded
+	TitleQueryHex  = 1 // Title reports are hex encoded
+	TitleSetUTF8   = 2 // Titles set by OSC are UTF-8
+	TitleQueryUTF8 = 3 // Title reports are UTF-8
+)
+
+// WindowState is the window state requested by the program so far.
+type WindowState struct {
+	Iconified  bool
+	X, Y       int // Last position requested by CSI 3 t, in pixels
+	Maximized  int // 0 normal, else the CSI 9 t mode: 1 both, 2 vertical, 3 horizontal
+	FullScreen bool
+	TitleModes [4]bool // Indexed by TitleSetHex, TitleQueryHex, ...
+}
+
+// WindowEvent describes one window operation.
+type WindowEvent struct {
+	Op     int   // One of the Win constants
+	Params []int // The parameters after Op
+	State  WindowState
+}
+
+// WindowManager is implemented by screens that track window state. It is
+// optional: Stream ignores CSI t and the title mode sequences for screens
+// without it.
+type WindowManager interface {
+	WindowOp(params []int)
+	SetTitleModes(modes []int, set bool)
+}
+
+// SetWindowHandler registers a callback invoked for every window operation
+// with the state after it. Pass nil to remove it.
+func (s *NativeScreen) SetWindowHandler(fn func(WindowEvent)) {
+	s.onWindow = fn
+}
+
+// WindowState returns the window state requested by the program.
+func (s *NativeScreen) WindowState() WindowState {
+	return s.window
+}
+
+// SetResizeRequestHandler registers a callback invoked when the program
+// asks for a new size in cells (CSI 8 ; lines ; columns t). A 0 in the
+// request keeps the current size, so the callback always gets both. The
+// screen does not resize itself; the embedder decides, and resizes the
+// pty along with it. Pass nil to remove it.
+func (s *NativeScreen) SetResizeRequestHandler(fn func(lines, columns int)) {
+	s.onResize = fn
+}
+
+// SetCellPixelSize sets the size of a character cell in pixels, which the
+// pixel size reports (CSI 14 t, CSI 16 t) need. They go unanswered until
+// it is set.
+func (s *NativeScreen) SetCellPixelSize(width, height int) {
+	s.cellPixels = [2]int{width, height}
+}
+
+// WindowOp applies CSI t. Window operations (1-10) update the state and
+// are passed to the window handler; reports are answered and the title
+// stack is pushed or popped. Other values are ignored.
+func (s *NativeScreen) WindowOp(params []int) {
+	if len(params) == 0 {
+		return
+	}
+	op, args := params[0], params[1:]
+	arg := func(i int) int {
+		if i < len(args) {
+			return args[i]
+		}
+		return 0
+	}
+
+	switch op {
+	case WinReportPixels, WinReportCellPixels, WinReportCells, WinReportScreenCells:
+		s.windowReport(op)
+		return
+	case WinPushTitle:
+		s.pushTitle(arg(0))
+		return
+	case WinPopTitle:
+		s.popTitle(arg(0))
+		return
+	}
+	if op < WinDeiconify || op > WinFullScreen {
+		return
+	}
+
+	w := &s.window
+	switch op {
+	case WinDeiconify:
+		w.Iconified = false
+	case WinIconify:
+		w.Iconified = true
+	case WinMove:
+		w.X, w.Y = arg(0), arg(1)
+	case WinMaximize:
+		if m := arg(0); m >= 0 && m <= 3 {
+			w.Maximized = m
+		}
+	case WinFullScreen:
+		switch arg(0) {
+		case 0:
+			w.FullScreen = false
+		case 1:
+			w.FullScreen = true
+		case 2:
+			w.FullScreen = !w.FullScreen
+		}
+	}
+	if s.onWindow != nil {
+		s.onWindow(WindowEvent{Op: op, Params: append([]int(nil), args...), State: *w})
+	}
+	if op == WinResizeCells && s.onResize != nil {
+		lines, columns := arg(0), arg(1)
+		if lines <= 0 {
+			lines = s.lines
+		}
+		if columns <= 0 {
+			columns = s.columns
+		}
+		s.onResize(lines, columns)
+	}
+}
+
+// windowReport answers one of the size reports.
+func (s *NativeScreen) windowReport(op int) {
+	cw, ch := s.cellPixels[0], s.cellPixels[1]
+	var report string
+	switch op {
+	case WinReportPixels, WinReportCellPixels:
+		if cw <= 0 || ch <= 0 {
+			return
+		}
+		if op == WinReportPixels {
+			report = fmt.Sprintf("4;%d;%d", s.lines*ch, s.columns*cw)
+		} else {
+			report = fmt.Sprintf("6;%d;%d", ch, cw)
+		}
+	case WinReportCells:
+		report = fmt.Sprintf("8;%d;%d", s.lines, s.columns)
+	case WinReportScreenCells:
+		report = fmt.Sprintf("9;%d;%d", s.lines, s.columns)
+	}
+	s.Respond(ResponseWindowReport, CSI+report+"t")
+}
+
+// pushTitle saves the icon name (which 1), the title (which 2) or both
+// (which 0) on the title stack.
+func (s *NativeScreen) pushTitle(which int) {
+	push := func(stack []string, v string) []string {
+		if len(stack) == maxTitleStack {
+			stack = stack[1:]
+		}
+		return append(stack, v)
+	}
+	if which == 0 || which == 1 {
+		s.iconStack = push(s.iconStack, s.iconName)
+	}
+	if which == 0 || which == 2 {
+		s.titleStack = push(s.titleStack, s.title)
+	}
+}
+
+// popTitle restores what pushTitle saved. Popping an empty stack does
+// nothing.
+func (s *NativeScreen) popTitle(which int) {
+	if n := len(s.iconStack); n > 0 && (which == 0 || which == 1) {
+		name := s.iconStack[n-1]
+		s.iconStack = s.iconStack[:n-1]
+		s.setIconName(name)
+	}
+	if n := len(s.titleStack); n > 0 && (which == 0 || which == 2) {
+		title := s.titleStack[n-1]
+		s.titleStack = s.titleStack[:n-1]
+		s.setTitle(title)
+	}
+}
+
+// SetTitleModes sets (CSI > t) or resets (CSI > T) title modes. With no
+// modes every one is reset, xterm's default.
+func (s *NativeScreen) SetTitleModes(modes []int, set bool) {
+	if len(modes) == 0 {
+		s.window.TitleModes = [4]bool{}
+		return
+	}
+	for _, m := range modes {
+		if m >= 0 && m < len(s.window.TitleModes) {
+			s.window.TitleModes[m] = set
+		}
+	}
+}
+
+// decodeTitle undoes the hex encoding of titles in TitleSetHex mode.
+// Values that are not valid hex are taken as they are.
+func (s *NativeScreen) decodeTitle(title string) string {
+	if !s.window.TitleModes[TitleSetHex] {
+		return title
+	}
+	if b, err := hex.DecodeString(title); err == nil {
+		return string(b)
+	}
+	return title
+}
@@ -0,0 +1,117 @@
+package gopyte
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Double-click style selection: the word or URL under the pointer. Both
+// look across soft wraps, so a URL broken by the right edge comes out
+// whole, and return the cells to highlight along with the text.
+
+// DefaultWordSeparators are the characters besides white space that end
+// a word for WordAt. Dots, slashes, dashes and colons are word characters,
+// so paths, host names and addresses are taken whole.
+const DefaultWordSeparators = "()[]{}<>'\"`,;|"
+
+// DefaultURLPattern is what URLAt takes for a URL: a scheme and what
+// follows up to white space or a quote, not ending in punctuation.
+var DefaultURLPattern = regexp.MustCompile(`(?:https?|ftp|file|ssh|git)://[^\s<>"'` + "`" + `]*[^\s<>"'` + "`" + `.,;:!?)\]}]`)
+
+// SetWordSeparators sets the characters besides white space that end a
+// word. An empty seps restores DefaultWordSeparators.
+func (s *NativeScreen) SetWordSeparators(seps string) {
+	s.wordSeparators = seps
+}
+
+// SetURLPattern sets what URLAt matches. nil restores DefaultURLPattern.
+func (s *NativeScreen) SetURLPattern(re *regexp.Regexp) {
+	s.urlPattern = re
+}
+
+// WordAt returns the word holding cell (x, y). It is false on white
+// space, on a separator and outside the text.
+func (s *NativeScreen) WordAt(x, y int) (FindMatch, bool) {
+	return s.wordAt(x, y, nil)
+}
+
+// URLAt returns the URL holding cell (x, y), if any.
+func (s *NativeScreen) URLAt(x, y int) (FindMatch, bool) {
+	return s.urlAt(x, y, nil)
+}
+
+// WordAt returns the word holding cell (x, y), either half of a wide
+// character included.
+func (w *WideCharScreen) WordAt(x, y int) (FindMatch, bool) {
+	return w.wordAt(x, y, w.cellWidths)
+}
+
+// URLAt returns the URL holding cell (x, y), if any.
+func (w *WideCharScreen) URLAt(x, y int) (FindMatch, bool) {
+	return w.urlAt(x, y, w.cellWidths)
+}
+
+// SelectMatch selects the cells of m, as a frontend does with the word
+// under a double click.
+func (s *NativeScreen) SelectMatch(m FindMatch) {
+	if len(m.Spans) == 0 {
+		return
+	}
+	first, last := m.Spans[0], m.Spans[len(m.Spans)-1]
+	s.StartSelection(first.Left, first.Top)
+	s.ExtendSelection(last.Right, last.Bottom)
+}
+
+// lineAt returns the soft-wrapped line through row y and the index of
+// the rune in cell (x, y), or -1.
+func (s *NativeScreen) lineAt(x, y int, widths [][]int) (findLine, int) {
+	if y < 0 || y >= s.lines {
+		return findLine{}, -1
+	}
+	line := s.findLine(s.wrapStart(y), widths)
+	return line, line.runeIndex(x, y)
+}
+
+func (s *NativeScreen) wordAt(x, y int, widths [][]int) (FindMatch, bool) {
+	line, i := s.lineAt(x, y, widths)
+	if i < 0 {
+		return FindMatch{}, false
+	}
+	seps := s.wordSeparators
+	if seps == "" {
+		seps = DefaultWordSeparators
+	}
+	isSep := func(r rune) bool { return unicode.IsSpace(r) || strings.ContainsRune(seps, r) }
+
+	runes := []rune(line.text)
+	if isSep(runes[i]) {
+		return FindMatch{}, false
+	}
+	from, to := i, i+1
+	for from > 0 && !isSep(runes[from-1]) {
+		from--
+	}
+	for to < len(runes) && !isSep(runes[to]) {
+		to++
+	}
+	return line.match(from, to), true
+}
+
+func (s *NativeScreen) urlAt(x, y int, widths [][]int) (FindMatch, bool) {
+	line, i := s.lineAt(x, y, widths)
+	if i < 0 {
+		return FindMatch{}, false
+	}
+	re := s.urlPattern
+	if re == nil {
+		re = DefaultURLPattern
+	}
+	for _, m := range re.FindAllStringIndex(line.text, -1) {
+		from, to := line.runeAt[m[0]], line.runeAt[m[1]]
+		if from <= i && i < to {
+			return line.match(from, to), true
+		}
+	}
+	return FindMatch{}, false
+}
@@ -0,0 +1,93 @@
+package gopyte
+
+// Soft-wrap tracking.
+//
+// wrapped[y] is true when line y was continued onto line y+1 by auto-wrap
+// rather than ended by an explicit line break. The flags travel with their
+// lines when the screen scrolls, inserts or deletes lines, and are cleared
+// when a line is erased. The repainted flags of progress.go and the line
+// metadata of meta.go ride along in the same helpers.
+
+// IsWrapped reports whether line y soft-wraps onto the next line.
+func (s *NativeScreen) IsWrapped(y int) bool {
+	if y < 0 || y >= len(s.wrapped) {
+		return false
+	}
+	return s.wrapped[y]
+}
+
+// ensureWrapped keeps the flag slice in step with the screen height.
+func (s *NativeScreen) ensureWrapped() {
+	if len(s.wrapped) != s.lines {
+		w := make([]bool, s.lines)
+		copy(w, s.wrapped)
+		s.wrapped = w
+	}
+	if len(s.repainted) != s.lines {
+		r := make([]bool, s.lines)
+		copy(r, s.repainted)
+		s.repainted = r
+	}
+	if len(s.meta) != s.lines {
+		m := make([]*LineMeta, s.lines)
+		copy(m, s.meta)
+		s.meta = m
+	}
+}
+
+func (s *NativeScreen) setWrapped(y int, v bool) {
+	s.ensureWrapped()
+	if y >= 0 && y < s.lines {
+		s.wrapped[y] = v
+	}
+}
+
+// shiftWrappedUp moves the flags of lines top+1..bottom up by one and
+// clears the flag of the bottom line, mirroring a one-line scroll up.
+func (s *NativeScreen) shiftWrappedUp(top, bottom int) {
+	s.ensureWrapped()
+	if top < 0 || bottom >= s.lines || top > bottom {
+		return
+	}
+	copy(s.wrapped[top:bottom], s.wrapped[top+1:bottom+1])
+	s.wrapped[bottom] = false
+	copy(s.repainted[top:bottom], s.repainted[top+1:bottom+1])
+	s.repainted[bottom] = false
+	copy(s.meta[top:bottom], s.meta[top+1:bottom+1])
+	s.meta[bottom] = nil
+	if len(s.cellWidths) == s.lines {
+		copy(s.cellWidths[top:bottom], s.cellWidths[top+1:bottom+1])
+		s.blankWidthRows(bottom, bottom+1)
+	}
+}
+
+// shiftWrappedDown moves the flags of lines top..bottom-1 down by one and
+// clears the flag of the top line, mirroring a one-line scroll down.
+func (s *NativeScreen) shiftWrappedDown(top, bottom int) {
+	s.ensureWrapped()
+	if top < 0 || bottom >= s.lines || top > bottom {
+		return
+	}
+	copy(s.wrapped[top+1:bottom+1], s.wrapped[top:bottom])
+	s.wrapped[top] = false
+	copy(s.repainted[top+1:bottom+1], s.repainted[top:bottom])
+	s.repainted[top] = false
+	copy(s.meta[top+1:bottom+1], s.meta[top:bottom])
+	s.meta[top] = nil
+	if len(s.cellWidths) == s.lines {
+		copy(s.cellWidths[top+1:bottom+1], s.cellWidths[top:bottom])
+		s.blankWidthRows(top, top+1)
+	}
+}
+
+// clearWrapped clears the flags of lines top..bottom inclusive.
+func (s *NativeScreen) clearWrapped(top, bottom int) {
+	s.ensureWrapped()
+	for y := top; y <= bottom && y < s.lines; y++ {
+		if y >= 0 {
+			s.wrapped[y] = false
+			s.repainted[y] = false
+			s.meta[y] = nil
+		}
+	}
+}